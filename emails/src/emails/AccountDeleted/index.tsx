@@ -0,0 +1,66 @@
+import * as React from 'react';
+import {
+  Heading,
+  Hr,
+  Link,
+  Text,
+} from '@react-email/components';
+
+import EmailLayout from '../../components/EmailLayout';
+import EmailLogo from '../../components/EmailLogo';
+
+interface AccountDeletedProps {
+  baseUrl?: string;
+  firstName?: string;
+  lastName?: string;
+  supportEmail?: string;
+}
+
+export const AccountDeleted = ({
+  baseUrl = '{{ .BaseURL }}',
+  firstName = '{{ .FirstName }}',
+  lastName = '{{ .LastName }}',
+  supportEmail = '{{ .SupportEmail }}',
+}: AccountDeletedProps) => {
+  const previewText = 'Your monetr account has been deleted';
+  return (
+    <EmailLayout previewText={previewText}>
+      <EmailLogo baseUrl={ baseUrl } />
+      <Heading className='text-black text-2xl font-normal text-center p-0 my-8 mx-0'>
+        Your <strong>monetr</strong> account has been deleted
+      </Heading>
+      <Text className='text-black text-sm leading-6'>
+        Hello {firstName},
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        As requested, your monetr account and all of its data have been permanently deleted. Any connections to your
+        financial institutions have been removed and your subscription, if you had one, has been canceled.
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        Thank you for using monetr!
+      </Text>
+      <Hr className='border border-solid border-gray-200 my-6 mx-0 w-full' />
+      <Text className='text-gray-500 text-xs leading-6'>
+        This message was intended for{' '}
+        <span className='text-black'>{firstName} {lastName}</span>.
+        If you did not sign up for <strong>monetr</strong>, you can ignore this email. If you are concerned about
+        this communication please reach out to{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>.
+      </Text>
+    </EmailLayout>
+  );
+};
+
+AccountDeleted.PreviewProps = {
+  baseUrl: 'https://my.monetr.dev',
+  firstName: 'Elliot',
+  lastName: 'Courant',
+  supportEmail: 'support@monetr.local',
+} as AccountDeletedProps;
+
+export default AccountDeleted;
@@ -0,0 +1,73 @@
+import * as React from 'react';
+import {
+  Heading,
+  Hr,
+  Link,
+  Text,
+} from '@react-email/components';
+
+import EmailLayout from '../../components/EmailLayout';
+import EmailLogo from '../../components/EmailLogo';
+
+interface AccountDeletionCanceledProps {
+  baseUrl?: string;
+  firstName?: string;
+  lastName?: string;
+  supportEmail?: string;
+}
+
+export const AccountDeletionCanceled = ({
+  baseUrl = '{{ .BaseURL }}',
+  firstName = '{{ .FirstName }}',
+  lastName = '{{ .LastName }}',
+  supportEmail = '{{ .SupportEmail }}',
+}: AccountDeletionCanceledProps) => {
+  const previewText = 'Your monetr account will no longer be deleted';
+  return (
+    <EmailLayout previewText={previewText}>
+      <EmailLogo baseUrl={ baseUrl } />
+      <Heading className='text-black text-2xl font-normal text-center p-0 my-8 mx-0'>
+        Your <strong>monetr</strong> account will no longer be deleted
+      </Heading>
+      <Text className='text-black text-sm leading-6'>
+        Hello {firstName},
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        The scheduled deletion of your monetr account has been canceled. Your account and its data will be kept and
+        no further action is required on your part.
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        If you did not cancel the deletion of your account please reach out to us immediately via our support
+        email:{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>
+      </Text>
+      <Hr className='border border-solid border-gray-200 my-6 mx-0 w-full' />
+      <Text className='text-gray-500 text-xs leading-6'>
+        This message was intended for{' '}
+        <span className='text-black'>{firstName} {lastName}</span>.
+        If you did not sign up for <strong>monetr</strong>, you can ignore this email. If you are concerned about
+        this communication please reach out to{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>.
+      </Text>
+    </EmailLayout>
+  );
+};
+
+AccountDeletionCanceled.PreviewProps = {
+  baseUrl: 'https://my.monetr.dev',
+  firstName: 'Elliot',
+  lastName: 'Courant',
+  supportEmail: 'support@monetr.local',
+} as AccountDeletionCanceledProps;
+
+export default AccountDeletionCanceled;
@@ -0,0 +1,77 @@
+import * as React from 'react';
+import {
+  Heading,
+  Hr,
+  Link,
+  Text,
+} from '@react-email/components';
+
+import EmailLayout from '../../components/EmailLayout';
+import EmailLogo from '../../components/EmailLogo';
+
+interface AccountDeletionRequestedProps {
+  baseUrl?: string;
+  firstName?: string;
+  lastName?: string;
+  deletionDate?: string;
+  supportEmail?: string;
+}
+
+export const AccountDeletionRequested = ({
+  baseUrl = '{{ .BaseURL }}',
+  firstName = '{{ .FirstName }}',
+  lastName = '{{ .LastName }}',
+  deletionDate = '{{ .DeletionDate }}',
+  supportEmail = '{{ .SupportEmail }}',
+}: AccountDeletionRequestedProps) => {
+  const previewText = 'Your monetr account is scheduled to be deleted';
+  return (
+    <EmailLayout previewText={previewText}>
+      <EmailLogo baseUrl={ baseUrl } />
+      <Heading className='text-black text-2xl font-normal text-center p-0 my-8 mx-0'>
+        Your <strong>monetr</strong> account is scheduled to be deleted
+      </Heading>
+      <Text className='text-black text-sm leading-6'>
+        Hello {firstName},
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        We received a request to delete your monetr account. Your account and all of its data will be permanently
+        deleted on <strong>{ deletionDate }</strong>. Until then you can still sign in and cancel the deletion from
+        your account settings.
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        If you did not request for your account to be deleted please reach out to us immediately via our support
+        email:{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>
+      </Text>
+      <Hr className='border border-solid border-gray-200 my-6 mx-0 w-full' />
+      <Text className='text-gray-500 text-xs leading-6'>
+        This message was intended for{' '}
+        <span className='text-black'>{firstName} {lastName}</span>.
+        If you did not sign up for <strong>monetr</strong>, you can ignore this email. If you are concerned about
+        this communication please reach out to{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>.
+      </Text>
+    </EmailLayout>
+  );
+};
+
+AccountDeletionRequested.PreviewProps = {
+  baseUrl: 'https://my.monetr.dev',
+  firstName: 'Elliot',
+  lastName: 'Courant',
+  deletionDate: 'Monday October 15, 2024',
+  supportEmail: 'support@monetr.local',
+} as AccountDeletionRequestedProps;
+
+export default AccountDeletionRequested;
@@ -0,0 +1,81 @@
+import * as React from 'react';
+import {
+  Button,
+  Heading,
+  Hr,
+  Link,
+  Section,
+  Text,
+} from '@react-email/components';
+
+import EmailLayout from '../../components/EmailLayout';
+import EmailLogo from '../../components/EmailLogo';
+
+interface InvitationProps {
+  baseUrl?: string;
+  email?: string;
+  firstName?: string;
+  lastName?: string;
+  supportEmail?: string;
+  inviteLink?: string;
+}
+
+export const Invitation = ({
+  baseUrl = '{{ .BaseURL }}',
+  email = '{{ .Email }}',
+  firstName = '{{ .FirstName }}',
+  lastName = '{{ .LastName }}',
+  supportEmail = '{{ .SupportEmail }}',
+  inviteLink = '{{ .InviteURL }}',
+}: InvitationProps) => {
+  const previewText = `${firstName} invited you to join their budget on monetr`;
+  return (
+    <EmailLayout previewText={previewText}>
+      <EmailLogo baseUrl={ baseUrl } />
+      <Heading className='text-black text-2xl font-normal text-center p-0 my-8 mx-0'>
+        Join <strong>{firstName}</strong> on <strong>monetr</strong>
+      </Heading>
+      <Text className='text-black text-sm leading-6'>
+        Hello,
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        {firstName} {lastName} has invited you to join their budget on monetr. Once you accept the invitation you
+        will be able to see and manage the budget together.
+      </Text>
+      <Section className='text-center mt-9 mb-9'>
+        <Button
+          className='bg-purple-500 rounded-lg text-white text-sm font-semibold no-underline text-center'
+          href={inviteLink}
+        >
+          <Text className='text-sm text-white m-2'>
+            Accept invitation
+          </Text>
+        </Button>
+      </Section>
+      <Hr className='border border-solid border-gray-200 my-6 mx-0 w-full' />
+      <Text className='text-gray-500 text-xs leading-6'>
+        This message was intended for{' '}
+        <span className='text-black'>{email}</span>.
+        If you were not expecting this invitation, you can ignore this email. If you are concerned about this
+        communication please reach out to{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>.
+      </Text>
+    </EmailLayout>
+  );
+};
+
+Invitation.PreviewProps = {
+  baseUrl: 'https://my.monetr.dev',
+  email: 'partner@monetr.local',
+  firstName: 'Elliot',
+  lastName: 'Courant',
+  supportEmail: 'support@monetr.local',
+  inviteLink: 'https://monetr.local/test',
+} as InvitationProps;
+
+export default Invitation;
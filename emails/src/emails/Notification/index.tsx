@@ -0,0 +1,79 @@
+import * as React from 'react';
+import {
+  Button,
+  Heading,
+  Hr,
+  Link,
+  Section,
+  Text,
+} from '@react-email/components';
+
+import EmailLayout from '../../components/EmailLayout';
+import EmailLogo from '../../components/EmailLogo';
+
+interface NotificationProps {
+  baseUrl?: string;
+  firstName?: string;
+  lastName?: string;
+  title?: string;
+  message?: string;
+  supportEmail?: string;
+}
+
+export const Notification = ({
+  baseUrl = '{{ .BaseURL }}',
+  firstName = '{{ .FirstName }}',
+  lastName = '{{ .LastName }}',
+  title = '{{ .Title }}',
+  message = '{{ .Message }}',
+  supportEmail = '{{ .SupportEmail }}',
+}: NotificationProps) => {
+  return (
+    <EmailLayout previewText={title}>
+      <EmailLogo baseUrl={ baseUrl } />
+      <Heading className='text-black text-2xl font-normal text-center p-0 my-8 mx-0'>
+        {title}
+      </Heading>
+      <Text className='text-black text-sm leading-6'>
+        Hello {firstName},
+      </Text>
+      <Text className='text-black text-sm leading-6'>
+        {message}
+      </Text>
+      <Section className='text-center mt-9 mb-9'>
+        <Button
+          className='bg-purple-500 rounded-lg text-white text-sm font-semibold no-underline text-center'
+          href={baseUrl}
+        >
+          <Text className='text-sm text-white m-2'>
+            Open monetr
+          </Text>
+        </Button>
+      </Section>
+      <Hr className='border border-solid border-gray-200 my-6 mx-0 w-full' />
+      <Text className='text-gray-500 text-xs leading-6'>
+        This message was intended for{' '}
+        <span className='text-black'>{firstName} {lastName}</span>.
+        You are receiving this because of your notification preferences in <strong>monetr</strong>, you can change
+        them at any time from your settings. If you are concerned about this communication please reach out to{' '}
+        <Link
+          href={`mailto:${supportEmail}`}
+          className='text-blue-600 no-underline'
+        >
+          {supportEmail}
+        </Link>.
+      </Text>
+    </EmailLayout>
+  );
+};
+
+Notification.PreviewProps = {
+  baseUrl: 'https://my.monetr.dev',
+  firstName: 'Elliot',
+  lastName: 'Courant',
+  title: 'Rent was funded short',
+  message: 'There was not enough free-to-use to fully fund Rent, it is now behind.',
+  supportEmail: 'support@monetr.local',
+} as NotificationProps;
+
+export default Notification;
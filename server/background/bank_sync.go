@@ -0,0 +1,951 @@
+package background
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// bankSyncMaxPages limits how many pages of data will be retrieved from a
+	// provider in a single sync.
+	bankSyncMaxPages = 10
+)
+
+type (
+	// BankSyncProvider is implemented by each of the bank data providers that
+	// monetr can sync with. The provider is only responsible for retrieving data
+	// and for maintaining its own records, like PlaidTransaction or
+	// TellerTransaction. Creating, updating and removing monetr's transactions
+	// and updating bank account balances is handled by bankSync so that every
+	// provider behaves the same way.
+	BankSyncProvider interface {
+		// Source is recorded on every transaction created by the provider, it is
+		// also used as the name of the provider in logs.
+		Source() TransactionSource
+		// Setup is called once before anything is retrieved from the provider. It
+		// returns the bank accounts of the link that will be synced, keyed by the
+		// provider's ID for each account. If no bank accounts are returned then
+		// there is nothing to sync and the sync will stop.
+		Setup(ctx context.Context, link *Link, timezone *time.Location) (map[string]BankAccount, error)
+		// Cursor returns where the previous sync left off. Providers that do not
+		// have a cursor, or links that have never been synced, return nil.
+		Cursor(ctx context.Context) (*string, error)
+		// Fetch retrieves a single page of data from the provider starting at the
+		// provided cursor. If a nil page is returned without an error then the
+		// sync stops without the link being marked as synced, this is used when
+		// there was nothing new or when the link needs to be reconnected.
+		Fetch(ctx context.Context, cursor *string) (*BankSyncPage, error)
+		// GetTransactions returns the transactions in monetr for the bank account
+		// that have any of the provided IDs, including deleted transactions. The
+		// result is keyed by the provider's ID, a transaction that was pending is
+		// keyed by both its pending and its posted ID.
+		GetTransactions(ctx context.Context, bankAccount *BankAccount, ids []string) (map[string]Transaction, error)
+		// GetPendingTransactions returns the pending transactions in monetr for
+		// the bank account on or after the provided date, keyed by the provider's
+		// ID. It is only called for accounts that specify PendingSince.
+		GetPendingTransactions(ctx context.Context, bankAccount *BankAccount, since time.Time) (map[string]Transaction, error)
+		// CreateTransaction is called before a new transaction is stored. The
+		// provider should prepare its own record of the transaction and associate
+		// it with the provided transaction.
+		CreateTransaction(ctx context.Context, bankAccount *BankAccount, transaction *Transaction, input BankSyncTransaction) error
+		// UpdateTransaction is called for every transaction that already exists,
+		// before any changes from the input are made to it. Any changes the
+		// provider makes to the transaction must be returned so that the
+		// transaction is persisted.
+		UpdateTransaction(ctx context.Context, bankAccount *BankAccount, transaction *Transaction, input BankSyncTransaction) ([]SyncChange, error)
+		// RemoveTransaction is called after a transaction has been removed.
+		RemoveTransaction(ctx context.Context, transaction *Transaction) error
+		// Flush stores any records that were prepared by CreateTransaction or
+		// UpdateTransaction. It is called before the transactions of each page are
+		// stored.
+		Flush(ctx context.Context) error
+		// UpdateBankAccount is called for every account in a page after status and
+		// balance changes have been made to the bank account. The provider should
+		// update its own record of the account and return the changes, including
+		// any of its own, so that the bank account can be persisted.
+		UpdateBankAccount(ctx context.Context, bankAccount *BankAccount, input BankSyncAccount, changes []SyncChange) ([]SyncChange, error)
+		// Complete is called once the sync has finished successfully, the provider
+		// should update the status of its link.
+		Complete(ctx context.Context) error
+	}
+
+	// BankSyncPage is a single page of data retrieved from a provider.
+	BankSyncPage struct {
+		Accounts     []BankSyncAccount
+		Transactions []BankSyncTransaction
+		// Removed are the IDs of transactions that the provider has explicitly
+		// told us no longer exist.
+		Removed []string
+		// NextCursor is provided to the next call to fetch if HasMore is true.
+		NextCursor *string
+		HasMore    bool
+	}
+
+	BankSyncAccount struct {
+		// Id is the provider's ID for the account.
+		Id     string
+		Status BankAccountStatus
+		// Balances may be nil if the provider could not retrieve them, in which
+		// case the balances of the bank account are left alone.
+		Balances *BankSyncBalances
+		// PendingSince can be provided by providers that do not tell us when a
+		// pending transaction goes away. Any pending transaction in monetr on or
+		// after this date that is not part of the page will be removed.
+		PendingSince *time.Time
+		// Data is the provider's own representation of the account.
+		Data any
+	}
+
+	BankSyncBalances struct {
+		Available int64
+		Current   int64
+		// Limit is nil if the provider does not report a limit.
+		Limit *int64
+	}
+
+	BankSyncTransaction struct {
+		// Id is the provider's ID for the transaction.
+		Id string
+		// PendingId is the ID of the pending transaction that this transaction
+		// replaces, if the provider tells us.
+		PendingId *string
+		// AccountId is the provider's ID for the account of the transaction.
+		AccountId string
+		Amount    int64
+		// Date should already be in the account's timezone.
+		Date         time.Time
+		Name         string
+		OriginalName string
+		MerchantName string
+		Category     *string
+		Categories   []string
+		IsPending    bool
+		// Data is the provider's own representation of the transaction.
+		Data any
+	}
+
+	SyncChange struct {
+		Field string `json:"field"`
+		Old   any    `json:"old"`
+		New   any    `json:"new"`
+	}
+
+	SyncAction string
+)
+
+const (
+	CreateSyncAction SyncAction = "create"
+	UpdateSyncAction SyncAction = "update"
+	DeleteSyncAction SyncAction = "delete"
+)
+
+// bankSync reconciles the data retrieved from a BankSyncProvider with the
+// transactions and bank accounts in monetr for a single link.
+type bankSync struct {
+	log      *logrus.Entry
+	repo     repository.BaseRepository
+	clock    clock.Clock
+	enqueuer JobEnqueuer
+	provider BankSyncProvider
+	trigger  string
+
+	link         *Link
+	timezone     *time.Location
+	bankAccounts map[string]BankAccount
+	transactions map[ID[BankAccount]]map[string]Transaction
+	created      []Transaction
+	similarity   map[ID[BankAccount]]CalculateTransactionClustersArguments
+	actions      map[ID[Transaction]]SyncAction
+	rules        map[ID[BankAccount]]*transactionRuleProcessor
+}
+
+func newBankSync(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	clock clock.Clock,
+	enqueuer JobEnqueuer,
+	provider BankSyncProvider,
+	trigger string,
+) *bankSync {
+	return &bankSync{
+		log:      log,
+		repo:     repo,
+		clock:    clock,
+		enqueuer: enqueuer,
+		provider: provider,
+		trigger:  trigger,
+
+		timezone:     nil, // Is set by Run
+		bankAccounts: make(map[string]BankAccount),
+		transactions: make(map[ID[BankAccount]]map[string]Transaction),
+		created:      make([]Transaction, 0),
+		similarity:   make(map[ID[BankAccount]]CalculateTransactionClustersArguments),
+		actions:      make(map[ID[Transaction]]SyncAction),
+		rules:        make(map[ID[BankAccount]]*transactionRuleProcessor),
+	}
+}
+
+func (b *bankSync) Run(ctx context.Context, link *Link) error {
+	source := b.provider.Source()
+	log := b.log.WithContext(ctx)
+	b.link = link
+
+	account, err := b.repo.GetAccount(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account for job")
+		return err
+	}
+
+	b.timezone, err = account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Warn("failed to get account's time zone, defaulting to UTC")
+		b.timezone = time.UTC
+	}
+
+	b.bankAccounts, err = b.provider.Setup(ctx, link, b.timezone)
+	if err != nil {
+		return err
+	}
+
+	if len(b.bankAccounts) == 0 {
+		log.Warnf("no bank accounts for %s link", source)
+		crumbs.Debug(ctx, "No bank accounts setup for link", map[string]interface{}{
+			"source": source,
+		})
+		return nil
+	}
+
+	cursor, err := b.provider.Cursor(ctx)
+	if err != nil {
+		return err
+	}
+
+	for iter := 0; iter < bankSyncMaxPages; iter++ {
+		page, err := b.provider.Fetch(ctx, cursor)
+		if err != nil {
+			return err
+		}
+
+		// The provider has already handled why there is nothing to do.
+		if page == nil {
+			return nil
+		}
+
+		if err := b.syncPage(ctx, page); err != nil {
+			return err
+		}
+
+		if !page.HasMore {
+			break
+		}
+
+		cursor = page.NextCursor
+		log.WithField("iter", iter).Infof("there is more data to sync from %s, continuing", source)
+	}
+
+	if err = notifyLargeTransactions(ctx, b.repo, b.created); err != nil {
+		log.WithError(err).Error("failed to create large transaction notifications")
+		return err
+	}
+
+	// Then enqueue all of the bank accounts we touched to have their similar
+	// transactions recalculated.
+	for key := range b.similarity {
+		b.enqueuer.EnqueueJob(ctx, CalculateTransactionClusters, b.similarity[key])
+	}
+
+	if err := notifyNegativeFreeToUse(
+		ctx,
+		log,
+		b.repo,
+		util.Midnight(b.clock.Now(), b.timezone),
+		b.similarity,
+	); err != nil {
+		log.WithError(err).Error("failed to create free-to-use notifications")
+		return err
+	}
+
+	completed := SyncCompletedEvent{
+		LinkId:  link.LinkId,
+		Trigger: b.trigger,
+	}
+	for _, action := range b.actions {
+		switch action {
+		case CreateSyncAction:
+			completed.Created++
+		case UpdateSyncAction:
+			completed.Updated++
+		case DeleteSyncAction:
+			completed.Deleted++
+		}
+	}
+	if err := EmitWebhookEvent(
+		ctx,
+		log,
+		b.repo,
+		b.clock,
+		WebhookEventSyncCompleted,
+		completed,
+	); err != nil {
+		log.WithError(err).Error("failed to emit sync completed webhook event")
+		return err
+	}
+
+	return b.provider.Complete(ctx)
+}
+
+// syncPage creates, updates and removes transactions and updates the bank
+// accounts from a single page of data from the provider.
+func (b *bankSync) syncPage(ctx context.Context, page *BankSyncPage) error {
+	source := b.provider.Source()
+	log := b.log.WithContext(ctx)
+
+	log.WithField("count", len(page.Transactions)).Debugf("retrieved transactions from %s", source)
+	crumbs.Debug(ctx, "Retrieved transactions from provider.", map[string]interface{}{
+		"source": source,
+		"count":  len(page.Transactions),
+	})
+
+	if err := b.hydrateTransactions(ctx, page); err != nil {
+		return errors.Wrap(err, "failed to hydrate existing transaction data")
+	}
+
+	seen := make(map[ID[BankAccount]]map[string]struct{}, len(b.bankAccounts))
+	transactionsToUpdate := make([]*Transaction, 0)
+	transactionsToInsert := make([]Transaction, 0)
+	for i := range page.Transactions {
+		input := page.Transactions[i]
+		bankAccount, ok := b.bankAccounts[input.AccountId]
+		if !ok {
+			log.WithFields(logrus.Fields{
+				"providerId":            input.Id,
+				"providerBankAccountId": input.AccountId,
+			}).Warnf("bank account for %s transaction does not exist, it will be skipped", source)
+			continue
+		}
+
+		if _, ok := seen[bankAccount.BankAccountId]; !ok {
+			seen[bankAccount.BankAccountId] = map[string]struct{}{}
+		}
+		// Providers should never return the same transaction twice, but if one
+		// did then it would be created twice.
+		if _, ok := seen[bankAccount.BankAccountId][input.Id]; ok {
+			continue
+		}
+		seen[bankAccount.BankAccountId][input.Id] = struct{}{}
+
+		created, updated, err := b.syncTransaction(ctx, &bankAccount, input)
+		if err != nil {
+			return errors.Wrap(err, "failed to sync transaction")
+		}
+
+		if created != nil {
+			rules, err := b.getTransactionRules(ctx, bankAccount.BankAccountId)
+			if err != nil {
+				return err
+			}
+			if err := rules.Process(ctx, created); err != nil {
+				return err
+			}
+			transactionsToInsert = append(transactionsToInsert, *created)
+			b.tagBankAccountForSimilarityRecalc(bankAccount.BankAccountId)
+		} else if updated != nil {
+			transactionsToUpdate = append(transactionsToUpdate, updated)
+			b.tagBankAccountForSimilarityRecalc(bankAccount.BankAccountId)
+		}
+	}
+
+	// The provider's records need to exist before the transactions that
+	// reference them.
+	if err := b.provider.Flush(ctx); err != nil {
+		log.WithError(err).Errorf("failed to create %s transactions for job", source)
+		return err
+	}
+
+	if len(transactionsToUpdate) > 0 {
+		log.Infof("updating %d transactions", len(transactionsToUpdate))
+		crumbs.Debug(ctx, "Updating transactions.", map[string]interface{}{
+			"count": len(transactionsToUpdate),
+		})
+		if err := b.repo.UpdateTransactions(ctx, transactionsToUpdate); err != nil {
+			log.WithError(err).Errorf("failed to update transactions for job")
+			return err
+		}
+		for i := range transactionsToUpdate {
+			b.actions[transactionsToUpdate[i].TransactionId] = UpdateSyncAction
+		}
+		if err := emitTransactionWebhookEvents(
+			ctx,
+			log,
+			b.repo,
+			b.clock,
+			WebhookEventTransactionUpdated,
+			transactionsToUpdate,
+		); err != nil {
+			log.WithError(err).Error("failed to emit transaction updated webhook events")
+			return err
+		}
+	}
+
+	if len(transactionsToInsert) > 0 {
+		// Sort by oldest to newest
+		sort.Slice(transactionsToInsert, func(i, j int) bool {
+			return transactionsToInsert[i].Date.Before(transactionsToInsert[j].Date)
+		})
+
+		log.Infof("creating %d transactions", len(transactionsToInsert))
+		crumbs.Debug(ctx, "Creating transactions.", map[string]interface{}{
+			"count": len(transactionsToInsert),
+		})
+		if err := b.repo.InsertTransactions(ctx, transactionsToInsert); err != nil {
+			log.WithError(err).Error("failed to insert new transactions")
+			return err
+		}
+		for _, rules := range b.rules {
+			if err := rules.Flush(ctx); err != nil {
+				log.WithError(err).Error("failed to update spending from transaction rules")
+				return err
+			}
+		}
+		for i := range transactionsToInsert {
+			b.actions[transactionsToInsert[i].TransactionId] = CreateSyncAction
+		}
+		if err := emitTransactionWebhookEvents(
+			ctx,
+			log,
+			b.repo,
+			b.clock,
+			WebhookEventTransactionCreated,
+			transactionsToInsert,
+		); err != nil {
+			log.WithError(err).Error("failed to emit transaction created webhook events")
+			return err
+		}
+		b.created = append(b.created, transactionsToInsert...)
+	}
+
+	// Handle transactions the provider told us were removed.
+	for _, id := range page.Removed {
+		existingTransaction, exists := b.lookupRemovedTransaction(id)
+		if !exists {
+			log.WithField("providerId", id).Warnf("%s wants to remove a transaction that does not exist", source)
+			continue
+		}
+
+		if err := b.syncRemovedTransaction(ctx, existingTransaction); err != nil {
+			return errors.Wrap(err, "failed to sync removed transaction")
+		}
+	}
+
+	for _, item := range page.Accounts {
+		bankAccount, ok := b.bankAccounts[item.Id]
+		if !ok {
+			log.WithField("providerBankAccountId", item.Id).Warn("bank was not found in map")
+			continue
+		}
+
+		// Some providers do not tell us when a pending transaction goes away, when
+		// it posts it may be given a new ID. So any pending transaction we have in
+		// the window that was just retrieved that the provider did not return is
+		// removed.
+		if item.PendingSince != nil {
+			if err := b.syncMissingPendingTransactions(
+				ctx,
+				&bankAccount,
+				*item.PendingSince,
+				seen[bankAccount.BankAccountId],
+			); err != nil {
+				return err
+			}
+		}
+
+		if err := b.syncBankAccount(ctx, &bankAccount, item); err != nil {
+			log.WithError(err).Error("failed to update bank account")
+			crumbs.ReportError(ctx, err, "Failed to update bank account", "job", nil)
+		}
+		b.bankAccounts[item.Id] = bankAccount
+	}
+
+	return nil
+}
+
+// hydrateTransactions retrieves the transactions that already exist in monetr
+// for everything in the page. This way when we are processing the transactions
+// we can calculate differences between the transactions retrieved and the ones
+// we have stored.
+func (b *bankSync) hydrateTransactions(ctx context.Context, page *BankSyncPage) error {
+	ids := make(map[string][]string, len(b.bankAccounts))
+	for _, transaction := range page.Transactions {
+		ids[transaction.AccountId] = append(ids[transaction.AccountId], transaction.Id)
+		if transaction.PendingId != nil {
+			ids[transaction.AccountId] = append(ids[transaction.AccountId], *transaction.PendingId)
+		}
+	}
+	// We don't know which account removed transactions belong to, so look for
+	// them in all of them.
+	if len(page.Removed) > 0 {
+		for providerId := range b.bankAccounts {
+			ids[providerId] = append(ids[providerId], page.Removed...)
+		}
+	}
+
+	b.transactions = make(map[ID[BankAccount]]map[string]Transaction, len(ids))
+	for providerId, items := range ids {
+		bankAccount, ok := b.bankAccounts[providerId]
+		if !ok || len(items) == 0 {
+			continue
+		}
+
+		b.log.
+			WithContext(ctx).
+			WithField("bankAccountId", bankAccount.BankAccountId).
+			Tracef("checking database for %d %s transaction(s)", len(items), b.provider.Source())
+
+		existing, err := b.provider.GetTransactions(ctx, &bankAccount, items)
+		if err != nil {
+			return err
+		}
+		b.transactions[bankAccount.BankAccountId] = existing
+	}
+
+	return nil
+}
+
+func (b *bankSync) lookupTransaction(
+	bankAccountId ID[BankAccount],
+	id string,
+	pendingId *string,
+) (Transaction, bool) {
+	txn, ok := b.transactions[bankAccountId][id]
+	if ok {
+		return txn, ok
+	}
+	if pendingId != nil {
+		txn, ok = b.transactions[bankAccountId][*pendingId]
+		return txn, ok
+	}
+
+	return Transaction{}, false
+}
+
+func (b *bankSync) lookupRemovedTransaction(id string) (Transaction, bool) {
+	for _, transactions := range b.transactions {
+		if txn, ok := transactions[id]; ok {
+			return txn, ok
+		}
+	}
+
+	return Transaction{}, false
+}
+
+func (b *bankSync) tagBankAccountForSimilarityRecalc(bankAccountId ID[BankAccount]) {
+	b.similarity[bankAccountId] = CalculateTransactionClustersArguments{
+		AccountId:     b.link.AccountId,
+		BankAccountId: bankAccountId,
+	}
+}
+
+// getTransactionRules returns the transaction rule processor for the specified
+// bank account, the rules are only retrieved the first time they are needed for
+// each bank account.
+func (b *bankSync) getTransactionRules(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) (*transactionRuleProcessor, error) {
+	if rules, ok := b.rules[bankAccountId]; ok {
+		return rules, nil
+	}
+
+	rules, err := newTransactionRuleProcessor(ctx, b.log, b.repo, bankAccountId)
+	if err != nil {
+		return nil, err
+	}
+	b.rules[bankAccountId] = rules
+
+	return rules, nil
+}
+
+// syncTransaction returns the transaction that needs to be created if we have
+// not seen the input before, or the existing transaction if it has changed.
+// If nothing has changed then both will be nil.
+func (b *bankSync) syncTransaction(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	input BankSyncTransaction,
+) (created, updated *Transaction, err error) {
+	existingTransaction, exists := b.lookupTransaction(
+		bankAccount.BankAccountId,
+		input.Id,
+		input.PendingId,
+	)
+
+	// If there is not a monetr transaction for this input then we simply need to
+	// create one, the provider will create its own record alongside it.
+	if !exists {
+		transaction := Transaction{
+			TransactionId:        NewID(&Transaction{}),
+			AccountId:            bankAccount.AccountId,
+			BankAccountId:        bankAccount.BankAccountId,
+			Amount:               input.Amount,
+			SpendingId:           nil,
+			SpendingAmount:       nil,
+			Categories:           input.Categories,
+			Category:             input.Category,
+			Date:                 input.Date,
+			Name:                 input.Name,
+			OriginalName:         input.OriginalName,
+			MerchantName:         input.MerchantName,
+			OriginalMerchantName: input.MerchantName,
+			IsPending:            input.IsPending,
+			Source:               b.provider.Source(),
+		}
+		if err := b.provider.CreateTransaction(ctx, bankAccount, &transaction, input); err != nil {
+			return nil, nil, err
+		}
+
+		return &transaction, nil, nil
+	}
+
+	changes, err := b.provider.UpdateTransaction(ctx, bankAccount, &existingTransaction, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// When a transaction clears the name it had while it was pending is often a
+	// placeholder, so the name is replaced with the cleared one. Otherwise the
+	// name is left alone since the user may have changed it. See
+	// https://github.com/monetr/monetr/issues/1714 for more information.
+	clearing := existingTransaction.IsPending && !input.IsPending
+
+	if input.Amount != existingTransaction.Amount {
+		changes = append(changes, SyncChange{
+			Field: "amount",
+			Old:   existingTransaction.Amount,
+			New:   input.Amount,
+		})
+		if err := b.syncTransactionSplits(ctx, &existingTransaction, input.Amount); err != nil {
+			return nil, nil, err
+		}
+		existingTransaction.Amount = input.Amount
+	}
+
+	if !myownsanity.StringPEqual(input.Category, existingTransaction.Category) {
+		changes = append(changes, SyncChange{
+			Field: "category",
+			Old:   existingTransaction.Category,
+			New:   input.Category,
+		})
+		existingTransaction.Category = input.Category
+	}
+
+	if !input.Date.Equal(existingTransaction.Date) {
+		changes = append(changes, SyncChange{
+			Field: "date",
+			Old:   existingTransaction.Date,
+			New:   input.Date,
+		})
+		existingTransaction.Date = input.Date
+	}
+
+	if clearing && input.Name != existingTransaction.Name {
+		changes = append(changes, SyncChange{
+			Field: "name",
+			Old:   existingTransaction.Name,
+			New:   input.Name,
+		})
+		existingTransaction.Name = input.Name
+	}
+
+	if input.OriginalName != existingTransaction.OriginalName {
+		changes = append(changes, SyncChange{
+			Field: "originalName",
+			Old:   existingTransaction.OriginalName,
+			New:   input.OriginalName,
+		})
+		existingTransaction.OriginalName = input.OriginalName
+	}
+
+	if clearing && input.MerchantName != existingTransaction.MerchantName {
+		changes = append(changes, SyncChange{
+			Field: "merchantName",
+			Old:   existingTransaction.MerchantName,
+			New:   input.MerchantName,
+		})
+		existingTransaction.MerchantName = input.MerchantName
+	}
+
+	if input.MerchantName != existingTransaction.OriginalMerchantName {
+		changes = append(changes, SyncChange{
+			Field: "originalMerchantName",
+			Old:   existingTransaction.OriginalMerchantName,
+			New:   input.MerchantName,
+		})
+		existingTransaction.OriginalMerchantName = input.MerchantName
+	}
+
+	if input.IsPending != existingTransaction.IsPending {
+		changes = append(changes, SyncChange{
+			Field: "isPending",
+			Old:   existingTransaction.IsPending,
+			New:   input.IsPending,
+		})
+		existingTransaction.IsPending = input.IsPending
+	}
+
+	// This happens when a pending transaction was removed before the cleared
+	// transaction became visible. When the cleared transaction shows up later
+	// the transaction needs to be restored.
+	if existingTransaction.DeletedAt != nil {
+		changes = append(changes, SyncChange{
+			Field: "deletedAt",
+			Old:   existingTransaction.DeletedAt,
+			New:   nil,
+		})
+		existingTransaction.DeletedAt = nil
+	}
+
+	// If any of the fields did change, log the changes and return the updated
+	// transaction object.
+	if len(changes) > 0 {
+		b.log.WithContext(ctx).WithFields(logrus.Fields{
+			"providerId": input.Id,
+			"kind":       "transaction",
+			"changes":    changes,
+		}).Debugf("detected transaction updates from %s", b.provider.Source())
+		return nil, &existingTransaction, nil
+	}
+
+	return nil, nil, nil
+}
+
+// syncTransactionSplits keeps the splits of a transaction in line with its
+// amount when the amount changes, which usually happens when a pending
+// transaction posts. The splits are scaled to the new amount, or removed if they
+// cannot be, and whatever was spent for the old splits is returned to the
+// spending objects before the new splits are spent from them.
+func (b *bankSync) syncTransactionSplits(
+	ctx context.Context,
+	existingTransaction *Transaction,
+	amount int64,
+) error {
+	splits, err := b.repo.GetTransactionSplits(
+		ctx,
+		existingTransaction.BankAccountId,
+		existingTransaction.TransactionId,
+	)
+	if err != nil {
+		return err
+	}
+	if len(splits) == 0 {
+		return nil
+	}
+
+	previousTransaction := *existingTransaction
+	previousTransaction.Splits = splits
+
+	updatedTransaction := *existingTransaction
+	updatedTransaction.Amount = amount
+	updatedTransaction.Splits = ScaleTransactionSplits(splits, amount)
+
+	b.log.WithContext(ctx).WithFields(logrus.Fields{
+		"kind":          "transaction",
+		"bankAccountId": existingTransaction.BankAccountId,
+		"transactionId": existingTransaction.TransactionId,
+		"splits":        len(updatedTransaction.Splits),
+	}).Debug("transaction amount changed, updating its splits")
+
+	if _, err := b.repo.ProcessTransactionSpentFrom(
+		ctx,
+		existingTransaction.BankAccountId,
+		&updatedTransaction,
+		&previousTransaction,
+	); err != nil {
+		return errors.Wrap(err, "failed to update splits for transaction")
+	}
+
+	existingTransaction.Splits = updatedTransaction.Splits
+	existingTransaction.SpendingId = nil
+	existingTransaction.SpendingAmount = nil
+
+	return nil
+}
+
+// syncMissingPendingTransactions removes any pending transactions for the bank
+// account on or after the since date that were not seen in this page.
+func (b *bankSync) syncMissingPendingTransactions(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	since time.Time,
+	seen map[string]struct{},
+) error {
+	pending, err := b.provider.GetPendingTransactions(ctx, bankAccount, since.UTC())
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(pending))
+	for id := range pending {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := b.syncRemovedTransaction(ctx, pending[id]); err != nil {
+			return errors.Wrap(err, "failed to sync removed transaction")
+		}
+	}
+
+	return nil
+}
+
+func (b *bankSync) syncRemovedTransaction(
+	ctx context.Context,
+	existingTransaction Transaction,
+) error {
+	log := b.log.WithContext(ctx).WithFields(logrus.Fields{
+		"kind":          "transaction",
+		"bankAccountId": existingTransaction.BankAccountId,
+		"transactionId": existingTransaction.TransactionId,
+	})
+
+	action := b.actions[existingTransaction.TransactionId]
+	switch action {
+	case CreateSyncAction, UpdateSyncAction:
+		// If a transaction was updated or created as part of this sync then that
+		// means the transaction we are deleting was likely a pending transaction
+		// and the cleared transaction has become available and was properly
+		// associated with the pending transaction. As such we should not remove
+		// the transaction since it should have the correct status now.
+		log.WithField("action", action).Debug("transaction to be removed has also been created or updated in this sync, it will not be removed")
+		return nil
+	case DeleteSyncAction:
+		return nil
+	}
+
+	b.tagBankAccountForSimilarityRecalc(existingTransaction.BankAccountId)
+
+	log.Debug("removing transaction")
+
+	splits, err := b.repo.GetTransactionSplits(
+		ctx,
+		existingTransaction.BankAccountId,
+		existingTransaction.TransactionId,
+	)
+	if err != nil {
+		return err
+	}
+	existingTransaction.Splits = splits
+
+	if existingTransaction.SpendingId != nil || len(existingTransaction.Splits) > 0 {
+		log.WithField("spendingId", existingTransaction.SpendingId).
+			Debug("transaction has spending, it will be removed")
+		updatedTransaction := existingTransaction
+		updatedTransaction.SpendingId = nil
+		updatedTransaction.Splits = nil
+		_, err := b.repo.ProcessTransactionSpentFrom(
+			ctx,
+			existingTransaction.BankAccountId,
+			&updatedTransaction,
+			&existingTransaction,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Safe to remove this transaction
+	if err := b.repo.DeleteTransaction(
+		ctx,
+		existingTransaction.BankAccountId,
+		existingTransaction.TransactionId,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove pending transaction")
+	}
+
+	if err := b.provider.RemoveTransaction(ctx, &existingTransaction); err != nil {
+		return err
+	}
+
+	b.actions[existingTransaction.TransactionId] = DeleteSyncAction
+
+	return nil
+}
+
+func (b *bankSync) syncBankAccount(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	input BankSyncAccount,
+) error {
+	changes := make([]SyncChange, 0)
+
+	if input.Status != "" && input.Status != bankAccount.Status {
+		changes = append(changes, SyncChange{
+			Field: "status",
+			Old:   bankAccount.Status,
+			New:   input.Status,
+		})
+		bankAccount.Status = input.Status
+	}
+
+	if balances := input.Balances; balances != nil {
+		if balances.Available != bankAccount.AvailableBalance {
+			changes = append(changes, SyncChange{
+				Field: "availableBalance",
+				Old:   bankAccount.AvailableBalance,
+				New:   balances.Available,
+			})
+			bankAccount.AvailableBalance = balances.Available
+		}
+
+		if balances.Current != bankAccount.CurrentBalance {
+			changes = append(changes, SyncChange{
+				Field: "currentBalance",
+				Old:   bankAccount.CurrentBalance,
+				New:   balances.Current,
+			})
+			bankAccount.CurrentBalance = balances.Current
+		}
+
+		if balances.Limit != nil && *balances.Limit != bankAccount.LimitBalance {
+			changes = append(changes, SyncChange{
+				Field: "limitBalance",
+				Old:   bankAccount.LimitBalance,
+				New:   *balances.Limit,
+			})
+			bankAccount.LimitBalance = *balances.Limit
+		}
+	}
+
+	changes, err := b.provider.UpdateBankAccount(ctx, bankAccount, input, changes)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		bankAccount.LastUpdated = b.clock.Now().UTC()
+		b.log.WithContext(ctx).WithFields(logrus.Fields{
+			"providerId": input.Id,
+			"kind":       "bankAccount",
+			"changes":    changes,
+		}).Debugf("detected bank account updates from %s", b.provider.Source())
+
+		if err := b.repo.UpdateBankAccount(ctx, bankAccount); err != nil {
+			return errors.Wrapf(err, "failed to persists bank account changes from %s sync", b.provider.Source())
+		}
+	}
+
+	return nil
+}
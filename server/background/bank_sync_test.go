@@ -0,0 +1,441 @@
+package background
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	_ BankSyncProvider = &testBankSyncProvider{}
+)
+
+// testBankSyncProvider is a provider that returns pages from memory. It uses
+// the upload identifier of transactions as the provider's ID so that it does
+// not need any records of its own.
+type testBankSyncProvider struct {
+	repo        repository.BaseRepository
+	bankAccount BankAccount
+	pages       []*BankSyncPage
+	cursors     []*string
+	completed   bool
+}
+
+func (p *testBankSyncProvider) Source() TransactionSource {
+	return TransactionSourceUpload
+}
+
+func (p *testBankSyncProvider) Setup(ctx context.Context, link *Link, timezone *time.Location) (map[string]BankAccount, error) {
+	return map[string]BankAccount{
+		"account": p.bankAccount,
+	}, nil
+}
+
+func (p *testBankSyncProvider) Cursor(ctx context.Context) (*string, error) {
+	return nil, nil
+}
+
+func (p *testBankSyncProvider) Fetch(ctx context.Context, cursor *string) (*BankSyncPage, error) {
+	p.cursors = append(p.cursors, cursor)
+	if len(p.pages) == 0 {
+		return nil, nil
+	}
+	page := p.pages[0]
+	p.pages = p.pages[1:]
+	return page, nil
+}
+
+func (p *testBankSyncProvider) GetTransactions(ctx context.Context, bankAccount *BankAccount, ids []string) (map[string]Transaction, error) {
+	return p.repo.GetTransactonsByUploadIdentifier(ctx, bankAccount.BankAccountId, ids)
+}
+
+func (p *testBankSyncProvider) GetPendingTransactions(ctx context.Context, bankAccount *BankAccount, since time.Time) (map[string]Transaction, error) {
+	return map[string]Transaction{}, nil
+}
+
+func (p *testBankSyncProvider) CreateTransaction(ctx context.Context, bankAccount *BankAccount, transaction *Transaction, input BankSyncTransaction) error {
+	transaction.UploadIdentifier = myownsanity.StringP(input.Id)
+	return nil
+}
+
+func (p *testBankSyncProvider) UpdateTransaction(ctx context.Context, bankAccount *BankAccount, transaction *Transaction, input BankSyncTransaction) ([]SyncChange, error) {
+	if myownsanity.StringPEqual(transaction.UploadIdentifier, &input.Id) {
+		return nil, nil
+	}
+
+	change := SyncChange{
+		Field: "uploadIdentifier",
+		Old:   transaction.UploadIdentifier,
+		New:   input.Id,
+	}
+	transaction.UploadIdentifier = myownsanity.StringP(input.Id)
+	return []SyncChange{change}, nil
+}
+
+func (p *testBankSyncProvider) RemoveTransaction(ctx context.Context, transaction *Transaction) error {
+	return nil
+}
+
+func (p *testBankSyncProvider) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (p *testBankSyncProvider) UpdateBankAccount(ctx context.Context, bankAccount *BankAccount, input BankSyncAccount, changes []SyncChange) ([]SyncChange, error) {
+	return changes, nil
+}
+
+func (p *testBankSyncProvider) Complete(ctx context.Context) error {
+	p.completed = true
+	return nil
+}
+
+func TestBankSync_Run(t *testing.T) {
+	t.Run("pending transaction clears", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC))
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(
+			t,
+			clock,
+			&link,
+			DepositoryBankAccountType,
+			CheckingBankAccountSubType,
+		)
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Eq(CalculateTransactionClusters), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		date := time.Date(2024, 03, 9, 0, 0, 0, 0, time.UTC)
+		provider := &testBankSyncProvider{
+			repo:        repo,
+			bankAccount: bankAccount,
+			pages: []*BankSyncPage{
+				{
+					Transactions: []BankSyncTransaction{
+						{
+							Id:           "pending",
+							AccountId:    "account",
+							Amount:       1250,
+							Date:         date,
+							Name:         "ACME PENDING",
+							OriginalName: "ACME PENDING",
+							IsPending:    true,
+						},
+					},
+				},
+			},
+		}
+
+		{ // First sync creates the pending transaction.
+			err := newBankSync(log, repo, clock, enqueuer, provider, "manual").Run(context.Background(), &link)
+			require.NoError(t, err, "must sync successfully")
+			assert.True(t, provider.completed, "provider should have been told the sync completed")
+			assert.EqualValues(t, 1, fixtures.CountPendingTransactions(t, user.AccountId))
+		}
+
+		provider.completed = false
+		provider.pages = []*BankSyncPage{
+			{
+				Transactions: []BankSyncTransaction{
+					{
+						Id:           "posted",
+						PendingId:    myownsanity.StringP("pending"),
+						AccountId:    "account",
+						Amount:       1300,
+						Date:         date,
+						Name:         "Acme Corp",
+						OriginalName: "ACME CORP",
+						IsPending:    false,
+					},
+				},
+				Removed: []string{"pending"},
+			},
+		}
+
+		{ // Second sync clears the pending transaction.
+			err := newBankSync(log, repo, clock, enqueuer, provider, "manual").Run(context.Background(), &link)
+			require.NoError(t, err, "must sync successfully")
+			assert.True(t, provider.completed, "provider should have been told the sync completed")
+		}
+
+		assert.EqualValues(t, 1, fixtures.CountNonDeletedTransactions(t, user.AccountId), "the pending transaction should have been updated")
+		assert.EqualValues(t, 1, fixtures.CountAllTransactions(t, user.AccountId), "nothing should have been removed")
+		assert.EqualValues(t, 0, fixtures.CountPendingTransactions(t, user.AccountId))
+
+		transactions, err := repo.GetTransactonsByUploadIdentifier(
+			context.Background(),
+			bankAccount.BankAccountId,
+			[]string{"posted"},
+		)
+		require.NoError(t, err, "must be able to retrieve transactions")
+		require.Contains(t, transactions, "posted")
+		transaction := transactions["posted"]
+		assert.EqualValues(t, 1300, transaction.Amount, "amount should be updated")
+		assert.Equal(t, "Acme Corp", transaction.Name, "name should be replaced when the transaction clears")
+		assert.Equal(t, "ACME CORP", transaction.OriginalName, "original name should be updated")
+		assert.Equal(t, TransactionSourceUpload, transaction.Source)
+	})
+
+	t.Run("removed transaction and balances", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC))
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(
+			t,
+			clock,
+			&link,
+			DepositoryBankAccountType,
+			CheckingBankAccountSubType,
+		)
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Eq(CalculateTransactionClusters), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		date := time.Date(2024, 03, 9, 0, 0, 0, 0, time.UTC)
+		provider := &testBankSyncProvider{
+			repo:        repo,
+			bankAccount: bankAccount,
+			pages: []*BankSyncPage{
+				{
+					Transactions: []BankSyncTransaction{
+						{
+							Id:        "one",
+							AccountId: "account",
+							Amount:    100,
+							Date:      date,
+							Name:      "One",
+						},
+						{
+							Id:        "two",
+							AccountId: "account",
+							Amount:    200,
+							Date:      date,
+							Name:      "Two",
+						},
+					},
+					NextCursor: myownsanity.StringP("next"),
+					HasMore:    true,
+				},
+				{
+					Accounts: []BankSyncAccount{
+						{
+							Id:     "account",
+							Status: ActiveBankAccountStatus,
+							Balances: &BankSyncBalances{
+								Available: 1000,
+								Current:   1500,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		{ // First sync creates the transactions over two pages.
+			err := newBankSync(log, repo, clock, enqueuer, provider, "manual").Run(context.Background(), &link)
+			require.NoError(t, err, "must sync successfully")
+			assert.True(t, provider.completed, "provider should have been told the sync completed")
+
+			require.Len(t, provider.cursors, 2, "should have fetched two pages")
+			assert.Nil(t, provider.cursors[0], "first page should not have a cursor")
+			assert.Equal(t, myownsanity.StringP("next"), provider.cursors[1], "second page should use the cursor from the first")
+			assert.EqualValues(t, 2, fixtures.CountNonDeletedTransactions(t, user.AccountId), "should have both transactions")
+
+			updatedBankAccount, err := repo.GetBankAccount(context.Background(), bankAccount.BankAccountId)
+			require.NoError(t, err, "must be able to retrieve bank account")
+			assert.EqualValues(t, 1000, updatedBankAccount.AvailableBalance, "available balance should be updated")
+			assert.EqualValues(t, 1500, updatedBankAccount.CurrentBalance, "current balance should be updated")
+			assert.Equal(t, bankAccount.LimitBalance, updatedBankAccount.LimitBalance, "limit should not change when it is not provided")
+		}
+
+		provider.pages = []*BankSyncPage{
+			{
+				Removed: []string{"two", "unknown"},
+			},
+		}
+
+		{ // Second sync removes one of them.
+			err := newBankSync(log, repo, clock, enqueuer, provider, "manual").Run(context.Background(), &link)
+			require.NoError(t, err, "must sync successfully")
+		}
+
+		assert.EqualValues(t, 1, fixtures.CountNonDeletedTransactions(t, user.AccountId), "one transaction should have been removed")
+		assert.EqualValues(t, 2, fixtures.CountAllTransactions(t, user.AccountId), "removed transaction should be soft deleted")
+	})
+
+	t.Run("pending split transaction posts with a different amount", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC))
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(
+			t,
+			clock,
+			&link,
+			DepositoryBankAccountType,
+			CheckingBankAccountSubType,
+		)
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &bankAccount, "FREQ=MONTHLY;BYMONTHDAY=15,-1", false)
+		spendingRule := testutils.NewRuleSet(t, 2024, 3, 20, time.UTC, "FREQ=MONTHLY;BYMONTHDAY=20")
+		newSpending := func(name string) Spending {
+			return testutils.MustInsert(t, Spending{
+				Name:                   name,
+				SpendingType:           SpendingTypeExpense,
+				TargetAmount:           5000,
+				CurrentAmount:          5000,
+				NextContributionAmount: 0,
+				NextRecurrence:         spendingRule.After(clock.Now(), false),
+				RuleSet:                spendingRule,
+				AccountId:              user.AccountId,
+				BankAccountId:          bankAccount.BankAccountId,
+				FundingScheduleId:      fundingSchedule.FundingScheduleId,
+				CreatedAt:              clock.Now(),
+			})
+		}
+		groceries := newSpending("Groceries")
+		household := newSpending("Household")
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Eq(CalculateTransactionClusters), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		date := time.Date(2024, 03, 9, 0, 0, 0, 0, time.UTC)
+		provider := &testBankSyncProvider{
+			repo:        repo,
+			bankAccount: bankAccount,
+			pages: []*BankSyncPage{
+				{
+					Transactions: []BankSyncTransaction{
+						{
+							Id:           "pending",
+							AccountId:    "account",
+							Amount:       1000,
+							Date:         date,
+							Name:         "ACME PENDING",
+							OriginalName: "ACME PENDING",
+							IsPending:    true,
+						},
+					},
+				},
+			},
+		}
+
+		{ // First sync creates the pending transaction.
+			err := newBankSync(log, repo, clock, enqueuer, provider, "manual").Run(context.Background(), &link)
+			require.NoError(t, err, "must sync successfully")
+		}
+
+		{ // Split the pending transaction between both spending objects.
+			transactions, err := repo.GetTransactonsByUploadIdentifier(
+				context.Background(),
+				bankAccount.BankAccountId,
+				[]string{"pending"},
+			)
+			require.NoError(t, err, "must be able to retrieve transactions")
+			require.Contains(t, transactions, "pending")
+			existing := transactions["pending"]
+			updated := existing
+			updated.Splits = []TransactionSplit{
+				{SpendingId: &groceries.SpendingId, Amount: 600},
+				{SpendingId: &household.SpendingId, Amount: 400},
+			}
+			_, err = repo.ProcessTransactionSpentFrom(
+				context.Background(),
+				bankAccount.BankAccountId,
+				&updated,
+				&existing,
+			)
+			require.NoError(t, err, "must be able to split the transaction")
+			require.NoError(t, repo.UpdateTransaction(context.Background(), bankAccount.BankAccountId, &updated))
+			assert.EqualValues(t, 4400, testutils.MustDBRead(t, groceries).CurrentAmount)
+			assert.EqualValues(t, 4600, testutils.MustDBRead(t, household).CurrentAmount)
+		}
+
+		provider.pages = []*BankSyncPage{
+			{
+				Transactions: []BankSyncTransaction{
+					{
+						Id:           "posted",
+						PendingId:    myownsanity.StringP("pending"),
+						AccountId:    "account",
+						Amount:       1250,
+						Date:         date,
+						Name:         "Acme Corp",
+						OriginalName: "ACME CORP",
+						IsPending:    false,
+					},
+				},
+				Removed: []string{"pending"},
+			},
+		}
+
+		{ // Second sync posts the transaction with a larger amount.
+			err := newBankSync(log, repo, clock, enqueuer, provider, "manual").Run(context.Background(), &link)
+			require.NoError(t, err, "must sync successfully")
+		}
+
+		transactions, err := repo.GetTransactonsByUploadIdentifier(
+			context.Background(),
+			bankAccount.BankAccountId,
+			[]string{"posted"},
+		)
+		require.NoError(t, err, "must be able to retrieve transactions")
+		require.Contains(t, transactions, "posted")
+		transaction := transactions["posted"]
+		assert.EqualValues(t, 1250, transaction.Amount, "amount should be updated")
+
+		splits, err := repo.GetTransactionSplits(
+			context.Background(),
+			bankAccount.BankAccountId,
+			transaction.TransactionId,
+		)
+		require.NoError(t, err, "must be able to retrieve splits")
+		require.Len(t, splits, 2, "the splits should have been scaled")
+		assert.EqualValues(t, 750, splits[0].Amount)
+		assert.EqualValues(t, 500, splits[1].Amount)
+		assert.NoError(t, ValidateTransactionSplits(transaction, splits), "splits should add up to the new amount")
+
+		assert.EqualValues(t, 4250, testutils.MustDBRead(t, groceries).CurrentAmount, "groceries should be spent from the scaled split")
+		assert.EqualValues(t, 4500, testutils.MustDBRead(t, household).CurrentAmount, "household should be spent from the scaled split")
+	})
+}
@@ -27,6 +27,7 @@ type CalculateTransactionClustersHandler struct {
 	log          *logrus.Entry
 	db           pg.DBI
 	clock        clock.Clock
+	enqueuer     JobEnqueuer
 	unmarshaller JobUnmarshaller
 }
 
@@ -36,10 +37,11 @@ type CalculateTransactionClustersArguments struct {
 }
 
 type CalculateTransactionClustersJob struct {
-	args  CalculateTransactionClustersArguments
-	log   *logrus.Entry
-	db    pg.DBI
-	clock clock.Clock
+	args     CalculateTransactionClustersArguments
+	log      *logrus.Entry
+	db       pg.DBI
+	clock    clock.Clock
+	enqueuer JobEnqueuer
 }
 
 func TriggerCalculateTransactionClusters(
@@ -54,11 +56,13 @@ func NewCalculateTransactionClustersHandler(
 	log *logrus.Entry,
 	db pg.DBI,
 	clock clock.Clock,
+	enqueuer JobEnqueuer,
 ) *CalculateTransactionClustersHandler {
 	return &CalculateTransactionClustersHandler{
 		log:          log,
 		db:           db,
 		clock:        clock,
+		enqueuer:     enqueuer,
 		unmarshaller: DefaultJobUnmarshaller,
 	}
 }
@@ -90,6 +94,7 @@ func (c *CalculateTransactionClustersHandler) HandleConsumeJob(
 			log.WithContext(span.Context()),
 			txn,
 			c.clock,
+			c.enqueuer,
 			args,
 		)
 		if err != nil {
@@ -104,13 +109,15 @@ func NewCalculateTransactionClustersJob(
 	log *logrus.Entry,
 	db pg.DBI,
 	clock clock.Clock,
+	enqueuer JobEnqueuer,
 	args CalculateTransactionClustersArguments,
 ) (*CalculateTransactionClustersJob, error) {
 	return &CalculateTransactionClustersJob{
-		args:  args,
-		log:   log,
-		db:    db,
-		clock: clock,
+		args:     args,
+		log:      log,
+		db:       db,
+		clock:    clock,
+		enqueuer: enqueuer,
 	}, nil
 }
 
@@ -129,6 +136,9 @@ func (c *CalculateTransactionClustersJob) Run(ctx context.Context) error {
 	})
 
 	clustering := recurring.NewSimilarTransactions_TFIDF_DBSCAN()
+	// Keep track of every transaction we have seen, this way transactions that
+	// have since been removed can be dropped from the user's overrides.
+	existing := map[ID[Transaction]]struct{}{}
 
 	limit := 500
 	offset := 0
@@ -146,6 +156,7 @@ func (c *CalculateTransactionClustersJob) Run(ctx context.Context) error {
 
 		for i := range transactions {
 			clustering.AddTransaction(&transactions[i])
+			existing[transactions[i].TransactionId] = struct{}{}
 		}
 
 		if len(transactions) < limit {
@@ -158,6 +169,25 @@ func (c *CalculateTransactionClustersJob) Run(ctx context.Context) error {
 
 	result := clustering.DetectSimilarTransactions(span.Context())
 
+	overrides, err := repo.GetTransactionClusterOverrides(span.Context(), bankAccountId)
+	if err != nil {
+		return err
+	}
+	for i := range overrides {
+		if !overrides[i].IsSplit() {
+			continue
+		}
+
+		members := make([]ID[Transaction], 0, len(overrides[i].Members))
+		for _, member := range overrides[i].Members {
+			if _, ok := existing[member]; ok {
+				members = append(members, member)
+			}
+		}
+		overrides[i].Members = members
+	}
+	result = ApplyTransactionClusterOverrides(result, overrides)
+
 	if len(result) == 0 {
 		log.Info("no similar transactions detected, nothing to persist")
 		return nil
@@ -171,5 +201,20 @@ func (c *CalculateTransactionClustersJob) Run(ctx context.Context) error {
 		return errors.Wrap(err, "failed to persist the calculated transaction clusters")
 	}
 
+	// Now that the clusters are up to date, look for recurring transactions
+	// within them. This is enqueued in the same transaction so that the
+	// detection will always see the clusters we just wrote.
+	if err := c.enqueuer.EnqueueJobTxn(
+		span.Context(),
+		c.db,
+		DetectRecurringTransactions,
+		DetectRecurringTransactionsArguments{
+			AccountId:     accountId,
+			BankAccountId: bankAccountId,
+		},
+	); err != nil {
+		return errors.Wrap(err, "failed to enqueue recurring transaction detection")
+	}
+
 	return nil
 }
@@ -0,0 +1,274 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/forecast"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CheckBalanceAlerts = "CheckBalanceAlerts"
+)
+
+var (
+	_ ScheduledJobHandler = &CheckBalanceAlertsHandler{}
+	_ JobImplementation   = &CheckBalanceAlertsJob{}
+)
+
+type (
+	CheckBalanceAlertsHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	CheckBalanceAlertsArguments struct {
+		AccountId     ID[Account]     `json:"accountId"`
+		BankAccountId ID[BankAccount] `json:"bankAccountId"`
+	}
+
+	CheckBalanceAlertsJob struct {
+		args  CheckBalanceAlertsArguments
+		log   *logrus.Entry
+		repo  repository.BaseRepository
+		clock clock.Clock
+	}
+)
+
+func NewCheckBalanceAlertsHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+) *CheckBalanceAlertsHandler {
+	return &CheckBalanceAlertsHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (c CheckBalanceAlertsHandler) QueueName() string {
+	return CheckBalanceAlerts
+}
+
+func (c *CheckBalanceAlertsHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args CheckBalanceAlertsArguments
+	if err := errors.Wrap(c.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Check Balance Alerts job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return c.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		repo := repository.NewRepositoryFromSession(c.clock, "user_system", args.AccountId, txn)
+		job, err := NewCheckBalanceAlertsJob(
+			log.WithContext(span.Context()),
+			repo,
+			args,
+			c.clock,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func (c CheckBalanceAlertsHandler) DefaultSchedule() string {
+	// Will run once a day, after the balances have been snapshot.
+	return "0 30 0 * * *"
+}
+
+func (c *CheckBalanceAlertsHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := c.log.WithContext(ctx)
+
+	log.Info("retrieving bank accounts with balance alerts")
+	bankAccounts, err := c.repo.GetBankAccountsWithBalanceAlerts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve bank accounts with balance alerts")
+	}
+
+	if len(bankAccounts) == 0 {
+		crumbs.Debug(ctx, "No bank accounts have balance alerts.", nil)
+		log.Info("no bank accounts have balance alerts")
+		return nil
+	}
+
+	log.WithField("count", len(bankAccounts)).Info("found bank accounts with balance alerts")
+
+	for _, item := range bankAccounts {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId":     item.AccountId,
+			"bankAccountId": item.BankAccountId,
+		})
+		itemLog.Trace("enqueuing bank account to check balance alert")
+		err = enqueuer.EnqueueJob(ctx, c.QueueName(), CheckBalanceAlertsArguments{
+			AccountId:     item.AccountId,
+			BankAccountId: item.BankAccountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to check balance alert")
+			crumbs.Warn(ctx, "Failed to enqueue job to check balance alert", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
+
+func NewCheckBalanceAlertsJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	args CheckBalanceAlertsArguments,
+	clock clock.Clock,
+) (*CheckBalanceAlertsJob, error) {
+	return &CheckBalanceAlertsJob{
+		args:  args,
+		log:   log,
+		repo:  repo,
+		clock: clock,
+	}, nil
+}
+
+// Run forecasts the bank account for the number of days configured on its
+// balance alert and flags the alert if the projected balance drops below the
+// threshold at any point within that window.
+func (c *CheckBalanceAlertsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := c.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId":     c.args.AccountId,
+		"bankAccountId": c.args.BankAccountId,
+	})
+
+	alert, err := c.repo.GetBalanceAlert(span.Context(), c.args.BankAccountId)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve balance alert")
+		return err
+	}
+
+	if !alert.IsEnabled {
+		log.Debug("balance alert is not enabled, it will not be checked")
+		return nil
+	}
+
+	account, err := c.repo.GetAccount(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account to check balance alert")
+		return err
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Error("failed to parse account's timezone")
+		return err
+	}
+
+	fundingSchedules, err := c.repo.GetFundingSchedules(span.Context(), c.args.BankAccountId)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve funding schedules to check balance alert")
+		return err
+	}
+
+	spending, err := c.repo.GetSpending(span.Context(), c.args.BankAccountId)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve spending to check balance alert")
+		return err
+	}
+
+	now := c.clock.Now()
+	timeout, cancel := context.WithTimeout(span.Context(), 25*time.Second)
+	defer cancel()
+	result, err := forecast.NewForecaster(
+		log,
+		spending,
+		fundingSchedules,
+	).GetForecast(
+		timeout,
+		now,
+		now.AddDate(0, 0, alert.Days),
+		timezone,
+	)
+	if err != nil {
+		log.WithError(err).Error("failed to forecast balance for balance alert")
+		return errors.Wrap(err, "failed to forecast balance for balance alert")
+	}
+
+	wasTriggered := alert.IsTriggered
+	evaluateBalanceAlert(alert, result, util.Midnight(now, timezone))
+	checkedAt := now.UTC()
+	alert.LastCheckedAt = &checkedAt
+
+	if err := c.repo.UpdateBalanceAlertResult(span.Context(), alert); err != nil {
+		log.WithError(err).Error("failed to store balance alert result")
+		return err
+	}
+
+	switch {
+	case alert.IsTriggered && !wasTriggered:
+		log.WithFields(logrus.Fields{
+			"threshold":        alert.Threshold,
+			"triggeredDate":    alert.TriggeredDate,
+			"projectedBalance": alert.ProjectedBalance,
+		}).Info("projected balance drops below the balance alert threshold")
+	case !alert.IsTriggered && wasTriggered:
+		log.Info("projected balance is no longer below the balance alert threshold")
+	}
+
+	return nil
+}
+
+// evaluateBalanceAlert updates the result of the balance alert based on the
+// provided forecast. The alert is triggered on the first date that the
+// projected balance is below the threshold, if the balance is already below
+// the threshold then that is today.
+func evaluateBalanceAlert(alert *BalanceAlert, result forecast.Forecast, today time.Time) {
+	lowest := result.StartingBalance
+	var triggeredDate *time.Time
+	if result.StartingBalance < alert.Threshold {
+		date := today.UTC()
+		triggeredDate = &date
+	}
+
+	for _, event := range result.Events {
+		if event.Balance < lowest {
+			lowest = event.Balance
+		}
+
+		if triggeredDate == nil && event.Balance < alert.Threshold {
+			date := event.Date
+			triggeredDate = &date
+		}
+	}
+
+	alert.IsTriggered = triggeredDate != nil
+	alert.TriggeredDate = triggeredDate
+	alert.ProjectedBalance = &lowest
+}
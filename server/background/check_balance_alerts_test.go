@@ -0,0 +1,93 @@
+package background
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/forecast"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateBalanceAlert(t *testing.T) {
+	today := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	result := forecast.Forecast{
+		StartingBalance: 5000,
+		Events: []forecast.Event{
+			{Date: today.AddDate(0, 0, 3), Balance: 2500},
+			{Date: today.AddDate(0, 0, 7), Balance: 500},
+			{Date: today.AddDate(0, 0, 10), Balance: 4000},
+		},
+	}
+
+	t.Run("triggered on the first date below the threshold", func(t *testing.T) {
+		alert := BalanceAlert{Threshold: 3000}
+		evaluateBalanceAlert(&alert, result, today)
+		assert.True(t, alert.IsTriggered)
+		assert.Equal(t, today.AddDate(0, 0, 3), *alert.TriggeredDate)
+		assert.EqualValues(t, 500, *alert.ProjectedBalance, "should record the lowest projected balance")
+	})
+
+	t.Run("already below the threshold", func(t *testing.T) {
+		alert := BalanceAlert{Threshold: 6000}
+		evaluateBalanceAlert(&alert, result, today)
+		assert.True(t, alert.IsTriggered)
+		assert.Equal(t, today, *alert.TriggeredDate)
+	})
+
+	t.Run("not triggered", func(t *testing.T) {
+		alert := BalanceAlert{
+			Threshold:   100,
+			IsTriggered: true,
+		}
+		evaluateBalanceAlert(&alert, result, today)
+		assert.False(t, alert.IsTriggered, "previous result should be cleared")
+		assert.Nil(t, alert.TriggeredDate)
+		assert.EqualValues(t, 500, *alert.ProjectedBalance)
+	})
+}
+
+func TestCheckBalanceAlertsJob_Run(t *testing.T) {
+	t.Run("flags the alert", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, hook := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+
+		// Nothing has been allocated to any spending objects, so the projected
+		// balance will be zero which is below the threshold.
+		alert := BalanceAlert{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+			Threshold:     100,
+			Days:          30,
+			IsEnabled:     true,
+		}
+		testutils.MustDBInsert(t, &alert)
+
+		handler := NewCheckBalanceAlertsHandler(log, db, clock)
+		argsEncoded, err := DefaultJobMarshaller(CheckBalanceAlertsArguments{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should check balance alert successfully")
+		testutils.MustHaveLogMessage(t, hook, "projected balance drops below the balance alert threshold")
+
+		updated := testutils.MustDBRead(t, alert)
+		assert.True(t, updated.IsTriggered, "alert should be triggered")
+		assert.NotNil(t, updated.TriggeredDate, "should have the date the balance drops below the threshold")
+		assert.NotNil(t, updated.LastCheckedAt, "should record when the alert was checked")
+		assert.EqualValues(t, 0, *updated.ProjectedBalance)
+	})
+}
@@ -0,0 +1,524 @@
+package background
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/billing"
+	"github.com/monetr/monetr/server/communication"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/platypus"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/storage"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DeleteAccount = "DeleteAccount"
+)
+
+var (
+	_ ScheduledJobHandler = &DeleteAccountHandler{}
+	_ JobImplementation   = &DeleteAccountJob{}
+)
+
+type (
+	DeleteAccountHandler struct {
+		log           *logrus.Entry
+		db            *pg.DB
+		clock         clock.Clock
+		configuration config.Configuration
+		kms           secrets.KeyManagement
+		plaidPlatypus platypus.Platypus
+		tellerClient  teller.Teller
+		files         storage.Storage
+		billing       billing.Billing
+		email         communication.EmailCommunication
+		unmarshaller  JobUnmarshaller
+	}
+
+	DeleteAccountArguments struct {
+		AccountId ID[Account] `json:"accountId"`
+	}
+
+	DeleteAccountJob struct {
+		args          DeleteAccountArguments
+		log           *logrus.Entry
+		db            *pg.DB
+		clock         clock.Clock
+		configuration config.Configuration
+		secrets       repository.SecretsRepository
+		plaidPlatypus platypus.Platypus
+		tellerClient  teller.Teller
+		files         storage.Storage
+		billing       billing.Billing
+		email         communication.EmailCommunication
+	}
+)
+
+func NewDeleteAccountHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	configuration config.Configuration,
+	kms secrets.KeyManagement,
+	plaidPlatypus platypus.Platypus,
+	tellerClient teller.Teller,
+	files storage.Storage,
+	billing billing.Billing,
+	email communication.EmailCommunication,
+) *DeleteAccountHandler {
+	return &DeleteAccountHandler{
+		log:           log,
+		db:            db,
+		clock:         clock,
+		configuration: configuration,
+		kms:           kms,
+		plaidPlatypus: plaidPlatypus,
+		tellerClient:  tellerClient,
+		files:         files,
+		billing:       billing,
+		email:         email,
+		unmarshaller:  DefaultJobUnmarshaller,
+	}
+}
+
+func (h DeleteAccountHandler) QueueName() string {
+	return DeleteAccount
+}
+
+func (h DeleteAccountHandler) DefaultSchedule() string {
+	// Run every hour, 15 minutes after the hour.
+	return "0 15 * * * *"
+}
+
+func (h *DeleteAccountHandler) EnqueueTriggeredJob(
+	ctx context.Context,
+	enqueuer JobEnqueuer,
+) error {
+	log := h.log.WithContext(ctx)
+
+	var accounts []Account
+	err := h.db.ModelContext(ctx, &accounts).
+		Where(`"account"."deletion_scheduled_at" IS NOT NULL`).
+		Where(`"account"."deletion_scheduled_at" <= ?`, h.clock.Now()).
+		Select(&accounts)
+	if err != nil {
+		return errors.Wrap(err, "failed to query accounts that are scheduled for deletion")
+	}
+
+	if len(accounts) == 0 {
+		log.Debug("no accounts are scheduled for deletion at this time")
+		return nil
+	}
+
+	log.WithField("count", len(accounts)).Info("accounts are scheduled for deletion")
+
+	for _, item := range accounts {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId": item.AccountId,
+		})
+
+		itemLog.Trace("enqueuing account for deletion")
+		err := enqueuer.EnqueueJob(ctx, h.QueueName(), DeleteAccountArguments{
+			AccountId: item.AccountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to delete account")
+			crumbs.Warn(ctx, "Failed to enqueue job to delete account", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+
+		itemLog.Trace("successfully enqueued account for deletion")
+	}
+
+	return nil
+}
+
+func (h *DeleteAccountHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args DeleteAccountArguments
+	if err := errors.Wrap(h.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Delete Account job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	job, err := NewDeleteAccountJob(
+		log,
+		h.db,
+		h.clock,
+		h.configuration,
+		repository.NewSecretsRepository(log, h.clock, h.db, h.kms, args.AccountId),
+		h.plaidPlatypus,
+		h.tellerClient,
+		h.files,
+		h.billing,
+		h.email,
+		args,
+	)
+	if err != nil {
+		return err
+	}
+
+	return job.Run(ctx)
+}
+
+func NewDeleteAccountJob(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	configuration config.Configuration,
+	secrets repository.SecretsRepository,
+	plaidPlatypus platypus.Platypus,
+	tellerClient teller.Teller,
+	files storage.Storage,
+	billing billing.Billing,
+	email communication.EmailCommunication,
+	args DeleteAccountArguments,
+) (*DeleteAccountJob, error) {
+	return &DeleteAccountJob{
+		args:          args,
+		log:           log,
+		db:            db,
+		clock:         clock,
+		configuration: configuration,
+		secrets:       secrets,
+		plaidPlatypus: plaidPlatypus,
+		tellerClient:  tellerClient,
+		files:         files,
+		billing:       billing,
+		email:         email,
+	}, nil
+}
+
+// Run will remove everything associated with the account. Things outside of
+// our database are cleaned up first; Plaid items and Teller enrollments are
+// removed, the subscription
+// is canceled and any stored files are removed. If any of those steps fail the
+// job will be retried. Once those are done all of the data for the account is
+// removed in a single transaction and the owner is notified.
+func (j *DeleteAccountJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := j.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId": j.args.AccountId,
+	})
+
+	repo := repository.NewRepositoryFromSession(
+		j.clock,
+		"user_system",
+		j.args.AccountId,
+		j.db,
+	)
+
+	owner, err := repo.GetAccountOwner(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account owner for deletion, this job will not be retried")
+		return nil
+	}
+
+	// The deletion may have been canceled after the job was enqueued, in that
+	// case there is nothing to do.
+	if !owner.Account.IsPendingDeletion() || owner.Account.DeletionScheduledAt.After(j.clock.Now()) {
+		log.Info("account is no longer scheduled for deletion, nothing will be removed")
+		return nil
+	}
+
+	log.Info("deleting account")
+
+	if err := j.removePlaidLinks(span.Context(), log, repo); err != nil {
+		return err
+	}
+
+	if err := j.removeTellerLinks(span.Context(), log, repo); err != nil {
+		return err
+	}
+
+	if j.configuration.Stripe.IsBillingEnabled() {
+		if err := j.billing.CancelSubscription(span.Context(), j.args.AccountId); err != nil {
+			log.WithError(err).Error("failed to cancel subscription for account deletion")
+			return err
+		}
+	}
+
+	if err := j.removeFiles(span.Context(), log); err != nil {
+		return err
+	}
+
+	if err := j.db.RunInTransaction(span.Context(), func(txn *pg.Tx) error {
+		return j.purge(span.Context(), log, txn)
+	}); err != nil {
+		log.WithError(err).Error("failed to remove account data")
+		return err
+	}
+
+	log.Info("account has been deleted")
+
+	// The email communication is nil when email is not enabled.
+	if !j.configuration.Email.Enabled || j.email == nil {
+		return nil
+	}
+
+	// The account no longer exists at this point so there is no reason to retry
+	// the job if the email cannot be sent.
+	if err := j.email.SendEmail(span.Context(), communication.AccountDeletedParams{
+		BaseURL:      j.configuration.Server.GetBaseURL().String(),
+		Email:        owner.Login.Email,
+		FirstName:    owner.Login.FirstName,
+		LastName:     owner.Login.LastName,
+		SupportEmail: "support@monetr.app",
+	}); err != nil {
+		log.WithError(err).Warn("failed to send account deleted notification")
+	}
+
+	return nil
+}
+
+func (j *DeleteAccountJob) removePlaidLinks(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+) error {
+	links, err := repo.GetLinks(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve links for account deletion")
+	}
+
+	for i := range links {
+		link := links[i]
+		if link.PlaidLink == nil || link.PlaidLink.DeletedAt != nil {
+			continue
+		}
+
+		linkLog := log.WithFields(logrus.Fields{
+			"linkId":      link.LinkId,
+			"plaidLinkId": link.PlaidLinkId,
+			"itemId":      link.PlaidLink.PlaidId,
+		})
+
+		secret, err := j.secrets.Read(ctx, link.PlaidLink.SecretId)
+		if err != nil {
+			linkLog.WithError(err).Error("failed to retrieve access token for plaid link")
+			return errors.Wrap(err, "failed to retrieve access token for plaid link")
+		}
+
+		client, err := j.plaidPlatypus.NewClient(ctx, &link, secret.Value, link.PlaidLink.PlaidId)
+		if err != nil {
+			linkLog.WithError(err).Error("failed to create plaid client for account deletion")
+			return err
+		}
+
+		// If the item has already been removed from Plaid then this will fail, we
+		// don't want that to prevent the account from being deleted.
+		if err := client.RemoveItem(ctx); err != nil {
+			linkLog.WithError(err).Warn("failed to remove plaid item, it may have already been removed")
+			continue
+		}
+
+		linkLog.Info("removed plaid item for account deletion")
+	}
+
+	return nil
+}
+
+func (j *DeleteAccountJob) removeTellerLinks(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+) error {
+	links, err := repo.GetLinks(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve links for account deletion")
+	}
+
+	for i := range links {
+		link := links[i]
+		if link.TellerLink == nil || link.TellerLink.DeletedAt != nil || link.TellerLink.SecretId.IsZero() {
+			continue
+		}
+
+		linkLog := log.WithFields(logrus.Fields{
+			"linkId":       link.LinkId,
+			"tellerLinkId": link.TellerLinkId,
+			"enrollmentId": link.TellerLink.EnrollmentId,
+		})
+
+		secret, err := j.secrets.Read(ctx, link.TellerLink.SecretId)
+		if err != nil {
+			linkLog.WithError(err).Error("failed to retrieve access token for teller link")
+			return errors.Wrap(err, "failed to retrieve access token for teller link")
+		}
+
+		client, err := j.tellerClient.NewClient(ctx, &link, secret.Value)
+		if err != nil {
+			linkLog.WithError(err).Error("failed to create teller client for account deletion")
+			return err
+		}
+
+		// Same as Plaid, if the enrollment was already removed on Teller's side
+		// then this will fail. That should not block the account deletion.
+		if err := client.RemoveEnrollment(ctx); err != nil {
+			linkLog.WithError(err).Warn("failed to remove teller enrollment, it may have already been removed")
+			continue
+		}
+
+		linkLog.Info("removed teller enrollment for account deletion")
+	}
+
+	return nil
+}
+
+func (j *DeleteAccountJob) removeFiles(
+	ctx context.Context,
+	log *logrus.Entry,
+) error {
+	var files []File
+	err := j.db.ModelContext(ctx, &files).
+		Where(`"file"."account_id" = ?`, j.args.AccountId).
+		Where(`"file"."deleted_at" IS NULL`).
+		Select(&files)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve files for account deletion")
+	}
+
+	for _, file := range files {
+		if err := j.files.Remove(ctx, file.BlobUri); err != nil {
+			log.WithError(err).WithField("fileId", file.FileId).Error("failed to remove file for account deletion")
+			return errors.Wrap(err, "failed to remove file")
+		}
+	}
+
+	log.WithField("removed", len(files)).Info("removed file(s) from storage")
+
+	return nil
+}
+
+// purge removes every row that belongs to the account. The order of the tables
+// matters here to avoid violating foreign keys.
+func (j *DeleteAccountJob) purge(
+	ctx context.Context,
+	log *logrus.Entry,
+	txn *pg.Tx,
+) error {
+	accountId := j.args.AccountId
+
+	userIds := make([]ID[User], 0)
+	loginIds := make([]ID[Login], 0)
+	{
+		var users []User
+		err := txn.ModelContext(ctx, &users).
+			Where(`"user"."account_id" = ?`, accountId).
+			Select(&users)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve users for account deletion")
+		}
+		for _, user := range users {
+			userIds = append(userIds, user.UserId)
+			loginIds = append(loginIds, user.LoginId)
+		}
+	}
+
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"transaction clusters", &TransactionCluster{}},
+		{"recurring transactions", &TransactionRecurring{}},
+		{"transaction cluster overrides", &TransactionClusterOverride{}},
+		{"transaction uploads", &TransactionUpload{}},
+		{"transaction upload mappings", &TransactionUploadMapping{}},
+		{"transaction rules", &TransactionRule{}},
+		{"transaction splits", &TransactionSplit{}},
+		{"spending allocations", &SpendingAllocation{}},
+		{"balance snapshots", &BalanceSnapshot{}},
+		{"balance alerts", &BalanceAlert{}},
+		{"notifications", &Notification{}},
+		{"notification preferences", &NotificationPreference{}},
+		{"transactions", &Transaction{}},
+		{"plaid transactions", &PlaidTransaction{}},
+		{"teller transactions", &TellerTransaction{}},
+		{"simplefin transactions", &SimpleFINTransaction{}},
+		{"spending", &Spending{}},
+		{"funding schedules", &FundingSchedule{}},
+		{"bank accounts", &BankAccount{}},
+		{"plaid syncs", &PlaidSync{}},
+		{"plaid bank accounts", &PlaidBankAccount{}},
+		{"teller bank accounts", &TellerBankAccount{}},
+		{"simplefin bank accounts", &SimpleFINBankAccount{}},
+		{"links", &Link{}},
+		{"plaid links", &PlaidLink{}},
+		{"teller links", &TellerLink{}},
+		{"simplefin links", &SimpleFINLink{}},
+		{"webhook deliveries", &WebhookDelivery{}},
+		{"webhooks", &Webhook{}},
+		{"invitations", &Invitation{}},
+		{"secrets", &Secret{}},
+		{"files", &File{}},
+	}
+	for _, table := range tables {
+		result, err := txn.ModelContext(ctx, table.model).
+			Where(`"account_id" = ?`, accountId).
+			Delete()
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove %s", table.name)
+		}
+		log.WithField("removed", result.RowsAffected()).Debugf("removed %s", table.name)
+	}
+
+	if len(userIds) > 0 {
+		if _, err := txn.ModelContext(ctx, &Beta{}).
+			Set(`"used_by" = NULL`).
+			WhereIn(`"used_by" IN (?)`, userIds).
+			Update(); err != nil {
+			return errors.Wrap(err, "failed to remove beta code usage")
+		}
+
+		if _, err := txn.ModelContext(ctx, &APIKey{}).
+			WhereIn(`"user_id" IN (?)`, userIds).
+			Delete(); err != nil {
+			return errors.Wrap(err, "failed to remove api keys")
+		}
+	}
+
+	if _, err := txn.ModelContext(ctx, &User{}).
+		Where(`"account_id" = ?`, accountId).
+		Delete(); err != nil {
+		return errors.Wrap(err, "failed to remove users")
+	}
+
+	// Logins can belong to more than one account, only remove the logins that no
+	// longer have any users.
+	if len(loginIds) > 0 {
+		if _, err := txn.ModelContext(ctx, &Login{}).
+			WhereIn(`"login_id" IN (?)`, loginIds).
+			Where(`NOT EXISTS (SELECT 1 FROM "users" WHERE "users"."login_id" = "login"."login_id")`).
+			Delete(); err != nil {
+			return errors.Wrap(err, "failed to remove logins")
+		}
+	}
+
+	if _, err := txn.ModelContext(ctx, &Account{}).
+		Where(`"account_id" = ?`, accountId).
+		Delete(); err != nil {
+		return errors.Wrap(err, "failed to remove account")
+	}
+
+	return nil
+}
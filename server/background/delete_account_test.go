@@ -0,0 +1,167 @@
+package background_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/communication"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/robfig/cron"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDeleteAccountHandler_DefaultSchedule(t *testing.T) {
+	t.Run("validate cron schedule", func(t *testing.T) {
+		handler := &background.DeleteAccountHandler{}
+		schedule, err := cron.Parse(handler.DefaultSchedule())
+		assert.NoError(t, err, "must be able too parse the schedule")
+		now := time.Now()
+		next := schedule.Next(now)
+		assert.GreaterOrEqual(t, next, now, "next cron should always be greater or equal than now")
+	})
+}
+
+func TestDeleteAccountJob_Run(t *testing.T) {
+	t.Run("removes everything for the account", func(t *testing.T) {
+		clock := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+		email := mockgen.NewMockEmailCommunication(ctrl)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, clock, bankAccount, 5)
+
+		user.Account.DeletionRequestedAt = myownsanity.TimeP(clock.Now().Add(-15 * 24 * time.Hour))
+		user.Account.DeletionScheduledAt = myownsanity.TimeP(clock.Now().Add(-1 * time.Hour))
+		testutils.MustDBUpdate(t, user.Account)
+
+		email.EXPECT().
+			SendEmail(
+				gomock.Any(),
+				testutils.NewGenericMatcher(func(params communication.AccountDeletedParams) bool {
+					return assert.EqualValues(t, user.Login.Email, params.Email)
+				}),
+			).
+			Return(nil).
+			Times(1)
+
+		job, err := background.NewDeleteAccountJob(
+			log,
+			db,
+			clock,
+			config.Configuration{
+				Email: config.Email{
+					Enabled: true,
+				},
+			},
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			email,
+			background.DeleteAccountArguments{
+				AccountId: user.AccountId,
+			},
+		)
+		assert.NoError(t, err, "must be able to create the job")
+		assert.NoError(t, job.Run(context.Background()), "must be able to delete the account")
+
+		for _, transaction := range transactions {
+			testutils.MustDBNotExist(t, transaction)
+		}
+		testutils.MustDBNotExist(t, bankAccount)
+		testutils.MustDBNotExist(t, link)
+		testutils.MustDBNotExist(t, user)
+		testutils.MustDBNotExist(t, *user.Login)
+		testutils.MustDBNotExist(t, *user.Account)
+	})
+
+	t.Run("email is not enabled", func(t *testing.T) {
+		clock := clock.NewMock()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+
+		user.Account.DeletionRequestedAt = myownsanity.TimeP(clock.Now().Add(-15 * 24 * time.Hour))
+		user.Account.DeletionScheduledAt = myownsanity.TimeP(clock.Now().Add(-1 * time.Hour))
+		testutils.MustDBUpdate(t, user.Account)
+
+		// When email is disabled the email communication is nil, the job should
+		// still delete the account without trying to send anything.
+		job, err := background.NewDeleteAccountJob(
+			log,
+			db,
+			clock,
+			config.Configuration{},
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			background.DeleteAccountArguments{
+				AccountId: user.AccountId,
+			},
+		)
+		assert.NoError(t, err, "must be able to create the job")
+		assert.NoError(t, job.Run(context.Background()), "must be able to delete the account")
+
+		testutils.MustDBNotExist(t, link)
+		testutils.MustDBNotExist(t, user)
+		testutils.MustDBNotExist(t, *user.Account)
+	})
+
+	t.Run("deletion was canceled", func(t *testing.T) {
+		clock := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+		email := mockgen.NewMockEmailCommunication(ctrl)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+
+		email.EXPECT().
+			SendEmail(gomock.Any(), gomock.Any()).
+			Times(0)
+
+		job, err := background.NewDeleteAccountJob(
+			log,
+			db,
+			clock,
+			config.Configuration{},
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			email,
+			background.DeleteAccountArguments{
+				AccountId: user.AccountId,
+			},
+		)
+		assert.NoError(t, err, "must be able to create the job")
+		assert.NoError(t, job.Run(context.Background()), "job should do nothing")
+
+		testutils.MustDBRead(t, *user.Account)
+	})
+}
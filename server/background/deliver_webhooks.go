@@ -0,0 +1,390 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DeliverWebhooks = "DeliverWebhooks"
+
+	// deliverWebhooksBatchSize is how many deliveries will be attempted for an
+	// account by a single job, anything left over is picked up the next time
+	// the job runs.
+	deliverWebhooksBatchSize = 100
+	// maxWebhookDeliveryAttempts is how many times a delivery is attempted
+	// before it is considered failed. With the backoff below the last attempt
+	// happens a little over two hours after the event.
+	maxWebhookDeliveryAttempts = 8
+	webhookDeliveryBaseBackoff = time.Minute
+)
+
+var (
+	_ ScheduledJobHandler = &DeliverWebhooksHandler{}
+	_ JobImplementation   = &DeliverWebhooksJob{}
+)
+
+type (
+	DeliverWebhooksHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		kms          secrets.KeyManagement
+		client       webhooks.Client
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	DeliverWebhooksArguments struct {
+		AccountId ID[Account] `json:"accountId"`
+	}
+
+	DeliverWebhooksJob struct {
+		args    DeliverWebhooksArguments
+		log     *logrus.Entry
+		repo    repository.BaseRepository
+		secrets repository.SecretsRepository
+		client  webhooks.Client
+		clock   clock.Clock
+	}
+)
+
+func NewDeliverWebhooksHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	kms secrets.KeyManagement,
+	client webhooks.Client,
+) *DeliverWebhooksHandler {
+	return &DeliverWebhooksHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		kms:          kms,
+		client:       client,
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (d DeliverWebhooksHandler) QueueName() string {
+	return DeliverWebhooks
+}
+
+func (d *DeliverWebhooksHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args DeliverWebhooksArguments
+	if err := errors.Wrap(d.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Deliver Webhooks job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	// Deliveries are not attempted inside of a transaction. Each attempt is
+	// recorded and committed before the request is made, that way a failure
+	// part way through the job cannot roll back the record of a request that
+	// was already sent, and database connections are not held open while we
+	// wait on someone else's server.
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	jobLog := log.WithContext(span.Context())
+	repo := repository.NewRepositoryFromSession(d.clock, "user_system", args.AccountId, d.db)
+	secretsRepo := repository.NewSecretsRepository(
+		jobLog,
+		d.clock,
+		d.db,
+		d.kms,
+		args.AccountId,
+	)
+	job, err := NewDeliverWebhooksJob(
+		jobLog,
+		repo,
+		secretsRepo,
+		d.client,
+		args,
+		d.clock,
+	)
+	if err != nil {
+		return err
+	}
+	return job.Run(span.Context())
+}
+
+func (d DeliverWebhooksHandler) DefaultSchedule() string {
+	// Deliveries are created by other jobs and by the API, attempt them every
+	// minute so that they are timely and so that retries honor their backoff.
+	return "0 * * * * *"
+}
+
+func (d *DeliverWebhooksHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := d.log.WithContext(ctx)
+
+	accountIds, err := d.repo.GetAccountsWithDueWebhookDeliveries(ctx, d.clock.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve accounts with due webhook deliveries")
+	}
+
+	if len(accountIds) == 0 {
+		crumbs.Debug(ctx, "No accounts have due webhook deliveries.", nil)
+		log.Debug("no accounts have due webhook deliveries")
+		return nil
+	}
+
+	log.WithField("count", len(accountIds)).Info("found accounts with due webhook deliveries")
+
+	for _, accountId := range accountIds {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId": accountId,
+		})
+		itemLog.Trace("enqueuing account to deliver webhooks")
+		err = enqueuer.EnqueueJob(ctx, d.QueueName(), DeliverWebhooksArguments{
+			AccountId: accountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to deliver webhooks")
+			crumbs.Warn(ctx, "Failed to enqueue job to deliver webhooks", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
+
+func NewDeliverWebhooksJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	secrets repository.SecretsRepository,
+	client webhooks.Client,
+	args DeliverWebhooksArguments,
+	clock clock.Clock,
+) (*DeliverWebhooksJob, error) {
+	return &DeliverWebhooksJob{
+		args:    args,
+		log:     log,
+		repo:    repo,
+		secrets: secrets,
+		client:  client,
+		clock:   clock,
+	}, nil
+}
+
+// Run attempts every pending delivery of the account that is due. Failed
+// attempts are retried with an exponential backoff until they run out of
+// attempts.
+func (d *DeliverWebhooksJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := d.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId": d.args.AccountId,
+	})
+
+	due, err := d.repo.GetDueWebhookDeliveries(span.Context(), d.clock.Now(), deliverWebhooksBatchSize)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve due webhook deliveries")
+		return err
+	}
+
+	webhookSecrets := map[ID[Webhook]]string{}
+	for i := range due {
+		delivery := &due[i]
+		deliveryLog := log.WithFields(logrus.Fields{
+			"webhookId":         delivery.WebhookId,
+			"webhookDeliveryId": delivery.WebhookDeliveryId,
+			"eventType":         delivery.EventType,
+		})
+
+		now := d.clock.Now().UTC()
+		if delivery.Webhook == nil || !delivery.Webhook.IsEnabled {
+			deliveryLog.Debug("webhook is disabled, delivery will not be attempted")
+			delivery.Status = WebhookDeliveryStatusFailed
+			delivery.Error = myownsanity.StringP("webhook is disabled")
+			delivery.NextAttemptAt = nil
+		} else {
+			secret, ok := webhookSecrets[delivery.WebhookId]
+			if !ok {
+				data, err := d.secrets.Read(span.Context(), delivery.Webhook.SecretId)
+				if err != nil {
+					deliveryLog.WithError(err).Error("failed to retrieve webhook secret")
+					return err
+				}
+				secret = data.Value
+				webhookSecrets[delivery.WebhookId] = secret
+			}
+
+			previousAttempts := delivery.Attempts
+			startWebhookDeliveryAttempt(delivery, now)
+			started, err := d.repo.StartWebhookDeliveryAttempt(span.Context(), delivery, previousAttempts)
+			if err != nil {
+				deliveryLog.WithError(err).Error("failed to record webhook delivery attempt")
+				return err
+			}
+			if !started {
+				deliveryLog.Debug("webhook delivery was already attempted by another job, it will be skipped")
+				continue
+			}
+
+			status, err := d.client.Deliver(span.Context(), *delivery.Webhook, secret, *delivery, now)
+			recordWebhookDeliveryAttempt(delivery, now, status, err)
+			if err != nil {
+				deliveryLog.WithError(err).
+					WithField("attempts", delivery.Attempts).
+					Warn("failed to deliver webhook")
+			}
+		}
+
+		if err := d.repo.UpdateWebhookDelivery(span.Context(), delivery); err != nil {
+			deliveryLog.WithError(err).Error("failed to update webhook delivery")
+			return err
+		}
+
+		deliveryLog.WithField("status", delivery.Status).Debug("attempted webhook delivery")
+	}
+
+	return nil
+}
+
+// startWebhookDeliveryAttempt counts the attempt that is about to be made and
+// schedules the next one as if it will fail. If the job dies before the result
+// of the attempt is recorded, the delivery will be retried after the backoff
+// instead of right away.
+func startWebhookDeliveryAttempt(delivery *WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	scheduleWebhookDeliveryRetry(delivery, now, "delivery attempt did not complete")
+}
+
+// recordWebhookDeliveryAttempt updates the delivery with the result of the
+// attempt started by startWebhookDeliveryAttempt, scheduling the next attempt
+// if it failed and there are attempts remaining.
+func recordWebhookDeliveryAttempt(
+	delivery *WebhookDelivery,
+	now time.Time,
+	status *int,
+	err error,
+) {
+	delivery.ResponseStatus = status
+
+	if err == nil {
+		delivery.Status = WebhookDeliveryStatusSucceeded
+		delivery.Error = nil
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	scheduleWebhookDeliveryRetry(delivery, now, err.Error())
+}
+
+func scheduleWebhookDeliveryRetry(delivery *WebhookDelivery, now time.Time, reason string) {
+	delivery.Error = myownsanity.StringP(reason)
+	if delivery.Attempts >= maxWebhookDeliveryAttempts {
+		delivery.Status = WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	// Wait 1, 2, 4, 8... minutes between attempts.
+	backoff := webhookDeliveryBaseBackoff * time.Duration(1<<(delivery.Attempts-1))
+	next := now.Add(backoff)
+	delivery.Status = WebhookDeliveryStatusPending
+	delivery.NextAttemptAt = &next
+}
+
+// EmitWebhookEvent records a delivery of the event for every enabled webhook in
+// the account that is subscribed to it. The deliveries are attempted by the
+// deliver webhooks job. This should be called with the same repository as the
+// change that caused the event, so that the event is only delivered if that
+// change is committed.
+func EmitWebhookEvent(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	clock clock.Clock,
+	eventType WebhookEventType,
+	data interface{},
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	hooks, err := repo.GetWebhooksForEvent(span.Context(), eventType)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	now := clock.Now().UTC()
+	payload := WebhookPayload{
+		Type:      eventType,
+		AccountId: repo.AccountId(),
+		CreatedAt: now,
+		Data:      data,
+	}
+	payload.EventId = NewID(&payload)
+
+	deliveries := make([]WebhookDelivery, 0, len(hooks))
+	for _, hook := range hooks {
+		deliveries = append(deliveries, WebhookDelivery{
+			WebhookId:     hook.WebhookId,
+			EventId:       payload.EventId,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		})
+	}
+
+	if err := repo.CreateWebhookDeliveries(span.Context(), deliveries); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"eventId":   payload.EventId,
+		"eventType": eventType,
+		"webhooks":  len(deliveries),
+	}).Debug("created webhook deliveries for event")
+
+	return nil
+}
+
+// emitTransactionWebhookEvents emits an event of the provided type for each of
+// the transactions.
+func emitTransactionWebhookEvents[T Transaction | *Transaction](
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	clock clock.Clock,
+	eventType WebhookEventType,
+	transactions []T,
+) error {
+	for i := range transactions {
+		if err := EmitWebhookEvent(ctx, log, repo, clock, eventType, transactions[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
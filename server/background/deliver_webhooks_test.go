@@ -0,0 +1,236 @@
+package background
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWebhookClient struct {
+	status  int
+	err     error
+	secrets []string
+	sent    []WebhookDelivery
+	// onDeliver is called before the result of the delivery is returned.
+	onDeliver func(delivery WebhookDelivery)
+}
+
+func (t *testWebhookClient) Deliver(
+	ctx context.Context,
+	webhook Webhook,
+	secret string,
+	delivery WebhookDelivery,
+	now time.Time,
+) (*int, error) {
+	t.secrets = append(t.secrets, secret)
+	t.sent = append(t.sent, delivery)
+	if t.onDeliver != nil {
+		t.onDeliver(delivery)
+	}
+	status := t.status
+	return &status, t.err
+}
+
+func TestRecordWebhookDeliveryAttempt(t *testing.T) {
+	now := time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC)
+
+	t.Run("succeeded", func(t *testing.T) {
+		delivery := WebhookDelivery{
+			Status:        WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		}
+		startWebhookDeliveryAttempt(&delivery, now)
+		recordWebhookDeliveryAttempt(&delivery, now, nil, nil)
+		assert.Equal(t, WebhookDeliveryStatusSucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Nil(t, delivery.NextAttemptAt)
+		assert.Equal(t, now, *delivery.DeliveredAt)
+	})
+
+	t.Run("backs off", func(t *testing.T) {
+		delivery := WebhookDelivery{
+			Status:   WebhookDeliveryStatusPending,
+			Attempts: 2,
+		}
+		startWebhookDeliveryAttempt(&delivery, now)
+		recordWebhookDeliveryAttempt(&delivery, now, nil, errors.New("connection refused"))
+		assert.Equal(t, WebhookDeliveryStatusPending, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Equal(t, now.Add(4*time.Minute), *delivery.NextAttemptAt, "third attempt should wait 4 minutes")
+		assert.Equal(t, "connection refused", *delivery.Error)
+	})
+
+	t.Run("out of attempts", func(t *testing.T) {
+		delivery := WebhookDelivery{
+			Status:   WebhookDeliveryStatusPending,
+			Attempts: maxWebhookDeliveryAttempts - 1,
+		}
+		startWebhookDeliveryAttempt(&delivery, now)
+		recordWebhookDeliveryAttempt(&delivery, now, nil, errors.New("connection refused"))
+		assert.Equal(t, WebhookDeliveryStatusFailed, delivery.Status)
+		assert.Nil(t, delivery.NextAttemptAt)
+	})
+
+	t.Run("interrupted attempt", func(t *testing.T) {
+		delivery := WebhookDelivery{
+			Status:        WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		}
+		startWebhookDeliveryAttempt(&delivery, now)
+		assert.Equal(t, WebhookDeliveryStatusPending, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, now.Add(time.Minute), *delivery.NextAttemptAt, "an interrupted attempt should wait for the backoff")
+		assert.Equal(t, "delivery attempt did not complete", *delivery.Error)
+	})
+}
+
+func TestDeliverWebhooksJob_Run(t *testing.T) {
+	setup := func(t *testing.T, clock *clock.Mock) (repository.Repository, User, secrets.KeyManagement) {
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		db := testutils.GetPgDatabase(t)
+		kms := secrets.NewPlaintextKMS()
+		log := testutils.GetLog(t)
+
+		secretData := repository.SecretData{
+			Kind:  WebhookSecretKind,
+			Value: "whsec_test",
+		}
+		secretsRepo := repository.NewSecretsRepository(log, clock, db, kms, user.AccountId)
+		require.NoError(t, secretsRepo.Store(context.Background(), &secretData))
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		require.NoError(t, repo.CreateWebhook(context.Background(), &Webhook{
+			URL:        "https://example.com/monetr",
+			EventTypes: []WebhookEventType{WebhookEventSyncCompleted},
+			IsEnabled:  true,
+			SecretId:   secretData.SecretId,
+		}))
+
+		return repo, user, kms
+	}
+
+	t.Run("delivers the event", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, _ := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+		repo, user, kms := setup(t, clock)
+
+		// Events the webhook is not subscribed to should not create deliveries.
+		err := EmitWebhookEvent(context.Background(), log, repo, clock, WebhookEventSpendingFunded, SpendingFundedEvent{})
+		require.NoError(t, err)
+		err = EmitWebhookEvent(context.Background(), log, repo, clock, WebhookEventSyncCompleted, SyncCompletedEvent{
+			LinkId:  "link_test",
+			Created: 2,
+		})
+		require.NoError(t, err)
+
+		client := &testWebhookClient{status: http.StatusOK}
+		handler := NewDeliverWebhooksHandler(log, db, clock, kms, client)
+		argsEncoded, err := DefaultJobMarshaller(DeliverWebhooksArguments{
+			AccountId: user.AccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should deliver webhooks successfully")
+		require.Len(t, client.sent, 1, "should only deliver the subscribed event")
+		assert.Equal(t, "whsec_test", client.secrets[0], "should sign with the webhook's secret")
+		assert.Equal(t, WebhookEventSyncCompleted, client.sent[0].Payload.Type)
+
+		hooks, err := repo.GetWebhooks(context.Background())
+		require.NoError(t, err)
+		deliveries, err := repo.GetWebhookDeliveries(context.Background(), hooks[0].WebhookId, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, WebhookDeliveryStatusSucceeded, deliveries[0].Status)
+		assert.EqualValues(t, http.StatusOK, *deliveries[0].ResponseStatus)
+	})
+
+	t.Run("retries failed deliveries", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, _ := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+		repo, user, kms := setup(t, clock)
+
+		err := EmitWebhookEvent(context.Background(), log, repo, clock, WebhookEventSyncCompleted, SyncCompletedEvent{})
+		require.NoError(t, err)
+
+		client := &testWebhookClient{
+			status: http.StatusInternalServerError,
+			err:    errors.New("webhook responded with status 500"),
+		}
+		handler := NewDeliverWebhooksHandler(log, db, clock, kms, client)
+		argsEncoded, err := DefaultJobMarshaller(DeliverWebhooksArguments{
+			AccountId: user.AccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "a failed delivery should not fail the job")
+		assert.Len(t, client.sent, 1)
+
+		// The delivery is not due again until the backoff has passed.
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err)
+		assert.Len(t, client.sent, 1, "should not retry before the backoff")
+
+		clock.Add(time.Minute)
+		client.err = nil
+		client.status = http.StatusOK
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err)
+		assert.Len(t, client.sent, 2, "should retry after the backoff")
+
+		hooks, err := repo.GetWebhooks(context.Background())
+		require.NoError(t, err)
+		deliveries, err := repo.GetWebhookDeliveries(context.Background(), hooks[0].WebhookId, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, WebhookDeliveryStatusSucceeded, deliveries[0].Status)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+	})
+
+	t.Run("records the attempt before delivering", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, _ := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+		repo, user, kms := setup(t, clock)
+
+		err := EmitWebhookEvent(context.Background(), log, repo, clock, WebhookEventSyncCompleted, SyncCompletedEvent{})
+		require.NoError(t, err)
+
+		hooks, err := repo.GetWebhooks(context.Background())
+		require.NoError(t, err)
+
+		client := &testWebhookClient{status: http.StatusOK}
+		client.onDeliver = func(delivery WebhookDelivery) {
+			// Read the delivery outside of the job, the attempt should already be
+			// committed while the request is being made.
+			deliveries, err := repo.GetWebhookDeliveries(context.Background(), hooks[0].WebhookId, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			assert.Equal(t, 1, deliveries[0].Attempts, "attempt should be recorded before the request")
+			assert.Equal(t, clock.Now().Add(time.Minute).UTC(), deliveries[0].NextAttemptAt.UTC(), "retry should already be scheduled")
+		}
+		handler := NewDeliverWebhooksHandler(log, db, clock, kms, client)
+		argsEncoded, err := DefaultJobMarshaller(DeliverWebhooksArguments{
+			AccountId: user.AccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err)
+		assert.Len(t, client.sent, 1)
+	})
+}
@@ -0,0 +1,271 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/recurring"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DetectRecurringTransactions = "DetectRecurringTransactions"
+)
+
+var (
+	_ JobHandler        = &DetectRecurringTransactionsHandler{}
+	_ JobImplementation = &DetectRecurringTransactionsJob{}
+)
+
+type DetectRecurringTransactionsHandler struct {
+	log          *logrus.Entry
+	db           pg.DBI
+	clock        clock.Clock
+	unmarshaller JobUnmarshaller
+}
+
+type DetectRecurringTransactionsArguments struct {
+	AccountId     ID[Account]     `json:"accountId"`
+	BankAccountId ID[BankAccount] `json:"bankAccountId"`
+}
+
+type DetectRecurringTransactionsJob struct {
+	args  DetectRecurringTransactionsArguments
+	log   *logrus.Entry
+	db    pg.DBI
+	clock clock.Clock
+}
+
+func NewDetectRecurringTransactionsHandler(
+	log *logrus.Entry,
+	db pg.DBI,
+	clock clock.Clock,
+) *DetectRecurringTransactionsHandler {
+	return &DetectRecurringTransactionsHandler{
+		log:          log,
+		db:           db,
+		clock:        clock,
+		unmarshaller: DefaultJobUnmarshaller,
+	}
+}
+
+func (d DetectRecurringTransactionsHandler) QueueName() string {
+	return DetectRecurringTransactions
+}
+
+func (d *DetectRecurringTransactionsHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args DetectRecurringTransactionsArguments
+	if err := errors.Wrap(d.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Detect Recurring Transactions job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return d.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		job, err := NewDetectRecurringTransactionsJob(
+			log.WithContext(span.Context()),
+			txn,
+			d.clock,
+			args,
+		)
+		if err != nil {
+			return err
+		}
+
+		return job.Run(span.Context())
+	})
+}
+
+func NewDetectRecurringTransactionsJob(
+	log *logrus.Entry,
+	db pg.DBI,
+	clock clock.Clock,
+	args DetectRecurringTransactionsArguments,
+) (*DetectRecurringTransactionsJob, error) {
+	return &DetectRecurringTransactionsJob{
+		args:  args,
+		log:   log,
+		db:    db,
+		clock: clock,
+	}, nil
+}
+
+// Run will look for recurring transactions within each of the transaction
+// clusters for the bank account. Any recurring transactions that are detected
+// will replace the ones that were previously detected for the bank account.
+func (d *DetectRecurringTransactionsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	accountId := d.args.AccountId
+	bankAccountId := d.args.BankAccountId
+
+	repo := repository.NewRepositoryFromSession(d.clock, "user_system", accountId, d.db)
+
+	log := d.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId":     accountId,
+		"bankAccountId": bankAccountId,
+	})
+
+	account, err := repo.GetAccount(span.Context())
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve account for recurring transaction detection")
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		return errors.Wrap(err, "failed to parse account's timezone")
+	}
+
+	clusters, err := repo.GetTransactionClusters(span.Context(), bankAccountId)
+	if err != nil {
+		return err
+	}
+
+	transactions := map[ID[Transaction]]Transaction{}
+	limit := 500
+	offset := 0
+	for {
+		items, err := repo.GetTransactions(span.Context(), bankAccountId, limit, offset)
+		if err != nil {
+			return errors.Wrap(err, "failed to read transactions for recurring detection")
+		}
+
+		for _, item := range items {
+			transactions[item.TransactionId] = item
+		}
+
+		if len(items) < limit {
+			break
+		}
+
+		offset += len(items)
+	}
+
+	now := d.clock.Now()
+	result := make([]TransactionRecurring, 0)
+	for _, cluster := range clusters {
+		clusterLog := log.WithField("transactionClusterId", cluster.TransactionClusterId)
+
+		members := make([]Transaction, 0, len(cluster.Members))
+		for _, transactionId := range cluster.Members {
+			if transaction, ok := transactions[transactionId]; ok {
+				members = append(members, transaction)
+			}
+		}
+
+		detected, err := recurring.DetectRecurringTransactions(span.Context(), d.clock, members)
+		if errors.Is(errors.Cause(err), recurring.ErrInsufficientTransactionData) {
+			continue
+		} else if err != nil {
+			clusterLog.WithError(err).Warn("failed to detect recurring transactions for cluster")
+			continue
+		}
+
+		item, ok := newTransactionRecurring(cluster, detected, timezone, now)
+		if !ok {
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	log.WithFields(logrus.Fields{
+		"clusters":  len(clusters),
+		"recurring": len(result),
+	}).Info("detected recurring transactions")
+
+	if err := repo.WriteTransactionRecurring(span.Context(), bankAccountId, result); err != nil {
+		return errors.Wrap(err, "failed to persist the detected recurring transactions")
+	}
+
+	return nil
+}
+
+// newTransactionRecurring builds the recurring transaction for a cluster from
+// the result of the recurring detection. If nothing recurring was detected for
+// the cluster then false is returned.
+func newTransactionRecurring(
+	cluster TransactionCluster,
+	detected *recurring.RecurringTransactionResult,
+	timezone *time.Location,
+	now time.Time,
+) (TransactionRecurring, bool) {
+	if detected.Best == nil || len(detected.Members) == 0 {
+		return TransactionRecurring{}, false
+	}
+
+	window, ok := recurring.GetWindowForFrequency(
+		detected.Best.Frequency,
+		detected.Best.StartDate,
+		timezone,
+	)
+	if !ok {
+		return TransactionRecurring{}, false
+	}
+
+	next := window.Rule.After(now, false)
+	if next.IsZero() {
+		return TransactionRecurring{}, false
+	}
+
+	members := make([]ID[Transaction], 0, len(detected.Members))
+	amounts := map[int64]int{}
+	seen := map[ID[Transaction]]struct{}{}
+	for _, member := range detected.Members {
+		// The same transaction can be included in adjacent peaks by the detector,
+		// make sure each transaction is only counted once.
+		if _, ok := seen[member.TransactionId]; ok {
+			continue
+		}
+		seen[member.TransactionId] = struct{}{}
+		members = append(members, member.TransactionId)
+		amounts[member.Amount]++
+	}
+	first := detected.Members[0]
+	last := detected.Members[len(detected.Members)-1]
+
+	// If the occurrence that should have come after the most recent member is
+	// well past, then the transaction is probably no longer recurring.
+	expected := window.Rule.After(last.Date, false)
+	ended := !expected.IsZero() && expected.AddDate(0, 0, window.Fuzzy).Before(now)
+
+	var confidence float64
+	if len(detected.Results) > 0 {
+		confidence = detected.Results[0].Confidence
+	}
+
+	return TransactionRecurring{
+		BankAccountId: cluster.BankAccountId,
+		Name:          cluster.Name,
+		Window:        window.Type,
+		RuleSet: &RuleSet{
+			Set: *window.Rule,
+		},
+		First:      first.Date,
+		Last:       last.Date,
+		Next:       next,
+		Ended:      ended,
+		Confidence: confidence,
+		Amounts:    amounts,
+		LastAmount: last.Amount,
+		Members:    members,
+	}, true
+}
@@ -0,0 +1,111 @@
+package background
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectRecurringTransactionsJob_Run(t *testing.T) {
+	t.Run("monthly subscription", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Date(2024, 7, 20, 12, 0, 0, 0, time.UTC))
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+
+		members := make([]models.ID[models.Transaction], 0, 6)
+		for i := 0; i < 6; i++ {
+			transaction := testutils.MustInsert(t, models.Transaction{
+				AccountId:            bankAccount.AccountId,
+				BankAccountId:        bankAccount.BankAccountId,
+				Amount:               1599,
+				Date:                 time.Date(2024, time.Month(2+i), 15, 0, 0, 0, 0, timezone),
+				Name:                 "Netflix",
+				OriginalName:         "NETFLIX.COM",
+				MerchantName:         "Netflix",
+				OriginalMerchantName: "Netflix",
+				Source:               models.TransactionSourceUpload,
+				CreatedAt:            clock.Now(),
+			})
+			members = append(members, transaction.TransactionId)
+		}
+
+		testutils.MustInsert(t, models.TransactionCluster{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+			Name:          "Netflix",
+			Members:       members,
+			CreatedAt:     clock.Now(),
+		})
+
+		job, err := NewDetectRecurringTransactionsJob(log, db, clock, DetectRecurringTransactionsArguments{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+		})
+		require.NoError(t, err, "must be able to create job")
+		require.NoError(t, job.Run(context.Background()), "must run job successfully")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, bankAccount.AccountId, db)
+		result, err := repo.GetTransactionRecurring(context.Background(), bankAccount.BankAccountId)
+		require.NoError(t, err, "must be able to read recurring transactions")
+		require.Len(t, result, 1, "should have detected one recurring transaction")
+
+		item := result[0]
+		assert.Equal(t, "Netflix", item.Name)
+		assert.Equal(t, models.MonthlyWindowType, item.Window)
+		assert.EqualValues(t, 1599, item.AverageAmount())
+		assert.False(t, item.Ended, "subscription should still be ongoing")
+		assert.Equal(t, time.Date(2024, 8, 15, 0, 0, 0, 0, timezone), item.Next.In(timezone))
+
+		{ // Detecting again should update the same recurring transaction.
+			clock.Add(24 * time.Hour)
+			job, err := NewDetectRecurringTransactionsJob(log, db, clock, DetectRecurringTransactionsArguments{
+				AccountId:     bankAccount.AccountId,
+				BankAccountId: bankAccount.BankAccountId,
+			})
+			require.NoError(t, err, "must be able to create job")
+			require.NoError(t, job.Run(context.Background()), "must run job successfully")
+
+			result, err := repo.GetTransactionRecurring(context.Background(), bankAccount.BankAccountId)
+			require.NoError(t, err, "must be able to read recurring transactions")
+			require.Len(t, result, 1, "should not have created another recurring transaction")
+			assert.Equal(t, item.TransactionRecurringId, result[0].TransactionRecurringId, "ID should not change when redetected")
+		}
+	})
+
+	t.Run("no clusters", func(t *testing.T) {
+		clock := clock.NewMock()
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t, testutils.IsolatedDatabase)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		fixtures.GivenIHaveNTransactions(t, clock, bankAccount, 5)
+
+		job, err := NewDetectRecurringTransactionsJob(log, db, clock, DetectRecurringTransactionsArguments{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+		})
+		require.NoError(t, err, "must be able to create job")
+		require.NoError(t, job.Run(context.Background()), "must run job successfully")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, bankAccount.AccountId, db)
+		result, err := repo.GetTransactionRecurring(context.Background(), bankAccount.BankAccountId)
+		require.NoError(t, err, "must be able to read recurring transactions")
+		assert.Empty(t, result)
+	})
+}
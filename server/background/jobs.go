@@ -8,10 +8,14 @@ import (
 	"github.com/monetr/monetr/server/billing"
 	"github.com/monetr/monetr/server/communication"
 	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/notifications"
 	"github.com/monetr/monetr/server/platypus"
 	"github.com/monetr/monetr/server/pubsub"
 	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/simplefin"
 	"github.com/monetr/monetr/server/storage"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/monetr/monetr/server/webhooks"
 	"github.com/sirupsen/logrus"
 )
 
@@ -55,6 +59,8 @@ func NewBackgroundJobs(
 	db *pg.DB,
 	publisher pubsub.Publisher,
 	plaidPlatypus platypus.Platypus,
+	tellerClient teller.Teller,
+	simpleFINClient simplefin.SimpleFIN,
 	kms secrets.KeyManagement,
 	fileStorage storage.Storage,
 	billing billing.Billing,
@@ -74,19 +80,49 @@ func NewBackgroundJobs(
 		enqueuer,
 	)
 
+	webhookClient := webhooks.NewClient(nil)
+	notificationChannels := []notifications.Channel{
+		notifications.NewWebhookChannel(clock, webhookClient),
+	}
+	if email != nil {
+		notificationChannels = append(notificationChannels, notifications.NewEmailChannel(configuration, email))
+	}
+	dispatcher := notifications.NewDispatcher(log, notificationChannels...)
+
 	jobs := []JobHandler{
-		NewCalculateTransactionClustersHandler(log, db, clock),
+		NewCalculateTransactionClustersHandler(log, db, clock, enqueuer),
+		NewCheckBalanceAlertsHandler(log, db, clock),
 		NewCleanupFilesHandler(log, db, clock, fileStorage, enqueuer),
 		NewCleanupJobsHandler(log, db),
+		NewDeliverWebhooksHandler(log, db, clock, kms, webhookClient),
+		NewDeleteAccountHandler(log, db, clock, configuration, kms, plaidPlatypus, tellerClient, fileStorage, billing, email),
+		NewDetectRecurringTransactionsHandler(log, db, clock),
+		NewProcessCSVUploadHandler(log, db, clock, fileStorage, publisher, enqueuer),
 		NewProcessFundingScheduleHandler(log, db, clock),
 		NewProcessOFXUploadHandler(log, db, clock, fileStorage, publisher, enqueuer),
 		NewProcessSpendingHandler(log, db, clock),
 		NewRemoveFileHandler(log, db, clock, fileStorage),
 		NewRemoveLinkHandler(log, db, clock, publisher),
+		NewSendNotificationsHandler(log, db, clock, kms, dispatcher),
+		NewSnapshotBalancesHandler(log, db, clock),
 		NewSyncPlaidAccountsHandler(log, db, clock, kms, plaidPlatypus),
 		NewSyncPlaidHandler(log, db, clock, kms, plaidPlatypus, publisher, enqueuer),
 	}
 
+	// Teller links can only be synced when Teller is configured.
+	if configuration.Teller.GetEnabled() {
+		jobs = append(jobs,
+			NewSyncTellerHandler(log, db, clock, kms, tellerClient, publisher, enqueuer),
+		)
+	}
+
+	// SimpleFIN links are only synced when SimpleFIN has been enabled.
+	if configuration.SimpleFIN.GetEnabled() {
+		jobs = append(jobs,
+			NewSyncSimpleFINHandler(log, db, clock, kms, simpleFINClient, publisher, enqueuer),
+		)
+	}
+
 	// When billing is enabled, periodically perform billing upkeep tasks.
 	if configuration.Stripe.IsBillingEnabled() {
 		jobs = append(jobs,
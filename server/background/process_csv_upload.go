@@ -0,0 +1,619 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/currency"
+	"github.com/monetr/monetr/server/formats"
+	"github.com/monetr/monetr/server/formats/csv"
+	"github.com/monetr/monetr/server/formats/xlsx"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/storage"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ProcessCSVUpload imports all tabular files that are described by a
+	// TransactionUploadMapping, which includes Excel workbooks as well as CSV
+	// files.
+	ProcessCSVUpload = "ProcessCSVUpload"
+)
+
+var (
+	_ JobHandler        = &ProcessCSVUploadHandler{}
+	_ JobImplementation = &ProcessCSVUploadJob{}
+)
+
+type (
+	ProcessCSVUploadHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		publisher    pubsub.Publisher
+		files        storage.Storage
+		enqueuer     JobEnqueuer
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	ProcessCSVUploadArguments struct {
+		AccountId           ID[Account]           `json:"accountId"`
+		BankAccountId       ID[BankAccount]       `json:"bankAccountId"`
+		TransactionUploadId ID[TransactionUpload] `json:"transactionUploadId"`
+		// Sheet is the name of the sheet to import when the upload is an Excel
+		// workbook. If it is blank then the first sheet in the workbook is used.
+		// It is ignored for CSV files.
+		Sheet string `json:"sheet,omitempty"`
+	}
+
+	ProcessCSVUploadJob struct {
+		args      ProcessCSVUploadArguments
+		log       *logrus.Entry
+		repo      repository.BaseRepository
+		files     storage.Storage
+		publisher pubsub.Publisher
+		enqueuer  JobEnqueuer
+		clock     clock.Clock
+		timezone  *time.Location
+
+		upload               *TransactionUpload
+		file                 *File
+		bankAccount          *BankAccount
+		mapping              *TransactionUploadMapping
+		transactions         []Transaction
+		existingTransactions map[string]Transaction
+	}
+)
+
+func NewProcessCSVUploadHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	files storage.Storage,
+	publisher pubsub.Publisher,
+	enqueuer JobEnqueuer,
+) *ProcessCSVUploadHandler {
+	return &ProcessCSVUploadHandler{
+		log:          log,
+		db:           db,
+		publisher:    publisher,
+		files:        files,
+		enqueuer:     enqueuer,
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (h *ProcessCSVUploadHandler) QueueName() string {
+	return ProcessCSVUpload
+}
+
+func (h *ProcessCSVUploadHandler) HandleConsumeJob(
+	ctx context.Context,
+	inLog *logrus.Entry,
+	data []byte,
+) error {
+	var args ProcessCSVUploadArguments
+	if err := errors.Wrap(h.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Processing CSV Upload job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	log := inLog.WithFields(logrus.Fields{
+		"accountId":           args.AccountId,
+		"transactionUploadId": args.TransactionUploadId,
+		"bankAccountId":       args.BankAccountId,
+	})
+
+	updateStatus := func(status TransactionUploadStatus, errorMessage *string) error {
+		return updateTransactionUploadStatus(
+			ctx,
+			h.log,
+			h.db,
+			h.clock,
+			h.publisher,
+			args.AccountId,
+			args.BankAccountId,
+			args.TransactionUploadId,
+			status,
+			errorMessage,
+		)
+	}
+
+	if err := updateStatus(TransactionUploadStatusProcessing, nil); err != nil {
+		return err
+	}
+
+	var err error
+	defer func() {
+		if recovery := recover(); recovery != nil {
+			log.WithError(err).Error("panic processing CSV file upload")
+			_ = updateStatus(TransactionUploadStatusFailed, nil)
+
+			panic(recovery)
+		}
+		if err != nil {
+			log.WithError(err).Error("error processing CSV file upload")
+			errorString := fmt.Sprintf("%s", err)
+			_ = updateStatus(TransactionUploadStatusFailed, &errorString)
+		} else {
+			_ = updateStatus(TransactionUploadStatusComplete, nil)
+		}
+	}()
+	err = h.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		log := log.WithContext(span.Context())
+		repo := repository.NewRepositoryFromSession(h.clock, "user_system", args.AccountId, txn)
+
+		job, err := NewProcessCSVUploadJob(
+			log, repo, h.clock, h.files, h.publisher, h.enqueuer, args,
+		)
+		if err != nil {
+			return err
+		}
+
+		return job.Run(span.Context())
+	})
+
+	// Return the error anyway so we can see failed uploads in sentry.
+	return err
+}
+
+func NewProcessCSVUploadJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	clock clock.Clock,
+	files storage.Storage,
+	publisher pubsub.Publisher,
+	enqueuer JobEnqueuer,
+	args ProcessCSVUploadArguments,
+) (*ProcessCSVUploadJob, error) {
+	return &ProcessCSVUploadJob{
+		args:      args,
+		log:       log,
+		repo:      repo,
+		files:     files,
+		publisher: publisher,
+		enqueuer:  enqueuer,
+		clock:     clock,
+	}, nil
+}
+
+func (j *ProcessCSVUploadJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+	crumbs.AddTag(span.Context(), "bankAccountId", j.args.BankAccountId.String())
+	crumbs.AddTag(span.Context(), "transactionUploadId", j.args.TransactionUploadId.String())
+	crumbs.IncludeUserInScope(span.Context(), j.args.AccountId)
+
+	log := j.log.WithContext(span.Context())
+
+	// No matter what, when we are finished clean up the file.
+	defer func() {
+		if j.file == nil {
+			return
+		}
+
+		now := j.clock.Now()
+		j.file.DeletedAt = &now
+		log.Debug("processing complete, marking file as deleted and queueing removal")
+		if err := j.repo.UpdateFile(span.Context(), j.file); err != nil {
+			log.
+				WithField("fileId", j.file.FileId).
+				WithError(err).
+				Warn("failed to update file with deleted at")
+		}
+
+		j.enqueuer.EnqueueJob(span.Context(), RemoveFile, RemoveFileArguments{
+			AccountId: j.args.AccountId,
+			FileId:    j.file.FileId,
+		})
+	}()
+
+	account, err := j.repo.GetAccount(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account for job")
+		return err
+	}
+
+	j.timezone, err = account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Warn("failed to get account's time zone, defaulting to UTC")
+		j.timezone = time.UTC
+	}
+
+	j.bankAccount, err = j.repo.GetBankAccount(span.Context(), j.args.BankAccountId)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve bank account for file import")
+	}
+
+	j.mapping, err = j.repo.GetTransactionUploadMapping(span.Context(), j.args.BankAccountId)
+	if err != nil {
+		return errors.Wrap(err, "a column mapping is required to import CSV or Excel files")
+	}
+
+	// Load the file and translate each row into a transaction.
+	if err := j.loadFile(span.Context()); err != nil {
+		return err
+	}
+
+	// Pull all of the transactions that already exist in our system from the file
+	// so we can compare.
+	if err := j.hydrateTransactions(span.Context()); err != nil {
+		return err
+	}
+
+	// Push new and updated transactions to the database.
+	if err := j.syncTransactions(span.Context()); err != nil {
+		return err
+	}
+
+	// Also kick off the transaction similarity job.
+	j.enqueuer.EnqueueJob(span.Context(), CalculateTransactionClusters, CalculateTransactionClustersArguments{
+		AccountId:     j.args.AccountId,
+		BankAccountId: j.args.BankAccountId,
+	})
+
+	return nil
+}
+
+// loadFile will read the upload's file from storage and will parse every row
+// using the saved mapping for the bank account.
+func (j *ProcessCSVUploadJob) loadFile(ctx context.Context) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	txnUpload, err := j.repo.GetTransactionUpload(
+		span.Context(),
+		j.args.BankAccountId,
+		j.args.TransactionUploadId,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to process CSV file upload")
+	}
+	j.upload = txnUpload
+
+	file, err := j.repo.GetFile(span.Context(), txnUpload.FileId)
+	if err != nil {
+		return errors.Wrap(err, "could not get file for processing")
+	}
+	j.file = file
+
+	if file.DeletedAt != nil {
+		return errors.New("cannot import transactions from a deleted file")
+	}
+
+	fileReader, _, err := j.files.Read(span.Context(), file.BlobUri)
+	if err != nil {
+		return errors.Wrap(err, "failed to access file from storage")
+	}
+	defer fileReader.Close()
+
+	var parser formats.RowReader
+	switch storage.ContentType(strings.ToLower(file.ContentType)) {
+	case storage.OpenXMLExcelContentType:
+		workbook, err := xlsx.ReadWorkbook(fileReader)
+		if err != nil {
+			return err
+		}
+
+		// Cells that Excel stores as dates or numbers are rendered using the
+		// mapping's date format and decimal separator so they can be parsed the
+		// same way as values stored as text.
+		rows, err := workbook.GetRows(
+			j.args.Sheet,
+			j.mapping.DateFormat,
+			j.mapping.DecimalSeparator,
+			j.mapping.Fields,
+		)
+		if err != nil {
+			return err
+		}
+
+		parser = xlsx.NewXLSXParser(j.mapping.Fields, j.mapping.HeaderRow, rows)
+	default:
+		parser = csv.NewCSVParser(j.mapping.Fields, j.mapping.HeaderRow, fileReader)
+	}
+
+	return j.readRows(span.Context(), parser)
+}
+
+// readRows consumes the provided reader until the end of the file and
+// translates each row into a transaction. Rows that cannot be translated are
+// logged and skipped.
+func (j *ProcessCSVUploadJob) readRows(ctx context.Context, reader formats.RowReader) error {
+	log := j.log.WithContext(ctx)
+
+	// Some exports will contain multiple transactions that are indistinguishable
+	// from one another (same day, same amount, same merchant). When we don't
+	// have a unique ID column we need to derive one, the generator keeps track
+	// of how many times we have seen the same transaction in this file.
+	identifiers := formats.NewIdentifierGenerator()
+	j.transactions = make([]Transaction, 0)
+	for line := 1; ; line++ {
+		row, err := reader.GetNextRow()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to read row %d of file", line)
+		}
+
+		transaction, err := j.translateRow(row, identifiers)
+		if err != nil {
+			log.WithError(err).WithField("line", line).Warn("failed to import row from file, it will be skipped")
+			continue
+		}
+
+		j.transactions = append(j.transactions, *transaction)
+	}
+
+	if len(j.transactions) == 0 {
+		return errors.New("no transactions could be read from the file, make sure the column mapping is correct")
+	}
+
+	return nil
+}
+
+func (j *ProcessCSVUploadJob) translateRow(
+	row formats.Row,
+	identifiers *formats.IdentifierGenerator,
+) (*Transaction, error) {
+	code := j.bankAccount.Currency
+	if rowCurrency := strings.ToUpper(row[formats.FieldCurrencyCodeISO]); rowCurrency != "" && rowCurrency != code {
+		return nil, errors.Errorf("currency of row [%s] does not match the bank account [%s]", rowCurrency, code)
+	}
+
+	date, err := formats.ParseDate(row[formats.FieldDate], j.mapping.DateFormat, j.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := j.parseAmount(row, code)
+	if err != nil {
+		return nil, err
+	}
+
+	name := myownsanity.CoalesceStrings(row[formats.FieldName], row[formats.FieldDescription])
+	originalName := myownsanity.CoalesceStrings(row[formats.FieldDescription], name)
+	if name == "" {
+		return nil, errors.New("transaction does not have a name or description")
+	}
+
+	var isPending bool
+	if value := row[formats.FieldPending]; value != "" {
+		isPending = parseFriendlyBool(value)
+	} else if status := row[formats.FieldStatus]; status != "" {
+		isPending = strings.EqualFold(status, "pending")
+	}
+
+	uploadIdentifier := row[formats.FieldUniqueId]
+	if uploadIdentifier == "" {
+		uploadIdentifier = identifiers.Next(
+			date.Format("2006-01-02"),
+			strconv.FormatInt(amount, 10),
+			originalName,
+		)
+	}
+
+	var categories []string
+	if category := row[formats.FieldCategory]; category != "" {
+		categories = []string{category}
+	}
+
+	transaction := Transaction{
+		AccountId:            j.args.AccountId,
+		BankAccountId:        j.args.BankAccountId,
+		Amount:               amount,
+		Categories:           categories,
+		Date:                 date,
+		Name:                 name,
+		OriginalName:         originalName,
+		OriginalMerchantName: name,
+		IsPending:            isPending,
+		UploadIdentifier:     &uploadIdentifier,
+		Source:               TransactionSourceUpload,
+	}
+	transaction.TransactionId = NewID(&transaction)
+
+	return &transaction, nil
+}
+
+// parseAmount will derive the monetr representation of the transaction amount
+// from the row. monetr uses positive amounts for debits and negative amounts
+// for deposits, most exports are the opposite.
+func (j *ProcessCSVUploadJob) parseAmount(row formats.Row, code string) (int64, error) {
+	parse := func(input string) (int64, bool, error) {
+		cleaned, err := formats.CleanAmount(input, j.mapping.DecimalSeparator)
+		if err != nil {
+			return 0, false, err
+		} else if cleaned == "" {
+			return 0, false, nil
+		}
+		amount, err := currency.ParseFriendlyToAmount(cleaned, code)
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "failed to parse amount [%s]", input)
+		}
+		return amount, true, nil
+	}
+
+	if j.mapping.Fields.Has(formats.FieldAmountCombined) {
+		amount, ok, err := parse(row[formats.FieldAmountCombined])
+		if err != nil {
+			return 0, err
+		} else if !ok {
+			return 0, errors.New("transaction does not have an amount")
+		}
+		return amount * -1, nil
+	}
+
+	// When debits and credits are separate columns, only one of them should
+	// have a value for a given row. Institutions are inconsistent about whether
+	// debits are presented as negative or positive so we only look at the
+	// absolute value and derive the sign from the column itself.
+	debit, hasDebit, err := parse(row[formats.FieldAmountDebit])
+	if err != nil {
+		return 0, err
+	}
+	credit, hasCredit, err := parse(row[formats.FieldAmountCredit])
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case hasDebit && debit != 0:
+		return myownsanity.Abs(debit), nil
+	case hasCredit && credit != 0:
+		return -myownsanity.Abs(credit), nil
+	case hasDebit || hasCredit:
+		return 0, nil
+	default:
+		return 0, errors.New("transaction does not have a debit or credit amount")
+	}
+}
+
+func (j *ProcessCSVUploadJob) hydrateTransactions(ctx context.Context) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	uploadIdentifiers := make([]string, len(j.transactions))
+	for i := range j.transactions {
+		uploadIdentifiers[i] = *j.transactions[i].UploadIdentifier
+	}
+
+	var err error
+	j.existingTransactions, err = j.repo.GetTransactonsByUploadIdentifier(
+		span.Context(),
+		j.args.BankAccountId,
+		uploadIdentifiers,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve existing transactions for upload processing")
+	}
+
+	if count := len(j.existingTransactions); count > 0 {
+		j.log.WithContext(span.Context()).WithFields(logrus.Fields{
+			"existingTransactions": count,
+		}).Debug("found existing transactions for upload")
+	}
+
+	return nil
+}
+
+func (j *ProcessCSVUploadJob) syncTransactions(ctx context.Context) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	log := j.log.WithContext(span.Context())
+
+	rules, err := newTransactionRuleProcessor(
+		span.Context(),
+		log,
+		j.repo,
+		j.args.BankAccountId,
+	)
+	if err != nil {
+		return err
+	}
+
+	transactionsToUpdate := make([]*Transaction, 0)
+	transactionsToCreate := make([]Transaction, 0)
+	for i := range j.transactions {
+		transaction := j.transactions[i]
+		existing, ok := j.existingTransactions[*transaction.UploadIdentifier]
+		if !ok {
+			if err := rules.Process(span.Context(), &transaction); err != nil {
+				return err
+			}
+			transactionsToCreate = append(transactionsToCreate, transaction)
+			// Make sure that if the same unique ID shows up twice in a file we
+			// don't try to create it twice.
+			j.existingTransactions[*transaction.UploadIdentifier] = transaction
+			continue
+		}
+
+		// The only thing we expect to change between exports is a transaction
+		// clearing, when that happens the amount and date may also change.
+		if existing.IsPending != transaction.IsPending ||
+			existing.Amount != transaction.Amount ||
+			!existing.Date.Equal(transaction.Date) {
+			existing.IsPending = transaction.IsPending
+			existing.Amount = transaction.Amount
+			existing.Date = transaction.Date
+			transactionsToUpdate = append(transactionsToUpdate, &existing)
+		}
+	}
+
+	// Persist any new transactions.
+	if count := len(transactionsToCreate); count > 0 {
+		log.WithField("new", count).Info("creating new transactions from import")
+		if err := j.repo.InsertTransactions(span.Context(), transactionsToCreate); err != nil {
+			return errors.Wrap(err, "failed to persist new transactions")
+		}
+
+		if err := rules.Flush(span.Context()); err != nil {
+			return err
+		}
+
+		if err := emitTransactionWebhookEvents(
+			span.Context(),
+			log,
+			j.repo,
+			j.clock,
+			WebhookEventTransactionCreated,
+			transactionsToCreate,
+		); err != nil {
+			return err
+		}
+	}
+
+	// If there are any updated transactions persist those as well.
+	if count := len(transactionsToUpdate); count > 0 {
+		log.WithField("updated", count).Info("updating transactions from import")
+		if err := j.repo.UpdateTransactions(span.Context(), transactionsToUpdate); err != nil {
+			return errors.Wrap(err, "failed to update transactions")
+		}
+
+		if err := emitTransactionWebhookEvents(
+			span.Context(),
+			log,
+			j.repo,
+			j.clock,
+			WebhookEventTransactionUpdated,
+			transactionsToUpdate,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseFriendlyBool is used for columns that indicate a yes/no value, but that
+// might not be formatted in a way that strconv can handle on its own.
+func parseFriendlyBool(input string) bool {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "y", "yes", "pending":
+		return true
+	}
+
+	value, _ := strconv.ParseBool(input)
+	return value
+}
@@ -0,0 +1,351 @@
+package background
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/formats"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// nopReadSeekCloser is used to store test files that are held in memory.
+type nopReadSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopReadSeekCloser) Close() error { return nil }
+
+// givenIHaveACSVUpload stores the provided contents as a CSV file and creates
+// a transaction upload for it, the same way the upload endpoint does. It
+// returns the arguments for the job that would be enqueued.
+func givenIHaveACSVUpload(
+	t *testing.T,
+	repo repository.BaseRepository,
+	files storage.Storage,
+	bankAccount models.BankAccount,
+	contents string,
+) ProcessCSVUploadArguments {
+	ctx := context.Background()
+	uri, err := files.Store(ctx, nopReadSeekCloser{strings.NewReader(contents)}, storage.FileInfo{
+		Name:        "transactions.csv",
+		Kind:        models.TransactionUpload{}.FileKind(),
+		AccountId:   bankAccount.AccountId,
+		ContentType: storage.TextCSVContentType,
+	})
+	require.NoError(t, err, "must be able to store the file")
+
+	file := models.File{
+		Name:        "transactions.csv",
+		ContentType: string(storage.TextCSVContentType),
+		Size:        uint64(len(contents)),
+		BlobUri:     uri,
+	}
+	require.NoError(t, repo.CreateFile(ctx, &file), "must be able to create the file")
+
+	upload := models.TransactionUpload{
+		FileId: file.FileId,
+		Status: models.TransactionUploadStatusPending,
+	}
+	require.NoError(t, repo.CreateTransactionUpload(
+		ctx,
+		bankAccount.BankAccountId,
+		&upload,
+	), "must be able to create the transaction upload")
+
+	return ProcessCSVUploadArguments{
+		AccountId:           bankAccount.AccountId,
+		BankAccountId:       bankAccount.BankAccountId,
+		TransactionUploadId: upload.TransactionUploadId,
+	}
+}
+
+// runCSVUploadJob runs the CSV upload job for the provided arguments and
+// returns the transactions in the bank account afterwards keyed by name.
+func runCSVUploadJob(
+	t *testing.T,
+	clock clock.Clock,
+	repo repository.BaseRepository,
+	files storage.Storage,
+	args ProcessCSVUploadArguments,
+) map[string]models.Transaction {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := testutils.GetLog(t)
+	db := testutils.GetPgDatabase(t)
+	publisher := pubsub.NewPostgresPubSub(log, db)
+
+	enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+	enqueuer.EXPECT().
+		EnqueueJob(
+			gomock.Any(),
+			gomock.Eq(RemoveFile),
+			gomock.Any(),
+		).
+		Times(1).
+		Return(nil)
+	enqueuer.EXPECT().
+		EnqueueJob(
+			gomock.Any(),
+			gomock.Eq(CalculateTransactionClusters),
+			testutils.NewGenericMatcher(func(arguments CalculateTransactionClustersArguments) bool {
+				return assert.Equal(t, args.BankAccountId, arguments.BankAccountId)
+			}),
+		).
+		Times(1).
+		Return(nil)
+
+	job, err := NewProcessCSVUploadJob(
+		log,
+		repo,
+		clock,
+		files,
+		publisher,
+		enqueuer,
+		args,
+	)
+	require.NoError(t, err, "must be able to create the job")
+	require.NoError(t, job.Run(context.Background()), "must process the upload")
+
+	transactions, err := repo.GetTransactions(context.Background(), args.BankAccountId, 100, 0)
+	require.NoError(t, err, "must be able to retrieve transactions")
+	result := map[string]models.Transaction{}
+	for _, transaction := range transactions {
+		result[transaction.Name] = transaction
+	}
+	return result
+}
+
+func TestProcessCSVUploadJob_Run(t *testing.T) {
+	givenIHaveABankAccount := func(t *testing.T, clock clock.Clock) (repository.BaseRepository, storage.Storage, models.BankAccount, *time.Location) {
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(
+			t,
+			clock,
+			&link,
+			models.DepositoryBankAccountType,
+			models.CheckingBankAccountSubType,
+		)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+		repo := repository.NewRepositoryFromSession(
+			clock,
+			user.UserId,
+			user.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		files, err := storage.NewFilesystemStorage(testutils.GetLog(t), t.TempDir())
+		require.NoError(t, err, "must be able to create file storage")
+		return repo, files, bankAccount, timezone
+	}
+
+	givenIHaveAMapping := func(t *testing.T, repo repository.BaseRepository, bankAccount models.BankAccount, mapping models.TransactionUploadMapping) {
+		require.NoError(t, repo.SaveTransactionUploadMapping(
+			context.Background(),
+			bankAccount.BankAccountId,
+			&mapping,
+		), "must be able to save the mapping")
+	}
+
+	t.Run("separate debit and credit columns", func(t *testing.T) {
+		clock := clock.NewMock()
+		repo, files, bankAccount, timezone := givenIHaveABankAccount(t, clock)
+		givenIHaveAMapping(t, repo, bankAccount, models.TransactionUploadMapping{
+			Fields: formats.FieldIndex{
+				formats.FieldDate,
+				formats.FieldName,
+				formats.FieldAmountDebit,
+				formats.FieldAmountCredit,
+			},
+			HeaderRow:        true,
+			DateFormat:       "2006-01-02",
+			DecimalSeparator: formats.DecimalSeparatorPeriod,
+		})
+
+		args := givenIHaveACSVUpload(t, repo, files, bankAccount, strings.Join([]string{
+			"Date,Description,Debit,Credit",
+			"2024-03-01,Coffee,4.50,",
+			// Some institutions present debits as negative numbers, the sign is
+			// derived from the column instead.
+			"2024-03-02,Groceries,-52.10,",
+			"2024-03-03,Paycheck,,1500.00",
+		}, "\n"))
+
+		transactions := runCSVUploadJob(t, clock, repo, files, args)
+		require.Len(t, transactions, 3, "should have created every transaction")
+		assert.EqualValues(t, 450, transactions["Coffee"].Amount, "debits should be positive")
+		assert.EqualValues(t, 5210, transactions["Groceries"].Amount, "negative debits should still be positive")
+		assert.EqualValues(t, -150000, transactions["Paycheck"].Amount, "credits should be negative")
+		assert.Equal(t, models.TransactionSourceUpload, transactions["Coffee"].Source)
+		assert.Equal(t, "2024-03-03", transactions["Paycheck"].Date.In(timezone).Format("2006-01-02"))
+	})
+
+	t.Run("single signed amount column", func(t *testing.T) {
+		clock := clock.NewMock()
+		repo, files, bankAccount, _ := givenIHaveABankAccount(t, clock)
+		givenIHaveAMapping(t, repo, bankAccount, models.TransactionUploadMapping{
+			Fields: formats.FieldIndex{
+				formats.FieldDate,
+				formats.FieldName,
+				formats.FieldIgnore,
+				formats.FieldAmountCombined,
+			},
+			HeaderRow:        true,
+			DecimalSeparator: formats.DecimalSeparatorPeriod,
+		})
+
+		args := givenIHaveACSVUpload(t, repo, files, bankAccount, strings.Join([]string{
+			"Date,Description,Balance,Amount",
+			"03/01/2024,Coffee,100.00,-4.50",
+			"03/02/2024,Rent,90.00,\"-1,250.00\"",
+			"03/03/2024,Refund,95.00,$5.00",
+		}, "\n"))
+
+		transactions := runCSVUploadJob(t, clock, repo, files, args)
+		require.Len(t, transactions, 3, "should have created every transaction")
+		assert.EqualValues(t, 450, transactions["Coffee"].Amount, "negative amounts are debits")
+		assert.EqualValues(t, 125000, transactions["Rent"].Amount, "thousands separators should be ignored")
+		assert.EqualValues(t, -500, transactions["Refund"].Amount, "positive amounts are credits")
+	})
+
+	t.Run("decimal comma", func(t *testing.T) {
+		clock := clock.NewMock()
+		repo, files, bankAccount, _ := givenIHaveABankAccount(t, clock)
+		givenIHaveAMapping(t, repo, bankAccount, models.TransactionUploadMapping{
+			Fields: formats.FieldIndex{
+				formats.FieldDate,
+				formats.FieldName,
+				formats.FieldAmountCombined,
+			},
+			HeaderRow:        false,
+			DecimalSeparator: formats.DecimalSeparatorComma,
+		})
+
+		args := givenIHaveACSVUpload(t, repo, files, bankAccount, strings.Join([]string{
+			"2024-03-01,Coffee,\"-0,500\"",
+			"2024-03-02,Rent,\"-1.250,00\"",
+		}, "\n"))
+
+		transactions := runCSVUploadJob(t, clock, repo, files, args)
+		require.Len(t, transactions, 2, "should have created every transaction")
+		assert.EqualValues(t, 50, transactions["Coffee"].Amount, "comma should be the decimal separator")
+		assert.EqualValues(t, 125000, transactions["Rent"].Amount, "periods should be thousands separators")
+	})
+
+	t.Run("date formats", func(t *testing.T) {
+		clock := clock.NewMock()
+		repo, files, bankAccount, timezone := givenIHaveABankAccount(t, clock)
+		// Day first dates are ambiguous, so they are only parsed correctly when
+		// the mapping specifies the layout.
+		givenIHaveAMapping(t, repo, bankAccount, models.TransactionUploadMapping{
+			Fields: formats.FieldIndex{
+				formats.FieldDate,
+				formats.FieldName,
+				formats.FieldAmountCombined,
+			},
+			HeaderRow:        true,
+			DateFormat:       "02/01/2006",
+			DecimalSeparator: formats.DecimalSeparatorPeriod,
+		})
+
+		args := givenIHaveACSVUpload(t, repo, files, bankAccount, strings.Join([]string{
+			"Date,Description,Amount",
+			"03/04/2024,Coffee,-4.50",
+			"25/12/2024,Gift,-20.00",
+			// Rows with dates that do not match the layout are skipped.
+			"2024-12-26,Skipped,-1.00",
+		}, "\n"))
+
+		transactions := runCSVUploadJob(t, clock, repo, files, args)
+		require.Len(t, transactions, 2, "the row with an invalid date should be skipped")
+		assert.Equal(t, "2024-04-03", transactions["Coffee"].Date.In(timezone).Format("2006-01-02"), "date should be day first")
+		assert.Equal(t, "2024-12-25", transactions["Gift"].Date.In(timezone).Format("2006-01-02"))
+		assert.NotContains(t, transactions, "Skipped")
+	})
+
+	t.Run("dedupe by unique id", func(t *testing.T) {
+		clock := clock.NewMock()
+		repo, files, bankAccount, _ := givenIHaveABankAccount(t, clock)
+		givenIHaveAMapping(t, repo, bankAccount, models.TransactionUploadMapping{
+			Fields: formats.FieldIndex{
+				formats.FieldUniqueId,
+				formats.FieldDate,
+				formats.FieldName,
+				formats.FieldAmountCombined,
+				formats.FieldStatus,
+			},
+			HeaderRow:        true,
+			DecimalSeparator: formats.DecimalSeparatorPeriod,
+		})
+
+		{ // Initial upload with a pending transaction
+			args := givenIHaveACSVUpload(t, repo, files, bankAccount, strings.Join([]string{
+				"Id,Date,Description,Amount,Status",
+				"txn_1,2024-03-01,Coffee,-4.50,Posted",
+				"txn_2,2024-03-02,Restaurant,-40.00,Pending",
+			}, "\n"))
+			transactions := runCSVUploadJob(t, clock, repo, files, args)
+			require.Len(t, transactions, 2, "should have created every transaction")
+			assert.Equal(t, "txn_2", *transactions["Restaurant"].UploadIdentifier)
+			assert.True(t, transactions["Restaurant"].IsPending, "transaction should be pending")
+		}
+
+		{ // Uploading an overlapping export should update instead of duplicate
+			args := givenIHaveACSVUpload(t, repo, files, bankAccount, strings.Join([]string{
+				"Id,Date,Description,Amount,Status",
+				"txn_1,2024-03-01,Coffee,-4.50,Posted",
+				"txn_2,2024-03-03,Restaurant,-48.00,Posted",
+				"txn_3,2024-03-04,Gas,-30.00,Posted",
+			}, "\n"))
+			transactions := runCSVUploadJob(t, clock, repo, files, args)
+			require.Len(t, transactions, 3, "only the new transaction should be created")
+			assert.False(t, transactions["Restaurant"].IsPending, "transaction should have cleared")
+			assert.EqualValues(t, 4800, transactions["Restaurant"].Amount, "amount should be updated when it clears")
+		}
+	})
+
+	t.Run("dedupe without a unique id", func(t *testing.T) {
+		clock := clock.NewMock()
+		repo, files, bankAccount, _ := givenIHaveABankAccount(t, clock)
+		givenIHaveAMapping(t, repo, bankAccount, models.TransactionUploadMapping{
+			Fields: formats.FieldIndex{
+				formats.FieldDate,
+				formats.FieldDescription,
+				formats.FieldAmountCombined,
+			},
+			HeaderRow:        true,
+			DecimalSeparator: formats.DecimalSeparatorPeriod,
+		})
+
+		// Two identical rows are two separate transactions, they must both be
+		// imported once and not be duplicated when the file is uploaded again.
+		contents := strings.Join([]string{
+			"Date,Description,Amount",
+			"2024-03-01,Coffee,-4.50",
+			"2024-03-01,Coffee,-4.50",
+			"2024-03-02,Groceries,-52.10",
+		}, "\n")
+
+		for i := 0; i < 2; i++ {
+			args := givenIHaveACSVUpload(t, repo, files, bankAccount, contents)
+			runCSVUploadJob(t, clock, repo, files, args)
+
+			transactions, err := repo.GetTransactions(context.Background(), bankAccount.BankAccountId, 100, 0)
+			require.NoError(t, err, "must be able to retrieve transactions")
+			assert.Len(t, transactions, 3, "identical rows should be kept, but never duplicated")
+		}
+	})
+}
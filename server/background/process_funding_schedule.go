@@ -2,13 +2,17 @@ package background
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/benbjohnson/clock"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-pg/pg/v10"
 	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
 	. "github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -147,6 +151,16 @@ type ProcessFundingScheduleArguments struct {
 	FundingScheduleIds []ID[FundingSchedule] `json:"fundingScheduleIds"`
 }
 
+// pendingContribution is a spending object that should receive a contribution
+// from one of the funding schedules being processed.
+type pendingContribution struct {
+	spending        Spending
+	fundingSchedule *FundingSchedule
+	// depositId is the deposit that triggered the funding schedule, if the
+	// funding schedule waits for a deposit.
+	depositId *ID[Transaction]
+}
+
 type ProcessFundingScheduleJob struct {
 	args  ProcessFundingScheduleArguments
 	log   *logrus.Entry
@@ -173,11 +187,18 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	contributions := make([]pendingContribution, 0)
 	expensesToUpdate := make([]Spending, 0)
+	allocations := make([]SpendingAllocation, 0)
+	notifications := make([]Notification, 0)
+	today := util.Midnight(p.clock.Now(), timezone).Format("2006-01-02")
 
+	// The free-to-use balance is needed to know how much can actually be
+	// allocated to spending objects.
 	initialBalances, err := p.repo.GetBalances(ctx, p.args.BankAccountId)
 	if err != nil {
-		log.WithError(err).Warn("failed to retrieve initial balances")
+		log.WithError(err).Error("failed to retrieve initial balances")
+		return err
 	}
 
 	for _, fundingScheduleId := range p.args.FundingScheduleIds {
@@ -191,31 +212,66 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 			return err
 		}
 
-		// If this funding schedule requires waiting for a deposit to process then check to see if there are any.
-		// TODO This approach is not going to scale well, if people were to create funding schedules with wait for
-		//  deposit enabled. But then they never receive a deposit, or maybe the plaid link isn't active anymore, or
-		//  some other scenario. We would continue to try and process these over and over again.
+		now := p.clock.Now()
+		// If this funding schedule requires waiting for a deposit then it is only
+		// processed once a deposit that satisfies its deposit matcher arrives
+		// within the tolerance window around the next recurrence. The deposit can
+		// arrive before the next recurrence, in which case the funding schedule is
+		// processed early.
+		var depositId *ID[Transaction]
 		if fundingSchedule.WaitForDeposit {
-			log.Info("funding schedule requires a deposit to be present before processing")
-			// TODO Eventually this should be moved out of the for loop.
-			// TODO Maybe this could just be a count? Idk what I'd like to use these transactions for in the future.
-			deposits, err := p.repo.GetRecentDepositTransactions(span.Context(), p.args.BankAccountId)
+			start, end := fundingSchedule.GetDepositWindow(timezone)
+			fundingLog = fundingLog.WithFields(logrus.Fields{
+				"depositWindowStart": start,
+				"depositWindowEnd":   end,
+			})
+			deposits, err := p.repo.GetDepositTransactionsBetween(span.Context(), p.args.BankAccountId, start, end)
 			if err != nil {
-				fundingLog.WithError(err).Error("failed to retrieve recent deposits to process funding schedule")
+				fundingLog.WithError(err).Error("failed to retrieve deposits to process funding schedule")
 				return err
 			}
 
-			// If there were any deposits then process the funding schedule, if there were not any deposits then do
-			// nothing.
-			if count := len(deposits); count > 0 {
-				fundingLog.WithField("count", count).Info("found deposits in the last 24 hours")
-			} else {
-				fundingLog.Info("did not find any deposits in the past 24 hours, funding schedule will not be processed")
+			for i := range deposits {
+				deposit := deposits[i]
+				// Don't fund twice from the same deposit if the windows of two
+				// occurrences overlap.
+				if fundingSchedule.LastDepositTransactionId != nil &&
+					*fundingSchedule.LastDepositTransactionId == deposit.TransactionId {
+					continue
+				}
+
+				if fundingSchedule.MatchesDeposit(deposit, timezone) {
+					depositId = &deposit.TransactionId
+					break
+				}
+			}
+
+			switch {
+			case depositId != nil:
+				fundingLog.WithField("transactionId", *depositId).Info("found matching deposit for funding schedule")
+				fundingSchedule.LastDepositTransactionId = depositId
+				// The deposit may have arrived before the next recurrence, the funding
+				// schedule should still move on to the following occurrence.
+				now = myownsanity.MaxTime(now, fundingSchedule.NextRecurrence)
+			case now.Before(end):
+				fundingLog.Info("waiting for a matching deposit, funding schedule will not be processed yet")
+				continue
+			default:
+				// The window has passed without a matching deposit, move on to the next
+				// occurrence so that this funding schedule is not checked forever.
+				fundingLog.Warn("no matching deposit arrived within the tolerance window, funding will be skipped")
+				if !fundingSchedule.CalculateNextOccurrence(span.Context(), now, timezone) {
+					continue
+				}
+				if err = p.repo.UpdateFundingSchedule(span.Context(), fundingSchedule); err != nil {
+					fundingLog.WithError(err).Error("failed to update the funding schedule with the updated next recurrence")
+					return err
+				}
 				continue
 			}
 		}
 
-		if !fundingSchedule.CalculateNextOccurrence(span.Context(), p.clock.Now(), timezone) {
+		if !fundingSchedule.CalculateNextOccurrence(span.Context(), now, timezone) {
 			crumbs.IndicateBug(span.Context(), "bug: funding schedule for processing occurs in the future", map[string]interface{}{
 				"nextOccurrence": fundingSchedule.NextRecurrence,
 			})
@@ -267,31 +323,116 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 					continue
 				}
 
-				// TODO Take free-to-use into account when allocating to expenses.
-				//  As of writing this I am not going to consider that balance. I'm going to assume that the user has
-				//  enough money in their account at the time of this running that this will accurately reflect a real
-				//  allocated balance. This can be impacted though by a delay in a deposit showing in Plaid and thus us
-				//  over-allocating temporarily until the deposit shows properly in Plaid.
-				spending.CurrentAmount += spending.NextContributionAmount
-				if err = (&spending).CalculateNextContribution(
-					span.Context(),
-					account.Timezone,
-					fundingSchedule,
-					p.clock.Now(),
-				); err != nil {
-					crumbs.Error(span.Context(), "Failed to calculate next contribution for spending", "spending", map[string]interface{}{
-						"fundingScheduleId": fundingScheduleId,
-						"spendingId":        spending.SpendingId,
-					})
-					spendingLog.WithError(err).Error("failed to calculate next contribution for spending")
-					return err
-				}
-
-				expensesToUpdate = append(expensesToUpdate, spending)
+				contributions = append(contributions, pendingContribution{
+					spending:        spending,
+					fundingSchedule: fundingSchedule,
+					depositId:       depositId,
+				})
 			}
 		}
 	}
 
+	// Spending objects are funded in order of their priority. Within the same
+	// priority the spending object that is needed soonest is funded first.
+	sort.SliceStable(contributions, func(i, j int) bool {
+		a, b := contributions[i].spending, contributions[j].spending
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if !a.NextRecurrence.Equal(b.NextRecurrence) {
+			return a.NextRecurrence.Before(b.NextRecurrence)
+		}
+		return a.SpendingId < b.SpendingId
+	})
+
+	// Only allocate what is actually free-to-use, if there isn't enough for
+	// every spending object then the lower priority spending objects are left
+	// under-funded instead of driving free-to-use negative.
+	available := myownsanity.Max(0, initialBalances.Free)
+	for _, item := range contributions {
+		spending := item.spending
+		spendingLog := log.WithFields(logrus.Fields{
+			"fundingScheduleId": spending.FundingScheduleId,
+			"spendingId":        spending.SpendingId,
+		})
+
+		contribution := myownsanity.Min(spending.NextContributionAmount, available)
+		available -= contribution
+		spending.CurrentAmount += contribution
+		spending.ContributionShortfall = spending.NextContributionAmount - contribution
+		if err = (&spending).CalculateNextContribution(
+			span.Context(),
+			account.Timezone,
+			item.fundingSchedule,
+			p.clock.Now(),
+		); err != nil {
+			crumbs.Error(span.Context(), "Failed to calculate next contribution for spending", "spending", map[string]interface{}{
+				"fundingScheduleId": spending.FundingScheduleId,
+				"spendingId":        spending.SpendingId,
+			})
+			spendingLog.WithError(err).Error("failed to calculate next contribution for spending")
+			return err
+		}
+
+		if spending.ContributionShortfall > 0 {
+			// Even if the spending object has enough for now, it did not receive
+			// what it needed to stay on track.
+			spending.IsBehind = true
+			crumbs.Debug(span.Context(), "Not enough free-to-use to fully fund spending object", map[string]interface{}{
+				"fundingScheduleId": spending.FundingScheduleId,
+				"spendingId":        spending.SpendingId,
+				"shortfall":         spending.ContributionShortfall,
+			})
+			spendingLog.WithField("shortfall", spending.ContributionShortfall).
+				Info("not enough free-to-use to fully fund spending object")
+			notifications = append(notifications, Notification{
+				BankAccountId: &p.args.BankAccountId,
+				Kind:          NotificationKindExpenseFundedShort,
+				DedupeKey:     fmt.Sprintf("%s:%s:%s", NotificationKindExpenseFundedShort, spending.SpendingId, today),
+				Title:         fmt.Sprintf("%s was funded short", spending.Name),
+				Message: fmt.Sprintf(
+					"There was not enough free-to-use to fully fund %s, it did not receive its full contribution and is now behind.",
+					spending.Name,
+				),
+				Amount: &spending.ContributionShortfall,
+				Data: map[string]string{
+					"spendingId":        spending.SpendingId.String(),
+					"fundingScheduleId": spending.FundingScheduleId.String(),
+				},
+			})
+		}
+
+		if spending.SpendingType == SpendingTypeGoal &&
+			contribution > 0 &&
+			spending.GetProgressAmount() >= spending.TargetAmount {
+			spendingLog.Info("goal has been fully funded")
+			notifications = append(notifications, Notification{
+				BankAccountId: &p.args.BankAccountId,
+				Kind:          NotificationKindGoalReached,
+				// Include the target amount, if the goal is raised later on then it
+				// can be reached again.
+				DedupeKey: fmt.Sprintf("%s:%s:%d", NotificationKindGoalReached, spending.SpendingId, spending.TargetAmount),
+				Title:     fmt.Sprintf("%s has been reached", spending.Name),
+				Message:   fmt.Sprintf("Your goal %s has been fully funded.", spending.Name),
+				Amount:    myownsanity.Int64P(spending.TargetAmount),
+				Data: map[string]string{
+					"spendingId": spending.SpendingId.String(),
+				},
+			})
+		}
+
+		expensesToUpdate = append(expensesToUpdate, spending)
+		allocation := NewSpendingAllocation(
+			&spending,
+			SpendingAllocationKindContribution,
+			SpendingAllocationSourceJob,
+			contribution,
+		)
+		allocation.FundingScheduleId = &item.fundingSchedule.FundingScheduleId
+		allocation.TransactionId = item.depositId
+		allocations = append(allocations, allocation)
+	}
+
 	if len(expensesToUpdate) == 0 {
 		crumbs.Debug(span.Context(), "No spending objects to update for funding schedule", nil)
 		log.Info("no spending objects to update for funding schedule")
@@ -309,9 +450,53 @@ func (p *ProcessFundingScheduleJob) Run(ctx context.Context) error {
 		return err
 	}
 
+	if err = p.repo.CreateSpendingAllocations(span.Context(), p.args.BankAccountId, allocations); err != nil {
+		log.WithError(err).Error("failed to record spending allocations")
+		return err
+	}
+
+	for i := range expensesToUpdate {
+		if allocations[i].Amount <= 0 {
+			continue
+		}
+
+		if err = EmitWebhookEvent(
+			span.Context(),
+			log,
+			p.repo,
+			p.clock,
+			WebhookEventSpendingFunded,
+			SpendingFundedEvent{
+				BankAccountId:     p.args.BankAccountId,
+				FundingScheduleId: expensesToUpdate[i].FundingScheduleId,
+				Contribution:      allocations[i].Amount,
+				Spending:          expensesToUpdate[i],
+			},
+		); err != nil {
+			log.WithError(err).Error("failed to emit spending funded webhook event")
+			return err
+		}
+	}
+
 	updatedBalances, err := p.repo.GetBalances(ctx, p.args.BankAccountId)
 	if err != nil {
 		log.WithError(err).Warn("failed to retrieve updated balances")
+		return err
+	}
+
+	if updatedBalances.Free < 0 {
+		notifications = append(notifications, newNegativeFreeToUseNotification(
+			p.args.BankAccountId,
+			updatedBalances.Free,
+			today,
+		))
+	}
+
+	for _, notification := range notifications {
+		if err = CreateNotification(span.Context(), log, p.repo, notification); err != nil {
+			log.WithError(err).Error("failed to create notification")
+			return err
+		}
 	}
 
 	// Trying to determine how often balances go negative.
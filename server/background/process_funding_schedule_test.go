@@ -7,6 +7,7 @@ import (
 
 	"github.com/benbjohnson/clock"
 	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/myownsanity"
 	"github.com/monetr/monetr/server/internal/testutils"
 	. "github.com/monetr/monetr/server/models"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +23,9 @@ func TestProcessFundingScheduleJob_Run(t *testing.T) {
 		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
 		link := fixtures.GivenIHaveAPlaidLink(t, clock, user)
 		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		// Make sure there is enough free-to-use for the contribution.
+		bankAccount.AvailableBalance = 100000
+		testutils.MustDBUpdate(t, &bankAccount)
 		timezone := testutils.MustEz(t, user.Account.GetTimezone)
 
 		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &bankAccount, "FREQ=WEEKLY;INTERVAL=1;BYDAY=FR", false)
@@ -83,6 +87,187 @@ func TestProcessFundingScheduleJob_Run(t *testing.T) {
 		updatedSpending := testutils.MustDBRead(t, spending)
 		assert.EqualValues(t, spending.CurrentAmount+spending.NextContributionAmount, updatedSpending.CurrentAmount, "current amount should have been incremented")
 		assert.Greater(t, updatedSpending.NextContributionAmount, int64(0), "next contribution must be greater than 0")
+
+		var allocations []SpendingAllocation
+		err = db.Model(&allocations).
+			Where(`"spending_allocation"."spending_id" = ?`, spending.SpendingId).
+			Select(&allocations)
+		require.NoError(t, err, "must be able to read the allocation ledger")
+		require.Len(t, allocations, 1, "should have recorded a single contribution")
+		assert.Equal(t, SpendingAllocationKindContribution, allocations[0].Kind)
+		assert.Equal(t, SpendingAllocationSourceJob, allocations[0].Source)
+		assert.EqualValues(t, spending.NextContributionAmount, allocations[0].Amount)
+		assert.EqualValues(t, updatedSpending.CurrentAmount, allocations[0].CurrentAmount)
+		assert.Equal(t, &fundingSchedule.FundingScheduleId, allocations[0].FundingScheduleId)
+	})
+
+	t.Run("funds by priority when free to use is insufficient", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, hook := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAPlaidLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		// Only enough for one and a half contributions.
+		bankAccount.AvailableBalance = 150
+		testutils.MustDBUpdate(t, &bankAccount)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &bankAccount, "FREQ=WEEKLY;INTERVAL=1;BYDAY=FR", false)
+		for fundingSchedule.NextRecurrence.After(clock.Now()) {
+			fundingSchedule.NextRecurrence = fundingSchedule.NextRecurrence.AddDate(0, 0, -7)
+		}
+		testutils.MustDBUpdate(t, fundingSchedule)
+
+		spendingRule := testutils.RuleToSet(t, timezone, "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", clock.Now())
+		nextDue := spendingRule.After(clock.Now(), false)
+
+		newSpending := func(name string, priority int) Spending {
+			spending := Spending{
+				AccountId:              user.AccountId,
+				BankAccountId:          bankAccount.BankAccountId,
+				FundingScheduleId:      fundingSchedule.FundingScheduleId,
+				SpendingType:           SpendingTypeExpense,
+				Name:                   name,
+				TargetAmount:           1000,
+				CurrentAmount:          0,
+				RuleSet:                spendingRule,
+				NextRecurrence:         nextDue,
+				NextContributionAmount: 100,
+				Priority:               priority,
+				CreatedAt:              clock.Now(),
+			}
+			testutils.MustDBInsert(t, &spending)
+			return spending
+		}
+		// Create the lower priority one first to make sure the order comes from the
+		// priority and not the order they were created in.
+		low := newSpending("Vacation", 5)
+		high := newSpending("Rent", 1)
+
+		handler := NewProcessFundingScheduleHandler(log, db, clock)
+		args := ProcessFundingScheduleArguments{
+			AccountId:     fundingSchedule.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+			FundingScheduleIds: []ID[FundingSchedule]{
+				fundingSchedule.FundingScheduleId,
+			},
+		}
+
+		argsEncoded, err := DefaultJobMarshaller(args)
+		assert.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should run job successfully")
+		testutils.MustHaveLogMessage(t, hook, "not enough free-to-use to fully fund spending object")
+
+		updatedHigh := testutils.MustDBRead(t, high)
+		assert.EqualValues(t, 100, updatedHigh.CurrentAmount, "higher priority spending should be fully funded")
+		assert.EqualValues(t, 0, updatedHigh.ContributionShortfall, "higher priority spending should not have a shortfall")
+
+		updatedLow := testutils.MustDBRead(t, low)
+		assert.EqualValues(t, 50, updatedLow.CurrentAmount, "lower priority spending should only get what is left")
+		assert.EqualValues(t, 50, updatedLow.ContributionShortfall, "lower priority spending should record the shortfall")
+		assert.True(t, updatedLow.IsBehind, "lower priority spending should be behind")
+	})
+
+	t.Run("waits for a matching deposit", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, hook := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAPlaidLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		bankAccount.AvailableBalance = 100000
+		testutils.MustDBUpdate(t, &bankAccount)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, clock, &bankAccount, "FREQ=WEEKLY;INTERVAL=1;BYDAY=FR", false)
+		for fundingSchedule.NextRecurrence.After(clock.Now()) {
+			fundingSchedule.NextRecurrence = fundingSchedule.NextRecurrence.AddDate(0, 0, -7)
+		}
+		fundingSchedule.WaitForDeposit = true
+		fundingSchedule.DepositNamePattern = myownsanity.StringP("payroll")
+		fundingSchedule.DepositMinimumAmount = myownsanity.Int64P(50000)
+		fundingSchedule.DepositToleranceDays = 1
+		testutils.MustDBUpdate(t, fundingSchedule)
+		// Still within the deposit window for the funding schedule.
+		clock.Set(fundingSchedule.NextRecurrence.Add(time.Hour))
+
+		spendingRule := testutils.RuleToSet(t, timezone, "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", clock.Now())
+		spending := Spending{
+			AccountId:              user.AccountId,
+			BankAccountId:          bankAccount.BankAccountId,
+			FundingScheduleId:      fundingSchedule.FundingScheduleId,
+			SpendingType:           SpendingTypeExpense,
+			Name:                   "Rent",
+			TargetAmount:           1000,
+			CurrentAmount:          0,
+			RuleSet:                spendingRule,
+			NextRecurrence:         spendingRule.After(clock.Now(), false),
+			NextContributionAmount: 100,
+			CreatedAt:              clock.Now(),
+		}
+		testutils.MustDBInsert(t, &spending)
+
+		newDeposit := func(name string, amount int64) Transaction {
+			transaction := fixtures.GivenIHaveATransaction(t, clock, bankAccount)
+			transaction.Name = name
+			transaction.OriginalName = name
+			transaction.Amount = amount
+			transaction.Date = fundingSchedule.NextRecurrence
+			testutils.MustDBUpdate(t, &transaction)
+			return transaction
+		}
+
+		handler := NewProcessFundingScheduleHandler(log, db, clock)
+		args := ProcessFundingScheduleArguments{
+			AccountId:     fundingSchedule.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+			FundingScheduleIds: []ID[FundingSchedule]{
+				fundingSchedule.FundingScheduleId,
+			},
+		}
+		argsEncoded, err := DefaultJobMarshaller(args)
+		assert.NoError(t, err, "must be able to marshal arguments")
+
+		{ // A deposit that does not match should not trigger funding.
+			newDeposit("VENMO CASHOUT", -100000)
+			newDeposit("ACME CORP PAYROLL", -2000)
+
+			err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+			assert.NoError(t, err, "should run job successfully")
+			testutils.MustHaveLogMessage(t, hook, "waiting for a matching deposit, funding schedule will not be processed yet")
+
+			updatedSpending := testutils.MustDBRead(t, spending)
+			assert.EqualValues(t, 0, updatedSpending.CurrentAmount, "spending should not be funded yet")
+			updatedFundingSchedule := testutils.MustDBRead(t, *fundingSchedule)
+			assert.Equal(t, fundingSchedule.NextRecurrence, updatedFundingSchedule.NextRecurrence, "funding schedule should not advance")
+		}
+
+		{ // Once the paycheck arrives the funding schedule should be processed.
+			paycheck := newDeposit("ACME CORP PAYROLL", -150000)
+
+			err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+			assert.NoError(t, err, "should run job successfully")
+			testutils.MustHaveLogMessage(t, hook, "found matching deposit for funding schedule")
+
+			updatedSpending := testutils.MustDBRead(t, spending)
+			assert.EqualValues(t, 100, updatedSpending.CurrentAmount, "spending should be funded")
+			updatedFundingSchedule := testutils.MustDBRead(t, *fundingSchedule)
+			assert.Greater(t, updatedFundingSchedule.NextRecurrence, fundingSchedule.NextRecurrence, "funding schedule should advance")
+			assert.Equal(t, &paycheck.TransactionId, updatedFundingSchedule.LastDepositTransactionId, "deposit should be linked to the funding schedule")
+
+			var allocations []SpendingAllocation
+			err = db.Model(&allocations).
+				Where(`"spending_allocation"."spending_id" = ?`, spending.SpendingId).
+				Select(&allocations)
+			require.NoError(t, err, "must be able to read the allocation ledger")
+			require.Len(t, allocations, 1, "should have recorded a single contribution")
+			assert.Equal(t, &paycheck.TransactionId, allocations[0].TransactionId, "contribution should reference the deposit")
+		}
 	})
 
 	t.Run("will fail for a fake account", func(t *testing.T) {
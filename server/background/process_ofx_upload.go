@@ -1,20 +1,26 @@
 package background
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/benbjohnson/clock"
+	locale "github.com/elliotcourant/go-lclocale"
 	"github.com/elliotcourant/gofx"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-pg/pg/v10"
 	"github.com/monetr/monetr/server/consts"
 	"github.com/monetr/monetr/server/crumbs"
 	"github.com/monetr/monetr/server/currency"
+	"github.com/monetr/monetr/server/formats"
+	"github.com/monetr/monetr/server/formats/camt"
 	"github.com/monetr/monetr/server/formats/ofx"
+	"github.com/monetr/monetr/server/formats/qif"
 	"github.com/monetr/monetr/server/internal/myownsanity"
 	. "github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/pubsub"
@@ -60,11 +66,14 @@ type (
 		clock     clock.Clock
 		timezone  *time.Location
 
-		upload                *TransactionUpload
-		file                  *File
-		data                  *gofx.OFX
-		currency              string
-		statementTransactions []gofx.StatementTransaction
+		upload   *TransactionUpload
+		file     *File
+		data     *gofx.OFX
+		currency string
+		// statement is used instead of data for formats other than OFX, like
+		// QIF and CAMT.053 files.
+		statement             *formats.Statement
+		statementTransactions []formats.StatementTransaction
 		existingTransactions  map[string]Transaction
 	}
 )
@@ -94,25 +103,55 @@ func (h *ProcessOFXUploadHandler) updateStatus(
 	status TransactionUploadStatus,
 	errorMessage *string,
 ) error {
-	log := h.log.WithContext(ctx).WithFields(logrus.Fields{
-		"accountId":           args.AccountId,
-		"bankAccountId":       args.BankAccountId,
-		"transactionUploadId": args.TransactionUploadId,
+	return updateTransactionUploadStatus(
+		ctx,
+		h.log,
+		h.db,
+		h.clock,
+		h.publisher,
+		args.AccountId,
+		args.BankAccountId,
+		args.TransactionUploadId,
+		status,
+		errorMessage,
+	)
+}
+
+// updateTransactionUploadStatus will update the status of the specified
+// transaction upload and will notify anyone listening for progress on that
+// upload of the new status. It is shared between all of the file import jobs.
+func updateTransactionUploadStatus(
+	ctx context.Context,
+	log *logrus.Entry,
+	db pg.DBI,
+	clock clock.Clock,
+	publisher pubsub.Publisher,
+	accountId ID[Account],
+	bankAccountId ID[BankAccount],
+	transactionUploadId ID[TransactionUpload],
+	status TransactionUploadStatus,
+	errorMessage *string,
+) error {
+	log = log.WithContext(ctx).WithFields(logrus.Fields{
+		"accountId":           accountId,
+		"bankAccountId":       bankAccountId,
+		"transactionUploadId": transactionUploadId,
 	})
 
-	query := h.db.ModelContext(ctx, &TransactionUpload{}).
-		Where(`"account_id" = ?`, args.AccountId).
-		Where(`"bank_account_id" = ?`, args.BankAccountId).
-		Where(`"transaction_upload_id" = ?`, args.TransactionUploadId).
+	var upload TransactionUpload
+	query := db.ModelContext(ctx, &upload).
+		Where(`"account_id" = ?`, accountId).
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_upload_id" = ?`, transactionUploadId).
 		Set(`"status" = ?`, status)
 
 	switch status {
 	case TransactionUploadStatusProcessing:
-		query = query.Set(`"processed_at" = ?`, h.clock.Now())
+		query = query.Set(`"processed_at" = ?`, clock.Now())
 	case TransactionUploadStatusComplete:
-		query = query.Set(`"completed_at" = ?`, h.clock.Now())
+		query = query.Set(`"completed_at" = ?`, clock.Now())
 	case TransactionUploadStatusFailed:
-		query = query.Set(`"completed_at" = ?`, h.clock.Now())
+		query = query.Set(`"completed_at" = ?`, clock.Now())
 		if errorMessage != nil {
 			query = query.Set(`"error" = ?`, *errorMessage)
 		} else {
@@ -122,17 +161,32 @@ func (h *ProcessOFXUploadHandler) updateStatus(
 
 	log.WithField("status", status).Trace("updated transaction upload status")
 
-	_, err := query.Update()
+	_, err := query.Returning(`*`).Update(&upload)
 	if err != nil {
 		return errors.Wrap(err, "failed to update upload status")
 	}
 
+	switch status {
+	case TransactionUploadStatusComplete, TransactionUploadStatusFailed:
+		repo := repository.NewRepositoryFromSession(clock, "user_system", accountId, db)
+		if err := EmitWebhookEvent(
+			ctx,
+			log,
+			repo,
+			clock,
+			WebhookEventUploadCompleted,
+			upload,
+		); err != nil {
+			return errors.Wrap(err, "failed to emit upload completed webhook event")
+		}
+	}
+
 	channel := fmt.Sprintf(
 		"account:%s:transaction_upload:%s:progress",
-		args.AccountId, args.TransactionUploadId,
+		accountId, transactionUploadId,
 	)
 	payload := string(status)
-	if err := h.publisher.Notify(ctx, channel, payload); err != nil {
+	if err := publisher.Notify(ctx, channel, payload); err != nil {
 		return errors.Wrap(err, "failed to send progress notification for job")
 	}
 	log.WithFields(logrus.Fields{
@@ -330,32 +384,110 @@ func (j *ProcessOFXUploadJob) loadFile(ctx context.Context) error {
 	}
 	defer fileReader.Close()
 
-	ofxData, err := ofx.Parse(fileReader)
-	if err != nil {
+	switch storage.ContentType(strings.ToLower(file.ContentType)) {
+	case storage.QIFContentType:
+		qifData, err := qif.Parse(fileReader)
+		if err != nil {
+			return err
+		}
+
+		j.statement, err = qif.Translate(qifData, j.timezone)
 		return err
-	}
+	case storage.XMLContentType:
+		data, err := io.ReadAll(fileReader)
+		if err != nil {
+			return errors.Wrap(err, "failed to read file from storage")
+		}
 
-	j.data = ofxData
-	return nil
+		if !camt.Validate(data) {
+			return errors.New("XML file is not a CAMT.053 bank statement")
+		}
+
+		document, err := camt.Parse(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		j.statement, err = camt.Translate(document, j.timezone)
+		return err
+	default:
+		ofxData, err := ofx.Parse(fileReader)
+		if err != nil {
+			return err
+		}
+
+		j.data = ofxData
+		return nil
+	}
 }
 
 // hydrateTransactions takes all of the transactions that were present in the
-// OFX file and tries to cross reference them with transactions that already
-// exist in the database. It relies on the OFX file having a unique identifier
-// for each transaction that is consistent between each download from the FI.
+// file and tries to cross reference them with transactions that already exist
+// in the database. It relies on the file having a unique identifier for each
+// transaction that is consistent between each download from the FI.
 func (j *ProcessOFXUploadJob) hydrateTransactions(ctx context.Context) error {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
-	externalTransactionIds := make([]string, 0)
+	if j.statement != nil {
+		j.currency = strings.ToUpper(j.statement.Currency)
+		j.statementTransactions = j.statement.Transactions
+		// QIF files do not include a currency at all, in that case the file is
+		// assumed to be in the currency of the bank account.
+		if j.currency == "" {
+			bankAccount, err := j.repo.GetBankAccount(span.Context(), j.args.BankAccountId)
+			if err != nil {
+				return errors.Wrap(err, "failed to retrieve bank account for file import")
+			}
+			j.currency = bankAccount.Currency
+		}
+	} else {
+		j.gatherOFXTransactions(span.Context())
+	}
+
+	// TODO Add others as needed. Not sure what other formats we'll end up seeing
+	// over time.
+
+	externalTransactionIds := make([]string, len(j.statementTransactions))
+	for i := range j.statementTransactions {
+		externalTransactionIds[i] = j.statementTransactions[i].Id
+	}
 
+	if len(externalTransactionIds) == 0 {
+		return errors.Errorf("no external transaction IDs were found in the file, account type may not be supported")
+	}
+
+	var err error
+	j.existingTransactions, err = j.repo.GetTransactonsByUploadIdentifier(
+		span.Context(),
+		j.args.BankAccountId,
+		externalTransactionIds,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve existing transactions for upload processing")
+	}
+
+	if count := len(j.existingTransactions); count > 0 {
+		j.log.WithContext(span.Context()).WithFields(logrus.Fields{
+			"existingTransactions": count,
+		}).Debug("found existing transactions for upload")
+	}
+
+	return nil
+}
+
+// gatherOFXTransactions translates the transactions in the OFX file into
+// statement transactions. Transactions with a date that cannot be parsed are
+// logged and skipped.
+func (j *ProcessOFXUploadJob) gatherOFXTransactions(ctx context.Context) {
+	log := j.log.WithContext(ctx)
+
+	externalTransactions := make([]gofx.StatementTransaction, 0)
 	// Gather the bank transactions
 	if bankResponse := j.data.BANKMSGSRSV1; bankResponse != nil {
 		for _, statementTransactions := range bankResponse.STMTTRNRS {
 			for _, transaction := range statementTransactions.STMTRS.BANKTRANLIST.STMTTRN {
-				// Someday we might need to also consider CORRECTFITID.
-				externalTransactionIds = append(externalTransactionIds, transaction.FITID)
-				j.statementTransactions = append(j.statementTransactions, *transaction)
+				externalTransactions = append(externalTransactions, *transaction)
 			}
 			if j.currency == "" {
 				j.currency = strings.ToUpper(statementTransactions.STMTRS.CURDEF)
@@ -364,9 +496,7 @@ func (j *ProcessOFXUploadJob) hydrateTransactions(ctx context.Context) error {
 	} else if bankResponse := j.data.CREDITCARDMSGSRSV1; bankResponse != nil {
 		for _, statementTransactions := range bankResponse.CCSTMTTRNRS {
 			for _, transaction := range statementTransactions.CCSTMTRS.BANKTRANLIST.STMTTRN {
-				// Someday we might need to also consider CORRECTFITID.
-				externalTransactionIds = append(externalTransactionIds, transaction.FITID)
-				j.statementTransactions = append(j.statementTransactions, *transaction)
+				externalTransactions = append(externalTransactions, *transaction)
 			}
 			if j.currency == "" {
 				j.currency = strings.ToUpper(statementTransactions.CCSTMTRS.CURDEF)
@@ -383,32 +513,38 @@ func (j *ProcessOFXUploadJob) hydrateTransactions(ctx context.Context) error {
 	// Reverse the order of the arrray we store such that the order we insert the
 	// transactions into the DB matches the order of the transactions in the
 	// actual file.
-	slices.Reverse(j.statementTransactions)
-
-	// TODO Add others as needed. Not sure what other formats we'll end up seeing
-	// over time.
-
-	if len(externalTransactionIds) == 0 {
-		return errors.Errorf("no external transaction IDs were found in the file, account type may not be supported")
-	}
+	slices.Reverse(externalTransactions)
 
-	var err error
-	j.existingTransactions, err = j.repo.GetTransactonsByUploadIdentifier(
-		span.Context(),
-		j.args.BankAccountId,
-		externalTransactionIds,
-	)
-	if err != nil {
-		return errors.Wrap(err, "failed to retrieve existing transactions for upload processing")
-	}
+	j.statementTransactions = make([]formats.StatementTransaction, 0, len(externalTransactions))
+	for _, externalTransaction := range externalTransactions {
+		// TODO Also parse DTAVAIL at some point
+		date, err := ofx.ParseDate(externalTransaction.DTPOSTED, j.timezone)
+		if err != nil {
+			log.WithError(err).
+				WithFields(logrus.Fields{
+					"uploadIdentifier": externalTransaction.FITID,
+					"dtposted":         externalTransaction.DTPOSTED,
+				}).
+				Error("failed to parse transaction date posted")
+			continue
+		}
 
-	if count := len(j.existingTransactions); count > 0 {
-		j.log.WithContext(span.Context()).WithFields(logrus.Fields{
-			"existingTransactions": count,
-		}).Debug("found existing transactions for upload")
+		j.statementTransactions = append(j.statementTransactions, formats.StatementTransaction{
+			// Someday we might need to also consider CORRECTFITID.
+			Id:     externalTransaction.FITID,
+			Amount: externalTransaction.TRNAMT,
+			Date:   date,
+			// Still need to figure out something to do with memo, but for now we
+			// can take the name and trim it. Memo seems to behave a bit
+			// differently from FI to FI. At NFCU for example it contains a larger
+			// more un-santized version of the transaction name, but at US Bank it
+			// seems to contain reference numbers that might be useful internally?
+			// But are definitely not helpful here.
+			Name:      externalTransaction.NAME,
+			Memo:      externalTransaction.MEMO,
+			IsPending: false, // OFX files don't show pending?
+		})
 	}
-
-	return nil
 }
 
 func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
@@ -417,23 +553,30 @@ func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
 
 	log := j.log.WithContext(span.Context())
 
+	rules, err := newTransactionRuleProcessor(
+		span.Context(),
+		log,
+		j.repo,
+		j.args.BankAccountId,
+	)
+	if err != nil {
+		return err
+	}
+
 	transactionsToUpdate := make([]*Transaction, 0)
 	transactionsToCreate := make([]Transaction, 0)
 	for y := range j.statementTransactions {
 		externalTransaction := j.statementTransactions[y]
-		uploadIdentifier := externalTransaction.FITID
+		uploadIdentifier := externalTransaction.Id
 		tlog := log.WithFields(logrus.Fields{
 			"uploadIdentifier": uploadIdentifier,
 		})
 
 		// Parse the amount in the specified currency.
-		amount, err := currency.ParseFriendlyToAmount(
-			externalTransaction.TRNAMT,
-			j.currency,
-		)
+		amount, err := j.parseStatementAmount(externalTransaction.Amount)
 		if err != nil {
 			tlog.WithError(err).
-				WithField("trnamt", externalTransaction.TRNAMT).
+				WithField("amount", externalTransaction.Amount).
 				Error("failed to parse transaction amount")
 			continue
 		}
@@ -442,14 +585,8 @@ func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
 		// to invert the amount.
 		amount = amount * -1
 
-		// Still need to figure out something to do with memo, but for now we can
-		// take the name and trim it. Memo seems to behave a bit differently from
-		// FI to FI. At NFCU for example it contains a larger more un-santized
-		// version of the transaction name, but at US Bank it seems to contain
-		// reference numbers that might be useful internally? But are definitely
-		// not helpful here.
-		name := strings.TrimSpace(externalTransaction.NAME)
-		originalName := strings.TrimSpace(externalTransaction.MEMO)
+		name := strings.TrimSpace(externalTransaction.Name)
+		originalName := strings.TrimSpace(externalTransaction.Memo)
 
 		// Make sure that the original name and name are set. This way if name is
 		// blank it will use the original name. And if original name is blank it
@@ -457,15 +594,6 @@ func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
 		name = myownsanity.CoalesceStrings(name, originalName)
 		originalName = myownsanity.CoalesceStrings(originalName, name)
 
-		// TODO Also parse DTAVAIL at some point
-		date, err := ofx.ParseDate(externalTransaction.DTPOSTED, j.timezone)
-		if err != nil {
-			tlog.WithError(err).
-				WithField("dtposted", externalTransaction.DTPOSTED).
-				Error("failed to parse transaction date posted")
-			continue
-		}
-
 		transaction, ok := j.existingTransactions[uploadIdentifier]
 		if !ok {
 			transaction = Transaction{
@@ -473,19 +601,34 @@ func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
 				AccountId:            j.args.AccountId,
 				BankAccountId:        j.args.BankAccountId,
 				Amount:               amount,
-				Date:                 date,
+				Date:                 externalTransaction.Date,
 				Name:                 name,
 				OriginalName:         originalName,
 				OriginalMerchantName: name,
-				IsPending:            false, // OFX files don't show pending?
+				IsPending:            externalTransaction.IsPending,
 				UploadIdentifier:     &uploadIdentifier,
 				Source:               TransactionSourceUpload,
 			}
+			if err := rules.Process(span.Context(), &transaction); err != nil {
+				return err
+			}
 			transactionsToCreate = append(transactionsToCreate, transaction)
+			// Make sure that if the same unique ID shows up twice in a file we
+			// don't try to create it twice.
+			j.existingTransactions[uploadIdentifier] = transaction
 			continue
 		}
 
-		// TODO Process changes to an existing transaction.
+		// The only change we process for an existing transaction is a pending
+		// transaction clearing, when that happens the amount and date may also
+		// change. OFX files never include pending transactions.
+		// TODO Process other changes to an existing transaction.
+		if transaction.IsPending && !externalTransaction.IsPending {
+			transaction.IsPending = false
+			transaction.Amount = amount
+			transaction.Date = externalTransaction.Date
+			transactionsToUpdate = append(transactionsToUpdate, &transaction)
+		}
 	}
 
 	// Persist any new transactions.
@@ -494,6 +637,21 @@ func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
 		if err := j.repo.InsertTransactions(span.Context(), transactionsToCreate); err != nil {
 			return errors.Wrap(err, "failed to persist new transactions")
 		}
+
+		if err := rules.Flush(span.Context()); err != nil {
+			return err
+		}
+
+		if err := emitTransactionWebhookEvents(
+			span.Context(),
+			log,
+			j.repo,
+			j.clock,
+			WebhookEventTransactionCreated,
+			transactionsToCreate,
+		); err != nil {
+			return err
+		}
 	}
 
 	// If there are any updated transactions persist those as well.
@@ -502,6 +660,17 @@ func (j *ProcessOFXUploadJob) syncTransactions(ctx context.Context) error {
 		if err := j.repo.UpdateTransactions(span.Context(), transactionsToUpdate); err != nil {
 			return errors.Wrap(err, "failed to update transactions")
 		}
+
+		if err := emitTransactionWebhookEvents(
+			span.Context(),
+			log,
+			j.repo,
+			j.clock,
+			WebhookEventTransactionUpdated,
+			transactionsToUpdate,
+		); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -511,6 +680,10 @@ func (j *ProcessOFXUploadJob) syncBalances(ctx context.Context) error {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
+	if j.statement != nil {
+		return j.syncStatementBalances(span.Context())
+	}
+
 	// TODO Somehow keep track of the as of timestamp? This way if someone is
 	// importing files out of order we could potentially avoid updating the
 	// balance to an old value.
@@ -589,3 +762,63 @@ func (j *ProcessOFXUploadJob) syncBalances(ctx context.Context) error {
 
 	return nil
 }
+
+// syncStatementBalances updates the balances of the bank account using the
+// closing balances of a statement. Not every format includes balances, so only
+// the balances that are present are updated.
+func (j *ProcessOFXUploadJob) syncStatementBalances(ctx context.Context) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	bankAccount, err := j.repo.GetBankAccount(span.Context(), j.args.BankAccountId)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve bank account for file import sync")
+	}
+
+	if j.currency != bankAccount.Currency {
+		return errors.Errorf("Currency of file does not match currency of bank account, file: [%s], account: [%s]", j.currency, bankAccount.Currency)
+	}
+
+	if j.statement.LedgerBalance == nil && j.statement.AvailableBalance == nil {
+		j.log.WithContext(span.Context()).Debug("file does not include any balances, bank account balances will not be updated")
+		return nil
+	}
+
+	if balance := j.statement.LedgerBalance; balance != nil {
+		bankAccount.CurrentBalance, err = j.parseStatementAmount(balance.Amount)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse ledger balance amount")
+		}
+	}
+
+	if balance := j.statement.AvailableBalance; balance != nil {
+		bankAccount.AvailableBalance, err = j.parseStatementAmount(balance.Amount)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse available balance amount")
+		}
+	}
+
+	if err := j.repo.UpdateBankAccount(span.Context(), bankAccount); err != nil {
+		return errors.Wrap(err, "failed to update bank account balances")
+	}
+
+	return nil
+}
+
+// parseStatementAmount converts an amount from the statement into the smallest
+// unit of the file's currency. Formats like CAMT.053 allow more fractional
+// digits than the currency has, so the amount is rounded to the currency's
+// precision first.
+func (j *ProcessOFXUploadJob) parseStatementAmount(amount string) (int64, error) {
+	fractionalDigits, err := locale.GetCurrencyInternationalFractionalDigits(j.currency)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse currency amount")
+	}
+
+	rounded, err := formats.RoundDecimalAmount(amount, int(fractionalDigits))
+	if err != nil {
+		return 0, err
+	}
+
+	return currency.ParseFriendlyToAmount(rounded, j.currency)
+}
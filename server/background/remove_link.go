@@ -166,19 +166,40 @@ func (r *RemoveLinkJob) Run(ctx context.Context) error {
 	plaidSyncIds := r.getPlaidSyncsToRemove(span.Context(), bankAccountIds)
 	plaidBankAccountIds := r.getPlaidBankAccountsToRemove(span.Context(), bankAccountIds)
 	plaidLinkIds := r.getPlaidLinksToRemove(span.Context())
+	tellerTransactionIds := r.getTellerTransactionsToRemove(span.Context(), bankAccountIds)
+	tellerBankAccountIds := r.getTellerBankAccountsToRemove(span.Context(), bankAccountIds)
+	tellerLinkIds := r.getTellerLinksToRemove(span.Context())
+	simpleFINTransactionIds := r.getSimpleFINTransactionsToRemove(span.Context(), bankAccountIds)
+	simpleFINBankAccountIds := r.getSimpleFINBankAccountsToRemove(span.Context(), bankAccountIds)
+	simpleFINLinkIds := r.getSimpleFINLinksToRemove(span.Context())
 
 	r.removeTransactionClusters(span.Context(), bankAccountIds)
+	r.removeTransactionClusterOverrides(span.Context(), bankAccountIds)
+	r.removeTransactionRecurring(span.Context(), bankAccountIds)
 	// TODO Also remove any non-reconciled files
 	r.removeTransactionUploads(span.Context(), bankAccountIds)
+	r.removeTransactionUploadMappings(span.Context(), bankAccountIds)
+	r.removeTransactionRules(span.Context(), bankAccountIds)
+	r.removeTransactionSplits(span.Context(), bankAccountIds)
 	r.removeTransactions(span.Context(), bankAccountIds)
 	r.removePlaidTransactions(span.Context(), plaidTransactionIds)
+	r.removeTellerTransactions(span.Context(), tellerTransactionIds)
+	r.removeSimpleFINTransactions(span.Context(), simpleFINTransactionIds)
+	r.removeSpendingAllocations(span.Context(), bankAccountIds)
+	r.removeBalanceSnapshots(span.Context(), bankAccountIds)
+	r.removeBalanceAlerts(span.Context(), bankAccountIds)
+	r.removeNotifications(span.Context(), bankAccountIds)
 	r.removeSpending(span.Context(), bankAccountIds)
 	r.removeFundingSchedules(span.Context(), bankAccountIds)
 	r.removeBankAccounts(span.Context(), bankAccountIds)
 	r.removePlaidSyncs(span.Context(), plaidSyncIds)
 	r.removePlaidBankAccounts(span.Context(), plaidBankAccountIds)
+	r.removeTellerBankAccounts(span.Context(), tellerBankAccountIds)
+	r.removeSimpleFINBankAccounts(span.Context(), simpleFINBankAccountIds)
 	r.removeLink(span.Context())
 	r.removePlaidLinks(span.Context(), plaidLinkIds)
+	r.removeTellerLinks(span.Context(), tellerLinkIds)
+	r.removeSimpleFINLinks(span.Context(), simpleFINLinkIds)
 
 	channelName := fmt.Sprintf("link:remove:%s:%s", accountId, linkId)
 	if err = r.publisher.Notify(span.Context(), channelName, "success"); err != nil {
@@ -207,6 +228,38 @@ func (r *RemoveLinkJob) removeTransactionClusters(
 	r.log.WithField("removed", result.RowsAffected()).Info("removed transaction cluster(s)")
 }
 
+func (r *RemoveLinkJob) removeTransactionClusterOverrides(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &TransactionClusterOverride{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove transaction cluster overrides for link")
+		panic(errors.Wrap(err, "failed to remove transaction cluster overrides for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed transaction cluster override(s)")
+}
+
+func (r *RemoveLinkJob) removeTransactionRecurring(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &TransactionRecurring{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove recurring transactions for link")
+		panic(errors.Wrap(err, "failed to remove recurring transactions for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed recurring transaction(s)")
+}
+
 func (r *RemoveLinkJob) removeTransactionUploads(
 	ctx context.Context,
 	bankAccountIds []ID[BankAccount],
@@ -223,6 +276,54 @@ func (r *RemoveLinkJob) removeTransactionUploads(
 	r.log.WithField("removed", result.RowsAffected()).Info("removed transaction upload(s)")
 }
 
+func (r *RemoveLinkJob) removeTransactionUploadMappings(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &TransactionUploadMapping{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove transaction upload mappings for link")
+		panic(errors.Wrap(err, "failed to remove transaction upload mappings for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed transaction upload mapping(s)")
+}
+
+func (r *RemoveLinkJob) removeTransactionRules(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &TransactionRule{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove transaction rules for link")
+		panic(errors.Wrap(err, "failed to remove transaction rules for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed transaction rule(s)")
+}
+
+func (r *RemoveLinkJob) removeTransactionSplits(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &TransactionSplit{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove transaction splits for link")
+		panic(errors.Wrap(err, "failed to remove transaction splits for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed transaction split(s)")
+}
+
 func (r *RemoveLinkJob) removeTransactions(
 	ctx context.Context,
 	bankAccountIds []ID[BankAccount],
@@ -282,6 +383,70 @@ func (r *RemoveLinkJob) removePlaidTransactions(
 	r.log.WithField("removed", result.RowsAffected()).Info("removed plaid transaction(s)")
 }
 
+func (r *RemoveLinkJob) removeSpendingAllocations(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &SpendingAllocation{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove spending allocations for link")
+		panic(errors.Wrap(err, "failed to remove spending allocations for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed spending allocation(s)")
+}
+
+func (r *RemoveLinkJob) removeBalanceSnapshots(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &BalanceSnapshot{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove balance snapshots for link")
+		panic(errors.Wrap(err, "failed to remove balance snapshots for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed balance snapshot(s)")
+}
+
+func (r *RemoveLinkJob) removeBalanceAlerts(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &BalanceAlert{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove balance alerts for link")
+		panic(errors.Wrap(err, "failed to remove balance alerts for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed balance alert(s)")
+}
+
+func (r *RemoveLinkJob) removeNotifications(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) {
+	result, err := r.db.ModelContext(ctx, &Notification{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account_id" IN (?)`, bankAccountIds).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove notifications for link")
+		panic(errors.Wrap(err, "failed to remove notifications for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed notification(s)")
+}
+
 func (r *RemoveLinkJob) removeSpending(
 	ctx context.Context,
 	bankAccountIds []ID[BankAccount],
@@ -436,6 +601,244 @@ func (r *RemoveLinkJob) removePlaidLinks(
 	r.log.WithField("removed", result.RowsAffected()).Info("removed plaid link(s)")
 }
 
+func (r *RemoveLinkJob) getTellerTransactionsToRemove(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) []ID[TellerTransaction] {
+	ids := make([]ID[TellerTransaction], 0)
+	err := r.db.ModelContext(ctx, &TellerTransaction{}).
+		Join(`INNER JOIN "bank_accounts" AS "bank_account"`).
+		JoinOn(`"bank_account"."teller_bank_account_id" = "teller_transaction"."teller_bank_account_id"`).
+		JoinOn(`"bank_account"."account_id" = "teller_transaction"."account_id"`).
+		Where(`"teller_transaction"."account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account"."bank_account_id" IN (?)`, bankAccountIds).
+		Column("teller_transaction.teller_transaction_id").
+		Select(&ids)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to find teller transactions to be removed"))
+	}
+
+	return ids
+}
+
+func (r *RemoveLinkJob) removeTellerTransactions(
+	ctx context.Context,
+	ids []ID[TellerTransaction],
+) {
+	if len(ids) == 0 {
+		return
+	}
+
+	result, err := r.db.ModelContext(ctx, &TellerTransaction{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"teller_transaction_id" IN (?)`, ids).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove teller transactions for link")
+		panic(errors.Wrap(err, "failed to remove teller transactions for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed teller transaction(s)")
+}
+
+func (r *RemoveLinkJob) getTellerBankAccountsToRemove(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) []ID[TellerBankAccount] {
+	ids := make([]ID[TellerBankAccount], 0)
+	err := r.db.ModelContext(ctx, &TellerBankAccount{}).
+		Join(`INNER JOIN "bank_accounts" AS "bank_account"`).
+		JoinOn(`"teller_bank_account"."teller_bank_account_id" = "bank_account"."teller_bank_account_id"`).
+		JoinOn(`"teller_bank_account"."account_id" = "bank_account"."account_id"`).
+		Where(`"teller_bank_account"."account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account"."bank_account_id" IN (?)`, bankAccountIds).
+		Column("teller_bank_account.teller_bank_account_id").
+		Select(&ids)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to find teller bank accounts to remove"))
+	}
+
+	return ids
+}
+
+func (r *RemoveLinkJob) removeTellerBankAccounts(
+	ctx context.Context,
+	ids []ID[TellerBankAccount],
+) {
+	if len(ids) == 0 {
+		return
+	}
+
+	result, err := r.db.ModelContext(ctx, &TellerBankAccount{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"teller_bank_account_id" IN (?)`, ids).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove teller bank accounts for link")
+		panic(errors.Wrap(err, "failed to remove teller bank accounts for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed teller bank account(s)")
+}
+
+func (r *RemoveLinkJob) getTellerLinksToRemove(
+	ctx context.Context,
+) []ID[TellerLink] {
+	ids := make([]ID[TellerLink], 0)
+	err := r.db.ModelContext(ctx, &TellerLink{}).
+		Join(`INNER JOIN "links" AS "link"`).
+		JoinOn(`"teller_link"."teller_link_id" = "link"."teller_link_id"`).
+		JoinOn(`"teller_link"."account_id" = "link"."account_id"`).
+		Where(`"teller_link"."account_id" = ?`, r.args.AccountId).
+		Where(`"link"."link_id" = ?`, r.args.LinkId).
+		Column("teller_link.teller_link_id").
+		Select(&ids)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to find teller links to remove"))
+	}
+
+	return ids
+}
+
+func (r *RemoveLinkJob) removeTellerLinks(
+	ctx context.Context,
+	ids []ID[TellerLink],
+) {
+	if len(ids) == 0 {
+		return
+	}
+
+	result, err := r.db.ModelContext(ctx, &TellerLink{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"teller_link_id" IN (?)`, ids).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove teller links for link")
+		panic(errors.Wrap(err, "failed to remove teller links for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed teller link(s)")
+}
+
+func (r *RemoveLinkJob) getSimpleFINTransactionsToRemove(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) []ID[SimpleFINTransaction] {
+	ids := make([]ID[SimpleFINTransaction], 0)
+	err := r.db.ModelContext(ctx, &SimpleFINTransaction{}).
+		Join(`INNER JOIN "bank_accounts" AS "bank_account"`).
+		JoinOn(`"bank_account"."simplefin_bank_account_id" = "simplefin_transaction"."simplefin_bank_account_id"`).
+		JoinOn(`"bank_account"."account_id" = "simplefin_transaction"."account_id"`).
+		Where(`"simplefin_transaction"."account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account"."bank_account_id" IN (?)`, bankAccountIds).
+		Column("simplefin_transaction.simplefin_transaction_id").
+		Select(&ids)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to find simplefin transactions to be removed"))
+	}
+
+	return ids
+}
+
+func (r *RemoveLinkJob) removeSimpleFINTransactions(
+	ctx context.Context,
+	ids []ID[SimpleFINTransaction],
+) {
+	if len(ids) == 0 {
+		return
+	}
+
+	result, err := r.db.ModelContext(ctx, &SimpleFINTransaction{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"simplefin_transaction_id" IN (?)`, ids).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove simplefin transactions for link")
+		panic(errors.Wrap(err, "failed to remove simplefin transactions for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed simplefin transaction(s)")
+}
+
+func (r *RemoveLinkJob) getSimpleFINBankAccountsToRemove(
+	ctx context.Context,
+	bankAccountIds []ID[BankAccount],
+) []ID[SimpleFINBankAccount] {
+	ids := make([]ID[SimpleFINBankAccount], 0)
+	err := r.db.ModelContext(ctx, &SimpleFINBankAccount{}).
+		Join(`INNER JOIN "bank_accounts" AS "bank_account"`).
+		JoinOn(`"simplefin_bank_account"."simplefin_bank_account_id" = "bank_account"."simplefin_bank_account_id"`).
+		JoinOn(`"simplefin_bank_account"."account_id" = "bank_account"."account_id"`).
+		Where(`"simplefin_bank_account"."account_id" = ?`, r.args.AccountId).
+		WhereIn(`"bank_account"."bank_account_id" IN (?)`, bankAccountIds).
+		Column("simplefin_bank_account.simplefin_bank_account_id").
+		Select(&ids)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to find simplefin bank accounts to remove"))
+	}
+
+	return ids
+}
+
+func (r *RemoveLinkJob) removeSimpleFINBankAccounts(
+	ctx context.Context,
+	ids []ID[SimpleFINBankAccount],
+) {
+	if len(ids) == 0 {
+		return
+	}
+
+	result, err := r.db.ModelContext(ctx, &SimpleFINBankAccount{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"simplefin_bank_account_id" IN (?)`, ids).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove simplefin bank accounts for link")
+		panic(errors.Wrap(err, "failed to remove simplefin bank accounts for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed simplefin bank account(s)")
+}
+
+func (r *RemoveLinkJob) getSimpleFINLinksToRemove(
+	ctx context.Context,
+) []ID[SimpleFINLink] {
+	ids := make([]ID[SimpleFINLink], 0)
+	err := r.db.ModelContext(ctx, &SimpleFINLink{}).
+		Join(`INNER JOIN "links" AS "link"`).
+		JoinOn(`"simplefin_link"."simplefin_link_id" = "link"."simplefin_link_id"`).
+		JoinOn(`"simplefin_link"."account_id" = "link"."account_id"`).
+		Where(`"simplefin_link"."account_id" = ?`, r.args.AccountId).
+		Where(`"link"."link_id" = ?`, r.args.LinkId).
+		Column("simplefin_link.simplefin_link_id").
+		Select(&ids)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to find simplefin links to remove"))
+	}
+
+	return ids
+}
+
+func (r *RemoveLinkJob) removeSimpleFINLinks(
+	ctx context.Context,
+	ids []ID[SimpleFINLink],
+) {
+	if len(ids) == 0 {
+		return
+	}
+
+	result, err := r.db.ModelContext(ctx, &SimpleFINLink{}).
+		Where(`"account_id" = ?`, r.args.AccountId).
+		WhereIn(`"simplefin_link_id" IN (?)`, ids).
+		Delete()
+	if err != nil {
+		r.log.WithError(err).Errorf("failed to remove simplefin links for link")
+		panic(errors.Wrap(err, "failed to remove simplefin links for link"))
+	}
+
+	r.log.WithField("removed", result.RowsAffected()).Info("removed simplefin link(s)")
+}
+
 func (r *RemoveLinkJob) removeBankAccounts(
 	ctx context.Context,
 	bankAccountIds []ID[BankAccount],
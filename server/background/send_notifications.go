@@ -0,0 +1,415 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/notifications"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SendNotifications = "SendNotifications"
+
+	// sendNotificationsBatchSize is how many notifications will be delivered
+	// for an account by a single job, anything left over is picked up the next
+	// time the job runs.
+	sendNotificationsBatchSize = 100
+)
+
+var (
+	_ ScheduledJobHandler = &SendNotificationsHandler{}
+	_ JobImplementation   = &SendNotificationsJob{}
+)
+
+type (
+	SendNotificationsHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		kms          secrets.KeyManagement
+		dispatcher   notifications.Dispatcher
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	SendNotificationsArguments struct {
+		AccountId ID[Account] `json:"accountId"`
+	}
+
+	SendNotificationsJob struct {
+		args       SendNotificationsArguments
+		log        *logrus.Entry
+		repo       repository.BaseRepository
+		secrets    repository.SecretsRepository
+		dispatcher notifications.Dispatcher
+		clock      clock.Clock
+	}
+)
+
+func NewSendNotificationsHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	kms secrets.KeyManagement,
+	dispatcher notifications.Dispatcher,
+) *SendNotificationsHandler {
+	return &SendNotificationsHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		kms:          kms,
+		dispatcher:   dispatcher,
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (s SendNotificationsHandler) QueueName() string {
+	return SendNotifications
+}
+
+func (s *SendNotificationsHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args SendNotificationsArguments
+	if err := errors.Wrap(s.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Send Notifications job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	// Notifications are not delivered inside of a transaction. Delivery can
+	// mean waiting on someone else's server, and a failure part way through the
+	// job must not roll back notifications that were already delivered. Each
+	// notification is instead marked as sent before it is delivered.
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	jobLog := log.WithContext(span.Context())
+	repo := repository.NewRepositoryFromSession(s.clock, "user_system", args.AccountId, s.db)
+	secretsRepo := repository.NewSecretsRepository(
+		jobLog,
+		s.clock,
+		s.db,
+		s.kms,
+		args.AccountId,
+	)
+	job, err := NewSendNotificationsJob(
+		jobLog,
+		repo,
+		secretsRepo,
+		s.dispatcher,
+		args,
+		s.clock,
+	)
+	if err != nil {
+		return err
+	}
+	return job.Run(span.Context())
+}
+
+func (s SendNotificationsHandler) DefaultSchedule() string {
+	// Notifications are created by other jobs, deliver them every minute so
+	// that they are timely.
+	return "0 * * * * *"
+}
+
+func (s *SendNotificationsHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := s.log.WithContext(ctx)
+
+	accountIds, err := s.repo.GetAccountsWithUnsentNotifications(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve accounts with unsent notifications")
+	}
+
+	if len(accountIds) == 0 {
+		crumbs.Debug(ctx, "No accounts have unsent notifications.", nil)
+		log.Debug("no accounts have unsent notifications")
+		return nil
+	}
+
+	log.WithField("count", len(accountIds)).Info("found accounts with unsent notifications")
+
+	for _, accountId := range accountIds {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId": accountId,
+		})
+		itemLog.Trace("enqueuing account to send notifications")
+		err = enqueuer.EnqueueJob(ctx, s.QueueName(), SendNotificationsArguments{
+			AccountId: accountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to send notifications")
+			crumbs.Warn(ctx, "Failed to enqueue job to send notifications", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
+
+func NewSendNotificationsJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	secrets repository.SecretsRepository,
+	dispatcher notifications.Dispatcher,
+	args SendNotificationsArguments,
+	clock clock.Clock,
+) (*SendNotificationsJob, error) {
+	return &SendNotificationsJob{
+		args:       args,
+		log:        log,
+		repo:       repo,
+		secrets:    secrets,
+		dispatcher: dispatcher,
+		clock:      clock,
+	}, nil
+}
+
+// Run delivers the unsent notifications of the account to every user whose
+// preferences want them. Each notification is marked as sent before it is
+// delivered, this way a broken webhook does not cause the same notification to
+// be sent to everyone else over and over, and a notification that another job
+// has already claimed is skipped.
+func (s *SendNotificationsJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := s.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId": s.args.AccountId,
+	})
+
+	unsent, err := s.repo.GetUnsentNotifications(span.Context(), sendNotificationsBatchSize)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve unsent notifications")
+		return err
+	}
+
+	preferences := map[NotificationKind][]NotificationPreference{}
+	webhookSecrets := map[ID[Secret]]string{}
+	for _, notification := range unsent {
+		notificationLog := log.WithFields(logrus.Fields{
+			"notificationId": notification.NotificationId,
+			"kind":           notification.Kind,
+		})
+
+		kindPreferences, ok := preferences[notification.Kind]
+		if !ok {
+			kindPreferences, err = s.repo.GetNotificationPreferencesByKind(span.Context(), notification.Kind)
+			if err != nil {
+				notificationLog.WithError(err).Error("failed to retrieve notification preferences")
+				return err
+			}
+			preferences[notification.Kind] = kindPreferences
+		}
+
+		claimed, err := s.repo.MarkNotificationSent(span.Context(), notification.NotificationId, s.clock.Now())
+		if err != nil {
+			notificationLog.WithError(err).Error("failed to mark notification as sent")
+			return err
+		}
+		if !claimed {
+			notificationLog.Debug("notification has already been sent, skipping")
+			continue
+		}
+
+		delivered := 0
+		for _, preference := range kindPreferences {
+			if !preference.Wants(notification) {
+				continue
+			}
+
+			recipient, err := notifications.NewRecipient(preference)
+			if err != nil {
+				notificationLog.WithError(err).Warn("failed to determine recipient for notification")
+				continue
+			}
+
+			if preference.WebhookSecretId != nil {
+				secret, ok := webhookSecrets[*preference.WebhookSecretId]
+				if !ok {
+					data, err := s.secrets.Read(span.Context(), *preference.WebhookSecretId)
+					if err != nil {
+						notificationLog.WithError(err).
+							WithField("userId", preference.UserId).
+							Warn("failed to retrieve webhook secret for notification preference")
+					} else {
+						secret = data.Value
+					}
+					webhookSecrets[*preference.WebhookSecretId] = secret
+				}
+				recipient.WebhookSecret = secret
+			}
+
+			if err := s.dispatcher.Dispatch(span.Context(), recipient, notification); err != nil {
+				notificationLog.WithError(err).
+					WithField("userId", preference.UserId).
+					Warn("failed to deliver notification to user")
+				continue
+			}
+			delivered++
+		}
+
+		notificationLog.WithField("delivered", delivered).Debug("sent notification")
+	}
+
+	return nil
+}
+
+// CreateNotification records a notification to be delivered by the send
+// notifications job. If none of the users in the account want this
+// notification then nothing is recorded.
+func CreateNotification(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	notification Notification,
+) error {
+	preferences, err := repo.GetNotificationPreferencesByKind(ctx, notification.Kind)
+	if err != nil {
+		return err
+	}
+
+	wanted := false
+	for i := range preferences {
+		if preferences[i].Wants(notification) {
+			wanted = true
+			break
+		}
+	}
+	if !wanted {
+		return nil
+	}
+
+	created, err := repo.CreateNotification(ctx, &notification)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		log.WithFields(logrus.Fields{
+			"notificationId": notification.NotificationId,
+			"kind":           notification.Kind,
+		}).Debug("created notification")
+	}
+
+	return nil
+}
+
+// newNegativeFreeToUseNotification is shared by the jobs that can notice that
+// free-to-use has gone negative. Only one is sent per bank account per day.
+func newNegativeFreeToUseNotification(
+	bankAccountId ID[BankAccount],
+	free int64,
+	date string,
+) Notification {
+	return Notification{
+		BankAccountId: &bankAccountId,
+		Kind:          NotificationKindNegativeFreeToUse,
+		DedupeKey:     fmt.Sprintf("%s:%s:%s", NotificationKindNegativeFreeToUse, bankAccountId, date),
+		Title:         "Free-to-use is negative",
+		Message:       "More money has been allocated or spent than is available, your free-to-use balance is now negative.",
+		Amount:        &free,
+		Data: map[string]string{
+			"bankAccountId": bankAccountId.String(),
+		},
+	}
+}
+
+// notifyLargeTransactions creates a notification for each of the new
+// transactions that is larger than the threshold of any user's large
+// transaction notification preference. This is shared by the jobs that sync
+// transactions from a data provider.
+func notifyLargeTransactions(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	transactions []Transaction,
+) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	preferences, err := repo.GetNotificationPreferencesByKind(ctx, NotificationKindLargeTransaction)
+	if err != nil || len(preferences) == 0 {
+		return err
+	}
+
+	for i := range transactions {
+		transaction := transactions[i]
+		notification := Notification{
+			BankAccountId: &transaction.BankAccountId,
+			Kind:          NotificationKindLargeTransaction,
+			DedupeKey:     fmt.Sprintf("%s:%s", NotificationKindLargeTransaction, transaction.TransactionId),
+			Title:         fmt.Sprintf("Large transaction at %s", transaction.Name),
+			Message:       fmt.Sprintf("A new transaction at %s is larger than your notification threshold.", transaction.Name),
+			Amount:        &transaction.Amount,
+			Data: map[string]string{
+				"transactionId": transaction.TransactionId.String(),
+			},
+		}
+
+		for _, preference := range preferences {
+			if !preference.Wants(notification) {
+				continue
+			}
+
+			if _, err := repo.CreateNotification(ctx, &notification); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// notifyNegativeFreeToUse checks the balances of the bank accounts that had
+// transactions changed by a sync, and creates a notification for any whose
+// free-to-use is now negative.
+func notifyNegativeFreeToUse[T any](
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	today time.Time,
+	bankAccounts map[ID[BankAccount]]T,
+) error {
+	date := today.Format("2006-01-02")
+	for bankAccountId := range bankAccounts {
+		balances, err := repo.GetBalances(ctx, bankAccountId)
+		if err != nil {
+			return err
+		}
+
+		if balances.Free >= 0 {
+			continue
+		}
+
+		if err := CreateNotification(
+			ctx,
+			log,
+			repo,
+			newNegativeFreeToUseNotification(bankAccountId, balances.Free, date),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
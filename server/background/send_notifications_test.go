@@ -0,0 +1,148 @@
+package background
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/notifications"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDispatcher struct {
+	sent []notifications.Recipient
+}
+
+func (t *testDispatcher) Dispatch(
+	ctx context.Context,
+	recipient notifications.Recipient,
+	notification Notification,
+) error {
+	t.sent = append(t.sent, recipient)
+	return nil
+}
+
+func TestSendNotificationsJob_Run(t *testing.T) {
+	t.Run("delivers to users who want it", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, _ := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+
+		testutils.MustDBInsert(t, &NotificationPreference{
+			AccountId: user.AccountId,
+			UserId:    user.UserId,
+			Kind:      NotificationKindLargeTransaction,
+			IsEnabled: true,
+			Channels:  []NotificationChannelType{NotificationChannelEmail},
+			Threshold: myownsanity.Int64P(10000),
+		})
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		small := Notification{
+			BankAccountId: &bankAccount.BankAccountId,
+			Kind:          NotificationKindLargeTransaction,
+			DedupeKey:     "large_transaction:small",
+			Title:         "Small transaction",
+			Amount:        myownsanity.Int64P(500),
+		}
+		err := CreateNotification(context.Background(), log, repo, small)
+		assert.NoError(t, err)
+
+		large := Notification{
+			BankAccountId: &bankAccount.BankAccountId,
+			Kind:          NotificationKindLargeTransaction,
+			DedupeKey:     "large_transaction:large",
+			Title:         "Large transaction",
+			Amount:        myownsanity.Int64P(15000),
+		}
+		err = CreateNotification(context.Background(), log, repo, large)
+		assert.NoError(t, err)
+		// Creating the same notification again should not result in a duplicate.
+		err = CreateNotification(context.Background(), log, repo, large)
+		assert.NoError(t, err)
+
+		unsent, err := repo.GetUnsentNotifications(context.Background(), 10)
+		require.NoError(t, err)
+		require.Len(t, unsent, 1, "only the large transaction should be recorded")
+
+		dispatcher := &testDispatcher{}
+		handler := NewSendNotificationsHandler(log, db, clock, secrets.NewPlaintextKMS(), dispatcher)
+		argsEncoded, err := DefaultJobMarshaller(SendNotificationsArguments{
+			AccountId: user.AccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should send notifications successfully")
+		if assert.Len(t, dispatcher.sent, 1, "should deliver to the one user") {
+			assert.Equal(t, user.Login.Email, dispatcher.sent[0].Email)
+		}
+
+		notificationId := unsent[0].NotificationId
+		unsent, err = repo.GetUnsentNotifications(context.Background(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, unsent, "notification should be marked as sent")
+
+		claimed, err := repo.MarkNotificationSent(context.Background(), notificationId, clock.Now())
+		assert.NoError(t, err)
+		assert.False(t, claimed, "a notification that was already sent cannot be claimed again")
+	})
+
+	t.Run("includes the webhook secret", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, _ := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+		kms := secrets.NewPlaintextKMS()
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+
+		secretsRepo := repository.NewSecretsRepository(log, clock, db, kms, user.AccountId)
+		secret := repository.SecretData{
+			Kind:  WebhookSecretKind,
+			Value: "whsec_test",
+		}
+		require.NoError(t, secretsRepo.Store(context.Background(), &secret))
+
+		testutils.MustDBInsert(t, &NotificationPreference{
+			AccountId:       user.AccountId,
+			UserId:          user.UserId,
+			Kind:            NotificationKindGoalReached,
+			IsEnabled:       true,
+			Channels:        []NotificationChannelType{NotificationChannelWebhook},
+			WebhookURL:      myownsanity.StringP("https://example.com/hook"),
+			WebhookSecretId: &secret.SecretId,
+		})
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		err := CreateNotification(context.Background(), log, repo, Notification{
+			Kind:      NotificationKindGoalReached,
+			DedupeKey: "goal_reached:test",
+			Title:     "Vacation is fully funded",
+		})
+		require.NoError(t, err)
+
+		dispatcher := &testDispatcher{}
+		handler := NewSendNotificationsHandler(log, db, clock, kms, dispatcher)
+		argsEncoded, err := DefaultJobMarshaller(SendNotificationsArguments{
+			AccountId: user.AccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should send notifications successfully")
+		if assert.Len(t, dispatcher.sent, 1, "should deliver to the one user") {
+			assert.Equal(t, "whsec_test", dispatcher.sent[0].WebhookSecret)
+		}
+	})
+}
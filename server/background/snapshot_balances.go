@@ -0,0 +1,195 @@
+package background
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SnapshotBalances = "SnapshotBalances"
+)
+
+var (
+	_ ScheduledJobHandler = &SnapshotBalancesHandler{}
+	_ JobImplementation   = &SnapshotBalancesJob{}
+)
+
+type (
+	SnapshotBalancesHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		repo         repository.JobRepository
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	SnapshotBalancesArguments struct {
+		AccountId     ID[Account]     `json:"accountId"`
+		BankAccountId ID[BankAccount] `json:"bankAccountId"`
+	}
+
+	SnapshotBalancesJob struct {
+		args  SnapshotBalancesArguments
+		log   *logrus.Entry
+		repo  repository.BaseRepository
+		clock clock.Clock
+	}
+)
+
+func NewSnapshotBalancesHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+) *SnapshotBalancesHandler {
+	return &SnapshotBalancesHandler{
+		log:          log,
+		db:           db,
+		repo:         repository.NewJobRepository(db, clock),
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (s SnapshotBalancesHandler) QueueName() string {
+	return SnapshotBalances
+}
+
+func (s *SnapshotBalancesHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args SnapshotBalancesArguments
+	if err := errors.Wrap(s.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Snapshot Balances job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return s.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		repo := repository.NewRepositoryFromSession(s.clock, "user_system", args.AccountId, txn)
+		job, err := NewSnapshotBalancesJob(
+			log.WithContext(span.Context()),
+			repo,
+			args,
+			s.clock,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func (s SnapshotBalancesHandler) DefaultSchedule() string {
+	// Will run once a day. The snapshot is dated using the account's timezone,
+	// so if the job is run more than once on the same day the snapshot is simply
+	// replaced.
+	return "0 0 0 * * *"
+}
+
+func (s *SnapshotBalancesHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := s.log.WithContext(ctx)
+
+	log.Info("retrieving bank accounts to snapshot balances")
+	bankAccounts, err := s.repo.GetBankAccountsToSnapshot(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve bank accounts to snapshot balances")
+	}
+
+	if len(bankAccounts) == 0 {
+		crumbs.Debug(ctx, "No bank accounts to snapshot balances for.", nil)
+		log.Info("no bank accounts to snapshot balances for")
+		return nil
+	}
+
+	log.WithField("count", len(bankAccounts)).Info("found bank accounts to snapshot balances for")
+
+	for _, item := range bankAccounts {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId":     item.AccountId,
+			"bankAccountId": item.BankAccountId,
+		})
+		itemLog.Trace("enqueuing bank account to snapshot balances")
+		err = enqueuer.EnqueueJob(ctx, s.QueueName(), SnapshotBalancesArguments{
+			AccountId:     item.AccountId,
+			BankAccountId: item.BankAccountId,
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to snapshot balances")
+			crumbs.Warn(ctx, "Failed to enqueue job to snapshot balances", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+	}
+
+	return nil
+}
+
+func NewSnapshotBalancesJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	args SnapshotBalancesArguments,
+	clock clock.Clock,
+) (*SnapshotBalancesJob, error) {
+	return &SnapshotBalancesJob{
+		args:  args,
+		log:   log,
+		repo:  repo,
+		clock: clock,
+	}, nil
+}
+
+// Run records the current balances of the bank account as the snapshot for
+// the current day in the account's timezone.
+func (s *SnapshotBalancesJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+
+	log := s.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId":     s.args.AccountId,
+		"bankAccountId": s.args.BankAccountId,
+	})
+
+	account, err := s.repo.GetAccount(span.Context())
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve account to snapshot balances")
+		return err
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		log.WithError(err).Error("failed to parse account's timezone")
+		return err
+	}
+
+	date := util.Midnight(s.clock.Now(), timezone)
+	snapshot, err := s.repo.SnapshotBalances(span.Context(), s.args.BankAccountId, date)
+	if err != nil {
+		log.WithError(err).Error("failed to snapshot balances")
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"balanceSnapshotId": snapshot.BalanceSnapshotId,
+		"date":              snapshot.Date,
+	}).Debug("recorded balance snapshot")
+
+	return nil
+}
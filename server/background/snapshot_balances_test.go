@@ -0,0 +1,55 @@
+package background
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotBalancesJob_Run(t *testing.T) {
+	t.Run("replaces the snapshot for the same day", func(t *testing.T) {
+		clock := clock.NewMock()
+		log, _ := testutils.GetTestLog(t)
+		db := testutils.GetPgDatabase(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveAManualLink(t, clock, user)
+		bankAccount := fixtures.GivenIHaveABankAccount(t, clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		bankAccount.CurrentBalance = 150000
+		bankAccount.AvailableBalance = 100000
+		testutils.MustDBUpdate(t, &bankAccount)
+
+		handler := NewSnapshotBalancesHandler(log, db, clock)
+		argsEncoded, err := DefaultJobMarshaller(SnapshotBalancesArguments{
+			AccountId:     bankAccount.AccountId,
+			BankAccountId: bankAccount.BankAccountId,
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should snapshot balances successfully")
+
+		// Balances change later on the same day.
+		bankAccount.AvailableBalance = 90000
+		testutils.MustDBUpdate(t, &bankAccount)
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "should snapshot balances successfully")
+
+		var snapshots []BalanceSnapshot
+		err = db.Model(&snapshots).
+			Where(`"balance_snapshot"."bank_account_id" = ?`, bankAccount.BankAccountId).
+			Select(&snapshots)
+		require.NoError(t, err, "must be able to read balance snapshots")
+		require.Len(t, snapshots, 1, "should only have a single snapshot for the day")
+		assert.EqualValues(t, 150000, snapshots[0].Current)
+		assert.EqualValues(t, 90000, snapshots[0].Available, "snapshot should have the latest balance")
+		assert.EqualValues(t, 90000, snapshots[0].Free, "without any spending everything is free to use")
+	})
+}
@@ -0,0 +1,733 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SyncSimpleFIN = "SyncSimpleFIN"
+
+	// simpleFINInitialHistory is how far back transactions are requested the
+	// first time a SimpleFIN link is synced.
+	simpleFINInitialHistory = 90 * 24 * time.Hour
+	// simpleFINSyncOverlap is how far before the last successful sync
+	// transactions will be retrieved again. SimpleFIN does not provide a cursor
+	// so we look back far enough to see pending transactions post.
+	simpleFINSyncOverlap = 14 * 24 * time.Hour
+)
+
+var (
+	_ ScheduledJobHandler = &SyncSimpleFINHandler{}
+	_ JobImplementation   = &SyncSimpleFINJob{}
+	_ BankSyncProvider    = &SyncSimpleFINJob{}
+)
+
+type (
+	SyncSimpleFINHandler struct {
+		log             *logrus.Entry
+		db              *pg.DB
+		kms             secrets.KeyManagement
+		simpleFINClient simplefin.SimpleFIN
+		publisher       pubsub.Publisher
+		enqueuer        JobEnqueuer
+		unmarshaller    JobUnmarshaller
+		clock           clock.Clock
+	}
+
+	SyncSimpleFINArguments struct {
+		AccountId ID[Account] `json:"accountId"`
+		LinkId    ID[Link]    `json:"linkId"`
+		// Trigger will be "initial" or "manual" or "cron"
+		Trigger string `json:"trigger"`
+	}
+
+	SyncSimpleFINJob struct {
+		args            SyncSimpleFINArguments
+		log             *logrus.Entry
+		repo            repository.BaseRepository
+		secrets         repository.SecretsRepository
+		simpleFINClient simplefin.SimpleFIN
+		publisher       pubsub.Publisher
+		enqueuer        JobEnqueuer
+		clock           clock.Clock
+
+		timezone              *time.Location
+		link                  *Link
+		client                simplefin.Client
+		bankAccounts          []BankAccount
+		serverErrors          []string
+		simpleFINTransactions []*SimpleFINTransaction
+	}
+
+	// simpleFINSyncTransaction is provided as the data of each transaction
+	// given to the bank sync, it has everything needed for the simplefin
+	// transaction.
+	simpleFINSyncTransaction struct {
+		input        simplefin.Transaction
+		description  string
+		postedAt     *time.Time
+		transactedAt *time.Time
+	}
+)
+
+func TriggerSyncSimpleFIN(
+	ctx context.Context,
+	backgroundJobs JobController,
+	arguments SyncSimpleFINArguments,
+) error {
+	if arguments.Trigger == "" {
+		arguments.Trigger = "manual"
+	}
+	return backgroundJobs.EnqueueJob(ctx, SyncSimpleFIN, arguments)
+}
+
+func NewSyncSimpleFINHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	kms secrets.KeyManagement,
+	simpleFINClient simplefin.SimpleFIN,
+	publisher pubsub.Publisher,
+	enqueuer JobEnqueuer,
+) *SyncSimpleFINHandler {
+	return &SyncSimpleFINHandler{
+		log:             log,
+		db:              db,
+		kms:             kms,
+		simpleFINClient: simpleFINClient,
+		publisher:       publisher,
+		enqueuer:        enqueuer,
+		unmarshaller:    DefaultJobUnmarshaller,
+		clock:           clock,
+	}
+}
+
+func (s SyncSimpleFINHandler) QueueName() string {
+	return SyncSimpleFIN
+}
+
+func (s *SyncSimpleFINHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args SyncSimpleFINArguments
+	if err := errors.Wrap(s.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Sync SimpleFIN job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return s.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		log := log.WithContext(span.Context())
+
+		repo := repository.NewRepositoryFromSession(s.clock, "user_simplefin", args.AccountId, txn)
+		secretsRepo := repository.NewSecretsRepository(
+			log,
+			s.clock,
+			txn,
+			s.kms,
+			args.AccountId,
+		)
+		job, err := NewSyncSimpleFINJob(
+			log,
+			repo,
+			s.clock,
+			secretsRepo,
+			s.simpleFINClient,
+			s.publisher,
+			s.enqueuer,
+			args,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func (s SyncSimpleFINHandler) DefaultSchedule() string {
+	// SimpleFIN servers limit how many requests can be made each day, and most
+	// institutions are only refreshed by the server a few times a day anyway.
+	return "0 0 */6 * * *"
+}
+
+func (s *SyncSimpleFINHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := s.log.WithContext(ctx)
+
+	log.Info("retrieving links to sync with SimpleFIN")
+
+	links := make([]Link, 0)
+	cutoff := s.clock.Now().Add(-6 * time.Hour)
+	err := s.db.ModelContext(ctx, &links).
+		Join(`INNER JOIN "simplefin_links" AS "simplefin_link"`).
+		JoinOn(`"simplefin_link"."simplefin_link_id" = "link"."simplefin_link_id"`).
+		JoinOn(`"simplefin_link"."account_id" = "link"."account_id"`).
+		Where(`"simplefin_link"."status" = ?`, SimpleFINLinkStatusSetup).
+		Where(`"simplefin_link"."last_attempted_update" < ?`, cutoff).
+		Where(`"simplefin_link"."deleted_at" IS NULL`).
+		Where(`"link"."link_type" = ?`, SimpleFINLinkType).
+		Where(`"link"."deleted_at" IS NULL`).
+		Select(&links)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve links that need to by synced with simplefin")
+	}
+
+	if len(links) == 0 {
+		log.Debug("no simplefin links need to be synced at this time")
+		return nil
+	}
+
+	log.WithField("count", len(links)).Info("syncing simplefin links")
+
+	for _, item := range links {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId": item.AccountId,
+			"linkId":    item.LinkId,
+		})
+		itemLog.Trace("enqueuing link to be synced with simplefin")
+		err := enqueuer.EnqueueJob(ctx, s.QueueName(), SyncSimpleFINArguments{
+			AccountId: item.AccountId,
+			LinkId:    item.LinkId,
+			Trigger:   "cron",
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to sync with simplefin")
+			crumbs.Warn(ctx, "Failed to enqueue job to sync with simplefin", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+
+		itemLog.Trace("successfully enqueued link to be synced with simplefin")
+	}
+
+	return nil
+}
+
+func NewSyncSimpleFINJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	clock clock.Clock,
+	secrets repository.SecretsRepository,
+	simpleFINClient simplefin.SimpleFIN,
+	publisher pubsub.Publisher,
+	enqueuer JobEnqueuer,
+	args SyncSimpleFINArguments,
+) (*SyncSimpleFINJob, error) {
+	return &SyncSimpleFINJob{
+		args:            args,
+		log:             log,
+		repo:            repo,
+		secrets:         secrets,
+		simpleFINClient: simpleFINClient,
+		publisher:       publisher,
+		enqueuer:        enqueuer,
+		clock:           clock,
+
+		timezone:              nil, // Is set by Setup
+		simpleFINTransactions: make([]*SimpleFINTransaction, 0),
+	}, nil
+}
+
+func (s *SyncSimpleFINJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+	crumbs.AddTag(span.Context(), "linkId", s.args.LinkId.String())
+
+	log := s.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId": s.args.AccountId,
+		"linkId":    s.args.LinkId,
+	})
+
+	link, err := s.repo.GetLink(span.Context(), s.args.LinkId)
+	if err = errors.Wrap(err, "failed to retrieve link to sync with simplefin"); err != nil {
+		log.WithError(err).Error("cannot sync without link")
+		return err
+	}
+
+	if link.SimpleFINLink == nil {
+		log.Warn("provided link does not have any simplefin credentials")
+		crumbs.IndicateBug(
+			span.Context(),
+			"BUG: Link was queued to sync with simplefin, but has no simplefin details",
+			map[string]interface{}{
+				"link": link,
+			},
+		)
+		span.Status = sentry.SpanStatusFailedPrecondition
+		return nil
+	}
+
+	simpleFINLink := link.SimpleFINLink
+	if simpleFINLink.DeletedAt != nil || simpleFINLink.Status == SimpleFINLinkStatusDisconnected {
+		log.Info("simplefin link is no longer connected, it will not be synced")
+		return nil
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"simpleFINLinkId": simpleFINLink.SimpleFINLinkId,
+		"simplefin": logrus.Fields{
+			"server":          simpleFINLink.Server,
+			"institutionName": simpleFINLink.InstitutionName,
+		},
+	})
+
+	// This way other methods will have these log fields too.
+	s.log = log
+
+	return newBankSync(
+		log,
+		s.repo,
+		s.clock,
+		s.enqueuer,
+		s,
+		s.args.Trigger,
+	).Run(span.Context(), link)
+}
+
+func (s *SyncSimpleFINJob) Source() TransactionSource {
+	return TransactionSourceSimpleFIN
+}
+
+func (s *SyncSimpleFINJob) Setup(
+	ctx context.Context,
+	link *Link,
+	timezone *time.Location,
+) (map[string]BankAccount, error) {
+	s.link = link
+	s.timezone = timezone
+
+	bankAccounts, err := s.repo.GetBankAccountsWithSimpleFINByLinkId(ctx, link.LinkId)
+	if err = errors.Wrap(err, "failed to read bank accounts for simplefin sync"); err != nil {
+		s.log.WithError(err).Error("cannot sync without bank accounts")
+		return nil, err
+	}
+
+	if len(bankAccounts) == 0 {
+		return nil, nil
+	}
+	s.bankAccounts = bankAccounts
+
+	secret, err := s.secrets.Read(ctx, link.SimpleFINLink.SecretId)
+	if err = errors.Wrap(err, "failed to retrieve access url for simplefin link"); err != nil {
+		s.log.WithError(err).Error("could not retrieve access URL for SimpleFIN link, this job will be retried")
+		return nil, err
+	}
+
+	s.client, err = s.simpleFINClient.NewClient(ctx, link, secret.Value)
+	if err != nil {
+		s.log.WithError(err).Error("failed to create simplefin client for link")
+		return nil, err
+	}
+
+	result := make(map[string]BankAccount, len(bankAccounts))
+	for _, bankAccount := range bankAccounts {
+		result[bankAccount.SimpleFINBankAccount.SimpleFINId] = bankAccount
+	}
+
+	return result, nil
+}
+
+// Cursor always returns nil, SimpleFIN does not provide a cursor.
+func (s *SyncSimpleFINJob) Cursor(ctx context.Context) (*string, error) {
+	return nil, nil
+}
+
+// Fetch retrieves all of the accounts and their transactions from the
+// SimpleFIN server as a single page.
+func (s *SyncSimpleFINJob) Fetch(ctx context.Context, cursor *string) (*BankSyncPage, error) {
+	simpleFINLink := s.link.SimpleFINLink
+
+	// SimpleFIN does not provide a cursor, so on every sync after the first we
+	// only look at the transactions since the last successful sync, with some
+	// overlap so we can see pending transactions post.
+	since := s.clock.Now().Add(-simpleFINInitialHistory)
+	if simpleFINLink.LastSuccessfulUpdate != nil {
+		since = simpleFINLink.LastSuccessfulUpdate.Add(-simpleFINSyncOverlap)
+	}
+	since = util.Midnight(since, s.timezone)
+
+	accountSet, err := s.client.GetAccounts(ctx, simplefin.GetAccountsOptions{
+		StartDate: &since,
+		Pending:   true,
+	})
+	if err != nil {
+		if simplefin.IsAccessRevoked(err) {
+			return nil, s.disconnectLink(ctx, simpleFINLink, err)
+		}
+
+		return nil, errors.Wrap(err, "failed to retrieve accounts from simplefin")
+	}
+	s.serverErrors = accountSet.Errors
+
+	accountsById := make(map[string]simplefin.Account, len(accountSet.Accounts))
+	for _, item := range accountSet.Accounts {
+		accountsById[item.Id] = item
+	}
+
+	page := &BankSyncPage{
+		Accounts:     make([]BankSyncAccount, 0, len(s.bankAccounts)),
+		Transactions: make([]BankSyncTransaction, 0),
+	}
+	for _, bankAccount := range s.bankAccounts {
+		simpleFINId := bankAccount.SimpleFINBankAccount.SimpleFINId
+		simpleFINAccount, ok := accountsById[simpleFINId]
+		// If the account is no longer returned by the server then it is marked as
+		// inactive and there is nothing more we can retrieve for it.
+		if !ok {
+			page.Accounts = append(page.Accounts, BankSyncAccount{
+				Id:     simpleFINId,
+				Status: InactiveBankAccountStatus,
+			})
+			continue
+		}
+
+		item := BankSyncAccount{
+			Id:           simpleFINId,
+			Status:       ActiveBankAccountStatus,
+			PendingSince: &since,
+			Data:         simpleFINAccount,
+		}
+
+		item.Balances, err = s.getBalances(simpleFINAccount)
+		if err != nil {
+			s.log.WithError(err).WithField("simpleFINId", simpleFINId).Error("failed to read balance for simplefin account")
+			crumbs.ReportError(ctx, err, "Failed to read balance for simplefin account", "job", nil)
+		}
+
+		for _, input := range simpleFINAccount.Transactions {
+			transaction, err := s.bankSyncTransaction(simpleFINAccount, input)
+			if err != nil {
+				return nil, err
+			}
+			page.Transactions = append(page.Transactions, *transaction)
+		}
+
+		page.Accounts = append(page.Accounts, item)
+	}
+
+	return page, nil
+}
+
+func (s *SyncSimpleFINJob) getBalances(simpleFINAccount simplefin.Account) (*BankSyncBalances, error) {
+	balance, err := simpleFINAccount.GetBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := simpleFINAccount.GetAvailableBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	return &BankSyncBalances{
+		Available: available,
+		Current:   balance,
+	}, nil
+}
+
+func (s *SyncSimpleFINJob) bankSyncTransaction(
+	simpleFINAccount simplefin.Account,
+	input simplefin.Transaction,
+) (*BankSyncTransaction, error) {
+	amount, err := input.GetAmount(simpleFINAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	var postedAt, transactedAt *time.Time
+	if input.Posted > 0 {
+		postedAt = myownsanity.TimeP(time.Unix(input.Posted, 0).UTC())
+	}
+	if input.TransactedAt != nil && *input.TransactedAt > 0 {
+		transactedAt = myownsanity.TimeP(time.Unix(*input.TransactedAt, 0).UTC())
+	}
+
+	description := strings.TrimSpace(input.Description)
+
+	return &BankSyncTransaction{
+		Id:           input.Id,
+		AccountId:    simpleFINAccount.Id,
+		Amount:       amount,
+		Date:         input.GetDateLocal(s.timezone, s.clock.Now()).UTC(),
+		Name:         input.GetName(),
+		OriginalName: description,
+		MerchantName: strings.TrimSpace(input.Payee),
+		IsPending:    input.GetIsPending(),
+		Data: simpleFINSyncTransaction{
+			input:        input,
+			description:  description,
+			postedAt:     postedAt,
+			transactedAt: transactedAt,
+		},
+	}, nil
+}
+
+func (s *SyncSimpleFINJob) GetTransactions(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	ids []string,
+) (map[string]Transaction, error) {
+	return s.repo.GetTransactionsBySimpleFINId(ctx, bankAccount.BankAccountId, ids)
+}
+
+func (s *SyncSimpleFINJob) GetPendingTransactions(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	since time.Time,
+) (map[string]Transaction, error) {
+	pending, err := s.repo.GetPendingSimpleFINTransactions(
+		ctx,
+		bankAccount.BankAccountId,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Transaction, len(pending))
+	for i := range pending {
+		if pending[i].SimpleFINTransaction == nil {
+			continue
+		}
+		result[pending[i].SimpleFINTransaction.SimpleFINId] = pending[i]
+	}
+
+	return result, nil
+}
+
+func (s *SyncSimpleFINJob) CreateTransaction(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	transaction *Transaction,
+	input BankSyncTransaction,
+) error {
+	data := input.Data.(simpleFINSyncTransaction)
+	simpleFINTransaction := SimpleFINTransaction{
+		SimpleFINTransactionId: NewID(&SimpleFINTransaction{}),
+		AccountId:              bankAccount.AccountId,
+		SimpleFINBankAccountId: *bankAccount.SimpleFINBankAccountId,
+		SimpleFINId:            input.Id,
+		Description:            data.description,
+		Payee:                  input.MerchantName,
+		Memo:                   data.input.Memo,
+		Amount:                 input.Amount,
+		IsPending:              input.IsPending,
+		PostedAt:               data.postedAt,
+		TransactedAt:           data.transactedAt,
+	}
+	s.simpleFINTransactions = append(s.simpleFINTransactions, &simpleFINTransaction)
+	transaction.SimpleFINTransactionId = &simpleFINTransaction.SimpleFINTransactionId
+
+	return nil
+}
+
+func (s *SyncSimpleFINJob) UpdateTransaction(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	transaction *Transaction,
+	input BankSyncTransaction,
+) ([]SyncChange, error) {
+	simpleFINTransaction := transaction.SimpleFINTransaction
+	if simpleFINTransaction == nil {
+		crumbs.IndicateBug(ctx, "Existing transaction did not correctly have the associated simplefin transaction stored", map[string]interface{}{
+			"simpleFINId":   input.Id,
+			"bankAccountId": bankAccount.BankAccountId,
+		})
+		return nil, errors.New("existing simplefin transaction is missing")
+	}
+
+	data := input.Data.(simpleFINSyncTransaction)
+	simpleFINChanged := simpleFINTransaction.Amount != input.Amount ||
+		simpleFINTransaction.IsPending != input.IsPending ||
+		simpleFINTransaction.Description != data.description ||
+		simpleFINTransaction.Payee != input.MerchantName ||
+		simpleFINTransaction.Memo != data.input.Memo ||
+		!myownsanity.TimesPEqual(simpleFINTransaction.PostedAt, data.postedAt) ||
+		!myownsanity.TimesPEqual(simpleFINTransaction.TransactedAt, data.transactedAt) ||
+		simpleFINTransaction.DeletedAt != nil
+	if simpleFINChanged {
+		simpleFINTransaction.Amount = input.Amount
+		simpleFINTransaction.IsPending = input.IsPending
+		simpleFINTransaction.Description = data.description
+		simpleFINTransaction.Payee = input.MerchantName
+		simpleFINTransaction.Memo = data.input.Memo
+		simpleFINTransaction.PostedAt = data.postedAt
+		simpleFINTransaction.TransactedAt = data.transactedAt
+		simpleFINTransaction.DeletedAt = nil
+		if err := s.repo.UpdateSimpleFINTransaction(ctx, simpleFINTransaction); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// RemoveTransaction marks the simplefin transaction as deleted as well, this
+// way it will be restored if the server returns it again.
+func (s *SyncSimpleFINJob) RemoveTransaction(ctx context.Context, transaction *Transaction) error {
+	simpleFINTransaction := transaction.SimpleFINTransaction
+	if simpleFINTransaction == nil {
+		return nil
+	}
+
+	simpleFINTransaction.DeletedAt = myownsanity.TimeP(s.clock.Now().UTC())
+	return s.repo.UpdateSimpleFINTransaction(ctx, simpleFINTransaction)
+}
+
+func (s *SyncSimpleFINJob) Flush(ctx context.Context) error {
+	if len(s.simpleFINTransactions) == 0 {
+		return nil
+	}
+
+	s.log.Infof("creating %d simplefin transactions", len(s.simpleFINTransactions))
+	if err := s.repo.CreateSimpleFINTransactions(ctx, s.simpleFINTransactions...); err != nil {
+		return err
+	}
+	s.simpleFINTransactions = make([]*SimpleFINTransaction, 0)
+
+	return nil
+}
+
+func (s *SyncSimpleFINJob) UpdateBankAccount(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	input BankSyncAccount,
+	changes []SyncChange,
+) ([]SyncChange, error) {
+	simpleFINBankAccount := bankAccount.SimpleFINBankAccount
+	simpleFINAccount, ok := input.Data.(simplefin.Account)
+	if !ok {
+		return changes, nil
+	}
+
+	simpleFINChanged := false
+	if simpleFINAccount.Name != simpleFINBankAccount.Name {
+		changes = append(changes, SyncChange{
+			Field: "name",
+			Old:   simpleFINBankAccount.Name,
+			New:   simpleFINAccount.Name,
+		})
+		simpleFINBankAccount.Name = simpleFINAccount.Name
+		bankAccount.OriginalName = simpleFINAccount.Name
+		simpleFINChanged = true
+	}
+
+	if balances := input.Balances; balances != nil {
+		balanceDate := simpleFINAccount.GetBalanceDate()
+		if simpleFINBankAccount.BalanceDate == nil || !simpleFINBankAccount.BalanceDate.Equal(balanceDate) {
+			simpleFINBankAccount.BalanceDate = &balanceDate
+			simpleFINChanged = true
+		}
+
+		if balances.Available != simpleFINBankAccount.AvailableBalance ||
+			balances.Current != simpleFINBankAccount.Balance {
+			simpleFINBankAccount.AvailableBalance = balances.Available
+			simpleFINBankAccount.Balance = balances.Current
+			simpleFINChanged = true
+		}
+	}
+
+	if simpleFINChanged {
+		if err := s.repo.UpdateSimpleFINBankAccount(ctx, simpleFINBankAccount); err != nil {
+			return nil, errors.Wrap(err, "failed to persists simplefin bank account changes from simplefin sync")
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *SyncSimpleFINJob) Complete(ctx context.Context) error {
+	return s.maintainLinkStatus(ctx, s.link.SimpleFINLink)
+}
+
+// disconnectLink is called when the SimpleFIN server tells us that the access
+// URL can no longer be used. The link is marked as disconnected and the job
+// does not fail, retrying would not change anything.
+func (s *SyncSimpleFINJob) disconnectLink(
+	ctx context.Context,
+	simpleFINLink *SimpleFINLink,
+	cause error,
+) error {
+	s.log.WithError(cause).Warn("simplefin access has been revoked, link will be marked as disconnected")
+
+	simpleFINLink.Status = SimpleFINLinkStatusDisconnected
+	if simpleFINError, ok := errors.Cause(cause).(*simplefin.Error); ok {
+		message := simpleFINError.Message
+		if message == "" {
+			message = fmt.Sprintf("access revoked (%d)", simpleFINError.StatusCode)
+		}
+		simpleFINLink.ErrorCode = &message
+	}
+	simpleFINLink.LastAttemptedUpdate = myownsanity.TimeP(s.clock.Now().UTC())
+	if err := s.repo.UpdateSimpleFINLink(ctx, simpleFINLink); err != nil {
+		s.log.WithError(err).Error("failed to update simplefin link status")
+		return err
+	}
+
+	return nil
+}
+
+func (s *SyncSimpleFINJob) maintainLinkStatus(ctx context.Context, simpleFINLink *SimpleFINLink) error {
+	linkWasSetup := false
+	if simpleFINLink.Status != SimpleFINLinkStatusSetup {
+		crumbs.Debug(ctx, "Updating simplefin link status.", map[string]interface{}{
+			"old": simpleFINLink.Status,
+			"new": SimpleFINLinkStatusSetup,
+		})
+		simpleFINLink.Status = SimpleFINLinkStatusSetup
+		linkWasSetup = true
+	}
+
+	// Errors returned by the server with the accounts are meant to be shown to
+	// the user, usually they mean one of the institutions needs attention on
+	// the SimpleFIN server itself.
+	if len(s.serverErrors) > 0 {
+		simpleFINLink.ErrorCode = myownsanity.StringP(strings.Join(s.serverErrors, "\n"))
+	} else {
+		simpleFINLink.ErrorCode = nil
+	}
+	simpleFINLink.LastSuccessfulUpdate = myownsanity.TimeP(s.clock.Now().UTC())
+	simpleFINLink.LastAttemptedUpdate = myownsanity.TimeP(s.clock.Now().UTC())
+	if err := s.repo.UpdateSimpleFINLink(ctx, simpleFINLink); err != nil {
+		s.log.WithError(err).Error("failed to update link after transaction sync")
+		return err
+	}
+
+	if linkWasSetup { // Send the notification that the link has been set up.
+		channelName := fmt.Sprintf("initial:simplefin:link:%s:%s", s.args.AccountId, s.args.LinkId)
+		if notifyErr := s.publisher.Notify(
+			ctx,
+			channelName,
+			"success",
+		); notifyErr != nil {
+			s.log.WithError(notifyErr).Error("failed to publish link status to pubsub")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package background
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mock_simplefin"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSyncSimpleFINJob_Run(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		clock := clock.NewMock()
+		clock.Set(time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC))
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		publisher := pubsub.NewPostgresPubSub(log, db)
+		kms := secrets.NewPlaintextKMS()
+		accessUrl := mock_simplefin.AccessURLFixture(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveASimpleFINLink(t, clock, user, accessUrl)
+
+		simpleFINAccount := mock_simplefin.AccountFixture(t)
+		bankAccount := fixtures.GivenIHaveASimpleFINBankAccount(
+			t,
+			clock,
+			&link,
+			simpleFINAccount.Id,
+		)
+
+		simpleFINClient := simplefin.NewSimpleFIN(log, config.SimpleFIN{
+			Enabled: true,
+		})
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(CalculateTransactionClusters),
+				testutils.NewGenericMatcher(func(args CalculateTransactionClustersArguments) bool {
+					return assert.Equal(t, bankAccount.BankAccountId, args.BankAccountId) &&
+						assert.Equal(t, bankAccount.AccountId, args.AccountId)
+				}),
+			).
+			Times(2).
+			Return(nil)
+
+		handler := NewSyncSimpleFINHandler(
+			log,
+			db,
+			clock,
+			kms,
+			simpleFINClient,
+			publisher,
+			enqueuer,
+		)
+
+		args := SyncSimpleFINArguments{
+			AccountId: user.AccountId,
+			LinkId:    link.LinkId,
+			Trigger:   "manual",
+		}
+		argsEncoded, err := DefaultJobMarshaller(args)
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		posted := mock_simplefin.TransactionFixture(t, clock.Now().AddDate(0, 0, -2), false)
+		pending := mock_simplefin.TransactionFixture(t, clock.Now().AddDate(0, 0, -1), true)
+		simpleFINAccount.Transactions = []simplefin.Transaction{posted, pending}
+		mock_simplefin.MockGetAccounts(t, accessUrl, []simplefin.Account{simpleFINAccount})
+
+		{ // Do our first simplefin sync.
+			fixtures.AssertThatIHaveZeroTransactions(t, user.AccountId)
+
+			err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+			assert.NoError(t, err, "must process job successfully")
+		}
+
+		assert.EqualValues(t, 2, fixtures.CountNonDeletedTransactions(t, user.AccountId), "should have both transactions")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		{ // Make sure the amounts were inverted and the balance was updated.
+			transactions, err := repo.GetTransactionsBySimpleFINId(
+				context.Background(),
+				bankAccount.BankAccountId,
+				[]string{posted.Id, pending.Id},
+			)
+			require.NoError(t, err, "must be able to retrieve transactions")
+			require.Len(t, transactions, 2)
+
+			amount, err := posted.GetAmount(simpleFINAccount)
+			require.NoError(t, err)
+			assert.Positive(t, amount, "debits should be positive in monetr")
+			assert.EqualValues(t, amount, transactions[posted.Id].Amount, "amount should match")
+			assert.False(t, transactions[posted.Id].IsPending, "posted transaction should not be pending")
+			assert.True(t, transactions[pending.Id].IsPending, "pending transaction should be pending")
+			assert.Equal(t, models.TransactionSourceSimpleFIN, transactions[pending.Id].Source)
+
+			updatedBankAccount, err := repo.GetBankAccount(context.Background(), bankAccount.BankAccountId)
+			require.NoError(t, err, "must be able to retrieve bank account")
+			balance, err := simpleFINAccount.GetBalance()
+			require.NoError(t, err)
+			assert.EqualValues(t, balance, updatedBankAccount.CurrentBalance, "current balance should be updated")
+		}
+
+		// Now the pending transaction disappears and is replaced by a new posted
+		// transaction with a different ID. The posted transaction is returned
+		// again and must not be duplicated.
+		clock.Add(7 * time.Hour)
+		cleared := mock_simplefin.TransactionFixture(t, clock.Now(), false)
+		simpleFINAccount.Transactions = []simplefin.Transaction{posted, cleared}
+		mock_simplefin.MockGetAccounts(t, accessUrl, []simplefin.Account{simpleFINAccount})
+
+		{ // Do our second simplefin sync.
+			err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+			assert.NoError(t, err, "must process job successfully")
+		}
+
+		assert.EqualValues(t, 2, fixtures.CountNonDeletedTransactions(t, user.AccountId), "pending transaction should have been removed")
+		assert.EqualValues(t, 3, fixtures.CountAllTransactions(t, user.AccountId), "pending transaction should be soft deleted")
+
+		updatedLink, err := repo.GetLink(context.Background(), link.LinkId)
+		require.NoError(t, err, "must be able to retrieve link")
+		assert.Equal(t, models.SimpleFINLinkStatusSetup, updatedLink.SimpleFINLink.Status)
+		assert.NotNil(t, updatedLink.SimpleFINLink.LastSuccessfulUpdate)
+	})
+
+	t.Run("access revoked", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		clock := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		publisher := pubsub.NewPostgresPubSub(log, db)
+		kms := secrets.NewPlaintextKMS()
+		accessUrl := mock_simplefin.AccessURLFixture(t)
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveASimpleFINLink(t, clock, user, accessUrl)
+		fixtures.GivenIHaveASimpleFINBankAccount(t, clock, &link, "ACT-test")
+
+		simpleFINClient := simplefin.NewSimpleFIN(log, config.SimpleFIN{
+			Enabled: true,
+		})
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+
+		mock_simplefin.MockAccessRevoked(t, accessUrl)
+
+		handler := NewSyncSimpleFINHandler(
+			log,
+			db,
+			clock,
+			kms,
+			simpleFINClient,
+			publisher,
+			enqueuer,
+		)
+
+		argsEncoded, err := DefaultJobMarshaller(SyncSimpleFINArguments{
+			AccountId: user.AccountId,
+			LinkId:    link.LinkId,
+			Trigger:   "cron",
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "revoked access should not fail the job")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		updatedLink, err := repo.GetLink(context.Background(), link.LinkId)
+		require.NoError(t, err, "must be able to retrieve link")
+		assert.Equal(t, models.SimpleFINLinkStatusDisconnected, updatedLink.SimpleFINLink.Status)
+		assert.NotNil(t, updatedLink.SimpleFINLink.ErrorCode)
+		fixtures.AssertThatIHaveZeroTransactions(t, user.AccountId)
+	})
+}
@@ -0,0 +1,805 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SyncTeller = "SyncTeller"
+
+	// tellerTransactionPageSize is how many transactions are requested from
+	// Teller at a time for a single account.
+	tellerTransactionPageSize = 250
+	// tellerTransactionMaxPages limits how many pages of transactions will be
+	// retrieved for a single account in a single sync.
+	tellerTransactionMaxPages = 20
+	// tellerSyncOverlap is how far before the last successful sync transactions
+	// will be retrieved again. Teller does not provide a cursor so we look back
+	// far enough to see pending transactions clear or disappear.
+	tellerSyncOverlap = 30 * 24 * time.Hour
+)
+
+var (
+	_ ScheduledJobHandler = &SyncTellerHandler{}
+	_ JobImplementation   = &SyncTellerJob{}
+	_ BankSyncProvider    = &SyncTellerJob{}
+)
+
+type (
+	SyncTellerHandler struct {
+		log          *logrus.Entry
+		db           *pg.DB
+		kms          secrets.KeyManagement
+		tellerClient teller.Teller
+		publisher    pubsub.Publisher
+		enqueuer     JobEnqueuer
+		unmarshaller JobUnmarshaller
+		clock        clock.Clock
+	}
+
+	SyncTellerArguments struct {
+		AccountId ID[Account] `json:"accountId"`
+		LinkId    ID[Link]    `json:"linkId"`
+		// Trigger will be "webhook" or "manual" or "cron"
+		Trigger string `json:"trigger"`
+	}
+
+	SyncTellerJob struct {
+		args         SyncTellerArguments
+		log          *logrus.Entry
+		repo         repository.BaseRepository
+		secrets      repository.SecretsRepository
+		tellerClient teller.Teller
+		publisher    pubsub.Publisher
+		enqueuer     JobEnqueuer
+		clock        clock.Clock
+
+		timezone           *time.Location
+		link               *Link
+		client             teller.Client
+		bankAccounts       []BankAccount
+		tellerTransactions []*TellerTransaction
+	}
+
+	// tellerSyncTransaction is provided as the data of each transaction given
+	// to the bank sync, it has everything needed for the teller transaction.
+	tellerSyncTransaction struct {
+		input          teller.Transaction
+		date           time.Time
+		runningBalance *int64
+	}
+)
+
+func TriggerSyncTeller(
+	ctx context.Context,
+	backgroundJobs JobController,
+	arguments SyncTellerArguments,
+) error {
+	if arguments.Trigger == "" {
+		arguments.Trigger = "manual"
+	}
+	return backgroundJobs.EnqueueJob(ctx, SyncTeller, arguments)
+}
+
+func NewSyncTellerHandler(
+	log *logrus.Entry,
+	db *pg.DB,
+	clock clock.Clock,
+	kms secrets.KeyManagement,
+	tellerClient teller.Teller,
+	publisher pubsub.Publisher,
+	enqueuer JobEnqueuer,
+) *SyncTellerHandler {
+	return &SyncTellerHandler{
+		log:          log,
+		db:           db,
+		kms:          kms,
+		tellerClient: tellerClient,
+		publisher:    publisher,
+		enqueuer:     enqueuer,
+		unmarshaller: DefaultJobUnmarshaller,
+		clock:        clock,
+	}
+}
+
+func (s SyncTellerHandler) QueueName() string {
+	return SyncTeller
+}
+
+func (s *SyncTellerHandler) HandleConsumeJob(
+	ctx context.Context,
+	log *logrus.Entry,
+	data []byte,
+) error {
+	var args SyncTellerArguments
+	if err := errors.Wrap(s.unmarshaller(data, &args), "failed to unmarshal arguments"); err != nil {
+		crumbs.Error(ctx, "Failed to unmarshal arguments for Sync Teller job.", "job", map[string]interface{}{
+			"data": data,
+		})
+		return err
+	}
+
+	crumbs.IncludeUserInScope(ctx, args.AccountId)
+
+	return s.db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+		span := sentry.StartSpan(ctx, "db.transaction")
+		defer span.Finish()
+
+		log := log.WithContext(span.Context())
+
+		repo := repository.NewRepositoryFromSession(s.clock, "user_teller", args.AccountId, txn)
+		secretsRepo := repository.NewSecretsRepository(
+			log,
+			s.clock,
+			txn,
+			s.kms,
+			args.AccountId,
+		)
+		job, err := NewSyncTellerJob(
+			log,
+			repo,
+			s.clock,
+			secretsRepo,
+			s.tellerClient,
+			s.publisher,
+			s.enqueuer,
+			args,
+		)
+		if err != nil {
+			return err
+		}
+		return job.Run(span.Context())
+	})
+}
+
+func (s SyncTellerHandler) DefaultSchedule() string {
+	// Teller does not send webhooks for every change the way Plaid does, so
+	// links are checked more often than Plaid links are.
+	return "0 0 */6 * * *"
+}
+
+func (s *SyncTellerHandler) EnqueueTriggeredJob(ctx context.Context, enqueuer JobEnqueuer) error {
+	log := s.log.WithContext(ctx)
+
+	log.Info("retrieving links to sync with Teller")
+
+	links := make([]Link, 0)
+	cutoff := s.clock.Now().Add(-6 * time.Hour)
+	err := s.db.ModelContext(ctx, &links).
+		Join(`INNER JOIN "teller_links" AS "teller_link"`).
+		JoinOn(`"teller_link"."teller_link_id" = "link"."teller_link_id"`).
+		JoinOn(`"teller_link"."account_id" = "link"."account_id"`).
+		Where(`"teller_link"."status" = ?`, TellerLinkStatusSetup).
+		Where(`"teller_link"."last_attempted_update" < ?`, cutoff).
+		Where(`"teller_link"."deleted_at" IS NULL`).
+		Where(`"link"."link_type" = ?`, TellerLinkType).
+		Where(`"link"."deleted_at" IS NULL`).
+		Select(&links)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve links that need to by synced with teller")
+	}
+
+	if len(links) == 0 {
+		log.Debug("no teller links need to be synced at this time")
+		return nil
+	}
+
+	log.WithField("count", len(links)).Info("syncing teller links")
+
+	for _, item := range links {
+		itemLog := log.WithFields(logrus.Fields{
+			"accountId": item.AccountId,
+			"linkId":    item.LinkId,
+		})
+		itemLog.Trace("enqueuing link to be synced with teller")
+		err := enqueuer.EnqueueJob(ctx, s.QueueName(), SyncTellerArguments{
+			AccountId: item.AccountId,
+			LinkId:    item.LinkId,
+			Trigger:   "cron",
+		})
+		if err != nil {
+			itemLog.WithError(err).Warn("failed to enqueue job to sync with teller")
+			crumbs.Warn(ctx, "Failed to enqueue job to sync with teller", "job", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+
+		itemLog.Trace("successfully enqueued link to be synced with teller")
+	}
+
+	return nil
+}
+
+func NewSyncTellerJob(
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	clock clock.Clock,
+	secrets repository.SecretsRepository,
+	tellerClient teller.Teller,
+	publisher pubsub.Publisher,
+	enqueuer JobEnqueuer,
+	args SyncTellerArguments,
+) (*SyncTellerJob, error) {
+	return &SyncTellerJob{
+		args:         args,
+		log:          log,
+		repo:         repo,
+		secrets:      secrets,
+		tellerClient: tellerClient,
+		publisher:    publisher,
+		enqueuer:     enqueuer,
+		clock:        clock,
+
+		timezone:           nil, // Is set by Setup
+		tellerTransactions: make([]*TellerTransaction, 0),
+	}, nil
+}
+
+func (s *SyncTellerJob) Run(ctx context.Context) error {
+	span := sentry.StartSpan(ctx, "job.exec")
+	defer span.Finish()
+	crumbs.AddTag(span.Context(), "linkId", s.args.LinkId.String())
+
+	log := s.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId": s.args.AccountId,
+		"linkId":    s.args.LinkId,
+	})
+
+	link, err := s.repo.GetLink(span.Context(), s.args.LinkId)
+	if err = errors.Wrap(err, "failed to retrieve link to sync with teller"); err != nil {
+		log.WithError(err).Error("cannot sync without link")
+		return err
+	}
+
+	if link.TellerLink == nil {
+		log.Warn("provided link does not have any teller credentials")
+		crumbs.IndicateBug(
+			span.Context(),
+			"BUG: Link was queued to sync with teller, but has no teller details",
+			map[string]interface{}{
+				"link": link,
+			},
+		)
+		span.Status = sentry.SpanStatusFailedPrecondition
+		return nil
+	}
+
+	tellerLink := link.TellerLink
+	if tellerLink.DeletedAt != nil || tellerLink.Status == TellerLinkStatusDisconnected {
+		log.Info("teller link is no longer connected, it will not be synced")
+		return nil
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"tellerLinkId": tellerLink.TellerLinkId,
+		"teller": logrus.Fields{
+			"enrollmentId":    tellerLink.EnrollmentId,
+			"institutionName": tellerLink.InstitutionName,
+		},
+	})
+
+	// This way other methods will have these log fields too.
+	s.log = log
+
+	return newBankSync(
+		log,
+		s.repo,
+		s.clock,
+		s.enqueuer,
+		s,
+		s.args.Trigger,
+	).Run(span.Context(), link)
+}
+
+func (s *SyncTellerJob) Source() TransactionSource {
+	return TransactionSourceTeller
+}
+
+func (s *SyncTellerJob) Setup(
+	ctx context.Context,
+	link *Link,
+	timezone *time.Location,
+) (map[string]BankAccount, error) {
+	s.link = link
+	s.timezone = timezone
+
+	bankAccounts, err := s.repo.GetBankAccountsWithTellerByLinkId(ctx, link.LinkId)
+	if err = errors.Wrap(err, "failed to read bank accounts for teller sync"); err != nil {
+		s.log.WithError(err).Error("cannot sync without bank accounts")
+		return nil, err
+	}
+
+	if len(bankAccounts) == 0 {
+		return nil, nil
+	}
+	s.bankAccounts = bankAccounts
+
+	secret, err := s.secrets.Read(ctx, link.TellerLink.SecretId)
+	if err = errors.Wrap(err, "failed to retrieve access token for teller link"); err != nil {
+		s.log.WithError(err).Error("could not retrieve API credentials for Teller for link, this job will be retried")
+		return nil, err
+	}
+
+	s.client, err = s.tellerClient.NewClient(ctx, link, secret.Value)
+	if err != nil {
+		s.log.WithError(err).Error("failed to create teller client for link")
+		return nil, err
+	}
+
+	result := make(map[string]BankAccount, len(bankAccounts))
+	for _, bankAccount := range bankAccounts {
+		result[bankAccount.TellerBankAccount.TellerId] = bankAccount
+	}
+
+	return result, nil
+}
+
+// Cursor always returns nil, Teller does not provide a cursor.
+func (s *SyncTellerJob) Cursor(ctx context.Context) (*string, error) {
+	return nil, nil
+}
+
+// Fetch retrieves the accounts, balances and transactions for the entire
+// enrollment from Teller as a single page.
+func (s *SyncTellerJob) Fetch(ctx context.Context, cursor *string) (*BankSyncPage, error) {
+	tellerLink := s.link.TellerLink
+	tellerAccounts, err := s.client.GetAccounts(ctx)
+	if err != nil {
+		if teller.IsEnrollmentDisconnected(err) {
+			return nil, s.disconnectLink(ctx, tellerLink, err)
+		}
+
+		return nil, errors.Wrap(err, "failed to retrieve accounts from teller")
+	}
+
+	accountsById := make(map[string]teller.Account, len(tellerAccounts))
+	for _, item := range tellerAccounts {
+		accountsById[item.Id] = item
+	}
+
+	// Teller does not provide a cursor, so on every sync after the first we
+	// only look at the transactions since the last successful sync, with some
+	// overlap so we can see pending transactions change.
+	var since *time.Time
+	if tellerLink.LastSuccessfulUpdate != nil {
+		since = myownsanity.TimeP(tellerLink.LastSuccessfulUpdate.Add(-tellerSyncOverlap))
+	}
+
+	page := &BankSyncPage{
+		Accounts:     make([]BankSyncAccount, 0, len(s.bankAccounts)),
+		Transactions: make([]BankSyncTransaction, 0),
+	}
+	for _, bankAccount := range s.bankAccounts {
+		tellerId := bankAccount.TellerBankAccount.TellerId
+		tellerAccount, ok := accountsById[tellerId]
+		// If the account is no longer part of the enrollment then it is marked as
+		// inactive and there is nothing more we can retrieve for it.
+		if !ok {
+			page.Accounts = append(page.Accounts, BankSyncAccount{
+				Id:     tellerId,
+				Status: InactiveBankAccountStatus,
+			})
+			continue
+		}
+
+		item := BankSyncAccount{
+			Id:     tellerId,
+			Status: tellerAccount.GetBankAccountStatus(),
+			Data:   tellerAccount,
+		}
+
+		item.Balances, err = s.getBalances(ctx, tellerAccount)
+		if err != nil {
+			if teller.IsEnrollmentDisconnected(err) {
+				return nil, s.disconnectLink(ctx, tellerLink, err)
+			}
+
+			// Balances can be updated by the next sync, it should not stop us from
+			// retrieving transactions.
+			s.log.WithError(err).WithField("tellerId", tellerId).Error("failed to retrieve balance for teller account")
+			crumbs.ReportError(ctx, err, "Failed to retrieve balance for teller account", "job", nil)
+		}
+
+		tellerTransactions, err := s.getTransactions(ctx, tellerAccount, since)
+		if err != nil {
+			if teller.IsEnrollmentDisconnected(err) {
+				return nil, s.disconnectLink(ctx, tellerLink, err)
+			}
+
+			return nil, err
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"tellerId": tellerId,
+			"count":    len(tellerTransactions),
+		}).Debug("retrieved transactions from teller")
+
+		for _, input := range tellerTransactions {
+			transaction, err := s.bankSyncTransaction(tellerAccount, input)
+			if err != nil {
+				return nil, err
+			}
+			page.Transactions = append(page.Transactions, *transaction)
+		}
+
+		// Transactions are returned newest first, the oldest one tells us how
+		// far back we can trust that a missing pending transaction is really
+		// gone.
+		if count := len(tellerTransactions); count > 0 {
+			oldest, err := tellerTransactions[count-1].GetDateLocal(s.timezone)
+			if err != nil {
+				return nil, err
+			}
+			item.PendingSince = &oldest
+		}
+
+		page.Accounts = append(page.Accounts, item)
+	}
+
+	return page, nil
+}
+
+func (s *SyncTellerJob) getBalances(
+	ctx context.Context,
+	tellerAccount teller.Account,
+) (*BankSyncBalances, error) {
+	balance, err := s.client.GetAccountBalance(ctx, tellerAccount.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := balance.GetAvailable(tellerAccount.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger, err := balance.GetLedger(tellerAccount.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BankSyncBalances{
+		Available: available,
+		Current:   ledger,
+	}, nil
+}
+
+// getTransactions retrieves transactions for the account from Teller, newest
+// first. If since is provided then pages are only retrieved until a
+// transaction before that date is seen.
+func (s *SyncTellerJob) getTransactions(
+	ctx context.Context,
+	tellerAccount teller.Account,
+	since *time.Time,
+) ([]teller.Transaction, error) {
+	result := make([]teller.Transaction, 0)
+	var fromId *string
+	for page := 0; page < tellerTransactionMaxPages; page++ {
+		items, err := s.client.GetTransactions(
+			ctx,
+			tellerAccount.Id,
+			fromId,
+			tellerTransactionPageSize,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+
+		if len(items) < tellerTransactionPageSize {
+			break
+		}
+
+		last := items[len(items)-1]
+		if since != nil {
+			date, err := last.GetDateLocal(s.timezone)
+			if err != nil {
+				return nil, err
+			}
+			if date.Before(*since) {
+				break
+			}
+		}
+
+		fromId = &last.Id
+	}
+
+	return result, nil
+}
+
+func (s *SyncTellerJob) bankSyncTransaction(
+	tellerAccount teller.Account,
+	input teller.Transaction,
+) (*BankSyncTransaction, error) {
+	amount, err := input.GetAmount(tellerAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	runningBalance, err := input.GetRunningBalance(tellerAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := input.GetDateLocal(s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	tellerDate, err := input.GetDate()
+	if err != nil {
+		return nil, err
+	}
+
+	transactionName := input.Description
+	merchantName := input.GetMerchantName()
+	// Same as Plaid, prefer the merchant name when it is shorter than the
+	// description since it is usually the cleaner of the two.
+	if merchantName != "" && len(merchantName) < len(transactionName) {
+		transactionName = merchantName
+	}
+
+	return &BankSyncTransaction{
+		Id:           input.Id,
+		AccountId:    tellerAccount.Id,
+		Amount:       amount,
+		Date:         date.UTC(),
+		Name:         transactionName,
+		OriginalName: input.Description,
+		MerchantName: merchantName,
+		Category:     input.Details.Category,
+		IsPending:    input.GetIsPending(),
+		Data: tellerSyncTransaction{
+			input:          input,
+			date:           tellerDate,
+			runningBalance: runningBalance,
+		},
+	}, nil
+}
+
+func (s *SyncTellerJob) GetTransactions(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	ids []string,
+) (map[string]Transaction, error) {
+	transactions, err := s.repo.GetTransactionsByTellerId(ctx, s.link.LinkId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Transaction, len(transactions))
+	for tellerId, transaction := range transactions {
+		if transaction.BankAccountId != bankAccount.BankAccountId {
+			continue
+		}
+		result[tellerId] = transaction
+	}
+
+	return result, nil
+}
+
+func (s *SyncTellerJob) GetPendingTransactions(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	since time.Time,
+) (map[string]Transaction, error) {
+	pending, err := s.repo.GetPendingTellerTransactions(
+		ctx,
+		bankAccount.BankAccountId,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Transaction, len(pending))
+	for i := range pending {
+		if pending[i].TellerTransaction == nil {
+			continue
+		}
+		result[pending[i].TellerTransaction.TellerId] = pending[i]
+	}
+
+	return result, nil
+}
+
+func (s *SyncTellerJob) CreateTransaction(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	transaction *Transaction,
+	input BankSyncTransaction,
+) error {
+	data := input.Data.(tellerSyncTransaction)
+	tellerTransaction := TellerTransaction{
+		TellerTransactionId: NewID(&TellerTransaction{}),
+		AccountId:           bankAccount.AccountId,
+		TellerBankAccountId: *bankAccount.TellerBankAccountId,
+		TellerId:            input.Id,
+		Name:                data.input.Description,
+		Category:            input.Category,
+		Type:                data.input.Type,
+		Date:                data.date,
+		IsPending:           input.IsPending,
+		Amount:              input.Amount,
+		RunningBalance:      data.runningBalance,
+	}
+	s.tellerTransactions = append(s.tellerTransactions, &tellerTransaction)
+	transaction.TellerTransactionId = &tellerTransaction.TellerTransactionId
+
+	return nil
+}
+
+func (s *SyncTellerJob) UpdateTransaction(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	transaction *Transaction,
+	input BankSyncTransaction,
+) ([]SyncChange, error) {
+	tellerTransaction := transaction.TellerTransaction
+	if tellerTransaction == nil {
+		crumbs.IndicateBug(ctx, "Existing transaction did not correctly have the associated teller transaction stored", map[string]interface{}{
+			"tellerId":      input.Id,
+			"bankAccountId": bankAccount.BankAccountId,
+		})
+		return nil, errors.New("existing teller transaction is missing")
+	}
+
+	data := input.Data.(tellerSyncTransaction)
+	tellerChanged := tellerTransaction.Amount != input.Amount ||
+		tellerTransaction.IsPending != input.IsPending ||
+		tellerTransaction.Name != data.input.Description ||
+		!tellerTransaction.Date.Equal(data.date) ||
+		!myownsanity.StringPEqual(tellerTransaction.Category, input.Category) ||
+		tellerTransaction.DeletedAt != nil
+	if tellerChanged {
+		tellerTransaction.Amount = input.Amount
+		tellerTransaction.IsPending = input.IsPending
+		tellerTransaction.Name = data.input.Description
+		tellerTransaction.Date = data.date
+		tellerTransaction.Category = input.Category
+		tellerTransaction.RunningBalance = data.runningBalance
+		tellerTransaction.DeletedAt = nil
+		if err := s.repo.UpdateTellerTransaction(ctx, tellerTransaction); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// RemoveTransaction marks the teller transaction as deleted as well, this way
+// it will be restored if Teller returns it again.
+func (s *SyncTellerJob) RemoveTransaction(ctx context.Context, transaction *Transaction) error {
+	tellerTransaction := transaction.TellerTransaction
+	if tellerTransaction == nil {
+		return nil
+	}
+
+	tellerTransaction.DeletedAt = myownsanity.TimeP(s.clock.Now().UTC())
+	return s.repo.UpdateTellerTransaction(ctx, tellerTransaction)
+}
+
+func (s *SyncTellerJob) Flush(ctx context.Context) error {
+	if len(s.tellerTransactions) == 0 {
+		return nil
+	}
+
+	s.log.Infof("creating %d teller transactions", len(s.tellerTransactions))
+	if err := s.repo.CreateTellerTransactions(ctx, s.tellerTransactions...); err != nil {
+		return err
+	}
+	s.tellerTransactions = make([]*TellerTransaction, 0)
+
+	return nil
+}
+
+func (s *SyncTellerJob) UpdateBankAccount(
+	ctx context.Context,
+	bankAccount *BankAccount,
+	input BankSyncAccount,
+	changes []SyncChange,
+) ([]SyncChange, error) {
+	tellerBankAccount := bankAccount.TellerBankAccount
+	if tellerAccount, ok := input.Data.(teller.Account); ok {
+		tellerBankAccount.Status = string(tellerAccount.Status)
+
+		if tellerAccount.Name != tellerBankAccount.Name {
+			changes = append(changes, SyncChange{
+				Field: "name",
+				Old:   tellerBankAccount.Name,
+				New:   tellerAccount.Name,
+			})
+			tellerBankAccount.Name = tellerAccount.Name
+			bankAccount.OriginalName = tellerAccount.Name
+		}
+	}
+
+	if balances := input.Balances; balances != nil {
+		tellerBankAccount.BalancedAt = myownsanity.TimeP(s.clock.Now().UTC())
+		tellerBankAccount.AvailableBalance = balances.Available
+		tellerBankAccount.LedgerBalance = balances.Current
+	}
+
+	// The balance timestamp changes on every sync, so the Teller bank account is
+	// always updated.
+	if err := s.repo.UpdateTellerBankAccount(ctx, tellerBankAccount); err != nil {
+		return nil, errors.Wrap(err, "failed to persists teller bank account changes from teller sync")
+	}
+
+	return changes, nil
+}
+
+func (s *SyncTellerJob) Complete(ctx context.Context) error {
+	return s.maintainLinkStatus(ctx, s.link.TellerLink)
+}
+
+// disconnectLink is called when Teller tells us that the enrollment needs to
+// be reconnected by the user. The link is marked as disconnected and the job
+// does not fail, retrying would not change anything.
+func (s *SyncTellerJob) disconnectLink(
+	ctx context.Context,
+	tellerLink *TellerLink,
+	cause error,
+) error {
+	s.log.WithError(cause).Warn("teller enrollment has been disconnected, link will be marked as disconnected")
+
+	tellerLink.Status = TellerLinkStatusDisconnected
+	if tellerError, ok := errors.Cause(cause).(*teller.Error); ok {
+		tellerLink.ErrorCode = &tellerError.Code
+	}
+	tellerLink.LastAttemptedUpdate = myownsanity.TimeP(s.clock.Now().UTC())
+	if err := s.repo.UpdateTellerLink(ctx, tellerLink); err != nil {
+		s.log.WithError(err).Error("failed to update teller link status")
+		return err
+	}
+
+	return nil
+}
+
+func (s *SyncTellerJob) maintainLinkStatus(ctx context.Context, tellerLink *TellerLink) error {
+	linkWasSetup := false
+	if tellerLink.Status != TellerLinkStatusSetup {
+		crumbs.Debug(ctx, "Updating teller link status.", map[string]interface{}{
+			"old": tellerLink.Status,
+			"new": TellerLinkStatusSetup,
+		})
+		tellerLink.Status = TellerLinkStatusSetup
+		tellerLink.ErrorCode = nil
+		linkWasSetup = true
+	}
+	tellerLink.LastSuccessfulUpdate = myownsanity.TimeP(s.clock.Now().UTC())
+	tellerLink.LastAttemptedUpdate = myownsanity.TimeP(s.clock.Now().UTC())
+	if err := s.repo.UpdateTellerLink(ctx, tellerLink); err != nil {
+		s.log.WithError(err).Error("failed to update link after transaction sync")
+		return err
+	}
+
+	if linkWasSetup { // Send the notification that the link has been set up.
+		channelName := fmt.Sprintf("initial:teller:link:%s:%s", s.args.AccountId, s.args.LinkId)
+		if notifyErr := s.publisher.Notify(
+			ctx,
+			channelName,
+			"success",
+		); notifyErr != nil {
+			s.log.WithError(notifyErr).Error("failed to publish link status to pubsub")
+		}
+	}
+
+	return nil
+}
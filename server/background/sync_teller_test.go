@@ -0,0 +1,221 @@
+package background
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mock_teller"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/pubsub"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSyncTellerJob_Run(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		clock := clock.NewMock()
+		clock.Set(time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC))
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		publisher := pubsub.NewPostgresPubSub(log, db)
+		kms := secrets.NewPlaintextKMS()
+		accessToken := gofakeit.UUID()
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveATellerLink(t, clock, user, accessToken)
+
+		tellerAccount := mock_teller.AccountFixture(t, link.TellerLink.EnrollmentId)
+		tellerAccount.Type = teller.AccountTypeDepository
+		tellerAccount.SubType = "checking"
+		bankAccount := fixtures.GivenIHaveATellerBankAccount(
+			t,
+			clock,
+			&link,
+			tellerAccount.Id,
+			models.DepositoryBankAccountType,
+			models.CheckingBankAccountSubType,
+		)
+
+		tellerClient, err := teller.NewTeller(log, clock, kms, db, config.Teller{
+			Enabled:       true,
+			ApplicationId: "app_test",
+		})
+		require.NoError(t, err, "must be able to create the teller client")
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(CalculateTransactionClusters),
+				testutils.NewGenericMatcher(func(args CalculateTransactionClustersArguments) bool {
+					return assert.Equal(t, bankAccount.BankAccountId, args.BankAccountId) &&
+						assert.Equal(t, bankAccount.AccountId, args.AccountId)
+				}),
+			).
+			Times(2).
+			Return(nil)
+
+		handler := NewSyncTellerHandler(
+			log,
+			db,
+			clock,
+			kms,
+			tellerClient,
+			publisher,
+			enqueuer,
+		)
+
+		args := SyncTellerArguments{
+			AccountId: user.AccountId,
+			LinkId:    link.LinkId,
+			Trigger:   "manual",
+		}
+		argsEncoded, err := DefaultJobMarshaller(args)
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		balance := mock_teller.BalanceFixture(t, tellerAccount)
+		posted := mock_teller.TransactionFixture(t, tellerAccount, clock.Now().AddDate(0, 0, -2), false)
+		pending := mock_teller.TransactionFixture(t, tellerAccount, clock.Now().AddDate(0, 0, -1), true)
+
+		mock_teller.MockGetAccounts(t, accessToken, []teller.Account{tellerAccount})
+		mock_teller.MockGetAccountBalance(t, accessToken, []teller.Balance{balance})
+		mock_teller.MockGetTransactions(t, accessToken, []teller.Transaction{posted, pending})
+
+		{ // Do our first teller sync.
+			fixtures.AssertThatIHaveZeroTransactions(t, user.AccountId)
+
+			err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+			assert.NoError(t, err, "must process job successfully")
+		}
+
+		assert.EqualValues(t, 2, fixtures.CountNonDeletedTransactions(t, user.AccountId), "should have both transactions")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		{ // Make sure the amounts were inverted and the balance was updated.
+			transactions, err := repo.GetTransactionsByTellerId(
+				context.Background(),
+				link.LinkId,
+				[]string{posted.Id, pending.Id},
+			)
+			require.NoError(t, err, "must be able to retrieve transactions")
+			require.Len(t, transactions, 2)
+
+			amount, err := posted.GetAmount(tellerAccount)
+			require.NoError(t, err)
+			assert.Positive(t, amount, "debits should be positive in monetr")
+			assert.EqualValues(t, amount, transactions[posted.Id].Amount, "amount should match")
+			assert.False(t, transactions[posted.Id].IsPending, "posted transaction should not be pending")
+			assert.True(t, transactions[pending.Id].IsPending, "pending transaction should be pending")
+			assert.Equal(t, models.TransactionSourceTeller, transactions[pending.Id].Source)
+
+			updatedBankAccount, err := repo.GetBankAccount(context.Background(), bankAccount.BankAccountId)
+			require.NoError(t, err, "must be able to retrieve bank account")
+			ledger, err := balance.GetLedger(tellerAccount.Currency)
+			require.NoError(t, err)
+			assert.EqualValues(t, ledger, updatedBankAccount.CurrentBalance, "current balance should be the ledger balance")
+		}
+
+		// Now the pending transaction disappears and is replaced by a new posted
+		// transaction with a different ID.
+		clock.Add(7 * time.Hour)
+		cleared := mock_teller.TransactionFixture(t, tellerAccount, clock.Now(), false)
+		mock_teller.MockGetTransactions(t, accessToken, []teller.Transaction{posted, cleared})
+
+		{ // Do our second teller sync.
+			err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+			assert.NoError(t, err, "must process job successfully")
+		}
+
+		assert.EqualValues(t, 2, fixtures.CountNonDeletedTransactions(t, user.AccountId), "pending transaction should have been removed")
+		assert.EqualValues(t, 3, fixtures.CountAllTransactions(t, user.AccountId), "pending transaction should be soft deleted")
+
+		updatedLink, err := repo.GetLink(context.Background(), link.LinkId)
+		require.NoError(t, err, "must be able to retrieve link")
+		assert.Equal(t, models.TellerLinkStatusSetup, updatedLink.TellerLink.Status)
+		assert.NotNil(t, updatedLink.TellerLink.LastSuccessfulUpdate)
+	})
+
+	t.Run("enrollment disconnected", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		clock := clock.NewMock()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		log := testutils.GetLog(t)
+		db := testutils.GetPgDatabase(t)
+		publisher := pubsub.NewPostgresPubSub(log, db)
+		kms := secrets.NewPlaintextKMS()
+		accessToken := gofakeit.UUID()
+
+		user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		link := fixtures.GivenIHaveATellerLink(t, clock, user, accessToken)
+		fixtures.GivenIHaveATellerBankAccount(
+			t,
+			clock,
+			&link,
+			"acc_test",
+			models.DepositoryBankAccountType,
+			models.CheckingBankAccountSubType,
+		)
+
+		tellerClient, err := teller.NewTeller(log, clock, kms, db, config.Teller{
+			Enabled:       true,
+			ApplicationId: "app_test",
+		})
+		require.NoError(t, err, "must be able to create the teller client")
+
+		enqueuer := mockgen.NewMockJobEnqueuer(ctrl)
+		enqueuer.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+
+		mock_teller.MockEnrollmentDisconnected(t, accessToken)
+
+		handler := NewSyncTellerHandler(
+			log,
+			db,
+			clock,
+			kms,
+			tellerClient,
+			publisher,
+			enqueuer,
+		)
+
+		argsEncoded, err := DefaultJobMarshaller(SyncTellerArguments{
+			AccountId: user.AccountId,
+			LinkId:    link.LinkId,
+			Trigger:   "cron",
+		})
+		require.NoError(t, err, "must be able to marshal arguments")
+
+		err = handler.HandleConsumeJob(context.Background(), log, argsEncoded)
+		assert.NoError(t, err, "a disconnected enrollment should not fail the job")
+
+		repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+		updatedLink, err := repo.GetLink(context.Background(), link.LinkId)
+		require.NoError(t, err, "must be able to retrieve link")
+		assert.Equal(t, models.TellerLinkStatusDisconnected, updatedLink.TellerLink.Status)
+		assert.NotNil(t, updatedLink.TellerLink.ErrorCode)
+		fixtures.AssertThatIHaveZeroTransactions(t, user.AccountId)
+	})
+}
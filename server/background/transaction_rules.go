@@ -0,0 +1,173 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// transactionRuleProcessor applies the transaction rules for a single bank
+// account to new transactions as they are synced or imported. Any spending
+// objects that are assigned to transactions are kept in memory until Flush is
+// called, this way multiple transactions can be spent from the same spending
+// object within a single job.
+type transactionRuleProcessor struct {
+	log           *logrus.Entry
+	repo          repository.BaseRepository
+	account       *Account
+	timezone      *time.Location
+	bankAccountId ID[BankAccount]
+	rules         []TransactionRule
+	spending      map[ID[Spending]]*Spending
+	order         []ID[Spending]
+	allocations   []SpendingAllocation
+}
+
+func newTransactionRuleProcessor(
+	ctx context.Context,
+	log *logrus.Entry,
+	repo repository.BaseRepository,
+	bankAccountId ID[BankAccount],
+) (*transactionRuleProcessor, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	account, err := repo.GetAccount(span.Context())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account for transaction rules")
+	}
+
+	timezone, err := account.GetTimezone()
+	if err != nil {
+		timezone = time.UTC
+	}
+
+	rules, err := repo.GetTransactionRules(span.Context(), bankAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only enabled rules are applied to transactions.
+	enabled := make([]TransactionRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsEnabled {
+			enabled = append(enabled, rule)
+		}
+	}
+
+	return &transactionRuleProcessor{
+		log: log.WithFields(logrus.Fields{
+			"bankAccountId": bankAccountId,
+		}),
+		repo:          repo,
+		account:       account,
+		timezone:      timezone,
+		bankAccountId: bankAccountId,
+		rules:         enabled,
+		spending:      map[ID[Spending]]*Spending{},
+		order:         make([]ID[Spending], 0),
+		allocations:   make([]SpendingAllocation, 0),
+	}, nil
+}
+
+// Process will apply the first rule that matches the provided transaction to
+// it. If the rule assigns a spending object to the transaction then the amount
+// of the transaction is deducted from that spending object.
+func (p *transactionRuleProcessor) Process(ctx context.Context, transaction *Transaction) error {
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !rule.Matches(*transaction, p.timezone) {
+			continue
+		}
+
+		log := p.log.WithFields(logrus.Fields{
+			"transactionRuleId": rule.TransactionRuleId,
+			"transactionId":     transaction.TransactionId,
+		})
+		log.Trace("transaction rule matched transaction")
+
+		rule.Apply(transaction)
+
+		// Deposits can't be spent from a spending object, and transactions that
+		// already have a spending object should be left alone.
+		if rule.SpendingId == nil || transaction.IsAddition() || transaction.SpendingId != nil {
+			return nil
+		}
+
+		spending, err := p.getSpending(ctx, *rule.SpendingId)
+		if err != nil {
+			log.WithError(err).Warn("failed to retrieve spending object for transaction rule")
+			return nil
+		}
+
+		transaction.SpendingId = &spending.SpendingId
+		if err := transaction.AddSpendingToTransaction(ctx, spending, p.account); err != nil {
+			return errors.Wrap(err, "failed to spend transaction from rule's spending object")
+		}
+
+		// New transactions don't have an ID until they are inserted, but the
+		// ledger entry needs to reference the transaction.
+		if transaction.TransactionId.IsZero() {
+			transaction.TransactionId = NewID(transaction)
+		}
+		allocation := NewSpendingAllocation(
+			spending,
+			SpendingAllocationKindSpend,
+			SpendingAllocationSourceTransaction,
+			-*transaction.SpendingAmount,
+		)
+		allocation.TransactionId = &transaction.TransactionId
+		p.allocations = append(p.allocations, allocation)
+
+		return nil
+	}
+
+	return nil
+}
+
+// Flush will persist any changes made to spending objects by the rules that
+// have been applied so far, along with their allocation ledger entries. It must
+// be called after the transactions have been stored.
+func (p *transactionRuleProcessor) Flush(ctx context.Context) error {
+	if len(p.order) == 0 {
+		return nil
+	}
+
+	updates := make([]Spending, 0, len(p.order))
+	for _, spendingId := range p.order {
+		updates = append(updates, *p.spending[spendingId])
+	}
+
+	if err := p.repo.UpdateSpending(ctx, p.bankAccountId, updates); err != nil {
+		return errors.Wrap(err, "failed to update spending objects for transaction rules")
+	}
+
+	if err := p.repo.CreateSpendingAllocations(ctx, p.bankAccountId, p.allocations); err != nil {
+		return errors.Wrap(err, "failed to record spending allocations for transaction rules")
+	}
+
+	p.spending = map[ID[Spending]]*Spending{}
+	p.order = make([]ID[Spending], 0)
+	p.allocations = make([]SpendingAllocation, 0)
+	return nil
+}
+
+func (p *transactionRuleProcessor) getSpending(ctx context.Context, spendingId ID[Spending]) (*Spending, error) {
+	if spending, ok := p.spending[spendingId]; ok {
+		return spending, nil
+	}
+
+	spending, err := p.repo.GetSpendingById(ctx, p.bankAccountId, spendingId)
+	if err != nil {
+		return nil, err
+	}
+
+	p.spending[spendingId] = spending
+	p.order = append(p.order, spendingId)
+	return spending, nil
+}
@@ -128,6 +128,13 @@ type Billing interface {
 	// subscription then the subscription is retrieved from stripe and the details
 	// of the subscription are persisted to the account as represented by stripe.
 	ReconcileSubscription(ctx context.Context, accountId ID[Account]) error
+
+	// CancelSubscription will immediately cancel the Stripe subscription for the
+	// specified account if it has one. The account is then updated to reflect the
+	// canceled subscription. If the account does not have a subscription then
+	// nothing is done and nil is returned. This is used when an account is being
+	// deleted.
+	CancelSubscription(ctx context.Context, accountId ID[Account]) error
 }
 
 // SubscriptionIsActive is a helper function that takes in a Stripe subscription
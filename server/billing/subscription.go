@@ -100,3 +100,61 @@ func (b *baseBilling) ReconcileSubscription(ctx context.Context, accountId ID[Ac
 		b.clock.Now(),
 	)
 }
+
+// CancelSubscription will immediately cancel the Stripe subscription for the
+// specified account if it has one. The account is then updated to reflect the
+// canceled subscription. If the account does not have a subscription then
+// nothing is done and nil is returned. This is used when an account is being
+// deleted.
+func (b *baseBilling) CancelSubscription(ctx context.Context, accountId ID[Account]) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	log := b.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"accountId": accountId,
+	})
+
+	account, err := b.accounts.GetAccount(span.Context(), accountId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to retrieve account to cancel subscription")
+	}
+
+	if account.StripeSubscriptionId == nil {
+		log.Trace("account does not have a subscription to cancel")
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"stripe": logrus.Fields{
+			"subscriptionId": account.StripeSubscriptionId,
+			"customerId":     account.StripeCustomerId,
+		},
+	})
+
+	log.Info("canceling subscription")
+	if err := b.stripe.CancelSubscription(
+		span.Context(),
+		*account.StripeSubscriptionId,
+	); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		log.WithError(err).Error("failed to cancel subscription in stripe")
+		return err
+	}
+
+	now := b.clock.Now()
+	status := stripe.SubscriptionStatusCanceled
+	account.StripeSubscriptionId = nil
+	account.SubscriptionStatus = &status
+	account.SubscriptionActiveUntil = &now
+	account.StripeWebhookLatestTimestamp = &now
+
+	if err := b.accounts.UpdateAccount(span.Context(), account); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update account after canceling subscription")
+	}
+
+	span.Status = sentry.SpanStatusOK
+	return nil
+}
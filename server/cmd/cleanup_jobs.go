@@ -70,6 +70,8 @@ func newCleanupJobsCommand(parent *cobra.Command) {
 				nil,
 				nil,
 				nil,
+				nil,
+				nil,
 			)
 			if err != nil {
 				return err
@@ -3,14 +3,22 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/go-pg/pg/v10"
 	"github.com/monetr/monetr/server/client"
 	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/database"
+	"github.com/monetr/monetr/server/internal/myownsanity"
 	"github.com/monetr/monetr/server/logging"
 	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -127,13 +135,13 @@ func newExportDataCommand(parent *cobra.Command) {
 				return err
 			}
 
-			dump := map[string]interface{}{
-				"you":              me,
-				"links":            links,
-				"bankAccounts":     bankAccounts,
-				"transactions":     transactions,
-				"spending":         spending,
-				"fundingSchedules": fundingSchedules,
+			dump := dataExport{
+				You:              me,
+				Links:            links,
+				BankAccounts:     bankAccounts,
+				Transactions:     transactions,
+				Spending:         spending,
+				FundingSchedules: fundingSchedules,
 			}
 
 			dumpRaw, err := json.Marshal(dump)
@@ -155,16 +163,652 @@ func newExportDataCommand(parent *cobra.Command) {
 
 func newImportDataCommand(parent *cobra.Command) {
 	var input string
+	var accountId string
 	var dryRun bool
 
 	command := &cobra.Command{
 		Use:   "import",
 		Short: "Import data from your monetr export into your local monetr instance. This requires database access.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return nil
+			clock := clock.New()
+			configuration := config.LoadConfiguration()
+
+			log := logging.NewLoggerWithConfig(configuration.Logging)
+			if configFileName := configuration.GetConfigFileName(); configFileName != "" {
+				log.WithField("config", configFileName).Info("config file loaded")
+			}
+
+			if accountId == "" {
+				log.Fatal("account ID must be specified via --account")
+				return cmd.Help()
+			}
+
+			targetAccountId, err := models.ParseID[models.Account](accountId)
+			if err != nil {
+				log.WithError(err).Fatal("invalid account ID provided")
+				return err
+			}
+
+			inputRaw, err := os.ReadFile(input)
+			if err != nil {
+				log.WithError(err).Fatal("failed to read data export")
+				return errors.Wrap(err, "failed to read data export")
+			}
+
+			var dump dataExport
+			if err := json.Unmarshal(inputRaw, &dump); err != nil {
+				log.WithError(err).Fatal("failed to decode data export")
+				return errors.Wrap(err, "failed to decode data export")
+			}
+
+			db, err := database.GetDatabase(log, configuration, nil)
+			if err != nil {
+				log.WithError(err).Fatal("failed to setup database")
+				return err
+			}
+
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(10*time.Minute))
+			defer cancel()
+
+			owner, err := repository.NewRepositoryFromSession(
+				clock,
+				"user_system",
+				targetAccountId,
+				db,
+			).GetAccountOwner(ctx)
+			if err != nil {
+				log.WithError(err).Fatal("failed to retrieve the owner of the target account")
+				return err
+			}
+
+			importer := &dataImporter{
+				log:    log.WithField("accountId", targetAccountId),
+				out:    cmd.OutOrStdout(),
+				dryRun: dryRun,
+			}
+
+			err = db.RunInTransaction(ctx, func(txn *pg.Tx) error {
+				repo := repository.NewRepositoryFromSession(
+					clock,
+					owner.UserId,
+					targetAccountId,
+					txn,
+				)
+				if err := importer.run(ctx, repo, dump); err != nil {
+					return err
+				}
+
+				// The import is performed in full during a dry run so that any failures
+				// from the database are surfaced, but the transaction is always rolled
+				// back afterwards.
+				if dryRun {
+					return errDataImportDryRun
+				}
+
+				return nil
+			})
+			switch errors.Cause(err) {
+			case nil:
+				log.Info("data import completed successfully")
+				return nil
+			case errDataImportDryRun:
+				log.Info("dry run completed successfully, no changes were made")
+				return nil
+			default:
+				log.WithError(err).Fatal("failed to import data")
+				return err
+			}
 		},
 	}
 	command.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run the data import, this will print any changes or any failures that would occur during the data import without changing anything.")
 	command.PersistentFlags().StringVarP(&input, "input", "i", "monetr_export.json", "Specify the input file, this file must be in the same format as the output of the export command.")
+	command.PersistentFlags().StringVarP(&accountId, "account", "a", "", "The ID of the account in your local monetr instance that the data should be imported into.")
 	parent.AddCommand(command)
 }
+
+var (
+	errDataImportDryRun = errors.New("dry run")
+)
+
+// dataExport is the structure of the file written by the export command.
+type dataExport struct {
+	You              *models.User             `json:"you"`
+	Links            []models.Link            `json:"links"`
+	BankAccounts     []models.BankAccount     `json:"bankAccounts"`
+	Transactions     []models.Transaction     `json:"transactions"`
+	Spending         []models.Spending        `json:"spending"`
+	FundingSchedules []models.FundingSchedule `json:"fundingSchedules"`
+}
+
+// dataImportAction is the prefix printed for each object during a dry run to
+// describe what the import did with it.
+type dataImportAction string
+
+const (
+	dataImportCreated dataImportAction = "+"
+	dataImportUpdated dataImportAction = "~"
+	dataImportSkipped dataImportAction = "="
+)
+
+// dataImporter recreates the objects from a data export in the target account.
+// Objects that already exist in the target account are matched by their
+// natural keys (names, masks and so on) and are updated in place or skipped,
+// that way importing the same export more than once does not duplicate data.
+// Links keep the ID they had in the export and are matched by it, every other
+// new object is given a new ID as it is created. References between objects
+// are remapped to the IDs in the target account as the import progresses.
+type dataImporter struct {
+	log    *logrus.Entry
+	out    io.Writer
+	dryRun bool
+
+	links            map[models.ID[models.Link]]models.ID[models.Link]
+	bankAccounts     map[models.ID[models.BankAccount]]models.ID[models.BankAccount]
+	fundingSchedules map[models.ID[models.FundingSchedule]]models.ID[models.FundingSchedule]
+	spending         map[models.ID[models.Spending]]models.ID[models.Spending]
+	// existingBankAccounts are the bank accounts in the target account that
+	// were matched by the import rather than created. Only these can already
+	// have transactions that need to be checked for duplicates.
+	existingBankAccounts map[models.ID[models.BankAccount]]struct{}
+	results              map[dataImportAction]int
+}
+
+func (d *dataImporter) run(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	dump dataExport,
+) error {
+	d.links = map[models.ID[models.Link]]models.ID[models.Link]{}
+	d.bankAccounts = map[models.ID[models.BankAccount]]models.ID[models.BankAccount]{}
+	d.fundingSchedules = map[models.ID[models.FundingSchedule]]models.ID[models.FundingSchedule]{}
+	d.spending = map[models.ID[models.Spending]]models.ID[models.Spending]{}
+	d.existingBankAccounts = map[models.ID[models.BankAccount]]struct{}{}
+	d.results = map[dataImportAction]int{}
+
+	// Order matters here, each step depends on the IDs remapped by the step
+	// before it.
+	steps := []func(ctx context.Context, repo repository.BaseRepository, dump dataExport) error{
+		d.importLinks,
+		d.importBankAccounts,
+		d.importFundingSchedules,
+		d.importSpending,
+		d.importTransactions,
+	}
+	for _, step := range steps {
+		if err := step(ctx, repo, dump); err != nil {
+			return err
+		}
+	}
+
+	d.log.WithFields(logrus.Fields{
+		"created": d.results[dataImportCreated],
+		"updated": d.results[dataImportUpdated],
+		"skipped": d.results[dataImportSkipped],
+	}).Info("imported data")
+
+	return nil
+}
+
+func (d *dataImporter) importLinks(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	dump dataExport,
+) error {
+	existing, err := repo.GetLinks(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve existing links")
+	}
+
+	// Links are created with the same ID they had in the export, so a link that
+	// was imported before is matched by its ID. Institution names are not used
+	// as several links can be for the same institution.
+	byId := map[models.ID[models.Link]]models.Link{}
+	for _, link := range existing {
+		byId[link.LinkId] = link
+	}
+
+	for _, item := range dump.Links {
+		link := item
+		oldId := link.LinkId
+		if match, ok := byId[oldId]; ok {
+			d.links[oldId] = match.LinkId
+			d.printChange(dataImportSkipped, "link", oldId, match.LinkId, match.InstitutionName)
+			continue
+		}
+
+		// Credentials for Plaid are not included in the export, so any Plaid links
+		// are recreated as manual links in the target account. The same is true
+		// for Teller and SimpleFIN links.
+		link.LinkType = models.ManualLinkType
+		link.PlaidLinkId = nil
+		link.PlaidLink = nil
+		link.TellerLinkId = nil
+		link.TellerLink = nil
+		link.SimpleFINLinkId = nil
+		link.SimpleFINLink = nil
+		if err := repo.CreateLink(ctx, &link); err != nil {
+			return errors.Wrapf(err, "failed to import link %s", oldId)
+		}
+		d.links[oldId] = link.LinkId
+		d.printChange(dataImportCreated, "link", oldId, link.LinkId, link.InstitutionName)
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importBankAccounts(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	dump dataExport,
+) error {
+	existing, err := repo.GetBankAccounts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve existing bank accounts")
+	}
+
+	type bankAccountKey struct {
+		linkId models.ID[models.Link]
+		name   string
+		mask   string
+	}
+	byKey := map[bankAccountKey]models.BankAccount{}
+	for _, bankAccount := range existing {
+		byKey[bankAccountKey{
+			linkId: bankAccount.LinkId,
+			name:   bankAccount.Name,
+			mask:   bankAccount.Mask,
+		}] = bankAccount
+	}
+
+	for _, item := range dump.BankAccounts {
+		bankAccount := item
+		oldId := bankAccount.BankAccountId
+		linkId, ok := d.links[bankAccount.LinkId]
+		if !ok {
+			return errors.Errorf("bank account %s references link %s which is not in the export", oldId, bankAccount.LinkId)
+		}
+
+		if match, ok := byKey[bankAccountKey{
+			linkId: linkId,
+			name:   bankAccount.Name,
+			mask:   bankAccount.Mask,
+		}]; ok {
+			d.bankAccounts[oldId] = match.BankAccountId
+			d.existingBankAccounts[match.BankAccountId] = struct{}{}
+			if match.CurrentBalance == bankAccount.CurrentBalance &&
+				match.AvailableBalance == bankAccount.AvailableBalance &&
+				match.LimitBalance == bankAccount.LimitBalance {
+				d.printChange(dataImportSkipped, "bank account", oldId, match.BankAccountId, match.Name)
+				continue
+			}
+
+			match.CurrentBalance = bankAccount.CurrentBalance
+			match.AvailableBalance = bankAccount.AvailableBalance
+			match.LimitBalance = bankAccount.LimitBalance
+			if err := repo.UpdateBankAccount(ctx, &match); err != nil {
+				return errors.Wrapf(err, "failed to update bank account %s", oldId)
+			}
+			d.printChange(dataImportUpdated, "bank account", oldId, match.BankAccountId, match.Name)
+			continue
+		}
+
+		bankAccount.BankAccountId = ""
+		bankAccount.LinkId = linkId
+		bankAccount.Link = nil
+		bankAccount.PlaidBankAccountId = nil
+		bankAccount.PlaidBankAccount = nil
+		bankAccount.TellerBankAccountId = nil
+		bankAccount.TellerBankAccount = nil
+		bankAccount.SimpleFINBankAccountId = nil
+		bankAccount.SimpleFINBankAccount = nil
+		if err := repo.CreateBankAccounts(ctx, &bankAccount); err != nil {
+			return errors.Wrapf(err, "failed to import bank account %s", oldId)
+		}
+		d.bankAccounts[oldId] = bankAccount.BankAccountId
+		d.printChange(dataImportCreated, "bank account", oldId, bankAccount.BankAccountId, bankAccount.Name)
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importFundingSchedules(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	dump dataExport,
+) error {
+	// Funding schedule names are unique within a bank account, so they are
+	// matched by name. Existing funding schedules are loaded lazily and only for
+	// bank accounts that already existed in the target account.
+	existing := map[models.ID[models.BankAccount]]map[string]models.FundingSchedule{}
+
+	for _, item := range dump.FundingSchedules {
+		fundingSchedule := item
+		oldId := fundingSchedule.FundingScheduleId
+		bankAccountId, ok := d.bankAccounts[fundingSchedule.BankAccountId]
+		if !ok {
+			return errors.Errorf("funding schedule %s references bank account %s which is not in the export", oldId, fundingSchedule.BankAccountId)
+		}
+
+		if _, ok := d.existingBankAccounts[bankAccountId]; ok {
+			byName, ok := existing[bankAccountId]
+			if !ok {
+				items, err := repo.GetFundingSchedules(ctx, bankAccountId)
+				if err != nil {
+					return errors.Wrap(err, "failed to retrieve existing funding schedules")
+				}
+				byName = make(map[string]models.FundingSchedule, len(items))
+				for _, existingItem := range items {
+					byName[existingItem.Name] = existingItem
+				}
+				existing[bankAccountId] = byName
+			}
+
+			if match, ok := byName[fundingSchedule.Name]; ok {
+				d.fundingSchedules[oldId] = match.FundingScheduleId
+				if match.Description == fundingSchedule.Description &&
+					ruleSetEqual(match.RuleSet, fundingSchedule.RuleSet) &&
+					match.ExcludeWeekends == fundingSchedule.ExcludeWeekends &&
+					match.WaitForDeposit == fundingSchedule.WaitForDeposit &&
+					myownsanity.Int64PEqual(match.EstimatedDeposit, fundingSchedule.EstimatedDeposit) {
+					d.printChange(dataImportSkipped, "funding schedule", oldId, match.FundingScheduleId, match.Name)
+					continue
+				}
+
+				match.Description = fundingSchedule.Description
+				match.RuleSet = fundingSchedule.RuleSet
+				match.ExcludeWeekends = fundingSchedule.ExcludeWeekends
+				match.WaitForDeposit = fundingSchedule.WaitForDeposit
+				match.EstimatedDeposit = fundingSchedule.EstimatedDeposit
+				match.LastRecurrence = fundingSchedule.LastRecurrence
+				match.NextRecurrence = fundingSchedule.NextRecurrence
+				match.NextRecurrenceOriginal = fundingSchedule.NextRecurrenceOriginal
+				if err := repo.UpdateFundingSchedule(ctx, &match); err != nil {
+					return errors.Wrapf(err, "failed to update funding schedule %s", oldId)
+				}
+				d.printChange(dataImportUpdated, "funding schedule", oldId, match.FundingScheduleId, match.Name)
+				continue
+			}
+		}
+
+		fundingSchedule.FundingScheduleId = ""
+		fundingSchedule.BankAccountId = bankAccountId
+		fundingSchedule.BankAccount = nil
+		// The deposit the funding schedule was last matched to does not exist in
+		// the target account.
+		fundingSchedule.LastDepositTransactionId = nil
+		if err := repo.CreateFundingSchedule(ctx, &fundingSchedule); err != nil {
+			return errors.Wrapf(err, "failed to import funding schedule %s", oldId)
+		}
+		d.fundingSchedules[oldId] = fundingSchedule.FundingScheduleId
+		d.printChange(dataImportCreated, "funding schedule", oldId, fundingSchedule.FundingScheduleId, fundingSchedule.Name)
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importSpending(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	dump dataExport,
+) error {
+	// Spending is unique by its type and name within a bank account.
+	type spendingKey struct {
+		spendingType models.SpendingType
+		name         string
+	}
+	existing := map[models.ID[models.BankAccount]]map[spendingKey]models.Spending{}
+
+	for _, item := range dump.Spending {
+		spending := item
+		oldId := spending.SpendingId
+		bankAccountId, ok := d.bankAccounts[spending.BankAccountId]
+		if !ok {
+			return errors.Errorf("spending %s references bank account %s which is not in the export", oldId, spending.BankAccountId)
+		}
+		fundingScheduleId, ok := d.fundingSchedules[spending.FundingScheduleId]
+		if !ok {
+			return errors.Errorf("spending %s references funding schedule %s which is not in the export", oldId, spending.FundingScheduleId)
+		}
+
+		if _, ok := d.existingBankAccounts[bankAccountId]; ok {
+			byKey, ok := existing[bankAccountId]
+			if !ok {
+				items, err := repo.GetSpending(ctx, bankAccountId)
+				if err != nil {
+					return errors.Wrap(err, "failed to retrieve existing spending")
+				}
+				byKey = make(map[spendingKey]models.Spending, len(items))
+				for _, existingItem := range items {
+					byKey[spendingKey{
+						spendingType: existingItem.SpendingType,
+						name:         existingItem.Name,
+					}] = existingItem
+				}
+				existing[bankAccountId] = byKey
+			}
+
+			if match, ok := byKey[spendingKey{
+				spendingType: spending.SpendingType,
+				name:         spending.Name,
+			}]; ok {
+				d.spending[oldId] = match.SpendingId
+				if match.FundingScheduleId == fundingScheduleId &&
+					match.Description == spending.Description &&
+					match.TargetAmount == spending.TargetAmount &&
+					match.CurrentAmount == spending.CurrentAmount &&
+					match.UsedAmount == spending.UsedAmount &&
+					ruleSetEqual(match.RuleSet, spending.RuleSet) &&
+					match.IsPaused == spending.IsPaused {
+					d.printChange(dataImportSkipped, "spending", oldId, match.SpendingId, match.Name)
+					continue
+				}
+
+				match.FundingScheduleId = fundingScheduleId
+				match.Description = spending.Description
+				match.TargetAmount = spending.TargetAmount
+				match.CurrentAmount = spending.CurrentAmount
+				match.UsedAmount = spending.UsedAmount
+				match.RuleSet = spending.RuleSet
+				match.IsPaused = spending.IsPaused
+				match.IsBehind = spending.IsBehind
+				match.LastRecurrence = spending.LastRecurrence
+				match.NextRecurrence = spending.NextRecurrence
+				match.NextContributionAmount = spending.NextContributionAmount
+				if err := repo.UpdateSpending(ctx, bankAccountId, []models.Spending{match}); err != nil {
+					return errors.Wrapf(err, "failed to update spending %s", oldId)
+				}
+				d.printChange(dataImportUpdated, "spending", oldId, match.SpendingId, match.Name)
+				continue
+			}
+		}
+
+		// Everything else on the spending object is kept as is, including the
+		// current and used amounts so that allocations carry over.
+		spending.SpendingId = ""
+		spending.BankAccountId = bankAccountId
+		spending.BankAccount = nil
+		spending.FundingScheduleId = fundingScheduleId
+		spending.FundingSchedule = nil
+		if err := repo.CreateSpending(ctx, &spending); err != nil {
+			return errors.Wrapf(err, "failed to import spending %s", oldId)
+		}
+		d.spending[oldId] = spending.SpendingId
+		d.printChange(dataImportCreated, "spending", oldId, spending.SpendingId, spending.Name)
+	}
+
+	return nil
+}
+
+func (d *dataImporter) importTransactions(
+	ctx context.Context,
+	repo repository.BaseRepository,
+	dump dataExport,
+) error {
+	if len(dump.Transactions) == 0 {
+		return nil
+	}
+
+	// Transactions have no name that is unique, so a transaction is considered
+	// to already exist if a transaction with the same date, amount and original
+	// name is present in the same bank account.
+	type transactionKey struct {
+		date         int64
+		amount       int64
+		originalName string
+	}
+	existing := map[models.ID[models.BankAccount]]map[transactionKey]models.ID[models.Transaction]{}
+	for bankAccountId := range d.existingBankAccounts {
+		byKey := map[transactionKey]models.ID[models.Transaction]{}
+		for offset := 0; ; offset += 500 {
+			items, err := repo.GetTransactions(ctx, bankAccountId, 500, offset)
+			if err != nil {
+				return errors.Wrap(err, "failed to retrieve existing transactions")
+			}
+			for _, existingItem := range items {
+				byKey[transactionKey{
+					date:         existingItem.Date.Unix(),
+					amount:       existingItem.Amount,
+					originalName: existingItem.OriginalName,
+				}] = existingItem.TransactionId
+			}
+			if len(items) < 500 {
+				break
+			}
+		}
+		existing[bankAccountId] = byKey
+	}
+
+	transactions := make([]models.Transaction, 0, len(dump.Transactions))
+	oldIds := make([]models.ID[models.Transaction], 0, len(dump.Transactions))
+	for _, item := range dump.Transactions {
+		transaction := item
+		oldId := transaction.TransactionId
+		bankAccountId, ok := d.bankAccounts[transaction.BankAccountId]
+		if !ok {
+			return errors.Errorf("transaction %s references bank account %s which is not in the export", oldId, transaction.BankAccountId)
+		}
+
+		if match, ok := existing[bankAccountId][transactionKey{
+			date:         transaction.Date.Unix(),
+			amount:       transaction.Amount,
+			originalName: transaction.OriginalName,
+		}]; ok {
+			d.printChange(dataImportSkipped, "transaction", oldId, match, transaction.Name)
+			continue
+		}
+
+		if transaction.SpendingId != nil {
+			spendingId, ok := d.spending[*transaction.SpendingId]
+			if !ok {
+				return errors.Errorf("transaction %s references spending %s which is not in the export", oldId, *transaction.SpendingId)
+			}
+			transaction.SpendingId = &spendingId
+		}
+		splits, err := d.remapTransactionSplits(transaction)
+		if err != nil {
+			return err
+		}
+		transaction.TransactionId = ""
+		transaction.BankAccountId = bankAccountId
+		transaction.BankAccount = nil
+		transaction.Spending = nil
+		// Splits are not stored with the transaction itself, they are created
+		// once the transaction has its new ID.
+		transaction.Splits = splits
+		transaction.PlaidTransactionId = nil
+		transaction.PlaidTransaction = nil
+		transaction.PendingPlaidTransactionId = nil
+		transaction.PendingPlaidTransaction = nil
+		transaction.TellerTransactionId = nil
+		transaction.TellerTransaction = nil
+		transaction.SimpleFINTransactionId = nil
+		transaction.SimpleFINTransaction = nil
+		transactions = append(transactions, transaction)
+		oldIds = append(oldIds, oldId)
+	}
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	if err := repo.InsertTransactions(ctx, transactions); err != nil {
+		return errors.Wrap(err, "failed to import transactions")
+	}
+
+	// The current and used amounts of the spending objects already include the
+	// splits, so they are stored as is without being spent from again.
+	for i := range transactions {
+		if err := repo.InsertTransactionSplits(
+			ctx,
+			transactions[i].BankAccountId,
+			transactions[i].TransactionId,
+			transactions[i].Splits,
+		); err != nil {
+			return errors.Wrapf(err, "failed to import splits for transaction %s", oldIds[i])
+		}
+	}
+
+	for i := range transactions {
+		d.printChange(dataImportCreated, "transaction", oldIds[i], transactions[i].TransactionId, transactions[i].Name)
+	}
+
+	return nil
+}
+
+// remapTransactionSplits returns the splits of a transaction from the export
+// with their spending objects remapped to the target account. The splits are
+// validated against the transaction the same way they are when they are created
+// through the API.
+func (d *dataImporter) remapTransactionSplits(
+	transaction models.Transaction,
+) ([]models.TransactionSplit, error) {
+	if len(transaction.Splits) == 0 {
+		return nil, nil
+	}
+
+	if err := models.ValidateTransactionSplits(transaction, transaction.Splits); err != nil {
+		return nil, errors.Wrapf(err, "transaction %s has invalid splits", transaction.TransactionId)
+	}
+
+	splits := make([]models.TransactionSplit, len(transaction.Splits))
+	for i, split := range transaction.Splits {
+		if split.SpendingId != nil {
+			spendingId, ok := d.spending[*split.SpendingId]
+			if !ok {
+				return nil, errors.Errorf("split %s of transaction %s references spending %s which is not in the export", split.TransactionSplitId, transaction.TransactionId, *split.SpendingId)
+			}
+			split.SpendingId = &spendingId
+		}
+		split.TransactionSplitId = ""
+		split.BankAccount = nil
+		split.Spending = nil
+		splits[i] = split
+	}
+
+	return splits, nil
+}
+
+// printChange records what the import did with a single object. A line
+// describing the change is only written for dry runs, otherwise the output for
+// large exports would be overwhelming.
+func (d *dataImporter) printChange(
+	action dataImportAction,
+	kind string,
+	oldId, newId models.Identifier,
+	name string,
+) {
+	d.results[action]++
+	if !d.dryRun {
+		return
+	}
+
+	fmt.Fprintf(d.out, "%s %-16s %s -> %s %q\n", action, kind, oldId, newId, name)
+}
+
+func ruleSetEqual(a, b *models.RuleSet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.String() == b.String()
+}
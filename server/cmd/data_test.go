@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// givenIHaveADataExport creates a link, bank account, funding schedule,
+// spending object and some transactions in a new account. It then returns
+// those objects in the same JSON format that the export command writes.
+func givenIHaveADataExport(t *testing.T, clock clock.Clock) dataExport {
+	user, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+	link := fixtures.GivenIHaveAManualLink(t, clock, user)
+	bankAccount := fixtures.GivenIHaveABankAccount(
+		t,
+		clock,
+		&link,
+		models.DepositoryBankAccountType,
+		models.CheckingBankAccountSubType,
+	)
+	fundingSchedule := fixtures.GivenIHaveAFundingSchedule(
+		t,
+		clock,
+		&bankAccount,
+		"FREQ=MONTHLY;BYMONTHDAY=15,-1",
+		false,
+	)
+	timezone := testutils.MustEz(t, user.Account.GetTimezone)
+	spendingRule := testutils.RuleToSet(t, timezone, "FREQ=MONTHLY;BYMONTHDAY=1", clock.Now())
+	spending := testutils.MustInsert(t, models.Spending{
+		AccountId:         bankAccount.AccountId,
+		BankAccountId:     bankAccount.BankAccountId,
+		FundingScheduleId: fundingSchedule.FundingScheduleId,
+		SpendingType:      models.SpendingTypeExpense,
+		Name:              "Rent",
+		TargetAmount:      100000,
+		CurrentAmount:     2500,
+		RuleSet:           spendingRule,
+		NextRecurrence:    spendingRule.After(clock.Now(), false),
+		CreatedAt:         clock.Now(),
+	})
+	fixtures.GivenIHaveNTransactions(t, clock, bankAccount, 3)
+
+	ctx := context.Background()
+	repo := repository.NewRepositoryFromSession(
+		clock,
+		user.UserId,
+		user.AccountId,
+		testutils.GetPgDatabase(t),
+	)
+	links, err := repo.GetLinks(ctx)
+	require.NoError(t, err, "must retrieve links")
+	bankAccounts, err := repo.GetBankAccounts(ctx)
+	require.NoError(t, err, "must retrieve bank accounts")
+	fundingSchedules, err := repo.GetFundingSchedules(ctx, bankAccount.BankAccountId)
+	require.NoError(t, err, "must retrieve funding schedules")
+	transactions, err := repo.GetTransactions(ctx, bankAccount.BankAccountId, 100, 0)
+	require.NoError(t, err, "must retrieve transactions")
+	require.Len(t, transactions, 3, "should have the transactions we created")
+	// Assign one of the transactions to the spending object so that the remapping
+	// of spending IDs is exercised.
+	transactions[0].SpendingId = &spending.SpendingId
+
+	raw, err := json.Marshal(dataExport{
+		You:              &user,
+		Links:            links,
+		BankAccounts:     bankAccounts,
+		Transactions:     transactions,
+		Spending:         []models.Spending{spending},
+		FundingSchedules: fundingSchedules,
+	})
+	require.NoError(t, err, "must be able to encode the export")
+
+	var dump dataExport
+	require.NoError(t, json.Unmarshal(raw, &dump), "must be able to decode the export")
+	return dump
+}
+
+func TestDataImporter(t *testing.T) {
+	t.Run("round trip with export", func(t *testing.T) {
+		clock := clock.NewMock()
+		dump := givenIHaveADataExport(t, clock)
+		target, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		repo := repository.NewRepositoryFromSession(
+			clock,
+			target.UserId,
+			target.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		ctx := context.Background()
+
+		importer := &dataImporter{
+			log: testutils.GetLog(t),
+			out: &bytes.Buffer{},
+		}
+		require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+		assert.EqualValues(t, map[dataImportAction]int{
+			dataImportCreated: 7,
+		}, importer.results, "every object should have been created")
+
+		links, err := repo.GetLinks(ctx)
+		require.NoError(t, err, "must retrieve links")
+		require.Len(t, links, 1, "should have imported the link")
+		assert.Equal(t, dump.Links[0].InstitutionName, links[0].InstitutionName)
+		assert.Equal(t, models.ManualLinkType, links[0].LinkType)
+
+		bankAccounts, err := repo.GetBankAccounts(ctx)
+		require.NoError(t, err, "must retrieve bank accounts")
+		require.Len(t, bankAccounts, 1, "should have imported the bank account")
+		assert.Equal(t, links[0].LinkId, bankAccounts[0].LinkId, "bank account should reference the new link")
+		assert.Equal(t, dump.BankAccounts[0].Name, bankAccounts[0].Name)
+		assert.Equal(t, dump.BankAccounts[0].CurrentBalance, bankAccounts[0].CurrentBalance)
+		bankAccountId := bankAccounts[0].BankAccountId
+
+		fundingSchedules, err := repo.GetFundingSchedules(ctx, bankAccountId)
+		require.NoError(t, err, "must retrieve funding schedules")
+		require.Len(t, fundingSchedules, 1, "should have imported the funding schedule")
+		assert.Equal(t, dump.FundingSchedules[0].Name, fundingSchedules[0].Name)
+		assert.Equal(t, dump.FundingSchedules[0].RuleSet.String(), fundingSchedules[0].RuleSet.String())
+
+		spending, err := repo.GetSpending(ctx, bankAccountId)
+		require.NoError(t, err, "must retrieve spending")
+		require.Len(t, spending, 1, "should have imported the spending")
+		assert.Equal(t, "Rent", spending[0].Name)
+		assert.Equal(t, fundingSchedules[0].FundingScheduleId, spending[0].FundingScheduleId, "spending should reference the new funding schedule")
+		assert.EqualValues(t, 2500, spending[0].CurrentAmount, "allocations should carry over")
+
+		transactions, err := repo.GetTransactions(ctx, bankAccountId, 100, 0)
+		require.NoError(t, err, "must retrieve transactions")
+		require.Len(t, transactions, 3, "should have imported the transactions")
+		var assigned int
+		for _, transaction := range transactions {
+			if transaction.SpendingId != nil {
+				assigned++
+				assert.Equal(t, spending[0].SpendingId, *transaction.SpendingId, "transaction should reference the new spending")
+			}
+		}
+		assert.Equal(t, 1, assigned, "one transaction should be assigned to the spending")
+	})
+
+	t.Run("links at the same institution", func(t *testing.T) {
+		clock := clock.NewMock()
+		dump := givenIHaveADataExport(t, clock)
+		// Add a second link at the same institution with its own bank account,
+		// like a personal and a business login at the same bank.
+		secondLink := dump.Links[0]
+		secondLink.LinkId = models.NewID(&models.Link{})
+		dump.Links = append(dump.Links, secondLink)
+		secondBankAccount := dump.BankAccounts[0]
+		secondBankAccount.BankAccountId = models.NewID(&models.BankAccount{})
+		secondBankAccount.LinkId = secondLink.LinkId
+		dump.BankAccounts = append(dump.BankAccounts, secondBankAccount)
+
+		target, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		repo := repository.NewRepositoryFromSession(
+			clock,
+			target.UserId,
+			target.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		ctx := context.Background()
+
+		{ // Both links and their bank accounts are created
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			assert.EqualValues(t, map[dataImportAction]int{
+				dataImportCreated: 9,
+			}, importer.results, "every object should have been created")
+		}
+
+		links, err := repo.GetLinks(ctx)
+		require.NoError(t, err, "must retrieve links")
+		require.Len(t, links, 2, "links at the same institution should not be merged")
+		assert.ElementsMatch(t, []models.ID[models.Link]{
+			dump.Links[0].LinkId,
+			dump.Links[1].LinkId,
+		}, []models.ID[models.Link]{
+			links[0].LinkId,
+			links[1].LinkId,
+		}, "links should keep the ID from the export")
+
+		bankAccounts, err := repo.GetBankAccounts(ctx)
+		require.NoError(t, err, "must retrieve bank accounts")
+		require.Len(t, bankAccounts, 2, "each link should have its own bank account")
+		assert.NotEqual(t, bankAccounts[0].LinkId, bankAccounts[1].LinkId, "bank accounts should reference different links")
+
+		{ // Importing again matches each link by its ID
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			assert.EqualValues(t, map[dataImportAction]int{
+				dataImportSkipped: 9,
+			}, importer.results, "every object should have been skipped")
+		}
+	})
+
+	t.Run("transaction splits", func(t *testing.T) {
+		clock := clock.NewMock()
+		dump := givenIHaveADataExport(t, clock)
+		oldSpendingId := dump.Spending[0].SpendingId
+		split := &dump.Transactions[1]
+		split.Amount = 5000
+		split.SpendingId = nil
+		split.Splits = []models.TransactionSplit{
+			{
+				TransactionSplitId: "tspl_1",
+				BankAccountId:      split.BankAccountId,
+				TransactionId:      split.TransactionId,
+				SpendingId:         &oldSpendingId,
+				Amount:             3000,
+			},
+			{
+				TransactionSplitId: "tspl_2",
+				BankAccountId:      split.BankAccountId,
+				TransactionId:      split.TransactionId,
+				Amount:             2000,
+			},
+		}
+
+		target, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		repo := repository.NewRepositoryFromSession(
+			clock,
+			target.UserId,
+			target.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		ctx := context.Background()
+
+		{ // A split that references spending that is not in the export
+			invalid := dump
+			invalid.Transactions = append([]models.Transaction{}, dump.Transactions...)
+			invalid.Transactions[1].Splits = append([]models.TransactionSplit{}, split.Splits...)
+			missing := models.NewID(&models.Spending{})
+			invalid.Transactions[1].Splits[0].SpendingId = &missing
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			err := importer.run(ctx, repo, invalid)
+			assert.EqualError(t, err, fmt.Sprintf("split tspl_1 of transaction %s references spending %s which is not in the export", split.TransactionId, missing))
+		}
+
+		{ // Splits that do not add up to the transaction
+			invalid := dump
+			invalid.Transactions = append([]models.Transaction{}, dump.Transactions...)
+			invalid.Transactions[1].Amount = 6000
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			err := importer.run(ctx, repo, invalid)
+			assert.ErrorContains(t, err, fmt.Sprintf("transaction %s has invalid splits", split.TransactionId))
+		}
+
+		// The failed imports above are not rolled back by the importer, so use a
+		// fresh account for the actual import.
+		target, _ = fixtures.GivenIHaveABasicAccount(t, clock)
+		repo = repository.NewRepositoryFromSession(
+			clock,
+			target.UserId,
+			target.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+
+		importer := &dataImporter{
+			log: testutils.GetLog(t),
+			out: &bytes.Buffer{},
+		}
+		require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+
+		bankAccounts, err := repo.GetBankAccounts(ctx)
+		require.NoError(t, err, "must retrieve bank accounts")
+		require.Len(t, bankAccounts, 1, "should have imported the bank account")
+		bankAccountId := bankAccounts[0].BankAccountId
+
+		spending, err := repo.GetSpending(ctx, bankAccountId)
+		require.NoError(t, err, "must retrieve spending")
+		require.Len(t, spending, 1, "should have imported the spending")
+		assert.EqualValues(t, 2500, spending[0].CurrentAmount, "splits should not be spent from again")
+
+		transactions, err := repo.GetTransactions(ctx, bankAccountId, 100, 0)
+		require.NoError(t, err, "must retrieve transactions")
+		var splitTransaction *models.Transaction
+		for i := range transactions {
+			if transactions[i].OriginalName == split.OriginalName && transactions[i].Amount == split.Amount {
+				splitTransaction = &transactions[i]
+			}
+		}
+		require.NotNil(t, splitTransaction, "should have imported the split transaction")
+
+		splits, err := repo.GetTransactionSplits(ctx, bankAccountId, splitTransaction.TransactionId)
+		require.NoError(t, err, "must retrieve splits")
+		require.Len(t, splits, 2, "should have imported both splits")
+		var total int64
+		for _, item := range splits {
+			total += item.Amount
+			assert.NotEqual(t, "tspl_1", item.TransactionSplitId.String(), "splits should be given a new ID")
+			assert.NotEqual(t, "tspl_2", item.TransactionSplitId.String(), "splits should be given a new ID")
+			if item.Amount == 3000 {
+				require.NotNil(t, item.SpendingId, "split should still be spent from spending")
+				assert.Equal(t, spending[0].SpendingId, *item.SpendingId, "split should reference the new spending")
+			} else {
+				assert.Nil(t, item.SpendingId, "split without spending should stay that way")
+			}
+		}
+		assert.EqualValues(t, split.Amount, total, "splits should add up to the transaction")
+	})
+
+	t.Run("importing twice updates and skips existing objects", func(t *testing.T) {
+		clock := clock.NewMock()
+		dump := givenIHaveADataExport(t, clock)
+		target, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		repo := repository.NewRepositoryFromSession(
+			clock,
+			target.UserId,
+			target.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		ctx := context.Background()
+
+		{ // Initial import
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+		}
+
+		{ // Importing the same export again should not change anything
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			assert.EqualValues(t, map[dataImportAction]int{
+				dataImportSkipped: 7,
+			}, importer.results, "every object should have been skipped")
+		}
+
+		{ // Changed objects should be updated in place
+			dump.BankAccounts[0].CurrentBalance += 1000
+			dump.Spending[0].CurrentAmount = 5000
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: &bytes.Buffer{},
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			assert.EqualValues(t, map[dataImportAction]int{
+				dataImportUpdated: 2,
+				dataImportSkipped: 5,
+			}, importer.results, "bank account and spending should be updated")
+		}
+
+		bankAccounts, err := repo.GetBankAccounts(ctx)
+		require.NoError(t, err, "must retrieve bank accounts")
+		require.Len(t, bankAccounts, 1, "should not have duplicated the bank account")
+		assert.Equal(t, dump.BankAccounts[0].CurrentBalance, bankAccounts[0].CurrentBalance, "balance should be updated")
+
+		spending, err := repo.GetSpending(ctx, bankAccounts[0].BankAccountId)
+		require.NoError(t, err, "must retrieve spending")
+		require.Len(t, spending, 1, "should not have duplicated the spending")
+		assert.EqualValues(t, 5000, spending[0].CurrentAmount, "current amount should be updated")
+
+		transactions, err := repo.GetTransactions(ctx, bankAccounts[0].BankAccountId, 100, 0)
+		require.NoError(t, err, "must retrieve transactions")
+		assert.Len(t, transactions, 3, "should not have duplicated the transactions")
+	})
+
+	t.Run("dry run output", func(t *testing.T) {
+		clock := clock.NewMock()
+		dump := givenIHaveADataExport(t, clock)
+		target, _ := fixtures.GivenIHaveABasicAccount(t, clock)
+		repo := repository.NewRepositoryFromSession(
+			clock,
+			target.UserId,
+			target.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		ctx := context.Background()
+
+		// The importer does not roll anything back itself, that is done by the
+		// import command. So the first dry run here still creates the objects in
+		// the target account.
+		{ // A dry run into an empty account only creates objects
+			out := &bytes.Buffer{}
+			importer := &dataImporter{
+				log:    testutils.GetLog(t),
+				out:    out,
+				dryRun: true,
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			assert.Len(t, lines, 7, "should print a line for every object")
+			for _, line := range lines {
+				assert.True(t, strings.HasPrefix(line, "+ "), "every object should be created: %s", line)
+			}
+			assert.Contains(t, out.String(), `"Rent"`)
+		}
+
+		{ // A dry run against existing objects reports updates and skips
+			dump.Spending[0].TargetAmount = 120000
+			out := &bytes.Buffer{}
+			importer := &dataImporter{
+				log:    testutils.GetLog(t),
+				out:    out,
+				dryRun: true,
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			assert.Len(t, lines, 7, "should print a line for every object")
+			for _, line := range lines {
+				if strings.Contains(line, `"Rent"`) {
+					assert.True(t, strings.HasPrefix(line, "~ spending"), "spending should be updated: %s", line)
+					continue
+				}
+				assert.True(t, strings.HasPrefix(line, "= "), "everything else should be skipped: %s", line)
+			}
+		}
+
+		{ // Output is only written for dry runs
+			out := &bytes.Buffer{}
+			importer := &dataImporter{
+				log: testutils.GetLog(t),
+				out: out,
+			}
+			require.NoError(t, importer.run(ctx, repo, dump), "import should succeed")
+			assert.Empty(t, out.String(), "should not print changes outside of a dry run")
+		}
+	})
+}
@@ -108,6 +108,8 @@ var (
 				nil,
 				nil,
 				nil,
+				nil,
+				nil,
 			)
 			if err != nil {
 				return err
@@ -235,6 +237,8 @@ var (
 				nil,
 				nil,
 				nil,
+				nil,
+				nil,
 			)
 			if err != nil {
 				return err
@@ -29,7 +29,9 @@ import (
 	"github.com/monetr/monetr/server/pubsub"
 	"github.com/monetr/monetr/server/repository"
 	"github.com/monetr/monetr/server/security"
+	"github.com/monetr/monetr/server/simplefin"
 	"github.com/monetr/monetr/server/stripe_helper"
+	"github.com/monetr/monetr/server/teller"
 	"github.com/monetr/monetr/server/ui"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -135,6 +137,7 @@ func RunServer() error {
 						delete(event.Request.Headers, "M-Token")
 						delete(event.Request.Headers, "Plaid-Verification")
 						delete(event.Request.Headers, "Stripe-Signature")
+						delete(event.Request.Headers, "Teller-Signature")
 					}
 				}
 
@@ -156,6 +159,7 @@ func RunServer() error {
 						delete(event.Request.Headers, "M-Token")
 						delete(event.Request.Headers, "Plaid-Verification")
 						delete(event.Request.Headers, "Stripe-Signature")
+						delete(event.Request.Headers, "Teller-Signature")
 					}
 				}
 				return event
@@ -248,6 +252,22 @@ func RunServer() error {
 		1*time.Hour,
 	)
 
+	var tellerClient teller.Teller
+	if configuration.Teller.GetEnabled() {
+		log.Debug("teller is enabled and will be setup")
+		tellerClient, err = teller.NewTeller(log, clock, kms, db, configuration.Teller)
+		if err != nil {
+			log.WithError(err).Fatal("failed to setup teller")
+			return err
+		}
+	}
+
+	var simpleFINClient simplefin.SimpleFIN
+	if configuration.SimpleFIN.GetEnabled() {
+		log.Debug("simplefin is enabled and will be setup")
+		simpleFINClient = simplefin.NewSimpleFIN(log, configuration.SimpleFIN)
+	}
+
 	var recaptcha captcha.Verification
 	if configuration.ReCAPTCHA.Enabled {
 		recaptcha, err = captcha.NewReCAPTCHAVerification(
@@ -274,6 +294,8 @@ func RunServer() error {
 			db,
 			pubSub,
 			plaidClient,
+			tellerClient,
+			simpleFINClient,
 			kms,
 			fileStorage,
 			bill,
@@ -316,8 +338,10 @@ func RunServer() error {
 			PlaidInstitutions:        plaidInstitutions,
 			PlaidWebhookVerification: plaidWebhooks,
 			PubSub:                   pubSub,
+			SimpleFIN:                simpleFINClient,
 			Stats:                    stats,
 			Stripe:                   stripe,
+			Teller:                   tellerClient,
 		},
 		ui.NewUIController(log, configuration),
 	}
@@ -136,3 +136,132 @@ func (TrialAboutToExpireParams) Template() string {
 func (TrialAboutToExpireParams) Subject() string {
 	return "Trial About To Expire"
 }
+
+type AccountDeletionRequestedParams struct {
+	BaseURL      string
+	Email        string
+	FirstName    string
+	LastName     string
+	DeletionDate string
+	SupportEmail string
+}
+
+func (p AccountDeletionRequestedParams) EmailAddress() string {
+	return p.Email
+}
+
+func (p AccountDeletionRequestedParams) Name() (firstName, lastName string) {
+	return p.FirstName, p.LastName
+}
+
+func (AccountDeletionRequestedParams) Template() string {
+	return "AccountDeletionRequested"
+}
+
+func (AccountDeletionRequestedParams) Subject() string {
+	return "Account Deletion Requested"
+}
+
+type AccountDeletionCanceledParams struct {
+	BaseURL      string
+	Email        string
+	FirstName    string
+	LastName     string
+	SupportEmail string
+}
+
+func (p AccountDeletionCanceledParams) EmailAddress() string {
+	return p.Email
+}
+
+func (p AccountDeletionCanceledParams) Name() (firstName, lastName string) {
+	return p.FirstName, p.LastName
+}
+
+func (AccountDeletionCanceledParams) Template() string {
+	return "AccountDeletionCanceled"
+}
+
+func (AccountDeletionCanceledParams) Subject() string {
+	return "Account Deletion Canceled"
+}
+
+type AccountDeletedParams struct {
+	BaseURL      string
+	Email        string
+	FirstName    string
+	LastName     string
+	SupportEmail string
+}
+
+func (p AccountDeletedParams) EmailAddress() string {
+	return p.Email
+}
+
+func (p AccountDeletedParams) Name() (firstName, lastName string) {
+	return p.FirstName, p.LastName
+}
+
+func (AccountDeletedParams) Template() string {
+	return "AccountDeleted"
+}
+
+func (AccountDeletedParams) Subject() string {
+	return "Account Deleted"
+}
+
+// NotificationParams is used for budget notifications, the title is used as
+// the subject of the email.
+type NotificationParams struct {
+	BaseURL      string
+	Email        string
+	FirstName    string
+	LastName     string
+	Title        string
+	Message      string
+	SupportEmail string
+}
+
+func (p NotificationParams) EmailAddress() string {
+	return p.Email
+}
+
+func (p NotificationParams) Name() (firstName, lastName string) {
+	return p.FirstName, p.LastName
+}
+
+func (NotificationParams) Template() string {
+	return "Notification"
+}
+
+func (p NotificationParams) Subject() string {
+	return p.Title
+}
+
+// InvitationParams is used to invite someone to join an existing account, the
+// first and last name are of the owner who sent the invitation.
+type InvitationParams struct {
+	BaseURL      string
+	Email        string
+	FirstName    string
+	LastName     string
+	SupportEmail string
+	InviteURL    string
+}
+
+func (p InvitationParams) EmailAddress() string {
+	return p.Email
+}
+
+func (p InvitationParams) Name() (firstName, lastName string) {
+	// We don't know the name of the person being invited yet.
+	return "", ""
+}
+
+func (InvitationParams) Template() string {
+	return "Invitation"
+}
+
+func (InvitationParams) Subject() string {
+	return "You've Been Invited To monetr"
+}
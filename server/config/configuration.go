@@ -46,8 +46,10 @@ type Configuration struct {
 	Security      Security      `yaml:"security"`
 	Sentry        Sentry        `yaml:"sentry"`
 	Server        Server        `yaml:"server"`
+	SimpleFIN     SimpleFIN     `yaml:"simplefin"`
 	Storage       Storage       `yaml:"storage"`
 	Stripe        Stripe        `yaml:"stripe"`
+	Teller        Teller        `yaml:"teller"`
 }
 
 func (c Configuration) GetConfigFileName() string {
@@ -221,6 +223,47 @@ func (p Plaid) GetWebhooksURL() string {
 	return fmt.Sprintf("https://%s/api/plaid/webhook", p.WebhooksDomain)
 }
 
+type TellerEnvironment string
+
+const (
+	TellerSandbox     TellerEnvironment = "sandbox"
+	TellerDevelopment TellerEnvironment = "development"
+	TellerProduction  TellerEnvironment = "production"
+)
+
+type Teller struct {
+	Enabled bool `yaml:"enabled"`
+	// ApplicationId is the public identifier of your Teller application, it is
+	// provided to the UI so that Teller Connect can be started.
+	ApplicationId string            `yaml:"applicationId"`
+	Environment   TellerEnvironment `yaml:"environment"`
+	// Certificate and PrivateKey are paths to the PEM encoded client
+	// certificate Teller issues for your application. Teller requires mutual TLS
+	// for the development and production environments, the sandbox environment
+	// can be used without them.
+	Certificate string `yaml:"certificate"`
+	PrivateKey  string `yaml:"privateKey"`
+	// WebhookSigningSecret is used to verify that webhooks received by monetr
+	// were actually sent by Teller. If this is left blank then Teller webhooks
+	// will be rejected.
+	WebhookSigningSecret string `yaml:"webhookSigningSecret"`
+}
+
+func (t Teller) GetEnabled() bool {
+	return t.Enabled && t.ApplicationId != ""
+}
+
+// SimpleFIN allows users to connect their own SimpleFIN server, like the
+// SimpleFIN Bridge, to sync their bank data. Unlike Plaid and Teller there are
+// no credentials for monetr itself; each user provides a setup token.
+type SimpleFIN struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+func (s SimpleFIN) GetEnabled() bool {
+	return s.Enabled
+}
+
 type CORS struct {
 	AllowedOrigins []string `yaml:"allowedOrigins"`
 	Debug          bool     `yaml:"debug"`
@@ -357,6 +400,9 @@ func setupDefaults(v *viper.Viper) {
 	v.SetDefault("KeyManagement.Vault", nil)
 	v.SetDefault("Plaid.Enabled", true)
 	v.SetDefault("Plaid.CountryCodes", []plaid.CountryCode{plaid.COUNTRYCODE_US})
+	v.SetDefault("Teller.Enabled", false)
+	v.SetDefault("Teller.Environment", TellerSandbox)
+	v.SetDefault("SimpleFIN.Enabled", false)
 	v.SetDefault("PostgreSQL.Address", "localhost")
 	v.SetDefault("PostgreSQL.Database", "postgres")
 	v.SetDefault("PostgreSQL.Port", 5432)
@@ -419,6 +465,13 @@ func setupEnv(v *viper.Viper) {
 	_ = v.BindEnv("Plaid.WebhooksEnabled", "MONETR_PLAID_WEBHOOKS_ENABLED")
 	_ = v.BindEnv("Plaid.WebhooksDomain", "MONETR_PLAID_WEBHOOKS_DOMAIN")
 	_ = v.BindEnv("Plaid.OAuthDomain", "MONETR_PLAID_OAUTH_DOMAIN")
+	_ = v.BindEnv("Teller.Enabled", "MONETR_TELLER_ENABLED")
+	_ = v.BindEnv("Teller.ApplicationId", "MONETR_TELLER_APPLICATION_ID")
+	_ = v.BindEnv("Teller.Environment", "MONETR_TELLER_ENVIRONMENT")
+	_ = v.BindEnv("Teller.Certificate", "MONETR_TELLER_CERTIFICATE")
+	_ = v.BindEnv("Teller.PrivateKey", "MONETR_TELLER_PRIVATE_KEY")
+	_ = v.BindEnv("Teller.WebhookSigningSecret", "MONETR_TELLER_WEBHOOK_SIGNING_SECRET")
+	_ = v.BindEnv("SimpleFIN.Enabled", "MONETR_SIMPLEFIN_ENABLED")
 	_ = v.BindEnv("PostgreSQL.Address", "MONETR_PG_ADDRESS")
 	_ = v.BindEnv("PostgreSQL.Port", "MONETR_PG_PORT")
 	_ = v.BindEnv("PostgreSQL.Username", "MONETR_PG_USERNAME")
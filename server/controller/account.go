@@ -2,11 +2,146 @@ package controller
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/communication"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
 )
 
+// accountDeletionGracePeriod is how long an account will remain after the
+// owner has requested that it be deleted. During this time the deletion can
+// still be canceled.
+const accountDeletionGracePeriod = 14 * 24 * time.Hour
+
 func (c *Controller) deleteAccount(ctx echo.Context) error {
-	// TODO Implement a way to delete account data.
-	return echo.NewHTTPError(http.StatusNotImplemented, "account deletion not yet implemented")
+	var request struct {
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	request.Password = strings.TrimSpace(request.Password)
+	request.TOTP = strings.TrimSpace(request.TOTP)
+
+	if request.Password == "" {
+		return c.badRequest(ctx, "Password is required to delete your account")
+	}
+
+	me, err := c.mustGetAuthenticatedRepository(ctx).GetMe(c.getContext(ctx))
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve current user details")
+	}
+
+	if me.Role != models.UserRoleOwner {
+		return c.returnError(ctx, http.StatusForbidden, "Only the owner of an account can delete it")
+	}
+
+	if me.Account.IsPendingDeletion() {
+		return c.badRequest(ctx, "Account is already scheduled to be deleted")
+	}
+
+	// Make sure that the user re-authenticates before we allow the account to be
+	// deleted. Their current password is always required, and their TOTP code is
+	// also required if they have TOTP enabled.
+	_, _, err = c.mustGetSecurityRepository(ctx).Login(
+		c.getContext(ctx),
+		me.Login.Email,
+		request.Password,
+	)
+	switch errors.Cause(err) {
+	case nil:
+	case repository.ErrInvalidCredentials:
+		return c.returnError(ctx, http.StatusUnauthorized, "Password provided is not correct")
+	default:
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify credentials")
+	}
+
+	if me.Login.TOTPEnabledAt != nil {
+		if request.TOTP == "" {
+			return c.badRequest(ctx, "TOTP code is required to delete your account")
+		}
+
+		if err := me.Login.VerifyTOTP(request.TOTP, c.Clock.Now()); err != nil {
+			return c.returnError(ctx, http.StatusUnauthorized, "Invalid TOTP code")
+		}
+	}
+
+	now := c.Clock.Now().UTC()
+	deleteAt := now.Add(accountDeletionGracePeriod)
+	account := me.Account
+	account.DeletionRequestedAt = &now
+	account.DeletionScheduledAt = &deleteAt
+	if err := c.Accounts.UpdateAccount(c.getContext(ctx), account); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to schedule account deletion")
+	}
+
+	// The deletion has already been scheduled at this point, so failing to send
+	// the email should not fail the request.
+	if c.Configuration.Email.Enabled {
+		timezone, err := account.GetTimezone()
+		if err != nil {
+			timezone = time.UTC
+		}
+
+		if err := c.Email.SendEmail(
+			c.getContext(ctx),
+			communication.AccountDeletionRequestedParams{
+				BaseURL:      c.Configuration.Server.GetBaseURL().String(),
+				Email:        me.Login.Email,
+				FirstName:    me.Login.FirstName,
+				LastName:     me.Login.LastName,
+				DeletionDate: deleteAt.In(timezone).Format("Monday January 2, 2006"),
+				SupportEmail: "support@monetr.app",
+			},
+		); err != nil {
+			c.getLog(ctx).WithError(err).Warn("failed to send account deletion notification")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, account)
+}
+
+func (c *Controller) deleteAccountDeletion(ctx echo.Context) error {
+	me, err := c.mustGetAuthenticatedRepository(ctx).GetMe(c.getContext(ctx))
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve current user details")
+	}
+
+	if me.Role != models.UserRoleOwner {
+		return c.returnError(ctx, http.StatusForbidden, "Only the owner of an account can cancel its deletion")
+	}
+
+	account := me.Account
+	if !account.IsPendingDeletion() {
+		return c.badRequest(ctx, "Account is not scheduled to be deleted")
+	}
+
+	account.DeletionRequestedAt = nil
+	account.DeletionScheduledAt = nil
+	if err := c.Accounts.UpdateAccount(c.getContext(ctx), account); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to cancel account deletion")
+	}
+
+	if c.Configuration.Email.Enabled {
+		if err := c.Email.SendEmail(
+			c.getContext(ctx),
+			communication.AccountDeletionCanceledParams{
+				BaseURL:      c.Configuration.Server.GetBaseURL().String(),
+				Email:        me.Login.Email,
+				FirstName:    me.Login.FirstName,
+				LastName:     me.Login.LastName,
+				SupportEmail: "support@monetr.app",
+			},
+		); err != nil {
+			c.getLog(ctx).WithError(err).Warn("failed to send account deletion canceled notification")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, account)
 }
@@ -0,0 +1,226 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/communication"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/pkg/errors"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDeleteAccount(t *testing.T) {
+	t.Run("schedule and cancel deletion", func(t *testing.T) {
+		config := NewTestApplicationConfig(t)
+		config.Email.Enabled = true
+		app, e := NewTestApplicationWithConfig(t, config)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		app.Email.EXPECT().
+			SendEmail(
+				gomock.Any(),
+				gomock.AssignableToTypeOf(communication.AccountDeletionRequestedParams{}),
+			).
+			Return(nil).
+			Times(1)
+
+		{ // Request that the account be deleted.
+			response := e.DELETE(`/api/account`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"password": password,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.deletionRequestedAt").String().NotEmpty()
+			response.JSON().Path("$.deletionScheduledAt").String().NotEmpty()
+		}
+
+		{ // Requesting it again should fail since it is already scheduled.
+			response := e.DELETE(`/api/account`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"password": password,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Account is already scheduled to be deleted")
+		}
+
+		app.Email.EXPECT().
+			SendEmail(
+				gomock.Any(),
+				gomock.AssignableToTypeOf(communication.AccountDeletionCanceledParams{}),
+			).
+			Return(nil).
+			Times(1)
+
+		{ // Cancel the deletion.
+			response := e.DELETE(`/api/account/deletion`).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.deletionRequestedAt").IsNull()
+			response.JSON().Path("$.deletionScheduledAt").IsNull()
+		}
+
+		{ // Canceling again should fail since nothing is scheduled.
+			response := e.DELETE(`/api/account/deletion`).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Account is not scheduled to be deleted")
+		}
+	})
+
+	t.Run("email failure does not fail the request", func(t *testing.T) {
+		config := NewTestApplicationConfig(t)
+		config.Email.Enabled = true
+		app, e := NewTestApplicationWithConfig(t, config)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		app.Email.EXPECT().
+			SendEmail(
+				gomock.Any(),
+				gomock.AssignableToTypeOf(communication.AccountDeletionRequestedParams{}),
+			).
+			Return(errors.New("smtp is down")).
+			Times(1)
+
+		{ // The deletion is still scheduled even though the email failed.
+			response := e.DELETE(`/api/account`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"password": password,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.deletionScheduledAt").String().NotEmpty()
+		}
+
+		app.Email.EXPECT().
+			SendEmail(
+				gomock.Any(),
+				gomock.AssignableToTypeOf(communication.AccountDeletionCanceledParams{}),
+			).
+			Return(errors.New("smtp is down")).
+			Times(1)
+
+		{ // And the cancellation still succeeds.
+			response := e.DELETE(`/api/account/deletion`).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.deletionScheduledAt").IsNull()
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.DELETE(`/api/account`).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"password": password + "wrong",
+			}).
+			Expect()
+
+		response.Status(http.StatusUnauthorized)
+		response.JSON().Path("$.error").String().IsEqual("Password provided is not correct")
+	})
+
+	t.Run("missing password", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.DELETE(`/api/account`).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Password is required to delete your account")
+	})
+
+	t.Run("requires totp when enabled", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		totp := fixtures.GivenIHaveTOTPForLogin(t, app.Clock, user.Login)
+
+		var token string
+		{ // Login, this will require MFA.
+			response := e.POST("/api/authentication/login").
+				WithJSON(map[string]interface{}{
+					"email":    user.Login.Email,
+					"password": password,
+				}).
+				Expect()
+
+			response.Status(http.StatusPreconditionRequired)
+			token = AssertSetTokenCookie(t, response)
+		}
+
+		{ // Provide the TOTP code to get an authenticated token.
+			response := e.POST("/api/authentication/multifactor").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"totp": totp.AtTime(app.Clock.Now()),
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			token = AssertSetTokenCookie(t, response)
+		}
+
+		{ // Without the TOTP code the deletion should be rejected.
+			response := e.DELETE(`/api/account`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"password": password,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("TOTP code is required to delete your account")
+		}
+
+		{ // With an invalid TOTP code the deletion should be rejected.
+			response := e.DELETE(`/api/account`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"password": password,
+					"totp":     "000000",
+				}).
+				Expect()
+
+			response.Status(http.StatusUnauthorized)
+			response.JSON().Path("$.error").String().IsEqual("Invalid TOTP code")
+		}
+
+		// Email is not enabled, so no notification should be sent.
+		{ // With a valid TOTP code the deletion should be scheduled.
+			response := e.DELETE(`/api/account`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"password": password,
+					"totp":     totp.AtTime(app.Clock.Now()),
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.deletionScheduledAt").String().NotEmpty()
+		}
+	})
+}
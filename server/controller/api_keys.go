@@ -3,15 +3,109 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/security"
 )
 
+// apiKeyRouteScopes is every endpoint that can be accessed using an API key,
+// along with the scopes that can be used to access it. If an endpoint is not
+// present here then it cannot be accessed by an API key at all. Keys are
+// looked up by the request method and route path without the API prefix.
+var apiKeyRouteScopes = map[string][]security.Scope{
+	// Links
+	"GET /links":                       {security.ReadLinksScope, security.WriteLinksScope},
+	"GET /links/:linkId":               {security.ReadLinksScope, security.WriteLinksScope},
+	"POST /links":                      {security.WriteLinksScope},
+	"PUT /links/:linkId":               {security.WriteLinksScope},
+	"PUT /links/convert/:linkId":       {security.WriteLinksScope},
+	"DELETE /links/:linkId":            {security.WriteLinksScope},
+	"GET /links/wait/:linkId":          {security.WriteLinksScope},
+	"GET /institutions/:institutionId": {security.ReadLinksScope, security.WriteLinksScope},
+	"POST /plaid/link/sync":            {security.TriggerSyncScope},
+	"POST /teller/link/sync":           {security.TriggerSyncScope},
+	"POST /simplefin/link/sync":        {security.TriggerSyncScope},
+	// Bank Accounts
+	"GET /bank_accounts":                         {security.ReadBankAccountsScope, security.WriteBankAccountsScope},
+	"GET /bank_accounts/:bankAccountId":          {security.ReadBankAccountsScope, security.WriteBankAccountsScope},
+	"PUT /bank_accounts/:bankAccountId":          {security.WriteBankAccountsScope},
+	"GET /bank_accounts/:bankAccountId/balances": {security.ReadBankAccountsScope, security.WriteBankAccountsScope},
+	"POST /bank_accounts":                        {security.WriteBankAccountsScope},
+	// Balances
+	"GET /bank_accounts/:bankAccountId/balances/history":  {security.ReadBankAccountsScope, security.WriteBankAccountsScope},
+	"GET /bank_accounts/:bankAccountId/balances/alert":    {security.ReadBankAccountsScope, security.WriteBankAccountsScope},
+	"PUT /bank_accounts/:bankAccountId/balances/alert":    {security.WriteBankAccountsScope},
+	"DELETE /bank_accounts/:bankAccountId/balances/alert": {security.WriteBankAccountsScope},
+	// Transactions
+	"GET /bank_accounts/:bankAccountId/transactions":                                      {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transactions/:transactionId":                       {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transactions/:transactionId/similar":               {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transactions/:transactionId/splits":                {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"PUT /bank_accounts/:bankAccountId/transactions/:transactionId/splits":                {security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/transactions":                                     {security.WriteTransactionsScope},
+	"PUT /bank_accounts/:bankAccountId/transactions/:transactionId":                       {security.WriteTransactionsScope},
+	"DELETE /bank_accounts/:bankAccountId/transactions/:transactionId":                    {security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/transactions/upload":                              {security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transactions/upload/mapping":                       {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"PUT /bank_accounts/:bankAccountId/transactions/upload/mapping":                       {security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transactions/upload/:transactionUploadId":          {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transactions/upload/:transactionUploadId/progress": {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	// Transaction rules
+	"GET /bank_accounts/:bankAccountId/transaction_rules":                       {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"GET /bank_accounts/:bankAccountId/transaction_rules/:transactionRuleId":    {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/transaction_rules":                      {security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/transaction_rules/preview":              {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"PUT /bank_accounts/:bankAccountId/transaction_rules/:transactionRuleId":    {security.WriteTransactionsScope},
+	"DELETE /bank_accounts/:bankAccountId/transaction_rules/:transactionRuleId": {security.WriteTransactionsScope},
+	// Recurring transactions
+	"GET /bank_accounts/:bankAccountId/recurring":                                   {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/recurring/:transactionRecurringId/spending": {security.WriteSpendingScope},
+	// Merchants
+	"GET /bank_accounts/:bankAccountId/merchants":                                 {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	"PUT /bank_accounts/:bankAccountId/merchants/:transactionClusterId":           {security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/merchants/:transactionClusterId/merge":    {security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/merchants/:transactionClusterId/split":    {security.WriteTransactionsScope},
+	"POST /bank_accounts/:bankAccountId/merchants/:transactionClusterId/spending": {security.WriteTransactionsScope},
+	// Reports
+	"GET /bank_accounts/:bankAccountId/reports": {security.ReadTransactionsScope, security.WriteTransactionsScope},
+	// Funding schedules
+	"GET /bank_accounts/:bankAccountId/funding_schedules":                       {security.ReadFundingSchedulesScope, security.WriteFundingSchedulesScope},
+	"GET /bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId":    {security.ReadFundingSchedulesScope, security.WriteFundingSchedulesScope},
+	"POST /bank_accounts/:bankAccountId/funding_schedules":                      {security.WriteFundingSchedulesScope},
+	"PUT /bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId":    {security.WriteFundingSchedulesScope},
+	"DELETE /bank_accounts/:bankAccountId/funding_schedules/:fundingScheduleId": {security.WriteFundingSchedulesScope},
+	// Spending
+	"GET /bank_accounts/:bankAccountId/spending":                          {security.ReadSpendingScope, security.WriteSpendingScope},
+	"GET /bank_accounts/:bankAccountId/spending/:spendingId":              {security.ReadSpendingScope, security.WriteSpendingScope},
+	"GET /bank_accounts/:bankAccountId/spending/:spendingId/transactions": {security.ReadSpendingScope, security.WriteSpendingScope},
+	"GET /bank_accounts/:bankAccountId/spending/:spendingId/allocations":  {security.ReadSpendingScope, security.WriteSpendingScope},
+	"POST /bank_accounts/:bankAccountId/spending":                         {security.WriteSpendingScope},
+	"POST /bank_accounts/:bankAccountId/spending/transfer":                {security.WriteSpendingScope},
+	"PUT /bank_accounts/:bankAccountId/spending/:spendingId":              {security.WriteSpendingScope},
+	"DELETE /bank_accounts/:bankAccountId/spending/:spendingId":           {security.WriteSpendingScope},
+	// Forecasting
+	"GET /bank_accounts/:bankAccountId/forecast":               {security.ReadSpendingScope, security.WriteSpendingScope},
+	"POST /bank_accounts/:bankAccountId/forecast/spending":     {security.ReadSpendingScope, security.WriteSpendingScope},
+	"POST /bank_accounts/:bankAccountId/forecast/next_funding": {security.ReadFundingSchedulesScope, security.WriteFundingSchedulesScope},
+	"POST /bank_accounts/:bankAccountId/forecast/scenario":     {security.ReadSpendingScope, security.WriteSpendingScope},
+	"POST /bank_accounts/:bankAccountId/forecast/goal":         {security.ReadSpendingScope, security.WriteSpendingScope},
+}
+
+// apiKeyRoute returns the key used to look up the current request in the
+// apiKeyRouteScopes map.
+func apiKeyRoute(ctx echo.Context) string {
+	return ctx.Request().Method + " " + strings.TrimPrefix(ctx.Path(), APIPath)
+}
+
 type CreateAPIKeyRequest struct {
-	Name      string     `json:"name" validate:"required"`
-	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Name           string            `json:"name" validate:"required"`
+	ExpiresAt      *time.Time        `json:"expiresAt,omitempty"`
+	Scopes         []security.Scope  `json:"scopes"`
+	BankAccountIds []ID[BankAccount] `json:"bankAccountIds"`
 }
 
 type CreateAPIKeyResponse struct {
@@ -31,9 +125,37 @@ func (c *Controller) createAPIKey(ctx echo.Context) error {
 		return c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "malformed request")
 	}
 
+	if len(request.Scopes) == 0 {
+		return c.badRequest(ctx, "At least one scope must be granted to the API key")
+	}
+
+	scopes := make([]string, 0, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		if !scope.IsGrant() {
+			return c.badRequest(ctx, "Invalid scope: %s", scope)
+		}
+		scopes = append(scopes, string(scope))
+	}
+
 	userId := c.mustGetUserId(ctx)
 	repo := repository.NewRepositoryFromSession(c.Clock, userId, c.mustGetAccountId(ctx), c.DB)
-	key, apiKey, err := repo.CreateAPIKey(ctx.Request().Context(), string(userId), request.Name, request.ExpiresAt)
+
+	// Make sure that every bank account the key is being restricted to actually
+	// belongs to the current account.
+	for _, bankAccountId := range request.BankAccountIds {
+		if _, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId); err != nil {
+			return c.badRequest(ctx, "Invalid bank account: %s", bankAccountId)
+		}
+	}
+
+	key, apiKey, err := repo.CreateAPIKey(
+		ctx.Request().Context(),
+		string(userId),
+		request.Name,
+		request.ExpiresAt,
+		scopes,
+		request.BankAccountIds,
+	)
 	if err != nil {
 		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create API key")
 	}
@@ -61,7 +183,7 @@ func (c *Controller) revokeAPIKey(ctx echo.Context) error {
 	if apiKeyIdStr == "" {
 		return c.badRequest(ctx, "apiKeyId is required")
 	}
-	
+
 	apiKeyId, err := strconv.ParseInt(apiKeyIdStr, 10, 64)
 	if err != nil {
 		return c.badRequest(ctx, "invalid apiKeyId")
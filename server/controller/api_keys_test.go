@@ -0,0 +1,184 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("requires a scope", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST(`/api/security/api-keys`).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "Dashboard",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("At least one scope must be granted to the API key")
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST(`/api/security/api-keys`).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "Dashboard",
+				"scopes": []string{
+					"authenticated",
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid scope: authenticated")
+	})
+
+	t.Run("bank account from another account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		otherUser, _ := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		otherLink := fixtures.GivenIHaveAManualLink(t, app.Clock, otherUser)
+		otherBank := fixtures.GivenIHaveABankAccount(t, app.Clock, &otherLink, DepositoryBankAccountType, CheckingBankAccountSubType)
+
+		response := e.POST(`/api/security/api-keys`).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "Dashboard",
+				"scopes": []string{
+					"transactions:read",
+				},
+				"bankAccountIds": []string{
+					otherBank.BankAccountId.String(),
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid bank account: " + otherBank.BankAccountId.String())
+	})
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	t.Run("read only and restricted to a bank account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		allowedBank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		otherBank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, SavingsBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var key string
+		{ // Create an API key that can only read data for a single bank account.
+			response := e.POST(`/api/security/api-keys`).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name": "Family dashboard",
+					"scopes": []string{
+						"bankAccounts:read",
+						"transactions:read",
+						"spending:read",
+					},
+					"bankAccountIds": []string{
+						allowedBank.BankAccountId.String(),
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.key").String().NotEmpty()
+			response.JSON().Path("$.apiKey.scopes").Array().Length().IsEqual(3)
+			response.JSON().Path("$.apiKey.bankAccountIds").Array().Length().IsEqual(1)
+			key = response.JSON().Path("$.key").String().Raw()
+		}
+
+		{ // Only the allowed bank account should be listed.
+			response := e.GET(`/api/bank_accounts`).
+				WithHeader("X-API-Key", key).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].bankAccountId").String().IsEqual(allowedBank.BankAccountId.String())
+		}
+
+		{ // Transactions for the allowed bank account can be read.
+			response := e.GET(`/api/bank_accounts/{bankAccountId}/transactions`).
+				WithPath("bankAccountId", allowedBank.BankAccountId).
+				WithHeader("X-API-Key", key).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{ // But not for any other bank account.
+			response := e.GET(`/api/bank_accounts/{bankAccountId}/transactions`).
+				WithPath("bankAccountId", otherBank.BankAccountId).
+				WithHeader("X-API-Key", key).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().IsEqual("API key does not have access to this bank account")
+		}
+
+		{ // The key was not granted permission to create spending objects.
+			response := e.POST(`/api/bank_accounts/{bankAccountId}/spending`).
+				WithPath("bankAccountId", allowedBank.BankAccountId).
+				WithHeader("X-API-Key", key).
+				WithJSON(map[string]interface{}{
+					"name": "Groceries",
+				}).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().IsEqual("API key does not have permission to access this endpoint")
+		}
+
+		{ // Links are not scoped to a bank account so the key cannot see them.
+			response := e.GET(`/api/links`).
+				WithHeader("X-API-Key", key).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+		}
+
+		{ // API keys can never delete the account.
+			response := e.DELETE(`/api/account`).
+				WithHeader("X-API-Key", key).
+				WithJSON(map[string]interface{}{
+					"password": password,
+				}).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().IsEqual("API keys cannot access this endpoint")
+		}
+
+		{ // API keys cannot create more API keys.
+			response := e.POST(`/api/security/api-keys`).
+				WithHeader("X-API-Key", key).
+				WithJSON(map[string]interface{}{
+					"name": "Another key",
+					"scopes": []string{
+						"transactions:write",
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().IsEqual("API keys cannot access this endpoint")
+		}
+	})
+}
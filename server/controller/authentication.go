@@ -154,8 +154,10 @@ func (c *Controller) postLogin(ctx echo.Context) error {
 	case 0:
 		// TODO (elliotcourant) Should we allow them to create an account?
 		return c.returnError(ctx, http.StatusInternalServerError, "User has no accounts")
-	case 1:
-		user := login.Users[0]
+	default:
+		// A login can be a member of more than one account if they have accepted
+		// an invitation, sign them into their primary account.
+		user := primaryUserForLogin(login)
 
 		crumbs.IncludeUserInScope(c.getContext(ctx), user.AccountId)
 
@@ -236,14 +238,23 @@ func (c *Controller) postLogin(ctx echo.Context) error {
 		}
 
 		return ctx.JSON(http.StatusOK, result)
-	default:
-		// If the login has more than one user then we want to generate a temp
-		// JWT that will only grant them access to API endpoints not specific to
-		// an account.
-		return c.badRequest(ctx, "Multiple accounts not implemented, please contact support")
 	}
 }
 
+// primaryUserForLogin returns the user that a login is authenticated as when
+// they sign in. If the login owns an account then that account is used,
+// otherwise the first account they are a member of is used. The login must
+// have at least one user.
+func primaryUserForLogin(login *models.Login) models.User {
+	for _, user := range login.Users {
+		if user.Role == models.UserRoleOwner {
+			return user
+		}
+	}
+
+	return login.Users[0]
+}
+
 func (c *Controller) postMultifactor(ctx echo.Context) error {
 	var request struct {
 		TOTP string `json:"totp"`
@@ -306,6 +317,122 @@ func (c *Controller) postMultifactor(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, result)
 }
 
+// getAccounts returns every account that the current login is a member of. The
+// account that the current token is authenticated to is marked as current.
+func (c *Controller) getAccounts(ctx echo.Context) error {
+	claims := c.mustGetClaims(ctx)
+	users, err := c.mustGetSecurityRepository(ctx).GetUsersForLogin(
+		c.getContext(ctx),
+		c.mustGetLoginId(ctx),
+	)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve accounts")
+	}
+
+	type accountItem struct {
+		models.User
+		IsCurrent bool `json:"isCurrent"`
+	}
+
+	result := make([]accountItem, len(users))
+	for i := range users {
+		result[i] = accountItem{
+			User:      users[i],
+			IsCurrent: users[i].UserId.String() == claims.UserId,
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// postSwitchAccount re-issues the current token for another account that the
+// login is a member of. Every request made with the new token is then scoped to
+// that account, the same as if they had signed into it directly.
+func (c *Controller) postSwitchAccount(ctx echo.Context) error {
+	var request struct {
+		AccountId models.ID[models.Account] `json:"accountId"`
+		IsMobile  bool                      `json:"isMobile"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.AccountId.IsZero() {
+		return c.badRequest(ctx, "Account ID must be specified")
+	}
+
+	claims := c.mustGetClaims(ctx)
+	users, err := c.mustGetSecurityRepository(ctx).GetUsersForLogin(
+		c.getContext(ctx),
+		c.mustGetLoginId(ctx),
+	)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve accounts")
+	}
+
+	var user *models.User
+	for i := range users {
+		if users[i].AccountId == request.AccountId {
+			user = &users[i]
+			break
+		}
+	}
+	if user == nil {
+		return c.notFound(ctx, "You are not a member of that account")
+	}
+
+	crumbs.IncludeUserInScope(c.getContext(ctx), user.AccountId)
+
+	ctx.Set(authenticationKey, security.Claims{
+		LoginId:   user.LoginId.String(),
+		AccountId: user.AccountId.String(),
+		UserId:    user.UserId.String(),
+		Scope:     security.AuthenticatedScope,
+	})
+
+	token, err := c.ClientTokens.Create(
+		14*24*time.Hour,
+		security.Claims{
+			Scope:        security.AuthenticatedScope,
+			EmailAddress: claims.EmailAddress,
+			UserId:       user.UserId.String(),
+			AccountId:    user.AccountId.String(),
+			LoginId:      user.LoginId.String(),
+			ReissueCount: claims.ReissueCount,
+		},
+	)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "Could not generate token")
+	}
+
+	result := map[string]interface{}{
+		"isActive": true,
+	}
+
+	if !request.IsMobile {
+		c.updateAuthenticationCookie(ctx, token)
+	} else {
+		result["token"] = token
+	}
+
+	if !c.Configuration.Stripe.IsBillingEnabled() {
+		return ctx.JSON(http.StatusOK, result)
+	}
+
+	subscriptionIsActive, err := c.Billing.GetSubscriptionIsActive(c.getContext(ctx), user.AccountId)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "Failed to determine whether or not subscription is active")
+	}
+
+	result["isActive"] = subscriptionIsActive
+
+	if !subscriptionIsActive {
+		result["nextUrl"] = "/account/subscribe"
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
 func (c *Controller) logoutEndpoint(ctx echo.Context) error {
 	if _, err := ctx.Cookie(c.Configuration.Server.Cookies.Name); err == http.ErrNoCookie {
 		return ctx.NoContent(http.StatusOK)
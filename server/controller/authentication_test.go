@@ -2061,3 +2061,182 @@ func TestResetPassword(t *testing.T) {
 			IsEqual("Failed to validate password reset token")
 	})
 }
+
+func TestSwitchAccount(t *testing.T) {
+	t.Run("switch to another account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		owner, ownerPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		other, otherPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		ownerToken := GivenILogin(t, e, owner.Login.Email, ownerPassword)
+		givenIInviteAnExistingLogin(t, e, ownerToken, other.Login.Email)
+
+		// Logins are signed into the account they own by default.
+		token := GivenILogin(t, e, other.Login.Email, otherPassword)
+
+		{
+			response := e.GET("/api/users/accounts").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+			current := map[string]bool{}
+			for _, item := range response.JSON().Array().Iter() {
+				current[item.Object().Value("accountId").String().Raw()] = item.Object().Value("isCurrent").Boolean().Raw()
+			}
+			assert.Equal(t, map[string]bool{
+				other.AccountId.String(): true,
+				owner.AccountId.String(): false,
+			}, current, "only the account the token is for should be current")
+		}
+
+		var switchedToken string
+		{
+			response := e.POST("/api/authentication/switch").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"accountId": owner.AccountId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.isActive").Boolean().IsTrue()
+			switchedToken = AssertSetTokenCookie(t, response)
+		}
+
+		{
+			response := e.GET("/api/users/me").
+				WithCookie(TestCookieName, switchedToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.user.accountId").IsEqual(owner.AccountId)
+			response.JSON().Path("$.user.loginId").IsEqual(other.LoginId)
+			response.JSON().Path("$.user.role").IsEqual("member")
+		}
+
+		{ // The original token is still scoped to the original account.
+			response := e.GET("/api/users/me").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.user.accountId").IsEqual(other.AccountId)
+		}
+
+		{ // Members cannot do owner things in the account they switched to.
+			response := e.GET("/api/users/invitations").
+				WithCookie(TestCookieName, switchedToken).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+		}
+	})
+
+	t.Run("mobile", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		owner, ownerPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		other, otherPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		ownerToken := GivenILogin(t, e, owner.Login.Email, ownerPassword)
+		givenIInviteAnExistingLogin(t, e, ownerToken, other.Login.Email)
+		token := GivenILogin(t, e, other.Login.Email, otherPassword)
+
+		response := e.POST("/api/authentication/switch").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"accountId": owner.AccountId,
+				"isMobile":  true,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.token").String().NotEmpty()
+		response.Cookies().IsEmpty()
+
+		claims, err := app.Tokens.Parse(response.JSON().Path("$.token").String().Raw())
+		require.NoError(t, err, "must be able to parse the new token")
+		assert.Equal(t, owner.AccountId.String(), claims.AccountId, "token must be for the account that was switched to")
+		assert.Equal(t, other.LoginId.String(), claims.LoginId, "token must still be for the same login")
+		assert.Equal(t, security.AuthenticatedScope, claims.Scope)
+	})
+
+	t.Run("not a member of the account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		other, _ := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/authentication/switch").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"accountId": other.AccountId,
+			}).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").String().IsEqual("You are not a member of that account")
+	})
+
+	t.Run("missing account id", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/authentication/switch").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Account ID must be specified")
+	})
+
+	t.Run("removed from the account after switching", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		owner, ownerPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		other, otherPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		ownerToken := GivenILogin(t, e, owner.Login.Email, ownerPassword)
+		givenIInviteAnExistingLogin(t, e, ownerToken, other.Login.Email)
+		token := GivenILogin(t, e, other.Login.Email, otherPassword)
+
+		response := e.POST("/api/authentication/switch").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"accountId": owner.AccountId,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+		switchedToken := AssertSetTokenCookie(t, response)
+
+		var memberId string
+		{
+			response := e.GET("/api/users/me").
+				WithCookie(TestCookieName, switchedToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			memberId = response.JSON().Path("$.user.userId").String().Raw()
+		}
+
+		e.DELETE("/api/users/{userId}").
+			WithPath("userId", memberId).
+			WithCookie(TestCookieName, ownerToken).
+			Expect().
+			Status(http.StatusNoContent)
+
+		e.GET("/api/links").
+			WithCookie(TestCookieName, switchedToken).
+			Expect().
+			Status(http.StatusUnauthorized)
+
+		{ // The account they were removed from is no longer listed.
+			response := e.GET("/api/users/accounts").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].accountId").IsEqual(other.AccountId)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// getBalanceHistory returns the daily balance snapshots for the bank account.
+// By default the last 90 days are returned.
+func (c *Controller) getBalanceHistory(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	end := util.Midnight(c.Clock.Now(), timezone).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -90)
+	if value := ctx.QueryParam("start_date"); value != "" {
+		start, err = parseTransactionDateParam(value, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid start_date, must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+	}
+
+	if value := ctx.QueryParam("end_date"); value != "" {
+		end, err = parseTransactionDateParam(value, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid end_date, must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		// If just a date was provided then include the snapshot for that date.
+		if _, err := time.Parse(time.DateOnly, value); err == nil {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+
+	if !end.After(start) {
+		return c.badRequest(ctx, "end_date must be after start_date")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	snapshots, err := repo.GetBalanceSnapshots(c.getContext(ctx), bankAccountId, start, end)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve balance history")
+	}
+
+	return ctx.JSON(http.StatusOK, snapshots)
+}
+
+func (c *Controller) getBalanceAlert(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	alert, err := repo.GetBalanceAlert(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve balance alert")
+	}
+
+	return ctx.JSON(http.StatusOK, alert)
+}
+
+// putBalanceAlert creates or replaces the balance alert for the bank account.
+// The alert will be checked the next time the balance alert job runs.
+func (c *Controller) putBalanceAlert(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var request struct {
+		Threshold int64 `json:"threshold"`
+		Days      int   `json:"days"`
+		IsEnabled *bool `json:"isEnabled"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	alert := BalanceAlert{
+		Threshold: request.Threshold,
+		Days:      request.Days,
+		IsEnabled: request.IsEnabled == nil || *request.IsEnabled,
+	}
+	if err := alert.Validate(); err != nil {
+		return c.badRequest(ctx, "Invalid balance alert: %s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	// Make sure the bank account actually exists before creating an alert for it.
+	if _, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve bank account")
+	}
+
+	if err := repo.SaveBalanceAlert(c.getContext(ctx), bankAccountId, &alert); err != nil {
+		return c.wrapPgError(ctx, err, "failed to save balance alert")
+	}
+
+	return ctx.JSON(http.StatusOK, alert)
+}
+
+func (c *Controller) deleteBalanceAlert(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err := repo.DeleteBalanceAlert(c.getContext(ctx), bankAccountId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrBalanceAlertNotFound) {
+			return c.notFound(ctx, "cannot remove balance alert, it does not exist")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to remove balance alert")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,136 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func TestGetBalanceHistory(t *testing.T) {
+	t.Run("no snapshots", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/balances/history").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().IsEmpty()
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/balances/history").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("start_date", "2024-10-01").
+			WithQuery("end_date", "2024-09-01").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("end_date must be after start_date")
+	})
+}
+
+func TestPutBalanceAlert(t *testing.T) {
+	t.Run("create, update and remove", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var alertId string
+		{
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/balances/alert").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"threshold": 50000,
+					"days":      14,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.balanceAlertId").String().NotEmpty()
+			response.JSON().Path("$.isEnabled").Boolean().IsTrue()
+			response.JSON().Path("$.isTriggered").Boolean().IsFalse()
+			alertId = response.JSON().Path("$.balanceAlertId").String().Raw()
+		}
+
+		{ // Updating the alert should keep the same alert.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/balances/alert").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"threshold": 25000,
+					"days":      30,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.balanceAlertId").IsEqual(alertId)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/balances/alert").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.threshold").IsEqual(25000)
+			response.JSON().Path("$.days").IsEqual(30)
+		}
+
+		{
+			response := e.DELETE("/api/bank_accounts/{bankAccountId}/balances/alert").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/balances/alert").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusNotFound)
+		}
+	})
+
+	t.Run("invalid days", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/balances/alert").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"threshold": 50000,
+				"days":      365,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid balance alert: days must be between 1 and 90")
+	})
+}
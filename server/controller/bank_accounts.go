@@ -20,6 +20,18 @@ func (c *Controller) getBankAccounts(ctx echo.Context) error {
 		return c.wrapPgError(ctx, err, "failed to retrieve bank accounts")
 	}
 
+	// If the request is being made with an API key that is restricted to
+	// specific bank accounts, then only return those bank accounts.
+	if claims := c.mustGetClaims(ctx); claims.IsBankAccountRestricted() {
+		allowed := make([]BankAccount, 0, len(bankAccounts))
+		for _, bankAccount := range bankAccounts {
+			if claims.RequireBankAccount(bankAccount.BankAccountId.String()) == nil {
+				allowed = append(allowed, bankAccount)
+			}
+		}
+		bankAccounts = allowed
+	}
+
 	return ctx.JSON(http.StatusOK, bankAccounts)
 }
 
@@ -71,3 +71,33 @@ func (c *Controller) sendPasswordReset(
 
 	return nil
 }
+
+func (c *Controller) sendInvitationEmail(
+	ctx echo.Context,
+	invitedBy *models.Login,
+	invitation models.Invitation,
+	inviteUrl string,
+) error {
+	baseUrl := c.Configuration.Server.GetBaseURL()
+	err := c.Email.SendEmail(
+		c.getContext(ctx),
+		communication.InvitationParams{
+			BaseURL:      baseUrl.String(),
+			Email:        invitation.Email,
+			FirstName:    invitedBy.FirstName,
+			LastName:     invitedBy.LastName,
+			SupportEmail: "support@monetr.app",
+			InviteURL:    inviteUrl,
+		},
+	)
+	if err != nil {
+		return c.wrapAndReturnError(
+			ctx,
+			err,
+			http.StatusInternalServerError,
+			"Failed to send invitation email",
+		)
+	}
+
+	return nil
+}
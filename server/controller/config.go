@@ -28,6 +28,10 @@ func (c *Controller) configEndpoint(ctx echo.Context) error {
 		BillingEnabled       bool         `json:"billingEnabled"`
 		IconsEnabled         bool         `json:"iconsEnabled"`
 		PlaidEnabled         bool         `json:"plaidEnabled"`
+		TellerEnabled        bool         `json:"tellerEnabled"`
+		TellerApplicationId  string       `json:"tellerApplicationId,omitempty"`
+		TellerEnvironment    string       `json:"tellerEnvironment,omitempty"`
+		SimpleFINEnabled     bool         `json:"simpleFINEnabled"`
 		ManualEnabled        bool         `json:"manualEnabled"`
 		UploadsEnabled       bool         `json:"uploadsEnabled"`
 		Release              string       `json:"release"`
@@ -87,6 +91,12 @@ func (c *Controller) configEndpoint(ctx echo.Context) error {
 
 	configuration.IconsEnabled = icons.GetIconsEnabled()
 	configuration.PlaidEnabled = c.Configuration.Plaid.GetEnabled()
+	if c.Configuration.Teller.GetEnabled() {
+		configuration.TellerEnabled = true
+		configuration.TellerApplicationId = c.Configuration.Teller.ApplicationId
+		configuration.TellerEnvironment = string(c.Configuration.Teller.Environment)
+	}
+	configuration.SimpleFINEnabled = c.Configuration.SimpleFIN.GetEnabled()
 	configuration.ManualEnabled = true
 	configuration.UploadsEnabled = c.Configuration.Storage.Enabled
 
@@ -21,8 +21,10 @@ import (
 	"github.com/monetr/monetr/server/repository"
 	"github.com/monetr/monetr/server/secrets"
 	"github.com/monetr/monetr/server/security"
+	"github.com/monetr/monetr/server/simplefin"
 	"github.com/monetr/monetr/server/storage"
 	"github.com/monetr/monetr/server/stripe_helper"
+	"github.com/monetr/monetr/server/teller"
 	"github.com/monetr/monetr/server/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -46,8 +48,10 @@ type Controller struct {
 	PlaidInstitutions        platypus.PlaidInstitutions
 	PlaidWebhookVerification platypus.WebhookVerification
 	PubSub                   pubsub.PublishSubscribe
+	SimpleFIN                simplefin.SimpleFIN
 	Stats                    *metrics.Stats
 	Stripe                   stripe_helper.Stripe
+	Teller                   teller.Teller
 }
 
 func (c *Controller) Close() error {
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"io"
+	"mime/multipart"
 	"net/http"
 	"path"
 	"strings"
@@ -12,7 +14,17 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func (c *Controller) consumeFileUpload(ctx echo.Context, kind Uploadable) (*File, error) {
+// fileUploadValidator is called with the uploaded file before it is stored so
+// that a file the caller cannot use is rejected without being persisted. The
+// returned error is returned to the client as is, so it should be created with
+// one of the controller's error helpers.
+type fileUploadValidator func(contentType storage.ContentType, reader multipart.File, size int64) error
+
+func (c *Controller) consumeFileUpload(
+	ctx echo.Context,
+	kind Uploadable,
+	validate fileUploadValidator,
+) (*File, error) {
 	if !c.Configuration.Storage.Enabled {
 		return nil, c.notFound(ctx, "File uploads are not enabled on this server")
 	}
@@ -42,10 +54,30 @@ func (c *Controller) consumeFileUpload(ctx echo.Context, kind Uploadable) (*File
 		case ".qfx", ".ofx":
 			log.Debug("detected OFX file format")
 			contentType = string(storage.IntuitQFXContentType)
+		case ".csv":
+			log.Debug("detected CSV file format")
+			contentType = string(storage.TextCSVContentType)
+		case ".xlsx":
+			log.Debug("detected Excel file format")
+			contentType = string(storage.OpenXMLExcelContentType)
+		case ".qif":
+			log.Debug("detected QIF file format")
+			contentType = string(storage.QIFContentType)
+		case ".xml":
+			log.Debug("detected XML file format")
+			contentType = string(storage.XMLContentType)
 		default:
 			log.Warn("could not determine file format by file extension")
 		}
 	}
+	// Browsers do not agree on the content type for some of the formats we
+	// accept, so normalize the alternatives we know about.
+	switch strings.ToLower(contentType) {
+	case "text/xml":
+		contentType = string(storage.XMLContentType)
+	case "application/x-qif", "application/vnd.intu.qif":
+		contentType = string(storage.QIFContentType)
+	}
 	valid := storage.GetContentTypeIsValid(contentType)
 	if !valid {
 		crumbs.Debug(c.getContext(ctx),
@@ -59,6 +91,15 @@ func (c *Controller) consumeFileUpload(ctx echo.Context, kind Uploadable) (*File
 		return nil, c.badRequest(ctx, "Unsupported file type!")
 	}
 
+	if validate != nil {
+		if err := validate(storage.ContentType(contentType), reader, header.Size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+			return nil, c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "Failed to read file upload")
+		}
+	}
+
 	fileUri, err := c.FileStorage.Store(
 		c.getContext(ctx),
 		reader,
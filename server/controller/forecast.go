@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"strings"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/monetr/monetr/server/forecast"
 	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
 )
 
 func (c *Controller) getForecast(ctx echo.Context) error {
@@ -212,3 +214,251 @@ func (c *Controller) postForecastNextFunding(ctx echo.Context) error {
 		"nextContribution": result,
 	})
 }
+
+// postForecastScenario forecasts the bank account with hypothetical changes
+// applied to its spending objects and funding schedules, along with any one-off
+// income or expenses. The resulting forecast is compared against the forecast
+// of the bank account as it is today. Nothing is persisted by this endpoint.
+func (c *Controller) postForecastScenario(ctx echo.Context) error {
+	var request struct {
+		End              *time.Time `json:"end"`
+		FundingSchedules struct {
+			Add    []FundingSchedule     `json:"add"`
+			Update []json.RawMessage     `json:"update"`
+			Remove []ID[FundingSchedule] `json:"remove"`
+		} `json:"fundingSchedules"`
+		Spending struct {
+			Add    []Spending        `json:"add"`
+			Update []json.RawMessage `json:"update"`
+			Remove []ID[Spending]    `json:"remove"`
+		} `json:"spending"`
+		Adjustments []forecast.Adjustment `json:"adjustments"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	now := c.Clock.Now()
+	endDate := now.AddDate(0, 0, 90).UTC()
+	if request.End != nil {
+		endDate = *request.End
+	}
+	if endDate.Before(now) {
+		return c.badRequest(ctx, "invalid end time provided, end time must be in the future")
+	}
+	if endDate.After(now.AddDate(0, 12, 0)) {
+		return c.badRequest(ctx, "you are not allowed for forecast more than 12 months into the future")
+	}
+
+	for _, adjustment := range request.Adjustments {
+		if adjustment.Date.IsZero() {
+			return c.badRequest(ctx, "Adjustments must have a date")
+		}
+		if adjustment.Amount == 0 {
+			return c.badRequest(ctx, "Adjustments must have a non-zero amount")
+		}
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	fundingSchedules, err := repo.GetFundingSchedules(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not retrieve funding schedules")
+	}
+
+	spending, err := repo.GetSpending(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not retreive spending")
+	}
+
+	scenario := forecast.Scenario{
+		AddFundingSchedules:    request.FundingSchedules.Add,
+		UpdateFundingSchedules: make([]FundingSchedule, 0, len(request.FundingSchedules.Update)),
+		RemoveFundingSchedules: request.FundingSchedules.Remove,
+		AddSpending:            request.Spending.Add,
+		UpdateSpending:         make([]Spending, 0, len(request.Spending.Update)),
+		RemoveSpending:         request.Spending.Remove,
+		Adjustments:            request.Adjustments,
+	}
+
+	// Updates only need to include the fields that are changing, so they are
+	// decoded on top of a copy of the existing object.
+	for _, patch := range request.FundingSchedules.Update {
+		var target struct {
+			FundingScheduleId ID[FundingSchedule] `json:"fundingScheduleId"`
+		}
+		if err := json.Unmarshal(patch, &target); err != nil {
+			return c.invalidJson(ctx)
+		}
+		item := FundingSchedule{FundingScheduleId: target.FundingScheduleId}
+		for _, existing := range fundingSchedules {
+			if existing.FundingScheduleId == target.FundingScheduleId {
+				item = existing
+				break
+			}
+		}
+		// Don't let the patch modify the rule of the baseline forecast.
+		if item.RuleSet != nil {
+			item.RuleSet = item.RuleSet.Clone()
+		}
+		if err := json.Unmarshal(patch, &item); err != nil {
+			return c.invalidJson(ctx)
+		}
+		item.FundingScheduleId = target.FundingScheduleId
+		item.BankAccountId = bankAccountId
+		scenario.UpdateFundingSchedules = append(scenario.UpdateFundingSchedules, item)
+	}
+
+	for _, patch := range request.Spending.Update {
+		var target struct {
+			SpendingId ID[Spending] `json:"spendingId"`
+		}
+		if err := json.Unmarshal(patch, &target); err != nil {
+			return c.invalidJson(ctx)
+		}
+		item := Spending{SpendingId: target.SpendingId}
+		for _, existing := range spending {
+			if existing.SpendingId == target.SpendingId {
+				item = existing
+				break
+			}
+		}
+		if item.RuleSet != nil {
+			item.RuleSet = item.RuleSet.Clone()
+		}
+		if err := json.Unmarshal(patch, &item); err != nil {
+			return c.invalidJson(ctx)
+		}
+		item.SpendingId = target.SpendingId
+		item.BankAccountId = bankAccountId
+		scenario.UpdateSpending = append(scenario.UpdateSpending, item)
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	scenarioSpending, scenarioFunding, err := scenario.Apply(
+		c.getContext(ctx),
+		now,
+		timezone,
+		spending,
+		fundingSchedules,
+	)
+	if err != nil {
+		return c.badRequest(ctx, "Invalid scenario: %s", err.Error())
+	}
+
+	log := c.getLog(ctx)
+	timeout, cancel := context.WithTimeout(c.getContext(ctx), 25*time.Second)
+	defer cancel()
+
+	baseline, err := forecast.NewForecaster(
+		log,
+		spending,
+		fundingSchedules,
+	).GetForecast(timeout, now, endDate, timezone)
+	if err == context.DeadlineExceeded {
+		return c.returnError(ctx, http.StatusRequestTimeout, "timeout forecasting")
+	} else if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to forecast")
+	}
+
+	result, err := forecast.NewForecasterWithAdjustments(
+		log,
+		scenarioSpending,
+		scenarioFunding,
+		request.Adjustments,
+	).GetForecast(timeout, now, endDate, timezone)
+	if err == context.DeadlineExceeded {
+		return c.returnError(ctx, http.StatusRequestTimeout, "timeout forecasting")
+	} else if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to forecast")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"forecast":              result,
+		"baselineEndingBalance": baseline.EndingBalance,
+		"endingBalance":         result.EndingBalance,
+		"difference":            result.EndingBalance - baseline.EndingBalance,
+	})
+}
+
+// postForecastGoal plans the contributions towards a goal. Either a fixed
+// contribution amount is provided and the date the goal will be fully funded is
+// returned, or a target date is provided and the contribution needed to reach
+// it is returned. Either way the plan includes whether or not the goal can be
+// funded before its next recurrence using the free-to-use balance.
+func (c *Controller) postForecastGoal(ctx echo.Context) error {
+	var request struct {
+		SpendingId         ID[Spending] `json:"spendingId"`
+		ContributionAmount *int64       `json:"contributionAmount"`
+		TargetDate         *time.Time   `json:"targetDate"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.SpendingId.IsZero() {
+		return c.badRequest(ctx, "Spending object must be specified")
+	}
+	if (request.ContributionAmount == nil) == (request.TargetDate == nil) {
+		return c.badRequest(ctx, "Either a contribution amount or a target date must be specified")
+	}
+
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	goal, err := repo.GetSpendingById(c.getContext(ctx), bankAccountId, request.SpendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not retrieve spending object")
+	}
+	if goal.SpendingType != SpendingTypeGoal {
+		return c.badRequest(ctx, "Spending object must be a goal")
+	}
+
+	fundingSchedule, err := repo.GetFundingSchedule(c.getContext(ctx), bankAccountId, goal.FundingScheduleId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not retrieve funding schedule")
+	}
+
+	balances, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not retrieve balances")
+	}
+
+	planner := forecast.NewGoalPlanner(
+		c.getLog(ctx),
+		*goal,
+		*fundingSchedule,
+		balances.Free,
+	)
+	timezone := c.mustGetTimezone(ctx)
+	timeout, cancel := context.WithTimeout(c.getContext(ctx), 25*time.Second)
+	defer cancel()
+
+	var plan *forecast.GoalPlan
+	if request.ContributionAmount != nil {
+		plan, err = planner.PlanByContribution(timeout, *request.ContributionAmount, c.Clock.Now(), timezone)
+	} else {
+		plan, err = planner.PlanByTargetDate(timeout, *request.TargetDate, c.Clock.Now(), timezone)
+	}
+	switch errors.Cause(err) {
+	case nil:
+		return ctx.JSON(http.StatusOK, plan)
+	case forecast.ErrInvalidContribution, forecast.ErrTargetDateInThePast, forecast.ErrGoalPlanTooLong:
+		return c.badRequest(ctx, "Invalid goal plan: %s", errors.Cause(err).Error())
+	case forecast.ErrFundingScheduleEnded:
+		return c.badRequest(ctx, "The funding schedule for this goal ends before the goal can be fully funded, try a larger contribution or a target date instead")
+	case context.DeadlineExceeded:
+		return c.returnError(ctx, http.StatusRequestTimeout, "timeout forecasting")
+	default:
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to plan goal")
+	}
+}
@@ -0,0 +1,67 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+)
+
+func TestPostForecastGoal(t *testing.T) {
+	t.Run("finite funding schedule", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+		// Only three funding events remain; the 15th of October, November and
+		// December.
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15;COUNT=3", false)
+		dueDate := time.Date(2024, 3, 1, 0, 0, 0, 0, timezone)
+		goal := testutils.MustInsert(t, models.Spending{
+			AccountId:         bank.AccountId,
+			BankAccountId:     bank.BankAccountId,
+			FundingScheduleId: fundingSchedule.FundingScheduleId,
+			SpendingType:      models.SpendingTypeGoal,
+			Name:              "Vacation",
+			TargetAmount:      90000,
+			NextRecurrence:    dueDate,
+			CreatedAt:         app.Clock.Now(),
+		})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Planning by target date uses the remaining occurrences
+			response := e.POST("/api/bank_accounts/{bankAccountId}/forecast/goal").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"spendingId": goal.SpendingId,
+					"targetDate": dueDate,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.numberOfContributions").Number().IsEqual(3)
+			response.JSON().Path("$.contributionAmount").Number().IsEqual(30000)
+			response.JSON().Path("$.targetDate").String().AsDateTime(time.RFC3339).IsEqual(time.Date(2023, 12, 15, 0, 0, 0, 0, timezone))
+			response.JSON().Path("$.isFeasible").Boolean().IsTrue()
+		}
+
+		{ // A contribution that needs more occurrences than are left
+			response := e.POST("/api/bank_accounts/{bankAccountId}/forecast/goal").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"spendingId":         goal.SpendingId,
+					"contributionAmount": 10000,
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("The funding schedule for this goal ends before the goal can be fully funded, try a larger contribution or a target date instead")
+		}
+	})
+}
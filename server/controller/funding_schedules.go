@@ -63,7 +63,11 @@ func (c *Controller) postFundingSchedules(ctx echo.Context) error {
 		return c.badRequest(ctx, "must specify a valid bank account Id")
 	}
 
-	var fundingSchedule FundingSchedule
+	// Fields that are omitted from the request are left untouched when the body
+	// is decoded, so the tolerance will only be the default when it is unset.
+	fundingSchedule := FundingSchedule{
+		DepositToleranceDays: DefaultDepositToleranceDays,
+	}
 	if err := ctx.Bind(&fundingSchedule); err != nil {
 		return c.invalidJson(ctx)
 	}
@@ -83,6 +87,10 @@ func (c *Controller) postFundingSchedules(ctx echo.Context) error {
 		return c.badRequest(ctx, "funding schedule must have a name")
 	}
 
+	if err := fundingSchedule.ValidateDepositMatcher(); err != nil {
+		return c.badRequest(ctx, "Invalid deposit matcher: %s", err.Error())
+	}
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	// Set the next occurrence based on the provided rule.
@@ -104,6 +112,7 @@ func (c *Controller) postFundingSchedules(ctx echo.Context) error {
 
 	// It has never occurred so this needs to be nil.
 	fundingSchedule.LastRecurrence = nil
+	fundingSchedule.LastDepositTransactionId = nil
 
 	if err = repo.CreateFundingSchedule(c.getContext(ctx), &fundingSchedule); err != nil {
 		return c.wrapPgError(ctx, err, "failed to create funding schedule")
@@ -147,6 +156,7 @@ func (c *Controller) putFundingSchedules(ctx echo.Context) error {
 	request.BankAccountId = bankAccountId
 	request.AccountId = existingFundingSchedule.AccountId
 	request.LastRecurrence = existingFundingSchedule.LastRecurrence
+	request.LastDepositTransactionId = existingFundingSchedule.LastDepositTransactionId
 
 	if request.Name == "" {
 		return c.badRequest(ctx, "funding schedule must have a name")
@@ -160,6 +170,10 @@ func (c *Controller) putFundingSchedules(ctx echo.Context) error {
 		return c.badRequest(ctx, "estimated deposit must be greater than or equal to zero")
 	}
 
+	if err := request.ValidateDepositMatcher(); err != nil {
+		return c.badRequest(ctx, "Invalid deposit matcher: %s", err.Error())
+	}
+
 	recalculateSpending := false
 	// If the next occurrence changes then we need to recalulate spending.
 	if !request.NextRecurrence.Equal(existingFundingSchedule.NextRecurrence) {
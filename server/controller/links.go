@@ -218,6 +218,34 @@ func (c *Controller) deleteLink(ctx echo.Context) error {
 		}
 	}
 
+	if link.TellerLink != nil && !link.TellerLink.SecretId.IsZero() {
+		secret, err := secretsRepo.Read(c.getContext(ctx), link.TellerLink.SecretId)
+		if err != nil {
+			crumbs.Error(
+				c.getContext(ctx),
+				"Failed to retrieve access token for teller link.", "secrets", map[string]interface{}{
+					"linkId":       link.LinkId,
+					"enrollmentId": link.TellerLink.EnrollmentId,
+				},
+			)
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve access token for removal")
+		}
+
+		client, err := c.Teller.NewClient(c.getContext(ctx), link, secret.Value)
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create teller client")
+		}
+
+		if err = client.RemoveEnrollment(c.getContext(ctx)); err != nil {
+			crumbs.Error(c.getContext(ctx), "Failed to remove enrollment", "teller", map[string]interface{}{
+				"linkId":       link.LinkId,
+				"enrollmentId": link.TellerLink.EnrollmentId,
+				"error":        err.Error(),
+			})
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to remove enrollment from Teller")
+		}
+	}
+
 	if err = background.TriggerRemoveLink(c.getContext(ctx), c.JobRunner, background.RemoveLinkArguments{
 		AccountId: link.AccountId,
 		LinkId:    link.LinkId,
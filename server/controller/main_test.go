@@ -28,13 +28,16 @@ import (
 	"github.com/monetr/monetr/server/controller"
 	"github.com/monetr/monetr/server/internal/mockgen"
 	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/middleware"
 	"github.com/monetr/monetr/server/platypus"
 	"github.com/monetr/monetr/server/pubsub"
 	"github.com/monetr/monetr/server/repository"
 	"github.com/monetr/monetr/server/secrets"
 	"github.com/monetr/monetr/server/security"
+	"github.com/monetr/monetr/server/simplefin"
 	"github.com/monetr/monetr/server/storage"
 	"github.com/monetr/monetr/server/stripe_helper"
+	"github.com/monetr/monetr/server/teller"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -119,6 +122,11 @@ func NewTestApplicationPatched(t *testing.T, configuration config.Configuration,
 		1*time.Hour,
 	)
 
+	tellerClient, err := teller.NewTeller(log, clock, kms, db, configuration.Teller)
+	require.NoError(t, err, "must be able to create the teller client")
+
+	simpleFINClient := simplefin.NewSimpleFIN(log, configuration.SimpleFIN)
+
 	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
 	require.NoError(t, err, "must be able to generate keys")
 
@@ -209,11 +217,14 @@ func NewTestApplicationPatched(t *testing.T, configuration config.Configuration,
 		PlaidInstitutions:        plaidInstitutions,
 		PlaidWebhookVerification: plaidWebhooks,
 		PubSub:                   pubSub,
+		SimpleFIN:                simpleFINClient,
 		Stats:                    nil,
 		Stripe:                   stripeHelper,
+		Teller:                   tellerClient,
 	}
 
 	app := application.NewApp(configuration, c)
+	app.Use(middleware.APIKeyAuthentication(repository.NewAPIKeyRepository(db), db))
 
 	// run server using httptest
 	server := httptest.NewServer(app)
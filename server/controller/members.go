@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/security"
+	"github.com/pkg/errors"
+)
+
+// invitationLifetime is how long someone has to accept an invitation to join
+// an account before the owner needs to invite them again.
+const invitationLifetime = 7 * 24 * time.Hour
+
+// getMembers returns every user that is a member of the current account,
+// including the owner.
+func (c *Controller) getMembers(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	users, err := repo.GetUsers(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve members")
+	}
+
+	return ctx.JSON(http.StatusOK, users)
+}
+
+// deleteMember removes a member from the current account, they will no longer
+// be able to access the account. The owner of the account cannot be removed.
+func (c *Controller) deleteMember(ctx echo.Context) error {
+	userId, err := ParseID[User](ctx.Param("userId"))
+	if err != nil || userId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid user Id")
+	}
+
+	if userId == c.mustGetUserId(ctx) {
+		return c.badRequest(ctx, "cannot remove yourself from the account")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err := repo.RemoveUser(c.getContext(ctx), userId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrUserNotFound) {
+			return c.notFound(ctx, "cannot remove member, they are not a member of this account")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to remove member")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *Controller) getInvitations(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	invitations, err := repo.GetInvitations(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve invitations")
+	}
+
+	return ctx.JSON(http.StatusOK, invitations)
+}
+
+// postInvitation invites someone to join the current account as a member. The
+// invitation is emailed to them, if email is not enabled on this server then
+// the link to accept the invitation is returned instead so that the owner can
+// share it with them directly.
+func (c *Controller) postInvitation(ctx echo.Context) error {
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	invitation := Invitation{
+		Email:     strings.ToLower(strings.TrimSpace(request.Email)),
+		Role:      UserRoleMember,
+		ExpiresAt: c.Clock.Now().Add(invitationLifetime).UTC(),
+	}
+	if err := invitation.Validate(); err != nil {
+		return c.badRequest(ctx, "Invalid invitation: %s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	me, err := repo.GetMe(c.getContext(ctx))
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to retrieve current user details")
+	}
+
+	members, err := repo.GetUsers(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve members")
+	}
+	for _, member := range members {
+		if member.Login != nil && strings.EqualFold(member.Login.Email, invitation.Email) {
+			return c.badRequest(ctx, "Invalid invitation: %s is already a member of this account", invitation.Email)
+		}
+	}
+
+	if err := repo.CreateInvitation(c.getContext(ctx), &invitation); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create invitation")
+	}
+
+	token, err := c.ClientTokens.Create(
+		invitationLifetime,
+		security.Claims{
+			Scope:        security.InviteScope,
+			EmailAddress: invitation.Email,
+			UserId:       me.UserId.String(),
+			AccountId:    me.AccountId.String(),
+			LoginId:      "",
+			InvitationId: invitation.InvitationId.String(),
+		},
+	)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "could not generate invitation token")
+	}
+
+	inviteUrl := c.Configuration.Server.GetURL("/invitation", map[string]string{
+		"token": token,
+	})
+
+	if !c.Configuration.Email.Enabled {
+		return ctx.JSON(http.StatusOK, struct {
+			Invitation
+			InviteURL string `json:"inviteUrl"`
+		}{
+			Invitation: invitation,
+			InviteURL:  inviteUrl,
+		})
+	}
+
+	if err := c.sendInvitationEmail(ctx, me.Login, invitation, inviteUrl); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, invitation)
+}
+
+func (c *Controller) deleteInvitation(ctx echo.Context) error {
+	invitationId, err := ParseID[Invitation](ctx.Param("invitationId"))
+	if err != nil || invitationId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid invitation Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err := repo.DeleteInvitation(c.getContext(ctx), invitationId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrInvitationNotFound) {
+			return c.notFound(ctx, "cannot remove invitation, it does not exist")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to remove invitation")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// postAcceptInvitation adds the person who was invited to the account from
+// their invitation. If they do not have a login yet then one is created for
+// them, in which case a password and their name are required. Either way they
+// will need to sign in afterwards.
+func (c *Controller) postAcceptInvitation(ctx echo.Context) error {
+	var request struct {
+		Token     string `json:"token"`
+		Password  string `json:"password"`
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	request.Token = strings.TrimSpace(request.Token)
+	request.Password = strings.TrimSpace(request.Password)
+	request.FirstName = strings.TrimSpace(request.FirstName)
+	request.LastName = strings.TrimSpace(request.LastName)
+
+	if request.Token == "" {
+		return c.badRequest(ctx, "Token cannot be blank")
+	}
+
+	claims, err := c.ClientTokens.Parse(request.Token)
+	if err != nil {
+		return c.badRequestError(ctx, err, "Invalid invitation")
+	}
+
+	if err := claims.RequireScope(security.InviteScope); err != nil {
+		return c.badRequestError(ctx, err, "Invalid invitation")
+	}
+
+	accountId, err := ParseID[Account](claims.AccountId)
+	if err != nil {
+		return c.badRequestError(ctx, err, "Invalid invitation")
+	}
+	invitationId, err := ParseID[Invitation](claims.InvitationId)
+	if err != nil {
+		return c.badRequestError(ctx, err, "Invalid invitation")
+	}
+
+	repo := c.mustGetUnauthenticatedRepository(ctx)
+	invitation, err := repo.GetInvitation(c.getContext(ctx), accountId, invitationId)
+	if err != nil {
+		return c.badRequestError(ctx, err, "Invitation is no longer valid")
+	}
+
+	// Invitations can only be used once, and only by the email address that they
+	// were sent to.
+	if !strings.EqualFold(invitation.Email, claims.EmailAddress) || !invitation.IsPending(c.Clock.Now()) {
+		return c.badRequest(ctx, "Invitation is no longer valid")
+	}
+
+	login, err := repo.GetLoginForEmail(c.getContext(ctx), invitation.Email)
+	switch errors.Cause(err) {
+	case nil:
+	case pg.ErrNoRows:
+		if len(request.Password) > 71 {
+			return c.badRequest(ctx, "Password must be less than 72 characters")
+		}
+		if err := c.validateRegistration(
+			ctx,
+			invitation.Email,
+			request.Password,
+			request.FirstName,
+		); err != nil {
+			return err // validateRegistration also returns a valid http error that can just be passed through.
+		}
+
+		login, err = repo.CreateLogin(
+			c.getContext(ctx),
+			invitation.Email,
+			request.Password,
+			request.FirstName,
+			request.LastName,
+		)
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create login")
+		}
+	default:
+		return c.wrapPgError(ctx, err, "failed to retrieve login for invitation")
+	}
+
+	// The invitation was sent to this email address, so accepting it verifies
+	// the email address as well.
+	if !login.IsEmailVerified {
+		if err := repo.SetEmailVerified(c.getContext(ctx), login.Email); err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify email")
+		}
+	}
+
+	if _, err := repo.AcceptInvitation(c.getContext(ctx), invitation, login.LoginId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrAlreadyMember) {
+			return c.badRequest(ctx, "You are already a member of this account")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to accept invitation")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"nextUrl": "/login",
+		"message": "You have joined the account. Please login.",
+	})
+}
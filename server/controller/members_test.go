@@ -0,0 +1,335 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+// givenIInviteAMember invites a new login to the owner's account and accepts
+// the invitation, it returns the credentials of the new member.
+func givenIInviteAMember(t *testing.T, e *httpexpect.Expect, ownerToken string) (email, password string) {
+	email = testutils.GetUniqueEmail(t)
+	password = gofakeit.Password(true, true, true, true, false, 32)
+
+	response := e.POST("/api/users/invitations").
+		WithCookie(TestCookieName, ownerToken).
+		WithJSON(map[string]interface{}{
+			"email": email,
+		}).
+		Expect()
+	response.Status(http.StatusOK)
+	response.JSON().Path("$.invitationId").String().NotEmpty()
+	response.JSON().Path("$.role").IsEqual("member")
+
+	// Email is not enabled for tests, so the invitation link is returned.
+	inviteUrl, err := url.Parse(response.JSON().Path("$.inviteUrl").String().Raw())
+	require.NoError(t, err, "must be able to parse the invitation url")
+	inviteToken := inviteUrl.Query().Get("token")
+	require.NotEmpty(t, inviteToken, "invitation url must include a token")
+
+	e.POST("/api/authentication/invitation").
+		WithJSON(map[string]interface{}{
+			"token":     inviteToken,
+			"password":  password,
+			"firstName": gofakeit.FirstName(),
+			"lastName":  gofakeit.LastName(),
+		}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Path("$.nextUrl").IsEqual("/login")
+
+	return email, password
+}
+
+// givenIInviteAnExistingLogin invites a login that already exists to the
+// owner's account and accepts the invitation on their behalf.
+func givenIInviteAnExistingLogin(t *testing.T, e *httpexpect.Expect, ownerToken, email string) {
+	response := e.POST("/api/users/invitations").
+		WithCookie(TestCookieName, ownerToken).
+		WithJSON(map[string]interface{}{
+			"email": email,
+		}).
+		Expect()
+	response.Status(http.StatusOK)
+
+	inviteUrl, err := url.Parse(response.JSON().Path("$.inviteUrl").String().Raw())
+	require.NoError(t, err, "must be able to parse the invitation url")
+
+	e.POST("/api/authentication/invitation").
+		WithJSON(map[string]interface{}{
+			"token": inviteUrl.Query().Get("token"),
+		}).
+		Expect().
+		Status(http.StatusOK)
+}
+
+func TestPostInvitation(t *testing.T) {
+	t.Run("invite and accept a new member", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		ownerToken := GivenILogin(t, e, user.Login.Email, password)
+
+		memberEmail, memberPassword := givenIInviteAMember(t, e, ownerToken)
+		memberToken := GivenILogin(t, e, memberEmail, memberPassword)
+
+		{ // The member is signed into the owner's account.
+			response := e.GET("/api/users/me").
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.user.accountId").IsEqual(user.AccountId)
+			response.JSON().Path("$.user.role").IsEqual("member")
+		}
+
+		{
+			response := e.GET("/api/users").
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+		}
+
+		{ // The invitation has been used and is no longer pending.
+			response := e.GET("/api/users/invitations").
+				WithCookie(TestCookieName, ownerToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/users/invitations").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"email": "not an email",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid invitation: email must be a valid email address")
+	})
+
+	t.Run("cannot invite an existing member", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/users/invitations").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"email": user.Login.Email,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().HasSuffix("is already a member of this account")
+	})
+
+	t.Run("existing login keeps their own account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		owner, ownerPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		other, otherPassword := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		ownerToken := GivenILogin(t, e, owner.Login.Email, ownerPassword)
+
+		response := e.POST("/api/users/invitations").
+			WithCookie(TestCookieName, ownerToken).
+			WithJSON(map[string]interface{}{
+				"email": other.Login.Email,
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+		inviteUrl, err := url.Parse(response.JSON().Path("$.inviteUrl").String().Raw())
+		require.NoError(t, err, "must be able to parse the invitation url")
+
+		// A login that already exists does not need to provide a password.
+		e.POST("/api/authentication/invitation").
+			WithJSON(map[string]interface{}{
+				"token": inviteUrl.Query().Get("token"),
+			}).
+			Expect().
+			Status(http.StatusOK)
+
+		{ // Accepting the same invitation twice is not allowed.
+			response := e.POST("/api/authentication/invitation").
+				WithJSON(map[string]interface{}{
+					"token": inviteUrl.Query().Get("token"),
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Invitation is no longer valid")
+		}
+
+		otherToken := GivenILogin(t, e, other.Login.Email, otherPassword)
+		{
+			response := e.GET("/api/users/me").
+				WithCookie(TestCookieName, otherToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.user.accountId").IsEqual(other.AccountId)
+			response.JSON().Path("$.user.role").IsEqual("owner")
+		}
+	})
+
+	t.Run("revoked invitation cannot be accepted", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/users/invitations").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"email": testutils.GetUniqueEmail(t),
+			}).
+			Expect()
+		response.Status(http.StatusOK)
+		invitationId := response.JSON().Path("$.invitationId").String().Raw()
+		inviteUrl, err := url.Parse(response.JSON().Path("$.inviteUrl").String().Raw())
+		require.NoError(t, err, "must be able to parse the invitation url")
+
+		e.DELETE("/api/users/invitations/{invitationId}").
+			WithPath("invitationId", invitationId).
+			WithCookie(TestCookieName, token).
+			Expect().
+			Status(http.StatusNoContent)
+
+		{
+			response := e.POST("/api/authentication/invitation").
+				WithJSON(map[string]interface{}{
+					"token":     inviteUrl.Query().Get("token"),
+					"password":  gofakeit.Password(true, true, true, true, false, 32),
+					"firstName": gofakeit.FirstName(),
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Invitation is no longer valid")
+		}
+	})
+}
+
+func TestMemberRestrictions(t *testing.T) {
+	t.Run("members cannot manage billing, links or members", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		ownerToken := GivenILogin(t, e, user.Login.Email, password)
+
+		memberEmail, memberPassword := givenIInviteAMember(t, e, ownerToken)
+		memberToken := GivenILogin(t, e, memberEmail, memberPassword)
+
+		{
+			response := e.GET("/api/billing/portal").
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().IsEqual("Only the owner of the account can do this")
+		}
+
+		{
+			response := e.DELETE("/api/links/{linkId}").
+				WithPath("linkId", link.LinkId).
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+			response.JSON().Path("$.error").String().IsEqual("Only the owner of the account can do this")
+		}
+
+		{
+			response := e.POST("/api/users/invitations").
+				WithCookie(TestCookieName, memberToken).
+				WithJSON(map[string]interface{}{
+					"email": testutils.GetUniqueEmail(t),
+				}).
+				Expect()
+
+			response.Status(http.StatusForbidden)
+		}
+
+		{ // Members can still use the rest of the account.
+			response := e.GET("/api/links").
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+		}
+	})
+}
+
+func TestDeleteMember(t *testing.T) {
+	t.Run("removed member loses access", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		ownerToken := GivenILogin(t, e, user.Login.Email, password)
+
+		memberEmail, memberPassword := givenIInviteAMember(t, e, ownerToken)
+		memberToken := GivenILogin(t, e, memberEmail, memberPassword)
+
+		var memberId string
+		{
+			response := e.GET("/api/users/me").
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			memberId = response.JSON().Path("$.user.userId").String().Raw()
+		}
+
+		e.DELETE("/api/users/{userId}").
+			WithPath("userId", memberId).
+			WithCookie(TestCookieName, ownerToken).
+			Expect().
+			Status(http.StatusNoContent)
+
+		{ // The member's existing token no longer works.
+			response := e.GET("/api/links").
+				WithCookie(TestCookieName, memberToken).
+				Expect()
+
+			response.Status(http.StatusUnauthorized)
+		}
+
+		{
+			response := e.GET("/api/users").
+				WithCookie(TestCookieName, ownerToken).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].userId").IsEqual(user.UserId)
+		}
+	})
+
+	t.Run("cannot remove the owner", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.DELETE("/api/users/{userId}").
+			WithPath("userId", user.UserId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("cannot remove yourself from the account")
+	})
+}
@@ -0,0 +1,442 @@
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+// Merchants are the transaction clusters that are calculated in the
+// background for each bank account. Because the clusters are regenerated
+// whenever new transactions come in, any changes made here are also stored as
+// overrides so that they are applied again the next time the clusters are
+// calculated.
+
+type merchant struct {
+	TransactionCluster
+	// TransactionCount and TotalAmount only include transactions from the months
+	// that were requested.
+	TransactionCount int64                                `json:"transactionCount"`
+	TotalAmount      int64                                `json:"totalAmount"`
+	Months           []repository.TransactionClusterMonth `json:"months"`
+}
+
+func (c *Controller) getMerchants(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	months := urlParamIntDefault(ctx, "months", 12)
+	if months < 1 {
+		return c.badRequest(ctx, "months must be at least 1")
+	} else if months > 60 {
+		return c.badRequest(ctx, "months cannot be greater than 60")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	timezone := c.mustGetTimezone(ctx)
+
+	clusters, err := repo.GetTransactionClusters(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve merchants")
+	}
+
+	// Include the entire current month as well as the previous months that were
+	// requested.
+	now := c.Clock.Now().In(timezone)
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, timezone).AddDate(0, -(months - 1), 0)
+	totals, err := repo.GetTransactionClusterMonths(c.getContext(ctx), bankAccountId, timezone, since)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve merchant totals")
+	}
+
+	byCluster := map[ID[TransactionCluster]][]repository.TransactionClusterMonth{}
+	for _, total := range totals {
+		byCluster[total.TransactionClusterId] = append(byCluster[total.TransactionClusterId], total)
+	}
+
+	result := make([]merchant, len(clusters))
+	for i, cluster := range clusters {
+		item := merchant{
+			TransactionCluster: cluster,
+			Months:             byCluster[cluster.TransactionClusterId],
+		}
+		if item.Months == nil {
+			item.Months = []repository.TransactionClusterMonth{}
+		}
+		for _, month := range item.Months {
+			item.TransactionCount += month.Count
+			item.TotalAmount += month.Amount
+		}
+		result[i] = item
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalAmount > result[j].TotalAmount
+	})
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (c *Controller) putMerchant(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionClusterId, err := ParseID[TransactionCluster](ctx.Param("transactionClusterId"))
+	if err != nil || transactionClusterId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid merchant Id")
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	request.Name, err = c.cleanString(ctx, "Name", request.Name)
+	if err != nil {
+		return err
+	}
+	if request.Name == "" {
+		return c.badRequest(ctx, "merchant must have a name")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	cluster, err := repo.GetTransactionClusterById(c.getContext(ctx), bankAccountId, transactionClusterId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve merchant")
+	}
+
+	override, err := c.getTransactionClusterOverride(ctx, repo, bankAccountId, cluster.Signature)
+	if err != nil {
+		return err
+	}
+	override.Name = &request.Name
+	if err := repo.SaveTransactionClusterOverride(c.getContext(ctx), bankAccountId, override); err != nil {
+		return c.wrapPgError(ctx, err, "failed to save merchant name")
+	}
+
+	cluster.Name = request.Name
+	if err := repo.UpdateTransactionCluster(c.getContext(ctx), bankAccountId, cluster); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update merchant")
+	}
+
+	return ctx.JSON(http.StatusOK, cluster)
+}
+
+// postMerchantMerge will move all of the transactions from the specified
+// merchants into the merchant in the path. The other merchants are removed.
+func (c *Controller) postMerchantMerge(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionClusterId, err := ParseID[TransactionCluster](ctx.Param("transactionClusterId"))
+	if err != nil || transactionClusterId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid merchant Id")
+	}
+
+	var request struct {
+		TransactionClusterIds []ID[TransactionCluster] `json:"transactionClusterIds"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if len(request.TransactionClusterIds) == 0 {
+		return c.badRequest(ctx, "must specify at least one merchant to merge")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	target, err := repo.GetTransactionClusterById(c.getContext(ctx), bankAccountId, transactionClusterId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve merchant")
+	}
+
+	seen := map[ID[TransactionCluster]]struct{}{}
+	for _, sourceId := range request.TransactionClusterIds {
+		if sourceId == target.TransactionClusterId {
+			return c.badRequest(ctx, "cannot merge a merchant into itself")
+		}
+		if _, ok := seen[sourceId]; ok {
+			continue
+		}
+		seen[sourceId] = struct{}{}
+
+		source, err := repo.GetTransactionClusterById(c.getContext(ctx), bankAccountId, sourceId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve merchant to merge")
+		}
+
+		override, err := c.getTransactionClusterOverride(ctx, repo, bankAccountId, source.Signature)
+		if err != nil {
+			return err
+		}
+		override.MergedInto = myownsanity.StringP(target.Signature)
+		if err := repo.SaveTransactionClusterOverride(c.getContext(ctx), bankAccountId, override); err != nil {
+			return c.wrapPgError(ctx, err, "failed to save merchant merge")
+		}
+
+		target.Members = append(target.Members, source.Members...)
+		if err := repo.DeleteTransactionCluster(c.getContext(ctx), bankAccountId, source.TransactionClusterId); err != nil {
+			return c.wrapPgError(ctx, err, "failed to remove merged merchant")
+		}
+	}
+
+	if err := repo.UpdateTransactionCluster(c.getContext(ctx), bankAccountId, target); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update merchant")
+	}
+
+	return ctx.JSON(http.StatusOK, target)
+}
+
+// postMerchantSplit will move the specified transactions out of the merchant
+// in the path and into a new merchant of their own.
+func (c *Controller) postMerchantSplit(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionClusterId, err := ParseID[TransactionCluster](ctx.Param("transactionClusterId"))
+	if err != nil || transactionClusterId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid merchant Id")
+	}
+
+	var request struct {
+		Name           string            `json:"name"`
+		TransactionIds []ID[Transaction] `json:"transactionIds"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	request.Name, err = c.cleanString(ctx, "Name", request.Name)
+	if err != nil {
+		return err
+	}
+	if request.Name == "" {
+		return c.badRequest(ctx, "merchant must have a name")
+	}
+
+	if len(request.TransactionIds) == 0 {
+		return c.badRequest(ctx, "must specify at least one transaction to split")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	cluster, err := repo.GetTransactionClusterById(c.getContext(ctx), bankAccountId, transactionClusterId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve merchant")
+	}
+
+	existing := make(map[ID[Transaction]]struct{}, len(cluster.Members))
+	for _, member := range cluster.Members {
+		existing[member] = struct{}{}
+	}
+
+	split := map[ID[Transaction]]struct{}{}
+	for _, transactionId := range request.TransactionIds {
+		if _, ok := existing[transactionId]; !ok {
+			return c.badRequest(ctx, "Transaction is not part of this merchant: %s", transactionId)
+		}
+		split[transactionId] = struct{}{}
+	}
+
+	if len(split) == len(cluster.Members) {
+		return c.badRequest(ctx, "cannot split every transaction out of a merchant")
+	}
+
+	remaining := make([]ID[Transaction], 0, len(cluster.Members)-len(split))
+	members := make([]ID[Transaction], 0, len(split))
+	for _, member := range cluster.Members {
+		if _, ok := split[member]; ok {
+			members = append(members, member)
+		} else {
+			remaining = append(remaining, member)
+		}
+	}
+
+	// If the merchant was itself split out of another merchant, then the
+	// transactions need to be removed from its override as well.
+	override, err := c.getTransactionClusterOverride(ctx, repo, bankAccountId, cluster.Signature)
+	if err != nil {
+		return err
+	}
+	if override.IsSplit() {
+		override.Members = remaining
+		if err := repo.SaveTransactionClusterOverride(c.getContext(ctx), bankAccountId, override); err != nil {
+			return c.wrapPgError(ctx, err, "failed to update merchant split")
+		}
+	}
+
+	cluster.Members = remaining
+	if err := repo.UpdateTransactionCluster(c.getContext(ctx), bankAccountId, cluster); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update merchant")
+	}
+
+	// Merchants that are created by splitting don't have a generated signature,
+	// so their ID is used as their signature instead.
+	newCluster := TransactionCluster{
+		Name:    request.Name,
+		Members: members,
+	}
+	newCluster.TransactionClusterId = NewID(&newCluster)
+	newCluster.Signature = newCluster.TransactionClusterId.String()
+	if err := repo.CreateTransactionCluster(c.getContext(ctx), bankAccountId, &newCluster); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create merchant")
+	}
+
+	if err := repo.SaveTransactionClusterOverride(c.getContext(ctx), bankAccountId, &TransactionClusterOverride{
+		Signature: newCluster.Signature,
+		Name:      &newCluster.Name,
+		Members:   members,
+	}); err != nil {
+		return c.wrapPgError(ctx, err, "failed to save merchant split")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"original": cluster,
+		"split":    newCluster,
+	})
+}
+
+// postMerchantSpending will spend every transaction of the merchant from the
+// specified spending object. Deposits and split transactions are skipped, as
+// are transactions that are already spent from another spending object unless
+// overwrite is specified.
+func (c *Controller) postMerchantSpending(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionClusterId, err := ParseID[TransactionCluster](ctx.Param("transactionClusterId"))
+	if err != nil || transactionClusterId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid merchant Id")
+	}
+
+	var request struct {
+		SpendingId ID[Spending] `json:"spendingId"`
+		Overwrite  bool         `json:"overwrite"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.SpendingId.IsZero() {
+		return c.badRequest(ctx, "must specify a spending object")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	exists, err := repo.GetSpendingExists(c.getContext(ctx), bankAccountId, request.SpendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to verify spending object")
+	}
+	if !exists {
+		return c.badRequest(ctx, "Spending object does not exist: %s", request.SpendingId)
+	}
+
+	cluster, err := repo.GetTransactionClusterById(c.getContext(ctx), bankAccountId, transactionClusterId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve merchant")
+	}
+
+	updated := make([]Transaction, 0, len(cluster.Members))
+	skipped := 0
+	for _, transactionId := range cluster.Members {
+		existing, err := repo.GetTransaction(c.getContext(ctx), bankAccountId, transactionId)
+		if errors.Is(errors.Cause(err), pg.ErrNoRows) {
+			// Clusters can be slightly out of date, so just ignore transactions
+			// that have been removed since they were calculated.
+			continue
+		} else if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve merchant transaction")
+		}
+
+		switch {
+		case existing.DeletedAt != nil:
+			continue
+		case existing.SpendingId != nil && *existing.SpendingId == request.SpendingId:
+			continue
+		case existing.IsAddition(),
+			len(existing.Splits) > 0,
+			existing.SpendingId != nil && !request.Overwrite:
+			skipped++
+			continue
+		}
+
+		transaction := *existing
+		transaction.SpendingId = &request.SpendingId
+		if _, err := repo.ProcessTransactionSpentFrom(
+			c.getContext(ctx),
+			bankAccountId,
+			&transaction,
+			existing,
+		); err != nil {
+			return c.wrapPgError(ctx, err, "failed to spend merchant transaction")
+		}
+
+		if err := repo.UpdateTransaction(c.getContext(ctx), bankAccountId, &transaction); err != nil {
+			return c.wrapPgError(ctx, err, "failed to update merchant transaction")
+		}
+
+		updated = append(updated, transaction)
+	}
+
+	spending, err := repo.GetSpendingById(c.getContext(ctx), bankAccountId, request.SpendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve updated spending")
+	}
+
+	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not get updated balances")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": updated,
+		"skipped":      skipped,
+		"spending":     spending,
+		"balance":      balance,
+	})
+}
+
+// getTransactionClusterOverride returns the existing override for the
+// signature, or a new one if the user has not changed that cluster before.
+func (c *Controller) getTransactionClusterOverride(
+	ctx echo.Context,
+	repo repository.Repository,
+	bankAccountId ID[BankAccount],
+	signature string,
+) (*TransactionClusterOverride, error) {
+	overrides, err := repo.GetTransactionClusterOverrides(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return nil, c.wrapPgError(ctx, err, "failed to retrieve merchant overrides")
+	}
+
+	for i := range overrides {
+		if overrides[i].Signature == signature {
+			return &overrides[i], nil
+		}
+	}
+
+	return &TransactionClusterOverride{
+		Signature: signature,
+	}, nil
+}
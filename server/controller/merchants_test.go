@@ -0,0 +1,317 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func givenIHaveAMerchant(t *testing.T, bank BankAccount, name string, transactions []Transaction) TransactionCluster {
+	members := make([]ID[Transaction], len(transactions))
+	for i := range transactions {
+		members[i] = transactions[i].TransactionId
+	}
+
+	return testutils.MustInsert(t, TransactionCluster{
+		AccountId:     bank.AccountId,
+		BankAccountId: bank.BankAccountId,
+		Signature:     name,
+		Name:          name,
+		Members:       members,
+	})
+}
+
+func TestGetMerchants(t *testing.T) {
+	t.Run("monthly totals", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 3)
+		cluster := givenIHaveAMerchant(t, bank, "Amazon", transactions)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var total int64
+		for _, transaction := range transactions {
+			total += transaction.Amount
+		}
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/merchants").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].transactionClusterId").IsEqual(cluster.TransactionClusterId)
+		response.JSON().Path("$[0].transactionCount").IsEqual(3)
+		response.JSON().Path("$[0].totalAmount").IsEqual(total)
+		response.JSON().Path("$[0].months").Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].months[0].count").IsEqual(3)
+	})
+
+	t.Run("invalid months", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/merchants").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("months", 0).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("months must be at least 1")
+	})
+}
+
+func TestPutMerchant(t *testing.T) {
+	t.Run("rename", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 2)
+		cluster := givenIHaveAMerchant(t, bank, "AMZN Mktp", transactions)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", cluster.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name": "Amazon",
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.name").IsEqual("Amazon")
+
+		updated := testutils.MustDBRead(t, cluster)
+		assert.Equal(t, "Amazon", updated.Name)
+	})
+}
+
+func TestPostMerchantMerge(t *testing.T) {
+	t.Run("merge two merchants", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 4)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions[:2])
+		amzn := givenIHaveAMerchant(t, bank, "AMZN Mktp", transactions[2:])
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/merge").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionClusterId", amazon.TransactionClusterId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"transactionClusterIds": []ID[TransactionCluster]{amzn.TransactionClusterId},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.members").Array().Length().IsEqual(4)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/merchants").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].transactionClusterId").IsEqual(amazon.TransactionClusterId)
+		}
+	})
+
+	t.Run("merge into itself", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 2)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/merge").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", amazon.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"transactionClusterIds": []ID[TransactionCluster]{amazon.TransactionClusterId},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("cannot merge a merchant into itself")
+	})
+}
+
+func TestPostMerchantSplit(t *testing.T) {
+	t.Run("split a transaction out", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 3)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/split").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", amazon.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":           "Amazon Prime",
+				"transactionIds": []ID[Transaction]{transactions[1].TransactionId},
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.original.members").Array().Length().IsEqual(2)
+		response.JSON().Path("$.split.name").IsEqual("Amazon Prime")
+		response.JSON().Path("$.split.members").Array().IsEqual([]ID[Transaction]{transactions[1].TransactionId})
+	})
+
+	t.Run("transaction from another merchant", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 3)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions[:2])
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/split").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", amazon.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":           "Amazon Prime",
+				"transactionIds": []ID[Transaction]{transactions[2].TransactionId},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("Transaction is not part of this merchant: " + transactions[2].TransactionId.String())
+	})
+
+	t.Run("split every transaction", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 1)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/split").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", amazon.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":           "Amazon Prime",
+				"transactionIds": []ID[Transaction]{transactions[0].TransactionId},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("cannot split every transaction out of a merchant")
+	})
+}
+
+func TestPostMerchantSpending(t *testing.T) {
+	t.Run("assign every transaction", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		now := app.Clock.Now()
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+		fundingRule := testutils.NewRuleSet(t, 2021, 12, 31, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+		spendingRule := testutils.NewRuleSet(t, 2022, 1, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 3)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions)
+		fundingSchedule := testutils.MustInsert(t, FundingSchedule{
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			Name:                   "Payday",
+			Description:            "Whenever I get paid",
+			RuleSet:                fundingRule,
+			NextRecurrence:         fundingRule.After(now, false),
+			NextRecurrenceOriginal: fundingRule.After(now, false),
+		})
+		shopping := testutils.MustInsert(t, Spending{
+			Name:                   "Shopping",
+			SpendingType:           SpendingTypeExpense,
+			TargetAmount:           100000,
+			CurrentAmount:          100000,
+			NextContributionAmount: 100000,
+			NextRecurrence:         spendingRule.After(now, false),
+			RuleSet:                spendingRule,
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			FundingScheduleId:      fundingSchedule.FundingScheduleId,
+			CreatedAt:              now,
+		})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var total int64
+		for _, transaction := range transactions {
+			total += transaction.Amount
+		}
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/spending").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", amazon.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"spendingId": shopping.SpendingId,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.transactions").Array().Length().IsEqual(3)
+		response.JSON().Path("$.skipped").IsEqual(0)
+		response.JSON().Path("$.spending.currentAmount").IsEqual(100000 - total)
+
+		for _, transaction := range transactions {
+			updated := testutils.MustDBRead(t, transaction)
+			assert.Equal(t, &shopping.SpendingId, updated.SpendingId, "transaction should be spent from shopping")
+		}
+	})
+
+	t.Run("spending object does not exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 2)
+		amazon := givenIHaveAMerchant(t, bank, "Amazon", transactions)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/merchants/{transactionClusterId}/spending").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionClusterId", amazon.TransactionClusterId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"spendingId": "spnd_bogus",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("Spending object does not exist: spnd_bogus")
+	})
+}
@@ -11,8 +11,11 @@ import (
 	"github.com/monetr/monetr/server/crumbs"
 	"github.com/monetr/monetr/server/internal/ctxkeys"
 	"github.com/monetr/monetr/server/internal/sentryecho"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
 	"github.com/monetr/monetr/server/security"
 	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -21,6 +24,7 @@ const (
 	spanContextKey               = "_spanContext_"
 	spanKey                      = "_span_"
 	authenticationKey            = "_authentication_"
+	userRoleContextKey           = "_userRole_"
 )
 
 func (c *Controller) databaseRepositoryMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
@@ -88,6 +92,24 @@ func (c *Controller) removeCookieIfPresent(ctx echo.Context) {
 
 func (c *Controller) requireActiveSubscriptionMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
+		// API keys can be restricted to specific bank accounts. These keys can only
+		// access endpoints for the bank accounts they are allowed, with the
+		// exception of listing bank accounts which is filtered instead.
+		if claims := c.mustGetClaims(ctx); claims.IsBankAccountRestricted() {
+			bankAccountId := ctx.Param("bankAccountId")
+			if bankAccountId == "" && apiKeyRoute(ctx) != "GET /bank_accounts" {
+				c.getSpan(ctx).Status = sentry.SpanStatusPermissionDenied
+				return c.returnError(ctx, http.StatusForbidden, "API key is restricted to specific bank accounts")
+			}
+
+			if bankAccountId != "" {
+				if err := claims.RequireBankAccount(bankAccountId); err != nil {
+					c.getSpan(ctx).Status = sentry.SpanStatusPermissionDenied
+					return c.wrapAndReturnError(ctx, err, http.StatusForbidden, "API key does not have access to this bank account")
+				}
+			}
+		}
+
 		if !c.Configuration.Stripe.IsBillingEnabled() {
 			return next(ctx)
 		}
@@ -229,9 +251,62 @@ func (c *Controller) requireToken(scopes ...security.Scope) func(next echo.Handl
 				return c.unauthorizedError(ctx, err)
 			}
 
+			// API keys can only access endpoints that have been explicitly made
+			// available to them, and only if the key has been granted one of the
+			// scopes for that endpoint.
+			if claims.Scope == security.APIKeyScope {
+				grants, ok := apiKeyRouteScopes[apiKeyRoute(ctx)]
+				if !ok {
+					c.getSpan(ctx).Status = sentry.SpanStatusPermissionDenied
+					return c.returnError(ctx, http.StatusForbidden, "API keys cannot access this endpoint")
+				}
+
+				if err := claims.RequireScope(grants...); err != nil {
+					c.getSpan(ctx).Status = sentry.SpanStatusPermissionDenied
+					return c.wrapAndReturnError(ctx, err, http.StatusForbidden, "API key does not have permission to access this endpoint")
+				}
+			}
+
 			// Everything looks good, run the next middleware or the actual controller
 			// function.
 			return next(ctx)
 		}
 	}
 }
+
+// requireMembershipMiddleware makes sure that the authenticated user is still a
+// member of the account in their token. Members that have been removed from an
+// account could otherwise keep using it until their token expires. The user's
+// role is stored on the request for requireOwnerMiddleware.
+func (c *Controller) requireMembershipMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		repo := c.mustGetAuthenticatedRepository(ctx)
+		role, err := repo.GetUserRole(c.getContext(ctx))
+		switch errors.Cause(err) {
+		case nil:
+		case repository.ErrUserNotFound:
+			return c.unauthorizedError(ctx, err)
+		default:
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to verify account membership")
+		}
+
+		ctx.Set(userRoleContextKey, role)
+
+		return next(ctx)
+	}
+}
+
+// requireOwnerMiddleware restricts an endpoint to the owner of the account.
+// Members cannot manage billing, delete links or manage the other members of
+// the account. This must be used after requireMembershipMiddleware.
+func (c *Controller) requireOwnerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		role, ok := ctx.Get(userRoleContextKey).(models.UserRole)
+		if !ok || role != models.UserRoleOwner {
+			c.getSpan(ctx).Status = sentry.SpanStatusPermissionDenied
+			return c.returnError(ctx, http.StatusForbidden, "Only the owner of the account can do this")
+		}
+
+		return next(ctx)
+	}
+}
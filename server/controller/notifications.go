@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/webhooks"
+)
+
+// getNotifications returns the most recent notifications for the current
+// account, including ones that have not been delivered yet.
+func (c *Controller) getNotifications(ctx echo.Context) error {
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	notifications, err := repo.GetNotifications(c.getContext(ctx), limit, offset)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve notifications")
+	}
+
+	return ctx.JSON(http.StatusOK, notifications)
+}
+
+func (c *Controller) getNotificationPreferences(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	preferences, err := repo.GetNotificationPreferences(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve notification preferences")
+	}
+
+	return ctx.JSON(http.StatusOK, preferences)
+}
+
+// putNotificationPreference creates or replaces the current user's preference
+// for a single kind of notification. Webhook notifications are signed the same
+// way as account webhooks, the secret is generated the first time a webhook url
+// is set on the preference and is only returned in that response.
+func (c *Controller) putNotificationPreference(ctx echo.Context) error {
+	kind := NotificationKind(ctx.Param("kind"))
+	if !kind.IsValid() {
+		return c.badRequest(ctx, "must specify a valid notification kind")
+	}
+
+	var request struct {
+		IsEnabled  *bool                     `json:"isEnabled"`
+		Channels   []NotificationChannelType `json:"channels"`
+		WebhookURL *string                   `json:"webhookUrl"`
+		Threshold  *int64                    `json:"threshold"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.WebhookURL != nil {
+		trimmed := strings.TrimSpace(*request.WebhookURL)
+		if trimmed == "" {
+			request.WebhookURL = nil
+		} else {
+			request.WebhookURL = &trimmed
+		}
+	}
+
+	preference := NotificationPreference{
+		Kind:       kind,
+		IsEnabled:  request.IsEnabled == nil || *request.IsEnabled,
+		Channels:   request.Channels,
+		WebhookURL: request.WebhookURL,
+		Threshold:  request.Threshold,
+	}
+	if preference.Channels == nil {
+		preference.Channels = []NotificationChannelType{}
+	}
+	if err := preference.Validate(); err != nil {
+		return c.badRequest(ctx, "Invalid notification preference: %s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	existingPreferences, err := repo.GetNotificationPreferences(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve existing notification preferences")
+	}
+
+	var existingSecretId *ID[Secret]
+	for _, existing := range existingPreferences {
+		if existing.Kind == kind {
+			existingSecretId = existing.WebhookSecretId
+			break
+		}
+	}
+
+	secretsRepo := c.mustGetSecretsRepository(ctx)
+	var secret *string
+	switch {
+	case preference.WebhookURL != nil && existingSecretId != nil:
+		preference.WebhookSecretId = existingSecretId
+	case preference.WebhookURL != nil:
+		generated, err := webhooks.GenerateSecret()
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to generate webhook secret")
+		}
+
+		secretData := repository.SecretData{
+			Kind:  WebhookSecretKind,
+			Value: generated,
+		}
+		if err := secretsRepo.Store(c.getContext(ctx), &secretData); err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store webhook secret")
+		}
+		preference.WebhookSecretId = &secretData.SecretId
+		secret = &generated
+	}
+
+	if err := repo.SaveNotificationPreference(c.getContext(ctx), &preference); err != nil {
+		return c.wrapPgError(ctx, err, "failed to save notification preference")
+	}
+
+	// If the webhook url was removed then the secret is no longer needed.
+	if preference.WebhookSecretId == nil && existingSecretId != nil {
+		if err := secretsRepo.Delete(c.getContext(ctx), *existingSecretId); err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to remove webhook secret")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, struct {
+		NotificationPreference
+		WebhookSecret *string `json:"webhookSecret,omitempty"`
+	}{
+		NotificationPreference: preference,
+		WebhookSecret:          secret,
+	})
+}
@@ -0,0 +1,124 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+)
+
+func TestPutNotificationPreference(t *testing.T) {
+	t.Run("create, update and list", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var preferenceId string
+		{
+			response := e.PUT("/api/notifications/preferences/{kind}").
+				WithPath("kind", "large_transaction").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"channels":  []string{"email"},
+					"threshold": 10000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.notificationPreferenceId").String().NotEmpty()
+			response.JSON().Path("$.kind").IsEqual("large_transaction")
+			response.JSON().Path("$.isEnabled").Boolean().IsTrue()
+			response.JSON().Path("$.threshold").IsEqual(10000)
+			preferenceId = response.JSON().Path("$.notificationPreferenceId").String().Raw()
+		}
+
+		{ // Updating the same kind should replace the existing preference.
+			response := e.PUT("/api/notifications/preferences/{kind}").
+				WithPath("kind", "large_transaction").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"isEnabled":  true,
+					"channels":   []string{"email", "webhook"},
+					"webhookUrl": "https://example.com/hook",
+					"threshold":  50000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.notificationPreferenceId").IsEqual(preferenceId)
+			response.JSON().Path("$.channels").Array().Length().IsEqual(2)
+			response.JSON().Path("$.threshold").IsEqual(50000)
+			response.JSON().Path("$.webhookSecret").String().NotEmpty()
+		}
+
+		{ // The secret is only returned when it is first generated.
+			response := e.PUT("/api/notifications/preferences/{kind}").
+				WithPath("kind", "large_transaction").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"channels":   []string{"webhook"},
+					"webhookUrl": "https://example.com/other",
+					"threshold":  50000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.webhookUrl").IsEqual("https://example.com/other")
+			response.JSON().Object().NotContainsKey("webhookSecret")
+		}
+
+		{
+			response := e.GET("/api/notifications/preferences").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].webhookUrl").IsEqual("https://example.com/other")
+		}
+
+		{
+			response := e.GET("/api/notifications").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+
+	t.Run("invalid kind", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/notifications/preferences/{kind}").
+			WithPath("kind", "bogus").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"channels": []string{"email"},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("must specify a valid notification kind")
+	})
+
+	t.Run("webhook without a url", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/notifications/preferences/{kind}").
+			WithPath("kind", "goal_reached").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"channels":   []string{"webhook"},
+				"webhookUrl": "   ",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid notification preference: webhook url is required for the webhook channel")
+	})
+}
@@ -244,6 +244,18 @@ func (c *Controller) processWebhook(ctx echo.Context, hook PlaidWebhook) error {
 			plaidLink.ErrorCode = myownsanity.StringP(code.(string))
 			log.Warn("plaid link is in an error state, updating")
 			err = authenticatedRepo.UpdatePlaidLink(c.getContext(ctx), plaidLink)
+			if err == nil {
+				// Failing to notify the user should not cause Plaid to retry the
+				// webhook, the link has already been updated.
+				if nerr := background.CreateNotification(
+					c.getContext(ctx),
+					log,
+					authenticatedRepo,
+					newLinkErrorNotification(link, code.(string), c.Clock.Now()),
+				); nerr != nil {
+					log.WithError(nerr).Warn("failed to create link error notification")
+				}
+			}
 		case "PENDING_EXPIRATION":
 			plaidLink.Status = models.PlaidLinkStatusPendingExpiration
 			plaidLink.ExpirationDate = hook.ConsentExpirationTime
@@ -278,3 +290,19 @@ func (c *Controller) processWebhook(ctx echo.Context, hook PlaidWebhook) error {
 
 	return err
 }
+
+func newLinkErrorNotification(link *models.Link, errorCode string, now time.Time) models.Notification {
+	return models.Notification{
+		Kind: models.NotificationKindLinkError,
+		DedupeKey: fmt.Sprintf(
+			"%s:%s:%s",
+			models.NotificationKindLinkError, link.LinkId, now.UTC().Format("2006-01-02"),
+		),
+		Title:   fmt.Sprintf("%s needs attention", link.InstitutionName),
+		Message: "monetr is no longer able to sync transactions from this institution, you may need to update your login.",
+		Data: map[string]string{
+			"linkId":    link.LinkId.String(),
+			"errorCode": errorCode,
+		},
+	}
+}
@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+)
+
+// maxReportPeriods limits how many periods can be requested in a single
+// report, this keeps someone from requesting a decade of weekly buckets.
+const maxReportPeriods = 260
+
+type reportAmount struct {
+	Total int64 `json:"total"`
+	Count int64 `json:"count"`
+	// AveragePerTransaction is the total divided by the number of transactions.
+	AveragePerTransaction int64 `json:"averagePerTransaction"`
+	// AveragePerPeriod is the total divided by the number of periods in the
+	// report, including periods where there were no transactions.
+	AveragePerPeriod int64 `json:"averagePerPeriod"`
+}
+
+type reportPeriod struct {
+	Period       time.Time `json:"period"`
+	Income       int64     `json:"income"`
+	IncomeCount  int64     `json:"incomeCount"`
+	Outflow      int64     `json:"outflow"`
+	OutflowCount int64     `json:"outflowCount"`
+}
+
+type reportGroup struct {
+	// Key is the spending ID, transaction cluster ID or category for the group.
+	// Transactions that do not belong to any group have a null key.
+	Key     *string        `json:"key"`
+	Name    *string        `json:"name"`
+	Income  reportAmount   `json:"income"`
+	Outflow reportAmount   `json:"outflow"`
+	Periods []reportPeriod `json:"periods"`
+}
+
+type report struct {
+	GroupBy   repository.ReportGroupBy  `json:"groupBy"`
+	Interval  repository.ReportInterval `json:"interval"`
+	StartDate time.Time                 `json:"startDate"`
+	EndDate   time.Time                 `json:"endDate"`
+	// Periods is the start of every period in the report, each group has one
+	// entry in its own periods for each of these in the same order.
+	Periods []time.Time   `json:"periods"`
+	Groups  []reportGroup `json:"groups"`
+	// Totals includes every transaction in the report regardless of its group.
+	Totals reportGroup `json:"totals"`
+}
+
+// getReports aggregates the transactions for a bank account by spending
+// object, merchant or category and by week, month or year in the account's
+// timezone. Every period in the requested range is included even if there were
+// no transactions in it, this way the result can be charted directly.
+func (c *Controller) getReports(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	params := repository.ReportParameters{
+		GroupBy:  repository.ReportGroupBySpending,
+		Interval: repository.ReportIntervalMonth,
+		Timezone: timezone,
+	}
+
+	if groupBy := ctx.QueryParam("group_by"); groupBy != "" {
+		switch repository.ReportGroupBy(groupBy) {
+		case repository.ReportGroupBySpending,
+			repository.ReportGroupByCluster,
+			repository.ReportGroupByCategory:
+			params.GroupBy = repository.ReportGroupBy(groupBy)
+		default:
+			return c.badRequest(ctx, "invalid group_by, must be one of spending, cluster or category")
+		}
+	}
+
+	if interval := ctx.QueryParam("interval"); interval != "" {
+		switch repository.ReportInterval(interval) {
+		case repository.ReportIntervalWeek,
+			repository.ReportIntervalMonth,
+			repository.ReportIntervalYear:
+			params.Interval = repository.ReportInterval(interval)
+		default:
+			return c.badRequest(ctx, "invalid interval, must be one of week, month or year")
+		}
+	}
+
+	// By default the report covers the current year up until now.
+	now := c.Clock.Now().In(timezone)
+	params.StartDate = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, timezone)
+	params.EndDate = now
+	if start := ctx.QueryParam("start_date"); start != "" {
+		params.StartDate, err = parseTransactionDateParam(start, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid start_date, must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+	}
+
+	if end := ctx.QueryParam("end_date"); end != "" {
+		params.EndDate, err = parseTransactionDateParam(end, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid end_date, must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		// If just a date was provided then include every transaction on that
+		// date.
+		if _, err := time.Parse(time.DateOnly, end); err == nil {
+			params.EndDate = params.EndDate.AddDate(0, 0, 1)
+		}
+	}
+
+	if !params.EndDate.After(params.StartDate) {
+		return c.badRequest(ctx, "end_date must be after start_date")
+	}
+
+	periods := getReportPeriods(params)
+	if len(periods) > maxReportPeriods {
+		return c.badRequest(ctx, "report cannot include more than %d periods, use a shorter date range or a larger interval", maxReportPeriods)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	rows, err := repo.GetTransactionReport(c.getContext(ctx), bankAccountId, params)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction report")
+	}
+
+	return ctx.JSON(http.StatusOK, buildReport(params, periods, rows))
+}
+
+// getReportPeriods returns the start of every period that overlaps with the
+// date range of the report. Weeks start on Monday to match postgres's
+// date_trunc.
+func getReportPeriods(params repository.ReportParameters) []time.Time {
+	start := params.StartDate.In(params.Timezone)
+	var period time.Time
+	var next func(time.Time) time.Time
+	switch params.Interval {
+	case repository.ReportIntervalWeek:
+		period = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, params.Timezone)
+		period = period.AddDate(0, 0, -((int(period.Weekday()) + 6) % 7))
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case repository.ReportIntervalYear:
+		period = time.Date(start.Year(), time.January, 1, 0, 0, 0, 0, params.Timezone)
+		next = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+	default:
+		period = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, params.Timezone)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+
+	periods := make([]time.Time, 0)
+	for ; period.Before(params.EndDate); period = next(period) {
+		periods = append(periods, period)
+		// Don't bother calculating periods that would be rejected anyway.
+		if len(periods) > maxReportPeriods {
+			break
+		}
+	}
+
+	return periods
+}
+
+func buildReport(
+	params repository.ReportParameters,
+	periods []time.Time,
+	rows []repository.ReportRow,
+) report {
+	index := make(map[int64]int, len(periods))
+	for i, period := range periods {
+		index[period.Unix()] = i
+	}
+
+	newGroup := func(key, name *string) *reportGroup {
+		group := &reportGroup{
+			Key:     key,
+			Name:    name,
+			Periods: make([]reportPeriod, len(periods)),
+		}
+		for i, period := range periods {
+			group.Periods[i].Period = period
+		}
+		return group
+	}
+
+	totals := newGroup(nil, nil)
+	groups := map[string]*reportGroup{}
+	order := make([]string, 0)
+	for _, row := range rows {
+		i, ok := index[row.Period.Unix()]
+		if !ok {
+			continue
+		}
+
+		// The totals are provided as their own rows so that transactions that
+		// appear in more than one group are only counted once.
+		if row.IsTotal {
+			totals.Periods[i].Income += row.Income
+			totals.Periods[i].IncomeCount += row.IncomeCount
+			totals.Periods[i].Outflow += row.Outflow
+			totals.Periods[i].OutflowCount += row.OutflowCount
+			continue
+		}
+
+		// Transactions without a group are still included, they are just given a
+		// null key.
+		var key string
+		if row.Key != nil {
+			key = *row.Key
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = newGroup(row.Key, row.Name)
+			groups[key] = group
+			order = append(order, key)
+		}
+
+		group.Periods[i].Income += row.Income
+		group.Periods[i].IncomeCount += row.IncomeCount
+		group.Periods[i].Outflow += row.Outflow
+		group.Periods[i].OutflowCount += row.OutflowCount
+	}
+
+	result := report{
+		GroupBy:   params.GroupBy,
+		Interval:  params.Interval,
+		StartDate: params.StartDate,
+		EndDate:   params.EndDate,
+		Periods:   periods,
+		Groups:    make([]reportGroup, 0, len(order)),
+	}
+	for _, key := range order {
+		group := groups[key]
+		group.summarize()
+		result.Groups = append(result.Groups, *group)
+	}
+	totals.summarize()
+	result.Totals = *totals
+
+	return result
+}
+
+// summarize calculates the income and outflow totals and averages of the group
+// from its periods.
+func (g *reportGroup) summarize() {
+	g.Income, g.Outflow = reportAmount{}, reportAmount{}
+	for _, period := range g.Periods {
+		g.Income.Total += period.Income
+		g.Income.Count += period.IncomeCount
+		g.Outflow.Total += period.Outflow
+		g.Outflow.Count += period.OutflowCount
+	}
+
+	for _, amount := range []*reportAmount{&g.Income, &g.Outflow} {
+		if amount.Count > 0 {
+			amount.AveragePerTransaction = int64(math.Round(float64(amount.Total) / float64(amount.Count)))
+		}
+		if len(g.Periods) > 0 {
+			amount.AveragePerPeriod = int64(math.Round(float64(amount.Total) / float64(len(g.Periods))))
+		}
+	}
+}
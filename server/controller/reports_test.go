@@ -0,0 +1,206 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReports(t *testing.T) {
+	t.Run("totals by category", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 5)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone, err := bank.Account.GetTimezone()
+		require.NoError(t, err, "must be able to get the account timezone")
+		today := util.Midnight(app.Clock.Now(), timezone)
+
+		var total int64
+		for _, transaction := range transactions {
+			total += transaction.Amount
+		}
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/reports").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("group_by", "category").
+			WithQuery("interval", "month").
+			WithQuery("start_date", today.Format(time.DateOnly)).
+			WithQuery("end_date", today.Format(time.DateOnly)).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.groupBy").IsEqual("category")
+		response.JSON().Path("$.interval").IsEqual("month")
+		response.JSON().Path("$.periods").Array().Length().IsEqual(1)
+		// None of the fixture transactions have a category, so they are all in
+		// a single group without a key.
+		response.JSON().Path("$.groups").Array().Length().IsEqual(1)
+		response.JSON().Path("$.groups[0].key").IsNull()
+		response.JSON().Path("$.totals.outflow.total").IsEqual(total)
+		response.JSON().Path("$.totals.outflow.count").IsEqual(5)
+		response.JSON().Path("$.totals.outflow.averagePerPeriod").IsEqual(total)
+		response.JSON().Path("$.totals.income.total").IsEqual(0)
+		response.JSON().Path("$.totals.periods[0].outflowCount").IsEqual(5)
+	})
+
+	t.Run("split transactions are counted once in the totals", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		now := app.Clock.Now()
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone, err := bank.Account.GetTimezone()
+		require.NoError(t, err, "must be able to get the account timezone")
+		today := util.Midnight(now, timezone)
+		fundingRule := testutils.NewRuleSet(t, 2021, 12, 31, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+		spendingRule := testutils.NewRuleSet(t, 2022, 1, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+
+		fundingSchedule := testutils.MustInsert(t, FundingSchedule{
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			Name:                   "Payday",
+			RuleSet:                fundingRule,
+			NextRecurrence:         fundingRule.After(now, false),
+			NextRecurrenceOriginal: fundingRule.After(now, false),
+		})
+		spending := make([]Spending, 0, 2)
+		for _, name := range []string{"Groceries", "Household"} {
+			spending = append(spending, testutils.MustInsert(t, Spending{
+				Name:              name,
+				SpendingType:      SpendingTypeExpense,
+				TargetAmount:      transaction.Amount,
+				CurrentAmount:     transaction.Amount,
+				NextRecurrence:    spendingRule.After(now, false),
+				RuleSet:           spendingRule,
+				AccountId:         user.AccountId,
+				BankAccountId:     bank.BankAccountId,
+				FundingScheduleId: fundingSchedule.FundingScheduleId,
+				CreatedAt:         now,
+			}))
+		}
+
+		{ // Split the transaction between the two spending objects.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/splits").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"splits": []map[string]interface{}{
+						{
+							"spendingId": spending[0].SpendingId,
+							"amount":     transaction.Amount - 50,
+						},
+						{
+							"spendingId": spending[1].SpendingId,
+							"amount":     50,
+						},
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/reports").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("group_by", "spending").
+			WithQuery("start_date", today.Format(time.DateOnly)).
+			WithQuery("end_date", today.Format(time.DateOnly)).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.groups").Array().Length().IsEqual(2)
+		response.JSON().Path("$.groups[0].outflow.count").IsEqual(1)
+		response.JSON().Path("$.groups[1].outflow.count").IsEqual(1)
+		response.JSON().Path("$.totals.outflow.total").IsEqual(transaction.Amount)
+		response.JSON().Path("$.totals.outflow.count").IsEqual(1)
+		response.JSON().Path("$.totals.outflow.averagePerTransaction").IsEqual(transaction.Amount)
+		response.JSON().Path("$.totals.periods[0].outflowCount").IsEqual(1)
+	})
+
+	t.Run("includes empty periods", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 2)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		timezone, err := bank.Account.GetTimezone()
+		require.NoError(t, err, "must be able to get the account timezone")
+		today := util.Midnight(app.Clock.Now(), timezone)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/reports").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("interval", "year").
+			WithQuery("start_date", today.AddDate(-2, 0, 0).Format(time.DateOnly)).
+			WithQuery("end_date", today.Format(time.DateOnly)).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.groupBy").IsEqual("spending")
+		response.JSON().Path("$.periods").Array().Length().IsEqual(3)
+		response.JSON().Path("$.totals.periods[0].outflowCount").IsEqual(0)
+		response.JSON().Path("$.totals.periods[2].outflowCount").IsEqual(2)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/reports").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("group_by", "payee").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("invalid group_by, must be one of spending, cluster or category")
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/reports").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("interval", "week").
+				WithQuery("start_date", "2010-01-01").
+				WithQuery("end_date", "2020-01-01").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().Contains("report cannot include more than")
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/reports").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("start_date", "2024-02-01").
+				WithQuery("end_date", "2024-01-01").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("end_date must be after start_date")
+		}
+	})
+}
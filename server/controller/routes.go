@@ -214,6 +214,7 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 		webhookParty := repoParty.Group("")
 		webhookParty.POST("/plaid/webhook", c.postPlaidWebhook)
 		webhookParty.POST("/stripe/webhook", c.handleStripeWebhook)
+		webhookParty.POST("/teller/webhook", c.postTellerWebhook)
 	}
 
 	// unauthed are endpoints that do not require authentication directly, but can
@@ -231,6 +232,7 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	unauthed.POST("/authentication/verify/resend", c.resendVerification)
 	unauthed.POST("/authentication/forgot", c.postForgotPassword)
 	unauthed.POST("/authentication/reset", c.resetPassword)
+	unauthed.POST("/authentication/invitation", c.postAcceptInvitation)
 
 	// These endpoints are only accessible if you have a token scoped for MFA.
 	multiFactorRequired := repoParty.Group("",
@@ -247,21 +249,28 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	)
 	userInfo.GET("/users/me", c.getMe)
 
-	// These endpoints all require a fully authenticated token
+	// These endpoints all require a fully authenticated token or an API key. API
+	// keys are further limited to the endpoints they have been granted access to.
 	authed := repoParty.Group("",
 		c.maybeTokenMiddleware,
-		c.requireToken(security.AuthenticatedScope),
+		c.requireToken(security.AuthenticatedScope, security.APIKeyScope),
+		c.requireMembershipMiddleware,
 	)
 	// User
 	authed.PUT("/users/security/password", c.changePassword)
 	authed.POST("/users/security/totp/setup", c.postSetupTOTP)
 	authed.POST("/users/security/totp/confirm", c.postConfirmTOTP)
+	authed.GET("/users/accounts", c.getAccounts)
+	authed.POST("/authentication/switch", c.postSwitchAccount)
+	// Account deletion can be canceled even if the subscription has lapsed
+	// during the grace period.
+	authed.DELETE("/account/deletion", c.deleteAccountDeletion, c.requireOwnerMiddleware)
 	// API Keys
 	c.RegisterAPIKeyRoutes(authed)
-	// Billing
-	authed.POST("/billing/create_checkout", c.handlePostCreateCheckout)
-	authed.GET("/billing/checkout/:checkoutSessionId", c.handleGetAfterCheckout)
-	authed.GET("/billing/portal", c.getBillingPortal)
+	// Billing, only the owner of an account can manage its subscription.
+	authed.POST("/billing/create_checkout", c.handlePostCreateCheckout, c.requireOwnerMiddleware)
+	authed.GET("/billing/checkout/:checkoutSessionId", c.handleGetAfterCheckout, c.requireOwnerMiddleware)
+	authed.GET("/billing/portal", c.getBillingPortal, c.requireOwnerMiddleware)
 
 	billed := authed.Group("", c.requireActiveSubscriptionMiddleware)
 	// Icons
@@ -269,14 +278,14 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	// Locale and currency data
 	billed.GET("/locale/currency", c.listCurrencies)
 	// Account
-	billed.DELETE("/account", c.deleteAccount)
+	billed.DELETE("/account", c.deleteAccount, c.requireOwnerMiddleware)
 	// Links
 	billed.GET("/links", c.getLinks)
 	billed.GET("/links/:linkId", c.getLink)
 	billed.POST("/links", c.postLinks)
 	billed.PUT("/links/:linkId", c.putLink)
 	billed.PUT("/links/convert/:linkId", c.convertLink)
-	billed.DELETE("/links/:linkId", c.deleteLink)
+	billed.DELETE("/links/:linkId", c.deleteLink, c.requireOwnerMiddleware)
 	billed.GET("/links/wait/:linkId", c.waitForDeleteLink)
 	// Institutions
 	billed.GET("/institutions/:institutionId", c.getInstitutionDetails)
@@ -285,17 +294,43 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.GET("/bank_accounts/:bankAccountId", c.getBankAccount)
 	billed.PUT("/bank_accounts/:bankAccountId", c.putBankAccounts)
 	billed.GET("/bank_accounts/:bankAccountId/balances", c.getBalances)
+	billed.GET("/bank_accounts/:bankAccountId/balances/history", c.getBalanceHistory)
+	billed.GET("/bank_accounts/:bankAccountId/balances/alert", c.getBalanceAlert)
+	billed.PUT("/bank_accounts/:bankAccountId/balances/alert", c.putBalanceAlert)
+	billed.DELETE("/bank_accounts/:bankAccountId/balances/alert", c.deleteBalanceAlert)
 	billed.POST("/bank_accounts", c.postBankAccounts)
 	// Transactions
 	billed.GET("/bank_accounts/:bankAccountId/transactions", c.getTransactions)
 	billed.GET("/bank_accounts/:bankAccountId/transactions/:transactionId", c.getTransactionById)
 	billed.GET("/bank_accounts/:bankAccountId/transactions/:transactionId/similar", c.getSimilarTransactionsById)
+	billed.GET("/bank_accounts/:bankAccountId/transactions/:transactionId/splits", c.getTransactionSplits)
+	billed.PUT("/bank_accounts/:bankAccountId/transactions/:transactionId/splits", c.putTransactionSplits)
 	billed.POST("/bank_accounts/:bankAccountId/transactions", c.postTransactions)
 	billed.POST("/bank_accounts/:bankAccountId/transactions/upload", c.postTransactionUpload)
+	billed.GET("/bank_accounts/:bankAccountId/transactions/upload/mapping", c.getTransactionUploadMapping)
+	billed.PUT("/bank_accounts/:bankAccountId/transactions/upload/mapping", c.putTransactionUploadMapping)
 	billed.GET("/bank_accounts/:bankAccountId/transactions/upload/:transactionUploadId", c.getTransactionUploadById)
 	billed.GET("/bank_accounts/:bankAccountId/transactions/upload/:transactionUploadId/progress", c.getTransactionUploadProgress)
 	billed.PUT("/bank_accounts/:bankAccountId/transactions/:transactionId", c.putTransactions)
 	billed.DELETE("/bank_accounts/:bankAccountId/transactions/:transactionId", c.deleteTransactions)
+	// Transaction rules
+	billed.GET("/bank_accounts/:bankAccountId/transaction_rules", c.getTransactionRules)
+	billed.GET("/bank_accounts/:bankAccountId/transaction_rules/:transactionRuleId", c.getTransactionRuleById)
+	billed.POST("/bank_accounts/:bankAccountId/transaction_rules", c.postTransactionRules)
+	billed.POST("/bank_accounts/:bankAccountId/transaction_rules/preview", c.postTransactionRulePreview)
+	billed.PUT("/bank_accounts/:bankAccountId/transaction_rules/:transactionRuleId", c.putTransactionRules)
+	billed.DELETE("/bank_accounts/:bankAccountId/transaction_rules/:transactionRuleId", c.deleteTransactionRules)
+	// Recurring transactions
+	billed.GET("/bank_accounts/:bankAccountId/recurring", c.getTransactionRecurring)
+	billed.POST("/bank_accounts/:bankAccountId/recurring/:transactionRecurringId/spending", c.postTransactionRecurringSpending)
+	// Merchants
+	billed.GET("/bank_accounts/:bankAccountId/merchants", c.getMerchants)
+	billed.PUT("/bank_accounts/:bankAccountId/merchants/:transactionClusterId", c.putMerchant)
+	billed.POST("/bank_accounts/:bankAccountId/merchants/:transactionClusterId/merge", c.postMerchantMerge)
+	billed.POST("/bank_accounts/:bankAccountId/merchants/:transactionClusterId/split", c.postMerchantSplit)
+	billed.POST("/bank_accounts/:bankAccountId/merchants/:transactionClusterId/spending", c.postMerchantSpending)
+	// Reports
+	billed.GET("/bank_accounts/:bankAccountId/reports", c.getReports)
 	// Uploads
 	billed.GET("/files", c.getFiles)
 	// Funding schedules
@@ -312,10 +347,29 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.PUT("/bank_accounts/:bankAccountId/spending/:spendingId", c.putSpending)
 	billed.DELETE("/bank_accounts/:bankAccountId/spending/:spendingId", c.deleteSpending)
 	billed.GET("/bank_accounts/:bankAccountId/spending/:spendingId/transactions", c.getSpendingTransactions)
+	billed.GET("/bank_accounts/:bankAccountId/spending/:spendingId/allocations", c.getSpendingAllocations)
 	// Forecasting
 	billed.GET("/bank_accounts/:bankAccountId/forecast", c.getForecast)
 	billed.POST("/bank_accounts/:bankAccountId/forecast/spending", c.postForecastNewSpending)
 	billed.POST("/bank_accounts/:bankAccountId/forecast/next_funding", c.postForecastNextFunding)
+	billed.POST("/bank_accounts/:bankAccountId/forecast/scenario", c.postForecastScenario)
+	billed.POST("/bank_accounts/:bankAccountId/forecast/goal", c.postForecastGoal)
+	// Members
+	billed.GET("/users", c.getMembers)
+	billed.DELETE("/users/:userId", c.deleteMember, c.requireOwnerMiddleware)
+	billed.GET("/users/invitations", c.getInvitations, c.requireOwnerMiddleware)
+	billed.POST("/users/invitations", c.postInvitation, c.requireOwnerMiddleware)
+	billed.DELETE("/users/invitations/:invitationId", c.deleteInvitation, c.requireOwnerMiddleware)
+	// Notifications
+	billed.GET("/notifications", c.getNotifications)
+	billed.GET("/notifications/preferences", c.getNotificationPreferences)
+	billed.PUT("/notifications/preferences/:kind", c.putNotificationPreference)
+	// Webhooks
+	billed.GET("/webhooks", c.getWebhooks)
+	billed.POST("/webhooks", c.postWebhooks)
+	billed.PUT("/webhooks/:webhookId", c.putWebhook)
+	billed.DELETE("/webhooks/:webhookId", c.deleteWebhook)
+	billed.GET("/webhooks/:webhookId/deliveries", c.getWebhookDeliveries)
 	// Plaid Link
 	billed.PUT("/plaid/link/update/:linkId", c.putUpdatePlaidLink)
 	billed.POST("/plaid/link/update/callback", c.updatePlaidTokenCallback)
@@ -323,4 +377,10 @@ func (c *Controller) RegisterRoutes(app *echo.Echo) {
 	billed.POST("/plaid/link/token/callback", c.postPlaidTokenCallback)
 	billed.GET("/plaid/link/setup/wait/:linkId", c.getWaitForPlaid)
 	billed.POST("/plaid/link/sync", c.postSyncPlaidManually)
+	// Teller Link
+	billed.POST("/teller/link", c.postTellerLink)
+	billed.POST("/teller/link/sync", c.postSyncTellerManually)
+	// SimpleFIN Link
+	billed.POST("/simplefin/link", c.postSimpleFINLink)
+	billed.POST("/simplefin/link/sync", c.postSyncSimpleFINManually)
 }
@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/sirupsen/logrus"
+)
+
+// postSimpleFINLink is called with the setup token that the user copied from
+// their SimpleFIN server. The setup token is claimed for an access URL, which
+// is stored and then used to create the accounts for the connection.
+func (c *Controller) postSimpleFINLink(ctx echo.Context) error {
+	if !c.Configuration.SimpleFIN.GetEnabled() {
+		return c.returnError(ctx, http.StatusNotAcceptable, "SimpleFIN is not enabled on this server.")
+	}
+
+	var request struct {
+		SetupToken string `json:"setupToken"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	request.SetupToken = strings.TrimSpace(request.SetupToken)
+	if request.SetupToken == "" {
+		return c.badRequest(ctx, "must provide a setup token")
+	}
+
+	// Setup tokens can only be claimed once, if anything after this fails then
+	// the user will need to create a new setup token.
+	accessUrl, err := c.SimpleFIN.ClaimSetupToken(c.getContext(ctx), request.SetupToken)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to claim SimpleFIN setup token")
+	}
+
+	server, _, err := simplefin.ParseAccessURL(accessUrl)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "invalid SimpleFIN access URL")
+	}
+
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"simplefin": logrus.Fields{
+			"server": server.Host,
+		},
+	})
+
+	client, err := c.SimpleFIN.NewClient(c.getContext(ctx), nil, accessUrl)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create SimpleFIN client")
+	}
+
+	// Only the balances are needed right now, transactions will be retrieved by
+	// the initial sync.
+	accountSet, err := client.GetAccounts(c.getContext(ctx), simplefin.GetAccountsOptions{
+		BalancesOnly: true,
+	})
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to retrieve accounts from SimpleFIN")
+	}
+
+	if len(accountSet.Accounts) == 0 {
+		return c.badRequest(ctx, "SimpleFIN connection does not have any accounts")
+	}
+
+	// A single SimpleFIN connection can include accounts from several
+	// institutions. Only use the institution name when they are all the same.
+	institutionName := accountSet.Accounts[0].GetInstitutionName()
+	for _, item := range accountSet.Accounts[1:] {
+		if item.GetInstitutionName() != institutionName {
+			institutionName = ""
+			break
+		}
+	}
+	if institutionName == "" {
+		institutionName = server.Host
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	secrets := c.mustGetSecretsRepository(ctx)
+	secret := repository.SecretData{
+		Kind:  SimpleFINSecretKind,
+		Value: accessUrl,
+	}
+	if err = secrets.Store(c.getContext(ctx), &secret); err != nil {
+		log.WithError(err).Errorf("failed to store access url")
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store access url")
+	}
+
+	simpleFINLink := SimpleFINLink{
+		SecretId:        secret.SecretId,
+		Server:          server.Host,
+		Status:          SimpleFINLinkStatusPending,
+		InstitutionName: institutionName,
+	}
+	if err = repo.CreateSimpleFINLink(c.getContext(ctx), &simpleFINLink); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create SimpleFIN link")
+	}
+
+	link := Link{
+		AccountId:       repo.AccountId(),
+		SimpleFINLinkId: &simpleFINLink.SimpleFINLinkId,
+		InstitutionName: institutionName,
+		LinkType:        SimpleFINLinkType,
+	}
+	if err = repo.CreateLink(c.getContext(ctx), &link); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create link")
+	}
+
+	now := c.Clock.Now().UTC()
+	accounts := make([]*BankAccount, 0, len(accountSet.Accounts))
+	for i := range accountSet.Accounts {
+		simpleFINAccount := accountSet.Accounts[i]
+		balance, err := simpleFINAccount.GetBalance()
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read account balance")
+		}
+		available, err := simpleFINAccount.GetAvailableBalance()
+		if err != nil {
+			return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read account balance")
+		}
+		balanceDate := simpleFINAccount.GetBalanceDate()
+
+		simpleFINBankAccount := SimpleFINBankAccount{
+			SimpleFINLinkId:    simpleFINLink.SimpleFINLinkId,
+			SimpleFINId:        simpleFINAccount.Id,
+			OrganizationName:   simpleFINAccount.GetInstitutionName(),
+			OrganizationDomain: simpleFINAccount.Org.Domain,
+			Name:               simpleFINAccount.Name,
+			Currency:           simpleFINAccount.GetCurrency(),
+			Balance:            balance,
+			AvailableBalance:   available,
+			BalanceDate:        &balanceDate,
+		}
+		if err := repo.CreateSimpleFINBankAccount(
+			c.getContext(ctx),
+			&simpleFINBankAccount,
+		); err != nil {
+			return c.wrapPgError(ctx, err, "failed to create simplefin bank account")
+		}
+
+		// SimpleFIN does not tell us what kind of account this is, the user can
+		// change the type later if they need to.
+		accounts = append(accounts, &BankAccount{
+			LinkId:                 link.LinkId,
+			SimpleFINBankAccountId: &simpleFINBankAccount.SimpleFINBankAccountId,
+			AvailableBalance:       available,
+			CurrentBalance:         balance,
+			Name:                   simpleFINAccount.Name,
+			OriginalName:           simpleFINAccount.Name,
+			Type:                   DepositoryBankAccountType,
+			SubType:                OtherBankAccountSubType,
+			Currency:               simpleFINAccount.GetCurrency(),
+			LastUpdated:            now,
+			Status:                 ActiveBankAccountStatus,
+		})
+	}
+
+	if err = repo.CreateBankAccounts(c.getContext(ctx), accounts...); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create bank accounts")
+	}
+
+	err = background.TriggerSyncSimpleFIN(c.getContext(ctx), c.JobRunner, background.SyncSimpleFINArguments{
+		AccountId: link.AccountId,
+		LinkId:    link.LinkId,
+		Trigger:   "initial",
+	})
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to pull initial transactions")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"linkId": link.LinkId,
+	})
+}
+
+func (c *Controller) postSyncSimpleFINManually(ctx echo.Context) error {
+	if !c.Configuration.SimpleFIN.GetEnabled() {
+		return c.returnError(ctx, http.StatusNotAcceptable, "SimpleFIN is not enabled on this server.")
+	}
+
+	var request struct {
+		LinkId ID[Link] `json:"linkId"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"linkId": request.LinkId,
+	})
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	link, err := repo.GetLink(c.getContext(ctx), request.LinkId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve link")
+	}
+
+	if link.LinkType != SimpleFINLinkType || link.SimpleFINLink == nil {
+		return c.badRequest(ctx, "cannot manually sync a non-SimpleFIN link")
+	}
+
+	simpleFINLink := link.SimpleFINLink
+	switch simpleFINLink.Status {
+	case SimpleFINLinkStatusSetup, SimpleFINLinkStatusError:
+		log.Debug("link is not disconnected, triggering manual sync")
+	default:
+		log.WithField("status", simpleFINLink.Status).Warn("link is not in a valid status, it cannot be manually synced")
+		return c.badRequest(ctx, "link is not in a valid status, it cannot be manually synced")
+	}
+
+	// SimpleFIN servers limit the number of requests each day, so manual syncs
+	// are throttled more than other providers.
+	if lastManualSync := simpleFINLink.LastManualSync; lastManualSync != nil && lastManualSync.After(c.Clock.Now().Add(-1*time.Hour)) {
+		return c.returnError(ctx, http.StatusTooEarly, "link has been manually synced too recently")
+	}
+
+	simpleFINLink.LastManualSync = myownsanity.TimeP(c.Clock.Now().UTC())
+	if err := repo.UpdateSimpleFINLink(c.getContext(ctx), simpleFINLink); err != nil {
+		return c.wrapPgError(ctx, err, "could not manually sync link")
+	}
+
+	err = background.TriggerSyncSimpleFIN(c.getContext(ctx), c.JobRunner, background.SyncSimpleFINArguments{
+		AccountId: link.AccountId,
+		LinkId:    link.LinkId,
+	})
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to trigger manual sync")
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
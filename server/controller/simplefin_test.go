@@ -0,0 +1,207 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mock_simplefin"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostSimpleFINLink(t *testing.T) {
+	t.Run("successful", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+
+		config := NewTestApplicationConfig(t)
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		setupToken, claimUrl := mock_simplefin.SetupTokenFixture(t)
+		accessUrl := mock_simplefin.AccessURLFixture(t)
+		checking := mock_simplefin.AccountFixture(t)
+		mock_simplefin.MockClaimSetupToken(t, claimUrl, accessUrl)
+		mock_simplefin.MockGetAccounts(t, accessUrl, []simplefin.Account{checking})
+
+		jobController.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(background.SyncSimpleFIN),
+				testutils.NewGenericMatcher(func(args background.SyncSimpleFINArguments) bool {
+					a := assert.EqualValues(t, user.AccountId, args.AccountId, "Account ID should match")
+					b := assert.EqualValues(t, "initial", args.Trigger, "Trigger should be initial")
+					return a && b
+				}),
+			).
+			Times(1).
+			Return(nil)
+
+		response := e.POST("/api/simplefin/link").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"setupToken": setupToken,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		linkId := response.JSON().Path("$.linkId").String().NotEmpty().Raw()
+
+		{ // The link should now be visible with its bank account.
+			response := e.GET("/api/links/{linkId}").
+				WithPath("linkId", linkId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.linkType").IsEqual(models.SimpleFINLinkType)
+			response.JSON().Path("$.institutionName").IsEqual(checking.Org.Name)
+			response.JSON().Path("$.simpleFINLink.status").IsEqual(models.SimpleFINLinkStatusPending)
+			response.JSON().Path("$.simpleFINLink.server").IsEqual(mock_simplefin.Host)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].name").IsEqual(checking.Name)
+		}
+
+		assert.EqualValues(t, 1, httpmock.GetCallCountInfo()["POST "+claimUrl], "setup token must only be claimed once")
+	})
+
+	t.Run("setup token already claimed", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		setupToken, claimUrl := mock_simplefin.SetupTokenFixture(t)
+		mock_simplefin.MockClaimSetupTokenUsed(t, claimUrl)
+
+		response := e.POST("/api/simplefin/link").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"setupToken": setupToken,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("failed to claim SimpleFIN setup token")
+
+		{ // No link should have been created.
+			response := e.GET("/api/links").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+
+	t.Run("missing setup token", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/simplefin/link").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"setupToken": "   ",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("must provide a setup token")
+	})
+}
+
+func TestPostSyncSimpleFINManually(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+
+		config := NewTestApplicationConfig(t)
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveASimpleFINLink(t, app.Clock, user, mock_simplefin.AccessURLFixture(t))
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		jobController.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(background.SyncSimpleFIN),
+				testutils.NewGenericMatcher(func(args background.SyncSimpleFINArguments) bool {
+					return assert.EqualValues(t, link.LinkId, args.LinkId, "Link ID should match")
+				}),
+			).
+			Times(1).
+			Return(nil)
+
+		{ // First request should succeed.
+			response := e.POST("/api/simplefin/link/sync").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"linkId": link.LinkId,
+				}).
+				Expect()
+
+			response.Status(http.StatusAccepted)
+		}
+
+		{ // Second request should be throttled.
+			response := e.POST("/api/simplefin/link/sync").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"linkId": link.LinkId,
+				}).
+				Expect()
+
+			response.Status(http.StatusTooEarly)
+			response.JSON().Path("$.error").String().IsEqual("link has been manually synced too recently")
+		}
+	})
+
+	t.Run("not a simplefin link", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/simplefin/link/sync").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"linkId": link.LinkId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("cannot manually sync a non-SimpleFIN link")
+	})
+}
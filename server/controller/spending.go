@@ -79,6 +79,11 @@ func (c *Controller) postSpending(ctx echo.Context) error {
 		return c.badRequest(ctx, "target amount must be greater than 0")
 	}
 
+	if spending.Priority < 0 {
+		return c.badRequest(ctx, "priority cannot be negative")
+	}
+	spending.ContributionShortfall = 0
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	// We need to calculate what the next contribution will be for this new
@@ -172,6 +177,8 @@ func (c *Controller) postSpendingTransfer(ctx echo.Context) error {
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
 	spendingToUpdate := make([]Spending, 0, 2)
+	allocations := make([]SpendingAllocation, 0, 2)
+	userId := c.mustGetUserId(ctx)
 
 	account, err := c.Accounts.GetAccount(c.getContext(ctx), c.mustGetAccountId(ctx))
 	if err != nil {
@@ -207,6 +214,15 @@ func (c *Controller) postSpendingTransfer(ctx echo.Context) error {
 		}
 
 		spendingToUpdate = append(spendingToUpdate, *fromExpense)
+		allocation := NewSpendingAllocation(
+			fromExpense,
+			SpendingAllocationKindTransfer,
+			SpendingAllocationSourceUser,
+			-transfer.Amount,
+		)
+		allocation.CounterpartSpendingId = transfer.ToSpendingId
+		allocation.UserId = &userId
+		allocations = append(allocations, allocation)
 	}
 
 	// If we are transferring the allocated funds to another spending object then
@@ -248,12 +264,25 @@ func (c *Controller) postSpendingTransfer(ctx echo.Context) error {
 		}
 
 		spendingToUpdate = append(spendingToUpdate, *toExpense)
+		allocation := NewSpendingAllocation(
+			toExpense,
+			SpendingAllocationKindTransfer,
+			SpendingAllocationSourceUser,
+			transfer.Amount,
+		)
+		allocation.CounterpartSpendingId = transfer.FromSpendingId
+		allocation.UserId = &userId
+		allocations = append(allocations, allocation)
 	}
 
 	if err = repo.UpdateSpending(c.getContext(ctx), bankAccountId, spendingToUpdate); err != nil {
 		return c.wrapPgError(ctx, err, "failed to update spending for transfer")
 	}
 
+	if err = repo.CreateSpendingAllocations(c.getContext(ctx), bankAccountId, allocations); err != nil {
+		return c.wrapPgError(ctx, err, "failed to record spending allocations for transfer")
+	}
+
 	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
 	if err != nil {
 		return c.wrapPgError(ctx, err, "could not get updated balances")
@@ -302,6 +331,10 @@ func (c *Controller) putSpending(ctx echo.Context) error {
 		return c.badRequest(ctx, "target amount must be greater than 0")
 	}
 
+	if updatedSpending.Priority < 0 {
+		return c.badRequest(ctx, "priority cannot be negative")
+	}
+
 	// These fields cannot be changed by the end user and must be maintained by the API, some of these fields are
 	// just meant to be immutable like date created.
 	updatedSpending.SpendingType = existingSpending.SpendingType
@@ -312,6 +345,7 @@ func (c *Controller) putSpending(ctx echo.Context) error {
 	updatedSpending.IsBehind = existingSpending.IsBehind
 	updatedSpending.LastRecurrence = existingSpending.LastRecurrence
 	updatedSpending.NextContributionAmount = existingSpending.NextContributionAmount
+	updatedSpending.ContributionShortfall = existingSpending.ContributionShortfall
 
 	if updatedSpending.SpendingType == SpendingTypeGoal {
 		updatedSpending.RuleSet = nil
@@ -455,3 +489,55 @@ func (c *Controller) getSpendingTransactions(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, transactions)
 }
+
+// getSpendingAllocations returns the allocation ledger for a single spending
+// object, newest first. Every change to the current amount of the spending
+// object is recorded here along with what caused it.
+func (c *Controller) getSpendingAllocations(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	spendingId, err := ParseID[Spending](ctx.Param("spendingId"))
+	if err != nil || spendingId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid spending Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	ok, err := repo.GetSpendingExists(c.getContext(ctx), bankAccountId, spendingId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to verify spending exists")
+	}
+
+	if !ok {
+		return c.returnError(ctx, http.StatusNotFound, "spending object does not exist")
+	}
+
+	allocations, err := repo.GetSpendingAllocations(
+		c.getContext(ctx),
+		bankAccountId,
+		spendingId,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve allocations for spending")
+	}
+
+	return ctx.JSON(http.StatusOK, allocations)
+}
@@ -1888,3 +1888,115 @@ func TestDeleteSpending(t *testing.T) {
 		}
 	})
 }
+
+func TestGetSpendingAllocations(t *testing.T) {
+	t.Run("records transfers and spends", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		now := app.Clock.Now()
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		timezone := testutils.MustEz(t, user.Account.GetTimezone)
+		fundingRule := testutils.NewRuleSet(t, 2021, 12, 31, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+		spendingRule := testutils.NewRuleSet(t, 2022, 1, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		fundingSchedule := testutils.MustInsert(t, FundingSchedule{
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			Name:                   "Payday",
+			Description:            "Whenever I get paid",
+			RuleSet:                fundingRule,
+			NextRecurrence:         fundingRule.After(now, false),
+			NextRecurrenceOriginal: fundingRule.After(now, false),
+		})
+		spending := testutils.MustInsert(t, Spending{
+			Name:                   "Groceries",
+			SpendingType:           SpendingTypeExpense,
+			TargetAmount:           5000,
+			CurrentAmount:          5000,
+			NextContributionAmount: 0,
+			NextRecurrence:         spendingRule.After(now, false),
+			RuleSet:                spendingRule,
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			FundingScheduleId:      fundingSchedule.FundingScheduleId,
+			CreatedAt:              now,
+		})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Move some of the money back to free to use.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/spending/transfer").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]any{
+					"fromSpendingId": spending.SpendingId,
+					"toSpendingId":   nil,
+					"amount":         1000,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.spending[0].currentAmount").Number().IsEqual(4000)
+		}
+
+		var transactionId string
+		{ // Then spend some of it.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]any{
+					"bankAccountId":  bank.BankAccountId,
+					"amount":         300,
+					"isPending":      false,
+					"name":           "Grocery Store",
+					"date":           now,
+					"adjustsBalance": false,
+					"spendingId":     spending.SpendingId,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.spending.currentAmount").Number().IsEqual(3700)
+			transactionId = response.JSON().Path("$.transaction.transactionId").String().Raw()
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/allocations").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("spendingId", spending.SpendingId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+			// Newest entries are returned first.
+			response.JSON().Path("$[0].kind").IsEqual(SpendingAllocationKindSpend)
+			response.JSON().Path("$[0].source").IsEqual(SpendingAllocationSourceTransaction)
+			response.JSON().Path("$[0].amount").Number().IsEqual(-300)
+			response.JSON().Path("$[0].currentAmount").Number().IsEqual(3700)
+			response.JSON().Path("$[0].transactionId").IsEqual(transactionId)
+			response.JSON().Path("$[1].kind").IsEqual(SpendingAllocationKindTransfer)
+			response.JSON().Path("$[1].source").IsEqual(SpendingAllocationSourceUser)
+			response.JSON().Path("$[1].amount").Number().IsEqual(-1000)
+			response.JSON().Path("$[1].currentAmount").Number().IsEqual(4000)
+			response.JSON().Path("$[1].counterpartSpendingId").IsNull()
+			response.JSON().Path("$[1].userId").IsEqual(user.UserId)
+		}
+	})
+
+	t.Run("spending does not exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/spending/{spendingId}/allocations").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("spendingId", "spnd_bogus").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").IsEqual("spending object does not exist")
+	})
+}
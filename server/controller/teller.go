@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// postTellerLink is called once the user has finished Teller Connect in the
+// UI. The access token for the enrollment is stored and the accounts that are
+// part of the enrollment are created in monetr.
+func (c *Controller) postTellerLink(ctx echo.Context) error {
+	if !c.Configuration.Teller.GetEnabled() {
+		return c.returnError(ctx, http.StatusNotAcceptable, "Teller is not enabled on this server.")
+	}
+
+	var request struct {
+		AccessToken     string `json:"accessToken"`
+		EnrollmentId    string `json:"enrollmentId"`
+		UserId          string `json:"userId"`
+		InstitutionName string `json:"institutionName"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	request.AccessToken = strings.TrimSpace(request.AccessToken)
+	request.EnrollmentId = strings.TrimSpace(request.EnrollmentId)
+	request.UserId = strings.TrimSpace(request.UserId)
+	request.InstitutionName = strings.TrimSpace(request.InstitutionName)
+	if request.AccessToken == "" {
+		return c.badRequest(ctx, "must provide an access token")
+	}
+	if request.EnrollmentId == "" {
+		return c.badRequest(ctx, "must provide an enrollment Id")
+	}
+
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"enrollmentId": request.EnrollmentId,
+	})
+
+	// Make sure the access token actually works before we store anything.
+	client, err := c.Teller.NewClient(c.getContext(ctx), nil, request.AccessToken)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create Teller client")
+	}
+
+	tellerAccounts, err := client.GetAccounts(c.getContext(ctx))
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusBadRequest, "failed to retrieve accounts from Teller")
+	}
+
+	if len(tellerAccounts) == 0 {
+		return c.badRequest(ctx, "enrollment does not have any accounts")
+	}
+
+	if request.InstitutionName == "" {
+		request.InstitutionName = tellerAccounts[0].Institution.Name
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	secrets := c.mustGetSecretsRepository(ctx)
+	secret := repository.SecretData{
+		Kind:  TellerSecretKind,
+		Value: request.AccessToken,
+	}
+	if err = secrets.Store(c.getContext(ctx), &secret); err != nil {
+		log.WithError(err).Errorf("failed to store access token")
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store access token")
+	}
+
+	tellerLink := TellerLink{
+		SecretId:        secret.SecretId,
+		EnrollmentId:    request.EnrollmentId,
+		TellerUserId:    request.UserId,
+		Status:          TellerLinkStatusPending,
+		InstitutionName: request.InstitutionName,
+	}
+	if err = repo.CreateTellerLink(c.getContext(ctx), &tellerLink); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create Teller link")
+	}
+
+	link := Link{
+		AccountId:       repo.AccountId(),
+		TellerLinkId:    &tellerLink.TellerLinkId,
+		InstitutionName: request.InstitutionName,
+		LinkType:        TellerLinkType,
+	}
+	if err = repo.CreateLink(c.getContext(ctx), &link); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create link")
+	}
+
+	now := c.Clock.Now().UTC()
+	accounts := make([]*BankAccount, 0, len(tellerAccounts))
+	for i := range tellerAccounts {
+		tellerAccount := tellerAccounts[i]
+		var available, ledger int64
+		// Balances are retrieved live from the institution, if we can't get them
+		// now then they will be updated by the first sync.
+		if balance, err := client.GetAccountBalance(c.getContext(ctx), tellerAccount.Id); err != nil {
+			log.WithError(err).WithField("tellerId", tellerAccount.Id).Warn("failed to retrieve balance for teller account")
+		} else {
+			if available, err = balance.GetAvailable(tellerAccount.Currency); err != nil {
+				return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read account balance")
+			}
+			if ledger, err = balance.GetLedger(tellerAccount.Currency); err != nil {
+				return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to read account balance")
+			}
+		}
+
+		tellerBankAccount := TellerBankAccount{
+			TellerLinkId:     tellerLink.TellerLinkId,
+			TellerId:         tellerAccount.Id,
+			InstitutionId:    tellerAccount.Institution.Id,
+			InstitutionName:  tellerAccount.Institution.Name,
+			Name:             tellerAccount.Name,
+			Mask:             tellerAccount.LastFour,
+			Type:             string(tellerAccount.Type),
+			SubType:          tellerAccount.SubType,
+			Status:           string(tellerAccount.Status),
+			Currency:         tellerAccount.Currency,
+			AvailableBalance: available,
+			LedgerBalance:    ledger,
+			BalancedAt:       &now,
+		}
+		if err := repo.CreateTellerBankAccount(
+			c.getContext(ctx),
+			&tellerBankAccount,
+		); err != nil {
+			return c.wrapPgError(ctx, err, "failed to create teller bank account")
+		}
+
+		accounts = append(accounts, &BankAccount{
+			LinkId:              link.LinkId,
+			TellerBankAccountId: &tellerBankAccount.TellerBankAccountId,
+			AvailableBalance:    available,
+			CurrentBalance:      ledger,
+			Name:                tellerAccount.Name,
+			Mask:                tellerAccount.LastFour,
+			Type:                tellerAccount.GetBankAccountType(),
+			SubType:             tellerAccount.GetBankAccountSubType(),
+			Currency:            tellerAccount.Currency,
+			LastUpdated:         now,
+			Status:              tellerAccount.GetBankAccountStatus(),
+		})
+	}
+
+	if err = repo.CreateBankAccounts(c.getContext(ctx), accounts...); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to create bank accounts")
+	}
+
+	// Teller does not send a webhook when an enrollment is first created, so
+	// the initial sync always needs to be triggered here.
+	err = background.TriggerSyncTeller(c.getContext(ctx), c.JobRunner, background.SyncTellerArguments{
+		AccountId: link.AccountId,
+		LinkId:    link.LinkId,
+		Trigger:   "initial",
+	})
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to pull initial transactions")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"linkId": link.LinkId,
+	})
+}
+
+func (c *Controller) postSyncTellerManually(ctx echo.Context) error {
+	if !c.Configuration.Teller.GetEnabled() {
+		return c.returnError(ctx, http.StatusNotAcceptable, "Teller is not enabled on this server.")
+	}
+
+	var request struct {
+		LinkId ID[Link] `json:"linkId"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"linkId": request.LinkId,
+	})
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	link, err := repo.GetLink(c.getContext(ctx), request.LinkId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve link")
+	}
+
+	if link.LinkType != TellerLinkType || link.TellerLink == nil {
+		return c.badRequest(ctx, "cannot manually sync a non-Teller link")
+	}
+
+	tellerLink := link.TellerLink
+	switch tellerLink.Status {
+	case TellerLinkStatusSetup, TellerLinkStatusError:
+		log.Debug("link is not disconnected, triggering manual sync")
+	default:
+		log.WithField("status", tellerLink.Status).Warn("link is not in a valid status, it cannot be manually synced")
+		return c.badRequest(ctx, "link is not in a valid status, it cannot be manually synced")
+	}
+
+	if lastManualSync := tellerLink.LastManualSync; lastManualSync != nil && lastManualSync.After(c.Clock.Now().Add(-30*time.Minute)) {
+		return c.returnError(ctx, http.StatusTooEarly, "link has been manually synced too recently")
+	}
+
+	tellerLink.LastManualSync = myownsanity.TimeP(c.Clock.Now().UTC())
+	if err := repo.UpdateTellerLink(c.getContext(ctx), tellerLink); err != nil {
+		return c.wrapPgError(ctx, err, "could not manually sync link")
+	}
+
+	err = background.TriggerSyncTeller(c.getContext(ctx), c.JobRunner, background.SyncTellerArguments{
+		AccountId: link.AccountId,
+		LinkId:    link.LinkId,
+	})
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to trigger manual sync")
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
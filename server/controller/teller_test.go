@@ -0,0 +1,295 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mock_teller"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostTellerLink(t *testing.T) {
+	t.Run("successful", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+
+		config := NewTestApplicationConfig(t)
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		accessToken := gofakeit.UUID()
+		enrollmentId := "enr_" + gofakeit.Generate("????????????????????")
+		checking := mock_teller.AccountFixture(t, enrollmentId)
+		checking.Type = teller.AccountTypeDepository
+		checking.SubType = "checking"
+		mock_teller.MockGetAccounts(t, accessToken, []teller.Account{checking})
+		mock_teller.MockGetAccountBalance(t, accessToken, []teller.Balance{
+			mock_teller.BalanceFixture(t, checking),
+		})
+
+		jobController.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(background.SyncTeller),
+				testutils.NewGenericMatcher(func(args background.SyncTellerArguments) bool {
+					a := assert.EqualValues(t, user.AccountId, args.AccountId, "Account ID should match")
+					b := assert.EqualValues(t, "initial", args.Trigger, "Trigger should be initial")
+					return a && b
+				}),
+			).
+			Times(1).
+			Return(nil)
+
+		response := e.POST("/api/teller/link").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"accessToken":     accessToken,
+				"enrollmentId":    enrollmentId,
+				"userId":          "usr_" + gofakeit.Generate("????????????????????"),
+				"institutionName": checking.Institution.Name,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		linkId := response.JSON().Path("$.linkId").String().NotEmpty().Raw()
+
+		{ // The link should now be visible with its bank account.
+			response := e.GET("/api/links/{linkId}").
+				WithPath("linkId", linkId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.linkType").IsEqual(models.TellerLinkType)
+			response.JSON().Path("$.tellerLink.status").IsEqual(models.TellerLinkStatusPending)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].name").IsEqual(checking.Name)
+			response.JSON().Path("$[0].mask").IsEqual(checking.LastFour)
+		}
+
+		assert.EqualValues(t, 1, httpmock.GetCallCountInfo()["GET https://api.teller.io/accounts"])
+	})
+
+	t.Run("invalid access token", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		accessToken := gofakeit.UUID()
+		mock_teller.MockEnrollmentDisconnected(t, accessToken)
+
+		response := e.POST("/api/teller/link").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"accessToken":  accessToken,
+				"enrollmentId": "enr_" + gofakeit.Generate("????????????????????"),
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("failed to retrieve accounts from Teller")
+
+		{ // No link should have been created.
+			response := e.GET("/api/links").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+
+	t.Run("missing enrollment", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/teller/link").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"accessToken": gofakeit.UUID(),
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("must provide an enrollment Id")
+	})
+}
+
+func TestPostSyncTellerManually(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+
+		config := NewTestApplicationConfig(t)
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveATellerLink(t, app.Clock, user, gofakeit.UUID())
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		jobController.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(background.SyncTeller),
+				testutils.NewGenericMatcher(func(args background.SyncTellerArguments) bool {
+					return assert.EqualValues(t, link.LinkId, args.LinkId, "Link ID should match")
+				}),
+			).
+			Times(1).
+			Return(nil)
+
+		{ // First request should succeed.
+			response := e.POST("/api/teller/link/sync").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"linkId": link.LinkId,
+				}).
+				Expect()
+
+			response.Status(http.StatusAccepted)
+		}
+
+		{ // Second request should be throttled.
+			response := e.POST("/api/teller/link/sync").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"linkId": link.LinkId,
+				}).
+				Expect()
+
+			response.Status(http.StatusTooEarly)
+			response.JSON().Path("$.error").String().IsEqual("link has been manually synced too recently")
+		}
+	})
+
+	t.Run("not a teller link", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/teller/link/sync").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"linkId": link.LinkId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("cannot manually sync a non-Teller link")
+	})
+}
+
+func TestPostTellerWebhook(t *testing.T) {
+	t.Run("missing signature", func(t *testing.T) {
+		_, e := NewTestApplication(t)
+
+		response := e.POST("/api/teller/webhook").
+			WithJSON(map[string]interface{}{
+				"id":   "wh_test",
+				"type": teller.WebhookTypeWebhookTest,
+			}).
+			Expect()
+
+		response.Status(http.StatusUnauthorized)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+
+		body, err := json.Marshal(map[string]interface{}{
+			"id":   "wh_test",
+			"type": teller.WebhookTypeWebhookTest,
+		})
+		require.NoError(t, err)
+
+		response := e.POST("/api/teller/webhook").
+			WithHeader(teller.SignatureHeader, webhooks.Sign(gofakeit.UUID(), app.Clock.Now(), body)).
+			WithHeader("Content-Type", "application/json").
+			WithBytes(body).
+			Expect()
+
+		response.Status(http.StatusUnauthorized)
+		response.JSON().Path("$.error").String().IsEqual("unauthorized")
+	})
+
+	t.Run("enrollment disconnected", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, _ := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveATellerLink(t, app.Clock, user, gofakeit.UUID())
+
+		body, err := json.Marshal(map[string]interface{}{
+			"id":        "wh_test",
+			"type":      teller.WebhookTypeEnrollmentDisconnected,
+			"timestamp": app.Clock.Now(),
+			"payload": map[string]interface{}{
+				"enrollment_id": link.TellerLink.EnrollmentId,
+				"reason":        "disconnected.credentials_invalid",
+			},
+		})
+		require.NoError(t, err)
+
+		response := e.POST("/api/teller/webhook").
+			WithHeader(teller.SignatureHeader, webhooks.Sign(
+				app.Configuration.Teller.WebhookSigningSecret,
+				app.Clock.Now(),
+				body,
+			)).
+			WithHeader("Content-Type", "application/json").
+			WithBytes(body).
+			Expect()
+
+		response.Status(http.StatusOK)
+
+		repo := repository.NewRepositoryFromSession(
+			app.Clock,
+			user.UserId,
+			user.AccountId,
+			testutils.GetPgDatabase(t),
+		)
+		updatedLink, err := repo.GetLink(context.Background(), link.LinkId)
+		require.NoError(t, err, "must be able to retrieve link")
+		assert.Equal(t, models.TellerLinkStatusDisconnected, updatedLink.TellerLink.Status)
+		require.NotNil(t, updatedLink.TellerLink.ErrorCode)
+		assert.Equal(t, "disconnected.credentials_invalid", *updatedLink.TellerLink.ErrorCode)
+	})
+}
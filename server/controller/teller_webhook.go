@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func (c *Controller) postTellerWebhook(ctx echo.Context) error {
+	if !c.Configuration.Teller.GetEnabled() || c.Configuration.Teller.WebhookSigningSecret == "" {
+		return c.notFound(ctx, "teller webhooks are not enabled")
+	}
+
+	signature := ctx.Request().Header.Get(teller.SignatureHeader)
+	if strings.TrimSpace(signature) == "" {
+		return c.returnError(ctx, http.StatusUnauthorized, "unauthorized")
+	}
+
+	// The signature is calculated from the raw body, so it needs to be read
+	// before anything is parsed.
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return c.badRequest(ctx, "failed to read request body")
+	}
+
+	hook, err := c.Teller.ParseWebhook(c.getContext(ctx), signature, body)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusUnauthorized, "unauthorized")
+	}
+
+	if err = c.processTellerWebhook(ctx, hook); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to handle webhook")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (c *Controller) processTellerWebhook(ctx echo.Context, hook *teller.Webhook) error {
+	log := c.getLog(ctx).WithFields(logrus.Fields{
+		"webhookId":   hook.Id,
+		"webhookType": hook.Type,
+	})
+
+	crumbs.AddTag(c.getContext(ctx), "webhook", "teller")
+	crumbs.AddTag(c.getContext(ctx), "teller.webhook.type", string(hook.Type))
+
+	repo := repository.NewTellerRepository(c.mustGetDatabase(ctx))
+
+	switch hook.Type {
+	case teller.WebhookTypeWebhookTest:
+		log.Info("received test webhook from teller")
+		return nil
+	case teller.WebhookTypeEnrollmentDisconnected:
+		log = log.WithField("enrollmentId", hook.Payload.EnrollmentId)
+		link, err := repo.GetLinkByEnrollmentId(c.getContext(ctx), hook.Payload.EnrollmentId)
+		if errors.Is(errors.Cause(err), pg.ErrNoRows) {
+			log.Warn("received disconnected webhook for an enrollment that does not exist")
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		crumbs.IncludeUserInScope(c.getContext(ctx), link.AccountId)
+		crumbs.AddTag(c.getContext(ctx), "linkId", link.LinkId.String())
+
+		authenticatedRepo := repository.NewRepositoryFromSession(
+			c.Clock,
+			link.TellerLink.CreatedBy,
+			link.AccountId,
+			c.mustGetDatabase(ctx),
+		)
+
+		reason := hook.Payload.Reason
+		tellerLink := link.TellerLink
+		tellerLink.Status = models.TellerLinkStatusDisconnected
+		tellerLink.ErrorCode = &reason
+		log.WithField("reason", reason).Warn("teller enrollment has been disconnected, updating")
+		if err := authenticatedRepo.UpdateTellerLink(c.getContext(ctx), tellerLink); err != nil {
+			return err
+		}
+
+		// Failing to notify the user should not cause Teller to retry the
+		// webhook, the link has already been updated.
+		if err := background.CreateNotification(
+			c.getContext(ctx),
+			log,
+			authenticatedRepo,
+			newLinkErrorNotification(link, reason, c.Clock.Now()),
+		); err != nil {
+			log.WithError(err).Warn("failed to create link error notification")
+		}
+
+		return nil
+	case teller.WebhookTypeTransactionsProcessed:
+		// A single webhook can include transactions for multiple accounts, but
+		// each link only needs to be synced once.
+		synced := map[models.ID[models.Link]]struct{}{}
+		for _, transaction := range hook.Payload.Transactions {
+			link, err := repo.GetLinkByTellerAccountId(c.getContext(ctx), transaction.AccountId)
+			if errors.Is(errors.Cause(err), pg.ErrNoRows) {
+				log.WithField("tellerAccountId", transaction.AccountId).
+					Warn("received transactions for a teller account that does not exist")
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if _, ok := synced[link.LinkId]; ok {
+				continue
+			}
+			synced[link.LinkId] = struct{}{}
+
+			if err := background.TriggerSyncTeller(
+				c.getContext(ctx),
+				c.JobRunner,
+				background.SyncTellerArguments{
+					AccountId: link.AccountId,
+					LinkId:    link.LinkId,
+					Trigger:   "webhook",
+				},
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		log.Debug("ignoring unknown teller webhook")
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func (c *Controller) getTransactionRecurring(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	recurring, err := repo.GetTransactionRecurring(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve recurring transactions")
+	}
+
+	return ctx.JSON(http.StatusOK, recurring)
+}
+
+// postTransactionRecurringSpending creates a new expense from a detected
+// recurring transaction. The recurrence rule and the target amount of the
+// expense are taken from the recurring transaction, but the name and the
+// amount can be overridden by the client.
+func (c *Controller) postTransactionRecurringSpending(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionRecurringId, err := ParseID[TransactionRecurring](ctx.Param("transactionRecurringId"))
+	if err != nil || transactionRecurringId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid recurring transaction Id")
+	}
+
+	var request struct {
+		FundingScheduleId ID[FundingSchedule] `json:"fundingScheduleId"`
+		Name              *string             `json:"name"`
+		TargetAmount      *int64              `json:"targetAmount"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	if request.FundingScheduleId.IsZero() {
+		return c.badRequest(ctx, "must specify a funding schedule for the expense")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	recurring, err := repo.GetTransactionRecurringById(
+		c.getContext(ctx),
+		bankAccountId,
+		transactionRecurringId,
+	)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve recurring transaction")
+	}
+
+	spending := &Spending{
+		BankAccountId:     bankAccountId,
+		FundingScheduleId: request.FundingScheduleId,
+		SpendingType:      SpendingTypeExpense,
+		Name:              recurring.Name,
+		TargetAmount:      recurring.AverageAmount(),
+		RuleSet:           recurring.RuleSet.Clone(),
+	}
+	if request.Name != nil {
+		spending.Name = *request.Name
+	}
+	if request.TargetAmount != nil {
+		spending.TargetAmount = *request.TargetAmount
+	}
+
+	spending.Name, err = c.cleanString(ctx, "Name", spending.Name)
+	if err != nil {
+		return err
+	}
+	if spending.Name == "" {
+		return c.badRequest(ctx, "spending must have a name")
+	}
+
+	// Deposits have a negative amount, so a recurring deposit will also be
+	// rejected here unless the client provides their own amount.
+	if spending.TargetAmount <= 0 {
+		return c.badRequest(ctx, "target amount must be greater than 0")
+	}
+
+	fundingSchedule, err := repo.GetFundingSchedule(
+		c.getContext(ctx),
+		bankAccountId,
+		spending.FundingScheduleId,
+	)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not find funding schedule specified")
+	}
+
+	account, err := repo.GetAccount(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve account details")
+	}
+
+	// The next recurrence stored on the recurring transaction might already be
+	// in the past if detection has not run recently, so calculate it again.
+	next := spending.RuleSet.After(c.Clock.Now(), false)
+	if next.IsZero() {
+		return c.badRequest(ctx, "recurring transaction does not have any future occurrences")
+	}
+
+	spending.NextRecurrence, err = c.midnightInLocal(ctx, next)
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "could not determine next recurrence")
+	}
+
+	if err = spending.CalculateNextContribution(
+		c.getContext(ctx),
+		account.Timezone,
+		fundingSchedule,
+		c.Clock.Now(),
+	); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to calculate the next contribution for the new spending")
+	}
+
+	if err = repo.CreateSpending(c.getContext(ctx), spending); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create spending")
+	}
+
+	return ctx.JSON(http.StatusOK, spending)
+}
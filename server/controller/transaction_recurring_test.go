@@ -0,0 +1,160 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func givenIHaveARecurringTransaction(t *testing.T, bank BankAccount, amounts map[int64]int) TransactionRecurring {
+	timezone := testutils.MustEz(t, bank.Account.GetTimezone)
+	rule := testutils.NewRuleSet(t, 2024, 1, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15")
+	return testutils.MustInsert(t, TransactionRecurring{
+		AccountId:     bank.AccountId,
+		BankAccountId: bank.BankAccountId,
+		Name:          "Netflix",
+		Window:        MonthlyWindowType,
+		RuleSet:       rule,
+		First:         rule.After(rule.GetDTStart(), true),
+		Last:          rule.After(rule.GetDTStart(), true),
+		Next:          rule.After(rule.GetDTStart(), false),
+		Confidence:    0.9,
+		Amounts:       amounts,
+		LastAmount:    1599,
+		Members:       []ID[Transaction]{},
+	})
+}
+
+func TestGetTransactionRecurring(t *testing.T) {
+	t.Run("list recurring transactions", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		recurring := givenIHaveARecurringTransaction(t, bank, map[int64]int{1599: 3})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/recurring").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().Length().IsEqual(1)
+		response.JSON().Path("$[0].transactionRecurringId").IsEqual(recurring.TransactionRecurringId)
+		response.JSON().Path("$[0].windowType").IsEqual(MonthlyWindowType)
+	})
+
+	t.Run("nothing detected", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/recurring").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Array().IsEmpty()
+	})
+}
+
+func TestPostTransactionRecurringSpending(t *testing.T) {
+	t.Run("create expense from suggestion", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		recurring := givenIHaveARecurringTransaction(t, bank, map[int64]int{1500: 2, 1800: 1})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/recurring/{transactionRecurringId}/spending").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionRecurringId", recurring.TransactionRecurringId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"fundingScheduleId": fundingSchedule.FundingScheduleId,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.spendingId").String().NotEmpty()
+		response.JSON().Path("$.spendingType").IsEqual(SpendingTypeExpense)
+		response.JSON().Path("$.name").IsEqual("Netflix")
+		response.JSON().Path("$.targetAmount").IsEqual(1600)
+		response.JSON().Path("$.ruleset").String().Contains("FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15")
+	})
+
+	t.Run("override name and amount", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		recurring := givenIHaveARecurringTransaction(t, bank, map[int64]int{1599: 3})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/recurring/{transactionRecurringId}/spending").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionRecurringId", recurring.TransactionRecurringId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"fundingScheduleId": fundingSchedule.FundingScheduleId,
+				"name":              "Streaming",
+				"targetAmount":      2000,
+			}).
+			Expect()
+
+		response.Status(http.StatusOK)
+		response.JSON().Path("$.name").IsEqual("Streaming")
+		response.JSON().Path("$.targetAmount").IsEqual(2000)
+	})
+
+	t.Run("recurring deposit", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		fundingSchedule := fixtures.GivenIHaveAFundingSchedule(t, app.Clock, &bank, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", false)
+		recurring := givenIHaveARecurringTransaction(t, bank, map[int64]int{-250000: 3})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/recurring/{transactionRecurringId}/spending").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionRecurringId", recurring.TransactionRecurringId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"fundingScheduleId": fundingSchedule.FundingScheduleId,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("target amount must be greater than 0")
+	})
+
+	t.Run("missing funding schedule", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		recurring := givenIHaveARecurringTransaction(t, bank, map[int64]int{1599: 3})
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/recurring/{transactionRecurringId}/spending").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionRecurringId", recurring.TransactionRecurringId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").IsEqual("must specify a funding schedule for the expense")
+	})
+}
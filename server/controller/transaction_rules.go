@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/pkg/errors"
+)
+
+func (c *Controller) getTransactionRules(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	rules, err := repo.GetTransactionRules(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction rules")
+	}
+
+	return ctx.JSON(http.StatusOK, rules)
+}
+
+func (c *Controller) getTransactionRuleById(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionRuleId, err := ParseID[TransactionRule](ctx.Param("transactionRuleId"))
+	if err != nil || transactionRuleId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid transaction rule Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	rule, err := repo.GetTransactionRule(c.getContext(ctx), bankAccountId, transactionRuleId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction rule")
+	}
+
+	return ctx.JSON(http.StatusOK, rule)
+}
+
+func (c *Controller) postTransactionRules(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var rule TransactionRule
+	if err := ctx.Bind(&rule); err != nil {
+		return c.invalidJson(ctx)
+	}
+	rule.TransactionRuleId = "" // Make sure we create a new rule.
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err := c.validateTransactionRule(ctx, repo, bankAccountId, &rule); err != nil {
+		return err
+	}
+
+	if err := repo.CreateTransactionRule(c.getContext(ctx), bankAccountId, &rule); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create transaction rule")
+	}
+
+	return ctx.JSON(http.StatusOK, rule)
+}
+
+func (c *Controller) putTransactionRules(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionRuleId, err := ParseID[TransactionRule](ctx.Param("transactionRuleId"))
+	if err != nil || transactionRuleId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid transaction rule Id")
+	}
+
+	var rule TransactionRule
+	if err := ctx.Bind(&rule); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	existing, err := repo.GetTransactionRule(c.getContext(ctx), bankAccountId, transactionRuleId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve existing transaction rule for update")
+	}
+
+	rule.TransactionRuleId = transactionRuleId
+	rule.CreatedAt = existing.CreatedAt
+	rule.CreatedBy = existing.CreatedBy
+
+	if err := c.validateTransactionRule(ctx, repo, bankAccountId, &rule); err != nil {
+		return err
+	}
+
+	if err := repo.UpdateTransactionRule(c.getContext(ctx), bankAccountId, &rule); err != nil {
+		return c.wrapPgError(ctx, err, "failed to update transaction rule")
+	}
+
+	return ctx.JSON(http.StatusOK, rule)
+}
+
+func (c *Controller) deleteTransactionRules(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionRuleId, err := ParseID[TransactionRule](ctx.Param("transactionRuleId"))
+	if err != nil || transactionRuleId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid transaction rule Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	if err := repo.DeleteTransactionRule(c.getContext(ctx), bankAccountId, transactionRuleId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrTransactionRuleNotFound) {
+			return c.notFound(ctx, "cannot remove transaction rule, it does not exist")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to remove transaction rule")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// postTransactionRulePreview evaluates the conditions of the provided rule
+// against the most recent transactions for the bank account without saving
+// the rule or changing any of the transactions. This way the user can see what
+// a rule would match before they create it.
+func (c *Controller) postTransactionRulePreview(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	var rule TransactionRule
+	if err := ctx.Bind(&rule); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	normalizeTransactionRule(&rule)
+	if err := rule.ValidateConditions(); err != nil {
+		return c.badRequest(ctx, "Invalid rule: %s", err.Error())
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	timezone := c.mustGetTimezone(ctx)
+
+	// Only look back through a limited number of transactions, this keeps the
+	// preview fast even for bank accounts with a lot of history.
+	const pageSize, maxScanned = 100, 1000
+	matches := make([]Transaction, 0, limit)
+	scanned := 0
+	filter := repository.TransactionFilter{
+		Limit:         pageSize,
+		IncludeHidden: true,
+	}
+	for scanned < maxScanned && len(matches) < limit {
+		transactions, next, err := repo.SearchTransactions(c.getContext(ctx), bankAccountId, filter)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to retrieve transactions for preview")
+		}
+
+		for _, transaction := range transactions {
+			scanned++
+			if !rule.Matches(transaction, timezone) {
+				continue
+			}
+
+			matches = append(matches, transaction)
+			if len(matches) >= limit {
+				break
+			}
+		}
+
+		if next == nil {
+			break
+		}
+		filter.Cursor = next
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": matches,
+		"scanned":      scanned,
+	})
+}
+
+// validateTransactionRule cleans up the provided rule and makes sure that it
+// is valid for the specified bank account. If it is not then the returned
+// error should be returned from the handler.
+func (c *Controller) validateTransactionRule(
+	ctx echo.Context,
+	repo repository.Repository,
+	bankAccountId ID[BankAccount],
+	rule *TransactionRule,
+) (err error) {
+	rule.BankAccountId = bankAccountId
+	rule.Name, err = c.cleanString(ctx, "Name", rule.Name)
+	if err != nil {
+		return err
+	}
+
+	normalizeTransactionRule(rule)
+	if err := rule.Validate(); err != nil {
+		return c.badRequest(ctx, "Invalid rule: %s", err.Error())
+	}
+
+	if rule.SpendingId != nil {
+		exists, err := repo.GetSpendingExists(c.getContext(ctx), bankAccountId, *rule.SpendingId)
+		if err != nil {
+			return c.wrapPgError(ctx, err, "failed to verify spending for rule")
+		}
+		if !exists {
+			return c.badRequest(ctx, "Spending object does not exist: %s", *rule.SpendingId)
+		}
+	}
+
+	return nil
+}
+
+// normalizeTransactionRule treats any blank text fields on the rule as if they
+// were not provided at all. Otherwise a blank pattern would match every
+// transaction.
+func normalizeTransactionRule(rule *TransactionRule) {
+	for _, field := range []**string{
+		&rule.NamePattern,
+		&rule.MerchantPattern,
+		&rule.Category,
+		&rule.RenameTo,
+	} {
+		if *field != nil && strings.TrimSpace(**field) == "" {
+			*field = nil
+		}
+	}
+
+	if rule.SpendingId != nil && rule.SpendingId.IsZero() {
+		rule.SpendingId = nil
+	}
+}
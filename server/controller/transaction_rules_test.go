@@ -0,0 +1,193 @@
+package controller_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func TestPostTransactionRules(t *testing.T) {
+	t.Run("create and list", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var ruleId string
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":        "Coffee",
+					"isEnabled":   true,
+					"namePattern": "starbucks",
+					"renameTo":    "Coffee",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.transactionRuleId").String().NotEmpty()
+			response.JSON().Path("$.bankAccountId").IsEqual(bank.BankAccountId)
+			response.JSON().Path("$.renameTo").IsEqual("Coffee")
+			ruleId = response.JSON().Path("$.transactionRuleId").String().Raw()
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transaction_rules").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].transactionRuleId").IsEqual(ruleId)
+		}
+
+		{
+			response := e.DELETE("/api/bank_accounts/{bankAccountId}/transaction_rules/{transactionRuleId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionRuleId", ruleId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transaction_rules").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+
+	t.Run("rule without an action", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":        "Coffee",
+				"namePattern": "starbucks",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid rule: rule must have at least one action")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":        "Coffee",
+				"namePattern": "starbucks(",
+				"hide":        true,
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Contains("Invalid rule: invalid name pattern")
+	})
+
+	t.Run("spending object from another bank account", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"name":        "Coffee",
+				"namePattern": "starbucks",
+				"spendingId":  "spnd_bogus",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Spending object does not exist: spnd_bogus")
+	})
+}
+
+func TestPostTransactionRulePreview(t *testing.T) {
+	t.Run("matches historical transactions", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 5)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Match a single transaction by its name.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules/preview").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"namePattern": "^" + regexp.QuoteMeta(transactions[2].Name) + "$",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.scanned").IsEqual(5)
+			response.JSON().Path("$.transactions").Array().Length().IsEqual(1)
+			response.JSON().Path("$.transactions[0].transactionId").IsEqual(transactions[2].TransactionId)
+		}
+
+		{ // All of the fixture transactions are debits, so they should all match
+			// but only up to the limit should be returned.
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules/preview").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("limit", 2).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"minAmount": 1,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.transactions").Array().Length().IsEqual(2)
+		}
+	})
+
+	t.Run("requires a condition", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transaction_rules/preview").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"namePattern": "  ",
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid rule: rule must have at least one condition")
+	})
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func (c *Controller) getTransactionSplits(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := ParseID[Transaction](ctx.Param("transactionId"))
+	if err != nil || transactionId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	// Retrieve the transaction first so that we return a 404 if it does not
+	// exist, the splits are loaded along with it.
+	transaction, err := repo.GetTransaction(c.getContext(ctx), bankAccountId, transactionId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve transaction")
+	}
+
+	splits := transaction.Splits
+	if splits == nil {
+		splits = make([]TransactionSplit, 0)
+	}
+
+	return ctx.JSON(http.StatusOK, splits)
+}
+
+// putTransactionSplits replaces the splits for a transaction. Whatever was
+// spent from the transaction's spending object or its previous splits is
+// returned, and then each of the new splits is deducted from its own spending
+// object. Providing an empty array of splits will remove the splits from the
+// transaction entirely.
+func (c *Controller) putTransactionSplits(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	transactionId, err := ParseID[Transaction](ctx.Param("transactionId"))
+	if err != nil || transactionId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid transaction Id")
+	}
+
+	var request struct {
+		Splits []struct {
+			SpendingId *ID[Spending] `json:"spendingId"`
+			Amount     int64         `json:"amount"`
+		} `json:"splits"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+
+	existingTransaction, err := repo.GetTransaction(c.getContext(ctx), bankAccountId, transactionId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve existing transaction for update")
+	}
+
+	splits := make([]TransactionSplit, 0, len(request.Splits))
+	for _, item := range request.Splits {
+		if item.SpendingId != nil && item.SpendingId.IsZero() {
+			item.SpendingId = nil
+		}
+
+		if item.SpendingId != nil {
+			exists, err := repo.GetSpendingExists(c.getContext(ctx), bankAccountId, *item.SpendingId)
+			if err != nil {
+				return c.wrapPgError(ctx, err, "failed to verify spending for split")
+			}
+			if !exists {
+				return c.badRequest(ctx, "Spending object does not exist: %s", *item.SpendingId)
+			}
+		}
+
+		splits = append(splits, TransactionSplit{
+			SpendingId: item.SpendingId,
+			Amount:     item.Amount,
+		})
+	}
+
+	if err := ValidateTransactionSplits(*existingTransaction, splits); err != nil {
+		return c.badRequest(ctx, "Invalid splits: %s", err.Error())
+	}
+
+	transaction := *existingTransaction
+	transaction.Splits = splits
+	if len(splits) > 0 {
+		// Split transactions are never spent from a single spending object.
+		transaction.SpendingId = nil
+	}
+	updatedExpenses, err := repo.ProcessTransactionSpentFrom(
+		c.getContext(ctx),
+		bankAccountId,
+		&transaction,
+		existingTransaction,
+	)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to process split changes")
+	}
+
+	if err = repo.UpdateTransaction(c.getContext(ctx), bankAccountId, &transaction); err != nil {
+		return c.wrapPgError(ctx, err, "could not update transaction")
+	}
+
+	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "could not get updated balances")
+	}
+
+	result := map[string]interface{}{
+		"transaction": transaction,
+		"balance":     balance,
+	}
+
+	if updatedExpenses != nil {
+		result["spending"] = updatedExpenses
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
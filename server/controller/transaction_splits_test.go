@@ -0,0 +1,197 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutTransactionSplits(t *testing.T) {
+	t.Run("split and unsplit a transaction", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		now := app.Clock.Now()
+
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		timezone, err := user.Account.GetTimezone()
+		require.NoError(t, err, "must be able to read the account's timezone")
+		fundingRule := testutils.NewRuleSet(t, 2021, 12, 31, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+		spendingRule := testutils.NewRuleSet(t, 2022, 1, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+		fundingSchedule := testutils.MustInsert(t, FundingSchedule{
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			Name:                   "Payday",
+			Description:            "Whenever I get paid",
+			RuleSet:                fundingRule,
+			ExcludeWeekends:        true,
+			NextRecurrence:         fundingRule.After(now, false),
+			NextRecurrenceOriginal: fundingRule.After(now, false),
+		})
+
+		groceries := testutils.MustInsert(t, Spending{
+			Name:                   "Groceries",
+			SpendingType:           SpendingTypeExpense,
+			TargetAmount:           transaction.Amount * 2,
+			CurrentAmount:          transaction.Amount * 2,
+			NextContributionAmount: transaction.Amount * 2,
+			NextRecurrence:         spendingRule.After(now, false),
+			RuleSet:                spendingRule,
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			FundingScheduleId:      fundingSchedule.FundingScheduleId,
+			CreatedAt:              now,
+		})
+		household := testutils.MustInsert(t, Spending{
+			Name:                   "Household",
+			SpendingType:           SpendingTypeExpense,
+			TargetAmount:           transaction.Amount * 2,
+			CurrentAmount:          transaction.Amount * 2,
+			NextContributionAmount: transaction.Amount * 2,
+			NextRecurrence:         spendingRule.After(now, false),
+			RuleSet:                spendingRule,
+			AccountId:              user.AccountId,
+			BankAccountId:          bank.BankAccountId,
+			FundingScheduleId:      fundingSchedule.FundingScheduleId,
+			CreatedAt:              now,
+		})
+
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Split the transaction between the two spending objects.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/splits").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"splits": []map[string]interface{}{
+						{
+							"spendingId": groceries.SpendingId,
+							"amount":     transaction.Amount - 50,
+						},
+						{
+							"spendingId": household.SpendingId,
+							"amount":     50,
+						},
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.transaction.spendingId").IsNull()
+			response.JSON().Path("$.transaction.splits").Array().Length().IsEqual(2)
+			response.JSON().Path("$.transaction.splits[0].spendingAmount").IsEqual(transaction.Amount - 50)
+			response.JSON().Path("$.transaction.splits[1].spendingAmount").IsEqual(50)
+			response.JSON().Path("$.spending").Array().Length().IsEqual(2)
+			response.JSON().Path("$.spending[0].currentAmount").IsEqual(groceries.CurrentAmount - (transaction.Amount - 50))
+			response.JSON().Path("$.spending[1].currentAmount").IsEqual(household.CurrentAmount - 50)
+		}
+
+		{ // The splits should be returned with the transaction.
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/splits").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+		}
+
+		{ // Spending cannot be set directly on a split transaction.
+			transaction.SpendingId = &groceries.SpendingId
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				WithJSON(transaction).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Cannot specify a spent from on a split transaction, remove the splits first")
+			transaction.SpendingId = nil
+		}
+
+		{ // Removing the splits should return everything to the spending objects.
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/splits").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithPath("transactionId", transaction.TransactionId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"splits": []map[string]interface{}{},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.transaction").Object().NotContainsKey("splits")
+			response.JSON().Path("$.spending").Array().Length().IsEqual(2)
+
+			assert.EqualValues(t, groceries.CurrentAmount, testutils.MustDBRead(t, groceries).CurrentAmount, "groceries should have been restored")
+			assert.EqualValues(t, household.CurrentAmount, testutils.MustDBRead(t, household).CurrentAmount, "household should have been restored")
+		}
+	})
+
+	t.Run("splits must add up to the transaction", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/splits").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"splits": []map[string]interface{}{
+					{
+						"amount": 25,
+					},
+					{
+						"amount": 25,
+					},
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().Contains("Invalid splits: splits must add up to the transaction amount")
+	})
+
+	t.Run("spending object does not exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transaction := fixtures.GivenIHaveATransaction(t, app.Clock, bank)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/{transactionId}/splits").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithPath("transactionId", transaction.TransactionId).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"splits": []map[string]interface{}{
+					{
+						"spendingId": "spnd_bogus",
+						"amount":     transaction.Amount - 50,
+					},
+					{
+						"amount": 50,
+					},
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Spending object does not exist: spnd_bogus")
+	})
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/formats"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+type transactionUploadMappingRequest struct {
+	Fields           formats.FieldIndex `json:"fields"`
+	HeaderRow        bool               `json:"headerRow"`
+	DateFormat       string             `json:"dateFormat"`
+	DecimalSeparator string             `json:"decimalSeparator"`
+}
+
+func (c *Controller) getTransactionUploadMapping(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	mapping, err := repo.GetTransactionUploadMapping(c.getContext(ctx), bankAccountId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "Failed to retrieve transaction upload mapping")
+	}
+
+	return ctx.JSON(http.StatusOK, mapping)
+}
+
+func (c *Controller) putTransactionUploadMapping(ctx echo.Context) error {
+	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
+	if err != nil || bankAccountId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid bank account Id")
+	}
+
+	var request transactionUploadMappingRequest
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	mapping, err := c.saveTransactionUploadMapping(ctx, bankAccountId, request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapping)
+}
+
+// ensureTransactionUploadMapping is used when a tabular file is being
+// uploaded. If the upload form includes a mapping then that mapping will be
+// saved for the bank account. Otherwise a mapping must already exist for the
+// bank account or a bad request is returned.
+func (c *Controller) ensureTransactionUploadMapping(ctx echo.Context, bankAccountId ID[BankAccount]) error {
+	if raw := strings.TrimSpace(ctx.FormValue("mapping")); raw != "" {
+		var request transactionUploadMappingRequest
+		if err := json.Unmarshal([]byte(raw), &request); err != nil {
+			return c.badRequestError(ctx, err, "Column mapping provided is not valid JSON")
+		}
+
+		_, err := c.saveTransactionUploadMapping(ctx, bankAccountId, request)
+		return err
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	_, err := repo.GetTransactionUploadMapping(c.getContext(ctx), bankAccountId)
+	switch errors.Cause(err) {
+	case nil:
+		return nil
+	case pg.ErrNoRows:
+		return c.badRequest(ctx, "A column mapping must be provided before CSV or Excel files can be imported for this bank account")
+	default:
+		return c.wrapPgError(ctx, err, "Failed to retrieve transaction upload mapping")
+	}
+}
+
+func (c *Controller) saveTransactionUploadMapping(
+	ctx echo.Context,
+	bankAccountId ID[BankAccount],
+	request transactionUploadMappingRequest,
+) (*TransactionUploadMapping, error) {
+	if err := request.Fields.Validate(); err != nil {
+		return nil, c.badRequestError(ctx, err, "Column mapping is not valid")
+	}
+
+	request.DateFormat = strings.TrimSpace(request.DateFormat)
+	if request.DateFormat != "" {
+		// Make sure that the layout can actually represent a date. A layout
+		// without any date components would happily "parse" anything as year 0.
+		reference := time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)
+		parsed, err := time.Parse(request.DateFormat, reference.Format(request.DateFormat))
+		if err != nil || !parsed.Equal(reference) {
+			return nil, c.badRequest(ctx, "Date format is not a valid layout")
+		}
+	}
+
+	switch request.DecimalSeparator {
+	case "":
+		request.DecimalSeparator = formats.DecimalSeparatorPeriod
+	case formats.DecimalSeparatorPeriod, formats.DecimalSeparatorComma:
+	default:
+		return nil, c.badRequest(ctx, "Decimal separator must be either a period or a comma")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if _, err := repo.GetBankAccount(c.getContext(ctx), bankAccountId); err != nil {
+		return nil, c.wrapPgError(ctx, err, "Failed to retrieve bank account")
+	}
+
+	mapping := TransactionUploadMapping{
+		Fields:           request.Fields,
+		HeaderRow:        request.HeaderRow,
+		DateFormat:       request.DateFormat,
+		DecimalSeparator: request.DecimalSeparator,
+	}
+	if err := repo.SaveTransactionUploadMapping(
+		c.getContext(ctx),
+		bankAccountId,
+		&mapping,
+	); err != nil {
+		return nil, c.wrapPgError(ctx, err, "Failed to save transaction upload mapping")
+	}
+
+	return &mapping, nil
+}
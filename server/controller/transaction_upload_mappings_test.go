@@ -0,0 +1,333 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/formats"
+	"github.com/monetr/monetr/server/internal/fixtures"
+	"github.com/monetr/monetr/server/internal/mockgen"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPutTransactionUploadMapping(t *testing.T) {
+	t.Run("save and retrieve a mapping", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // No mapping has been saved yet
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusNotFound)
+		}
+
+		{ // Save a mapping with separate debit and credit columns
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"fields": []formats.Field{
+						formats.FieldDate,
+						formats.FieldName,
+						formats.FieldAmountDebit,
+						formats.FieldAmountCredit,
+					},
+					"headerRow":  true,
+					"dateFormat": "02/01/2006",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.fields").Array().IsEqual([]formats.Field{
+				formats.FieldDate,
+				formats.FieldName,
+				formats.FieldAmountDebit,
+				formats.FieldAmountCredit,
+			})
+			response.JSON().Path("$.headerRow").Boolean().IsTrue()
+			response.JSON().Path("$.dateFormat").String().IsEqual("02/01/2006")
+			response.JSON().Path("$.decimalSeparator").String().IsEqual(".")
+		}
+
+		{ // Saving again replaces the existing mapping
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"fields": []formats.Field{
+						formats.FieldDate,
+						formats.FieldDescription,
+						formats.FieldAmountCombined,
+					},
+					"headerRow":        false,
+					"decimalSeparator": ",",
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{ // Retrieve the updated mapping
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.fields").Array().IsEqual([]formats.Field{
+				formats.FieldDate,
+				formats.FieldDescription,
+				formats.FieldAmountCombined,
+			})
+			response.JSON().Path("$.headerRow").Boolean().IsFalse()
+			response.JSON().Path("$.dateFormat").String().IsEmpty()
+			response.JSON().Path("$.decimalSeparator").String().IsEqual(",")
+		}
+	})
+
+	t.Run("invalid mappings", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Missing an amount column
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"fields": []formats.Field{
+						formats.FieldDate,
+						formats.FieldName,
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Column mapping is not valid")
+		}
+
+		{ // Both a signed amount and debit or credit columns
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"fields": []formats.Field{
+						formats.FieldDate,
+						formats.FieldName,
+						formats.FieldAmountCombined,
+						formats.FieldAmountDebit,
+					},
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Column mapping is not valid")
+		}
+
+		{ // Date format without any date components
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"fields": []formats.Field{
+						formats.FieldDate,
+						formats.FieldName,
+						formats.FieldAmountCombined,
+					},
+					"dateFormat": "not a date",
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Date format is not a valid layout")
+		}
+
+		{ // Unsupported decimal separator
+			response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"fields": []formats.Field{
+						formats.FieldDate,
+						formats.FieldName,
+						formats.FieldAmountCombined,
+					},
+					"decimalSeparator": "'",
+				}).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("Decimal separator must be either a period or a comma")
+		}
+	})
+
+	t.Run("bank account does not exist", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.PUT("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+			WithPath("bankAccountId", models.NewID(&models.BankAccount{})).
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"fields": []formats.Field{
+					formats.FieldDate,
+					formats.FieldName,
+					formats.FieldAmountCombined,
+				},
+			}).
+			Expect()
+
+		response.Status(http.StatusNotFound)
+		response.JSON().Path("$.error").String().IsEqual("Failed to retrieve bank account: record does not exist")
+	})
+}
+
+func TestPostTransactionUploadCSV(t *testing.T) {
+	contents := []byte("Date,Description,Amount\n2024-03-01,Coffee,-4.50\n")
+
+	t.Run("with a mapping in the upload", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+		config := NewTestApplicationConfig(t)
+		config.Storage.Enabled = true
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		jobController.EXPECT().
+			EnqueueJob(
+				gomock.Any(),
+				gomock.Eq(background.ProcessCSVUpload),
+				testutils.NewGenericMatcher(func(args background.ProcessCSVUploadArguments) bool {
+					return assert.Equal(t, bank.BankAccountId, args.BankAccountId) &&
+						assert.Equal(t, user.AccountId, args.AccountId)
+				}),
+			).
+			Times(1).
+			Return(nil)
+
+		mapping, err := json.Marshal(map[string]interface{}{
+			"fields": []formats.Field{
+				formats.FieldDate,
+				formats.FieldDescription,
+				formats.FieldAmountCombined,
+			},
+			"headerRow": true,
+		})
+		require.NoError(t, err, "must encode the mapping")
+
+		{ // Upload the file along with a mapping
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/upload").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithMultipart().
+				WithFileBytes("data", "transactions.csv", contents).
+				WithFormField("mapping", string(mapping)).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.transactionUploadId").String().NotEmpty()
+			response.JSON().Path("$.status").String().IsEqual(string(models.TransactionUploadStatusPending))
+		}
+
+		{ // The mapping is saved for future uploads
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions/upload/mapping").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.fields").Array().IsEqual([]formats.Field{
+				formats.FieldDate,
+				formats.FieldDescription,
+				formats.FieldAmountCombined,
+			})
+			response.JSON().Path("$.headerRow").Boolean().IsTrue()
+		}
+	})
+
+	t.Run("without a mapping", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+		config := NewTestApplicationConfig(t)
+		config.Storage.Enabled = true
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		jobController.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/upload").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFileBytes("data", "transactions.csv", contents).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("A column mapping must be provided before CSV or Excel files can be imported for this bank account")
+	})
+
+	t.Run("with an invalid mapping", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		jobController := mockgen.NewMockJobController(ctrl)
+		var controller background.JobController = jobController
+		config := NewTestApplicationConfig(t)
+		config.Storage.Enabled = true
+		app, e := NewTestApplicationPatched(t, config, TestAppInterfaces{
+			JobController: &controller,
+		})
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, models.DepositoryBankAccountType, models.CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		jobController.EXPECT().
+			EnqueueJob(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+
+		response := e.POST("/api/bank_accounts/{bankAccountId}/transactions/upload").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithCookie(TestCookieName, token).
+			WithMultipart().
+			WithFileBytes("data", "transactions.csv", contents).
+			WithFormField("mapping", "{not json").
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Column mapping provided is not valid JSON")
+	})
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/getsentry/sentry-go"
 	"github.com/labstack/echo/v4"
 	"github.com/monetr/monetr/server/background"
+	"github.com/monetr/monetr/server/formats/xlsx"
 	. "github.com/monetr/monetr/server/models"
 	"github.com/monetr/monetr/server/storage"
 	"golang.org/x/net/websocket"
@@ -36,21 +38,58 @@ func (c *Controller) postTransactionUpload(ctx echo.Context) error {
 		Error:         nil,
 	}
 
-	// Take the body and upload it as a file
-	file, err := c.consumeFileUpload(ctx, upload)
+	// Take the body and upload it as a file. Everything about the upload is
+	// validated before the file is stored, that way a file that cannot be
+	// processed is never persisted.
+	sheet := strings.TrimSpace(ctx.FormValue("sheet"))
+	var queue string
+	file, err := c.consumeFileUpload(ctx, upload, func(
+		contentType storage.ContentType,
+		reader multipart.File,
+		size int64,
+	) error {
+		switch {
+		case strings.EqualFold(string(contentType), string(storage.IntuitQFXContentType)),
+			strings.EqualFold(string(contentType), string(storage.QIFContentType)),
+			strings.EqualFold(string(contentType), string(storage.XMLContentType)):
+			// QIF and CAMT.053 (XML) statements are translated into the same shape
+			// as OFX files, so they are all processed by the same job.
+			queue = background.ProcessOFXUpload
+			return nil
+		case strings.EqualFold(string(contentType), string(storage.OpenXMLExcelContentType)):
+			// Make sure the workbook can be opened and has the requested sheet
+			// now, rather than failing later in the background job.
+			workbook, err := xlsx.OpenWorkbook(reader, size)
+			if err != nil {
+				return c.badRequestError(ctx, err, "File is not a valid Excel workbook")
+			}
+			if sheet, err = workbook.LookupSheet(sheet); err != nil {
+				return c.badRequest(ctx, "Invalid sheet: %s", err.Error())
+			}
+			fallthrough
+		case strings.EqualFold(string(contentType), string(storage.TextCSVContentType)):
+			// CSV and Excel files do not describe their own layout, so we need a
+			// column mapping for the bank account. One can be provided alongside
+			// the upload in which case it is saved for future uploads, or a
+			// previously saved one is used.
+			if err := c.ensureTransactionUploadMapping(ctx, bankAccountId); err != nil {
+				return err
+			}
+			queue = background.ProcessCSVUpload
+			return nil
+		default:
+			c.getLog(ctx).
+				WithField("contentType", contentType).
+				Debug("could not create transaction upload because the file is not a supported content type")
+			return c.badRequest(ctx, "File is not a OFX, QIF, CAMT.053, CSV or Excel file, and cannot be used for transaction imports")
+		}
+	})
 	if err != nil {
 		return err
 	}
 	upload.FileId = file.FileId
 	upload.File = file
 
-	if !strings.EqualFold(file.ContentType, string(storage.IntuitQFXContentType)) {
-		c.getLog(ctx).
-			WithField("contentType", file.ContentType).
-			Debug("could not create transaction upload because the file is not the expected content type: OFX")
-		return c.badRequest(ctx, "File is not a OFX file, and cannot be used for transaction imports")
-	}
-
 	if err := repo.CreateTransactionUpload(
 		c.getContext(ctx),
 		bankAccountId,
@@ -59,14 +98,31 @@ func (c *Controller) postTransactionUpload(ctx echo.Context) error {
 		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "Failed to create transaction upload")
 	}
 
-	if err := c.JobRunner.EnqueueJob(
-		c.getContext(ctx),
-		background.ProcessOFXUpload,
-		background.ProcessOFXUploadArguments{
+	// Both of the upload jobs take the same arguments, but are kept as separate
+	// types so they can diverge if they need to.
+	var arguments interface{}
+	switch queue {
+	case background.ProcessCSVUpload:
+		arguments = background.ProcessCSVUploadArguments{
 			AccountId:           c.mustGetAccountId(ctx),
 			BankAccountId:       bankAccountId,
 			TransactionUploadId: upload.TransactionUploadId,
-		},
+			// Workbooks can contain several sheets, the user can pick which one
+			// contains their transactions otherwise the first sheet is used.
+			Sheet: sheet,
+		}
+	default:
+		arguments = background.ProcessOFXUploadArguments{
+			AccountId:           c.mustGetAccountId(ctx),
+			BankAccountId:       bankAccountId,
+			TransactionUploadId: upload.TransactionUploadId,
+		}
+	}
+
+	if err := c.JobRunner.EnqueueJob(
+		c.getContext(ctx),
+		queue,
+		arguments,
 	); err != nil {
 		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "Failed to enqueue upload for processing")
 	}
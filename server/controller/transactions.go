@@ -3,13 +3,21 @@ package controller
 import (
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/monetr/monetr/server/background"
 	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
 	"github.com/sirupsen/logrus"
 )
 
+// getTransactions returns the transactions for the specified bank account,
+// newest first. The results can be narrowed down using query parameters, and
+// if there are more results than were returned then the cursor for the next
+// page is provided in the X-Next-Cursor header.
 func (c *Controller) getTransactions(ctx echo.Context) error {
 	bankAccountId, err := ParseID[BankAccount](ctx.Param("bankAccountId"))
 	if err != nil || bankAccountId.IsZero() {
@@ -32,16 +40,130 @@ func (c *Controller) getTransactions(ctx echo.Context) error {
 	// Only let a maximum of 100 transactions be requested at a time.
 	limit = int(math.Min(100, float64(limit)))
 
+	filter := repository.TransactionFilter{
+		Limit:  limit,
+		Offset: offset,
+		Query:  strings.TrimSpace(ctx.QueryParam("query")),
+	}
+
+	if cursor := ctx.QueryParam("cursor"); cursor != "" {
+		if offset > 0 {
+			return c.badRequest(ctx, "offset cannot be used with a cursor")
+		}
+
+		filter.Cursor, err = repository.ParseTransactionCursor(cursor)
+		if err != nil {
+			return c.badRequestError(ctx, err, "invalid cursor")
+		}
+	}
+
+	timezone := c.mustGetTimezone(ctx)
+	if start := ctx.QueryParam("start_date"); start != "" {
+		startDate, err := parseTransactionDateParam(start, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid start_date, must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		filter.StartDate = &startDate
+	}
+
+	if end := ctx.QueryParam("end_date"); end != "" {
+		endDate, err := parseTransactionDateParam(end, timezone)
+		if err != nil {
+			return c.badRequest(ctx, "invalid end_date, must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		// If just a date was provided then include every transaction on that
+		// date.
+		if _, err := time.Parse(time.DateOnly, end); err == nil {
+			endDate = endDate.AddDate(0, 0, 1)
+		}
+		filter.EndDate = &endDate
+	}
+
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.EndDate.After(*filter.StartDate) {
+		return c.badRequest(ctx, "end_date must be after start_date")
+	}
+
+	if minAmount := ctx.QueryParam("min_amount"); minAmount != "" {
+		amount, err := strconv.ParseInt(minAmount, 10, 64)
+		if err != nil {
+			return c.badRequest(ctx, "invalid min_amount, must be an integer amount in cents")
+		}
+		filter.MinAmount = &amount
+	}
+
+	if maxAmount := ctx.QueryParam("max_amount"); maxAmount != "" {
+		amount, err := strconv.ParseInt(maxAmount, 10, 64)
+		if err != nil {
+			return c.badRequest(ctx, "invalid max_amount, must be an integer amount in cents")
+		}
+		filter.MaxAmount = &amount
+	}
+
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return c.badRequest(ctx, "min_amount cannot be greater than max_amount")
+	}
+
+	if spending := ctx.QueryParam("spending_id"); spending != "" {
+		spendingId, err := ParseID[Spending](spending)
+		if err != nil || spendingId.IsZero() {
+			return c.badRequest(ctx, "invalid spending_id")
+		}
+		filter.SpendingId = &spendingId
+	}
+
+	if pending := ctx.QueryParam("pending"); pending != "" {
+		isPending, err := strconv.ParseBool(pending)
+		if err != nil {
+			return c.badRequest(ctx, "invalid pending, must be true or false")
+		}
+		filter.IsPending = &isPending
+	}
+
+	if source := TransactionSource(ctx.QueryParam("source")); source != "" {
+		switch source {
+		case TransactionSourcePlaid, TransactionSourceTeller, TransactionSourceSimpleFIN, TransactionSourceUpload, TransactionSourceManual:
+			filter.Source = &source
+		default:
+			return c.badRequest(ctx, "invalid source, must be one of: plaid, teller, simplefin, upload, manual")
+		}
+	}
+
+	if category := strings.TrimSpace(ctx.QueryParam("category")); category != "" {
+		filter.Category = &category
+	}
+
+	if hidden := ctx.QueryParam("include_hidden"); hidden != "" {
+		filter.IncludeHidden, err = strconv.ParseBool(hidden)
+		if err != nil {
+			return c.badRequest(ctx, "invalid include_hidden, must be true or false")
+		}
+	}
+
 	repo := c.mustGetAuthenticatedRepository(ctx)
 
-	transactions, err := repo.GetTransactions(c.getContext(ctx), bankAccountId, limit, offset)
+	transactions, next, err := repo.SearchTransactions(c.getContext(ctx), bankAccountId, filter)
 	if err != nil {
 		return c.wrapPgError(ctx, err, "failed to retrieve transactions")
 	}
 
+	if next != nil {
+		ctx.Response().Header().Set("X-Next-Cursor", next.String())
+	}
+
 	return ctx.JSON(http.StatusOK, transactions)
 }
 
+// parseTransactionDateParam accepts either a plain date, which is interpreted
+// as the start of that day in the provided timezone, or a full RFC3339
+// timestamp.
+func parseTransactionDateParam(input string, timezone *time.Location) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, input, timezone); err == nil {
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, input)
+}
+
 // getTransactionById will simply return a single transaction for the given bank
 // and transaction specified. If the transaction does not exist then a 404 not
 // found will be returned via the wrapPgError.
@@ -223,6 +345,35 @@ func (c *Controller) postTransactions(ctx echo.Context) error {
 		return c.wrapPgError(ctx, err, "could not create transaction")
 	}
 
+	if err = background.EmitWebhookEvent(
+		c.getContext(ctx),
+		c.getLog(ctx),
+		repo,
+		c.Clock,
+		WebhookEventTransactionCreated,
+		request.Transaction,
+	); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to emit webhook event for transaction")
+	}
+
+	// The ledger entry can only be recorded once the transaction has an ID.
+	if updatedSpending != nil {
+		allocation := NewSpendingAllocation(
+			updatedSpending,
+			SpendingAllocationKindSpend,
+			SpendingAllocationSourceTransaction,
+			-*request.SpendingAmount,
+		)
+		allocation.TransactionId = &request.TransactionId
+		if err = repo.CreateSpendingAllocations(
+			c.getContext(ctx),
+			bankAccountId,
+			[]SpendingAllocation{allocation},
+		); err != nil {
+			return c.wrapPgError(ctx, err, "failed to record spending allocation for transaction")
+		}
+	}
+
 	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
 	if err != nil {
 		return c.wrapPgError(ctx, err, "could not get updated balances")
@@ -295,6 +446,18 @@ func (c *Controller) putTransactions(ctx echo.Context) error {
 	transaction.PendingPlaidTransactionId = existingTransaction.PendingPlaidTransactionId
 	transaction.OriginalName = existingTransaction.OriginalName
 	transaction.OriginalMerchantName = existingTransaction.OriginalMerchantName
+	// Splits can only be changed via the splits endpoint.
+	transaction.Splits = existingTransaction.Splits
+
+	if len(existingTransaction.Splits) > 0 {
+		if transaction.SpendingId != nil {
+			return c.badRequest(ctx, "Cannot specify a spent from on a split transaction, remove the splits first")
+		}
+
+		if existingTransaction.Amount != transaction.Amount {
+			return c.badRequest(ctx, "Cannot change the amount of a split transaction, remove the splits first")
+		}
+	}
 
 	if !isManual {
 		// Prevent the user from attempting to change a transaction's amount if we are on a plaid link.
@@ -329,6 +492,17 @@ func (c *Controller) putTransactions(ctx echo.Context) error {
 		return c.wrapPgError(ctx, err, "could not update transaction")
 	}
 
+	if err = background.EmitWebhookEvent(
+		c.getContext(ctx),
+		c.getLog(ctx),
+		repo,
+		c.Clock,
+		WebhookEventTransactionUpdated,
+		transaction,
+	); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to emit webhook event for transaction")
+	}
+
 	balance, err := repo.GetBalances(c.getContext(ctx), bankAccountId)
 	if err != nil {
 		return c.wrapPgError(ctx, err, "could not get updated balances")
@@ -407,6 +581,22 @@ func (c *Controller) deleteTransactions(ctx echo.Context) error {
 		return c.wrapPgError(ctx, err, "Failed to find transaction to be removed")
 	}
 
+	// If the transaction was spent from any spending objects then that amount
+	// needs to be returned to them before the transaction is removed.
+	if transaction.SpendingId != nil || len(transaction.Splits) > 0 {
+		updatedTransaction := *transaction
+		updatedTransaction.SpendingId = nil
+		updatedTransaction.Splits = nil
+		if _, err := repo.ProcessTransactionSpentFrom(
+			c.getContext(ctx),
+			bankAccountId,
+			&updatedTransaction,
+			transaction,
+		); err != nil {
+			return c.wrapPgError(ctx, err, "Failed to restore spending for transaction")
+		}
+	}
+
 	if err := repo.DeleteTransaction(
 		c.getContext(ctx),
 		bankAccountId,
@@ -87,6 +87,169 @@ func TestGetTransactions(t *testing.T) {
 			response.JSON().Array().Length().IsEqual(20)
 		}
 	})
+
+	t.Run("cursor pagination", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		var token string
+		var bank BankAccount
+
+		{ // Seed the data for the test.
+			user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+			link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+			bank = fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+			fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 60)
+
+			token = GivenILogin(t, e, user.Login.Email, password)
+		}
+
+		seen := map[string]struct{}{}
+		cursor := ""
+		for page := 0; page < 3; page++ {
+			request := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("limit", 25).
+				WithCookie(TestCookieName, token)
+			if cursor != "" {
+				request = request.WithQuery("cursor", cursor)
+			}
+			response := request.Expect()
+
+			response.Status(http.StatusOK)
+			for _, item := range response.JSON().Array().Iter() {
+				seen[item.Object().Value("transactionId").String().Raw()] = struct{}{}
+			}
+			cursor = response.Header("X-Next-Cursor").Raw()
+		}
+
+		assert.Len(t, seen, 60, "should have seen every transaction exactly once")
+		assert.Empty(t, cursor, "there should not be a cursor after the last page")
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+			WithPath("bankAccountId", bank.BankAccountId).
+			WithQuery("cursor", "not a cursor").
+			WithCookie(TestCookieName, token).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("invalid cursor")
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		transactions := fixtures.GivenIHaveNTransactions(t, app.Clock, bank, 5)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		{ // Make a few of the transactions look like Costco charges.
+			transactions[0].Name = "Costco Wholesale"
+			transactions[0].Amount = 15432
+			transactions[0].IsPending = true
+			testutils.MustDBUpdate(t, &transactions[0])
+
+			transactions[1].Name = "Gas"
+			transactions[1].MerchantName = "COSTCO GAS"
+			transactions[1].Amount = 4500
+			testutils.MustDBUpdate(t, &transactions[1])
+
+			transactions[2].Name = "Costco Wholesale"
+			transactions[2].Amount = 9876
+			transactions[2].Date = transactions[2].Date.AddDate(0, -6, 0)
+			testutils.MustDBUpdate(t, &transactions[2])
+		}
+
+		{ // Free text search matches the name and merchant name.
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("query", "costco").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(3)
+		}
+
+		{ // Narrow that down to the last quarter.
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("query", "costco").
+				WithQuery("start_date", app.Clock.Now().AddDate(0, -3, 0).Format(time.DateOnly)).
+				WithQuery("end_date", app.Clock.Now().Format(time.DateOnly)).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+		}
+
+		{ // Amount range
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("query", "costco").
+				WithQuery("min_amount", 5000).
+				WithQuery("max_amount", 20000).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(2)
+		}
+
+		{ // Pending only
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("pending", true).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].transactionId").String().IsEqual(transactions[0].TransactionId.String())
+		}
+
+		{ // Source
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("source", "plaid").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+
+		{ // Invalid source
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("source", "teller").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("invalid source, must be one of: plaid, teller, simplefin, upload, manual")
+		}
+
+		{ // Invalid amount range
+			response := e.GET("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithQuery("min_amount", 500).
+				WithQuery("max_amount", 100).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusBadRequest)
+			response.JSON().Path("$.error").String().IsEqual("min_amount cannot be greater than max_amount")
+		}
+	})
 }
 
 func TestPostTransactions(t *testing.T) {
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/pkg/errors"
+)
+
+type webhookRequest struct {
+	URL         string             `json:"url"`
+	Description *string            `json:"description"`
+	EventTypes  []WebhookEventType `json:"eventTypes"`
+	IsEnabled   *bool              `json:"isEnabled"`
+}
+
+func (r webhookRequest) apply(webhook *Webhook) {
+	webhook.URL = strings.TrimSpace(r.URL)
+	webhook.Description = nil
+	if r.Description != nil {
+		if description := strings.TrimSpace(*r.Description); description != "" {
+			webhook.Description = &description
+		}
+	}
+	webhook.EventTypes = r.EventTypes
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []WebhookEventType{}
+	}
+	webhook.IsEnabled = r.IsEnabled == nil || *r.IsEnabled
+}
+
+func (c *Controller) getWebhooks(ctx echo.Context) error {
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	result, err := repo.GetWebhooks(c.getContext(ctx))
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve webhooks")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// postWebhooks registers a new webhook for the account. The secret used to
+// sign requests to the webhook is only ever returned by this endpoint.
+func (c *Controller) postWebhooks(ctx echo.Context) error {
+	var request webhookRequest
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	var webhook Webhook
+	request.apply(&webhook)
+	if err := webhook.Validate(); err != nil {
+		return c.badRequest(ctx, "Invalid webhook: %s", err.Error())
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to generate webhook secret")
+	}
+
+	secretData := repository.SecretData{
+		Kind:  WebhookSecretKind,
+		Value: secret,
+	}
+	if err := c.mustGetSecretsRepository(ctx).Store(c.getContext(ctx), &secretData); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to store webhook secret")
+	}
+	webhook.SecretId = secretData.SecretId
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	if err := repo.CreateWebhook(c.getContext(ctx), &webhook); err != nil {
+		return c.wrapPgError(ctx, err, "failed to create webhook")
+	}
+
+	return ctx.JSON(http.StatusOK, struct {
+		Webhook
+		Secret string `json:"secret"`
+	}{
+		Webhook: webhook,
+		Secret:  secret,
+	})
+}
+
+func (c *Controller) putWebhook(ctx echo.Context) error {
+	webhookId, err := ParseID[Webhook](ctx.Param("webhookId"))
+	if err != nil || webhookId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid webhook Id")
+	}
+
+	var request webhookRequest
+	if err := ctx.Bind(&request); err != nil {
+		return c.invalidJson(ctx)
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	existing, err := repo.GetWebhook(c.getContext(ctx), webhookId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve existing webhook for update")
+	}
+
+	request.apply(existing)
+	if err := existing.Validate(); err != nil {
+		return c.badRequest(ctx, "Invalid webhook: %s", err.Error())
+	}
+
+	if err := repo.UpdateWebhook(c.getContext(ctx), existing); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrWebhookNotFound) {
+			return c.notFound(ctx, "cannot update webhook, it does not exist")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to update webhook")
+	}
+
+	return ctx.JSON(http.StatusOK, existing)
+}
+
+func (c *Controller) deleteWebhook(ctx echo.Context) error {
+	webhookId, err := ParseID[Webhook](ctx.Param("webhookId"))
+	if err != nil || webhookId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid webhook Id")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	existing, err := repo.GetWebhook(c.getContext(ctx), webhookId)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve webhook")
+	}
+
+	if err := repo.DeleteWebhook(c.getContext(ctx), webhookId); err != nil {
+		if errors.Is(errors.Cause(err), repository.ErrWebhookNotFound) {
+			return c.notFound(ctx, "cannot remove webhook, it does not exist")
+		}
+
+		return c.wrapPgError(ctx, err, "failed to remove webhook")
+	}
+
+	// The secret can only be removed once nothing references it anymore.
+	if err := c.mustGetSecretsRepository(ctx).Delete(c.getContext(ctx), existing.SecretId); err != nil {
+		return c.wrapAndReturnError(ctx, err, http.StatusInternalServerError, "failed to remove webhook secret")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// getWebhookDeliveries returns the delivery log for the webhook, newest first.
+func (c *Controller) getWebhookDeliveries(ctx echo.Context) error {
+	webhookId, err := ParseID[Webhook](ctx.Param("webhookId"))
+	if err != nil || webhookId.IsZero() {
+		return c.badRequest(ctx, "must specify a valid webhook Id")
+	}
+
+	limit := urlParamIntDefault(ctx, "limit", 25)
+	offset := urlParamIntDefault(ctx, "offset", 0)
+
+	if limit < 1 {
+		return c.badRequest(ctx, "limit must be at least 1")
+	} else if limit > 100 {
+		return c.badRequest(ctx, "limit cannot be greater than 100")
+	}
+
+	if offset < 0 {
+		return c.badRequest(ctx, "offset cannot be less than 0")
+	}
+
+	repo := c.mustGetAuthenticatedRepository(ctx)
+	// Make sure the webhook exists so that a typo doesn't look like an empty log.
+	if _, err := repo.GetWebhook(c.getContext(ctx), webhookId); err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve webhook")
+	}
+
+	deliveries, err := repo.GetWebhookDeliveries(c.getContext(ctx), webhookId, limit, offset)
+	if err != nil {
+		return c.wrapPgError(ctx, err, "failed to retrieve webhook deliveries")
+	}
+
+	return ctx.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,156 @@
+package controller_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/fixtures"
+	. "github.com/monetr/monetr/server/models"
+)
+
+func TestPostWebhooks(t *testing.T) {
+	t.Run("create, list and remove", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var webhookId string
+		{
+			response := e.POST("/api/webhooks").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"url":         "https://example.com/monetr",
+					"description": "Home Assistant",
+					"eventTypes":  []string{"transaction.created", "sync.completed"},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.webhookId").String().NotEmpty()
+			response.JSON().Path("$.isEnabled").Boolean().IsTrue()
+			response.JSON().Path("$.secret").String().HasPrefix("whsec_")
+			webhookId = response.JSON().Path("$.webhookId").String().Raw()
+		}
+
+		{ // The secret is only returned when the webhook is created.
+			response := e.GET("/api/webhooks").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].webhookId").IsEqual(webhookId)
+			response.JSON().Path("$[0]").Object().NotContainsKey("secret")
+		}
+
+		{
+			response := e.PUT("/api/webhooks/{webhookId}").
+				WithPath("webhookId", webhookId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"url":        "https://example.com/monetr",
+					"eventTypes": []string{"spending.funded"},
+					"isEnabled":  false,
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Path("$.isEnabled").Boolean().IsFalse()
+			response.JSON().Path("$.eventTypes").Array().IsEqual([]string{"spending.funded"})
+		}
+
+		{
+			response := e.GET("/api/webhooks/{webhookId}/deliveries").
+				WithPath("webhookId", webhookId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+
+		{
+			response := e.DELETE("/api/webhooks/{webhookId}").
+				WithPath("webhookId", webhookId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.GET("/api/webhooks").
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().IsEmpty()
+		}
+	})
+
+	t.Run("invalid event type", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		response := e.POST("/api/webhooks").
+			WithCookie(TestCookieName, token).
+			WithJSON(map[string]interface{}{
+				"url":        "https://example.com/monetr",
+				"eventTypes": []string{"transaction.deleted"},
+			}).
+			Expect()
+
+		response.Status(http.StatusBadRequest)
+		response.JSON().Path("$.error").String().IsEqual("Invalid webhook: invalid event type: transaction.deleted")
+	})
+
+	t.Run("transactions create deliveries", func(t *testing.T) {
+		app, e := NewTestApplication(t)
+		user, password := fixtures.GivenIHaveABasicAccount(t, app.Clock)
+		link := fixtures.GivenIHaveAManualLink(t, app.Clock, user)
+		bank := fixtures.GivenIHaveABankAccount(t, app.Clock, &link, DepositoryBankAccountType, CheckingBankAccountSubType)
+		token := GivenILogin(t, e, user.Login.Email, password)
+
+		var webhookId string
+		{
+			response := e.POST("/api/webhooks").
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"url":        "https://example.com/monetr",
+					"eventTypes": []string{"transaction.created"},
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+			webhookId = response.JSON().Path("$.webhookId").String().Raw()
+		}
+
+		{
+			response := e.POST("/api/bank_accounts/{bankAccountId}/transactions").
+				WithPath("bankAccountId", bank.BankAccountId).
+				WithCookie(TestCookieName, token).
+				WithJSON(map[string]interface{}{
+					"name":   "Coffee",
+					"amount": 500,
+					"date":   app.Clock.Now(),
+				}).
+				Expect()
+
+			response.Status(http.StatusOK)
+		}
+
+		{
+			response := e.GET("/api/webhooks/{webhookId}/deliveries").
+				WithPath("webhookId", webhookId).
+				WithCookie(TestCookieName, token).
+				Expect()
+
+			response.Status(http.StatusOK)
+			response.JSON().Array().Length().IsEqual(1)
+			response.JSON().Path("$[0].eventType").IsEqual("transaction.created")
+			response.JSON().Path("$[0].status").IsEqual("pending")
+			response.JSON().Path("$[0].payload.data.name").IsEqual("Coffee")
+		}
+	})
+}
@@ -19,6 +19,10 @@ type Event struct {
 	Balance      int64           `json:"balance"`
 	Spending     []SpendingEvent `json:"spending"`
 	Funding      []FundingEvent  `json:"funding"`
+	// Adjustment is the sum of the one-off adjustments that happen on this date,
+	// it uses the same sign as Transaction.
+	Adjustment  int64        `json:"adjustment,omitempty"`
+	Adjustments []Adjustment `json:"adjustments,omitempty"`
 }
 
 type Forecast struct {
@@ -53,14 +57,33 @@ type forecasterBase struct {
 	currentBalance int64
 	funding        map[ID[FundingSchedule]]FundingInstructions
 	spending       map[ID[Spending]]SpendingInstructions
+	adjustments    []Adjustment
 }
 
 func NewForecaster(log *logrus.Entry, spending []Spending, funding []FundingSchedule) Forecaster {
+	return NewForecasterWithAdjustments(log, spending, funding, nil)
+}
+
+// NewForecasterWithAdjustments is the same as NewForecaster, but the provided
+// one-off adjustments are also included in the forecast.
+func NewForecasterWithAdjustments(
+	log *logrus.Entry,
+	spending []Spending,
+	funding []FundingSchedule,
+	adjustments []Adjustment,
+) Forecaster {
 	forecaster := &forecasterBase{
-		log:      log,
-		funding:  map[ID[FundingSchedule]]FundingInstructions{},
-		spending: map[ID[Spending]]SpendingInstructions{},
+		log:         log,
+		funding:     map[ID[FundingSchedule]]FundingInstructions{},
+		spending:    map[ID[Spending]]SpendingInstructions{},
+		adjustments: make([]Adjustment, len(adjustments)),
 	}
+	// Keep adjustments in a consistent order so that the output is the same no
+	// matter what order they were provided in.
+	copy(forecaster.adjustments, adjustments)
+	sort.SliceStable(forecaster.adjustments, func(i, j int) bool {
+		return forecaster.adjustments[i].Date.Before(forecaster.adjustments[j].Date)
+	})
 	for _, fundingSchedule := range funding {
 		forecaster.funding[fundingSchedule.FundingScheduleId] = NewFundingScheduleFundingInstructions(log, fundingSchedule)
 	}
@@ -191,6 +214,8 @@ func (f *forecasterBase) GetForecast(
 		}).
 		ToSlice(&forecast.Events)
 
+	f.addAdjustmentEvents(&forecast, start, end, timezone)
+
 	for i, event := range forecast.Events {
 		var previousBalance int64 = 0
 		if i == 0 {
@@ -0,0 +1,351 @@
+package forecast
+
+import (
+	"context"
+	"time"
+
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxGoalPlanFundingEvents is how many funding events the goal planner will
+// look at before giving up. This keeps tiny contributions towards large goals
+// from looping practically forever.
+const maxGoalPlanFundingEvents = 1000
+
+var (
+	ErrNotAGoal             = errors.New("spending object is not a goal")
+	ErrGoalPlanTooLong      = errors.Errorf("goal cannot be fully funded within %d contributions", maxGoalPlanFundingEvents)
+	ErrFundingScheduleEnded = errors.New("funding schedule does not have enough occurrences to fund the goal")
+	ErrInvalidContribution  = errors.New("contribution amount must be greater than 0")
+	ErrTargetDateInThePast  = errors.New("target date must be in the future")
+)
+
+// GoalPlan is the result of planning the contributions towards a goal. Amounts
+// are in the same units as the goal itself.
+type GoalPlan struct {
+	SpendingId        ID[Spending]        `json:"spendingId"`
+	FundingScheduleId ID[FundingSchedule] `json:"fundingScheduleId"`
+	// Remaining is how much still needs to be allocated to the goal before it is
+	// fully funded.
+	Remaining int64 `json:"remaining"`
+	// ContributionAmount is how much is allocated to the goal each time the
+	// funding schedule is processed.
+	ContributionAmount    int64 `json:"contributionAmount"`
+	NumberOfContributions int64 `json:"numberOfContributions"`
+	// TargetDate is the date of the contribution that fully funds the goal. It is
+	// nil when the goal cannot be funded by contributions alone.
+	TargetDate *time.Time `json:"targetDate"`
+	// EarliestDate is when the goal would be fully funded if the free-to-use
+	// balance was moved to the goal today and the contributions continued.
+	EarliestDate *time.Time `json:"earliestDate"`
+	// DueDate is the next recurrence of the goal, this is when the user wants the
+	// goal to be fully funded.
+	DueDate                    time.Time `json:"dueDate"`
+	ContributionsBeforeDueDate int64     `json:"contributionsBeforeDueDate"`
+	FreeToUse                  int64     `json:"freeToUse"`
+	// FreeToUseRequired is how much would need to be moved from the free-to-use
+	// balance for the goal to be fully funded by its due date.
+	FreeToUseRequired int64 `json:"freeToUseRequired"`
+	// IsFeasible is false when the goal cannot be fully funded by its due date
+	// even if the entire free-to-use balance was moved to it.
+	IsFeasible bool `json:"isFeasible"`
+	// Shortfall is how much the goal would still be missing on its due date
+	// after the free-to-use balance is used.
+	Shortfall int64 `json:"shortfall"`
+}
+
+type GoalPlanner interface {
+	// PlanByContribution solves for the date the goal will be fully funded if a
+	// fixed amount is contributed each time the funding schedule is processed.
+	PlanByContribution(ctx context.Context, contribution int64, now time.Time, timezone *time.Location) (*GoalPlan, error)
+	// PlanByTargetDate solves for the amount that must be contributed each time
+	// the funding schedule is processed for the goal to be fully funded by the
+	// provided date.
+	PlanByTargetDate(ctx context.Context, targetDate, now time.Time, timezone *time.Location) (*GoalPlan, error)
+}
+
+var (
+	_ GoalPlanner = &goalPlannerBase{}
+)
+
+type goalPlannerBase struct {
+	log             *logrus.Entry
+	goal            Spending
+	fundingSchedule FundingSchedule
+	funding         FundingInstructions
+	freeToUse       int64
+}
+
+// NewGoalPlanner returns a planner for the provided goal and the funding
+// schedule that contributes to it. The free-to-use balance is the amount in the
+// bank account that is not allocated to any spending object.
+func NewGoalPlanner(
+	log *logrus.Entry,
+	goal Spending,
+	fundingSchedule FundingSchedule,
+	freeToUse int64,
+) GoalPlanner {
+	return &goalPlannerBase{
+		log:             log,
+		goal:            goal,
+		fundingSchedule: fundingSchedule,
+		funding:         NewFundingScheduleFundingInstructions(log, fundingSchedule),
+		freeToUse:       myownsanity.Max(freeToUse, 0),
+	}
+}
+
+func (g *goalPlannerBase) PlanByContribution(
+	ctx context.Context,
+	contribution int64,
+	now time.Time,
+	timezone *time.Location,
+) (*GoalPlan, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if g.goal.SpendingType != SpendingTypeGoal {
+		return nil, errors.WithStack(ErrNotAGoal)
+	}
+	if contribution <= 0 {
+		return nil, errors.WithStack(ErrInvalidContribution)
+	}
+
+	plan := g.newPlan(contribution)
+	numberOfContributions, targetDate, err := g.fundingEventsToReach(span.Context(), plan.Remaining, contribution, now, timezone)
+	if err != nil {
+		return nil, err
+	}
+	plan.NumberOfContributions = numberOfContributions
+	plan.TargetDate = &targetDate
+
+	if err := g.evaluatePlan(span.Context(), plan, now, timezone); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (g *goalPlannerBase) PlanByTargetDate(
+	ctx context.Context,
+	targetDate, now time.Time,
+	timezone *time.Location,
+) (*GoalPlan, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if g.goal.SpendingType != SpendingTypeGoal {
+		return nil, errors.WithStack(ErrNotAGoal)
+	}
+	targetDate = util.Midnight(targetDate, timezone)
+	if !targetDate.After(now) {
+		return nil, errors.WithStack(ErrTargetDateInThePast)
+	}
+
+	// Count the funding events that happen on or before the target date, the
+	// remaining amount is then spread evenly across them. If the funding
+	// schedule ends before the target date then only its remaining occurrences
+	// are used.
+	var numberOfContributions int64
+	var lastContribution time.Time
+	err := g.walkFundingEvents(span.Context(), now, timezone, func(n int64, event FundingEvent) bool {
+		if event.Date.After(targetDate) {
+			return false
+		}
+		numberOfContributions = n
+		lastContribution = event.Date
+		return true
+	})
+	if err != nil && !errors.Is(err, ErrFundingScheduleEnded) {
+		return nil, err
+	}
+
+	plan := g.newPlan(0)
+	switch {
+	case plan.Remaining == 0:
+		today := util.Midnight(now, timezone)
+		plan.TargetDate = &today
+	case numberOfContributions > 0:
+		// Round up so that the goal is not left a few cents short.
+		plan.ContributionAmount = (plan.Remaining + numberOfContributions - 1) / numberOfContributions
+		plan.NumberOfContributions = numberOfContributions
+		plan.TargetDate = &lastContribution
+	default:
+		// There are no contributions before the target date, so the goal can only
+		// be funded by moving money from the free-to-use balance.
+	}
+
+	if err := g.evaluatePlan(span.Context(), plan, now, timezone); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (g *goalPlannerBase) newPlan(contribution int64) *GoalPlan {
+	return &GoalPlan{
+		SpendingId:         g.goal.SpendingId,
+		FundingScheduleId:  g.fundingSchedule.FundingScheduleId,
+		Remaining:          myownsanity.Max(g.goal.TargetAmount-g.goal.GetProgressAmount(), 0),
+		ContributionAmount: contribution,
+		FreeToUse:          g.freeToUse,
+	}
+}
+
+// evaluatePlan fills in the earliest date and whether or not the goal can be
+// fully funded by its due date with the planned contribution amount.
+func (g *goalPlannerBase) evaluatePlan(
+	ctx context.Context,
+	plan *GoalPlan,
+	now time.Time,
+	timezone *time.Location,
+) error {
+	plan.DueDate = util.Midnight(g.goal.NextRecurrence, timezone)
+
+	funded := int64(0)
+	if plan.ContributionAmount > 0 && plan.Remaining > 0 {
+		err := g.walkFundingEvents(ctx, now, timezone, func(n int64, event FundingEvent) bool {
+			if event.Date.After(plan.DueDate) {
+				return false
+			}
+			plan.ContributionsBeforeDueDate = n
+			return n*plan.ContributionAmount < plan.Remaining
+		})
+		// A funding schedule that ends before the due date just contributes fewer
+		// times, the rest would need to come from the free-to-use balance.
+		if err != nil && !errors.Is(err, ErrFundingScheduleEnded) {
+			return err
+		}
+		funded = myownsanity.Min(plan.ContributionsBeforeDueDate*plan.ContributionAmount, plan.Remaining)
+	}
+
+	plan.FreeToUseRequired = plan.Remaining - funded
+	plan.Shortfall = myownsanity.Max(plan.FreeToUseRequired-g.freeToUse, 0)
+	plan.IsFeasible = plan.Shortfall == 0
+
+	afterFreeToUse := plan.Remaining - g.freeToUse
+	switch {
+	case afterFreeToUse <= 0:
+		today := util.Midnight(now, timezone)
+		plan.EarliestDate = &today
+	case plan.ContributionAmount > 0:
+		_, earliest, err := g.fundingEventsToReach(ctx, afterFreeToUse, plan.ContributionAmount, now, timezone)
+		if err != nil {
+			return err
+		}
+		plan.EarliestDate = &earliest
+	}
+
+	return nil
+}
+
+// fundingEventsToReach returns how many contributions of the provided amount
+// are needed to allocate the remaining amount, and the date of the last one.
+func (g *goalPlannerBase) fundingEventsToReach(
+	ctx context.Context,
+	remaining, contribution int64,
+	now time.Time,
+	timezone *time.Location,
+) (int64, time.Time, error) {
+	if remaining <= 0 {
+		return 0, util.Midnight(now, timezone), nil
+	}
+	// Don't bother walking the funding events if the contribution is too small
+	// to ever reach the remaining amount.
+	if (remaining-1)/contribution+1 > maxGoalPlanFundingEvents {
+		return 0, time.Time{}, errors.WithStack(ErrGoalPlanTooLong)
+	}
+
+	var count int64
+	var date time.Time
+	err := g.walkFundingEvents(ctx, now, timezone, func(n int64, event FundingEvent) bool {
+		count, date = n, event.Date
+		return n*contribution < remaining
+	})
+	return count, date, err
+}
+
+// walkFundingEvents calls fn with each funding event after now in order, until
+// fn returns false. If fn still wants more events after
+// maxGoalPlanFundingEvents then ErrGoalPlanTooLong is returned. The events are
+// generated in a single pass over the rule of the funding schedule, rather than
+// searching for each one from the start of the rule.
+func (g *goalPlannerBase) walkFundingEvents(
+	ctx context.Context,
+	now time.Time,
+	timezone *time.Location,
+	fn func(n int64, event FundingEvent) bool,
+) error {
+	rule := g.fundingSchedule.RuleSet.Clone()
+	rule.DTStart(rule.GetDTStart().In(timezone))
+	// Funding schedules with a COUNT or UNTIL can run out of occurrences, there
+	// are no more funding events at all once that happens.
+	if rule.After(util.Midnight(now, timezone), false).IsZero() {
+		return errors.WithStack(ErrFundingScheduleEnded)
+	}
+
+	// The first event might be the already established next recurrence of the
+	// funding schedule, so that is still handled by the funding instructions.
+	first, err := g.funding.GetNextFundingEventAfter(ctx, now, timezone)
+	if err != nil {
+		return err
+	}
+	if !fn(1, first) {
+		return nil
+	}
+
+	next := rule.Iterator()
+	previous := first.Date
+	for n := int64(2); n <= maxGoalPlanFundingEvents; {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		original, ok := next()
+		if !ok {
+			return errors.WithStack(ErrFundingScheduleEnded)
+		}
+
+		event := g.fundingEvent(original, timezone)
+		// Skip the occurrences up to and including the first event.
+		if !event.Date.After(previous) {
+			continue
+		}
+
+		if !fn(n, event) {
+			return nil
+		}
+		previous = event.Date
+		n++
+	}
+
+	return errors.WithStack(ErrGoalPlanTooLong)
+}
+
+// fundingEvent returns the funding event for an occurrence of the funding
+// schedule's rule, moving it to the previous business day if the funding
+// schedule excludes weekends.
+func (g *goalPlannerBase) fundingEvent(original time.Time, timezone *time.Location) FundingEvent {
+	original = util.Midnight(original, timezone)
+	event := FundingEvent{
+		FundingScheduleId: g.fundingSchedule.FundingScheduleId,
+		Date:              original,
+		OriginalDate:      original,
+	}
+	if g.fundingSchedule.ExcludeWeekends {
+		switch original.Weekday() {
+		case time.Sunday:
+			event.Date = util.Midnight(original.AddDate(0, 0, -2), timezone)
+			event.WeekendAvoided = true
+		case time.Saturday:
+			event.Date = util.Midnight(original.AddDate(0, 0, -1), timezone)
+			event.WeekendAvoided = true
+		}
+	}
+
+	return event
+}
@@ -0,0 +1,169 @@
+package forecast
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoalPlanner(t *testing.T) {
+	timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
+	now := time.Date(2022, 9, 13, 0, 0, 1, 0, timezone).UTC()
+	log := testutils.GetLog(t)
+	// Funding events after now are on the 15th and the last day of each month,
+	// starting on 2022-09-15.
+	fundingSchedule := models.FundingSchedule{
+		FundingScheduleId: "fund_1",
+		RuleSet:           testutils.NewRuleSet(t, 2022, 9, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1"),
+		NextRecurrence:    time.Date(2022, 9, 15, 0, 0, 0, 0, timezone),
+	}
+	goal := models.Spending{
+		SpendingId:        "spnd_1",
+		FundingScheduleId: "fund_1",
+		SpendingType:      models.SpendingTypeGoal,
+		TargetAmount:      110000,
+		CurrentAmount:     6000,
+		UsedAmount:        4000,
+		NextRecurrence:    time.Date(2023, 1, 1, 0, 0, 0, 0, timezone),
+	}
+
+	t.Run("by contribution", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 0)
+		plan, err := planner.PlanByContribution(context.Background(), 20000, now, timezone)
+		assert.NoError(t, err, "should be able to plan the goal")
+		assert.EqualValues(t, 100000, plan.Remaining, "used and current amounts count towards the goal")
+		assert.EqualValues(t, 5, plan.NumberOfContributions)
+		assert.WithinDuration(t, time.Date(2022, 11, 15, 0, 0, 0, 0, timezone), *plan.TargetDate, 0)
+		assert.WithinDuration(t, time.Date(2022, 11, 15, 0, 0, 0, 0, timezone), *plan.EarliestDate, 0)
+		assert.True(t, plan.IsFeasible, "goal should be funded before it is due")
+		assert.Zero(t, plan.FreeToUseRequired)
+	})
+
+	t.Run("infeasible by contribution", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 5000)
+		plan, err := planner.PlanByContribution(context.Background(), 10000, now, timezone)
+		assert.NoError(t, err, "should be able to plan the goal")
+		assert.EqualValues(t, 10, plan.NumberOfContributions)
+		assert.WithinDuration(t, time.Date(2023, 1, 31, 0, 0, 0, 0, timezone), *plan.TargetDate, 0)
+		assert.EqualValues(t, 8, plan.ContributionsBeforeDueDate)
+		assert.EqualValues(t, 20000, plan.FreeToUseRequired)
+		assert.EqualValues(t, 15000, plan.Shortfall)
+		assert.False(t, plan.IsFeasible, "there is not enough free-to-use to fund the goal in time")
+	})
+
+	t.Run("feasible with free-to-use", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 30000)
+		plan, err := planner.PlanByContribution(context.Background(), 10000, now, timezone)
+		assert.NoError(t, err, "should be able to plan the goal")
+		assert.True(t, plan.IsFeasible, "free-to-use balance covers the difference")
+		assert.Zero(t, plan.Shortfall)
+		assert.WithinDuration(t, time.Date(2022, 12, 15, 0, 0, 0, 0, timezone), *plan.EarliestDate, 0)
+	})
+
+	t.Run("by target date", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 0)
+		plan, err := planner.PlanByTargetDate(context.Background(), time.Date(2022, 11, 1, 0, 0, 0, 0, timezone), now, timezone)
+		assert.NoError(t, err, "should be able to plan the goal")
+		assert.EqualValues(t, 4, plan.NumberOfContributions)
+		assert.EqualValues(t, 25000, plan.ContributionAmount)
+		assert.WithinDuration(t, time.Date(2022, 10, 31, 0, 0, 0, 0, timezone), *plan.TargetDate, 0)
+		assert.True(t, plan.IsFeasible)
+	})
+
+	t.Run("no contributions before the target date", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 0)
+		plan, err := planner.PlanByTargetDate(context.Background(), time.Date(2022, 9, 14, 0, 0, 0, 0, timezone), now, timezone)
+		assert.NoError(t, err, "should be able to plan the goal")
+		assert.Zero(t, plan.ContributionAmount)
+		assert.Nil(t, plan.TargetDate)
+		assert.EqualValues(t, 100000, plan.FreeToUseRequired)
+		assert.False(t, plan.IsFeasible)
+	})
+
+	t.Run("many contributions", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 0)
+		plan, err := planner.PlanByContribution(context.Background(), 100, now, timezone)
+		assert.NoError(t, err, "should be able to plan the goal")
+		assert.EqualValues(t, 1000, plan.NumberOfContributions)
+		assert.WithinDuration(t, time.Date(2064, 4, 30, 0, 0, 0, 0, timezone), *plan.TargetDate, 0)
+	})
+
+	t.Run("finite funding schedule by target date", func(t *testing.T) {
+		{ // Funding schedule with a COUNT
+			finite := fundingSchedule
+			finite.RuleSet = testutils.NewRuleSet(t, 2022, 9, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15;COUNT=3")
+			plan, err := NewGoalPlanner(log, goal, finite, 0).
+				PlanByTargetDate(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, timezone), now, timezone)
+			assert.NoError(t, err, "should use the remaining occurrences of the funding schedule")
+			assert.EqualValues(t, 3, plan.NumberOfContributions)
+			assert.EqualValues(t, 33334, plan.ContributionAmount, "should round up to fully fund the goal")
+			assert.WithinDuration(t, time.Date(2022, 11, 15, 0, 0, 0, 0, timezone), *plan.TargetDate, 0)
+			assert.EqualValues(t, 3, plan.ContributionsBeforeDueDate)
+			assert.True(t, plan.IsFeasible)
+		}
+
+		{ // Funding schedule with an UNTIL
+			finite := fundingSchedule
+			finite.RuleSet = testutils.NewRuleSet(t, 2022, 9, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15;UNTIL=20221020T000000Z")
+			plan, err := NewGoalPlanner(log, goal, finite, 0).
+				PlanByTargetDate(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, timezone), now, timezone)
+			assert.NoError(t, err, "should use the remaining occurrences of the funding schedule")
+			assert.EqualValues(t, 2, plan.NumberOfContributions)
+			assert.EqualValues(t, 50000, plan.ContributionAmount)
+			assert.WithinDuration(t, time.Date(2022, 10, 15, 0, 0, 0, 0, timezone), *plan.TargetDate, 0)
+			assert.True(t, plan.IsFeasible)
+		}
+
+		{ // Funding schedule that has already ended
+			ended := fundingSchedule
+			ended.RuleSet = testutils.NewRuleSet(t, 2022, 6, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15;COUNT=2")
+			ended.NextRecurrence = time.Date(2022, 7, 15, 0, 0, 0, 0, timezone)
+			plan, err := NewGoalPlanner(log, goal, ended, 30000).
+				PlanByTargetDate(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, timezone), now, timezone)
+			assert.NoError(t, err, "should still plan the goal using only free-to-use")
+			assert.Zero(t, plan.NumberOfContributions)
+			assert.Zero(t, plan.ContributionAmount)
+			assert.Nil(t, plan.TargetDate)
+			assert.EqualValues(t, 100000, plan.FreeToUseRequired)
+			assert.EqualValues(t, 70000, plan.Shortfall)
+			assert.False(t, plan.IsFeasible)
+
+			_, err = NewGoalPlanner(log, goal, ended, 0).
+				PlanByContribution(context.Background(), 10000, now, timezone)
+			assert.ErrorIs(t, err, ErrFundingScheduleEnded, "contributions cannot fund the goal")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		planner := NewGoalPlanner(log, goal, fundingSchedule, 0)
+		_, err := planner.PlanByContribution(context.Background(), 0, now, timezone)
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+
+		_, err = planner.PlanByTargetDate(context.Background(), now.AddDate(0, 0, -1), now, timezone)
+		assert.ErrorIs(t, err, ErrTargetDateInThePast)
+
+		_, err = planner.PlanByContribution(context.Background(), 1, now, timezone)
+		assert.ErrorIs(t, err, ErrGoalPlanTooLong)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = planner.PlanByContribution(ctx, 10000, now, timezone)
+		assert.ErrorIs(t, err, context.Canceled, "should stop walking funding events when the context is done")
+
+		ended := fundingSchedule
+		ended.RuleSet = testutils.NewRuleSet(t, 2022, 9, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15;COUNT=3")
+		_, err = NewGoalPlanner(log, goal, ended, 0).
+			PlanByContribution(context.Background(), 10000, now, timezone)
+		assert.ErrorIs(t, err, ErrFundingScheduleEnded)
+
+		expense := goal
+		expense.SpendingType = models.SpendingTypeExpense
+		_, err = NewGoalPlanner(log, expense, fundingSchedule, 0).
+			PlanByContribution(context.Background(), 100, now, timezone)
+		assert.ErrorIs(t, err, ErrNotAGoal)
+	})
+}
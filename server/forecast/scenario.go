@@ -0,0 +1,243 @@
+package forecast
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// Adjustment is a one-off income or expense that is not represented by any
+// spending object or funding schedule. Like transactions, a positive amount is
+// money leaving the account and a negative amount is income.
+type Adjustment struct {
+	Name   string    `json:"name"`
+	Date   time.Time `json:"date"`
+	Amount int64     `json:"amount"`
+}
+
+// Scenario describes hypothetical changes to the spending objects and funding
+// schedules of a bank account. Applying a scenario never changes anything that
+// has been persisted, it only produces the inputs for a forecaster.
+type Scenario struct {
+	AddFundingSchedules    []FundingSchedule
+	UpdateFundingSchedules []FundingSchedule
+	RemoveFundingSchedules []ID[FundingSchedule]
+	AddSpending            []Spending
+	UpdateSpending         []Spending
+	RemoveSpending         []ID[Spending]
+	Adjustments            []Adjustment
+}
+
+// Apply returns a copy of the provided spending objects and funding schedules
+// with the changes of the scenario applied to them. Objects that are added
+// without an ID are given a new one, and funding schedules without a next
+// recurrence have one calculated relative to now. An error is returned if the
+// scenario references objects that do not exist or would leave a spending
+// object without its funding schedule.
+func (s Scenario) Apply(
+	ctx context.Context,
+	now time.Time,
+	timezone *time.Location,
+	spending []Spending,
+	funding []FundingSchedule,
+) ([]Spending, []FundingSchedule, error) {
+	fundingSchedules := make(map[ID[FundingSchedule]]FundingSchedule, len(funding))
+	fundingOrder := make([]ID[FundingSchedule], 0, len(funding))
+	for _, item := range funding {
+		fundingSchedules[item.FundingScheduleId] = item
+		fundingOrder = append(fundingOrder, item.FundingScheduleId)
+	}
+
+	spendingItems := make(map[ID[Spending]]Spending, len(spending))
+	spendingOrder := make([]ID[Spending], 0, len(spending))
+	for _, item := range spending {
+		spendingItems[item.SpendingId] = item
+		spendingOrder = append(spendingOrder, item.SpendingId)
+	}
+
+	for _, fundingScheduleId := range s.RemoveFundingSchedules {
+		if _, ok := fundingSchedules[fundingScheduleId]; !ok {
+			return nil, nil, errors.Errorf("funding schedule does not exist: %s", fundingScheduleId)
+		}
+		delete(fundingSchedules, fundingScheduleId)
+	}
+
+	for _, item := range s.UpdateFundingSchedules {
+		if _, ok := fundingSchedules[item.FundingScheduleId]; !ok {
+			return nil, nil, errors.Errorf("funding schedule does not exist: %s", item.FundingScheduleId)
+		}
+		if err := prepareScenarioFundingSchedule(now, timezone, &item); err != nil {
+			return nil, nil, err
+		}
+		fundingSchedules[item.FundingScheduleId] = item
+	}
+
+	for _, item := range s.AddFundingSchedules {
+		if item.FundingScheduleId.IsZero() {
+			item.FundingScheduleId = NewID(&item)
+		} else if _, ok := fundingSchedules[item.FundingScheduleId]; ok {
+			return nil, nil, errors.Errorf("funding schedule already exists: %s", item.FundingScheduleId)
+		}
+		if err := prepareScenarioFundingSchedule(now, timezone, &item); err != nil {
+			return nil, nil, err
+		}
+		fundingSchedules[item.FundingScheduleId] = item
+		fundingOrder = append(fundingOrder, item.FundingScheduleId)
+	}
+
+	for _, spendingId := range s.RemoveSpending {
+		if _, ok := spendingItems[spendingId]; !ok {
+			return nil, nil, errors.Errorf("spending object does not exist: %s", spendingId)
+		}
+		delete(spendingItems, spendingId)
+	}
+
+	for _, item := range s.UpdateSpending {
+		if _, ok := spendingItems[item.SpendingId]; !ok {
+			return nil, nil, errors.Errorf("spending object does not exist: %s", item.SpendingId)
+		}
+		if err := validateScenarioSpending(item); err != nil {
+			return nil, nil, err
+		}
+		spendingItems[item.SpendingId] = item
+	}
+
+	for _, item := range s.AddSpending {
+		if item.SpendingId.IsZero() {
+			item.SpendingId = NewID(&item)
+		} else if _, ok := spendingItems[item.SpendingId]; ok {
+			return nil, nil, errors.Errorf("spending object already exists: %s", item.SpendingId)
+		}
+		if err := validateScenarioSpending(item); err != nil {
+			return nil, nil, err
+		}
+		spendingItems[item.SpendingId] = item
+		spendingOrder = append(spendingOrder, item.SpendingId)
+	}
+
+	resultFunding := make([]FundingSchedule, 0, len(fundingSchedules))
+	for _, fundingScheduleId := range fundingOrder {
+		if item, ok := fundingSchedules[fundingScheduleId]; ok {
+			resultFunding = append(resultFunding, item)
+		}
+	}
+
+	resultSpending := make([]Spending, 0, len(spendingItems))
+	for _, spendingId := range spendingOrder {
+		item, ok := spendingItems[spendingId]
+		if !ok {
+			continue
+		}
+
+		// The forecaster cannot handle spending objects without a funding
+		// schedule, so this needs to be caught here.
+		if _, ok := fundingSchedules[item.FundingScheduleId]; !ok {
+			return nil, nil, errors.Errorf(
+				"spending object %s requires funding schedule %s which does not exist in the scenario",
+				item.SpendingId, item.FundingScheduleId,
+			)
+		}
+		resultSpending = append(resultSpending, item)
+	}
+
+	return resultSpending, resultFunding, nil
+}
+
+func prepareScenarioFundingSchedule(
+	now time.Time,
+	timezone *time.Location,
+	fundingSchedule *FundingSchedule,
+) error {
+	if fundingSchedule.RuleSet == nil {
+		return errors.Errorf("funding schedule must have a rule set: %s", fundingSchedule.FundingScheduleId)
+	}
+
+	if !fundingSchedule.NextRecurrence.IsZero() {
+		return nil
+	}
+
+	// The funding schedule has never been processed, so its first occurrence is
+	// the first one after now. Schedules added in a scenario usually start in the
+	// future, so this cannot rely on a previous occurrence like the funding
+	// instructions do.
+	rule := fundingSchedule.RuleSet.Clone()
+	rule.DTStart(rule.GetDTStart().In(timezone))
+	original := rule.After(now.In(timezone), true)
+	if original.IsZero() {
+		return errors.Errorf("funding schedule does not have any occurrences after now: %s", fundingSchedule.FundingScheduleId)
+	}
+	original = util.Midnight(original, timezone)
+
+	next := original
+	if fundingSchedule.ExcludeWeekends {
+		switch next.Weekday() {
+		case time.Sunday:
+			next = util.Midnight(next.AddDate(0, 0, -2), timezone)
+		case time.Saturday:
+			next = util.Midnight(next.AddDate(0, 0, -1), timezone)
+		}
+	}
+
+	fundingSchedule.NextRecurrence = next
+	fundingSchedule.NextRecurrenceOriginal = original
+
+	return nil
+}
+
+func validateScenarioSpending(spending Spending) error {
+	switch {
+	case spending.TargetAmount <= 0:
+		return errors.Errorf("spending object target amount must be greater than 0: %s", spending.SpendingId)
+	case spending.CurrentAmount < 0:
+		return errors.Errorf("spending object current amount cannot be less than 0: %s", spending.SpendingId)
+	case spending.NextRecurrence.IsZero():
+		return errors.Errorf("spending object must have a next recurrence: %s", spending.SpendingId)
+	case spending.SpendingType == SpendingTypeExpense && spending.RuleSet == nil:
+		return errors.Errorf("expense spending must have a recurrence rule: %s", spending.SpendingId)
+	case spending.SpendingType == SpendingTypeGoal && spending.RuleSet != nil:
+		return errors.Errorf("goal spending must not have a recurrence rule: %s", spending.SpendingId)
+	default:
+		return nil
+	}
+}
+
+// addAdjustmentEvents merges the one-off adjustments of the forecaster into the
+// events of the provided forecast. Adjustments that happen on the same day as
+// an existing event are added to that event, otherwise a new event is created.
+// This must be called before the running balances are calculated.
+func (f *forecasterBase) addAdjustmentEvents(
+	forecast *Forecast,
+	start, end time.Time,
+	timezone *time.Location,
+) {
+	start = util.Midnight(start, timezone)
+	for _, adjustment := range f.adjustments {
+		date := util.Midnight(adjustment.Date, timezone)
+		if date.Before(start) || !date.Before(end) {
+			continue
+		}
+		adjustment.Date = date.UTC()
+
+		index := sort.Search(len(forecast.Events), func(i int) bool {
+			return !forecast.Events[i].Date.Before(adjustment.Date)
+		})
+		if index == len(forecast.Events) || !forecast.Events[index].Date.Equal(adjustment.Date) {
+			forecast.Events = append(forecast.Events, Event{})
+			copy(forecast.Events[index+1:], forecast.Events[index:])
+			forecast.Events[index] = Event{
+				Date:     adjustment.Date,
+				Spending: []SpendingEvent{},
+				Funding:  []FundingEvent{},
+			}
+		}
+
+		event := &forecast.Events[index]
+		event.Delta -= adjustment.Amount
+		event.Adjustment += adjustment.Amount
+		event.Adjustments = append(event.Adjustments, adjustment)
+	}
+}
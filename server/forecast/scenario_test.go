@@ -0,0 +1,191 @@
+package forecast
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenario_Apply(t *testing.T) {
+	timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
+	now := time.Date(2022, 9, 13, 0, 0, 1, 0, timezone).UTC()
+	fundingRule := testutils.NewRuleSet(t, 2022, 9, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+	spendingRule := testutils.NewRuleSet(t, 2022, 10, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+
+	fundingSchedules := []models.FundingSchedule{
+		{
+			FundingScheduleId: "fund_1",
+			RuleSet:           fundingRule,
+			NextRecurrence:    time.Date(2022, 9, 15, 0, 0, 0, 0, timezone),
+		},
+	}
+	spending := []models.Spending{
+		{
+			SpendingId:        "spnd_1",
+			FundingScheduleId: "fund_1",
+			SpendingType:      models.SpendingTypeExpense,
+			TargetAmount:      5000,
+			NextRecurrence:    time.Date(2022, 10, 8, 0, 0, 0, 0, timezone),
+			RuleSet:           spendingRule,
+		},
+		{
+			SpendingId:        "spnd_2",
+			FundingScheduleId: "fund_1",
+			SpendingType:      models.SpendingTypeGoal,
+			TargetAmount:      100000,
+			NextRecurrence:    time.Date(2023, 9, 1, 0, 0, 0, 0, timezone),
+		},
+	}
+
+	t.Run("add, update and remove", func(t *testing.T) {
+		updated := spending[0]
+		updated.TargetAmount = 7500
+		scenario := Scenario{
+			AddFundingSchedules: []models.FundingSchedule{
+				{
+					FundingScheduleId: "fund_2",
+					RuleSet:           testutils.NewRuleSet(t, 2022, 9, 16, timezone, "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR"),
+				},
+			},
+			AddSpending: []models.Spending{
+				{
+					FundingScheduleId: "fund_2",
+					SpendingType:      models.SpendingTypeExpense,
+					TargetAmount:      35000,
+					NextRecurrence:    time.Date(2022, 10, 1, 0, 0, 0, 0, timezone),
+					RuleSet:           testutils.NewRuleSet(t, 2022, 10, 1, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=1"),
+				},
+			},
+			UpdateSpending: []models.Spending{updated},
+			RemoveSpending: []models.ID[models.Spending]{"spnd_2"},
+		}
+
+		resultSpending, resultFunding, err := scenario.Apply(context.Background(), now, timezone, spending, fundingSchedules)
+		assert.NoError(t, err, "should apply the scenario")
+		assert.Len(t, resultFunding, 2, "should have the existing and the added funding schedule")
+		assert.Equal(t, time.Date(2022, 9, 16, 0, 0, 0, 0, timezone), resultFunding[1].NextRecurrence, "added funding schedule should have its first occurrence as the next recurrence")
+		assert.Len(t, resultSpending, 2, "should have the updated and the added spending object")
+		assert.EqualValues(t, 7500, resultSpending[0].TargetAmount, "spending object should be updated")
+		assert.NotEmpty(t, resultSpending[1].SpendingId, "added spending object should be given an ID")
+		assert.EqualValues(t, 5000, spending[0].TargetAmount, "the original spending object should not change")
+	})
+
+	t.Run("add funding schedule without future occurrences", func(t *testing.T) {
+		scenario := Scenario{
+			AddFundingSchedules: []models.FundingSchedule{
+				{
+					FundingScheduleId: "fund_2",
+					RuleSet:           testutils.NewRuleSet(t, 2022, 9, 2, timezone, "FREQ=WEEKLY;INTERVAL=1;COUNT=1"),
+				},
+			},
+		}
+		_, _, err := scenario.Apply(context.Background(), now, timezone, spending, fundingSchedules)
+		assert.EqualError(t, err, "funding schedule does not have any occurrences after now: fund_2")
+	})
+
+	t.Run("update missing spending", func(t *testing.T) {
+		scenario := Scenario{
+			UpdateSpending: []models.Spending{
+				{
+					SpendingId:        "spnd_bogus",
+					FundingScheduleId: "fund_1",
+					SpendingType:      models.SpendingTypeGoal,
+					TargetAmount:      100,
+					NextRecurrence:    now,
+				},
+			},
+		}
+		_, _, err := scenario.Apply(context.Background(), now, timezone, spending, fundingSchedules)
+		assert.EqualError(t, err, "spending object does not exist: spnd_bogus")
+	})
+
+	t.Run("remove funding schedule that is still used", func(t *testing.T) {
+		scenario := Scenario{
+			RemoveFundingSchedules: []models.ID[models.FundingSchedule]{"fund_1"},
+			RemoveSpending:         []models.ID[models.Spending]{"spnd_2"},
+		}
+		_, _, err := scenario.Apply(context.Background(), now, timezone, spending, fundingSchedules)
+		assert.EqualError(t, err, "spending object spnd_1 requires funding schedule fund_1 which does not exist in the scenario")
+	})
+}
+
+func TestForecasterBase_GetForecastWithAdjustments(t *testing.T) {
+	timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
+	fundingRule := testutils.NewRuleSet(t, 2022, 9, 15, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1")
+	spendingRule := testutils.NewRuleSet(t, 2022, 10, 8, timezone, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8")
+	now := time.Date(2022, 9, 13, 0, 0, 1, 0, timezone).UTC()
+	end := time.Date(2022, 11, 1, 0, 0, 0, 0, timezone).UTC()
+	log := testutils.GetLog(t)
+
+	fundingSchedules := []models.FundingSchedule{
+		{
+			FundingScheduleId: "fund_1",
+			RuleSet:           fundingRule,
+			NextRecurrence:    time.Date(2022, 9, 15, 0, 0, 0, 0, timezone),
+		},
+	}
+	spending := []models.Spending{
+		{
+			SpendingId:        "spnd_1",
+			FundingScheduleId: "fund_1",
+			SpendingType:      models.SpendingTypeExpense,
+			TargetAmount:      5000,
+			NextRecurrence:    time.Date(2022, 10, 8, 0, 0, 0, 0, timezone),
+			RuleSet:           spendingRule,
+		},
+	}
+	adjustments := []Adjustment{
+		{
+			Name:   "Tax refund",
+			Date:   time.Date(2022, 9, 20, 12, 0, 0, 0, timezone),
+			Amount: -20000,
+		},
+		{
+			Name:   "Car repair",
+			Date:   time.Date(2022, 9, 15, 0, 0, 0, 0, timezone),
+			Amount: 15000,
+		},
+		{
+			Name:   "Too late",
+			Date:   time.Date(2022, 12, 1, 0, 0, 0, 0, timezone),
+			Amount: 100,
+		},
+	}
+
+	baseline, err := NewForecaster(log, spending, fundingSchedules).
+		GetForecast(context.Background(), now, end, timezone)
+	assert.NoError(t, err, "should be able to forecast the baseline")
+
+	result, err := NewForecasterWithAdjustments(log, spending, fundingSchedules, adjustments).
+		GetForecast(context.Background(), now, end, timezone)
+	assert.NoError(t, err, "should be able to forecast with adjustments")
+
+	assert.Equal(t, baseline.EndingBalance+5000, result.EndingBalance, "adjustments within the window should change the ending balance")
+	assert.Len(t, result.Events, len(baseline.Events)+1, "the tax refund should be a new event")
+
+	var repair, refund *Event
+	for i := range result.Events {
+		switch date := result.Events[i].Date; {
+		case date.Equal(time.Date(2022, 9, 15, 0, 0, 0, 0, timezone)):
+			repair = &result.Events[i]
+		case date.Equal(time.Date(2022, 9, 20, 0, 0, 0, 0, timezone)):
+			refund = &result.Events[i]
+		}
+		if i > 0 {
+			assert.True(t, result.Events[i-1].Date.Before(result.Events[i].Date), "events must remain in order")
+		}
+	}
+	if assert.NotNil(t, repair, "car repair should be merged with the funding event") {
+		assert.EqualValues(t, 15000, repair.Adjustment)
+		assert.NotEmpty(t, repair.Spending, "the funding event should still have its contributions")
+	}
+	if assert.NotNil(t, refund, "tax refund should have its own event") {
+		assert.EqualValues(t, -20000, refund.Adjustment)
+		assert.EqualValues(t, 20000, refund.Delta)
+		assert.Len(t, refund.Adjustments, 1)
+	}
+}
@@ -0,0 +1,166 @@
+package camt
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Balance type codes from the ISO 20022 external code sets that monetr cares
+// about.
+const (
+	OpeningBookedBalanceCode    = "OPBD"
+	ClosingBookedBalanceCode    = "CLBD"
+	ClosingAvailableBalanceCode = "CLAV"
+)
+
+// Entry status codes. Older versions of the message use the code as the value
+// of the status element directly, newer versions nest it in a Cd element.
+const (
+	BookedStatus      = "BOOK"
+	PendingStatus     = "PDNG"
+	InformationStatus = "INFO"
+)
+
+const (
+	CreditIndicator = "CRDT"
+	DebitIndicator  = "DBIT"
+)
+
+// Document is the subset of a camt.053 BankToCustomerStatement message that is
+// needed to import transactions and balances. Elements are matched without
+// their namespace so that every version of the message can be read.
+type Document struct {
+	XMLName                 xml.Name                 `xml:"Document"`
+	BankToCustomerStatement *BankToCustomerStatement `xml:"BkToCstmrStmt"`
+}
+
+type BankToCustomerStatement struct {
+	Statements []Statement `xml:"Stmt"`
+}
+
+type Statement struct {
+	Id        string    `xml:"Id"`
+	CreatedAt string    `xml:"CreDtTm"`
+	Account   Account   `xml:"Acct"`
+	Balances  []Balance `xml:"Bal"`
+	Entries   []Entry   `xml:"Ntry"`
+}
+
+type Account struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+// Identifier returns the IBAN of the account, or the other identifier if the
+// account does not have an IBAN.
+func (a Account) Identifier() string {
+	if iban := strings.TrimSpace(a.IBAN); iban != "" {
+		return iban
+	}
+
+	return strings.TrimSpace(a.Other)
+}
+
+type Amount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type DateAndTime struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type Balance struct {
+	Code        string      `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      Amount      `xml:"Amt"`
+	CreditDebit string      `xml:"CdtDbtInd"`
+	Date        DateAndTime `xml:"Dt"`
+}
+
+type Status struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+func (s Status) String() string {
+	if code := strings.TrimSpace(s.Code); code != "" {
+		return strings.ToUpper(code)
+	}
+
+	return strings.ToUpper(strings.TrimSpace(s.Value))
+}
+
+type Entry struct {
+	Reference             string               `xml:"NtryRef"`
+	Amount                Amount               `xml:"Amt"`
+	CreditDebit           string               `xml:"CdtDbtInd"`
+	Status                Status               `xml:"Sts"`
+	BookingDate           DateAndTime          `xml:"BookgDt"`
+	ValueDate             DateAndTime          `xml:"ValDt"`
+	ServicerReference     string               `xml:"AcctSvcrRef"`
+	Details               []TransactionDetails `xml:"NtryDtls>TxDtls"`
+	AdditionalInformation string               `xml:"AddtlNtryInf"`
+}
+
+type TransactionDetails struct {
+	ServicerReference string `xml:"Refs>AcctSvcrRef"`
+	EndToEndId        string `xml:"Refs>EndToEndId"`
+	TransactionId     string `xml:"Refs>TxId"`
+	// Versions 8 and later nest the name of the related parties in a Pty
+	// element, earlier versions do not.
+	DebtorName            string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPartyName       string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	CreditorName          string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPartyName     string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Unstructured          []string `xml:"RmtInf>Ustrd"`
+	AdditionalInformation string   `xml:"AddtlTxInf"`
+}
+
+func Parse(reader io.Reader) (*Document, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read CAMT buffer")
+	}
+
+	var document Document
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, errors.Wrap(err, "failed to parse CAMT")
+	}
+
+	if document.BankToCustomerStatement == nil || len(document.BankToCustomerStatement.Statements) == 0 {
+		return nil, errors.New("CAMT file does not contain a bank to customer statement")
+	}
+
+	return &document, nil
+}
+
+// ParseDate parses either the date or the date time of the provided value.
+// Dates are interpreted in the provided timezone, date times are only
+// interpreted in the provided timezone if they do not include an offset.
+func ParseDate(input DateAndTime, timezone *time.Location) (time.Time, error) {
+	if value := strings.TrimSpace(input.Date); value != "" {
+		// ISO dates are allowed to include an offset, but it is not useful for a
+		// date without a time.
+		if len(value) > len("2006-01-02") {
+			value = value[:len("2006-01-02")]
+		}
+		result, err := time.ParseInLocation("2006-01-02", value, timezone)
+		return result, errors.Wrapf(err, "failed to parse CAMT date [%s]", input.Date)
+	}
+
+	if value := strings.TrimSpace(input.DateTime); value != "" {
+		if result, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return result, nil
+		}
+		result, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", value, timezone)
+		return result, errors.Wrapf(err, "failed to parse CAMT date time [%s]", input.DateTime)
+	}
+
+	return time.Time{}, errors.New("CAMT date is blank")
+}
@@ -0,0 +1,102 @@
+package camt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	timezone, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err, "must load timezone")
+
+	t.Run("date", func(t *testing.T) {
+		result, err := ParseDate(DateAndTime{Date: "2025-01-02"}, timezone)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, timezone), result)
+	})
+
+	t.Run("date with offset", func(t *testing.T) {
+		result, err := ParseDate(DateAndTime{Date: "2025-01-02+01:00"}, timezone)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, timezone), result)
+	})
+
+	t.Run("date time with offset", func(t *testing.T) {
+		result, err := ParseDate(DateAndTime{DateTime: "2025-01-04T14:30:00+01:00"}, time.UTC)
+		assert.NoError(t, err)
+		assert.True(t, time.Date(2025, 1, 4, 13, 30, 0, 0, time.UTC).Equal(result))
+	})
+
+	t.Run("date time without offset", func(t *testing.T) {
+		result, err := ParseDate(DateAndTime{DateTime: "2025-01-04T14:30:00.123"}, timezone)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 4, 14, 30, 0, 123000000, timezone), result)
+	})
+
+	t.Run("blank", func(t *testing.T) {
+		result, err := ParseDate(DateAndTime{}, timezone)
+		assert.EqualError(t, err, "CAMT date is blank")
+		assert.True(t, result.IsZero(), "date returned must be zero")
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("version 2", func(t *testing.T) {
+		reader := bytes.NewReader(GetFixtures(t, "sample-v02.xml"))
+
+		result, err := Parse(reader)
+		assert.NoError(t, err, "must not return an error parsing known valid file")
+		require.NotNil(t, result, "resulting CAMT object should not be nil")
+		require.Len(t, result.BankToCustomerStatement.Statements, 1)
+
+		statement := result.BankToCustomerStatement.Statements[0]
+		assert.Equal(t, "DE89370400440532013000", statement.Account.Identifier())
+		assert.Equal(t, "EUR", statement.Account.Currency)
+		assert.Len(t, statement.Balances, 3)
+		require.Len(t, statement.Entries, 4)
+
+		entry := statement.Entries[0]
+		assert.Equal(t, "125.43", entry.Amount.Value)
+		assert.Equal(t, "EUR", entry.Amount.Currency)
+		assert.Equal(t, BookedStatus, entry.Status.String())
+		require.Len(t, entry.Details, 1)
+		assert.Equal(t, "REWE Markt GmbH", entry.Details[0].CreditorName)
+		assert.Equal(t, []string{"Kartenzahlung", "REWE SAGT DANKE"}, entry.Details[0].Unstructured)
+		assert.Equal(t, PendingStatus, statement.Entries[2].Status.String())
+	})
+
+	t.Run("version 8", func(t *testing.T) {
+		reader := bytes.NewReader(GetFixtures(t, "sample-v08.xml"))
+
+		result, err := Parse(reader)
+		assert.NoError(t, err, "must not return an error parsing known valid file")
+		require.NotNil(t, result, "resulting CAMT object should not be nil")
+		require.Len(t, result.BankToCustomerStatement.Statements, 2)
+
+		statement := result.BankToCustomerStatement.Statements[0]
+		assert.Equal(t, "0532013000", statement.Account.Identifier(), "should fall back to the other identifier")
+		require.Len(t, statement.Entries, 1)
+		assert.Equal(t, BookedStatus, statement.Entries[0].Status.String(), "nested status codes should be read")
+		assert.Equal(t, "SBB CFF FFS", statement.Entries[0].Details[0].CreditorPartyName)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		reader := bytes.NewReader(GetFixtures(t, "invalid.xml"))
+
+		result, err := Parse(reader)
+		assert.Error(t, err, "a json file is not a CAMT file")
+		assert.Nil(t, result)
+	})
+
+	t.Run("not a statement", func(t *testing.T) {
+		reader := bytes.NewReader([]byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.02"><BkToCstmrAcctRpt/></Document>`))
+
+		result, err := Parse(reader)
+		assert.EqualError(t, err, "CAMT file does not contain a bank to customer statement")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,192 @@
+package camt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/pkg/errors"
+)
+
+// notProvided is used by some institutions in place of an end to end id when
+// the payer did not provide one.
+const notProvided = "NOTPROVIDED"
+
+// Translate converts a parsed camt.053 document into a statement. A document
+// can include several statements, for example one for each day, but they must
+// all be for the same account. Informational entries are not booked to the
+// account and are skipped. The closing balances of the latest statement are
+// used.
+func Translate(document *Document, timezone *time.Location) (*formats.Statement, error) {
+	statements := document.BankToCustomerStatement.Statements
+	accountId := statements[0].Account.Identifier()
+	result := &formats.Statement{
+		Transactions: make([]formats.StatementTransaction, 0),
+	}
+
+	identifiers := formats.NewIdentifierGenerator()
+	for _, statement := range statements {
+		if statement.Account.Identifier() != accountId {
+			return nil, errors.New("CAMT file contains statements for more than one account, only one account can be imported at a time")
+		}
+
+		if result.Currency == "" {
+			result.Currency = strings.ToUpper(strings.TrimSpace(statement.Account.Currency))
+		}
+
+		for _, balance := range statement.Balances {
+			var target **formats.StatementBalance
+			switch strings.ToUpper(strings.TrimSpace(balance.Code)) {
+			case ClosingBookedBalanceCode:
+				target = &result.LedgerBalance
+			case ClosingAvailableBalanceCode:
+				target = &result.AvailableBalance
+			default:
+				continue
+			}
+
+			date, err := ParseDate(balance.Date, timezone)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to translate CAMT balance")
+			}
+
+			// When a document includes several statements, keep the latest
+			// balance.
+			if *target != nil && (*target).Date.After(date) {
+				continue
+			}
+
+			amount, err := signedAmount(balance.Amount, balance.CreditDebit)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to translate CAMT balance")
+			}
+
+			*target = &formats.StatementBalance{
+				Amount: amount,
+				Date:   date,
+			}
+			if result.Currency == "" {
+				result.Currency = strings.ToUpper(strings.TrimSpace(balance.Amount.Currency))
+			}
+		}
+
+		for i, entry := range statement.Entries {
+			status := entry.Status.String()
+			if status == InformationStatus {
+				continue
+			}
+
+			transaction, err := translateEntry(entry, identifiers, timezone)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to translate CAMT entry %d of statement [%s]", i+1, statement.Id)
+			}
+			transaction.IsPending = status == PendingStatus
+			result.Transactions = append(result.Transactions, *transaction)
+
+			if result.Currency == "" {
+				result.Currency = strings.ToUpper(strings.TrimSpace(entry.Amount.Currency))
+			}
+		}
+	}
+
+	// Not every institution includes a closing available balance, for a
+	// checking account the booked balance is the closest thing to it.
+	if result.AvailableBalance == nil && result.LedgerBalance != nil {
+		balance := *result.LedgerBalance
+		result.AvailableBalance = &balance
+	}
+
+	return result, nil
+}
+
+func translateEntry(
+	entry Entry,
+	identifiers *formats.IdentifierGenerator,
+	timezone *time.Location,
+) (*formats.StatementTransaction, error) {
+	dateInput := entry.BookingDate
+	if dateInput.Date == "" && dateInput.DateTime == "" {
+		dateInput = entry.ValueDate
+	}
+	date, err := ParseDate(dateInput, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := signedAmount(entry.Amount, entry.CreditDebit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Batch bookings can have several transaction details for a single entry,
+	// the entry is still a single transaction so only the first is used.
+	var details TransactionDetails
+	if len(entry.Details) > 0 {
+		details = entry.Details[0]
+	}
+
+	remittance := strings.TrimSpace(strings.Join(details.Unstructured, " "))
+	memo := myownsanity.CoalesceStrings(
+		remittance,
+		details.AdditionalInformation,
+		entry.AdditionalInformation,
+	)
+
+	// The name of the transaction is the other party. For a debit that is the
+	// creditor being paid, and for a credit it is the debtor paying.
+	var counterparty string
+	if strings.EqualFold(strings.TrimSpace(entry.CreditDebit), DebitIndicator) {
+		counterparty = myownsanity.CoalesceStrings(details.CreditorName, details.CreditorPartyName)
+	} else {
+		counterparty = myownsanity.CoalesceStrings(details.DebtorName, details.DebtorPartyName)
+	}
+	name := strings.TrimSpace(myownsanity.CoalesceStrings(counterparty, memo))
+	if name == "" {
+		return nil, errors.New("entry does not have a counterparty or remittance information")
+	}
+
+	endToEndId := details.EndToEndId
+	if strings.EqualFold(strings.TrimSpace(endToEndId), notProvided) {
+		endToEndId = ""
+	}
+	id := strings.TrimSpace(myownsanity.CoalesceStrings(
+		entry.ServicerReference,
+		details.ServicerReference,
+		details.TransactionId,
+		entry.Reference,
+		endToEndId,
+	))
+	if id == "" {
+		id = identifiers.Next(date.Format("2006-01-02"), amount, name)
+	}
+
+	return &formats.StatementTransaction{
+		Id:     id,
+		Amount: amount,
+		Date:   date,
+		Name:   name,
+		Memo:   strings.TrimSpace(memo),
+	}, nil
+}
+
+// signedAmount returns the amount with a negative sign if it is a debit.
+// Amounts in CAMT files are always positive and the direction is indicated
+// separately. They always use a "." as the decimal separator and may have more
+// fractional digits than the currency, they are rounded when they are
+// imported.
+func signedAmount(amount Amount, creditDebit string) (string, error) {
+	value, err := formats.ParseDecimalAmount(amount.Value)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(creditDebit)) {
+	case CreditIndicator:
+		return value, nil
+	case DebitIndicator:
+		return "-" + strings.TrimPrefix(value, "-"), nil
+	default:
+		return "", errors.Errorf("credit debit indicator [%s] is not valid", creditDebit)
+	}
+}
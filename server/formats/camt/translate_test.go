@@ -0,0 +1,124 @@
+package camt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate(t *testing.T) {
+	timezone, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err, "must load timezone")
+
+	t.Run("version 2", func(t *testing.T) {
+		document, err := Parse(bytes.NewReader(GetFixtures(t, "sample-v02.xml")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(document, timezone)
+		assert.NoError(t, err, "must translate file")
+		require.NotNil(t, statement)
+		assert.Equal(t, "EUR", statement.Currency)
+		assert.Equal(t, &formats.StatementBalance{
+			Amount: "3374.57",
+			Date:   time.Date(2025, 1, 4, 0, 0, 0, 0, timezone),
+		}, statement.LedgerBalance, "closing booked balance should be the ledger balance")
+		assert.Equal(t, &formats.StatementBalance{
+			Amount: "3350.07",
+			Date:   time.Date(2025, 1, 4, 0, 0, 0, 0, timezone),
+		}, statement.AvailableBalance, "closing available balance should be the available balance")
+
+		assert.Equal(t, []formats.StatementTransaction{
+			{
+				Id:     "2025010200001",
+				Amount: "-125.43",
+				Date:   time.Date(2025, 1, 2, 0, 0, 0, 0, timezone),
+				Name:   "REWE Markt GmbH",
+				Memo:   "Kartenzahlung REWE SAGT DANKE",
+			},
+			{
+				Id:     "PAYROLL-2025-01",
+				Amount: "2500.00",
+				Date:   time.Date(2025, 1, 3, 0, 0, 0, 0, timezone),
+				Name:   "ACME Corp",
+				Memo:   "Salary January",
+			},
+			{
+				Id:        "2025010400007",
+				Amount:    "-24.50",
+				Date:      time.Date(2025, 1, 4, 0, 0, 0, 0, timezone),
+				Name:      "Pending card payment Cafe Central",
+				Memo:      "Pending card payment Cafe Central",
+				IsPending: true,
+			},
+		}, statement.Transactions, "informational entries should be skipped")
+	})
+
+	t.Run("version 8 with multiple statements", func(t *testing.T) {
+		document, err := Parse(bytes.NewReader(GetFixtures(t, "sample-v08.xml")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(document, timezone)
+		assert.NoError(t, err, "must translate file")
+		require.NotNil(t, statement)
+		assert.Equal(t, "CHF", statement.Currency)
+		require.Len(t, statement.Transactions, 2, "should include entries from both statements")
+		assert.Equal(t, "E-1", statement.Transactions[0].Id)
+		assert.Equal(t, "SBB CFF FFS", statement.Transactions[0].Name)
+		assert.Equal(t, "-15.00", statement.Transactions[0].Amount)
+		assert.Equal(t, "Migros", statement.Transactions[1].Name)
+
+		require.NotNil(t, statement.LedgerBalance)
+		assert.Equal(t, "-20.00", statement.LedgerBalance.Amount, "latest balance should be used and debit balances are negative")
+		require.NotNil(t, statement.AvailableBalance)
+		assert.Equal(t, "-20.00", statement.AvailableBalance.Amount, "booked balance should be used when there is no available balance")
+	})
+
+	t.Run("multiple accounts", func(t *testing.T) {
+		document, err := Parse(bytes.NewReader([]byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"><BkToCstmrStmt>
+<Stmt><Id>1</Id><Acct><Id><IBAN>DE89370400440532013000</IBAN></Id></Acct></Stmt>
+<Stmt><Id>2</Id><Acct><Id><IBAN>GB29NWBK60161331926819</IBAN></Id></Acct></Stmt>
+</BkToCstmrStmt></Document>`)))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(document, timezone)
+		assert.EqualError(t, err, "CAMT file contains statements for more than one account, only one account can be imported at a time")
+		assert.Nil(t, statement)
+	})
+
+	t.Run("invalid credit debit indicator", func(t *testing.T) {
+		document, err := Parse(bytes.NewReader([]byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"><BkToCstmrStmt>
+<Stmt><Id>1</Id><Ntry><Amt Ccy="EUR">1.00</Amt><CdtDbtInd>X</CdtDbtInd><Sts>BOOK</Sts><BookgDt><Dt>2025-01-02</Dt></BookgDt></Ntry></Stmt>
+</BkToCstmrStmt></Document>`)))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(document, timezone)
+		assert.EqualError(t, err, "failed to translate CAMT entry 1 of statement [1]: credit debit indicator [X] is not valid")
+		assert.Nil(t, statement)
+	})
+}
+
+func TestSignedAmount(t *testing.T) {
+	cases := []struct {
+		value       string
+		creditDebit string
+		expected    string
+	}{
+		{"125.43", DebitIndicator, "-125.43"},
+		{"2500.00", CreditIndicator, "2500.00"},
+		{"100.000", CreditIndicator, "100.000"},
+		{"0.500", DebitIndicator, "-0.500"},
+		{"12.34567", CreditIndicator, "12.34567"},
+	}
+	for _, item := range cases {
+		result, err := signedAmount(Amount{Value: item.value, Currency: "KWD"}, item.creditDebit)
+		assert.NoError(t, err, "value: %q", item.value)
+		assert.Equal(t, item.expected, result, "value: %q", item.value)
+	}
+
+	_, err := signedAmount(Amount{Value: "abc", Currency: "EUR"}, CreditIndicator)
+	assert.EqualError(t, err, "amount [abc] is not a valid decimal")
+}
@@ -0,0 +1,16 @@
+package camt
+
+import (
+	"regexp"
+)
+
+var (
+	// Every version of the camt.053 message uses a namespace that starts with
+	// the same prefix, like urn:iso:std:iso:20022:tech:xsd:camt.053.001.02.
+	namespaceRegex = regexp.MustCompile(`urn:iso:std:iso:20022:tech:xsd:camt\.053\.\d{3}\.\d{2}`)
+	statementRegex = regexp.MustCompile(`<(\w+:)?BkToCstmrStmt[\s>]`)
+)
+
+func Validate(data []byte) bool {
+	return namespaceRegex.Match(data) && statementRegex.Match(data)
+}
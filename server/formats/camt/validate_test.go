@@ -0,0 +1,34 @@
+package camt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("version 2 valid file", func(t *testing.T) {
+		data := GetFixtures(t, "sample-v02.xml")
+		assert.True(t, Validate(data), "camt.053.001.02 file should be valid")
+	})
+
+	t.Run("version 8 valid file", func(t *testing.T) {
+		data := GetFixtures(t, "sample-v08.xml")
+		assert.True(t, Validate(data), "camt.053.001.08 file should be valid")
+	})
+
+	t.Run("prefixed elements", func(t *testing.T) {
+		data := []byte(`<c:Document xmlns:c="urn:iso:std:iso:20022:tech:xsd:camt.053.001.04"><c:BkToCstmrStmt></c:BkToCstmrStmt></c:Document>`)
+		assert.True(t, Validate(data), "namespace prefixes should be allowed")
+	})
+
+	t.Run("invalid file should return false", func(t *testing.T) {
+		data := GetFixtures(t, "invalid.xml")
+		assert.False(t, Validate(data), "invalid CAMT file should not be valid")
+	})
+
+	t.Run("camt.052 file should return false", func(t *testing.T) {
+		data := []byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.052.001.02"><BkToCstmrAcctRpt></BkToCstmrAcctRpt></Document>`)
+		assert.False(t, Validate(data), "intraday reports are not statements")
+	})
+}
@@ -3,14 +3,20 @@ package csv
 import (
 	"encoding/csv"
 	"io"
+	"strings"
 
 	"github.com/monetr/monetr/server/formats"
 	"github.com/pkg/errors"
 )
 
+var (
+	_ formats.RowReader = &CSVParser{}
+)
+
 type CSVParser struct {
 	mapping        formats.FieldIndex
 	firstRowHeader bool
+	headerConsumed bool
 	reader         *csv.Reader
 }
 
@@ -19,14 +25,45 @@ func NewCSVParser(
 	firstRowHeader bool,
 	reader io.Reader,
 ) *CSVParser {
+	csvReader := csv.NewReader(reader)
+	// Some institutions include trailing summary lines that have fewer columns
+	// than the rest of the file, we validate the column count ourselves per row.
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
 	return &CSVParser{
 		mapping:        mapping,
 		firstRowHeader: firstRowHeader,
-		reader:         csv.NewReader(reader),
+		reader:         csvReader,
+	}
+}
+
+// GetHeader will return the first row of the file if the parser was created
+// with firstRowHeader. This must be called before GetNextRow, if it is not
+// called then the header will be skipped automatically.
+func (c *CSVParser) GetHeader() ([]string, error) {
+	if !c.firstRowHeader {
+		return nil, errors.New("csv parser was not configured with a header row")
+	}
+	if c.headerConsumed {
+		return nil, errors.New("csv header has already been read")
+	}
+
+	header, err := c.reader.Read()
+	if err != nil {
+		return nil, err
 	}
+	c.headerConsumed = true
+
+	return header, nil
 }
 
 func (c *CSVParser) GetNextRow() (formats.Row, error) {
+	if c.firstRowHeader && !c.headerConsumed {
+		if _, err := c.GetHeader(); err != nil {
+			return nil, err
+		}
+	}
+
 	baseRow, err := c.reader.Read()
 	if err != nil {
 		return nil, err
@@ -45,7 +82,7 @@ func (c *CSVParser) GetNextRow() (formats.Row, error) {
 		if field == formats.FieldIgnore {
 			continue
 		}
-		row[field] = baseRow[index]
+		row[field] = strings.TrimSpace(baseRow[index])
 	}
 
 	return row, nil
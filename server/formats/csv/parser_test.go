@@ -0,0 +1,72 @@
+package csv
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVParser_GetNextRow(t *testing.T) {
+	t.Run("with header", func(t *testing.T) {
+		data := strings.NewReader(strings.Join([]string{
+			"Date,Description,Amount,Balance",
+			"01/02/2025,COSTCO WHOLESALE,-125.43,1000.00",
+			"01/03/2025,\"Payroll, Inc\",2500.00,3500.00",
+		}, "\n"))
+		parser := NewCSVParser(formats.FieldIndex{
+			formats.FieldDate,
+			formats.FieldName,
+			formats.FieldAmountCombined,
+			formats.FieldIgnore,
+		}, true, data)
+
+		row, err := parser.GetNextRow()
+		require.NoError(t, err, "must read the first row")
+		assert.Equal(t, formats.Row{
+			formats.FieldDate:           "01/02/2025",
+			formats.FieldName:           "COSTCO WHOLESALE",
+			formats.FieldAmountCombined: "-125.43",
+		}, row, "header should be skipped and ignored column omitted")
+
+		row, err = parser.GetNextRow()
+		require.NoError(t, err, "must read the second row")
+		assert.Equal(t, "Payroll, Inc", row[formats.FieldName])
+
+		_, err = parser.GetNextRow()
+		assert.ErrorIs(t, err, io.EOF, "should reach the end of the file")
+	})
+
+	t.Run("read header explicitly", func(t *testing.T) {
+		data := strings.NewReader("Date,Name,Amount\n2025-01-02,Coffee,4.50\n")
+		parser := NewCSVParser(formats.FieldIndex{
+			formats.FieldDate,
+			formats.FieldName,
+			formats.FieldAmountCombined,
+		}, true, data)
+
+		header, err := parser.GetHeader()
+		require.NoError(t, err, "must be able to read the header")
+		assert.Equal(t, []string{"Date", "Name", "Amount"}, header)
+
+		row, err := parser.GetNextRow()
+		require.NoError(t, err, "must read the first row")
+		assert.Equal(t, "Coffee", row[formats.FieldName])
+	})
+
+	t.Run("too few columns", func(t *testing.T) {
+		data := strings.NewReader("2025-01-02,Coffee\n")
+		parser := NewCSVParser(formats.FieldIndex{
+			formats.FieldDate,
+			formats.FieldName,
+			formats.FieldAmountCombined,
+		}, false, data)
+
+		row, err := parser.GetNextRow()
+		assert.EqualError(t, err, "col number mismatch, expected 3 column(s); found 2")
+		assert.Nil(t, row)
+	})
+}
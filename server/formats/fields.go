@@ -1,5 +1,9 @@
 package formats
 
+import (
+	"github.com/pkg/errors"
+)
+
 type Field uint32
 
 const (
@@ -18,6 +22,70 @@ const (
 	FieldAmountCredit    Field = 12
 )
 
+// FieldIndex describes the layout of a tabular file. Each item in the index
+// corresponds to the column at the same position in the file, and indicates
+// which field that column represents. Columns that are not needed should be
+// FieldIgnore.
 type FieldIndex []Field
 
+// Has returns true if the provided field is mapped to at least one column in
+// the index.
+func (f FieldIndex) Has(field Field) bool {
+	for _, item := range f {
+		if item == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate makes sure that the field index contains enough information to
+// derive a transaction from each row. At a minimum a date, a name and an amount
+// must be present. Amounts can be represented as a single combined column or as
+// separate debit and credit columns, but not both.
+func (f FieldIndex) Validate() error {
+	if len(f) == 0 {
+		return errors.New("field index must contain at least one column")
+	}
+
+	seen := map[Field]struct{}{}
+	for _, field := range f {
+		if field > FieldAmountCredit {
+			return errors.Errorf("field index contains an unknown field: %d", field)
+		}
+		if field == FieldIgnore {
+			continue
+		}
+		if _, ok := seen[field]; ok {
+			return errors.Errorf("field index contains field %d more than once", field)
+		}
+		seen[field] = struct{}{}
+	}
+
+	if !f.Has(FieldDate) {
+		return errors.New("field index must include a date column")
+	}
+
+	if !f.Has(FieldName) && !f.Has(FieldDescription) {
+		return errors.New("field index must include a name or description column")
+	}
+
+	hasSplitAmount := f.Has(FieldAmountDebit) || f.Has(FieldAmountCredit)
+	switch {
+	case f.Has(FieldAmountCombined) && hasSplitAmount:
+		return errors.New("field index cannot include both a combined amount and debit or credit amounts")
+	case !f.Has(FieldAmountCombined) && !hasSplitAmount:
+		return errors.New("field index must include an amount column")
+	}
+
+	return nil
+}
+
 type Row map[Field]string
+
+// RowReader is implemented by the tabular file parsers in the sub packages. It
+// returns io.EOF once there are no more rows to be read.
+type RowReader interface {
+	GetNextRow() (Row, error)
+}
@@ -0,0 +1,147 @@
+package formats
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDateLayouts are the layouts that will be attempted when a date is
+// parsed without a specific layout. The order matters, month first layouts are
+// attempted before day first layouts because most of the exports we see are
+// from US institutions.
+var DefaultDateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"01/02/06",
+	"1/2/06",
+	"02.01.2006",
+	"2006/01/02",
+	"01-02-2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+const (
+	DecimalSeparatorPeriod = "."
+	DecimalSeparatorComma  = ","
+)
+
+// CleanAmount takes an amount as it might appear in a spreadsheet or CSV export
+// and returns a string that can be parsed by currency.ParseFriendlyToAmount.
+// The decimal separator is provided by the column mapping, any other "." or ","
+// is treated as a thousands separator and removed along with currency symbols
+// and whitespace. Accounting style negatives like (12.34) are converted to
+// -12.34. An empty string is returned if the input does not contain any
+// digits.
+func CleanAmount(input, decimalSeparator string) (string, error) {
+	if decimalSeparator == "" {
+		decimalSeparator = DecimalSeparatorPeriod
+	}
+
+	value := strings.TrimSpace(input)
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+	}
+
+	var digits strings.Builder
+	// separator is the number of digits that came before the decimal separator,
+	// or -1 if there is no decimal separator.
+	separator := -1
+	for _, character := range value {
+		switch {
+		case unicode.IsDigit(character):
+			digits.WriteRune(character)
+		case string(character) == decimalSeparator:
+			if separator >= 0 {
+				return "", errors.Errorf("amount [%s] has more than one decimal separator", input)
+			}
+			separator = digits.Len()
+		case character == '-':
+			negative = !negative
+		}
+	}
+
+	if digits.Len() == 0 {
+		return "", nil
+	}
+
+	result := digits.String()
+	switch {
+	case separator == 0:
+		result = "0." + result
+	case separator > 0 && separator < len(result):
+		result = result[:separator] + "." + result[separator:]
+	}
+
+	if negative {
+		return "-" + result, nil
+	}
+
+	return result, nil
+}
+
+var decimalAmountPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// ParseDecimalAmount parses an amount from a format that always uses a "." as
+// the decimal separator, like CAMT.053 and QIF. Commas are only ever thousands
+// separators in these formats and are removed. Unlike CleanAmount, the number
+// of fractional digits is not used to guess the meaning of the separator, so
+// an amount like 0.500 is kept as is.
+func ParseDecimalAmount(input string) (string, error) {
+	value := strings.ReplaceAll(strings.TrimSpace(input), ",", "")
+	if !decimalAmountPattern.MatchString(value) {
+		return "", errors.Errorf("amount [%s] is not a valid decimal", input)
+	}
+
+	return strings.TrimPrefix(value, "+"), nil
+}
+
+// RoundDecimalAmount rounds a decimal string to the specified number of
+// fractional digits, halves are rounded away from zero. Some formats allow
+// more precision than the currency itself, this way those amounts can still be
+// parsed by currency.ParseFriendlyToAmount.
+func RoundDecimalAmount(input string, fractionalDigits int) (string, error) {
+	value, ok := new(big.Rat).SetString(input)
+	if !ok {
+		return "", errors.Errorf("amount [%s] is not a valid decimal", input)
+	}
+
+	return value.FloatString(fractionalDigits), nil
+}
+
+// ParseDate will parse the provided input in the specified timezone. If a
+// layout is provided then only that layout is used, otherwise each of the
+// DefaultDateLayouts is attempted in order.
+func ParseDate(input, layout string, timezone *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, errors.New("date is blank")
+	}
+
+	if layout != "" {
+		result, err := time.ParseInLocation(layout, input, timezone)
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to parse date with layout [%s]", layout)
+		}
+		return result, nil
+	}
+
+	for _, defaultLayout := range DefaultDateLayouts {
+		result, err := time.ParseInLocation(defaultLayout, input, timezone)
+		if err == nil {
+			return result, nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("date [%s] is not in a recognized format", input)
+}
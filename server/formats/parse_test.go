@@ -0,0 +1,190 @@
+package formats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanAmount(t *testing.T) {
+	t.Run("period", func(t *testing.T) {
+		cases := map[string]string{
+			"12.34":       "12.34",
+			"-12.34":      "-12.34",
+			"$1,234.56":   "1234.56",
+			"($1,234.56)": "-1234.56",
+			"12.34-":      "-12.34",
+			" 100 ":       "100",
+			"":            "",
+			"N/A":         "",
+			"1,234":       "1234",
+			"1,234,567.8": "1234567.8",
+			"0.500":       "0.500",
+			"1,000.125":   "1000.125",
+			".5":          "0.5",
+			"12.":         "12",
+		}
+		for input, expected := range cases {
+			result, err := CleanAmount(input, DecimalSeparatorPeriod)
+			assert.NoError(t, err, "input: %q", input)
+			assert.Equal(t, expected, result, "input: %q", input)
+		}
+	})
+
+	t.Run("comma", func(t *testing.T) {
+		cases := map[string]string{
+			"12,34":      "12.34",
+			"-12,5":      "-12.5",
+			"1.234,56 €": "1234.56",
+			"1 234,56":   "1234.56",
+			"1.234":      "1234",
+			"1.234.567":  "1234567",
+			"0,500":      "0.500",
+		}
+		for input, expected := range cases {
+			result, err := CleanAmount(input, DecimalSeparatorComma)
+			assert.NoError(t, err, "input: %q", input)
+			assert.Equal(t, expected, result, "input: %q", input)
+		}
+	})
+
+	t.Run("defaults to a period", func(t *testing.T) {
+		result, err := CleanAmount("1,234.500", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "1234.500", result)
+	})
+
+	t.Run("more than one decimal separator", func(t *testing.T) {
+		_, err := CleanAmount("1.234.567", DecimalSeparatorPeriod)
+		assert.EqualError(t, err, "amount [1.234.567] has more than one decimal separator")
+	})
+}
+
+func TestParseDecimalAmount(t *testing.T) {
+	cases := map[string]string{
+		"12.34":     "12.34",
+		"-12.34":    "-12.34",
+		"+12.34":    "12.34",
+		"0.500":     "0.500",
+		"100.000":   "100.000",
+		"1,234.567": "1234.567",
+		" 100 ":     "100",
+		".5":        ".5",
+	}
+	for input, expected := range cases {
+		result, err := ParseDecimalAmount(input)
+		assert.NoError(t, err, "input: %q", input)
+		assert.Equal(t, expected, result, "input: %q", input)
+	}
+
+	for _, input := range []string{"", "N/A", "12,34.5.6", "$12.34", "1.2.3"} {
+		_, err := ParseDecimalAmount(input)
+		assert.Error(t, err, "input: %q", input)
+	}
+}
+
+func TestRoundDecimalAmount(t *testing.T) {
+	cases := []struct {
+		input    string
+		digits   int
+		expected string
+	}{
+		{"100.000", 2, "100.00"},
+		{"0.500", 3, "0.500"},
+		{"0.505", 2, "0.51"},
+		{"-0.505", 2, "-0.51"},
+		{"12.34567", 2, "12.35"},
+		{"1234.5", 0, "1235"},
+		{"12", 2, "12.00"},
+	}
+	for _, item := range cases {
+		result, err := RoundDecimalAmount(item.input, item.digits)
+		assert.NoError(t, err, "input: %q", item.input)
+		assert.Equal(t, item.expected, result, "input: %q", item.input)
+	}
+
+	_, err := RoundDecimalAmount("abc", 2)
+	assert.EqualError(t, err, "amount [abc] is not a valid decimal")
+}
+
+func TestParseDate(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "must load timezone")
+
+	t.Run("default layouts", func(t *testing.T) {
+		expected := time.Date(2025, 1, 2, 0, 0, 0, 0, timezone)
+		for _, input := range []string{
+			"2025-01-02",
+			"01/02/2025",
+			"1/2/2025",
+			"1/2/25",
+		} {
+			result, err := ParseDate(input, "", timezone)
+			assert.NoError(t, err, "input: %q", input)
+			assert.Equal(t, expected, result, "input: %q", input)
+		}
+	})
+
+	t.Run("explicit layout", func(t *testing.T) {
+		result, err := ParseDate("02/01/2025", "02/01/2006", timezone)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, timezone), result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseDate("tomorrow", "", timezone)
+		assert.EqualError(t, err, "date [tomorrow] is not in a recognized format")
+
+		_, err = ParseDate("", "", timezone)
+		assert.EqualError(t, err, "date is blank")
+	})
+}
+
+func TestFieldIndex_Validate(t *testing.T) {
+	t.Run("valid combined", func(t *testing.T) {
+		assert.NoError(t, FieldIndex{
+			FieldDate,
+			FieldName,
+			FieldIgnore,
+			FieldIgnore,
+			FieldAmountCombined,
+		}.Validate())
+	})
+
+	t.Run("valid split", func(t *testing.T) {
+		assert.NoError(t, FieldIndex{
+			FieldUniqueId,
+			FieldDate,
+			FieldDescription,
+			FieldAmountDebit,
+			FieldAmountCredit,
+		}.Validate())
+	})
+
+	t.Run("missing date", func(t *testing.T) {
+		assert.EqualError(t, FieldIndex{
+			FieldName,
+			FieldAmountCombined,
+		}.Validate(), "field index must include a date column")
+	})
+
+	t.Run("both amounts", func(t *testing.T) {
+		assert.EqualError(t, FieldIndex{
+			FieldDate,
+			FieldName,
+			FieldAmountCombined,
+			FieldAmountDebit,
+		}.Validate(), "field index cannot include both a combined amount and debit or credit amounts")
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		assert.EqualError(t, FieldIndex{
+			FieldDate,
+			FieldDate,
+			FieldName,
+			FieldAmountCombined,
+		}.Validate(), "field index contains field 5 more than once")
+	})
+}
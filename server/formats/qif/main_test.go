@@ -0,0 +1,18 @@
+package qif
+
+import (
+	"embed"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed fixtures/*.qif
+var fixtureData embed.FS
+
+func GetFixtures(t *testing.T, name string) []byte {
+	data, err := fixtureData.ReadFile(path.Join("fixtures", name))
+	require.NoError(t, err, "must be able to load fixture data for QIF parsing")
+	return data
+}
@@ -0,0 +1,235 @@
+package qif
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/pkg/errors"
+)
+
+// Account types that can appear in a !Type header or in the T field of an
+// !Account record.
+const (
+	BankAccountType       = "Bank"
+	CashAccountType       = "Cash"
+	CreditCardAccountType = "CCard"
+	InvestmentAccountType = "Invst"
+	AssetAccountType      = "Oth A"
+	LiabilityAccountType  = "Oth L"
+)
+
+type QIF struct {
+	Accounts []Account
+}
+
+type Account struct {
+	// Name is only present when the file includes an !Account record, files
+	// exported for a single account usually do not.
+	Name         string
+	Type         string
+	Transactions []Transaction
+}
+
+type Transaction struct {
+	Date     string
+	Amount   string
+	Payee    string
+	Memo     string
+	Number   string
+	Cleared  string
+	Category string
+	Address  []string
+	Splits   []Split
+}
+
+type Split struct {
+	Category string
+	Memo     string
+	Amount   string
+}
+
+// Parse reads an entire QIF file. Records in lists that are not tied to an
+// account, like categories or memorized transactions, are ignored.
+func Parse(reader io.Reader) (*QIF, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read QIF buffer")
+	}
+
+	tokens, err := Tokenize(string(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse")
+	}
+
+	result := &QIF{
+		Accounts: make([]Account, 0, 1),
+	}
+	// current is the index of the account that transactions are being added
+	// to, or -1 if there is not an account yet.
+	current := -1
+	var section string
+	var record []Token
+	flush := func() error {
+		defer func() {
+			record = record[:0]
+		}()
+		if len(record) == 0 {
+			return nil
+		}
+
+		switch {
+		case section == "":
+			return errors.Errorf("QIF record on line %d appears before any header", record[0].Line)
+		case strings.EqualFold(section, "Account"):
+			account := parseAccount(record)
+			result.Accounts = append(result.Accounts, account)
+			current = len(result.Accounts) - 1
+		case isTransactionSection(section):
+			accountType := strings.TrimSpace(section[len("Type:"):])
+			if current < 0 {
+				result.Accounts = append(result.Accounts, Account{
+					Type: accountType,
+				})
+				current = len(result.Accounts) - 1
+			}
+			if result.Accounts[current].Type == "" {
+				result.Accounts[current].Type = accountType
+			}
+			result.Accounts[current].Transactions = append(
+				result.Accounts[current].Transactions,
+				parseTransaction(record),
+			)
+		}
+
+		return nil
+	}
+
+	for _, token := range tokens {
+		switch token.Type {
+		case HeaderTokenType:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			// Options only change how Quicken behaves when importing, they do not
+			// start a new section.
+			if hasPrefixFold(token.Value, "Option:") || hasPrefixFold(token.Value, "Clear:") {
+				continue
+			}
+			section = token.Value
+		case FieldTokenType:
+			record = append(record, token)
+		case EndTokenType:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Some exporters do not terminate the last record in the file.
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ParseDate parses the dates that QIF files use. Dates are month first, and
+// Quicken writes years after 1999 with an apostrophe like 1/2'25 or even
+// 1/2' 5, both of which are January 2nd 2025.
+func ParseDate(input string, timezone *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if before, after, ok := strings.Cut(input, "'"); ok {
+		year := strings.TrimSpace(after)
+		if len(year) == 1 {
+			year = "0" + year
+		}
+		input = before + "/" + year
+	}
+	input = strings.ReplaceAll(input, " ", "")
+
+	result, err := formats.ParseDate(input, "", timezone)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to parse QIF date")
+	}
+
+	return result, nil
+}
+
+func isTransactionSection(section string) bool {
+	if !hasPrefixFold(section, "Type:") {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(section[len("Type:"):])) {
+	case "bank", "cash", "ccard", "invst", "oth a", "oth l":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseAccount(record []Token) Account {
+	var account Account
+	for _, field := range record {
+		switch field.Code {
+		case 'N':
+			account.Name = field.Value
+		case 'T':
+			account.Type = field.Value
+		}
+	}
+
+	return account
+}
+
+func parseTransaction(record []Token) Transaction {
+	var transaction Transaction
+	var amount string
+	for _, field := range record {
+		switch field.Code {
+		case 'D':
+			transaction.Date = field.Value
+		case 'T':
+			transaction.Amount = field.Value
+		case 'U':
+			// Newer versions of Quicken write the amount twice, U is only used if
+			// T is missing.
+			amount = field.Value
+		case 'P':
+			transaction.Payee = field.Value
+		case 'M':
+			transaction.Memo = field.Value
+		case 'N':
+			transaction.Number = field.Value
+		case 'C':
+			transaction.Cleared = field.Value
+		case 'L':
+			transaction.Category = field.Value
+		case 'A':
+			transaction.Address = append(transaction.Address, field.Value)
+		case 'S':
+			transaction.Splits = append(transaction.Splits, Split{
+				Category: field.Value,
+			})
+		case 'E':
+			if count := len(transaction.Splits); count > 0 {
+				transaction.Splits[count-1].Memo = field.Value
+			}
+		case '$':
+			if count := len(transaction.Splits); count > 0 {
+				transaction.Splits[count-1].Amount = field.Value
+			}
+		}
+	}
+	if transaction.Amount == "" {
+		transaction.Amount = amount
+	}
+
+	return transaction
+}
+
+func hasPrefixFold(input, prefix string) bool {
+	return len(input) >= len(prefix) && strings.EqualFold(input[:len(prefix)], prefix)
+}
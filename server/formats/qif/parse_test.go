@@ -0,0 +1,113 @@
+package qif
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	expected := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{
+		"01/02/2025",
+		"1/2/25",
+		"1/ 2'25",
+		"1/2'25",
+		"2025-01-02",
+	} {
+		result, err := ParseDate(input, time.UTC)
+		assert.NoError(t, err, "input: %q", input)
+		assert.Equal(t, expected, result, "input: %q", input)
+	}
+
+	t.Run("single digit year", func(t *testing.T) {
+		result, err := ParseDate("1/2' 5", time.UTC)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2005, 1, 2, 0, 0, 0, 0, time.UTC), result)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		result, err := ParseDate("yesterday", time.UTC)
+		assert.EqualError(t, err, "failed to parse QIF date: date [yesterday] is not in a recognized format")
+		assert.True(t, result.IsZero(), "date returned must be zero")
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("bank", func(t *testing.T) {
+		reader := bytes.NewReader(GetFixtures(t, "sample-bank.qif"))
+
+		result, err := Parse(reader)
+		assert.NoError(t, err, "must not return an error parsing known valid file")
+		require.NotNil(t, result, "resulting QIF object should not be nil")
+		require.Len(t, result.Accounts, 1, "should have an implicit account")
+
+		account := result.Accounts[0]
+		assert.Empty(t, account.Name, "implicit accounts do not have a name")
+		assert.Equal(t, BankAccountType, account.Type)
+		require.Len(t, account.Transactions, 6)
+		assert.Equal(t, Transaction{
+			Date:     "1/ 2'25",
+			Amount:   "-125.43",
+			Payee:    "COSTCO WHOLESALE",
+			Memo:     "Warehouse #123",
+			Cleared:  "*",
+			Category: "Groceries",
+		}, account.Transactions[1])
+		assert.Equal(t, "2,500.00", account.Transactions[2].Amount)
+		assert.Equal(t, []Split{
+			{Category: "Utilities:Water", Memo: "Water bill", Amount: "-80.00"},
+			{Category: "Utilities:Electric", Memo: "Power bill", Amount: "-120.00"},
+		}, account.Transactions[5].Splits)
+	})
+
+	t.Run("multiple accounts", func(t *testing.T) {
+		reader := bytes.NewReader(GetFixtures(t, "sample-multiple-accounts.qif"))
+
+		result, err := Parse(reader)
+		assert.NoError(t, err, "must not return an error parsing known valid file")
+		require.NotNil(t, result, "resulting QIF object should not be nil")
+
+		withTransactions := make([]Account, 0)
+		for _, account := range result.Accounts {
+			if len(account.Transactions) > 0 {
+				withTransactions = append(withTransactions, account)
+			}
+		}
+		require.Len(t, withTransactions, 2, "should have transactions for both accounts")
+		assert.Equal(t, "Everyday Checking", withTransactions[0].Name)
+		assert.Equal(t, "Lunch", withTransactions[0].Transactions[0].Payee)
+		assert.Equal(t, "Rewards Card", withTransactions[1].Name)
+		assert.Equal(t, CreditCardAccountType, withTransactions[1].Type)
+		assert.Equal(t, "Gas Station", withTransactions[1].Transactions[0].Payee)
+	})
+
+	t.Run("unterminated record", func(t *testing.T) {
+		reader := bytes.NewReader([]byte("!Type:Cash\nD01/02/2025\nT-4.50\nPCoffee"))
+
+		result, err := Parse(reader)
+		assert.NoError(t, err, "the last record does not need to be terminated")
+		require.Len(t, result.Accounts, 1)
+		require.Len(t, result.Accounts[0].Transactions, 1)
+		assert.Equal(t, "Coffee", result.Accounts[0].Transactions[0].Payee)
+	})
+
+	t.Run("record before header", func(t *testing.T) {
+		reader := bytes.NewReader([]byte("D01/02/2025\nT-4.50\n^\n"))
+
+		result, err := Parse(reader)
+		assert.EqualError(t, err, "QIF record on line 1 appears before any header")
+		assert.Nil(t, result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		reader := bytes.NewReader(GetFixtures(t, "invalid.qif"))
+
+		result, err := Parse(reader)
+		assert.Error(t, err, "a json file is not a QIF file")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,76 @@
+package qif
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type TokenType uint8
+
+const (
+	// HeaderTokenType is a line that begins with an exclamation point, like
+	// !Type:Bank or !Account. Headers change how the records that follow them
+	// are interpreted.
+	HeaderTokenType TokenType = 0
+	// FieldTokenType is a single value within a record. The first character of
+	// the line is the code of the field and the rest of the line is the value.
+	FieldTokenType TokenType = 1
+	// EndTokenType is the caret that terminates a record.
+	EndTokenType TokenType = 2
+)
+
+type Token struct {
+	Type TokenType
+	// Line is the one based line number the token was read from, it is used to
+	// make errors a bit more helpful.
+	Line int
+	// Code is the field code for field tokens, it is zero for other tokens.
+	Code byte
+	// Value is the name of the header without the exclamation point for header
+	// tokens, or the value of the field for field tokens.
+	Value string
+}
+
+// Tokenize splits QIF data into its headers, fields and record terminators.
+// Blank lines are skipped and both unix and windows line endings are
+// supported.
+func Tokenize(qifData string) ([]Token, error) {
+	qifData = strings.TrimPrefix(qifData, "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(qifData, "\r\n", "\n"), "\n")
+
+	tokens := make([]Token, 0, len(lines))
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '!':
+			tokens = append(tokens, Token{
+				Type:  HeaderTokenType,
+				Line:  i + 1,
+				Value: strings.TrimSpace(line[1:]),
+			})
+		case '^':
+			tokens = append(tokens, Token{
+				Type: EndTokenType,
+				Line: i + 1,
+			})
+		default:
+			tokens = append(tokens, Token{
+				Type:  FieldTokenType,
+				Line:  i + 1,
+				Code:  line[0],
+				Value: strings.TrimSpace(line[1:]),
+			})
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("QIF file provided is not valid")
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,52 @@
+package qif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Run("bank", func(t *testing.T) {
+		data := GetFixtures(t, "sample-bank.qif")
+		tokens, err := Tokenize(string(data))
+		assert.NoError(t, err)
+		require.NotEmpty(t, tokens)
+		assert.Equal(t, Token{
+			Type:  HeaderTokenType,
+			Line:  1,
+			Value: "Type:Bank",
+		}, tokens[0], "first token should be the type header")
+		assert.Equal(t, Token{
+			Type:  FieldTokenType,
+			Line:  2,
+			Code:  'D',
+			Value: "12/31'24",
+		}, tokens[1], "second token should be the date of the first record")
+	})
+
+	t.Run("multiple accounts", func(t *testing.T) {
+		data := GetFixtures(t, "sample-multiple-accounts.qif")
+		tokens, err := Tokenize(string(data))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens)
+	})
+
+	t.Run("windows line endings and byte order mark", func(t *testing.T) {
+		tokens, err := Tokenize("\ufeff!Type:Bank\r\nD01/02/2025\r\n\r\nT-4.50\r\n^\r\n")
+		assert.NoError(t, err)
+		assert.Equal(t, []Token{
+			{Type: HeaderTokenType, Line: 1, Value: "Type:Bank"},
+			{Type: FieldTokenType, Line: 2, Code: 'D', Value: "01/02/2025"},
+			{Type: FieldTokenType, Line: 4, Code: 'T', Value: "-4.50"},
+			{Type: EndTokenType, Line: 5},
+		}, tokens, "blank lines should be skipped and line endings removed")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		tokens, err := Tokenize("\n\n")
+		assert.EqualError(t, err, "QIF file provided is not valid")
+		assert.Nil(t, tokens)
+	})
+}
@@ -0,0 +1,84 @@
+package qif
+
+import (
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/pkg/errors"
+)
+
+// Translate converts a parsed QIF file into a statement. Only a single account
+// can be imported at a time, so the file must contain transactions for
+// exactly one non-investment account. QIF files do not include balances or a
+// currency, and they do not include a unique identifier for each transaction
+// so one is derived from the transaction itself.
+func Translate(data *QIF, timezone *time.Location) (*formats.Statement, error) {
+	var account *Account
+	for i := range data.Accounts {
+		if len(data.Accounts[i].Transactions) == 0 {
+			continue
+		}
+		if account != nil {
+			return nil, errors.New("QIF file contains transactions for more than one account, only one account can be imported at a time")
+		}
+		account = &data.Accounts[i]
+	}
+
+	if account == nil {
+		return nil, errors.New("QIF file does not contain any transactions")
+	}
+
+	if strings.EqualFold(account.Type, InvestmentAccountType) {
+		return nil, errors.New("QIF files for investment accounts are not supported")
+	}
+
+	identifiers := formats.NewIdentifierGenerator()
+	statement := &formats.Statement{
+		Transactions: make([]formats.StatementTransaction, 0, len(account.Transactions)),
+	}
+	for i, transaction := range account.Transactions {
+		// Quicken exports the starting balance of the account as a transfer from
+		// the account to itself. It is not a real transaction.
+		if strings.EqualFold(transaction.Payee, "Opening Balance") &&
+			strings.HasPrefix(transaction.Category, "[") {
+			continue
+		}
+
+		date, err := ParseDate(transaction.Date, timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to translate QIF transaction %d", i+1)
+		}
+
+		if strings.TrimSpace(transaction.Amount) == "" {
+			return nil, errors.Errorf("failed to translate QIF transaction %d, it does not have an amount", i+1)
+		}
+		// QIF amounts always use a "." as the decimal separator, commas are only
+		// used to separate thousands.
+		amount, err := formats.ParseDecimalAmount(transaction.Amount)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to translate QIF transaction %d", i+1)
+		}
+
+		name := myownsanity.CoalesceStrings(transaction.Payee, transaction.Memo, transaction.Category)
+		if name == "" {
+			return nil, errors.Errorf("failed to translate QIF transaction %d, it does not have a payee or memo", i+1)
+		}
+
+		statement.Transactions = append(statement.Transactions, formats.StatementTransaction{
+			Id: identifiers.Next(
+				date.Format("2006-01-02"),
+				amount,
+				transaction.Number,
+				name,
+			),
+			Amount: amount,
+			Date:   date,
+			Name:   name,
+			Memo:   transaction.Memo,
+		})
+	}
+
+	return statement, nil
+}
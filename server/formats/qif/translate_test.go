@@ -0,0 +1,87 @@
+package qif
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate(t *testing.T) {
+	timezone, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "must load timezone")
+
+	t.Run("bank", func(t *testing.T) {
+		data, err := Parse(bytes.NewReader(GetFixtures(t, "sample-bank.qif")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(data, timezone)
+		assert.NoError(t, err, "must translate file")
+		require.NotNil(t, statement)
+		assert.Empty(t, statement.Currency, "QIF files do not have a currency")
+		assert.Nil(t, statement.LedgerBalance, "QIF files do not have balances")
+		assert.Nil(t, statement.AvailableBalance, "QIF files do not have balances")
+		require.Len(t, statement.Transactions, 5, "opening balance should be skipped")
+
+		costco := statement.Transactions[0]
+		assert.Equal(t, "-125.43", costco.Amount)
+		assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, timezone), costco.Date)
+		assert.Equal(t, "COSTCO WHOLESALE", costco.Name)
+		assert.Equal(t, "Warehouse #123", costco.Memo)
+		assert.NotEmpty(t, costco.Id)
+		assert.False(t, costco.IsPending)
+
+		assert.Equal(t, "2500.00", statement.Transactions[1].Amount, "thousands separators should be removed")
+
+		first, second := statement.Transactions[2], statement.Transactions[3]
+		assert.Equal(t, first.Name, second.Name)
+		assert.NotEqual(t, first.Id, second.Id, "identical transactions should still have unique ids")
+
+		again, err := Translate(data, timezone)
+		require.NoError(t, err, "must translate file again")
+		for i := range statement.Transactions {
+			assert.Equal(t, statement.Transactions[i].Id, again.Transactions[i].Id, "ids should be stable")
+		}
+	})
+
+	t.Run("amounts with more than two fractional digits", func(t *testing.T) {
+		data, err := Parse(bytes.NewReader([]byte("!Type:Bank\nD1/ 2'25\nT-0.500\nPCoffee\n^\nD1/ 3'25\nT1,000.125\nPPayroll\n^\n")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(data, timezone)
+		assert.NoError(t, err, "must translate file")
+		require.NotNil(t, statement)
+		require.Len(t, statement.Transactions, 2)
+		assert.Equal(t, "-0.500", statement.Transactions[0].Amount, "a dot is always the decimal separator")
+		assert.Equal(t, "1000.125", statement.Transactions[1].Amount, "commas are always thousands separators")
+	})
+
+	t.Run("multiple accounts", func(t *testing.T) {
+		data, err := Parse(bytes.NewReader(GetFixtures(t, "sample-multiple-accounts.qif")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(data, timezone)
+		assert.EqualError(t, err, "QIF file contains transactions for more than one account, only one account can be imported at a time")
+		assert.Nil(t, statement)
+	})
+
+	t.Run("investment account", func(t *testing.T) {
+		data, err := Parse(bytes.NewReader([]byte("!Type:Invst\nD01/02/2025\nNBuy\nYACME\nT100.00\n^\n")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(data, timezone)
+		assert.EqualError(t, err, "QIF files for investment accounts are not supported")
+		assert.Nil(t, statement)
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		data, err := Parse(bytes.NewReader([]byte("!Type:Cat\nNGroceries\n^\n")))
+		require.NoError(t, err, "must parse file")
+
+		statement, err := Translate(data, timezone)
+		assert.EqualError(t, err, "QIF file does not contain any transactions")
+		assert.Nil(t, statement)
+	})
+}
@@ -0,0 +1,15 @@
+package qif
+
+import (
+	"regexp"
+)
+
+var (
+	// QIF files do not have a formal header, but every file we have seen begins
+	// with a type, account or option header.
+	validateRegex = regexp.MustCompile(`(?i)^\x{FEFF}?\s*!(Type:|Account|Option:|Clear:)`)
+)
+
+func Validate(data []byte) bool {
+	return validateRegex.Match(data)
+}
@@ -0,0 +1,28 @@
+package qif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("bank valid file", func(t *testing.T) {
+		data := GetFixtures(t, "sample-bank.qif")
+		assert.True(t, Validate(data), "bank QIF file should be valid")
+	})
+
+	t.Run("multiple accounts valid file", func(t *testing.T) {
+		data := GetFixtures(t, "sample-multiple-accounts.qif")
+		assert.True(t, Validate(data), "multiple account QIF file should be valid")
+	})
+
+	t.Run("invalid file should return false", func(t *testing.T) {
+		data := GetFixtures(t, "invalid.qif")
+		assert.False(t, Validate(data), "invalid QIF file should not be valid")
+	})
+
+	t.Run("ofx file should return false", func(t *testing.T) {
+		assert.False(t, Validate([]byte("OFXHEADER:100\nDATA:OFXSGML\n<OFX>")), "OFX file should not be a valid QIF file")
+	})
+}
@@ -0,0 +1,68 @@
+package formats
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Statement is the format neutral representation of a file that describes the
+// transactions, and optionally the balances, of a single account. Parsers for
+// formats that are not tabular translate their own structures into a
+// statement so that they can all be imported the same way.
+type Statement struct {
+	// Currency is the ISO currency code of the statement, or blank if the
+	// format does not specify one.
+	Currency     string
+	Transactions []StatementTransaction
+	// LedgerBalance and AvailableBalance are the closing balances of the
+	// statement, they are nil if the file does not include them.
+	LedgerBalance    *StatementBalance
+	AvailableBalance *StatementBalance
+}
+
+type StatementTransaction struct {
+	// Id uniquely identifies the transaction across every file exported for the
+	// account. It is used to avoid importing the same transaction twice.
+	Id string
+	// Amount is a decimal string that can be parsed by
+	// currency.ParseFriendlyToAmount. Like OFX, deposits are positive and debits
+	// are negative.
+	Amount    string
+	Date      time.Time
+	Name      string
+	Memo      string
+	IsPending bool
+}
+
+type StatementBalance struct {
+	// Amount is a decimal string that can be parsed by
+	// currency.ParseFriendlyToAmount.
+	Amount string
+	Date   time.Time
+}
+
+// IdentifierGenerator derives identifiers for transactions in formats that do
+// not include a unique identifier of their own. The identifier is a hash of the
+// provided parts, and of how many times those same parts have already been seen
+// in the file. This way two identical purchases on the same day still receive
+// different identifiers, but exporting the same file again yields the same
+// identifiers.
+type IdentifierGenerator struct {
+	occurrences map[string]int
+}
+
+func NewIdentifierGenerator() *IdentifierGenerator {
+	return &IdentifierGenerator{
+		occurrences: map[string]int{},
+	}
+}
+
+func (g *IdentifierGenerator) Next(parts ...string) string {
+	key := strings.Join(parts, "|")
+	g.occurrences[key]++
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, g.occurrences[key])))
+	return hex.EncodeToString(hash[:16])
+}
@@ -0,0 +1,21 @@
+package formats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifierGenerator_Next(t *testing.T) {
+	first := NewIdentifierGenerator()
+	a := first.Next("2025-01-02", "-4.50", "Coffee")
+	b := first.Next("2025-01-02", "-4.50", "Coffee")
+	c := first.Next("2025-01-02", "-4.51", "Coffee")
+	assert.NotEqual(t, a, b, "repeated transactions should have different identifiers")
+	assert.NotEqual(t, a, c, "different transactions should have different identifiers")
+	assert.Len(t, a, 32, "identifiers should be 16 bytes hex encoded")
+
+	second := NewIdentifierGenerator()
+	assert.Equal(t, a, second.Next("2025-01-02", "-4.50", "Coffee"), "identifiers should be stable between files")
+	assert.Equal(t, b, second.Next("2025-01-02", "-4.50", "Coffee"), "identifiers should be stable between files")
+}
@@ -0,0 +1,163 @@
+package xlsx
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ formats.RowReader = &XLSXParser{}
+)
+
+// maxHeaderSearchRows is how far into a sheet we will look for the header row.
+// Exports often start with a few rows describing the account or the statement
+// period before the actual table begins.
+const maxHeaderSearchRows = 25
+
+type XLSXParser struct {
+	mapping        formats.FieldIndex
+	firstRowHeader bool
+	headerConsumed bool
+	rows           [][]string
+	position       int
+}
+
+// NewXLSXParser creates a row reader over rows that have been read from a
+// workbook sheet. If firstRowHeader is true then the header row is detected
+// using DetectHeaderRow and everything up to and including it is skipped.
+// Blank rows are always skipped.
+func NewXLSXParser(
+	mapping formats.FieldIndex,
+	firstRowHeader bool,
+	rows [][]string,
+) *XLSXParser {
+	return &XLSXParser{
+		mapping:        mapping,
+		firstRowHeader: firstRowHeader,
+		rows:           rows,
+	}
+}
+
+// GetHeader will return the detected header row of the sheet if the parser was
+// created with firstRowHeader. This must be called before GetNextRow, if it is
+// not called then the header will be skipped automatically.
+func (x *XLSXParser) GetHeader() ([]string, error) {
+	if !x.firstRowHeader {
+		return nil, errors.New("xlsx parser was not configured with a header row")
+	}
+	if x.headerConsumed {
+		return nil, errors.New("xlsx header has already been read")
+	}
+
+	index := DetectHeaderRow(x.mapping, x.rows)
+	if index < 0 {
+		return nil, io.EOF
+	}
+	x.headerConsumed = true
+	x.position = index + 1
+
+	return x.rows[index], nil
+}
+
+func (x *XLSXParser) GetNextRow() (formats.Row, error) {
+	if x.firstRowHeader && !x.headerConsumed {
+		if _, err := x.GetHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	for ; x.position < len(x.rows); x.position++ {
+		if isBlankRow(x.rows[x.position]) {
+			continue
+		}
+
+		baseRow := x.rows[x.position]
+		x.position++
+
+		// Unlike CSV files, trailing empty cells are simply not present in a
+		// sheet, so short rows are treated as having blank values.
+		row := make(formats.Row)
+		for index, field := range x.mapping {
+			if field == formats.FieldIgnore || index >= len(baseRow) {
+				continue
+			}
+			row[field] = strings.TrimSpace(baseRow[index])
+		}
+
+		return row, nil
+	}
+
+	return nil, io.EOF
+}
+
+// DetectHeaderRow returns the index of the row that is most likely the header
+// of the table described by the mapping, or -1 if the sheet does not have any
+// rows with values. The header is the first row that has a value for every
+// mapped column, where the date and amount columns do not contain any digits.
+// If no row looks like a header then the first row with any values is used.
+func DetectHeaderRow(mapping formats.FieldIndex, rows [][]string) int {
+	first := -1
+	for i := 0; i < len(rows) && i < maxHeaderSearchRows; i++ {
+		if isBlankRow(rows[i]) {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if looksLikeHeader(mapping, rows[i]) {
+			return i
+		}
+	}
+
+	if first < 0 {
+		for i := maxHeaderSearchRows; i < len(rows); i++ {
+			if !isBlankRow(rows[i]) {
+				return i
+			}
+		}
+	}
+
+	return first
+}
+
+func looksLikeHeader(mapping formats.FieldIndex, row []string) bool {
+	for index, field := range mapping {
+		if field == formats.FieldIgnore {
+			continue
+		}
+		if index >= len(row) {
+			return false
+		}
+
+		value := strings.TrimSpace(row[index])
+		if value == "" {
+			return false
+		}
+
+		switch field {
+		case formats.FieldDate,
+			formats.FieldAmountCombined,
+			formats.FieldAmountDebit,
+			formats.FieldAmountCredit:
+			if strings.IndexFunc(value, unicode.IsDigit) >= 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+package xlsx
+
+import (
+	"io"
+	"testing"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXLSXParser_GetNextRow(t *testing.T) {
+	mapping := formats.FieldIndex{
+		formats.FieldDate,
+		formats.FieldName,
+		formats.FieldAmountCombined,
+	}
+
+	t.Run("detects header after preamble", func(t *testing.T) {
+		parser := NewXLSXParser(mapping, true, [][]string{
+			{"Account", "Everyday Checking"},
+			{},
+			{"Date", "Description", "Amount"},
+			{"2025-01-02", "COSTCO WHOLESALE", "-125.43"},
+			{"", "", ""},
+			{"2025-01-03", "Payroll"},
+		})
+
+		header, err := parser.GetHeader()
+		require.NoError(t, err, "must be able to read the header")
+		assert.Equal(t, []string{"Date", "Description", "Amount"}, header)
+
+		row, err := parser.GetNextRow()
+		require.NoError(t, err, "must read the first row")
+		assert.Equal(t, formats.Row{
+			formats.FieldDate:           "2025-01-02",
+			formats.FieldName:           "COSTCO WHOLESALE",
+			formats.FieldAmountCombined: "-125.43",
+		}, row)
+
+		row, err = parser.GetNextRow()
+		require.NoError(t, err, "blank rows should be skipped and short rows allowed")
+		assert.Equal(t, formats.Row{
+			formats.FieldDate: "2025-01-03",
+			formats.FieldName: "Payroll",
+		}, row)
+
+		_, err = parser.GetNextRow()
+		assert.ErrorIs(t, err, io.EOF, "should reach the end of the sheet")
+	})
+
+	t.Run("without header", func(t *testing.T) {
+		parser := NewXLSXParser(mapping, false, [][]string{
+			{},
+			{"2025-01-02", "Coffee", "4.50"},
+		})
+
+		_, err := parser.GetHeader()
+		assert.EqualError(t, err, "xlsx parser was not configured with a header row")
+
+		row, err := parser.GetNextRow()
+		require.NoError(t, err, "must read the first row")
+		assert.Equal(t, "Coffee", row[formats.FieldName])
+
+		_, err = parser.GetNextRow()
+		assert.ErrorIs(t, err, io.EOF, "should reach the end of the sheet")
+	})
+
+	t.Run("empty sheet", func(t *testing.T) {
+		parser := NewXLSXParser(mapping, true, [][]string{})
+
+		row, err := parser.GetNextRow()
+		assert.ErrorIs(t, err, io.EOF, "an empty sheet has no rows")
+		assert.Nil(t, row)
+	})
+}
+
+func TestDetectHeaderRow(t *testing.T) {
+	mapping := formats.FieldIndex{
+		formats.FieldDate,
+		formats.FieldIgnore,
+		formats.FieldDescription,
+		formats.FieldAmountDebit,
+		formats.FieldAmountCredit,
+	}
+
+	t.Run("header below preamble", func(t *testing.T) {
+		index := DetectHeaderRow(mapping, [][]string{
+			{"Statement for 01/01/2025 - 01/31/2025"},
+			{"Posted", "Ref", "Memo", "Debit", "Credit"},
+			{"01/02/2025", "", "Coffee", "4.50", ""},
+		})
+		assert.Equal(t, 1, index)
+	})
+
+	t.Run("falls back to the first row with values", func(t *testing.T) {
+		index := DetectHeaderRow(mapping, [][]string{
+			{},
+			{"01/02/2025", "", "Coffee", "4.50", ""},
+		})
+		assert.Equal(t, 1, index)
+	})
+
+	t.Run("no values", func(t *testing.T) {
+		index := DetectHeaderRow(mapping, [][]string{{}, {""}})
+		assert.Equal(t, -1, index)
+	})
+}
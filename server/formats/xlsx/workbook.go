@@ -0,0 +1,614 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxPartSize is the largest any single XML part of a workbook is allowed to
+	// be once it has been decompressed. This keeps a small malicious file from
+	// expanding into something that exhausts memory.
+	maxPartSize = 64 << 20
+	// maxCompressionRatio is how many times larger than its compressed size a
+	// part is allowed to be once decompressed. Spreadsheet XML compresses well,
+	// but not nearly this well unless it was crafted to. Parts that decompress
+	// to less than minRatioPartSize are not held to the ratio.
+	maxCompressionRatio = 100
+	minRatioPartSize    = 1 << 20
+	// maxColumns is the number of columns Excel supports, A through XFD.
+	maxColumns = 16384
+	// maxRows and maxCells limit how much of a single sheet will be read. These
+	// are far beyond what a transaction export will contain. Every cell element
+	// counts towards maxCells, as well as any blank cells that are added to
+	// fill the gaps between them.
+	maxRows  = 100000
+	maxCells = 2000000
+
+	relationshipsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+var (
+	// excelEpoch is the zero day of the 1900 date system. Excel treats 1900 as
+	// a leap year, so the epoch is shifted back a day for all serials after the
+	// fictional February 29th, which covers every date a transaction will have.
+	excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	// excelEpoch1904 is the zero day of the 1904 date system, which is used by
+	// workbooks that originated from older versions of Excel for Mac.
+	excelEpoch1904 = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type (
+	xlsxWorkbook struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name  string `xml:"name,attr"`
+			RelId string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	xlsxRelationships struct {
+		Relationships []struct {
+			Id     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	xlsxStyles struct {
+		NumberFormats []struct {
+			Id   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellFormats []struct {
+			NumberFormatId int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+
+	xlsxText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+
+	xlsxSharedStrings struct {
+		Items []xlsxText `xml:"si"`
+	}
+
+	xlsxCell struct {
+		Reference string   `xml:"r,attr"`
+		Type      string   `xml:"t,attr"`
+		Style     int      `xml:"s,attr"`
+		Value     string   `xml:"v"`
+		Inline    xlsxText `xml:"is"`
+	}
+)
+
+// String returns the plain text of a shared or inline string, rich text runs
+// are concatenated and their formatting is discarded.
+func (x xlsxText) String() string {
+	if len(x.Runs) == 0 {
+		return x.Text
+	}
+
+	var builder strings.Builder
+	for _, run := range x.Runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+type sheet struct {
+	name string
+	path string
+}
+
+// Workbook is a read only view of an Office Open XML spreadsheet. Only the
+// parts needed to read cell values are loaded, formulas are not evaluated and
+// the cached value that was stored when the file was saved is used instead.
+type Workbook struct {
+	files         map[string]*zip.File
+	sheets        []sheet
+	sharedStrings []string
+	dateStyles    []bool
+	date1904      bool
+}
+
+// ReadWorkbook will read the entire provided reader into memory and then open
+// it as a workbook. The zip format stores its directory at the end of the file
+// so it cannot be read as a stream.
+func ReadWorkbook(reader io.Reader) (*Workbook, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read workbook")
+	}
+
+	return OpenWorkbook(bytes.NewReader(data), int64(len(data)))
+}
+
+// OpenWorkbook parses the workbook, shared strings and styles of an xlsx file.
+// Worksheets themselves are not parsed until GetRows is called.
+func OpenWorkbook(reader io.ReaderAt, size int64) (*Workbook, error) {
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "file is not a valid xlsx workbook")
+	}
+
+	w := &Workbook{
+		files: make(map[string]*zip.File, len(archive.File)),
+	}
+	for _, file := range archive.File {
+		w.files[path.Clean(file.Name)] = file
+	}
+
+	var workbook xlsxWorkbook
+	if err := w.decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("workbook does not contain any sheets")
+	}
+	w.date1904 = workbook.Properties.Date1904 == "1" ||
+		strings.EqualFold(workbook.Properties.Date1904, "true")
+
+	var relationships xlsxRelationships
+	if err := w.decode("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(relationships.Relationships))
+	for _, relationship := range relationships.Relationships {
+		target := relationship.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[relationship.Id] = path.Clean(target)
+	}
+
+	w.sheets = make([]sheet, 0, len(workbook.Sheets))
+	for _, item := range workbook.Sheets {
+		target, ok := targets[item.RelId]
+		if !ok {
+			return nil, errors.Errorf("workbook sheet [%s] does not have a relationship", item.Name)
+		}
+		w.sheets = append(w.sheets, sheet{
+			name: item.Name,
+			path: target,
+		})
+	}
+
+	// Shared strings and styles are both optional, a workbook that only
+	// contains numbers or that was written by a minimal exporter may omit them.
+	if _, ok := w.files["xl/sharedStrings.xml"]; ok {
+		var sharedStrings xlsxSharedStrings
+		if err := w.decode("xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+		w.sharedStrings = make([]string, len(sharedStrings.Items))
+		for i, item := range sharedStrings.Items {
+			w.sharedStrings[i] = item.String()
+		}
+	}
+
+	if _, ok := w.files["xl/styles.xml"]; ok {
+		var styles xlsxStyles
+		if err := w.decode("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+		customFormats := make(map[int]string, len(styles.NumberFormats))
+		for _, format := range styles.NumberFormats {
+			customFormats[format.Id] = format.Code
+		}
+		w.dateStyles = make([]bool, len(styles.CellFormats))
+		for i, format := range styles.CellFormats {
+			if code, ok := customFormats[format.NumberFormatId]; ok {
+				w.dateStyles[i] = isDateFormatCode(code)
+			} else {
+				w.dateStyles[i] = isBuiltInDateFormat(format.NumberFormatId)
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// Sheets returns the names of the sheets in the workbook in the order they
+// appear in Excel.
+func (w *Workbook) Sheets() []string {
+	names := make([]string, len(w.sheets))
+	for i, item := range w.sheets {
+		names[i] = item.name
+	}
+	return names
+}
+
+// LookupSheet returns the name of the specified sheet as it appears in the
+// workbook, or the name of the first sheet if the name is blank. Sheet names
+// are matched case insensitively just like they are in Excel.
+func (w *Workbook) LookupSheet(name string) (string, error) {
+	target, err := w.lookupSheet(name)
+	if err != nil {
+		return "", err
+	}
+
+	return target.name, nil
+}
+
+func (w *Workbook) lookupSheet(name string) (sheet, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return w.sheets[0], nil
+	}
+
+	for _, item := range w.sheets {
+		if strings.EqualFold(item.name, name) {
+			return item, nil
+		}
+	}
+
+	return sheet{}, errors.Errorf(
+		"sheet [%s] does not exist in the workbook, available sheets are: %s",
+		name, strings.Join(w.Sheets(), ", "),
+	)
+}
+
+// GetRows returns the values of every row in the specified sheet, see
+// LookupSheet. Cells that are formatted as dates are rendered using the
+// provided layout, if the layout is blank then dates are rendered as
+// 2006-01-02 or as 2006-01-02T15:04:05 when they include a time. Numeric cells
+// are rendered with the provided decimal separator so that they are parsed the
+// same way as numbers stored as text, a period is used if it is blank. Rows
+// that are not present in the sheet are omitted. If a mapping is provided then
+// only the columns up to the last mapped column are kept, and the values of
+// ignored columns are left blank; otherwise each row is as long as its right
+// most cell. The sheet is read as a stream, and an error is returned if it has
+// more than maxRows rows or maxCells cells.
+func (w *Workbook) GetRows(name, dateLayout, decimalSeparator string, mapping formats.FieldIndex) ([][]string, error) {
+	target, err := w.lookupSheet(name)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := maxColumns
+	if mapping != nil {
+		columns = 0
+		for index, field := range mapping {
+			if field != formats.FieldIgnore && index < maxColumns {
+				columns = index + 1
+			}
+		}
+	}
+
+	reader, err := w.open(target.path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decoder := xml.NewDecoder(reader)
+	rows := make([][]string, 0)
+	var values []string
+	inRow := false
+	// next is the column that a cell without a reference belongs to.
+	next := 0
+	cells := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read sheet [%s]", target.name)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "row":
+				if len(rows) >= maxRows {
+					return nil, errors.Errorf("sheet [%s] has more than %d rows", target.name, maxRows)
+				}
+				values = make([]string, 0)
+				next = 0
+				inRow = true
+			case "c":
+				if !inRow {
+					if err := decoder.Skip(); err != nil {
+						return nil, errors.Wrapf(err, "failed to read sheet [%s]", target.name)
+					}
+					continue
+				}
+
+				var cell xlsxCell
+				if err := decoder.DecodeElement(&cell, &element); err != nil {
+					return nil, errors.Wrapf(err, "failed to read sheet [%s]", target.name)
+				}
+
+				column := next
+				if cell.Reference != "" {
+					index, err := columnIndex(cell.Reference)
+					if err != nil {
+						return nil, errors.Wrapf(err, "sheet [%s] contains an invalid cell", target.name)
+					}
+					column = index
+				}
+				if column < next {
+					return nil, errors.Errorf("sheet [%s] contains cells out of order at [%s]", target.name, cell.Reference)
+				}
+				next = column + 1
+
+				cells++
+				if column < columns {
+					cells += column - len(values)
+				}
+				if cells > maxCells {
+					return nil, errors.Errorf("sheet [%s] has more than %d cells", target.name, maxCells)
+				}
+
+				// Cells past the last column we care about are still validated
+				// above, but their values are not kept.
+				if column >= columns {
+					continue
+				}
+				for len(values) < column {
+					values = append(values, "")
+				}
+
+				var value string
+				if mapping == nil || mapping[column] != formats.FieldIgnore {
+					value, err = w.cellValue(cell, dateLayout, decimalSeparator)
+					if err != nil {
+						return nil, errors.Wrapf(err, "sheet [%s] contains an invalid cell", target.name)
+					}
+				}
+				values = append(values, value)
+			}
+		case xml.EndElement:
+			if element.Name.Local == "row" && inRow {
+				rows = append(rows, values)
+				inRow = false
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+func (w *Workbook) cellValue(cell xlsxCell, dateLayout, decimalSeparator string) (string, error) {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(cell.Value)
+		if err != nil || index < 0 || index >= len(w.sharedStrings) {
+			return "", errors.Errorf("invalid shared string reference at [%s]", cell.Reference)
+		}
+		return w.sharedStrings[index], nil
+	case "inlineStr":
+		return cell.Inline.String(), nil
+	case "str":
+		return cell.Value, nil
+	case "b":
+		if cell.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "e":
+		// Errors like #N/A or #DIV/0! are not meaningful values for a
+		// transaction, treat them as blank cells.
+		return "", nil
+	case "d":
+		return w.formatISODate(cell.Value, dateLayout), nil
+	default:
+		if cell.Style >= 0 && cell.Style < len(w.dateStyles) && w.dateStyles[cell.Style] {
+			return w.formatSerialDate(cell.Value, dateLayout), nil
+		}
+		value := formatNumber(cell.Value)
+		if decimalSeparator != "" && decimalSeparator != formats.DecimalSeparatorPeriod {
+			value = strings.Replace(value, ".", decimalSeparator, 1)
+		}
+		return value, nil
+	}
+}
+
+// open returns a reader for the decompressed contents of a part of the
+// workbook. The reader returns an error if the part decompresses to more than
+// maxPartSize, or more than maxCompressionRatio times its compressed size.
+func (w *Workbook) open(name string) (io.ReadCloser, error) {
+	file, ok := w.files[name]
+	if !ok {
+		return nil, errors.Errorf("workbook is missing required part [%s]", name)
+	}
+
+	limit := int64(maxPartSize)
+	if ratioLimit := int64(file.CompressedSize64) * maxCompressionRatio; ratioLimit < limit {
+		limit = max(ratioLimit, minRatioPartSize)
+	}
+	// The uncompressed size in the archive cannot be trusted, but if it is
+	// honest about being too large then there is no point in reading it.
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, errors.Errorf("workbook part [%s] is too large", name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open workbook part [%s]", name)
+	}
+
+	return &partReader{
+		ReadCloser: reader,
+		name:       name,
+		remaining:  limit,
+	}, nil
+}
+
+func (w *Workbook) decode(name string, result any) error {
+	reader, err := w.open(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(reader).Decode(result); err != nil {
+		return errors.Wrapf(err, "failed to parse workbook part [%s]", name)
+	}
+
+	return nil
+}
+
+// partReader returns an error instead of io.EOF once more than the remaining
+// number of bytes have been read, so that a part that is too large fails
+// instead of being silently truncated.
+type partReader struct {
+	io.ReadCloser
+	name      string
+	remaining int64
+}
+
+func (p *partReader) Read(buffer []byte) (int, error) {
+	if int64(len(buffer)) > p.remaining+1 {
+		buffer = buffer[:p.remaining+1]
+	}
+	n, err := p.ReadCloser.Read(buffer)
+	p.remaining -= int64(n)
+	if p.remaining < 0 {
+		return n, errors.Errorf("workbook part [%s] is too large", p.name)
+	}
+
+	return n, err
+}
+
+func (w *Workbook) formatSerialDate(input, layout string) string {
+	serial, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return input
+	}
+
+	epoch := excelEpoch
+	if w.date1904 {
+		epoch = excelEpoch1904
+	}
+
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	result := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+
+	return formatDate(result, seconds != 0, layout)
+}
+
+func (w *Workbook) formatISODate(input, layout string) string {
+	for _, isoLayout := range []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02",
+	} {
+		result, err := time.Parse(isoLayout, input)
+		if err == nil {
+			hasTime := result.Hour() != 0 || result.Minute() != 0 || result.Second() != 0
+			return formatDate(result, hasTime, layout)
+		}
+	}
+
+	return input
+}
+
+func formatDate(input time.Time, hasTime bool, layout string) string {
+	switch {
+	case layout != "":
+		return input.Format(layout)
+	case hasTime:
+		return input.Format("2006-01-02T15:04:05")
+	default:
+		return input.Format("2006-01-02")
+	}
+}
+
+// formatNumber renders a numeric cell the way Excel would display it with the
+// general format. Values are stored as binary floating point, so a value typed
+// as 0.3 can be stored as 0.30000000000000004; rounding to 15 significant
+// digits removes that noise.
+func formatNumber(input string) string {
+	value, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return input
+	}
+
+	value, _ = strconv.ParseFloat(strconv.FormatFloat(value, 'g', 15, 64), 64)
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// columnIndex returns the zero based column of a cell reference like "C12".
+func columnIndex(reference string) (int, error) {
+	index := 0
+	letters := 0
+	for _, character := range strings.ToUpper(reference) {
+		if character < 'A' || character > 'Z' {
+			break
+		}
+		index = index*26 + int(character-'A') + 1
+		letters++
+	}
+	if letters == 0 || letters > 3 || index > maxColumns {
+		return 0, errors.Errorf("invalid cell reference [%s]", reference)
+	}
+
+	return index - 1, nil
+}
+
+// isBuiltInDateFormat returns true for the number formats that Excel defines
+// implicitly and that represent a date or a time.
+func isBuiltInDateFormat(id int) bool {
+	switch {
+	case id >= 14 && id <= 22:
+		return true
+	case id >= 45 && id <= 47:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDateFormatCode returns true if a custom number format would render the
+// value as a date or a time. Literal text, escaped characters and bracketed
+// sections like colors or locales are ignored so that a format such as
+// [Red]"Due "0.00 is not mistaken for a date.
+func isDateFormatCode(code string) bool {
+	code = strings.ToLower(code)
+	inQuotes := false
+	inBrackets := false
+	for i := 0; i < len(code); i++ {
+		character := code[i]
+		switch {
+		case inQuotes:
+			if character == '"' {
+				inQuotes = false
+			}
+		case inBrackets:
+			if character == ']' {
+				inBrackets = false
+			}
+		case character == '"':
+			inQuotes = true
+		case character == '[':
+			inBrackets = true
+		case character == '\\', character == '_', character == '*':
+			// These all apply to the character that follows them.
+			i++
+		case strings.IndexByte("ymdhs", character) >= 0:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,265 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/monetr/monetr/server/formats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildWorkbook zips the provided parts into an xlsx file. Only the parts that
+// the reader actually looks at need to be provided.
+func buildWorkbook(t *testing.T, parts map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range parts {
+		file, err := writer.Create(name)
+		require.NoError(t, err, "must be able to create workbook part")
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err, "must be able to write workbook part")
+	}
+	require.NoError(t, writer.Close(), "must be able to close workbook")
+	return buffer.Bytes()
+}
+
+// exampleWorkbook is shaped like a typical bank export. It has a summary sheet
+// first, and the transaction sheet begins with a few rows describing the
+// account before the actual table.
+func exampleWorkbook(t *testing.T) []byte {
+	return buildWorkbook(t, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+	<sheets>
+		<sheet name="Summary" sheetId="1" r:id="rId1"/>
+		<sheet name="Transactions" sheetId="2" r:id="rId2"/>
+	</sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+	<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+	<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`,
+		"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<numFmts count="1">
+		<numFmt numFmtId="164" formatCode="&quot;$&quot;#,##0.00"/>
+	</numFmts>
+	<cellXfs count="3">
+		<xf numFmtId="0"/>
+		<xf numFmtId="14"/>
+		<xf numFmtId="164"/>
+	</cellXfs>
+</styleSheet>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<si><t>Account</t></si>
+	<si><t>Everyday Checking</t></si>
+	<si><t>Date</t></si>
+	<si><t>Description</t></si>
+	<si><t>Amount</t></si>
+	<si><r><t>COSTCO </t></r><r><rPr><b/></rPr><t>WHOLESALE</t></r></si>
+	<si><t>Payroll</t></si>
+</sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<sheetData>
+		<row r="1"><c r="A1" t="inlineStr"><is><t>Nothing to see here</t></is></c></row>
+	</sheetData>
+</worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+	<sheetData>
+		<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+		<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" t="s"><v>3</v></c><c r="C3" t="s"><v>4</v></c></row>
+		<row r="4"><c r="A4" s="1"><v>45659</v></c><c r="B4" t="s"><v>5</v></c><c r="C4" s="2"><v>-125.43000000000001</v></c></row>
+		<row r="5"><c r="A5" s="1"><v>45660.5</v></c><c r="B5" t="s"><v>6</v></c><c r="C5"><v>2500</v></c></row>
+		<row r="7"><c r="A7" s="1"><v>45661</v></c><c r="C7" t="e"><v>#N/A</v></c></row>
+	</sheetData>
+</worksheet>`,
+	})
+}
+
+// singleSheetWorkbook returns a workbook with a single sheet named Sheet1 that
+// contains the provided sheet data.
+func singleSheetWorkbook(t *testing.T, sheetData string) []byte {
+	return buildWorkbook(t, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+	<sheets>
+		<sheet name="Sheet1" sheetId="1" r:id="rId1"/>
+	</sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData +
+			`</sheetData></worksheet>`,
+	})
+}
+
+func TestWorkbook_Sheets(t *testing.T) {
+	data := exampleWorkbook(t)
+	workbook, err := OpenWorkbook(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err, "must be able to open workbook")
+	assert.Equal(t, []string{"Summary", "Transactions"}, workbook.Sheets())
+
+	name, err := workbook.LookupSheet("transactions")
+	assert.NoError(t, err, "sheet names should be case insensitive")
+	assert.Equal(t, "Transactions", name)
+
+	name, err = workbook.LookupSheet("")
+	assert.NoError(t, err, "blank sheet name should use the first sheet")
+	assert.Equal(t, "Summary", name)
+}
+
+func TestWorkbook_GetRows(t *testing.T) {
+	t.Run("default sheet", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader(exampleWorkbook(t)))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("", "", "", nil)
+		require.NoError(t, err, "must be able to read the first sheet")
+		assert.Equal(t, [][]string{
+			{"Nothing to see here"},
+		}, rows)
+	})
+
+	t.Run("named sheet", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader(exampleWorkbook(t)))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("transactions", "", "", nil)
+		require.NoError(t, err, "sheet names should be case insensitive")
+		assert.Equal(t, [][]string{
+			{"Account", "Everyday Checking"},
+			{"Date", "Description", "Amount"},
+			{"2025-01-02", "COSTCO WHOLESALE", "-125.43"},
+			{"2025-01-03T12:00:00", "Payroll", "2500"},
+			{"2025-01-04", "", ""},
+		}, rows, "dates should be converted, rich text flattened and float noise removed")
+	})
+
+	t.Run("date layout", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader(exampleWorkbook(t)))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("Transactions", "01/02/2006", "", nil)
+		require.NoError(t, err, "must be able to read the sheet")
+		assert.Equal(t, "01/02/2025", rows[2][0], "date cells should use the provided layout")
+		assert.Equal(t, "Date", rows[1][0], "text cells should not be affected by the layout")
+	})
+
+	t.Run("decimal separator", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader(exampleWorkbook(t)))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("Transactions", "", formats.DecimalSeparatorComma, nil)
+		require.NoError(t, err, "must be able to read the sheet")
+		assert.Equal(t, "-125,43", rows[2][2], "numeric cells should use the provided decimal separator")
+		assert.Equal(t, "2500", rows[3][2], "whole numbers should not have a separator")
+		assert.Equal(t, "COSTCO WHOLESALE", rows[2][1], "text cells should not be affected")
+	})
+
+	t.Run("missing sheet", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader(exampleWorkbook(t)))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("Checking", "", "", nil)
+		assert.EqualError(t, err, "sheet [Checking] does not exist in the workbook, available sheets are: Summary, Transactions")
+		assert.Nil(t, rows)
+	})
+
+	t.Run("mapped columns", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader(exampleWorkbook(t)))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("Transactions", "", "", formats.FieldIndex{
+			formats.FieldIgnore,
+			formats.FieldName,
+		})
+		require.NoError(t, err, "must be able to read the sheet")
+		assert.Equal(t, [][]string{
+			{"", "Everyday Checking"},
+			{"", "Description"},
+			{"", "COSTCO WHOLESALE"},
+			{"", "Payroll"},
+			{""},
+		}, rows, "only the mapped columns should be kept")
+	})
+
+	t.Run("too many cells", func(t *testing.T) {
+		// Each of these rows would be padded out to the very last column.
+		data := singleSheetWorkbook(t, strings.Repeat(`<row><c r="XFD1"><v>1</v></c></row>`, 200))
+		workbook, err := ReadWorkbook(bytes.NewReader(data))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("", "", "", nil)
+		assert.EqualError(t, err, "sheet [Sheet1] has more than 2000000 cells")
+		assert.Nil(t, rows)
+
+		rows, err = workbook.GetRows("", "", "", formats.FieldIndex{formats.FieldDate})
+		assert.NoError(t, err, "cells outside of the mapping should not be kept")
+		assert.Len(t, rows, 200)
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		data := singleSheetWorkbook(t, strings.Repeat(`<row/>`, maxRows+1))
+		workbook, err := ReadWorkbook(bytes.NewReader(data))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("", "", "", nil)
+		assert.EqualError(t, err, "sheet [Sheet1] has more than 100000 rows")
+		assert.Nil(t, rows)
+	})
+
+	t.Run("compression ratio", func(t *testing.T) {
+		data := singleSheetWorkbook(t, strings.Repeat(" ", 2*minRatioPartSize))
+		workbook, err := ReadWorkbook(bytes.NewReader(data))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("", "", "", nil)
+		assert.ErrorContains(t, err, "workbook part [xl/worksheets/sheet1.xml] is too large")
+		assert.Nil(t, rows)
+	})
+
+	t.Run("column past XFD", func(t *testing.T) {
+		data := singleSheetWorkbook(t, `<row><c r="XFE1"><v>1</v></c></row>`)
+		workbook, err := ReadWorkbook(bytes.NewReader(data))
+		require.NoError(t, err, "must be able to open workbook")
+
+		rows, err := workbook.GetRows("", "", "", nil)
+		assert.EqualError(t, err, "sheet [Sheet1] contains an invalid cell: invalid cell reference [XFE1]")
+		assert.Nil(t, rows)
+	})
+
+	t.Run("not a workbook", func(t *testing.T) {
+		workbook, err := ReadWorkbook(bytes.NewReader([]byte("Date,Name,Amount\n")))
+		assert.Error(t, err, "a csv file is not a workbook")
+		assert.Nil(t, workbook)
+	})
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	cases := map[string]bool{
+		"yyyy-mm-dd":                    true,
+		"m/d/yy":                        true,
+		`[$-409]mmmm\ d\,\ yyyy`:        true,
+		"h:mm:ss AM/PM":                 true,
+		"General":                       false,
+		"0.00":                          false,
+		`"$"#,##0.00_);\("$"#,##0.00\)`: false,
+		`[Red]"Due "0.00`:               false,
+		`#,##0.00\ "days"`:              false,
+	}
+	for code, expected := range cases {
+		assert.Equal(t, expected, isDateFormatCode(code), "code: %q", code)
+	}
+}
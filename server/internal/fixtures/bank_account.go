@@ -121,3 +121,143 @@ func GivenIHaveAPlaidBankAccount(
 
 	return bankAccount
 }
+
+// GivenIHaveATellerBankAccount will seed a bank account for the provided
+// Teller link, tellerId is the ID of the account in Teller.
+func GivenIHaveATellerBankAccount(
+	t *testing.T,
+	clock clock.Clock,
+	link *models.Link,
+	tellerId string,
+	accountType models.BankAccountType,
+	subType models.BankAccountSubType,
+) models.BankAccount {
+	require.NotNil(t, link, "link must actually be provided")
+	require.NotZero(t, link.LinkId, "link id must be included")
+	require.NotZero(t, link.AccountId, "link id must be included")
+	require.NotZero(t, link.TellerLinkId, "link teller link id must be included")
+
+	db := testutils.GetPgDatabase(t)
+	repo := repository.NewRepositoryFromSession(clock, link.CreatedBy, link.AccountId, db)
+
+	current := int64(gofakeit.Number(2000, 100000))
+	available := current - int64(gofakeit.Number(100, 2000))
+	mask := gofakeit.Generate("####")
+
+	tellerBankAccount := models.TellerBankAccount{
+		AccountId:        link.AccountId,
+		TellerLinkId:     *link.TellerLinkId,
+		TellerId:         tellerId,
+		InstitutionId:    "test_bank",
+		InstitutionName:  link.InstitutionName,
+		Name:             "E-ACCOUNT",
+		Mask:             mask,
+		Type:             string(accountType),
+		SubType:          string(subType),
+		Status:           "open",
+		Currency:         "USD",
+		AvailableBalance: available,
+		LedgerBalance:    current,
+		CreatedAt:        clock.Now(),
+		CreatedBy:        link.CreatedBy,
+	}
+	require.NoError(
+		t,
+		repo.CreateTellerBankAccount(context.Background(), &tellerBankAccount),
+		"must be able to create the teller bank account record",
+	)
+
+	bankAccount := models.BankAccount{
+		AccountId:           link.AccountId,
+		Account:             link.Account,
+		LinkId:              link.LinkId,
+		Link:                link,
+		TellerBankAccountId: &tellerBankAccount.TellerBankAccountId,
+		TellerBankAccount:   &tellerBankAccount,
+		AvailableBalance:    available,
+		CurrentBalance:      current,
+		Currency:            "USD",
+		Mask:                mask,
+		Name:                "E-ACCOUNT",
+		Type:                accountType,
+		SubType:             subType,
+		Status:              models.ActiveBankAccountStatus,
+		LastUpdated:         clock.Now(),
+		CreatedAt:           clock.Now(),
+	}
+
+	require.NoError(
+		t,
+		repo.CreateBankAccounts(context.Background(), &bankAccount),
+		"must seed bank account",
+	)
+	require.NotZero(t, bankAccount.BankAccountId, "bank account Id must have been set")
+
+	return bankAccount
+}
+
+// GivenIHaveASimpleFINBankAccount will seed a bank account for the provided
+// SimpleFIN link, simpleFINId is the ID of the account on the SimpleFIN
+// server.
+func GivenIHaveASimpleFINBankAccount(
+	t *testing.T,
+	clock clock.Clock,
+	link *models.Link,
+	simpleFINId string,
+) models.BankAccount {
+	require.NotNil(t, link, "link must actually be provided")
+	require.NotZero(t, link.LinkId, "link id must be included")
+	require.NotZero(t, link.AccountId, "link id must be included")
+	require.NotZero(t, link.SimpleFINLinkId, "link simplefin link id must be included")
+
+	db := testutils.GetPgDatabase(t)
+	repo := repository.NewRepositoryFromSession(clock, link.CreatedBy, link.AccountId, db)
+
+	current := int64(gofakeit.Number(2000, 100000))
+	available := current - int64(gofakeit.Number(100, 2000))
+
+	simpleFINBankAccount := models.SimpleFINBankAccount{
+		AccountId:        link.AccountId,
+		SimpleFINLinkId:  *link.SimpleFINLinkId,
+		SimpleFINId:      simpleFINId,
+		OrganizationName: link.InstitutionName,
+		Name:             "E-ACCOUNT",
+		Currency:         "USD",
+		Balance:          current,
+		AvailableBalance: available,
+		CreatedAt:        clock.Now(),
+		CreatedBy:        link.CreatedBy,
+	}
+	require.NoError(
+		t,
+		repo.CreateSimpleFINBankAccount(context.Background(), &simpleFINBankAccount),
+		"must be able to create the simplefin bank account record",
+	)
+
+	bankAccount := models.BankAccount{
+		AccountId:              link.AccountId,
+		Account:                link.Account,
+		LinkId:                 link.LinkId,
+		Link:                   link,
+		SimpleFINBankAccountId: &simpleFINBankAccount.SimpleFINBankAccountId,
+		SimpleFINBankAccount:   &simpleFINBankAccount,
+		AvailableBalance:       available,
+		CurrentBalance:         current,
+		Currency:               "USD",
+		Name:                   "E-ACCOUNT",
+		Type:                   models.DepositoryBankAccountType,
+		SubType:                models.CheckingBankAccountSubType,
+		Status:                 models.ActiveBankAccountStatus,
+		LastUpdated:            clock.Now(),
+		CreatedAt:              clock.Now(),
+	}
+
+	require.NoError(
+		t,
+		repo.CreateBankAccounts(context.Background(), &bankAccount),
+		"must seed bank account",
+	)
+	require.NotZero(t, bankAccount.BankAccountId, "bank account Id must have been set")
+
+	return bankAccount
+}
@@ -83,6 +83,127 @@ func GivenIHaveAPlaidLink(t *testing.T, clock clock.Clock, user User) Link {
 	return link
 }
 
+// GivenIHaveATellerLink will seed the following models and assoc them and
+// return the parent Link model:
+//   - Secret
+//   - TellerLink
+//   - Link
+//
+// The provided access token is stored as the secret for the link so that it
+// can be used with the Teller mocks.
+func GivenIHaveATellerLink(t *testing.T, clock clock.Clock, user User, accessToken string) Link {
+	log := testutils.GetLog(t)
+	db := testutils.GetPgDatabase(t)
+
+	repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+	secretsRepo := repository.NewSecretsRepository(
+		log,
+		clock,
+		db,
+		secrets.NewPlaintextKMS(),
+		user.AccountId,
+	)
+
+	secret := repository.SecretData{
+		Kind:  TellerSecretKind,
+		Value: accessToken,
+	}
+	err := secretsRepo.Store(context.Background(), &secret)
+	require.NoError(t, err, "must be able to seed teller token secret")
+
+	tellerLink := TellerLink{
+		AccountId:       user.AccountId,
+		SecretId:        secret.SecretId,
+		EnrollmentId:    gofakeit.Generate("enr_????????????????????"),
+		TellerUserId:    gofakeit.Generate("usr_????????????????????"),
+		Status:          TellerLinkStatusSetup,
+		InstitutionName: fmt.Sprintf("Bank Of %s", gofakeit.City()),
+		UpdatedAt:       clock.Now().UTC(),
+		CreatedAt:       clock.Now().UTC(),
+		CreatedBy:       user.UserId,
+	}
+	err = repo.CreateTellerLink(context.Background(), &tellerLink)
+	require.NoError(t, err, "must be able to seed teller link")
+
+	link := Link{
+		AccountId:       user.AccountId,
+		Account:         user.Account,
+		LinkType:        TellerLinkType,
+		TellerLinkId:    &tellerLink.TellerLinkId,
+		TellerLink:      &tellerLink,
+		InstitutionName: tellerLink.InstitutionName,
+		CreatedAt:       clock.Now(),
+		CreatedBy:       user.UserId,
+		CreatedByUser:   &user,
+		UpdatedAt:       clock.Now(),
+	}
+
+	err = repo.CreateLink(context.Background(), &link)
+	require.NoError(t, err, "must be able to seed link")
+
+	return link
+}
+
+// GivenIHaveASimpleFINLink will seed the following models and assoc them and
+// return the parent Link model:
+//   - Secret
+//   - SimpleFINLink
+//   - Link
+//
+// The provided access URL is stored as the secret for the link so that it can
+// be used with the SimpleFIN mocks.
+func GivenIHaveASimpleFINLink(t *testing.T, clock clock.Clock, user User, accessUrl string) Link {
+	log := testutils.GetLog(t)
+	db := testutils.GetPgDatabase(t)
+
+	repo := repository.NewRepositoryFromSession(clock, user.UserId, user.AccountId, db)
+	secretsRepo := repository.NewSecretsRepository(
+		log,
+		clock,
+		db,
+		secrets.NewPlaintextKMS(),
+		user.AccountId,
+	)
+
+	secret := repository.SecretData{
+		Kind:  SimpleFINSecretKind,
+		Value: accessUrl,
+	}
+	err := secretsRepo.Store(context.Background(), &secret)
+	require.NoError(t, err, "must be able to seed simplefin access url secret")
+
+	simpleFINLink := SimpleFINLink{
+		AccountId:       user.AccountId,
+		SecretId:        secret.SecretId,
+		Server:          "bridge.simplefin.test",
+		Status:          SimpleFINLinkStatusSetup,
+		InstitutionName: fmt.Sprintf("Bank Of %s", gofakeit.City()),
+		UpdatedAt:       clock.Now().UTC(),
+		CreatedAt:       clock.Now().UTC(),
+		CreatedBy:       user.UserId,
+	}
+	err = repo.CreateSimpleFINLink(context.Background(), &simpleFINLink)
+	require.NoError(t, err, "must be able to seed simplefin link")
+
+	link := Link{
+		AccountId:       user.AccountId,
+		Account:         user.Account,
+		LinkType:        SimpleFINLinkType,
+		SimpleFINLinkId: &simpleFINLink.SimpleFINLinkId,
+		SimpleFINLink:   &simpleFINLink,
+		InstitutionName: simpleFINLink.InstitutionName,
+		CreatedAt:       clock.Now(),
+		CreatedBy:       user.UserId,
+		CreatedByUser:   &user,
+		UpdatedAt:       clock.Now(),
+	}
+
+	err = repo.CreateLink(context.Background(), &link)
+	require.NoError(t, err, "must be able to seed link")
+
+	return link
+}
+
 func GivenIHaveAManualLink(t *testing.T, clock clock.Clock, user User) Link {
 	db := testutils.GetPgDatabase(t)
 
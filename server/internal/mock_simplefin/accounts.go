@@ -0,0 +1,113 @@
+package mock_simplefin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/monetr/server/internal/mock_http_helper"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/simplefin"
+)
+
+func AccountFixture(t *testing.T) simplefin.Account {
+	institution := gofakeit.Company()
+	domain := strings.ToLower(strings.ReplaceAll(institution, " ", "")) + ".test"
+	balance := gofakeit.Float64Range(100, 500)
+	available := gofakeit.Float64Range(balance-10, balance)
+
+	return simplefin.Account{
+		Org: simplefin.Organization{
+			Domain:  domain,
+			SFINURL: fmt.Sprintf("https://%s/simplefin", Host),
+			Name:    institution,
+		},
+		Id:               "ACT-" + strings.ToLower(gofakeit.UUID()),
+		Name:             fmt.Sprintf("%s Checking", institution),
+		Currency:         "USD",
+		Balance:          fmt.Sprintf("%.2f", balance),
+		AvailableBalance: myownsanity.StringP(fmt.Sprintf("%.2f", available)),
+		BalanceDate:      time.Now().Unix(),
+		Transactions:     []simplefin.Transaction{},
+	}
+}
+
+// TransactionFixture returns a random debit for the provided date. Pending
+// transactions do not have a posted timestamp.
+func TransactionFixture(t *testing.T, date time.Time, pending bool) simplefin.Transaction {
+	amount := gofakeit.Float64Range(1, 100)
+	transaction := simplefin.Transaction{
+		Id:           "TRN-" + strings.ToLower(gofakeit.UUID()),
+		Amount:       fmt.Sprintf("-%.2f", amount),
+		Description:  strings.ToUpper(gofakeit.Company()),
+		Payee:        gofakeit.Company(),
+		TransactedAt: myownsanity.Int64P(date.Unix()),
+		Pending:      pending,
+	}
+	if !pending {
+		transaction.Posted = date.Unix()
+	}
+
+	return transaction
+}
+
+// MockClaimSetupToken will respond to the claim URL for the setup token with
+// the provided access URL.
+func MockClaimSetupToken(t *testing.T, claimUrl string, accessUrl string) {
+	httpmock.RegisterResponder(
+		http.MethodPost, claimUrl,
+		httpmock.NewStringResponder(http.StatusOK, accessUrl),
+	)
+}
+
+// MockClaimSetupTokenUsed will respond to the claim URL the same way a
+// SimpleFIN server does when the setup token has already been claimed.
+func MockClaimSetupTokenUsed(t *testing.T, claimUrl string) {
+	httpmock.RegisterResponder(
+		http.MethodPost, claimUrl,
+		httpmock.NewStringResponder(http.StatusForbidden, "Forbidden"),
+	)
+}
+
+// MockGetAccounts will return the provided accounts for any request to the
+// accounts endpoint. If balances only are requested then the transactions are
+// omitted.
+func MockGetAccounts(t *testing.T, accessUrl string, accounts []simplefin.Account) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"GET", Path(t, accessUrl, "/accounts"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidateSimpleFINAuthentication(t, request, accessUrl)
+			result := make([]simplefin.Account, len(accounts))
+			copy(result, accounts)
+			if request.URL.Query().Get("balances-only") == "1" {
+				for i := range result {
+					result[i].Transactions = nil
+				}
+			}
+			return simplefin.AccountSet{
+				Errors:   []string{},
+				Accounts: result,
+			}, http.StatusOK
+		},
+		nil,
+	)
+}
+
+// MockAccessRevoked will make every request for accounts return the status
+// that a SimpleFIN server returns when the access URL has been revoked.
+func MockAccessRevoked(t *testing.T, accessUrl string) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"GET", Path(t, accessUrl, "/accounts"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidateSimpleFINAuthentication(t, request, accessUrl)
+			return "Access has been revoked", http.StatusForbidden
+		},
+		nil,
+	)
+}
@@ -0,0 +1,30 @@
+package mock_simplefin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountFixture(t *testing.T) {
+	account := AccountFixture(t)
+	assert.NotEmpty(t, account.Id, "account ID must not be empty")
+
+	balance, err := account.GetBalance()
+	assert.NoError(t, err, "must be able to parse balance")
+	available, err := account.GetAvailableBalance()
+	assert.NoError(t, err, "must be able to parse available balance")
+	assert.NotZero(t, balance, "balance must not be zero")
+	assert.LessOrEqual(t, available, balance, "available must always be less than or equal to balance")
+
+	transaction := TransactionFixture(t, time.Now(), false)
+	amount, err := transaction.GetAmount(account)
+	assert.NoError(t, err, "must be able to parse amount")
+	assert.Positive(t, amount, "fixture transactions are debits")
+	assert.False(t, transaction.GetIsPending(), "posted transaction must not be pending")
+
+	_, _, err = simplefin.ParseAccessURL(AccessURLFixture(t))
+	assert.NoError(t, err, "access url fixture must be valid")
+}
@@ -0,0 +1,22 @@
+package mock_simplefin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/stretchr/testify/require"
+)
+
+// ValidateSimpleFINAuthentication makes sure that the request was sent with
+// the credentials from the access URL as basic auth.
+func ValidateSimpleFINAuthentication(t *testing.T, request *http.Request, accessUrl string) {
+	_, credentials, err := simplefin.ParseAccessURL(accessUrl)
+	require.NoError(t, err, "must be able to parse access url")
+	expectedPassword, _ := credentials.Password()
+
+	username, password, ok := request.BasicAuth()
+	require.True(t, ok, "request must use basic authentication")
+	require.Equal(t, credentials.Username(), username, "username must match")
+	require.Equal(t, expectedPassword, password, "password must match")
+}
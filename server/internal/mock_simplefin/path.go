@@ -0,0 +1,43 @@
+package mock_simplefin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/monetr/server/simplefin"
+	"github.com/stretchr/testify/require"
+)
+
+// Host is the hostname used for all of the mocked SimpleFIN server requests.
+const Host = "bridge.simplefin.test"
+
+// AccessURLFixture returns an access URL with random credentials, like the
+// one a SimpleFIN server would return when a setup token is claimed.
+func AccessURLFixture(t *testing.T) string {
+	username := strings.ToLower(gofakeit.Generate("????????????????"))
+	password := strings.ToLower(gofakeit.Generate("????????????????????????????????"))
+	return fmt.Sprintf("https://%s:%s@%s/simplefin", username, password, Host)
+}
+
+// SetupTokenFixture returns a setup token and the claim URL that is encoded
+// in it.
+func SetupTokenFixture(t *testing.T) (setupToken string, claimUrl string) {
+	claimUrl = fmt.Sprintf(
+		"https://%s/simplefin/claim/%s",
+		Host,
+		strings.ToLower(gofakeit.Generate("????????????????????????")),
+	)
+	return base64.StdEncoding.EncodeToString([]byte(claimUrl)), claimUrl
+}
+
+// Path returns the URL for the provided path relative to the access URL, the
+// credentials are not included.
+func Path(t *testing.T, accessUrl string, relative string) string {
+	require.NotEmpty(t, relative, "relative url cannot be empty")
+	base, _, err := simplefin.ParseAccessURL(accessUrl)
+	require.NoError(t, err, "must be able to parse access url")
+	return fmt.Sprintf("%s%s", strings.TrimSuffix(base.String(), "/"), relative)
+}
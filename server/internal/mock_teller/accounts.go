@@ -0,0 +1,100 @@
+package mock_teller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/monetr/server/internal/mock_http_helper"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/stretchr/testify/require"
+)
+
+func AccountFixture(t *testing.T, enrollmentId string) teller.Account {
+	accountNumber := gofakeit.AchAccount()
+	require.NotEmpty(t, accountNumber, "account number cannot be empty")
+	mask := accountNumber[len(accountNumber)-4:]
+
+	accountType := teller.AccountType(gofakeit.RandomString([]string{
+		string(teller.AccountTypeDepository),
+		string(teller.AccountTypeCredit),
+	}))
+	subType := "credit_card"
+	if accountType == teller.AccountTypeDepository {
+		subType = gofakeit.RandomString([]string{
+			"checking",
+			"savings",
+		})
+	}
+
+	institution := gofakeit.Company()
+
+	return teller.Account{
+		Id:           "acc_" + strings.ToLower(gofakeit.Generate("????????????????????")),
+		EnrollmentId: enrollmentId,
+		Currency:     "USD",
+		Institution: teller.Institution{
+			Id:   strings.ToLower(strings.ReplaceAll(institution, " ", "_")),
+			Name: institution,
+		},
+		LastFour: mask,
+		Name:     fmt.Sprintf("Personal Account - %s", mask),
+		Type:     accountType,
+		SubType:  subType,
+		Status:   teller.AccountStatusOpen,
+	}
+}
+
+func BalanceFixture(t *testing.T, account teller.Account) teller.Balance {
+	ledger := gofakeit.Float64Range(100, 500)
+	available := gofakeit.Float64Range(ledger-10, ledger)
+	ledgerString := fmt.Sprintf("%.2f", ledger)
+	availableString := fmt.Sprintf("%.2f", available)
+
+	return teller.Balance{
+		AccountId: account.Id,
+		Available: &availableString,
+		Ledger:    &ledgerString,
+	}
+}
+
+func MockGetAccounts(t *testing.T, accessToken string, accounts []teller.Account) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"GET", Path(t, "/accounts"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidateTellerAuthentication(t, request, accessToken)
+			return accounts, http.StatusOK
+		},
+		nil,
+	)
+}
+
+func MockGetAccountBalance(t *testing.T, accessToken string, balances []teller.Balance) {
+	for i := range balances {
+		balance := balances[i]
+		mock_http_helper.NewHttpMockJsonResponder(
+			t,
+			"GET", Path(t, fmt.Sprintf("/accounts/%s/balances", balance.AccountId)),
+			func(t *testing.T, request *http.Request) (interface{}, int) {
+				ValidateTellerAuthentication(t, request, accessToken)
+				return balance, http.StatusOK
+			},
+			nil,
+		)
+	}
+}
+
+func MockRemoveEnrollment(t *testing.T, accessToken string) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"DELETE", Path(t, "/accounts"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidateTellerAuthentication(t, request, accessToken)
+			return nil, http.StatusNoContent
+		},
+		nil,
+	)
+}
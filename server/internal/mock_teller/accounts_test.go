@@ -0,0 +1,21 @@
+package mock_teller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountFixture(t *testing.T) {
+	account := AccountFixture(t, "enr_test")
+	assert.NotEmpty(t, account.Id, "account ID must not be empty")
+	assert.Len(t, account.LastFour, 4, "last four must be four characters")
+
+	balance := BalanceFixture(t, account)
+	available, err := balance.GetAvailable(account.Currency)
+	assert.NoError(t, err, "must be able to parse available balance")
+	ledger, err := balance.GetLedger(account.Currency)
+	assert.NoError(t, err, "must be able to parse ledger balance")
+	assert.NotZero(t, ledger, "ledger must not be zero")
+	assert.LessOrEqual(t, available, ledger, "available must always be less than or equal to ledger")
+}
@@ -0,0 +1,18 @@
+package mock_teller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ValidateTellerAuthentication makes sure that the request was sent with the
+// expected access token, Teller uses the access token as the basic auth
+// username with an empty password.
+func ValidateTellerAuthentication(t *testing.T, request *http.Request, accessToken string) {
+	username, password, ok := request.BasicAuth()
+	require.True(t, ok, "request must use basic authentication")
+	require.Equal(t, accessToken, username, "access token must match")
+	require.Empty(t, password, "password must be blank")
+}
@@ -0,0 +1,27 @@
+package mock_teller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/mock_http_helper"
+)
+
+// MockEnrollmentDisconnected will make every request for accounts return the
+// error that Teller returns when an enrollment needs to be reconnected.
+func MockEnrollmentDisconnected(t *testing.T, accessToken string) {
+	mock_http_helper.NewHttpMockJsonResponder(
+		t,
+		"GET", Path(t, "/accounts"),
+		func(t *testing.T, request *http.Request) (interface{}, int) {
+			ValidateTellerAuthentication(t, request, accessToken)
+			return map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":    "enrollment.disconnected.user_action.credentials_invalid",
+					"message": "The user's credentials are no longer valid.",
+				},
+			}, http.StatusNotFound
+		},
+		nil,
+	)
+}
@@ -0,0 +1,102 @@
+package mock_teller
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/monetr/monetr/server/internal/mock_http_helper"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/stretchr/testify/require"
+)
+
+func TransactionFixture(
+	t *testing.T,
+	account teller.Account,
+	date time.Time,
+	pending bool,
+) teller.Transaction {
+	amount := gofakeit.Float64Range(1, 100)
+	// Teller reports money leaving a depository account as a negative amount.
+	if account.Type == teller.AccountTypeDepository {
+		amount = -amount
+	}
+
+	status := teller.TransactionStatusPosted
+	if pending {
+		status = teller.TransactionStatusPending
+	}
+
+	merchant := gofakeit.Company()
+
+	return teller.Transaction{
+		Id:          "txn_" + strings.ToLower(gofakeit.Generate("????????????????????")),
+		AccountId:   account.Id,
+		Amount:      fmt.Sprintf("%.2f", amount),
+		Date:        date.Format("2006-01-02"),
+		Description: strings.ToUpper(fmt.Sprintf("%s %s", merchant, gofakeit.Generate("#####"))),
+		Details: teller.TransactionDetails{
+			ProcessingStatus: "complete",
+			Category:         myownsanity.StringP("general"),
+			Counterparty: &teller.Counterparty{
+				Name: myownsanity.StringP(merchant),
+				Type: myownsanity.StringP("organization"),
+			},
+		},
+		Status: status,
+		Type:   "card_payment",
+	}
+}
+
+// MockGetTransactions will respond to transaction requests for each of the
+// accounts that the provided transactions belong to. Transactions are returned
+// newest first and the from_id and count parameters are respected the same way
+// that Teller does.
+func MockGetTransactions(t *testing.T, accessToken string, transactions []teller.Transaction) {
+	byAccount := map[string][]teller.Transaction{}
+	for _, transaction := range transactions {
+		byAccount[transaction.AccountId] = append(byAccount[transaction.AccountId], transaction)
+	}
+
+	for accountId := range byAccount {
+		items := byAccount[accountId]
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Date > items[j].Date
+		})
+
+		mock_http_helper.NewHttpMockJsonResponder(
+			t,
+			"GET", Path(t, fmt.Sprintf("/accounts/%s/transactions", accountId)),
+			func(t *testing.T, request *http.Request) (interface{}, int) {
+				ValidateTellerAuthentication(t, request, accessToken)
+
+				result := items
+				if fromId := request.URL.Query().Get("from_id"); fromId != "" {
+					for i := range items {
+						if items[i].Id == fromId {
+							result = items[i+1:]
+							break
+						}
+					}
+				}
+
+				if countString := request.URL.Query().Get("count"); countString != "" {
+					count, err := strconv.Atoi(countString)
+					require.NoError(t, err, "count must be a valid number")
+					if count < len(result) {
+						result = result[:count]
+					}
+				}
+
+				return result, http.StatusOK
+			},
+			nil,
+		)
+	}
+}
@@ -20,6 +20,13 @@ func TestInt32P(t *testing.T) {
 	assert.Equal(t, input, *result, "and the underlying value should match the input")
 }
 
+func TestInt64P(t *testing.T) {
+	var input int64 = 12345
+	result := Int64P(input)
+	assert.NotNil(t, result, "resulting pointer should never be nil")
+	assert.Equal(t, input, *result, "and the underlying value should match the input")
+}
+
 func TestMax(t *testing.T) {
 	assert.Equal(t, 2, Max(1, 2))
 	assert.Equal(t, 1000, Max(1000, 100))
@@ -31,3 +38,17 @@ func TestMin(t *testing.T) {
 	assert.Equal(t, 100, Min(1000, 100))
 	assert.Equal(t, 500, Min(500, 500))
 }
+
+func TestAbs(t *testing.T) {
+	assert.Equal(t, 1, Abs(-1))
+	assert.Equal(t, int64(1000), Abs(int64(1000)))
+	assert.Equal(t, 0, Abs(0))
+}
+
+func TestInt64PEqual(t *testing.T) {
+	assert.True(t, Int64PEqual(Int64P(1), Int64P(1)), "should be equal")
+	assert.False(t, Int64PEqual(Int64P(1), Int64P(2)), "should not be equal")
+	assert.False(t, Int64PEqual(Int64P(1), nil), "should not be equal")
+	assert.False(t, Int64PEqual(nil, Int64P(2)), "should not be equal")
+	assert.True(t, Int64PEqual(nil, nil), "should be equal")
+}
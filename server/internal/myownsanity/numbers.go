@@ -12,6 +12,10 @@ func Int32P(value int32) *int32 {
 	return &value
 }
 
+func Int64P(value int64) *int64 {
+	return &value
+}
+
 type Number interface {
 	int | int32 | int64
 }
@@ -31,3 +35,19 @@ func Min[T Number](a, b T) T {
 
 	return b
 }
+
+func Abs[T Number](a T) T {
+	if a < 0 {
+		return -a
+	}
+
+	return a
+}
+
+func Int64PEqual(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -41,6 +41,15 @@ func GetConfig(t *testing.T) config.Configuration {
 			ClientSecret: gofakeit.UUID(),
 			Environment:  plaid.Sandbox,
 		},
+		Teller: config.Teller{
+			Enabled:              true,
+			ApplicationId:        "app_" + gofakeit.UUID(),
+			Environment:          config.TellerSandbox,
+			WebhookSigningSecret: gofakeit.UUID(),
+		},
+		SimpleFIN: config.SimpleFIN{
+			Enabled: true,
+		},
 		CORS: config.CORS{
 			Debug: false,
 		},
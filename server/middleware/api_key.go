@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-pg/pg/v10"
@@ -58,13 +59,21 @@ func APIKeyAuthentication(repo repository.APIKeyRepository, db pg.DBI) echo.Midd
 				return echo.NewHTTPError(http.StatusUnauthorized, "User not found for API key")
 			}
 
-			// Create and set security claims for the API key
+			// Create and set security claims for the API key. The key is limited to
+			// the scopes and bank accounts it was granted when it was created.
 			claims := security.Claims{
 				CreatedAt: time.Now(),
 				UserId:    key.UserId,
 				LoginId:   string(user.LoginId),
 				AccountId: string(user.AccountId),
-				Scope:     security.AuthenticatedScope,
+				Scope:     security.APIKeyScope,
+				APIKeyId:  strconv.FormatInt(key.APIKeyId, 10),
+			}
+			for _, scope := range key.Scopes {
+				claims.Grants = append(claims.Grants, security.Scope(scope))
+			}
+			for _, bankAccountId := range key.BankAccountIds {
+				claims.BankAccountIds = append(claims.BankAccountIds, string(bankAccountId))
 			}
 			
 			// Store the authentication claims on the request context
@@ -0,0 +1,16 @@
+CREATE TABLE "transaction_upload_mappings" (
+  "transaction_upload_mapping_id" VARCHAR(32)              NOT NULL,
+  "account_id"                    VARCHAR(32)              NOT NULL,
+  "bank_account_id"               VARCHAR(32)              NOT NULL,
+  "fields"                        JSONB                    NOT NULL,
+  "header_row"                    BOOLEAN                  NOT NULL DEFAULT FALSE,
+  "date_format"                   TEXT                     NOT NULL DEFAULT '',
+  "created_at"                    TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_by"                    VARCHAR(32)              NOT NULL,
+  "updated_at"                    TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_transaction_upload_mappings" PRIMARY KEY ("transaction_upload_mapping_id", "account_id"),
+  CONSTRAINT "uq_transaction_upload_mappings_bank_account" UNIQUE ("account_id", "bank_account_id"),
+  CONSTRAINT "fk_transaction_upload_mappings_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_transaction_upload_mappings_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id"),
+  CONSTRAINT "fk_transaction_upload_mappings_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
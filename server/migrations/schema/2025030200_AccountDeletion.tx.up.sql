@@ -0,0 +1,9 @@
+-- When the owner of an account requests that the account be deleted, the
+-- account is not removed immediately. Instead it is scheduled to be removed
+-- after a grace period, during which the owner can still cancel the deletion.
+ALTER TABLE "accounts"
+ADD COLUMN "deletion_requested_at" TIMESTAMP WITH TIME ZONE,
+ADD COLUMN "deletion_scheduled_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX "ix_accounts_deletion_scheduled_at" ON "accounts" ("deletion_scheduled_at")
+WHERE "deletion_scheduled_at" IS NOT NULL;
@@ -0,0 +1,21 @@
+-- API keys can now be granted a subset of permissions and can optionally be
+-- restricted to specific bank accounts. Keys that already exist are granted
+-- every scope so that they continue to work as they did before.
+ALTER TABLE "api_keys"
+ADD COLUMN "scopes"           TEXT[]        NOT NULL DEFAULT '{}',
+ADD COLUMN "bank_account_ids" VARCHAR(32)[] NOT NULL DEFAULT '{}';
+
+UPDATE "api_keys"
+SET "scopes" = ARRAY[
+  'bankAccounts:read',
+  'bankAccounts:write',
+  'transactions:read',
+  'transactions:write',
+  'spending:read',
+  'spending:write',
+  'fundingSchedules:read',
+  'fundingSchedules:write',
+  'links:read',
+  'links:write',
+  'sync:trigger'
+];
@@ -0,0 +1,20 @@
+-- Transactions can be split across multiple spending objects. Each split has
+-- its own amount and spending object, and the amounts of all of the splits for
+-- a transaction must add up to the amount of the transaction.
+CREATE TABLE "transaction_splits" (
+  "transaction_split_id" VARCHAR(32)              NOT NULL,
+  "account_id"           VARCHAR(32)              NOT NULL,
+  "bank_account_id"      VARCHAR(32)              NOT NULL,
+  "transaction_id"       VARCHAR(32)              NOT NULL,
+  "spending_id"          VARCHAR(32),
+  "amount"               BIGINT                   NOT NULL,
+  "spending_amount"      BIGINT,
+  "created_at"           TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_transaction_splits" PRIMARY KEY ("transaction_split_id", "account_id"),
+  CONSTRAINT "fk_transaction_splits_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_transaction_splits_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id"),
+  CONSTRAINT "fk_transaction_splits_transaction" FOREIGN KEY ("transaction_id", "account_id", "bank_account_id") REFERENCES "transactions" ("transaction_id", "account_id", "bank_account_id") ON DELETE CASCADE,
+  CONSTRAINT "fk_transaction_splits_spending" FOREIGN KEY ("spending_id", "account_id", "bank_account_id") REFERENCES "spending" ("spending_id", "account_id", "bank_account_id")
+);
+
+CREATE INDEX "ix_transaction_splits_transaction" ON "transaction_splits" ("account_id", "bank_account_id", "transaction_id");
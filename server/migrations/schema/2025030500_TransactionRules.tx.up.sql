@@ -0,0 +1,34 @@
+-- Transaction rules are evaluated against new transactions as they are synced
+-- or imported for a bank account. The first enabled rule (by priority) whose
+-- conditions all match the transaction will have its actions applied to it.
+CREATE TABLE "transaction_rules" (
+  "transaction_rule_id" VARCHAR(32)              NOT NULL,
+  "account_id"          VARCHAR(32)              NOT NULL,
+  "bank_account_id"     VARCHAR(32)              NOT NULL,
+  "name"                TEXT                     NOT NULL,
+  "priority"            INTEGER                  NOT NULL DEFAULT 0,
+  "is_enabled"          BOOLEAN                  NOT NULL DEFAULT TRUE,
+  "name_pattern"        TEXT,
+  "merchant_pattern"    TEXT,
+  "min_amount"          BIGINT,
+  "max_amount"          BIGINT,
+  "days_of_month"       INTEGER[],
+  "category"            TEXT,
+  "spending_id"         VARCHAR(32),
+  "rename_to"           TEXT,
+  "hide"                BOOLEAN                  NOT NULL DEFAULT FALSE,
+  "created_at"          TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_by"          VARCHAR(32)              NOT NULL,
+  "updated_at"          TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_transaction_rules" PRIMARY KEY ("transaction_rule_id", "account_id"),
+  CONSTRAINT "fk_transaction_rules_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_transaction_rules_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id"),
+  CONSTRAINT "fk_transaction_rules_spending" FOREIGN KEY ("spending_id", "account_id", "bank_account_id") REFERENCES "spending" ("spending_id", "account_id", "bank_account_id"),
+  CONSTRAINT "fk_transaction_rules_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
+
+CREATE INDEX "ix_transaction_rules_bank_account" ON "transaction_rules" ("account_id", "bank_account_id", "priority");
+
+-- Rules can hide transactions that the user does not want to see in their
+-- transaction list.
+ALTER TABLE "transactions" ADD COLUMN "is_hidden" BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,25 @@
+-- Recurring transactions are detected periodically from the transaction
+-- clusters of a bank account. The entire set of recurring transactions for a
+-- bank account is replaced each time detection runs.
+CREATE TABLE "transaction_recurring" (
+  "transaction_recurring_id" VARCHAR(32)              NOT NULL,
+  "account_id"               VARCHAR(32)              NOT NULL,
+  "bank_account_id"          VARCHAR(32)              NOT NULL,
+  "name"                     TEXT                     NOT NULL,
+  "window_type"              TEXT                     NOT NULL,
+  "ruleset"                  TEXT                     NOT NULL,
+  "first"                    TIMESTAMP WITH TIME ZONE NOT NULL,
+  "last"                     TIMESTAMP WITH TIME ZONE NOT NULL,
+  "next"                     TIMESTAMP WITH TIME ZONE NOT NULL,
+  "ended"                    BOOLEAN                  NOT NULL DEFAULT FALSE,
+  "confidence"               DOUBLE PRECISION         NOT NULL,
+  "amounts"                  JSONB                    NOT NULL,
+  "last_amount"              BIGINT                   NOT NULL,
+  "members"                  VARCHAR(32)[]            NOT NULL,
+  "created_at"               TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+  CONSTRAINT "pk_transaction_recurring" PRIMARY KEY ("transaction_recurring_id", "account_id"),
+  CONSTRAINT "fk_transaction_recurring_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_transaction_recurring_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id")
+);
+
+CREATE INDEX "ix_transaction_recurring_bank_account" ON "transaction_recurring" ("account_id", "bank_account_id");
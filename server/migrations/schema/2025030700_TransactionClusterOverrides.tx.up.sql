@@ -0,0 +1,18 @@
+-- Transaction clusters are regenerated every time new transactions come in,
+-- so any changes the user makes to a cluster are stored here by the cluster's
+-- signature and are re-applied each time the clusters are calculated.
+CREATE TABLE "transaction_cluster_overrides" (
+  "transaction_cluster_override_id" VARCHAR(32)              NOT NULL,
+  "account_id"                      VARCHAR(32)              NOT NULL,
+  "bank_account_id"                 VARCHAR(32)              NOT NULL,
+  "signature"                       TEXT                     NOT NULL,
+  "name"                            TEXT,
+  "merged_into"                     TEXT,
+  "members"                         VARCHAR(32)[],
+  "created_at"                      TIMESTAMP WITH TIME ZONE NOT NULL,
+  "updated_at"                      TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_transaction_cluster_overrides" PRIMARY KEY ("transaction_cluster_override_id", "account_id"),
+  CONSTRAINT "uq_transaction_cluster_overrides_signature" UNIQUE ("account_id", "bank_account_id", "signature"),
+  CONSTRAINT "fk_transaction_cluster_overrides_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_transaction_cluster_overrides_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id")
+);
@@ -0,0 +1,27 @@
+-- Spending allocations are an append-only ledger of every change made to the
+-- current amount of a spending object. Each entry records why the amount
+-- changed (a contribution, transfer, spend or refund) and what caused it. The
+-- other references are intentionally not foreign keys so that the history is
+-- kept even after a transaction or funding schedule is removed.
+CREATE TABLE "spending_allocations" (
+  "spending_allocation_id"  VARCHAR(32)              NOT NULL,
+  "account_id"              VARCHAR(32)              NOT NULL,
+  "bank_account_id"         VARCHAR(32)              NOT NULL,
+  "spending_id"             VARCHAR(32)              NOT NULL,
+  "kind"                    TEXT                     NOT NULL,
+  "source"                  TEXT                     NOT NULL,
+  "amount"                  BIGINT                   NOT NULL,
+  "current_amount"          BIGINT                   NOT NULL,
+  "funding_schedule_id"     VARCHAR(32),
+  "transaction_id"          VARCHAR(32),
+  "transaction_split_id"    VARCHAR(32),
+  "counterpart_spending_id" VARCHAR(32),
+  "user_id"                 VARCHAR(32),
+  "created_at"              TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_spending_allocations" PRIMARY KEY ("spending_allocation_id", "account_id"),
+  CONSTRAINT "fk_spending_allocations_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_spending_allocations_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id"),
+  CONSTRAINT "fk_spending_allocations_spending" FOREIGN KEY ("spending_id", "account_id", "bank_account_id") REFERENCES "spending" ("spending_id", "account_id", "bank_account_id")
+);
+
+CREATE INDEX "ix_spending_allocations_spending" ON "spending_allocations" ("account_id", "bank_account_id", "spending_id", "created_at");
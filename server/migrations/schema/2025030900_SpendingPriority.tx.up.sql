@@ -0,0 +1,6 @@
+-- Spending objects are funded in order of their priority, lowest first. When
+-- there is not enough free-to-use to fund every spending object then the
+-- amount that could not be contributed is stored as the shortfall.
+ALTER TABLE "spending"
+ADD COLUMN "priority"               INT    NOT NULL DEFAULT 0,
+ADD COLUMN "contribution_shortfall" BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Funding schedules that wait for a deposit now describe the deposit they are
+-- waiting for. Only deposits that arrive within the tolerance window around the
+-- next recurrence are considered, and the deposit that triggered the most
+-- recent funding is recorded so that it is not used twice. Existing schedules
+-- keep matching any deposit, but only within a day of when they are due.
+ALTER TABLE "funding_schedules"
+ADD COLUMN "deposit_name_pattern"        TEXT,
+ADD COLUMN "deposit_minimum_amount"      BIGINT,
+ADD COLUMN "deposit_tolerance_days"      INT    NOT NULL DEFAULT 1,
+ADD COLUMN "last_deposit_transaction_id" VARCHAR(32);
@@ -0,0 +1,40 @@
+-- Balance snapshots record the balances of a bank account once a day so that
+-- they can be shown over time. There is only ever one snapshot per bank
+-- account per day, taking the snapshot again replaces it.
+CREATE TABLE "balance_snapshots" (
+  "balance_snapshot_id" VARCHAR(32)              NOT NULL,
+  "account_id"          VARCHAR(32)              NOT NULL,
+  "bank_account_id"     VARCHAR(32)              NOT NULL,
+  "date"                TIMESTAMP WITH TIME ZONE NOT NULL,
+  "current"             BIGINT                   NOT NULL,
+  "available"           BIGINT                   NOT NULL,
+  "free"                BIGINT                   NOT NULL,
+  "expenses"            BIGINT                   NOT NULL,
+  "goals"               BIGINT                   NOT NULL,
+  "created_at"          TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_balance_snapshots" PRIMARY KEY ("balance_snapshot_id", "account_id"),
+  CONSTRAINT "uq_balance_snapshots_date" UNIQUE ("account_id", "bank_account_id", "date"),
+  CONSTRAINT "fk_balance_snapshots_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_balance_snapshots_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id")
+);
+
+-- Balance alerts are configured per bank account, the result of the most
+-- recent check is stored on the alert itself.
+CREATE TABLE "balance_alerts" (
+  "balance_alert_id"  VARCHAR(32)              NOT NULL,
+  "account_id"        VARCHAR(32)              NOT NULL,
+  "bank_account_id"   VARCHAR(32)              NOT NULL,
+  "threshold"         BIGINT                   NOT NULL,
+  "days"              INT                      NOT NULL,
+  "is_enabled"        BOOLEAN                  NOT NULL DEFAULT true,
+  "is_triggered"      BOOLEAN                  NOT NULL DEFAULT false,
+  "triggered_date"    TIMESTAMP WITH TIME ZONE,
+  "projected_balance" BIGINT,
+  "last_checked_at"   TIMESTAMP WITH TIME ZONE,
+  "created_at"        TIMESTAMP WITH TIME ZONE NOT NULL,
+  "updated_at"        TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_balance_alerts" PRIMARY KEY ("balance_alert_id", "account_id"),
+  CONSTRAINT "uq_balance_alerts_bank_account" UNIQUE ("account_id", "bank_account_id"),
+  CONSTRAINT "fk_balance_alerts_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_balance_alerts_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id")
+);
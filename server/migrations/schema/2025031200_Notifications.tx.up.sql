@@ -0,0 +1,38 @@
+-- Notifications are created by background jobs and delivered separately, the
+-- dedupe key keeps the same event from being recorded more than once.
+CREATE TABLE "notifications" (
+  "notification_id" VARCHAR(32)              NOT NULL,
+  "account_id"      VARCHAR(32)              NOT NULL,
+  "bank_account_id" VARCHAR(32),
+  "kind"            TEXT                     NOT NULL,
+  "dedupe_key"      TEXT                     NOT NULL,
+  "title"           TEXT                     NOT NULL,
+  "message"         TEXT                     NOT NULL,
+  "amount"          BIGINT,
+  "data"            JSONB,
+  "created_at"      TIMESTAMP WITH TIME ZONE NOT NULL,
+  "sent_at"         TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_notifications" PRIMARY KEY ("notification_id", "account_id"),
+  CONSTRAINT "uq_notifications_dedupe_key" UNIQUE ("account_id", "dedupe_key"),
+  CONSTRAINT "fk_notifications_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_notifications_bank_account" FOREIGN KEY ("bank_account_id", "account_id") REFERENCES "bank_accounts" ("bank_account_id", "account_id")
+);
+
+CREATE INDEX "ix_notifications_unsent" ON "notifications" ("account_id", "created_at") WHERE "sent_at" IS NULL;
+
+CREATE TABLE "notification_preferences" (
+  "notification_preference_id" VARCHAR(32)              NOT NULL,
+  "account_id"                 VARCHAR(32)              NOT NULL,
+  "user_id"                    VARCHAR(32)              NOT NULL,
+  "kind"                       TEXT                     NOT NULL,
+  "is_enabled"                 BOOLEAN                  NOT NULL DEFAULT true,
+  "channels"                   TEXT[]                   NOT NULL DEFAULT '{}',
+  "webhook_url"                TEXT,
+  "threshold"                  BIGINT,
+  "created_at"                 TIMESTAMP WITH TIME ZONE NOT NULL,
+  "updated_at"                 TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_notification_preferences" PRIMARY KEY ("notification_preference_id", "account_id"),
+  CONSTRAINT "uq_notification_preferences_kind" UNIQUE ("account_id", "user_id", "kind"),
+  CONSTRAINT "fk_notification_preferences_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_notification_preferences_user" FOREIGN KEY ("user_id") REFERENCES "users" ("user_id")
+);
@@ -0,0 +1,41 @@
+CREATE TABLE "webhooks" (
+  "webhook_id"  VARCHAR(32)              NOT NULL,
+  "account_id"  VARCHAR(32)              NOT NULL,
+  "url"         TEXT                     NOT NULL,
+  "description" TEXT,
+  "event_types" TEXT[]                   NOT NULL DEFAULT '{}',
+  "is_enabled"  BOOLEAN                  NOT NULL DEFAULT true,
+  "secret_id"   VARCHAR(32)              NOT NULL,
+  "created_by"  VARCHAR(32)              NOT NULL,
+  "created_at"  TIMESTAMP WITH TIME ZONE NOT NULL,
+  "updated_at"  TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT "pk_webhooks" PRIMARY KEY ("webhook_id", "account_id"),
+  CONSTRAINT "fk_webhooks_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_webhooks_secret" FOREIGN KEY ("secret_id", "account_id") REFERENCES "secrets" ("secret_id", "account_id"),
+  CONSTRAINT "fk_webhooks_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
+
+-- Deliveries double as the delivery log, pending deliveries are picked up by
+-- the deliver webhooks job once their next attempt time has passed.
+CREATE TABLE "webhook_deliveries" (
+  "webhook_delivery_id" VARCHAR(32)              NOT NULL,
+  "account_id"          VARCHAR(32)              NOT NULL,
+  "webhook_id"          VARCHAR(32)              NOT NULL,
+  "event_id"            VARCHAR(32)              NOT NULL,
+  "event_type"          TEXT                     NOT NULL,
+  "payload"             JSONB                    NOT NULL,
+  "status"              TEXT                     NOT NULL,
+  "attempts"            INTEGER                  NOT NULL DEFAULT 0,
+  "response_status"     INTEGER,
+  "error"               TEXT,
+  "next_attempt_at"     TIMESTAMP WITH TIME ZONE,
+  "last_attempt_at"     TIMESTAMP WITH TIME ZONE,
+  "created_at"          TIMESTAMP WITH TIME ZONE NOT NULL,
+  "delivered_at"        TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_webhook_deliveries" PRIMARY KEY ("webhook_delivery_id", "account_id"),
+  CONSTRAINT "fk_webhook_deliveries_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_webhook_deliveries_webhook" FOREIGN KEY ("webhook_id", "account_id") REFERENCES "webhooks" ("webhook_id", "account_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "ix_webhook_deliveries_webhook" ON "webhook_deliveries" ("account_id", "webhook_id", "created_at" DESC);
+CREATE INDEX "ix_webhook_deliveries_due" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
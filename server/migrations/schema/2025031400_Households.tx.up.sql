@@ -0,0 +1,23 @@
+-- Members that are removed from an account are soft deleted, other records
+-- still reference the user that created them.
+ALTER TABLE "users" ADD COLUMN "deleted_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE "invitations" (
+  "invitation_id" VARCHAR(32)              NOT NULL,
+  "account_id"    VARCHAR(32)              NOT NULL,
+  "email"         TEXT                     NOT NULL,
+  "role"          user_role                NOT NULL,
+  "invited_by"    VARCHAR(32)              NOT NULL,
+  "created_at"    TIMESTAMP WITH TIME ZONE NOT NULL,
+  "expires_at"    TIMESTAMP WITH TIME ZONE NOT NULL,
+  "accepted_by"   VARCHAR(32),
+  "accepted_at"   TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_invitations" PRIMARY KEY ("invitation_id", "account_id"),
+  CONSTRAINT "fk_invitations_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_invitations_invited_by" FOREIGN KEY ("invited_by") REFERENCES "users" ("user_id"),
+  CONSTRAINT "fk_invitations_accepted_by" FOREIGN KEY ("accepted_by") REFERENCES "users" ("user_id")
+);
+
+-- Only a single invitation can be pending for an email address at a time,
+-- inviting someone again replaces their pending invitation.
+CREATE UNIQUE INDEX "uq_invitations_pending_email" ON "invitations" ("account_id", "email") WHERE "accepted_at" IS NULL;
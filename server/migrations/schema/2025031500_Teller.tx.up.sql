@@ -0,0 +1,81 @@
+-- Teller was previously supported by monetr but its tables were dropped when we
+-- moved to string IDs. This brings it back using the same layout as the Plaid
+-- tables.
+CREATE TABLE "teller_links" (
+  "teller_link_id"         VARCHAR(32)              NOT NULL,
+  "account_id"             VARCHAR(32)              NOT NULL,
+  "secret_id"              VARCHAR(32),
+  "enrollment_id"          TEXT                     NOT NULL,
+  "teller_user_id"         TEXT                     NOT NULL,
+  "status"                 INT                      NOT NULL DEFAULT 0,
+  "error_code"             TEXT,
+  "institution_name"       TEXT                     NOT NULL,
+  "last_manual_sync"       TIMESTAMP WITH TIME ZONE,
+  "last_successful_update" TIMESTAMP WITH TIME ZONE,
+  "last_attempted_update"  TIMESTAMP WITH TIME ZONE,
+  "updated_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_by"             VARCHAR(32)              NOT NULL,
+  "deleted_at"             TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_teller_links" PRIMARY KEY ("teller_link_id", "account_id"),
+  CONSTRAINT "uq_teller_links_enrollment_id" UNIQUE ("enrollment_id"),
+  CONSTRAINT "fk_teller_links_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_teller_links_secret" FOREIGN KEY ("secret_id", "account_id") REFERENCES "secrets" ("secret_id", "account_id"),
+  CONSTRAINT "fk_teller_links_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
+
+CREATE TABLE "teller_bank_accounts" (
+  "teller_bank_account_id" VARCHAR(32)              NOT NULL,
+  "account_id"             VARCHAR(32)              NOT NULL,
+  "teller_link_id"         VARCHAR(32)              NOT NULL,
+  "teller_id"              TEXT                     NOT NULL,
+  "institution_id"         TEXT                     NOT NULL,
+  "institution_name"       TEXT                     NOT NULL,
+  "name"                   VARCHAR(200)             NOT NULL,
+  "mask"                   VARCHAR(50),
+  "type"                   TEXT                     NOT NULL,
+  "sub_type"               TEXT                     NOT NULL,
+  "status"                 TEXT                     NOT NULL,
+  "currency"               TEXT                     NOT NULL,
+  "available_balance"      BIGINT                   NOT NULL,
+  "ledger_balance"         BIGINT                   NOT NULL,
+  "balanced_at"            TIMESTAMP WITH TIME ZONE,
+  "created_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_by"             VARCHAR(32)              NOT NULL,
+  CONSTRAINT "pk_teller_bank_accounts" PRIMARY KEY ("teller_bank_account_id", "account_id"),
+  CONSTRAINT "uq_teller_bank_accounts_teller_id" UNIQUE ("teller_link_id", "teller_id"),
+  CONSTRAINT "fk_teller_bank_accounts_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_teller_bank_accounts_teller_link" FOREIGN KEY ("teller_link_id", "account_id") REFERENCES "teller_links" ("teller_link_id", "account_id"),
+  CONSTRAINT "fk_teller_bank_accounts_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
+
+CREATE TABLE "teller_transactions" (
+  "teller_transaction_id"  VARCHAR(32)              NOT NULL,
+  "account_id"             VARCHAR(32)              NOT NULL,
+  "teller_bank_account_id" VARCHAR(32)              NOT NULL,
+  "teller_id"              TEXT                     NOT NULL,
+  "name"                   TEXT                     NOT NULL,
+  "category"               TEXT,
+  "type"                   TEXT                     NOT NULL,
+  "date"                   DATE                     NOT NULL,
+  "is_pending"             BOOLEAN                  NOT NULL,
+  "amount"                 BIGINT                   NOT NULL,
+  "running_balance"        BIGINT,
+  "created_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "updated_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "deleted_at"             TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_teller_transactions" PRIMARY KEY ("teller_transaction_id", "account_id"),
+  CONSTRAINT "uq_teller_transactions_teller_id" UNIQUE ("teller_bank_account_id", "teller_id"),
+  CONSTRAINT "fk_teller_transactions_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_teller_transactions_teller_bank_account" FOREIGN KEY ("teller_bank_account_id", "account_id") REFERENCES "teller_bank_accounts" ("teller_bank_account_id", "account_id")
+);
+
+ALTER TABLE "links" ADD COLUMN "teller_link_id" VARCHAR(32);
+ALTER TABLE "links" ADD CONSTRAINT "fk_links_teller_link" FOREIGN KEY ("teller_link_id", "account_id") REFERENCES "teller_links" ("teller_link_id", "account_id");
+
+ALTER TABLE "bank_accounts" ADD COLUMN "teller_bank_account_id" VARCHAR(32);
+ALTER TABLE "bank_accounts" ADD CONSTRAINT "fk_bank_accounts_teller_bank_account" FOREIGN KEY ("teller_bank_account_id", "account_id") REFERENCES "teller_bank_accounts" ("teller_bank_account_id", "account_id");
+
+ALTER TABLE "transactions" ADD COLUMN "teller_transaction_id" VARCHAR(32);
+ALTER TABLE "transactions" ADD CONSTRAINT "fk_transactions_teller_transaction" FOREIGN KEY ("teller_transaction_id", "account_id") REFERENCES "teller_transactions" ("teller_transaction_id", "account_id");
+CREATE UNIQUE INDEX "uq_transactions_teller_transaction" ON "transactions" ("account_id", "teller_transaction_id") WHERE "teller_transaction_id" IS NOT NULL;
@@ -0,0 +1,76 @@
+-- SimpleFIN links are set up by the user with a setup token from their own
+-- SimpleFIN server. The tables follow the same layout as the Plaid and Teller
+-- tables.
+CREATE TABLE "simplefin_links" (
+  "simplefin_link_id"      VARCHAR(32)              NOT NULL,
+  "account_id"             VARCHAR(32)              NOT NULL,
+  "secret_id"              VARCHAR(32),
+  "server"                 TEXT                     NOT NULL,
+  "status"                 INT                      NOT NULL DEFAULT 0,
+  "error_code"             TEXT,
+  "institution_name"       TEXT                     NOT NULL,
+  "last_manual_sync"       TIMESTAMP WITH TIME ZONE,
+  "last_successful_update" TIMESTAMP WITH TIME ZONE,
+  "last_attempted_update"  TIMESTAMP WITH TIME ZONE,
+  "updated_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_at"             TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_by"             VARCHAR(32)              NOT NULL,
+  "deleted_at"             TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_simplefin_links" PRIMARY KEY ("simplefin_link_id", "account_id"),
+  CONSTRAINT "fk_simplefin_links_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_simplefin_links_secret" FOREIGN KEY ("secret_id", "account_id") REFERENCES "secrets" ("secret_id", "account_id"),
+  CONSTRAINT "fk_simplefin_links_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
+
+CREATE TABLE "simplefin_bank_accounts" (
+  "simplefin_bank_account_id" VARCHAR(32)              NOT NULL,
+  "account_id"                VARCHAR(32)              NOT NULL,
+  "simplefin_link_id"         VARCHAR(32)              NOT NULL,
+  "simplefin_id"              TEXT                     NOT NULL,
+  "organization_name"         TEXT                     NOT NULL,
+  "organization_domain"       TEXT,
+  "name"                      VARCHAR(200)             NOT NULL,
+  "currency"                  TEXT                     NOT NULL,
+  "balance"                   BIGINT                   NOT NULL,
+  "available_balance"         BIGINT                   NOT NULL,
+  "balance_date"              TIMESTAMP WITH TIME ZONE,
+  "created_at"                TIMESTAMP WITH TIME ZONE NOT NULL,
+  "created_by"                VARCHAR(32)              NOT NULL,
+  CONSTRAINT "pk_simplefin_bank_accounts" PRIMARY KEY ("simplefin_bank_account_id", "account_id"),
+  CONSTRAINT "uq_simplefin_bank_accounts_simplefin_id" UNIQUE ("simplefin_link_id", "simplefin_id"),
+  CONSTRAINT "fk_simplefin_bank_accounts_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_simplefin_bank_accounts_simplefin_link" FOREIGN KEY ("simplefin_link_id", "account_id") REFERENCES "simplefin_links" ("simplefin_link_id", "account_id"),
+  CONSTRAINT "fk_simplefin_bank_accounts_created_by" FOREIGN KEY ("created_by") REFERENCES "users" ("user_id")
+);
+
+CREATE TABLE "simplefin_transactions" (
+  "simplefin_transaction_id"  VARCHAR(32)              NOT NULL,
+  "account_id"                VARCHAR(32)              NOT NULL,
+  "simplefin_bank_account_id" VARCHAR(32)              NOT NULL,
+  "simplefin_id"              TEXT                     NOT NULL,
+  "description"               TEXT                     NOT NULL,
+  "payee"                     TEXT,
+  "memo"                      TEXT,
+  "amount"                    BIGINT                   NOT NULL,
+  "is_pending"                BOOLEAN                  NOT NULL,
+  "posted_at"                 TIMESTAMP WITH TIME ZONE,
+  "transacted_at"             TIMESTAMP WITH TIME ZONE,
+  "created_at"                TIMESTAMP WITH TIME ZONE NOT NULL,
+  "updated_at"                TIMESTAMP WITH TIME ZONE NOT NULL,
+  "deleted_at"                TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT "pk_simplefin_transactions" PRIMARY KEY ("simplefin_transaction_id", "account_id"),
+  -- Transactions are deduplicated by the ID the SimpleFIN server provides.
+  CONSTRAINT "uq_simplefin_transactions_simplefin_id" UNIQUE ("simplefin_bank_account_id", "simplefin_id"),
+  CONSTRAINT "fk_simplefin_transactions_account" FOREIGN KEY ("account_id") REFERENCES "accounts" ("account_id"),
+  CONSTRAINT "fk_simplefin_transactions_simplefin_bank_account" FOREIGN KEY ("simplefin_bank_account_id", "account_id") REFERENCES "simplefin_bank_accounts" ("simplefin_bank_account_id", "account_id")
+);
+
+ALTER TABLE "links" ADD COLUMN "simplefin_link_id" VARCHAR(32);
+ALTER TABLE "links" ADD CONSTRAINT "fk_links_simplefin_link" FOREIGN KEY ("simplefin_link_id", "account_id") REFERENCES "simplefin_links" ("simplefin_link_id", "account_id");
+
+ALTER TABLE "bank_accounts" ADD COLUMN "simplefin_bank_account_id" VARCHAR(32);
+ALTER TABLE "bank_accounts" ADD CONSTRAINT "fk_bank_accounts_simplefin_bank_account" FOREIGN KEY ("simplefin_bank_account_id", "account_id") REFERENCES "simplefin_bank_accounts" ("simplefin_bank_account_id", "account_id");
+
+ALTER TABLE "transactions" ADD COLUMN "simplefin_transaction_id" VARCHAR(32);
+ALTER TABLE "transactions" ADD CONSTRAINT "fk_transactions_simplefin_transaction" FOREIGN KEY ("simplefin_transaction_id", "account_id") REFERENCES "simplefin_transactions" ("simplefin_transaction_id", "account_id");
+CREATE UNIQUE INDEX "uq_transactions_simplefin_transaction" ON "transactions" ("account_id", "simplefin_transaction_id") WHERE "simplefin_transaction_id" IS NOT NULL;
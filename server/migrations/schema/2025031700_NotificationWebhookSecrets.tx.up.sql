@@ -0,0 +1,4 @@
+-- Notification webhooks are signed the same way as account webhooks, each
+-- preference with a webhook url gets its own secret.
+ALTER TABLE "notification_preferences" ADD COLUMN "webhook_secret_id" VARCHAR(32);
+ALTER TABLE "notification_preferences" ADD CONSTRAINT "fk_notification_preferences_webhook_secret" FOREIGN KEY ("webhook_secret_id", "account_id") REFERENCES "secrets" ("secret_id", "account_id");
@@ -0,0 +1,9 @@
+-- Recurring transactions are now updated in place when detection runs rather
+-- than being replaced, this key is how the same recurring transaction is found
+-- again. Existing rows are given a placeholder key, they will be replaced the
+-- next time detection runs for their bank account.
+ALTER TABLE "transaction_recurring" ADD COLUMN "recurring_key" TEXT;
+UPDATE "transaction_recurring" SET "recurring_key" = "transaction_recurring_id";
+ALTER TABLE "transaction_recurring" ALTER COLUMN "recurring_key" SET NOT NULL;
+
+CREATE UNIQUE INDEX "ix_uq_transaction_recurring_key" ON "transaction_recurring" ("account_id", "bank_account_id", "recurring_key");
@@ -0,0 +1,4 @@
+-- Amounts in tabular files are parsed using the decimal separator from the
+-- mapping rather than guessing it from the number of fractional digits.
+-- Existing mappings keep using a period.
+ALTER TABLE "transaction_upload_mappings" ADD COLUMN "decimal_separator" TEXT NOT NULL DEFAULT '.';
@@ -28,6 +28,8 @@ type Account struct {
 	SubscriptionStatus            *stripe.SubscriptionStatus `json:"subscriptionStatus" pg:"subscription_status"`
 	TrialEndsAt                   *time.Time                 `json:"trialEndsAt" pg:"trial_ends_at"`
 	TrialExpiryNotificationSentAt *time.Time                 `json:"-" pg:"trial_expiry_notification_sent_at"`
+	DeletionRequestedAt           *time.Time                 `json:"deletionRequestedAt" pg:"deletion_requested_at"`
+	DeletionScheduledAt           *time.Time                 `json:"deletionScheduledAt" pg:"deletion_scheduled_at"`
 	CreatedAt                     time.Time                  `json:"createdAt" pg:"created_at,notnull"`
 }
 
@@ -130,3 +132,10 @@ func (a *Account) IsTrialing(now time.Time) bool {
 		a.TrialEndsAt != nil &&
 		a.TrialEndsAt.After(now)
 }
+
+// IsPendingDeletion returns true if the owner of the account has requested
+// that the account be deleted and that deletion has not been cancelled. The
+// account can still be used until the deletion is actually performed.
+func (a *Account) IsPendingDeletion() bool {
+	return a.DeletionScheduledAt != nil
+}
@@ -7,12 +7,18 @@ import (
 type APIKey struct {
 	tableName struct{} `pg:"api_keys"`
 
-	APIKeyId   int64     `json:"apiKeyId" pg:"api_key_id,pk"`
-	UserId     string    `json:"-" pg:"user_id"`
-	Name       string    `json:"name" pg:"name"`
-	KeyHash    string    `json:"-" pg:"key_hash"`
-	CreatedAt  time.Time `json:"createdAt" pg:"created_at"`
-	LastUsedAt time.Time `json:"lastUsedAt,omitempty" pg:"last_used_at"`
-	ExpiresAt  time.Time `json:"expiresAt,omitempty" pg:"expires_at"`
-	IsActive   bool      `json:"isActive" pg:"is_active"`
+	APIKeyId int64  `json:"apiKeyId" pg:"api_key_id,pk"`
+	UserId   string `json:"-" pg:"user_id"`
+	Name     string `json:"name" pg:"name"`
+	KeyHash  string `json:"-" pg:"key_hash"`
+	// Scopes are the permissions that have been granted to the API key. An API
+	// key can only access endpoints that require one of these scopes.
+	Scopes []string `json:"scopes" pg:"scopes,array"`
+	// BankAccountIds optionally restricts the API key to only the specified bank
+	// accounts. If this is empty then the key can access every bank account.
+	BankAccountIds []ID[BankAccount] `json:"bankAccountIds" pg:"bank_account_ids,array"`
+	CreatedAt      time.Time         `json:"createdAt" pg:"created_at"`
+	LastUsedAt     time.Time         `json:"lastUsedAt,omitempty" pg:"last_used_at"`
+	ExpiresAt      time.Time         `json:"expiresAt,omitempty" pg:"expires_at"`
+	IsActive       bool              `json:"isActive" pg:"is_active"`
 }
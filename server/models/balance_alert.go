@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/pkg/errors"
+)
+
+// MaxBalanceAlertDays is the furthest into the future that a balance alert can
+// look.
+const MaxBalanceAlertDays = 90
+
+var (
+	_ pg.BeforeInsertHook = (*BalanceAlert)(nil)
+	_ Identifiable        = BalanceAlert{}
+)
+
+// BalanceAlert flags a bank account when the forecasted balance is projected
+// to drop below the threshold within the specified number of days. Each bank
+// account can only have a single balance alert.
+type BalanceAlert struct {
+	tableName string `pg:"balance_alerts"`
+
+	BalanceAlertId ID[BalanceAlert] `json:"balanceAlertId" pg:"balance_alert_id,notnull,pk"`
+	AccountId      ID[Account]      `json:"-" pg:"account_id,notnull,pk"`
+	Account        *Account         `json:"-" pg:"rel:has-one"`
+	BankAccountId  ID[BankAccount]  `json:"bankAccountId" pg:"bank_account_id,notnull"`
+	BankAccount    *BankAccount     `json:"-" pg:"rel:has-one"`
+	Threshold      int64            `json:"threshold" pg:"threshold,notnull,use_zero"`
+	Days           int              `json:"days" pg:"days,notnull,use_zero"`
+	IsEnabled      bool             `json:"isEnabled" pg:"is_enabled,notnull,use_zero"`
+
+	// The fields below are the result of the most recent check and cannot be
+	// changed by the user.
+
+	// IsTriggered is true when the projected balance drops below the threshold.
+	IsTriggered bool `json:"isTriggered" pg:"is_triggered,notnull,use_zero"`
+	// TriggeredDate is the first date that the projected balance is below the
+	// threshold.
+	TriggeredDate *time.Time `json:"triggeredDate" pg:"triggered_date"`
+	// ProjectedBalance is the lowest projected balance within the window.
+	ProjectedBalance *int64     `json:"projectedBalance" pg:"projected_balance"`
+	LastCheckedAt    *time.Time `json:"lastCheckedAt" pg:"last_checked_at"`
+
+	CreatedAt time.Time `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+func (BalanceAlert) IdentityPrefix() string {
+	return "balr"
+}
+
+func (o *BalanceAlert) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.BalanceAlertId.IsZero() {
+		o.BalanceAlertId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
+
+// Validate makes sure that the user configurable fields of the balance alert
+// are valid.
+func (o *BalanceAlert) Validate() error {
+	if o.Days < 1 || o.Days > MaxBalanceAlertDays {
+		return errors.Errorf("days must be between 1 and %d", MaxBalanceAlertDays)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*BalanceSnapshot)(nil)
+	_ Identifiable        = BalanceSnapshot{}
+)
+
+// BalanceSnapshot is a record of the balances of a bank account on a single
+// day. Date is midnight of that day in the account's timezone.
+type BalanceSnapshot struct {
+	tableName string `pg:"balance_snapshots"`
+
+	BalanceSnapshotId ID[BalanceSnapshot] `json:"balanceSnapshotId" pg:"balance_snapshot_id,notnull,pk"`
+	AccountId         ID[Account]         `json:"-" pg:"account_id,notnull,pk"`
+	Account           *Account            `json:"-" pg:"rel:has-one"`
+	BankAccountId     ID[BankAccount]     `json:"bankAccountId" pg:"bank_account_id,notnull"`
+	BankAccount       *BankAccount        `json:"-" pg:"rel:has-one"`
+	Date              time.Time           `json:"date" pg:"date,notnull"`
+	Current           int64               `json:"current" pg:"current,notnull,use_zero"`
+	Available         int64               `json:"available" pg:"available,notnull,use_zero"`
+	Free              int64               `json:"free" pg:"free,notnull,use_zero"`
+	Expenses          int64               `json:"expenses" pg:"expenses,notnull,use_zero"`
+	Goals             int64               `json:"goals" pg:"goals,notnull,use_zero"`
+	CreatedAt         time.Time           `json:"createdAt" pg:"created_at,notnull"`
+}
+
+func (BalanceSnapshot) IdentityPrefix() string {
+	return "bsnp"
+}
+
+func (o *BalanceSnapshot) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.BalanceSnapshotId.IsZero() {
+		o.BalanceSnapshotId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
@@ -102,26 +102,30 @@ var (
 type BankAccount struct {
 	tableName string `pg:"bank_accounts"`
 
-	BankAccountId      ID[BankAccount]       `json:"bankAccountId" pg:"bank_account_id,notnull,pk"`
-	AccountId          ID[Account]           `json:"-" pg:"account_id,notnull,pk"`
-	Account            *Account              `json:"-" pg:"rel:has-one"`
-	LinkId             ID[Link]              `json:"linkId" pg:"link_id,notnull"`
-	Link               *Link                 `json:"-,omitempty" pg:"rel:has-one"`
-	PlaidBankAccountId *ID[PlaidBankAccount] `json:"-" pg:"plaid_bank_account_id"`
-	PlaidBankAccount   *PlaidBankAccount     `json:"plaidBankAccount,omitempty" pg:"rel:has-one"`
-	Currency           string                `json:"currency" pg:"currency,notnull"`
-	AvailableBalance   int64                 `json:"availableBalance" pg:"available_balance,notnull,use_zero"`
-	CurrentBalance     int64                 `json:"currentBalance" pg:"current_balance,notnull,use_zero"`
-	LimitBalance       int64                 `json:"limitBalance" pg:"limit_balance,notnull,use_zero"`
-	Mask               string                `json:"mask" pg:"mask"`
-	Name               string                `json:"name,omitempty" pg:"name,notnull"`
-	OriginalName       string                `json:"originalName" pg:"original_name,notnull"`
-	Type               BankAccountType       `json:"accountType" pg:"account_type"`
-	SubType            BankAccountSubType    `json:"accountSubType" pg:"account_sub_type"`
-	Status             BankAccountStatus     `json:"status" pg:"status,notnull"`
-	LastUpdated        time.Time             `json:"lastUpdated" pg:"last_updated,notnull"`
-	CreatedAt          time.Time             `json:"createdAt" pg:"created_at,notnull"`
-	UpdatedAt          time.Time             `json:"updatedAt" pg:"updated_at,notnull"`
+	BankAccountId          ID[BankAccount]           `json:"bankAccountId" pg:"bank_account_id,notnull,pk"`
+	AccountId              ID[Account]               `json:"-" pg:"account_id,notnull,pk"`
+	Account                *Account                  `json:"-" pg:"rel:has-one"`
+	LinkId                 ID[Link]                  `json:"linkId" pg:"link_id,notnull"`
+	Link                   *Link                     `json:"-,omitempty" pg:"rel:has-one"`
+	PlaidBankAccountId     *ID[PlaidBankAccount]     `json:"-" pg:"plaid_bank_account_id"`
+	PlaidBankAccount       *PlaidBankAccount         `json:"plaidBankAccount,omitempty" pg:"rel:has-one"`
+	TellerBankAccountId    *ID[TellerBankAccount]    `json:"-" pg:"teller_bank_account_id"`
+	TellerBankAccount      *TellerBankAccount        `json:"tellerBankAccount,omitempty" pg:"rel:has-one"`
+	SimpleFINBankAccountId *ID[SimpleFINBankAccount] `json:"-" pg:"simplefin_bank_account_id"`
+	SimpleFINBankAccount   *SimpleFINBankAccount     `json:"simpleFINBankAccount,omitempty" pg:"simplefin_bank_account,rel:has-one"`
+	Currency               string                    `json:"currency" pg:"currency,notnull"`
+	AvailableBalance       int64                     `json:"availableBalance" pg:"available_balance,notnull,use_zero"`
+	CurrentBalance         int64                     `json:"currentBalance" pg:"current_balance,notnull,use_zero"`
+	LimitBalance           int64                     `json:"limitBalance" pg:"limit_balance,notnull,use_zero"`
+	Mask                   string                    `json:"mask" pg:"mask"`
+	Name                   string                    `json:"name,omitempty" pg:"name,notnull"`
+	OriginalName           string                    `json:"originalName" pg:"original_name,notnull"`
+	Type                   BankAccountType           `json:"accountType" pg:"account_type"`
+	SubType                BankAccountSubType        `json:"accountSubType" pg:"account_sub_type"`
+	Status                 BankAccountStatus         `json:"status" pg:"status,notnull"`
+	LastUpdated            time.Time                 `json:"lastUpdated" pg:"last_updated,notnull"`
+	CreatedAt              time.Time                 `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt              time.Time                 `json:"updatedAt" pg:"updated_at,notnull"`
 }
 
 func (BankAccount) IdentityPrefix() string {
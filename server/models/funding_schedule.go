@@ -27,6 +27,21 @@ type FundingSchedule struct {
 	LastRecurrence         *time.Time          `json:"lastRecurrence" pg:"last_recurrence"`
 	NextRecurrence         time.Time           `json:"nextRecurrence" pg:"next_recurrence,notnull"`
 	NextRecurrenceOriginal time.Time           `json:"nextRecurrenceOriginal" pg:"next_recurrence_original,notnull"`
+	// When WaitForDeposit is enabled the funding schedule is not processed until
+	// a deposit that matches the fields below arrives, see MatchesDeposit.
+	//
+	// DepositNamePattern is a case-insensitive regular expression that is
+	// matched against the name and the original name of deposits.
+	DepositNamePattern *string `json:"depositNamePattern" pg:"deposit_name_pattern"`
+	// DepositMinimumAmount is the smallest deposit that will be matched, as a
+	// positive amount.
+	DepositMinimumAmount *int64 `json:"depositMinimumAmount" pg:"deposit_minimum_amount"`
+	// DepositToleranceDays is how many days before or after the next recurrence
+	// a deposit can arrive and still be matched.
+	DepositToleranceDays int `json:"depositToleranceDays" pg:"deposit_tolerance_days,notnull,use_zero"`
+	// LastDepositTransactionId is the deposit that triggered the most recent
+	// funding of this schedule.
+	LastDepositTransactionId *ID[Transaction] `json:"lastDepositTransactionId" pg:"last_deposit_transaction_id"`
 }
 
 func (FundingSchedule) IdentityPrefix() string {
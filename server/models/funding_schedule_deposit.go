@@ -0,0 +1,86 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// MaxDepositToleranceDays is the largest window that can be specified on a
+// deposit matcher. Anything larger risks matching the deposit for the previous
+// or the next occurrence of the funding schedule instead.
+const MaxDepositToleranceDays = 7
+
+// DefaultDepositToleranceDays is the tolerance used for new funding schedules
+// when one is not specified, this matches the default of the column.
+const DefaultDepositToleranceDays = 1
+
+// ValidateDepositMatcher makes sure that the deposit matcher fields on the
+// funding schedule are valid. Blank patterns are treated as if they were not
+// provided at all.
+func (f *FundingSchedule) ValidateDepositMatcher() error {
+	if f.DepositNamePattern != nil && strings.TrimSpace(*f.DepositNamePattern) == "" {
+		f.DepositNamePattern = nil
+	}
+
+	if f.DepositNamePattern != nil {
+		if _, err := regexp.Compile("(?i)" + *f.DepositNamePattern); err != nil {
+			return errors.Wrap(err, "invalid deposit name pattern")
+		}
+	}
+
+	if f.DepositMinimumAmount != nil && *f.DepositMinimumAmount <= 0 {
+		return errors.New("deposit minimum amount must be greater than 0")
+	}
+
+	if f.DepositToleranceDays < 0 || f.DepositToleranceDays > MaxDepositToleranceDays {
+		return errors.Errorf("deposit tolerance must be between 0 and %d days", MaxDepositToleranceDays)
+	}
+
+	return nil
+}
+
+// GetDepositWindow returns the range of time that a deposit must fall within
+// for it to be matched to the next recurrence of the funding schedule. The
+// start is inclusive and the end is exclusive.
+func (f *FundingSchedule) GetDepositWindow(timezone *time.Location) (start, end time.Time) {
+	date := util.Midnight(f.NextRecurrence, timezone)
+	start = date.AddDate(0, 0, -f.DepositToleranceDays)
+	end = date.AddDate(0, 0, f.DepositToleranceDays+1)
+	return start, end
+}
+
+// MatchesDeposit returns true if the provided transaction is a deposit that
+// satisfies the deposit matcher of the funding schedule and falls within the
+// deposit window of its next recurrence.
+func (f *FundingSchedule) MatchesDeposit(transaction Transaction, timezone *time.Location) bool {
+	if !transaction.IsAddition() {
+		return false
+	}
+
+	start, end := f.GetDepositWindow(timezone)
+	if transaction.Date.Before(start) || !transaction.Date.Before(end) {
+		return false
+	}
+
+	// Deposits are stored as negative amounts.
+	if f.DepositMinimumAmount != nil && -transaction.Amount < *f.DepositMinimumAmount {
+		return false
+	}
+
+	if f.DepositNamePattern != nil {
+		pattern, err := regexp.Compile("(?i)" + *f.DepositNamePattern)
+		if err != nil {
+			return false
+		}
+
+		if !pattern.MatchString(transaction.Name) && !pattern.MatchString(transaction.OriginalName) {
+			return false
+		}
+	}
+
+	return true
+}
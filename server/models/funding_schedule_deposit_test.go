@@ -0,0 +1,107 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFundingSchedule_ValidateDepositMatcher(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		fundingSchedule := models.FundingSchedule{
+			WaitForDeposit:       true,
+			DepositNamePattern:   myownsanity.StringP("acme corp payroll"),
+			DepositMinimumAmount: myownsanity.Int64P(100000),
+			DepositToleranceDays: 2,
+		}
+		assert.NoError(t, fundingSchedule.ValidateDepositMatcher())
+	})
+
+	t.Run("blank pattern is removed", func(t *testing.T) {
+		fundingSchedule := models.FundingSchedule{
+			DepositNamePattern: myownsanity.StringP("  "),
+		}
+		assert.NoError(t, fundingSchedule.ValidateDepositMatcher())
+		assert.Nil(t, fundingSchedule.DepositNamePattern)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		fundingSchedule := models.FundingSchedule{
+			DepositNamePattern: myownsanity.StringP("payroll("),
+		}
+		assert.ErrorContains(t, fundingSchedule.ValidateDepositMatcher(), "invalid deposit name pattern")
+	})
+
+	t.Run("invalid minimum amount", func(t *testing.T) {
+		fundingSchedule := models.FundingSchedule{
+			DepositMinimumAmount: myownsanity.Int64P(-100),
+		}
+		assert.EqualError(t, fundingSchedule.ValidateDepositMatcher(), "deposit minimum amount must be greater than 0")
+	})
+
+	t.Run("tolerance too large", func(t *testing.T) {
+		fundingSchedule := models.FundingSchedule{
+			DepositToleranceDays: 30,
+		}
+		assert.EqualError(t, fundingSchedule.ValidateDepositMatcher(), "deposit tolerance must be between 0 and 7 days")
+	})
+}
+
+func TestFundingSchedule_MatchesDeposit(t *testing.T) {
+	central, err := time.LoadLocation("America/Chicago")
+	assert.NoError(t, err)
+
+	fundingSchedule := models.FundingSchedule{
+		WaitForDeposit:       true,
+		NextRecurrence:       time.Date(2024, 10, 15, 0, 0, 0, 0, central),
+		DepositNamePattern:   myownsanity.StringP("payroll"),
+		DepositMinimumAmount: myownsanity.Int64P(100000),
+		DepositToleranceDays: 1,
+	}
+	paycheck := models.Transaction{
+		Name:         "Paycheck",
+		OriginalName: "ACME CORP PAYROLL",
+		Amount:       -150000,
+		Date:         time.Date(2024, 10, 14, 0, 0, 0, 0, central),
+	}
+
+	t.Run("matches within the window", func(t *testing.T) {
+		assert.True(t, fundingSchedule.MatchesDeposit(paycheck, central))
+
+		late := paycheck
+		late.Date = time.Date(2024, 10, 16, 23, 0, 0, 0, central)
+		assert.True(t, fundingSchedule.MatchesDeposit(late, central), "end of the last day of the window should match")
+	})
+
+	t.Run("outside of the window", func(t *testing.T) {
+		early := paycheck
+		early.Date = time.Date(2024, 10, 13, 0, 0, 0, 0, central)
+		assert.False(t, fundingSchedule.MatchesDeposit(early, central))
+
+		late := paycheck
+		late.Date = time.Date(2024, 10, 17, 0, 0, 0, 0, central)
+		assert.False(t, fundingSchedule.MatchesDeposit(late, central))
+	})
+
+	t.Run("small deposits are ignored", func(t *testing.T) {
+		refund := paycheck
+		refund.Amount = -2500
+		assert.False(t, fundingSchedule.MatchesDeposit(refund, central))
+	})
+
+	t.Run("name must match", func(t *testing.T) {
+		venmo := paycheck
+		venmo.Name = "Venmo"
+		venmo.OriginalName = "VENMO CASHOUT"
+		assert.False(t, fundingSchedule.MatchesDeposit(venmo, central))
+	})
+
+	t.Run("debits never match", func(t *testing.T) {
+		debit := paycheck
+		debit.Amount = 150000
+		assert.False(t, fundingSchedule.MatchesDeposit(debit, central))
+	})
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*Invitation)(nil)
+	_ Identifiable        = Invitation{}
+)
+
+// Invitation is sent by the owner of an account to invite someone else to
+// join their account as a member. The invitation is accepted using a token
+// that is emailed to the invited email address.
+type Invitation struct {
+	tableName string `pg:"invitations"`
+
+	InvitationId ID[Invitation] `json:"invitationId" pg:"invitation_id,notnull,pk"`
+	AccountId    ID[Account]    `json:"-" pg:"account_id,notnull,pk"`
+	Account      *Account       `json:"-" pg:"rel:has-one"`
+	Email        string         `json:"email" pg:"email,notnull"`
+	Role         UserRole       `json:"role" pg:"role,notnull"`
+	InvitedBy    ID[User]       `json:"invitedBy" pg:"invited_by,notnull"`
+	CreatedAt    time.Time      `json:"createdAt" pg:"created_at,notnull"`
+	ExpiresAt    time.Time      `json:"expiresAt" pg:"expires_at,notnull"`
+	AcceptedBy   *ID[User]      `json:"acceptedBy" pg:"accepted_by"`
+	AcceptedAt   *time.Time     `json:"acceptedAt" pg:"accepted_at"`
+}
+
+func (Invitation) IdentityPrefix() string {
+	return "invt"
+}
+
+func (o *Invitation) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.InvitationId.IsZero() {
+		o.InvitationId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
+
+// Validate makes sure that the invitation is for a valid email address and
+// role. Owners can only invite members, an account only ever has one owner.
+func (o *Invitation) Validate() error {
+	if strings.TrimSpace(o.Email) == "" {
+		return errors.New("email is required")
+	}
+
+	if _, err := mail.ParseAddress(o.Email); err != nil {
+		return errors.New("email must be a valid email address")
+	}
+
+	if o.Role != UserRoleMember {
+		return errors.Errorf("invalid role: %s", o.Role)
+	}
+
+	return nil
+}
+
+// IsPending returns true if the invitation has not been accepted and has not
+// expired yet.
+func (o *Invitation) IsPending(now time.Time) bool {
+	return o.AcceptedAt == nil && now.Before(o.ExpiresAt)
+}
@@ -8,4 +8,6 @@ const (
 	PlaidLinkType
 	ManualLinkType
 	StripeLinkType
+	TellerLinkType
+	SimpleFINLinkType
 )
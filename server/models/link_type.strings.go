@@ -12,11 +12,13 @@ func _() {
 	_ = x[PlaidLinkType-1]
 	_ = x[ManualLinkType-2]
 	_ = x[StripeLinkType-3]
+	_ = x[TellerLinkType-4]
+	_ = x[SimpleFINLinkType-5]
 }
 
-const _LinkType_name = "UnknownLinkTypePlaidLinkTypeManualLinkTypeStripeLinkType"
+const _LinkType_name = "UnknownLinkTypePlaidLinkTypeManualLinkTypeStripeLinkTypeTellerLinkTypeSimpleFINLinkType"
 
-var _LinkType_index = [...]uint8{0, 15, 28, 42, 56}
+var _LinkType_index = [...]uint8{0, 15, 28, 42, 56, 70, 87}
 
 func (i LinkType) String() string {
 	if i >= LinkType(len(_LinkType_index)-1) {
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// NotificationKind is the type of budget event that a notification is about,
+// users can configure their preferences separately for each kind.
+type NotificationKind string
+
+const (
+	// NotificationKindExpenseFundedShort is sent when a funding schedule could
+	// not allocate the full contribution to a spending object.
+	NotificationKindExpenseFundedShort NotificationKind = "expense_funded_short"
+	// NotificationKindGoalReached is sent when a goal is fully funded.
+	NotificationKindGoalReached NotificationKind = "goal_reached"
+	// NotificationKindLargeTransaction is sent when a new transaction is larger
+	// than the threshold in the user's preference.
+	NotificationKindLargeTransaction NotificationKind = "large_transaction"
+	// NotificationKindLinkError is sent when a Plaid link enters an error state.
+	NotificationKindLinkError NotificationKind = "link_error"
+	// NotificationKindNegativeFreeToUse is sent when the free-to-use balance of a
+	// bank account drops below zero.
+	NotificationKindNegativeFreeToUse NotificationKind = "negative_free_to_use"
+)
+
+var NotificationKinds = []NotificationKind{
+	NotificationKindExpenseFundedShort,
+	NotificationKindGoalReached,
+	NotificationKindLargeTransaction,
+	NotificationKindLinkError,
+	NotificationKindNegativeFreeToUse,
+}
+
+func (k NotificationKind) IsValid() bool {
+	for _, kind := range NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	_ pg.BeforeInsertHook = (*Notification)(nil)
+	_ Identifiable        = Notification{}
+)
+
+// Notification is a budget event that should be delivered to the users of an
+// account. Notifications are created by background jobs and are then
+// delivered separately according to each user's preferences.
+type Notification struct {
+	tableName string `pg:"notifications"`
+
+	NotificationId ID[Notification] `json:"notificationId" pg:"notification_id,notnull,pk"`
+	AccountId      ID[Account]      `json:"-" pg:"account_id,notnull,pk"`
+	Account        *Account         `json:"-" pg:"rel:has-one"`
+	BankAccountId  *ID[BankAccount] `json:"bankAccountId" pg:"bank_account_id"`
+	Kind           NotificationKind `json:"kind" pg:"kind,notnull"`
+	// DedupeKey is unique per account, creating a notification with a key that
+	// already exists is a no-op. This keeps the same event from being sent more
+	// than once when a job is retried or runs again.
+	DedupeKey string `json:"-" pg:"dedupe_key,notnull"`
+	Title     string `json:"title" pg:"title,notnull"`
+	Message   string `json:"message" pg:"message,notnull"`
+	// Amount is the amount that the notification is about, if any. Large
+	// transaction notifications use this to compare against the threshold of
+	// each user's preference.
+	Amount    *int64            `json:"amount" pg:"amount"`
+	Data      map[string]string `json:"data" pg:"data,type:'jsonb'"`
+	CreatedAt time.Time         `json:"createdAt" pg:"created_at,notnull"`
+	// SentAt is set once the notification has been delivered, or skipped, for
+	// every user of the account.
+	SentAt *time.Time `json:"sentAt" pg:"sent_at"`
+}
+
+func (Notification) IdentityPrefix() string {
+	return "ntfy"
+}
+
+func (o *Notification) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.NotificationId.IsZero() {
+		o.NotificationId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
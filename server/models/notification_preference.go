@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/pkg/errors"
+)
+
+// NotificationChannelType is how a notification is delivered to a user.
+type NotificationChannelType string
+
+const (
+	NotificationChannelEmail   NotificationChannelType = "email"
+	NotificationChannelWebhook NotificationChannelType = "webhook"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*NotificationPreference)(nil)
+	_ Identifiable        = NotificationPreference{}
+)
+
+// NotificationPreference is a single user's preference for one kind of
+// notification. If a user does not have a preference for a kind then they do
+// not receive those notifications.
+type NotificationPreference struct {
+	tableName string `pg:"notification_preferences"`
+
+	NotificationPreferenceId ID[NotificationPreference] `json:"notificationPreferenceId" pg:"notification_preference_id,notnull,pk"`
+	AccountId                ID[Account]                `json:"-" pg:"account_id,notnull,pk"`
+	Account                  *Account                   `json:"-" pg:"rel:has-one"`
+	UserId                   ID[User]                   `json:"-" pg:"user_id,notnull"`
+	User                     *User                      `json:"-" pg:"rel:has-one"`
+	Kind                     NotificationKind           `json:"kind" pg:"kind,notnull"`
+	IsEnabled                bool                       `json:"isEnabled" pg:"is_enabled,notnull,use_zero"`
+	Channels                 []NotificationChannelType  `json:"channels" pg:"channels,array"`
+	// WebhookURL is where notifications are posted when the webhook channel is
+	// enabled.
+	WebhookURL *string `json:"webhookUrl" pg:"webhook_url"`
+	// WebhookSecretId is the secret that webhook notifications are signed with,
+	// it is present whenever the webhook url is.
+	WebhookSecretId *ID[Secret] `json:"-" pg:"webhook_secret_id"`
+	// Threshold is only used by large transaction notifications, transactions
+	// with an absolute amount below the threshold do not send a notification.
+	Threshold *int64    `json:"threshold" pg:"threshold"`
+	CreatedAt time.Time `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+func (NotificationPreference) IdentityPrefix() string {
+	return "ntfp"
+}
+
+func (o *NotificationPreference) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.NotificationPreferenceId.IsZero() {
+		o.NotificationPreferenceId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
+
+// Validate makes sure that the user configurable fields of the preference are
+// valid.
+func (o *NotificationPreference) Validate() error {
+	if !o.Kind.IsValid() {
+		return errors.Errorf("invalid notification kind: %s", o.Kind)
+	}
+
+	for _, channel := range o.Channels {
+		switch channel {
+		case NotificationChannelEmail:
+		case NotificationChannelWebhook:
+			if o.WebhookURL == nil {
+				return errors.New("webhook url is required for the webhook channel")
+			}
+		default:
+			return errors.Errorf("invalid notification channel: %s", channel)
+		}
+	}
+
+	if o.WebhookURL != nil {
+		if err := validateWebhookURL("webhook url", *o.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	if o.Threshold != nil {
+		if o.Kind != NotificationKindLargeTransaction {
+			return errors.New("threshold is only valid for large transaction notifications")
+		}
+		if *o.Threshold <= 0 {
+			return errors.New("threshold must be greater than 0")
+		}
+	}
+
+	return nil
+}
+
+// Wants returns true if the notification should be delivered to the user with
+// this preference.
+func (o *NotificationPreference) Wants(notification Notification) bool {
+	if !o.IsEnabled || o.Kind != notification.Kind || len(o.Channels) == 0 {
+		return false
+	}
+
+	if o.Threshold != nil && notification.Amount != nil {
+		amount := *notification.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		return amount >= *o.Threshold
+	}
+
+	return true
+}
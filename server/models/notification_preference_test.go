@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationPreference_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		preference := NotificationPreference{
+			Kind:       NotificationKindLargeTransaction,
+			IsEnabled:  true,
+			Channels:   []NotificationChannelType{NotificationChannelEmail, NotificationChannelWebhook},
+			WebhookURL: myownsanity.StringP("https://example.com/hook"),
+			Threshold:  myownsanity.Int64P(10000),
+		}
+		assert.NoError(t, preference.Validate())
+	})
+
+	t.Run("invalid kind", func(t *testing.T) {
+		preference := NotificationPreference{
+			Kind: "bogus",
+		}
+		assert.EqualError(t, preference.Validate(), "invalid notification kind: bogus")
+	})
+
+	t.Run("webhook without a url", func(t *testing.T) {
+		preference := NotificationPreference{
+			Kind:     NotificationKindGoalReached,
+			Channels: []NotificationChannelType{NotificationChannelWebhook},
+		}
+		assert.EqualError(t, preference.Validate(), "webhook url is required for the webhook channel")
+	})
+
+	t.Run("webhook with a bad scheme", func(t *testing.T) {
+		preference := NotificationPreference{
+			Kind:       NotificationKindGoalReached,
+			Channels:   []NotificationChannelType{NotificationChannelWebhook},
+			WebhookURL: myownsanity.StringP("ftp://example.com/hook"),
+		}
+		assert.EqualError(t, preference.Validate(), "webhook url must be an https url")
+	})
+
+	t.Run("webhook over http", func(t *testing.T) {
+		preference := NotificationPreference{
+			Kind:       NotificationKindGoalReached,
+			Channels:   []NotificationChannelType{NotificationChannelWebhook},
+			WebhookURL: myownsanity.StringP("http://example.com/hook"),
+		}
+		assert.EqualError(t, preference.Validate(), "webhook url must be an https url")
+	})
+
+	t.Run("webhook to a private address", func(t *testing.T) {
+		for _, input := range []string{
+			"https://localhost/hook",
+			"https://127.0.0.1/hook",
+			"https://10.0.0.5/hook",
+			"https://169.254.169.254/latest/meta-data",
+			"https://[::1]/hook",
+		} {
+			preference := NotificationPreference{
+				Kind:       NotificationKindGoalReached,
+				Channels:   []NotificationChannelType{NotificationChannelWebhook},
+				WebhookURL: myownsanity.StringP(input),
+			}
+			assert.EqualError(t, preference.Validate(), "webhook url must not point to a private address", input)
+		}
+	})
+
+	t.Run("threshold on the wrong kind", func(t *testing.T) {
+		preference := NotificationPreference{
+			Kind:      NotificationKindGoalReached,
+			Channels:  []NotificationChannelType{NotificationChannelEmail},
+			Threshold: myownsanity.Int64P(100),
+		}
+		assert.EqualError(t, preference.Validate(), "threshold is only valid for large transaction notifications")
+	})
+}
+
+func TestNotificationPreference_Wants(t *testing.T) {
+	preference := NotificationPreference{
+		Kind:      NotificationKindLargeTransaction,
+		IsEnabled: true,
+		Channels:  []NotificationChannelType{NotificationChannelEmail},
+		Threshold: myownsanity.Int64P(10000),
+	}
+
+	assert.True(t, preference.Wants(Notification{
+		Kind:   NotificationKindLargeTransaction,
+		Amount: myownsanity.Int64P(12000),
+	}), "should want transactions above the threshold")
+	assert.True(t, preference.Wants(Notification{
+		Kind:   NotificationKindLargeTransaction,
+		Amount: myownsanity.Int64P(-10000),
+	}), "should want deposits above the threshold")
+	assert.False(t, preference.Wants(Notification{
+		Kind:   NotificationKindLargeTransaction,
+		Amount: myownsanity.Int64P(500),
+	}), "should not want transactions below the threshold")
+	assert.False(t, preference.Wants(Notification{
+		Kind: NotificationKindGoalReached,
+	}), "should not want other kinds")
+
+	preference.IsEnabled = false
+	assert.False(t, preference.Wants(Notification{
+		Kind:   NotificationKindLargeTransaction,
+		Amount: myownsanity.Int64P(12000),
+	}), "disabled preferences should not want anything")
+}
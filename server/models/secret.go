@@ -10,8 +10,10 @@ import (
 type SecretKind string
 
 const (
-	PlaidSecretKind  SecretKind = "plaid"
-	TellerSecretKind SecretKind = "teller"
+	PlaidSecretKind     SecretKind = "plaid"
+	SimpleFINSecretKind SecretKind = "simplefin"
+	TellerSecretKind    SecretKind = "teller"
+	WebhookSecretKind   SecretKind = "webhook"
 )
 
 type Secret struct {
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type SimpleFINBankAccount struct {
+	tableName string `pg:"simplefin_bank_accounts,alias:simplefin_bank_account"`
+
+	SimpleFINBankAccountId ID[SimpleFINBankAccount] `json:"-" pg:"simplefin_bank_account_id,notnull,pk"`
+	AccountId              ID[Account]              `json:"-" pg:"account_id,notnull,pk"`
+	Account                *Account                 `json:"-" pg:"rel:has-one"`
+	SimpleFINLinkId        ID[SimpleFINLink]        `json:"-" pg:"simplefin_link_id,notnull,unique:per_link"`
+	SimpleFINLink          *SimpleFINLink           `json:"-" pg:"simplefin_link,rel:has-one"`
+	SimpleFINId            string                   `json:"-" pg:"simplefin_id,notnull,unique:per_link"`
+	OrganizationName       string                   `json:"organizationName" pg:"organization_name,notnull"`
+	OrganizationDomain     string                   `json:"organizationDomain" pg:"organization_domain"`
+	Name                   string                   `json:"name" pg:"name,notnull"`
+	Currency               string                   `json:"currency" pg:"currency,notnull"`
+	Balance                int64                    `json:"balance" pg:"balance,notnull,use_zero"`
+	AvailableBalance       int64                    `json:"availableBalance" pg:"available_balance,notnull,use_zero"`
+	BalanceDate            *time.Time               `json:"balanceDate" pg:"balance_date"`
+	CreatedAt              time.Time                `json:"createdAt" pg:"created_at,notnull"`
+	CreatedBy              ID[User]                 `json:"createdBy" pg:"created_by,notnull"`
+	CreatedByUser          *User                    `json:"-" pg:"rel:has-one,fk:created_by"`
+}
+
+func (SimpleFINBankAccount) IdentityPrefix() string {
+	return "sfbac"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*SimpleFINBankAccount)(nil)
+)
+
+func (o *SimpleFINBankAccount) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.SimpleFINBankAccountId.IsZero() {
+		o.SimpleFINBankAccountId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type SimpleFINLinkStatus uint8
+
+//go:generate go run golang.org/x/tools/cmd/stringer@v0.21.0 -type=SimpleFINLinkStatus -output=simplefin_link.strings.go
+const (
+	SimpleFINLinkStatusUnknown      SimpleFINLinkStatus = 0
+	SimpleFINLinkStatusPending      SimpleFINLinkStatus = 1
+	SimpleFINLinkStatusSetup        SimpleFINLinkStatus = 2
+	SimpleFINLinkStatusError        SimpleFINLinkStatus = 3
+	SimpleFINLinkStatusDisconnected SimpleFINLinkStatus = 4
+)
+
+type SimpleFINLink struct {
+	tableName string `pg:"simplefin_links,alias:simplefin_link"`
+
+	SimpleFINLinkId ID[SimpleFINLink] `json:"-" pg:"simplefin_link_id,notnull,pk"`
+	AccountId       ID[Account]       `json:"-" pg:"account_id,notnull,pk"`
+	Account         *Account          `json:"-" pg:"rel:has-one"`
+	// SecretId is the encrypted access URL for the SimpleFIN server, the URL
+	// includes the credentials used to retrieve data.
+	SecretId ID[Secret] `json:"-" pg:"secret_id"`
+	Secret   *Secret    `json:"-" pg:"rel:has-one"`
+	// Server is the host of the SimpleFIN server that the access URL belongs
+	// to, this does not include any credentials.
+	Server               string              `json:"server" pg:"server,notnull"`
+	Status               SimpleFINLinkStatus `json:"status" pg:"status,notnull,default:0"`
+	ErrorCode            *string             `json:"errorCode,omitempty" pg:"error_code"`
+	InstitutionName      string              `json:"institutionName" pg:"institution_name,notnull"`
+	LastManualSync       *time.Time          `json:"lastManualSync" pg:"last_manual_sync"`
+	LastSuccessfulUpdate *time.Time          `json:"lastSuccessfulUpdate" pg:"last_successful_update"`
+	LastAttemptedUpdate  *time.Time          `json:"lastAttemptedUpdate" pg:"last_attempted_update"`
+	UpdatedAt            time.Time           `json:"updatedAt" pg:"updated_at,notnull"`
+	CreatedAt            time.Time           `json:"createdAt" pg:"created_at,notnull"`
+	CreatedBy            ID[User]            `json:"createdBy" pg:"created_by,notnull"`
+	CreatedByUser        *User               `json:"-" pg:"rel:has-one,fk:created_by"`
+	DeletedAt            *time.Time          `json:"deletedAt" pg:"deleted_at"`
+}
+
+func (SimpleFINLink) IdentityPrefix() string {
+	return "sflx"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*SimpleFINLink)(nil)
+)
+
+func (o *SimpleFINLink) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.SimpleFINLinkId.IsZero() {
+		o.SimpleFINLinkId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type SimpleFINTransaction struct {
+	tableName string `pg:"simplefin_transactions,alias:simplefin_transaction"`
+
+	SimpleFINTransactionId ID[SimpleFINTransaction] `json:"-" pg:"simplefin_transaction_id,notnull,pk"`
+	AccountId              ID[Account]              `json:"-" pg:"account_id,notnull,pk"`
+	Account                *Account                 `json:"-" pg:"rel:has-one"`
+	SimpleFINBankAccountId ID[SimpleFINBankAccount] `json:"-" pg:"simplefin_bank_account_id,notnull,unique:per_bank_account"`
+	SimpleFINBankAccount   *SimpleFINBankAccount    `json:"-" pg:"simplefin_bank_account,rel:has-one"`
+	// SimpleFINId is the ID of the transaction provided by the SimpleFIN
+	// server, it is only unique within the account it belongs to.
+	SimpleFINId string `json:"-" pg:"simplefin_id,notnull,unique:per_bank_account"`
+	Description string `json:"description" pg:"description,notnull"`
+	Payee       string `json:"payee" pg:"payee"`
+	Memo        string `json:"memo" pg:"memo"`
+	// Amount is stored the same way monetr stores transaction amounts, positive
+	// values are debits and negative values are credits. SimpleFIN uses the
+	// opposite convention.
+	Amount       int64      `json:"amount" pg:"amount,notnull,use_zero"`
+	IsPending    bool       `json:"isPending" pg:"is_pending,notnull,use_zero"`
+	PostedAt     *time.Time `json:"postedAt" pg:"posted_at"`
+	TransactedAt *time.Time `json:"transactedAt" pg:"transacted_at"`
+	CreatedAt    time.Time  `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt    time.Time  `json:"updatedAt" pg:"updated_at,notnull"`
+	DeletedAt    *time.Time `json:"deletedAt" pg:"deleted_at"`
+}
+
+func (SimpleFINTransaction) IdentityPrefix() string {
+	return "sftxn"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*SimpleFINTransaction)(nil)
+)
+
+func (o *SimpleFINTransaction) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.SimpleFINTransactionId.IsZero() {
+		o.SimpleFINTransactionId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
@@ -42,7 +42,15 @@ type Spending struct {
 	NextContributionAmount int64               `json:"nextContributionAmount" pg:"next_contribution_amount,notnull,use_zero"`
 	IsBehind               bool                `json:"isBehind" pg:"is_behind,notnull,use_zero"`
 	IsPaused               bool                `json:"isPaused" pg:"is_paused,notnull,use_zero"`
-	CreatedAt              time.Time           `json:"createdAt" pg:"created_at,notnull"`
+	// Priority determines the order that spending objects are funded in when
+	// there is not enough free-to-use to fund all of them, spending objects with
+	// a lower priority are funded first.
+	Priority int `json:"priority" pg:"priority,notnull,use_zero"`
+	// ContributionShortfall is how much of the last contribution could not be
+	// allocated to this spending object because there was not enough
+	// free-to-use. It is reset once a contribution is made in full.
+	ContributionShortfall int64     `json:"contributionShortfall" pg:"contribution_shortfall,notnull,use_zero"`
+	CreatedAt             time.Time `json:"createdAt" pg:"created_at,notnull"`
 }
 
 func (Spending) IdentityPrefix() string {
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type SpendingAllocationKind string
+
+const (
+	// SpendingAllocationKindContribution is money that was allocated to the
+	// spending object by its funding schedule.
+	SpendingAllocationKindContribution SpendingAllocationKind = "contribution"
+	// SpendingAllocationKindTransfer is money that was moved between spending
+	// objects, or between a spending object and free-to-use, by the user.
+	SpendingAllocationKindTransfer SpendingAllocationKind = "transfer"
+	// SpendingAllocationKindSpend is money that was deducted from the spending
+	// object for a transaction.
+	SpendingAllocationKindSpend SpendingAllocationKind = "spend"
+	// SpendingAllocationKindRefund is money that was previously spent for a
+	// transaction being returned to the spending object, usually because the
+	// transaction was changed to be spent from something else.
+	SpendingAllocationKindRefund SpendingAllocationKind = "refund"
+)
+
+type SpendingAllocationSource string
+
+const (
+	SpendingAllocationSourceJob         SpendingAllocationSource = "job"
+	SpendingAllocationSourceTransaction SpendingAllocationSource = "transaction"
+	SpendingAllocationSourceUser        SpendingAllocationSource = "user"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*SpendingAllocation)(nil)
+	_ Identifiable        = SpendingAllocation{}
+)
+
+// SpendingAllocation is a single entry in the allocation ledger of a spending
+// object. An entry is recorded every time the current amount of a spending
+// object changes, and entries are never updated once they have been created.
+type SpendingAllocation struct {
+	tableName string `pg:"spending_allocations"`
+
+	SpendingAllocationId ID[SpendingAllocation]   `json:"spendingAllocationId" pg:"spending_allocation_id,notnull,pk"`
+	AccountId            ID[Account]              `json:"-" pg:"account_id,notnull,pk"`
+	Account              *Account                 `json:"-" pg:"rel:has-one"`
+	BankAccountId        ID[BankAccount]          `json:"bankAccountId" pg:"bank_account_id,notnull"`
+	BankAccount          *BankAccount             `json:"-" pg:"rel:has-one"`
+	SpendingId           ID[Spending]             `json:"spendingId" pg:"spending_id,notnull"`
+	Spending             *Spending                `json:"-" pg:"rel:has-one"`
+	Kind                 SpendingAllocationKind   `json:"kind" pg:"kind,notnull"`
+	Source               SpendingAllocationSource `json:"source" pg:"source,notnull"`
+	// Amount is how much the current amount of the spending object changed by,
+	// it is positive when money was added to the spending object and negative
+	// when money was taken from it.
+	Amount int64 `json:"amount" pg:"amount,notnull,use_zero"`
+	// CurrentAmount is the current amount of the spending object after this
+	// entry was applied.
+	CurrentAmount int64 `json:"currentAmount" pg:"current_amount,notnull,use_zero"`
+	// FundingScheduleId is provided for contributions.
+	FundingScheduleId *ID[FundingSchedule] `json:"fundingScheduleId" pg:"funding_schedule_id"`
+	// TransactionId is provided for spends and refunds, if the transaction was
+	// split then TransactionSplitId is provided as well.
+	TransactionId      *ID[Transaction]      `json:"transactionId" pg:"transaction_id"`
+	TransactionSplitId *ID[TransactionSplit] `json:"transactionSplitId" pg:"transaction_split_id"`
+	// CounterpartSpendingId is the other spending object involved in a transfer.
+	// If it is nil then the money was transferred to or from free-to-use.
+	CounterpartSpendingId *ID[Spending] `json:"counterpartSpendingId" pg:"counterpart_spending_id"`
+	// UserId is the user who made the change, if it was made by a user.
+	UserId    *ID[User] `json:"userId" pg:"user_id"`
+	CreatedAt time.Time `json:"createdAt" pg:"created_at,notnull"`
+}
+
+func (SpendingAllocation) IdentityPrefix() string {
+	return "salc"
+}
+
+func (o *SpendingAllocation) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.SpendingAllocationId.IsZero() {
+		o.SpendingAllocationId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
+
+// NewSpendingAllocation creates a ledger entry for a change that has already
+// been made to the provided spending object. The amount is how much the
+// current amount of the spending object changed by.
+func NewSpendingAllocation(
+	spending *Spending,
+	kind SpendingAllocationKind,
+	source SpendingAllocationSource,
+	amount int64,
+) SpendingAllocation {
+	return SpendingAllocation{
+		BankAccountId: spending.BankAccountId,
+		SpendingId:    spending.SpendingId,
+		Kind:          kind,
+		Source:        source,
+		Amount:        amount,
+		CurrentAmount: spending.CurrentAmount,
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type TellerBankAccount struct {
+	tableName string `pg:"teller_bank_accounts"`
+
+	TellerBankAccountId ID[TellerBankAccount] `json:"-" pg:"teller_bank_account_id,notnull,pk"`
+	AccountId           ID[Account]           `json:"-" pg:"account_id,notnull,pk"`
+	Account             *Account              `json:"-" pg:"rel:has-one"`
+	TellerLinkId        ID[TellerLink]        `json:"-" pg:"teller_link_id,notnull,unique:per_link"`
+	TellerLink          *TellerLink           `json:"-" pg:"rel:has-one"`
+	TellerId            string                `json:"-" pg:"teller_id,notnull,unique:per_link"`
+	InstitutionId       string                `json:"institutionId" pg:"institution_id,notnull"`
+	InstitutionName     string                `json:"institutionName" pg:"institution_name,notnull"`
+	Name                string                `json:"name" pg:"name,notnull"`
+	Mask                string                `json:"mask" pg:"mask"`
+	Type                string                `json:"type" pg:"type,notnull"`
+	SubType             string                `json:"subType" pg:"sub_type,notnull"`
+	Status              string                `json:"status" pg:"status,notnull"`
+	Currency            string                `json:"currency" pg:"currency,notnull"`
+	AvailableBalance    int64                 `json:"availableBalance" pg:"available_balance,notnull,use_zero"`
+	LedgerBalance       int64                 `json:"ledgerBalance" pg:"ledger_balance,notnull,use_zero"`
+	BalancedAt          *time.Time            `json:"balancedAt" pg:"balanced_at"`
+	CreatedAt           time.Time             `json:"createdAt" pg:"created_at,notnull"`
+	CreatedBy           ID[User]              `json:"createdBy" pg:"created_by,notnull"`
+	CreatedByUser       *User                 `json:"-" pg:"rel:has-one,fk:created_by"`
+}
+
+func (TellerBankAccount) IdentityPrefix() string {
+	return "tbac"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*TellerBankAccount)(nil)
+)
+
+func (o *TellerBankAccount) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TellerBankAccountId.IsZero() {
+		o.TellerBankAccountId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	return ctx, nil
+}
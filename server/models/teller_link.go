@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type TellerLinkStatus uint8
+
+//go:generate go run golang.org/x/tools/cmd/stringer@v0.21.0 -type=TellerLinkStatus -output=teller_link.strings.go
+const (
+	TellerLinkStatusUnknown      TellerLinkStatus = 0
+	TellerLinkStatusPending      TellerLinkStatus = 1
+	TellerLinkStatusSetup        TellerLinkStatus = 2
+	TellerLinkStatusError        TellerLinkStatus = 3
+	TellerLinkStatusDisconnected TellerLinkStatus = 4
+)
+
+type TellerLink struct {
+	tableName string `pg:"teller_links"`
+
+	TellerLinkId         ID[TellerLink]   `json:"-" pg:"teller_link_id,notnull,pk"`
+	AccountId            ID[Account]      `json:"-" pg:"account_id,notnull,pk"`
+	Account              *Account         `json:"-" pg:"rel:has-one"`
+	SecretId             ID[Secret]       `json:"-" pg:"secret_id"`
+	Secret               *Secret          `json:"-" pg:"rel:has-one"`
+	EnrollmentId         string           `json:"-" pg:"enrollment_id,unique,notnull"`
+	TellerUserId         string           `json:"-" pg:"teller_user_id,notnull"`
+	Status               TellerLinkStatus `json:"status" pg:"status,notnull,default:0"`
+	ErrorCode            *string          `json:"errorCode,omitempty" pg:"error_code"`
+	InstitutionName      string           `json:"institutionName" pg:"institution_name,notnull"`
+	LastManualSync       *time.Time       `json:"lastManualSync" pg:"last_manual_sync"`
+	LastSuccessfulUpdate *time.Time       `json:"lastSuccessfulUpdate" pg:"last_successful_update"`
+	LastAttemptedUpdate  *time.Time       `json:"lastAttemptedUpdate" pg:"last_attempted_update"`
+	UpdatedAt            time.Time        `json:"updatedAt" pg:"updated_at,notnull"`
+	CreatedAt            time.Time        `json:"createdAt" pg:"created_at,notnull"`
+	CreatedBy            ID[User]         `json:"createdBy" pg:"created_by,notnull"`
+	CreatedByUser        *User            `json:"-" pg:"rel:has-one,fk:created_by"`
+	DeletedAt            *time.Time       `json:"deletedAt" pg:"deleted_at"`
+}
+
+func (TellerLink) IdentityPrefix() string {
+	return "tlx"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*TellerLink)(nil)
+)
+
+func (o *TellerLink) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TellerLinkId.IsZero() {
+		o.TellerLinkId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+type TellerTransaction struct {
+	tableName string `pg:"teller_transactions"`
+
+	TellerTransactionId ID[TellerTransaction] `json:"-" pg:"teller_transaction_id,notnull,pk"`
+	AccountId           ID[Account]           `json:"-" pg:"account_id,notnull,pk"`
+	Account             *Account              `json:"-" pg:"rel:has-one"`
+	TellerBankAccountId ID[TellerBankAccount] `json:"-" pg:"teller_bank_account_id,notnull,unique:per_bank_account"`
+	TellerBankAccount   *TellerBankAccount    `json:"-" pg:"rel:has-one"`
+	TellerId            string                `json:"-" pg:"teller_id,notnull,unique:per_bank_account"`
+	Name                string                `json:"name" pg:"name,notnull"`
+	Category            *string               `json:"category" pg:"category"`
+	Type                string                `json:"type" pg:"type,notnull"`
+	Date                time.Time             `json:"date" pg:"date,notnull"`
+	IsPending           bool                  `json:"isPending" pg:"is_pending,notnull,use_zero"`
+	// Amount is stored the same way monetr stores transaction amounts, positive
+	// values are debits and negative values are credits. Teller uses the
+	// opposite convention for depository accounts.
+	Amount         int64      `json:"amount" pg:"amount,notnull,use_zero"`
+	RunningBalance *int64     `json:"runningBalance" pg:"running_balance"`
+	CreatedAt      time.Time  `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt      time.Time  `json:"updatedAt" pg:"updated_at,notnull"`
+	DeletedAt      *time.Time `json:"deletedAt" pg:"deleted_at"`
+}
+
+func (TellerTransaction) IdentityPrefix() string {
+	return "ttxn"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*TellerTransaction)(nil)
+)
+
+func (o *TellerTransaction) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TellerTransactionId.IsZero() {
+		o.TellerTransactionId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
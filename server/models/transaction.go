@@ -14,26 +14,32 @@ import (
 type TransactionSource string
 
 const (
-	TransactionSourcePlaid  TransactionSource = "plaid"
-	TransactionSourceUpload TransactionSource = "upload"
-	TransactionSourceManual TransactionSource = "manual"
+	TransactionSourcePlaid     TransactionSource = "plaid"
+	TransactionSourceUpload    TransactionSource = "upload"
+	TransactionSourceManual    TransactionSource = "manual"
+	TransactionSourceTeller    TransactionSource = "teller"
+	TransactionSourceSimpleFIN TransactionSource = "simplefin"
 )
 
 type Transaction struct {
 	tableName string `pg:"transactions"`
 
-	TransactionId             ID[Transaction]       `json:"transactionId" pg:"transaction_id,notnull,pk"`
-	AccountId                 ID[Account]           `json:"-" pg:"account_id,notnull,pk"`
-	Account                   *Account              `json:"-" pg:"rel:has-one"`
-	BankAccountId             ID[BankAccount]       `json:"bankAccountId" pg:"bank_account_id,notnull,pk,unique:per_bank_account"`
-	BankAccount               *BankAccount          `json:"-" pg:"rel:has-one"`
-	PlaidTransactionId        *ID[PlaidTransaction] `json:"-" pg:"plaid_transaction_id"`
-	PlaidTransaction          *PlaidTransaction     `json:"plaidTransaction" pg:"rel:has-one"`
-	PendingPlaidTransactionId *ID[PlaidTransaction] `json:"-" pg:"pending_plaid_transaction_id"`
-	PendingPlaidTransaction   *PlaidTransaction     `json:"pendingPlaidTransaction" pg:"rel:has-one,fk:pending_"` // fk: is the prefix of the column we want to use to join on in a multikey join.
-	Amount                    int64                 `json:"amount" pg:"amount,notnull,use_zero"`
-	SpendingId                *ID[Spending]         `json:"spendingId" pg:"spending_id,on_delete:SET NULL"`
-	Spending                  *Spending             `json:"spending,omitempty" pg:"rel:has-one"`
+	TransactionId             ID[Transaction]           `json:"transactionId" pg:"transaction_id,notnull,pk"`
+	AccountId                 ID[Account]               `json:"-" pg:"account_id,notnull,pk"`
+	Account                   *Account                  `json:"-" pg:"rel:has-one"`
+	BankAccountId             ID[BankAccount]           `json:"bankAccountId" pg:"bank_account_id,notnull,pk,unique:per_bank_account"`
+	BankAccount               *BankAccount              `json:"-" pg:"rel:has-one"`
+	PlaidTransactionId        *ID[PlaidTransaction]     `json:"-" pg:"plaid_transaction_id"`
+	PlaidTransaction          *PlaidTransaction         `json:"plaidTransaction" pg:"rel:has-one"`
+	PendingPlaidTransactionId *ID[PlaidTransaction]     `json:"-" pg:"pending_plaid_transaction_id"`
+	PendingPlaidTransaction   *PlaidTransaction         `json:"pendingPlaidTransaction" pg:"rel:has-one,fk:pending_"` // fk: is the prefix of the column we want to use to join on in a multikey join.
+	TellerTransactionId       *ID[TellerTransaction]    `json:"-" pg:"teller_transaction_id"`
+	TellerTransaction         *TellerTransaction        `json:"tellerTransaction,omitempty" pg:"rel:has-one"`
+	SimpleFINTransactionId    *ID[SimpleFINTransaction] `json:"-" pg:"simplefin_transaction_id"`
+	SimpleFINTransaction      *SimpleFINTransaction     `json:"simpleFINTransaction,omitempty" pg:"simplefin_transaction,rel:has-one"`
+	Amount                    int64                     `json:"amount" pg:"amount,notnull,use_zero"`
+	SpendingId                *ID[Spending]             `json:"spendingId" pg:"spending_id,on_delete:SET NULL"`
+	Spending                  *Spending                 `json:"spending,omitempty" pg:"rel:has-one"`
 	// SpendingAmount is the amount deducted from the expense this transaction was
 	// spent from. This is used when a transaction is more than the expense
 	// currently has allocated. If the transaction were to be deleted or changed
@@ -47,10 +53,16 @@ type Transaction struct {
 	MerchantName         string            `json:"merchantName,omitempty" pg:"merchant_name"`
 	OriginalMerchantName string            `json:"originalMerchantName" pg:"original_merchant_name"`
 	IsPending            bool              `json:"isPending" pg:"is_pending,notnull,use_zero"`
+	IsHidden             bool              `json:"isHidden" pg:"is_hidden,notnull,use_zero"`
 	UploadIdentifier     *string           `json:"uploadIdentifier" pg:"upload_identifier"`
 	Source               TransactionSource `json:"source" pg:"source"`
 	CreatedAt            time.Time         `json:"createdAt" pg:"created_at,notnull,default:now()"`
 	DeletedAt            *time.Time        `json:"deletedAt" pg:"deleted_at"`
+	// Splits are only present when the transaction has been split across
+	// multiple spending objects. When a transaction has splits it will not have
+	// a spendingId of its own. Splits are stored separately and are only loaded
+	// when they are needed.
+	Splits []TransactionSplit `json:"splits,omitempty" pg:"-"`
 }
 
 func (Transaction) IdentityPrefix() string {
@@ -85,16 +97,54 @@ func (t *Transaction) AddSpendingToTransaction(ctx context.Context, spending *Sp
 	span := sentry.StartSpan(ctx, "AddSpendingToTransaction")
 	defer span.Finish()
 
+	allocationAmount, err := deductSpending(span.Context(), t.Amount, spending, account)
+	if err != nil {
+		return errors.Wrap(err, "failed to calculate next contribution for new transaction expense")
+	}
+
+	// Keep track of how much we took from the spending in case things change
+	// later.
+	t.SpendingAmount = &allocationAmount
+
+	return nil
+}
+
+// RemoveSpendingFromTransaction is the inverse of AddSpendingToTransaction. It
+// will return the amount that was previously deducted for this transaction to
+// the provided spending object. It does not change the spendingId on the
+// transaction.
+func (t *Transaction) RemoveSpendingFromTransaction(ctx context.Context, spending *Spending, account *Account) error {
+	span := sentry.StartSpan(ctx, "RemoveSpendingFromTransaction")
+	defer span.Finish()
+
+	if t.SpendingAmount == nil {
+		return errors.New("transaction spending amount is missing, cannot restore spending")
+	}
+
+	if err := restoreSpending(span.Context(), *t.SpendingAmount, spending, account); err != nil {
+		return errors.Wrap(err, "failed to calculate next contribution for current transaction expense")
+	}
+
+	t.SpendingAmount = nil
+
+	return nil
+}
+
+// deductSpending will take as much of the provided amount as it can from the
+// spending object, and will return the amount that was actually taken. If the
+// spending object does not have enough allocated to it then only what it has
+// will be taken.
+func deductSpending(ctx context.Context, amount int64, spending *Spending, account *Account) (int64, error) {
 	var allocationAmount int64
 	// If the amount allocated to the spending we are adding to the transaction is
 	// less than the amount of the transaction then we can only do a partial
 	// allocation.
-	if spending.CurrentAmount < t.Amount {
+	if spending.CurrentAmount < amount {
 		allocationAmount = spending.CurrentAmount
 	} else {
 		// Otherwise, we will allocate the entire transaction amount from the
 		// spending.
-		allocationAmount = t.Amount
+		allocationAmount = amount
 	}
 
 	// Subtract the amount we are taking from the spending from it's current
@@ -111,22 +161,41 @@ func (t *Transaction) AddSpendingToTransaction(ctx context.Context, spending *Sp
 		spending.UsedAmount += allocationAmount
 	}
 
-	// Keep track of how much we took from the spending in case things change
-	// later.
-	t.SpendingAmount = &allocationAmount
-
 	// Now that we have deducted the amount we need from the spending we need to
 	// recalculate it's next contribution.
 	if err := spending.CalculateNextContribution(
-		span.Context(),
+		ctx,
 		account.Timezone,
 		spending.FundingSchedule,
 		time.Now(),
 	); err != nil {
-		return errors.Wrap(err, "failed to calculate next contribution for new transaction expense")
+		return 0, err
 	}
 
-	return nil
+	return allocationAmount, nil
+}
+
+// restoreSpending will add an amount that was previously taken from the
+// spending object by deductSpending back to it.
+func restoreSpending(ctx context.Context, amount int64, spending *Spending, account *Account) error {
+	spending.CurrentAmount += amount
+
+	switch spending.SpendingType {
+	case SpendingTypeExpense:
+	// Nothing special for expenses.
+	case SpendingTypeGoal:
+		// Revert the amount used for the spending object.
+		spending.UsedAmount -= amount
+	}
+
+	// Now that we have added that money back to the spending we need to
+	// calculate its next contribution.
+	return spending.CalculateNextContribution(
+		ctx,
+		account.Timezone,
+		spending.FundingSchedule,
+		time.Now(),
+	)
 }
 
 func AddSpendingToTransaction(
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*TransactionClusterOverride)(nil)
+	_ Identifiable        = TransactionClusterOverride{}
+)
+
+// TransactionClusterOverride stores a change that the user has made to a
+// transaction cluster. Clusters are regenerated whenever new transactions come
+// in, so overrides are keyed by the signature of the cluster rather than its
+// ID and are applied again each time the clusters are calculated.
+type TransactionClusterOverride struct {
+	tableName string `pg:"transaction_cluster_overrides"`
+
+	TransactionClusterOverrideId ID[TransactionClusterOverride] `json:"-" pg:"transaction_cluster_override_id,notnull,pk"`
+	AccountId                    ID[Account]                    `json:"-" pg:"account_id,notnull,pk"`
+	Account                      *Account                       `json:"-" pg:"rel:has-one"`
+	BankAccountId                ID[BankAccount]                `json:"-" pg:"bank_account_id,notnull"`
+	BankAccount                  *BankAccount                   `json:"-" pg:"rel:has-one"`
+	// Signature is the signature of the cluster that this override applies to.
+	Signature string `json:"-" pg:"signature,notnull"`
+	// Name will replace the generated name of the cluster.
+	Name *string `json:"-" pg:"name"`
+	// MergedInto is the signature of another cluster that all of the members of
+	// this cluster should be moved into.
+	MergedInto *string `json:"-" pg:"merged_into"`
+	// Members is only set for clusters that were created by splitting
+	// transactions out of another cluster. These transactions will always be
+	// removed from the generated clusters and kept together in their own.
+	Members   []ID[Transaction] `json:"-" pg:"members,type:'varchar(32)[]'"`
+	CreatedAt time.Time         `json:"-" pg:"created_at,notnull"`
+	UpdatedAt time.Time         `json:"-" pg:"updated_at,notnull"`
+}
+
+func (TransactionClusterOverride) IdentityPrefix() string {
+	return "tclo"
+}
+
+func (o *TransactionClusterOverride) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TransactionClusterOverrideId.IsZero() {
+		o.TransactionClusterOverrideId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
+
+// IsSplit returns true if the override represents a cluster that was created
+// by splitting transactions out of another cluster.
+func (o *TransactionClusterOverride) IsSplit() bool {
+	return len(o.Members) > 0
+}
+
+// ApplyTransactionClusterOverrides will take freshly calculated transaction
+// clusters and apply the user's overrides to them. Transactions that were
+// split out into their own clusters are removed from the generated clusters,
+// merged clusters are combined and renamed clusters are given their new name.
+// Any clusters that end up without members are dropped.
+func ApplyTransactionClusterOverrides(
+	clusters []TransactionCluster,
+	overrides []TransactionClusterOverride,
+) []TransactionCluster {
+	if len(overrides) == 0 {
+		return clusters
+	}
+
+	bySignature := make(map[string]*TransactionClusterOverride, len(overrides))
+	splitMembers := map[ID[Transaction]]struct{}{}
+	for i := range overrides {
+		override := &overrides[i]
+		bySignature[override.Signature] = override
+		for _, member := range override.Members {
+			splitMembers[member] = struct{}{}
+		}
+	}
+
+	result := make([]TransactionCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		members := make([]ID[Transaction], 0, len(cluster.Members))
+		for _, member := range cluster.Members {
+			if _, ok := splitMembers[member]; !ok {
+				members = append(members, member)
+			}
+		}
+		cluster.Members = members
+		result = append(result, cluster)
+	}
+
+	for _, override := range overrides {
+		if !override.IsSplit() {
+			continue
+		}
+
+		result = append(result, TransactionCluster{
+			BankAccountId: override.BankAccountId,
+			Signature:     override.Signature,
+			Members:       override.Members,
+		})
+	}
+
+	indexes := make(map[string]int, len(result))
+	for i, cluster := range result {
+		indexes[cluster.Signature] = i
+	}
+
+	// resolve follows the merges for a cluster until it reaches a cluster that
+	// has not been merged into another one. If the final cluster does not exist
+	// then -1 is returned.
+	resolve := func(signature string) int {
+		// Limit the number of hops so that a cycle cannot loop forever.
+		for hops := 0; hops <= len(overrides); hops++ {
+			override, ok := bySignature[signature]
+			if !ok || override.MergedInto == nil {
+				break
+			}
+			signature = *override.MergedInto
+		}
+
+		if index, ok := indexes[signature]; ok {
+			return index
+		}
+		return -1
+	}
+
+	merged := make([]bool, len(result))
+	for i := range result {
+		target := resolve(result[i].Signature)
+		// If the target was already merged into this cluster then the merges form
+		// a cycle, leave the cluster where it is.
+		if target == -1 || target == i || merged[target] {
+			continue
+		}
+
+		result[target].Members = append(result[target].Members, result[i].Members...)
+		result[i].Members = nil
+		merged[i] = true
+	}
+
+	clustersWithOverrides := make([]TransactionCluster, 0, len(result))
+	for i, cluster := range result {
+		if merged[i] || len(cluster.Members) == 0 {
+			continue
+		}
+
+		if override, ok := bySignature[cluster.Signature]; ok && override.Name != nil {
+			cluster.Name = *override.Name
+		}
+
+		clustersWithOverrides = append(clustersWithOverrides, cluster)
+	}
+
+	return clustersWithOverrides
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTransactionClusterOverrides(t *testing.T) {
+	generated := func() []TransactionCluster {
+		return []TransactionCluster{
+			{
+				Signature: "amazon",
+				Name:      "Amazon",
+				Members:   []ID[Transaction]{"txn_1", "txn_2", "txn_3"},
+			},
+			{
+				Signature: "amzn",
+				Name:      "AMZN Mktp",
+				Members:   []ID[Transaction]{"txn_4", "txn_5"},
+			},
+			{
+				Signature: "starbucks",
+				Name:      "Starbucks",
+				Members:   []ID[Transaction]{"txn_6", "txn_7"},
+			},
+		}
+	}
+
+	t.Run("no overrides", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), nil)
+		assert.Equal(t, generated(), result)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature: "starbucks",
+				Name:      myownsanity.StringP("Coffee"),
+			},
+		})
+		assert.Len(t, result, 3)
+		assert.Equal(t, "Coffee", result[2].Name)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature:  "amzn",
+				MergedInto: myownsanity.StringP("amazon"),
+			},
+		})
+		assert.Len(t, result, 2)
+		assert.Equal(t, "amazon", result[0].Signature)
+		assert.ElementsMatch(t, []ID[Transaction]{"txn_1", "txn_2", "txn_3", "txn_4", "txn_5"}, result[0].Members)
+	})
+
+	t.Run("merge chain", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature:  "amzn",
+				MergedInto: myownsanity.StringP("amazon"),
+			},
+			{
+				Signature:  "amazon",
+				MergedInto: myownsanity.StringP("starbucks"),
+			},
+		})
+		assert.Len(t, result, 1)
+		assert.Equal(t, "starbucks", result[0].Signature)
+		assert.Len(t, result[0].Members, 7)
+	})
+
+	t.Run("merge into a missing cluster", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature:  "amzn",
+				MergedInto: myownsanity.StringP("gone"),
+			},
+		})
+		assert.Equal(t, generated(), result, "clusters should be left alone")
+	})
+
+	t.Run("merge cycle", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature:  "amzn",
+				MergedInto: myownsanity.StringP("amazon"),
+			},
+			{
+				Signature:  "amazon",
+				MergedInto: myownsanity.StringP("amzn"),
+			},
+		})
+		var members int
+		for _, cluster := range result {
+			members += len(cluster.Members)
+		}
+		assert.Equal(t, 7, members, "no transactions should be lost")
+	})
+
+	t.Run("split", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature: "tcl_split",
+				Name:      myownsanity.StringP("Amazon Prime"),
+				Members:   []ID[Transaction]{"txn_2", "txn_3"},
+			},
+		})
+		assert.Len(t, result, 4)
+		assert.Equal(t, []ID[Transaction]{"txn_1"}, result[0].Members)
+		assert.Equal(t, "tcl_split", result[3].Signature)
+		assert.Equal(t, "Amazon Prime", result[3].Name)
+		assert.Equal(t, []ID[Transaction]{"txn_2", "txn_3"}, result[3].Members)
+	})
+
+	t.Run("split every member", func(t *testing.T) {
+		result := ApplyTransactionClusterOverrides(generated(), []TransactionClusterOverride{
+			{
+				Signature: "tcl_split",
+				Name:      myownsanity.StringP("Coffee"),
+				Members:   []ID[Transaction]{"txn_6", "txn_7"},
+			},
+		})
+		assert.Len(t, result, 3, "the empty cluster should be removed")
+		for _, cluster := range result {
+			assert.NotEqual(t, "starbucks", cluster.Signature)
+		}
+	})
+}
@@ -1,6 +1,14 @@
 package models
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pg/pg/v10"
+)
 
 type WindowType string
 
@@ -16,24 +24,85 @@ const (
 	YearlyWindowType            WindowType = "Yearly"
 )
 
+var (
+	_ pg.BeforeInsertHook = (*TransactionRecurring)(nil)
+	_ Identifiable        = TransactionRecurring{}
+)
+
+// TransactionRecurring is a suggestion of a recurring transaction that was
+// detected from a cluster of similar transactions for a bank account. These
+// are recalculated in the background and can be turned into an expense by the
+// user.
 type TransactionRecurring struct {
 	tableName string `pg:"transaction_recurring"`
 
-	TransactionRecurringId string            `json:"transactionRecurringId" pg:"transaction_recurring_id,notnull,pk"`
-	AccountId              uint64            `json:"-" pg:"account_id,notnull,type:'bigint'"`
-	Account                *Account          `json:"-" pg:"rel:has-one"`
-	BankAccountId          uint64            `json:"bankAccountId" pg:"bank_account_id,notnull,type:'bigint'"`
-	BankAccount            *BankAccount      `json:"-" pg:"rel:has-one"`
-	Name                   string            `json:"name" pg:"name,notnull"`
-	Window                 WindowType        `json:"windowType" pg:"window_type,notnull"`
-	RuleSet                *RuleSet          `json:"ruleset" pg:"ruleset,notnull,type:'text'"`
-	First                  time.Time         `json:"first" pg:"first,notnull"`
-	Last                   time.Time         `json:"last" pg:"last,notnull"`
-	Next                   time.Time         `json:"next" pg:"next,notnull"`
-	Ended                  bool              `json:"ended" pg:"ended,notnull"`
-	Confidence             float32           `json:"confidence" pg:"confidence,notnull"` // TODO What type should this be in postgres?
-	Amounts                map[int64]int     `json:"amounts" pg:"amounts,notnull"`       // TODO This will be a JSONB or hashmap col.
-	LastAmount             int64             `json:"lastAmount" pg:"last_amount,notnull"`
-	Members                []ID[Transaction] `json:"members" pg:"members,notnull,type:'bigint[]'"`
-	CreatedAt              time.Time         `json:"createdAt" pg:"created_at,notnull,default:now()"`
+	TransactionRecurringId ID[TransactionRecurring] `json:"transactionRecurringId" pg:"transaction_recurring_id,notnull,pk"`
+	AccountId              ID[Account]              `json:"-" pg:"account_id,notnull,pk"`
+	Account                *Account                 `json:"-" pg:"rel:has-one"`
+	BankAccountId          ID[BankAccount]          `json:"bankAccountId" pg:"bank_account_id,notnull"`
+	BankAccount            *BankAccount             `json:"-" pg:"rel:has-one"`
+	Name                   string                   `json:"name" pg:"name,notnull"`
+	Window                 WindowType               `json:"windowType" pg:"window_type,notnull"`
+	RuleSet                *RuleSet                 `json:"ruleset" pg:"ruleset,notnull,type:'text'"`
+	First                  time.Time                `json:"first" pg:"first,notnull"`
+	Last                   time.Time                `json:"last" pg:"last,notnull"`
+	Next                   time.Time                `json:"next" pg:"next,notnull"`
+	Ended                  bool                     `json:"ended" pg:"ended,notnull,use_zero"`
+	Confidence             float64                  `json:"confidence" pg:"confidence,notnull,use_zero"`
+	// Amounts is the number of times each amount was observed among the
+	// members, keyed by the amount.
+	Amounts    map[int64]int     `json:"amounts" pg:"amounts,notnull,type:'jsonb'"`
+	LastAmount int64             `json:"lastAmount" pg:"last_amount,notnull,use_zero"`
+	Members    []ID[Transaction] `json:"members" pg:"members,notnull,type:'varchar(32)[]'"`
+	// RecurringKey identifies the same recurring transaction each time that
+	// detection runs, this way its ID does not change when it is redetected.
+	RecurringKey string    `json:"-" pg:"recurring_key,notnull"`
+	CreatedAt    time.Time `json:"createdAt" pg:"created_at,notnull,default:now()"`
+}
+
+// TransactionRecurringKey returns the key that a recurring transaction with the
+// provided name and window is stored under. The name is normalized so that
+// small changes to how a merchant's name is formatted do not change the key.
+func TransactionRecurringKey(name string, window WindowType) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsDigit(character)
+	})
+	return fmt.Sprintf("%s:%s", strings.Join(words, " "), window)
+}
+
+func (TransactionRecurring) IdentityPrefix() string {
+	return "txrc"
+}
+
+func (o *TransactionRecurring) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TransactionRecurringId.IsZero() {
+		o.TransactionRecurringId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
+
+// AverageAmount returns the average amount of all of the members of the
+// recurring transaction, rounded to the nearest cent.
+func (o *TransactionRecurring) AverageAmount() int64 {
+	var total, count int64
+	for amount, occurrences := range o.Amounts {
+		total += amount * int64(occurrences)
+		count += int64(occurrences)
+	}
+
+	if count == 0 {
+		return o.LastAmount
+	}
+
+	// Round half away from zero so that deposits (which are negative) round the
+	// same way as debits.
+	if total < 0 {
+		return (total - count/2) / count
+	}
+	return (total + count/2) / count
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionRecurringKey(t *testing.T) {
+	assert.Equal(t, "netflix com:Monthly", TransactionRecurringKey("Netflix.com", MonthlyWindowType))
+	assert.Equal(
+		t,
+		TransactionRecurringKey("NETFLIX.COM ", MonthlyWindowType),
+		TransactionRecurringKey("Netflix com", MonthlyWindowType),
+		"formatting of the name should not change the key",
+	)
+	assert.NotEqual(
+		t,
+		TransactionRecurringKey("Netflix", MonthlyWindowType),
+		TransactionRecurringKey("Netflix", YearlyWindowType),
+		"the window should be part of the key",
+	)
+}
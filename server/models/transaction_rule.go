@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*TransactionRule)(nil)
+	_ Identifiable        = TransactionRule{}
+)
+
+// TransactionRule is a user defined rule that is evaluated against new
+// transactions for a bank account as they are synced or imported. Every
+// condition that is specified on the rule must match the transaction for the
+// rule to match, conditions that are left blank are ignored. When a rule
+// matches, each of its actions are applied to the transaction.
+type TransactionRule struct {
+	tableName string `pg:"transaction_rules"`
+
+	TransactionRuleId ID[TransactionRule] `json:"transactionRuleId" pg:"transaction_rule_id,notnull,pk"`
+	AccountId         ID[Account]         `json:"-" pg:"account_id,notnull,pk"`
+	Account           *Account            `json:"-" pg:"rel:has-one"`
+	BankAccountId     ID[BankAccount]     `json:"bankAccountId" pg:"bank_account_id,notnull"`
+	BankAccount       *BankAccount        `json:"-" pg:"rel:has-one"`
+	Name              string              `json:"name" pg:"name,notnull"`
+	// Priority determines the order that rules are evaluated in, rules with a
+	// lower priority are evaluated first. Only the first rule that matches a
+	// transaction is applied.
+	Priority  int  `json:"priority" pg:"priority,notnull,use_zero"`
+	IsEnabled bool `json:"isEnabled" pg:"is_enabled,notnull,use_zero"`
+
+	// NamePattern is a case-insensitive regular expression that is matched
+	// against the name and the original name of the transaction.
+	NamePattern *string `json:"namePattern" pg:"name_pattern"`
+	// MerchantPattern is a case-insensitive regular expression that is matched
+	// against the merchant name and the original merchant name of the
+	// transaction.
+	MerchantPattern *string `json:"merchantPattern" pg:"merchant_pattern"`
+	// MinAmount and MaxAmount are both inclusive and use the same sign as the
+	// transaction amount, deposits are negative.
+	MinAmount *int64 `json:"minAmount" pg:"min_amount"`
+	MaxAmount *int64 `json:"maxAmount" pg:"max_amount"`
+	// DaysOfMonth will match transactions that happened on any of the specified
+	// days of the month in the account's timezone.
+	DaysOfMonth []int `json:"daysOfMonth" pg:"days_of_month,array"`
+	// Category is matched case-insensitively against the custom category of the
+	// transaction or any of the categories provided by Plaid.
+	Category *string `json:"category" pg:"category"`
+
+	// SpendingId is the spending object that matching transactions will be spent
+	// from.
+	SpendingId *ID[Spending] `json:"spendingId" pg:"spending_id"`
+	Spending   *Spending     `json:"-" pg:"rel:has-one"`
+	// RenameTo will replace the name of matching transactions.
+	RenameTo *string `json:"renameTo" pg:"rename_to"`
+	// Hide will hide matching transactions from the transaction list.
+	Hide bool `json:"hide" pg:"hide,notnull,use_zero"`
+
+	CreatedAt time.Time `json:"createdAt" pg:"created_at,notnull"`
+	CreatedBy ID[User]  `json:"createdBy" pg:"created_by,notnull"`
+	UpdatedAt time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+
+	namePattern     *regexp.Regexp
+	merchantPattern *regexp.Regexp
+}
+
+func (TransactionRule) IdentityPrefix() string {
+	return "trul"
+}
+
+func (o *TransactionRule) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TransactionRuleId.IsZero() {
+		o.TransactionRuleId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
+
+// Validate makes sure that the rule has a name, at least one action and that
+// all of its conditions are valid.
+func (o *TransactionRule) Validate() error {
+	if strings.TrimSpace(o.Name) == "" {
+		return errors.New("rule must have a name")
+	}
+
+	if o.SpendingId == nil && o.RenameTo == nil && !o.Hide {
+		return errors.New("rule must have at least one action")
+	}
+
+	if o.RenameTo != nil && strings.TrimSpace(*o.RenameTo) == "" {
+		return errors.New("rule cannot rename transactions to a blank name")
+	}
+
+	return o.ValidateConditions()
+}
+
+// ValidateConditions makes sure that the rule has at least one condition and
+// that all of its conditions are valid. It will also compile any patterns on
+// the rule so that they can be used by Matches.
+func (o *TransactionRule) ValidateConditions() error {
+	if !o.hasCondition() {
+		return errors.New("rule must have at least one condition")
+	}
+
+	if o.MinAmount != nil && o.MaxAmount != nil && *o.MinAmount > *o.MaxAmount {
+		return errors.New("minimum amount cannot be greater than the maximum amount")
+	}
+
+	for _, day := range o.DaysOfMonth {
+		if day < 1 || day > 31 {
+			return errors.Errorf("invalid day of month: %d", day)
+		}
+	}
+
+	return o.compile()
+}
+
+// Matches returns true if every condition on the rule matches the provided
+// transaction. The timezone is used to determine the day of the month that
+// the transaction happened on.
+func (o *TransactionRule) Matches(transaction Transaction, timezone *time.Location) bool {
+	if !o.hasCondition() {
+		return false
+	}
+
+	if err := o.compile(); err != nil {
+		return false
+	}
+
+	if o.namePattern != nil &&
+		!o.namePattern.MatchString(transaction.Name) &&
+		!o.namePattern.MatchString(transaction.OriginalName) {
+		return false
+	}
+
+	if o.merchantPattern != nil &&
+		!o.merchantPattern.MatchString(transaction.MerchantName) &&
+		!o.merchantPattern.MatchString(transaction.OriginalMerchantName) {
+		return false
+	}
+
+	if o.MinAmount != nil && transaction.Amount < *o.MinAmount {
+		return false
+	}
+
+	if o.MaxAmount != nil && transaction.Amount > *o.MaxAmount {
+		return false
+	}
+
+	if len(o.DaysOfMonth) > 0 {
+		day := transaction.Date.In(timezone).Day()
+		found := false
+		for _, item := range o.DaysOfMonth {
+			if item == day {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if o.Category != nil {
+		found := transaction.Category != nil && strings.EqualFold(*transaction.Category, *o.Category)
+		for _, category := range transaction.Categories {
+			if found {
+				break
+			}
+			found = strings.EqualFold(category, *o.Category)
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply will rename or hide the provided transaction if the rule specifies it.
+// Spending objects are not handled here because the amount of the transaction
+// also needs to be deducted from the spending object, see
+// AddSpendingToTransaction.
+func (o *TransactionRule) Apply(transaction *Transaction) {
+	if o.RenameTo != nil {
+		transaction.Name = *o.RenameTo
+	}
+
+	if o.Hide {
+		transaction.IsHidden = true
+	}
+}
+
+func (o *TransactionRule) hasCondition() bool {
+	return o.NamePattern != nil ||
+		o.MerchantPattern != nil ||
+		o.MinAmount != nil ||
+		o.MaxAmount != nil ||
+		len(o.DaysOfMonth) > 0 ||
+		o.Category != nil
+}
+
+func (o *TransactionRule) compile() (err error) {
+	if o.NamePattern != nil && o.namePattern == nil {
+		o.namePattern, err = regexp.Compile("(?i)" + *o.NamePattern)
+		if err != nil {
+			return errors.Wrap(err, "invalid name pattern")
+		}
+	}
+
+	if o.MerchantPattern != nil && o.merchantPattern == nil {
+		o.merchantPattern, err = regexp.Compile("(?i)" + *o.MerchantPattern)
+		if err != nil {
+			return errors.Wrap(err, "invalid merchant pattern")
+		}
+	}
+
+	return nil
+}
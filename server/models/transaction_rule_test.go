@@ -0,0 +1,128 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionRule_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		rule := TransactionRule{
+			Name:        "Coffee",
+			NamePattern: myownsanity.StringP("starbucks"),
+			Hide:        true,
+		}
+		assert.NoError(t, rule.Validate())
+	})
+
+	t.Run("no conditions", func(t *testing.T) {
+		rule := TransactionRule{
+			Name: "Everything",
+			Hide: true,
+		}
+		assert.EqualError(t, rule.Validate(), "rule must have at least one condition")
+	})
+
+	t.Run("no actions", func(t *testing.T) {
+		rule := TransactionRule{
+			Name:        "Coffee",
+			NamePattern: myownsanity.StringP("starbucks"),
+		}
+		assert.EqualError(t, rule.Validate(), "rule must have at least one action")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		rule := TransactionRule{
+			Name:        "Coffee",
+			NamePattern: myownsanity.StringP("starbucks("),
+			Hide:        true,
+		}
+		assert.ErrorContains(t, rule.Validate(), "invalid name pattern")
+	})
+
+	t.Run("invalid amount range", func(t *testing.T) {
+		rule := TransactionRule{
+			Name:      "Coffee",
+			MinAmount: myownsanity.Int64P(500),
+			MaxAmount: myownsanity.Int64P(100),
+			Hide:      true,
+		}
+		assert.EqualError(t, rule.Validate(), "minimum amount cannot be greater than the maximum amount")
+	})
+
+	t.Run("invalid day of month", func(t *testing.T) {
+		rule := TransactionRule{
+			Name:        "Rent",
+			DaysOfMonth: []int{1, 32},
+			Hide:        true,
+		}
+		assert.EqualError(t, rule.Validate(), "invalid day of month: 32")
+	})
+}
+
+func TestTransactionRule_Matches(t *testing.T) {
+	transaction := Transaction{
+		Name:                 "Starbucks",
+		OriginalName:         "POS DEBIT STARBUCKS STORE 1234",
+		MerchantName:         "Starbucks",
+		OriginalMerchantName: "Starbucks",
+		Amount:               575,
+		Date:                 time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC),
+		Categories:           []string{"Food and Drink", "Coffee Shop"},
+	}
+
+	t.Run("name pattern is case insensitive", func(t *testing.T) {
+		rule := TransactionRule{
+			NamePattern: myownsanity.StringP(`store \d+`),
+		}
+		assert.True(t, rule.Matches(transaction, time.UTC))
+	})
+
+	t.Run("all conditions must match", func(t *testing.T) {
+		rule := TransactionRule{
+			MerchantPattern: myownsanity.StringP("^starbucks$"),
+			MinAmount:       myownsanity.Int64P(100),
+			MaxAmount:       myownsanity.Int64P(1000),
+			DaysOfMonth:     []int{5, 20},
+			Category:        myownsanity.StringP("coffee shop"),
+		}
+		assert.True(t, rule.Matches(transaction, time.UTC))
+
+		rule.MaxAmount = myownsanity.Int64P(500)
+		assert.False(t, rule.Matches(transaction, time.UTC), "amount is above the maximum")
+	})
+
+	t.Run("day of month uses timezone", func(t *testing.T) {
+		central, err := time.LoadLocation("America/Chicago")
+		assert.NoError(t, err)
+
+		rule := TransactionRule{
+			DaysOfMonth: []int{5},
+		}
+		assert.True(t, rule.Matches(transaction, time.UTC))
+		assert.False(t, rule.Matches(transaction, central), "midnight UTC is the day before in central time")
+	})
+
+	t.Run("no conditions never matches", func(t *testing.T) {
+		rule := TransactionRule{
+			Hide: true,
+		}
+		assert.False(t, rule.Matches(transaction, time.UTC))
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		rule := TransactionRule{
+			NamePattern: myownsanity.StringP("starbucks"),
+			RenameTo:    myownsanity.StringP("Coffee"),
+			Hide:        true,
+		}
+		result := transaction
+		rule.Apply(&result)
+		assert.Equal(t, "Coffee", result.Name)
+		assert.Equal(t, transaction.OriginalName, result.OriginalName, "original name should not change")
+		assert.True(t, result.IsHidden)
+	})
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"math/bits"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/pkg/errors"
+)
+
+// TransactionSplit represents a portion of a transaction's amount. When a
+// transaction has been split, each split can be spent from its own spending
+// object instead of the transaction itself being spent from a single one. The
+// amounts of all of the splits for a transaction must add up to the amount of
+// the transaction.
+type TransactionSplit struct {
+	tableName string `pg:"transaction_splits"`
+
+	TransactionSplitId ID[TransactionSplit] `json:"transactionSplitId" pg:"transaction_split_id,notnull,pk"`
+	AccountId          ID[Account]          `json:"-" pg:"account_id,notnull,pk"`
+	Account            *Account             `json:"-" pg:"rel:has-one"`
+	BankAccountId      ID[BankAccount]      `json:"bankAccountId" pg:"bank_account_id,notnull"`
+	BankAccount        *BankAccount         `json:"-" pg:"rel:has-one"`
+	TransactionId      ID[Transaction]      `json:"transactionId" pg:"transaction_id,notnull"`
+	SpendingId         *ID[Spending]        `json:"spendingId" pg:"spending_id"`
+	Spending           *Spending            `json:"-" pg:"rel:has-one"`
+	Amount             int64                `json:"amount" pg:"amount,notnull,use_zero"`
+	// SpendingAmount is the amount that was actually deducted from the spending
+	// object for this split. Just like on the transaction itself, this may be
+	// less than the amount of the split if the spending object did not have
+	// enough allocated to it.
+	SpendingAmount *int64    `json:"spendingAmount,omitempty" pg:"spending_amount,use_zero"`
+	CreatedAt      time.Time `json:"createdAt" pg:"created_at,notnull"`
+}
+
+func (TransactionSplit) IdentityPrefix() string {
+	return "tspl"
+}
+
+var (
+	_ pg.BeforeInsertHook = (*TransactionSplit)(nil)
+)
+
+func (o *TransactionSplit) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TransactionSplitId.IsZero() {
+		o.TransactionSplitId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
+
+// ValidateTransactionSplits makes sure that the provided splits are valid for
+// the provided transaction. An empty set of splits is always valid, as that is
+// how the splits are removed from a transaction.
+func ValidateTransactionSplits(transaction Transaction, splits []TransactionSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	if transaction.IsAddition() {
+		return errors.New("deposits cannot be split")
+	}
+
+	if len(splits) < 2 {
+		return errors.New("a transaction must be split at least two ways")
+	}
+
+	var total int64
+	for _, split := range splits {
+		if split.Amount <= 0 {
+			return errors.New("each split must have an amount greater than zero")
+		}
+		total += split.Amount
+	}
+
+	if total != transaction.Amount {
+		return errors.Errorf(
+			"splits must add up to the transaction amount; splits: %d transaction: %d",
+			total, transaction.Amount,
+		)
+	}
+
+	return nil
+}
+
+// ScaleTransactionSplits returns new splits for a transaction whose amount has
+// changed, each split keeps its spending object and its share of the amount.
+// Any amount left over from rounding is added to the last split. If the splits
+// cannot be scaled, because the transaction is now a deposit or because a split
+// would be left with nothing, then nil is returned and the splits should be
+// removed instead.
+func ScaleTransactionSplits(splits []TransactionSplit, amount int64) []TransactionSplit {
+	if len(splits) == 0 || amount <= 0 {
+		return nil
+	}
+
+	var total int64
+	for _, split := range splits {
+		if split.Amount <= 0 {
+			return nil
+		}
+		total += split.Amount
+	}
+
+	result := make([]TransactionSplit, len(splits))
+	remaining := amount
+	for i, split := range splits {
+		scaled := remaining
+		if i < len(splits)-1 {
+			// Each split is at most the total, so the quotient always fits.
+			hi, lo := bits.Mul64(uint64(split.Amount), uint64(amount))
+			quotient, _ := bits.Div64(hi, lo, uint64(total))
+			scaled = int64(quotient)
+		}
+		if scaled <= 0 {
+			return nil
+		}
+		remaining -= scaled
+
+		result[i] = TransactionSplit{
+			SpendingId: split.SpendingId,
+			Amount:     scaled,
+		}
+	}
+
+	return result
+}
+
+// AddSpendingToSplit is the same as AddSpendingToTransaction, but only deducts
+// the amount of the split from the provided spending object.
+func (s *TransactionSplit) AddSpendingToSplit(ctx context.Context, spending *Spending, account *Account) error {
+	allocationAmount, err := deductSpending(ctx, s.Amount, spending, account)
+	if err != nil {
+		return errors.Wrap(err, "failed to calculate next contribution for split expense")
+	}
+
+	// Keep track of how much we took from the spending in case things change
+	// later.
+	s.SpendingAmount = &allocationAmount
+	return nil
+}
+
+// RemoveSpendingFromSplit is the inverse of AddSpendingToSplit. It will return
+// the amount that was deducted for this split to the provided spending object.
+func (s *TransactionSplit) RemoveSpendingFromSplit(ctx context.Context, spending *Spending, account *Account) error {
+	if s.SpendingAmount == nil {
+		return errors.New("split spending amount is missing, cannot restore spending")
+	}
+
+	if err := restoreSpending(ctx, *s.SpendingAmount, spending, account); err != nil {
+		return errors.Wrap(err, "failed to calculate next contribution for split expense")
+	}
+
+	s.SpendingAmount = nil
+	return nil
+}
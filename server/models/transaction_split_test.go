@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransactionSplits(t *testing.T) {
+	transaction := Transaction{
+		Amount: 1000,
+	}
+
+	t.Run("no splits", func(t *testing.T) {
+		assert.NoError(t, ValidateTransactionSplits(transaction, nil))
+	})
+
+	t.Run("valid splits", func(t *testing.T) {
+		err := ValidateTransactionSplits(transaction, []TransactionSplit{
+			{Amount: 600},
+			{Amount: 400},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("deposit", func(t *testing.T) {
+		err := ValidateTransactionSplits(Transaction{Amount: -1000}, []TransactionSplit{
+			{Amount: -600},
+			{Amount: -400},
+		})
+		assert.EqualError(t, err, "deposits cannot be split")
+	})
+
+	t.Run("single split", func(t *testing.T) {
+		err := ValidateTransactionSplits(transaction, []TransactionSplit{
+			{Amount: 1000},
+		})
+		assert.EqualError(t, err, "a transaction must be split at least two ways")
+	})
+
+	t.Run("zero amount", func(t *testing.T) {
+		err := ValidateTransactionSplits(transaction, []TransactionSplit{
+			{Amount: 1000},
+			{Amount: 0},
+		})
+		assert.EqualError(t, err, "each split must have an amount greater than zero")
+	})
+
+	t.Run("does not add up", func(t *testing.T) {
+		err := ValidateTransactionSplits(transaction, []TransactionSplit{
+			{Amount: 600},
+			{Amount: 300},
+		})
+		assert.EqualError(t, err, "splits must add up to the transaction amount; splits: 900 transaction: 1000")
+	})
+}
+
+func TestScaleTransactionSplits(t *testing.T) {
+	groceries := ID[Spending]("spnd_groceries")
+	household := ID[Spending]("spnd_household")
+	splits := []TransactionSplit{
+		{TransactionSplitId: "tspl_one", SpendingId: &groceries, Amount: 600},
+		{TransactionSplitId: "tspl_two", SpendingId: &household, Amount: 400},
+	}
+
+	t.Run("larger amount", func(t *testing.T) {
+		result := ScaleTransactionSplits(splits, 1250)
+		if assert.Len(t, result, 2) {
+			assert.EqualValues(t, 750, result[0].Amount)
+			assert.EqualValues(t, 500, result[1].Amount)
+			assert.Equal(t, &groceries, result[0].SpendingId)
+			assert.Equal(t, &household, result[1].SpendingId)
+			assert.Empty(t, result[0].TransactionSplitId, "scaled splits are new splits")
+		}
+		assert.NoError(t, ValidateTransactionSplits(Transaction{Amount: 1250}, result))
+	})
+
+	t.Run("remainder goes to the last split", func(t *testing.T) {
+		result := ScaleTransactionSplits([]TransactionSplit{
+			{Amount: 100},
+			{Amount: 100},
+			{Amount: 100},
+		}, 1000)
+		if assert.Len(t, result, 3) {
+			assert.EqualValues(t, 333, result[0].Amount)
+			assert.EqualValues(t, 333, result[1].Amount)
+			assert.EqualValues(t, 334, result[2].Amount)
+		}
+	})
+
+	t.Run("deposit", func(t *testing.T) {
+		assert.Nil(t, ScaleTransactionSplits(splits, -1000))
+	})
+
+	t.Run("split would be empty", func(t *testing.T) {
+		assert.Nil(t, ScaleTransactionSplits([]TransactionSplit{
+			{Amount: 1},
+			{Amount: 999},
+		}, 10))
+	})
+}
+
+func TestTransactionSplit_AddSpendingToSplit(t *testing.T) {
+	account := &Account{
+		Timezone: "UTC",
+	}
+	now := time.Now()
+	fundingRule := RuleToSet(t, time.UTC, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15,-1", now)
+	spendingRule := RuleToSet(t, time.UTC, "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=8", now)
+	fundingSchedule := GiveMeAFundingSchedule(fundingRule.After(now, false), fundingRule)
+
+	t.Run("deduct and restore", func(t *testing.T) {
+		spending := &Spending{
+			SpendingType:    SpendingTypeExpense,
+			TargetAmount:    5000,
+			CurrentAmount:   1000,
+			RuleSet:         spendingRule,
+			NextRecurrence:  spendingRule.After(now, false),
+			FundingSchedule: fundingSchedule,
+		}
+
+		split := TransactionSplit{
+			Amount: 400,
+		}
+
+		err := split.AddSpendingToSplit(context.Background(), spending, account)
+		assert.NoError(t, err, "should be able to spend the split from the spending object")
+		assert.EqualValues(t, 600, spending.CurrentAmount, "only the split amount should be deducted")
+		if assert.NotNil(t, split.SpendingAmount, "spending amount should be set") {
+			assert.EqualValues(t, 400, *split.SpendingAmount)
+		}
+
+		err = split.RemoveSpendingFromSplit(context.Background(), spending, account)
+		assert.NoError(t, err, "should be able to restore the spending object")
+		assert.EqualValues(t, 1000, spending.CurrentAmount, "the split amount should be returned")
+		assert.Nil(t, split.SpendingAmount, "spending amount should be cleared")
+	})
+
+	t.Run("partial deduction", func(t *testing.T) {
+		spending := &Spending{
+			SpendingType:    SpendingTypeExpense,
+			TargetAmount:    5000,
+			CurrentAmount:   250,
+			RuleSet:         spendingRule,
+			NextRecurrence:  spendingRule.After(now, false),
+			FundingSchedule: fundingSchedule,
+		}
+
+		split := TransactionSplit{
+			Amount: 400,
+		}
+
+		err := split.AddSpendingToSplit(context.Background(), spending, account)
+		assert.NoError(t, err, "should be able to spend the split from the spending object")
+		assert.EqualValues(t, 0, spending.CurrentAmount, "everything allocated should be used")
+		if assert.NotNil(t, split.SpendingAmount, "spending amount should be set") {
+			assert.EqualValues(t, 250, *split.SpendingAmount)
+		}
+	})
+}
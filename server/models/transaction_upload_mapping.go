@@ -0,0 +1,66 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/formats"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*TransactionUploadMapping)(nil)
+	_ Identifiable        = TransactionUploadMapping{}
+)
+
+// TransactionUploadMapping describes the column layout of tabular transaction
+// files (like CSV) for a single bank account. Institutions do not agree on a
+// single layout for their exports, so the user describes it once and the
+// mapping is reused for every subsequent upload to that bank account.
+type TransactionUploadMapping struct {
+	tableName string `pg:"transaction_upload_mappings"`
+
+	TransactionUploadMappingId ID[TransactionUploadMapping] `json:"transactionUploadMappingId" pg:"transaction_upload_mapping_id,notnull,pk"`
+	AccountId                  ID[Account]                  `json:"-" pg:"account_id,notnull,pk"`
+	Account                    *Account                     `json:"-" pg:"rel:has-one"`
+	BankAccountId              ID[BankAccount]              `json:"bankAccountId" pg:"bank_account_id,notnull,unique:per_bank_account"`
+	BankAccount                *BankAccount                 `json:"-" pg:"rel:has-one"`
+	// Fields is the index of columns in the file, each item in the array
+	// represents the column at the same index in the file.
+	Fields formats.FieldIndex `json:"fields" pg:"fields,notnull"`
+	// HeaderRow indicates that the first row in the file is a header and should
+	// not be imported as a transaction. For Excel workbooks the header row is
+	// detected instead, so any rows above it are skipped as well.
+	HeaderRow bool `json:"headerRow" pg:"header_row,notnull,use_zero"`
+	// DateFormat is the Go time layout used to parse dates in the file. If it is
+	// left blank then monetr will try several common formats.
+	DateFormat string `json:"dateFormat" pg:"date_format,notnull,use_zero"`
+	// DecimalSeparator is the character that separates the whole and fractional
+	// parts of amounts in the file, either formats.DecimalSeparatorPeriod or
+	// formats.DecimalSeparatorComma.
+	DecimalSeparator string    `json:"decimalSeparator" pg:"decimal_separator,notnull"`
+	CreatedAt        time.Time `json:"createdAt" pg:"created_at,notnull"`
+	CreatedBy        ID[User]  `json:"createdBy" pg:"created_by,notnull"`
+	UpdatedAt        time.Time `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+func (TransactionUploadMapping) IdentityPrefix() string {
+	return "txum"
+}
+
+func (o *TransactionUploadMapping) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.TransactionUploadMappingId.IsZero() {
+		o.TransactionUploadMappingId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-pg/pg/v10"
 )
@@ -23,6 +24,9 @@ type User struct {
 	AccountId ID[Account] `json:"accountId" pg:"account_id,notnull,unique:per_account"`
 	Account   *Account    `json:"account" pg:"rel:has-one"`
 	Role      UserRole    `json:"role" pg:"role,notnull"`
+	// DeletedAt is set when a member is removed from the account. The user is
+	// kept so that the records they created still have an author.
+	DeletedAt *time.Time `json:"-" pg:"deleted_at"`
 }
 
 var (
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"context"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// WebhookEventType is the kind of account event that a webhook can subscribe
+// to.
+type WebhookEventType string
+
+const (
+	WebhookEventTransactionCreated WebhookEventType = "transaction.created"
+	WebhookEventTransactionUpdated WebhookEventType = "transaction.updated"
+	WebhookEventSpendingFunded     WebhookEventType = "spending.funded"
+	WebhookEventSyncCompleted      WebhookEventType = "sync.completed"
+	WebhookEventUploadCompleted    WebhookEventType = "upload.completed"
+	// WebhookEventNotificationCreated is sent to the webhook url of a
+	// notification preference, it is not something that account webhooks can
+	// subscribe to.
+	WebhookEventNotificationCreated WebhookEventType = "notification.created"
+)
+
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventTransactionCreated,
+	WebhookEventTransactionUpdated,
+	WebhookEventSpendingFunded,
+	WebhookEventSyncCompleted,
+	WebhookEventUploadCompleted,
+}
+
+func (t WebhookEventType) IsValid() bool {
+	for _, eventType := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	_ pg.BeforeInsertHook = (*Webhook)(nil)
+	_ Identifiable        = Webhook{}
+)
+
+// Webhook is an endpoint registered by a user that account events are posted
+// to. Every request is signed with the webhook's secret, which is stored
+// encrypted in the secrets table.
+type Webhook struct {
+	tableName string `pg:"webhooks"`
+
+	WebhookId   ID[Webhook]        `json:"webhookId" pg:"webhook_id,notnull,pk"`
+	AccountId   ID[Account]        `json:"-" pg:"account_id,notnull,pk"`
+	Account     *Account           `json:"-" pg:"rel:has-one"`
+	URL         string             `json:"url" pg:"url,notnull"`
+	Description *string            `json:"description" pg:"description"`
+	EventTypes  []WebhookEventType `json:"eventTypes" pg:"event_types,array"`
+	IsEnabled   bool               `json:"isEnabled" pg:"is_enabled,notnull,use_zero"`
+	SecretId    ID[Secret]         `json:"-" pg:"secret_id,notnull"`
+	Secret      *Secret            `json:"-" pg:"rel:has-one"`
+	CreatedBy   ID[User]           `json:"createdBy" pg:"created_by,notnull"`
+	CreatedAt   time.Time          `json:"createdAt" pg:"created_at,notnull"`
+	UpdatedAt   time.Time          `json:"updatedAt" pg:"updated_at,notnull"`
+}
+
+func (Webhook) IdentityPrefix() string {
+	return "whk"
+}
+
+func (o *Webhook) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.WebhookId.IsZero() {
+		o.WebhookId = NewID(o)
+	}
+
+	now := time.Now()
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = now
+	}
+
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = now
+	}
+
+	return ctx, nil
+}
+
+// Validate makes sure that the user configurable fields of the webhook are
+// valid.
+func (o *Webhook) Validate() error {
+	if strings.TrimSpace(o.URL) == "" {
+		return errors.New("url is required")
+	}
+
+	if err := validateWebhookURL("url", o.URL); err != nil {
+		return err
+	}
+
+	if len(o.EventTypes) == 0 {
+		return errors.New("at least one event type is required")
+	}
+
+	for _, eventType := range o.EventTypes {
+		if !eventType.IsValid() {
+			return errors.Errorf("invalid event type: %s", eventType)
+		}
+	}
+
+	return nil
+}
+
+// validateWebhookURL makes sure that a user provided url can be used to send
+// webhooks to. Only https urls are allowed, and hosts that are obviously not
+// on the public internet are rejected. Hostnames can still resolve to internal
+// addresses, so the address is checked again when the request is made.
+func validateWebhookURL(name, input string) error {
+	webhookUrl, err := url.Parse(input)
+	if err != nil || webhookUrl.Host == "" {
+		return errors.Errorf("%s must be a valid url", name)
+	}
+	if webhookUrl.Scheme != "https" {
+		return errors.Errorf("%s must be an https url", name)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(webhookUrl.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.Errorf("%s must not point to a private address", name)
+	}
+	if address, err := netip.ParseAddr(host); err == nil && !util.IsPublicAddress(address) {
+		return errors.Errorf("%s must not point to a private address", name)
+	}
+
+	return nil
+}
+
+// Subscribes returns true if the webhook is enabled and wants the provided
+// type of event.
+func (o *Webhook) Subscribes(eventType WebhookEventType) bool {
+	if !o.IsEnabled {
+		return false
+	}
+
+	for _, item := range o.EventTypes {
+		if item == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	_ Identifiable = WebhookPayload{}
+)
+
+// WebhookPayload is the body that is posted to a webhook. The same event ID is
+// used for every webhook that receives the event, so that consumers can
+// ignore events they have already processed.
+type WebhookPayload struct {
+	EventId   ID[WebhookPayload] `json:"eventId"`
+	Type      WebhookEventType   `json:"type"`
+	AccountId ID[Account]        `json:"accountId"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      interface{}        `json:"data"`
+}
+
+func (WebhookPayload) IdentityPrefix() string {
+	return "whev"
+}
+
+// SpendingFundedEvent is the data of a spending.funded event.
+type SpendingFundedEvent struct {
+	BankAccountId     ID[BankAccount]     `json:"bankAccountId"`
+	FundingScheduleId ID[FundingSchedule] `json:"fundingScheduleId"`
+	// Contribution is how much was allocated to the spending object, the
+	// spending object already includes the contribution in its current amount.
+	Contribution int64    `json:"contribution"`
+	Spending     Spending `json:"spending"`
+}
+
+// SyncCompletedEvent is the data of a sync.completed event, it includes how
+// many transactions were changed by the sync.
+type SyncCompletedEvent struct {
+	LinkId  ID[Link] `json:"linkId"`
+	Trigger string   `json:"trigger"`
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Deleted int      `json:"deleted"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending deliveries have not been delivered yet, they
+	// will be attempted again at their next attempt time.
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryStatusSucceeded deliveries received a 2xx response.
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryStatusFailed deliveries ran out of attempts, or the
+	// webhook was disabled before they could be delivered.
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "failed"
+)
+
+var (
+	_ pg.BeforeInsertHook = (*WebhookDelivery)(nil)
+	_ Identifiable        = WebhookDelivery{}
+)
+
+// WebhookDelivery is a single event being delivered to a single webhook. It is
+// also the delivery log that users can see through the API.
+type WebhookDelivery struct {
+	tableName string `pg:"webhook_deliveries"`
+
+	WebhookDeliveryId ID[WebhookDelivery]   `json:"webhookDeliveryId" pg:"webhook_delivery_id,notnull,pk"`
+	AccountId         ID[Account]           `json:"-" pg:"account_id,notnull,pk"`
+	Account           *Account              `json:"-" pg:"rel:has-one"`
+	WebhookId         ID[Webhook]           `json:"webhookId" pg:"webhook_id,notnull"`
+	Webhook           *Webhook              `json:"-" pg:"rel:has-one"`
+	EventId           ID[WebhookPayload]    `json:"eventId" pg:"event_id,notnull"`
+	EventType         WebhookEventType      `json:"eventType" pg:"event_type,notnull"`
+	Payload           WebhookPayload        `json:"payload" pg:"payload,type:'jsonb',notnull"`
+	Status            WebhookDeliveryStatus `json:"status" pg:"status,notnull"`
+	Attempts          int                   `json:"attempts" pg:"attempts,notnull,use_zero"`
+	// ResponseStatus is the HTTP status code of the most recent attempt, it is
+	// nil if the request could not be made at all.
+	ResponseStatus *int    `json:"responseStatus" pg:"response_status"`
+	Error          *string `json:"error" pg:"error"`
+	// NextAttemptAt is when the delivery will be attempted again, it is nil once
+	// the delivery has succeeded or failed.
+	NextAttemptAt *time.Time `json:"nextAttemptAt" pg:"next_attempt_at"`
+	LastAttemptAt *time.Time `json:"lastAttemptAt" pg:"last_attempt_at"`
+	CreatedAt     time.Time  `json:"createdAt" pg:"created_at,notnull"`
+	DeliveredAt   *time.Time `json:"deliveredAt" pg:"delivered_at"`
+}
+
+func (WebhookDelivery) IdentityPrefix() string {
+	return "whdl"
+}
+
+func (o *WebhookDelivery) BeforeInsert(ctx context.Context) (context.Context, error) {
+	if o.WebhookDeliveryId.IsZero() {
+		o.WebhookDeliveryId = NewID(o)
+	}
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+
+	return ctx, nil
+}
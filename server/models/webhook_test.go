@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		webhook := Webhook{
+			URL:        "https://example.com/monetr",
+			EventTypes: []WebhookEventType{WebhookEventTransactionCreated, WebhookEventSyncCompleted},
+		}
+		assert.NoError(t, webhook.Validate())
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		webhook := Webhook{
+			EventTypes: []WebhookEventType{WebhookEventTransactionCreated},
+		}
+		assert.EqualError(t, webhook.Validate(), "url is required")
+	})
+
+	t.Run("bad scheme", func(t *testing.T) {
+		webhook := Webhook{
+			URL:        "ftp://example.com/monetr",
+			EventTypes: []WebhookEventType{WebhookEventTransactionCreated},
+		}
+		assert.EqualError(t, webhook.Validate(), "url must be an https url")
+
+		webhook.URL = "http://example.com/monetr"
+		assert.EqualError(t, webhook.Validate(), "url must be an https url")
+	})
+
+	t.Run("private address", func(t *testing.T) {
+		for _, input := range []string{
+			"https://localhost/monetr",
+			"https://127.0.0.1:8443/monetr",
+			"https://169.254.169.254/latest/meta-data",
+			"https://[::1]/monetr",
+			"https://192.168.1.10/monetr",
+		} {
+			webhook := Webhook{
+				URL:        input,
+				EventTypes: []WebhookEventType{WebhookEventTransactionCreated},
+			}
+			assert.EqualError(t, webhook.Validate(), "url must not point to a private address", "url: %s", input)
+		}
+	})
+
+	t.Run("no event types", func(t *testing.T) {
+		webhook := Webhook{
+			URL: "https://example.com/monetr",
+		}
+		assert.EqualError(t, webhook.Validate(), "at least one event type is required")
+	})
+
+	t.Run("invalid event type", func(t *testing.T) {
+		webhook := Webhook{
+			URL:        "https://example.com/monetr",
+			EventTypes: []WebhookEventType{"transaction.deleted"},
+		}
+		assert.EqualError(t, webhook.Validate(), "invalid event type: transaction.deleted")
+	})
+}
+
+func TestWebhook_Subscribes(t *testing.T) {
+	webhook := Webhook{
+		IsEnabled:  true,
+		EventTypes: []WebhookEventType{WebhookEventSpendingFunded},
+	}
+	assert.True(t, webhook.Subscribes(WebhookEventSpendingFunded))
+	assert.False(t, webhook.Subscribes(WebhookEventSyncCompleted))
+
+	webhook.IsEnabled = false
+	assert.False(t, webhook.Subscribes(WebhookEventSpendingFunded), "disabled webhooks do not subscribe to anything")
+}
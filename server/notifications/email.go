@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/monetr/monetr/server/communication"
+	"github.com/monetr/monetr/server/config"
+	. "github.com/monetr/monetr/server/models"
+)
+
+var (
+	_ Channel = &emailChannel{}
+)
+
+type emailChannel struct {
+	config config.Configuration
+	email  communication.EmailCommunication
+}
+
+// NewEmailChannel delivers notifications as emails to the login of each
+// recipient.
+func NewEmailChannel(
+	configuration config.Configuration,
+	email communication.EmailCommunication,
+) Channel {
+	return &emailChannel{
+		config: configuration,
+		email:  email,
+	}
+}
+
+func (emailChannel) Type() NotificationChannelType {
+	return NotificationChannelEmail
+}
+
+func (e *emailChannel) Send(ctx context.Context, recipient Recipient, notification Notification) error {
+	return e.email.SendEmail(ctx, communication.NotificationParams{
+		BaseURL:      e.config.Server.GetBaseURL().String(),
+		Email:        recipient.Email,
+		FirstName:    recipient.FirstName,
+		LastName:     recipient.LastName,
+		Title:        notification.Title,
+		Message:      notification.Message,
+		SupportEmail: "support@monetr.app",
+	})
+}
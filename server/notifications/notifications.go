@@ -0,0 +1,103 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Recipient is a single user that a notification is being delivered to, along
+// with their preference for that kind of notification.
+type Recipient struct {
+	Email      string
+	FirstName  string
+	LastName   string
+	Preference NotificationPreference
+	// WebhookSecret is the secret that webhook notifications are signed with,
+	// it is only present when the preference has a webhook url.
+	WebhookSecret string
+}
+
+// NewRecipient builds a recipient from a preference that was retrieved with
+// its user and login.
+func NewRecipient(preference NotificationPreference) (Recipient, error) {
+	if preference.User == nil || preference.User.Login == nil {
+		return Recipient{}, errors.New("notification preference must include the user's login")
+	}
+
+	return Recipient{
+		Email:      preference.User.Login.Email,
+		FirstName:  preference.User.Login.FirstName,
+		LastName:   preference.User.Login.LastName,
+		Preference: preference,
+	}, nil
+}
+
+// Channel is a way that notifications can be delivered to a user.
+type Channel interface {
+	Type() NotificationChannelType
+	Send(ctx context.Context, recipient Recipient, notification Notification) error
+}
+
+// Dispatcher delivers notifications to a recipient through every channel
+// enabled in their preference.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, recipient Recipient, notification Notification) error
+}
+
+type dispatcherBase struct {
+	log      *logrus.Entry
+	channels map[NotificationChannelType]Channel
+}
+
+// NewDispatcher returns a dispatcher for the provided channels. Channels that a
+// recipient has enabled but which are not provided here are skipped, this way
+// email notifications are simply not sent when email is not configured.
+func NewDispatcher(log *logrus.Entry, channels ...Channel) Dispatcher {
+	dispatcher := &dispatcherBase{
+		log:      log,
+		channels: map[NotificationChannelType]Channel{},
+	}
+	for _, channel := range channels {
+		dispatcher.channels[channel.Type()] = channel
+	}
+
+	return dispatcher
+}
+
+// Dispatch will try every channel that the recipient has enabled even if one
+// of them fails. The first error encountered is returned.
+func (d *dispatcherBase) Dispatch(ctx context.Context, recipient Recipient, notification Notification) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	log := d.log.WithContext(span.Context()).WithFields(logrus.Fields{
+		"notificationId": notification.NotificationId,
+		"kind":           notification.Kind,
+		"userId":         recipient.Preference.UserId,
+	})
+
+	var result error
+	for _, channelType := range recipient.Preference.Channels {
+		channel, ok := d.channels[channelType]
+		if !ok {
+			log.WithField("channel", channelType).Debug("notification channel is not available, it will be skipped")
+			continue
+		}
+
+		if err := channel.Send(span.Context(), recipient, notification); err != nil {
+			log.WithError(err).WithField("channel", channelType).Warn("failed to send notification")
+			if result == nil {
+				result = errors.Wrapf(err, "failed to send notification via %s", channelType)
+			}
+			continue
+		}
+
+		log.WithField("channel", channelType).Trace("sent notification")
+	}
+
+	return result
+}
@@ -0,0 +1,159 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/internal/myownsanity"
+	"github.com/monetr/monetr/server/internal/testutils"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChannel struct {
+	channelType NotificationChannelType
+	err         error
+	sent        []Notification
+}
+
+func (t *testChannel) Type() NotificationChannelType {
+	return t.channelType
+}
+
+func (t *testChannel) Send(ctx context.Context, recipient Recipient, notification Notification) error {
+	t.sent = append(t.sent, notification)
+	return t.err
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	notification := Notification{
+		NotificationId: "ntfy_test",
+		Kind:           NotificationKindGoalReached,
+		Title:          "Vacation is fully funded",
+	}
+
+	t.Run("sends to every enabled channel", func(t *testing.T) {
+		email := &testChannel{channelType: NotificationChannelEmail}
+		webhook := &testChannel{channelType: NotificationChannelWebhook}
+		dispatcher := NewDispatcher(testutils.GetLog(t), email, webhook)
+
+		err := dispatcher.Dispatch(context.Background(), Recipient{
+			Preference: NotificationPreference{
+				Channels: []NotificationChannelType{NotificationChannelWebhook},
+			},
+		}, notification)
+		assert.NoError(t, err)
+		assert.Empty(t, email.sent, "email was not enabled by the recipient")
+		assert.Len(t, webhook.sent, 1)
+	})
+
+	t.Run("skips unavailable channels", func(t *testing.T) {
+		webhook := &testChannel{channelType: NotificationChannelWebhook}
+		dispatcher := NewDispatcher(testutils.GetLog(t), webhook)
+
+		err := dispatcher.Dispatch(context.Background(), Recipient{
+			Preference: NotificationPreference{
+				Channels: []NotificationChannelType{NotificationChannelEmail, NotificationChannelWebhook},
+			},
+		}, notification)
+		assert.NoError(t, err)
+		assert.Len(t, webhook.sent, 1)
+	})
+
+	t.Run("tries every channel when one fails", func(t *testing.T) {
+		email := &testChannel{channelType: NotificationChannelEmail, err: errors.New("smtp is down")}
+		webhook := &testChannel{channelType: NotificationChannelWebhook}
+		dispatcher := NewDispatcher(testutils.GetLog(t), email, webhook)
+
+		err := dispatcher.Dispatch(context.Background(), Recipient{
+			Preference: NotificationPreference{
+				Channels: []NotificationChannelType{NotificationChannelEmail, NotificationChannelWebhook},
+			},
+		}, notification)
+		assert.EqualError(t, err, "failed to send notification via email: smtp is down")
+		assert.Len(t, webhook.sent, 1, "webhook should still be sent")
+	})
+}
+
+func TestWebhookChannel_Send(t *testing.T) {
+	notification := Notification{
+		NotificationId: "ntfy_test",
+		AccountId:      "acct_test",
+		Kind:           NotificationKindLargeTransaction,
+		Title:          "Large transaction at Amazon",
+		Amount:         myownsanity.Int64P(25000),
+	}
+	secret := "whsec_test"
+
+	t.Run("posts a signed notification", func(t *testing.T) {
+		clock := clock.NewMock()
+		clock.Set(time.Now())
+
+		var received struct {
+			Type string       `json:"type"`
+			Data Notification `json:"data"`
+		}
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, "notification.created", r.Header.Get(webhooks.EventTypeHeader))
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.NoError(t, webhooks.Verify(secret, r.Header.Get(webhooks.SignatureHeader), body, clock.Now(), time.Minute))
+			assert.NoError(t, json.Unmarshal(body, &received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		channel := NewWebhookChannel(clock, webhooks.NewClient(server.Client()))
+		err := channel.Send(context.Background(), Recipient{
+			Preference: NotificationPreference{
+				WebhookURL: myownsanity.StringP(server.URL),
+			},
+			WebhookSecret: secret,
+		}, notification)
+		assert.NoError(t, err)
+		assert.Equal(t, "notification.created", received.Type)
+		assert.Equal(t, notification.NotificationId, received.Data.NotificationId)
+		assert.EqualValues(t, 25000, *received.Data.Amount)
+	})
+
+	t.Run("non-2xx response", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		channel := NewWebhookChannel(clock.New(), webhooks.NewClient(server.Client()))
+		err := channel.Send(context.Background(), Recipient{
+			Preference: NotificationPreference{
+				WebhookURL: myownsanity.StringP(server.URL),
+			},
+			WebhookSecret: secret,
+		}, notification)
+		assert.EqualError(t, err, "webhook responded with status 500")
+	})
+
+	t.Run("without a secret", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("unsigned notifications should not be sent")
+		}))
+		defer server.Close()
+
+		channel := NewWebhookChannel(clock.New(), webhooks.NewClient(server.Client()))
+		err := channel.Send(context.Background(), Recipient{
+			Preference: NotificationPreference{
+				WebhookURL: myownsanity.StringP(server.URL),
+			},
+		}, notification)
+		assert.EqualError(t, err, "notification preference does not have a webhook secret")
+	})
+}
@@ -0,0 +1,79 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/benbjohnson/clock"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ Channel = &webhookChannel{}
+)
+
+type webhookChannel struct {
+	clock  clock.Clock
+	client webhooks.Client
+}
+
+// NewWebhookChannel delivers notifications to the webhook url in each
+// recipient's preference. Notifications are sent through the same client as
+// account webhooks, so they are signed with the preference's webhook secret.
+func NewWebhookChannel(clock clock.Clock, client webhooks.Client) Channel {
+	if client == nil {
+		client = webhooks.NewClient(nil)
+	}
+
+	return &webhookChannel{
+		clock:  clock,
+		client: client,
+	}
+}
+
+func (webhookChannel) Type() NotificationChannelType {
+	return NotificationChannelWebhook
+}
+
+func (w *webhookChannel) Send(ctx context.Context, recipient Recipient, notification Notification) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if recipient.Preference.WebhookURL == nil {
+		return errors.New("notification preference does not have a webhook url")
+	}
+
+	if recipient.WebhookSecret == "" {
+		return errors.New("notification preference does not have a webhook secret")
+	}
+
+	now := w.clock.Now().UTC()
+	payload := WebhookPayload{
+		EventId:   NewID(&WebhookPayload{}),
+		Type:      WebhookEventNotificationCreated,
+		AccountId: notification.AccountId,
+		CreatedAt: now,
+		Data:      notification,
+	}
+	delivery := WebhookDelivery{
+		WebhookDeliveryId: NewID(&WebhookDelivery{}),
+		AccountId:         notification.AccountId,
+		EventId:           payload.EventId,
+		EventType:         payload.Type,
+		Payload:           payload,
+	}
+
+	_, err := w.client.Deliver(
+		span.Context(),
+		Webhook{
+			AccountId: notification.AccountId,
+			URL:       *recipient.Preference.WebhookURL,
+		},
+		recipient.WebhookSecret,
+		delivery,
+		now,
+	)
+	return err
+}
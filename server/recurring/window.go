@@ -78,6 +78,34 @@ func GetWindowsForDate(date time.Time, timezone *time.Location) []Window {
 	return windows
 }
 
+// GetWindowForFrequency returns the window that represents a frequency (in
+// days) detected by DetectRecurringTransactions, starting on the provided date.
+// If there is no window for the frequency then false is returned.
+func GetWindowForFrequency(frequency int, date time.Time, timezone *time.Location) (Window, bool) {
+	date = util.Midnight(date, timezone)
+	switch frequency {
+	case 7:
+		return windowWeekly(date), true
+	case 14:
+		return windowBiWeekly(date), true
+	case 15, 16:
+		// Twice a month is usually either the 1st and the 15th or the 15th and the
+		// last day of the month, use the start date to guess which one it is.
+		if date.Day() <= 7 {
+			return windowFirstAndFifteenth(date), true
+		}
+		return windowFifteenthAndTheLastDay(date), true
+	case 30, 31:
+		return windowMonthly(date), true
+	case 60:
+		return windowBiMonthly(date), true
+	case 90:
+		return windowQuarterly(date), true
+	default:
+		return Window{}, false
+	}
+}
+
 // getDayOfMonth returns the day of the month, if the day of the month is the last day then -1 will be returned.
 func getDayOfMonth(date time.Time) int {
 	tomorrow := date.AddDate(0, 0, 1)
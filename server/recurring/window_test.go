@@ -145,6 +145,44 @@ func TestWindowGetDeviation(t *testing.T) {
 	})
 }
 
+func TestGetWindowForFrequency(t *testing.T) {
+	timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
+
+	t.Run("monthly", func(t *testing.T) {
+		date := time.Date(2023, 11, 15, 8, 30, 0, 0, time.UTC)
+		window, ok := GetWindowForFrequency(31, date, timezone)
+		assert.True(t, ok)
+		assert.Equal(t, models.MonthlyWindowType, window.Type)
+		assert.Equal(t, time.Date(2023, 12, 15, 0, 0, 0, 0, timezone), window.Rule.After(window.Start, false))
+	})
+
+	t.Run("twice a month", func(t *testing.T) {
+		{ // Starting early in the month should be the 1st and 15th.
+			window, ok := GetWindowForFrequency(15, time.Date(2023, 11, 1, 0, 0, 0, 0, timezone), timezone)
+			assert.True(t, ok)
+			assert.Equal(t, models.FirstAndFifteenthWindowType, window.Type)
+		}
+
+		{ // Starting later in the month should be the 15th and last day.
+			window, ok := GetWindowForFrequency(16, time.Date(2023, 11, 15, 0, 0, 0, 0, timezone), timezone)
+			assert.True(t, ok)
+			assert.Equal(t, models.FifteenthAndLastWindowType, window.Type)
+			assert.Equal(t, time.Date(2023, 11, 30, 0, 0, 0, 0, timezone), window.Rule.After(window.Start, false))
+		}
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		window, ok := GetWindowForFrequency(7, time.Date(2023, 11, 19, 0, 0, 0, 0, timezone), timezone)
+		assert.True(t, ok)
+		assert.Equal(t, models.WeeklyWindowType, window.Type)
+	})
+
+	t.Run("unknown frequency", func(t *testing.T) {
+		_, ok := GetWindowForFrequency(45, time.Date(2023, 11, 19, 0, 0, 0, 0, timezone), timezone)
+		assert.False(t, ok)
+	})
+}
+
 func TestWindowExperiment(t *testing.T) {
 	t.Run("with cluster", func(t *testing.T) {
 		timezone := testutils.Must(t, time.LoadLocation, "America/Chicago")
@@ -23,7 +23,7 @@ type apiKeyRepository struct {
 	db pg.DBI
 }
 
-func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time) (string, *models.APIKey, error) {
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time, scopes []string, bankAccountIds []models.ID[models.BankAccount]) (string, *models.APIKey, error) {
 	// Generate a random key
 	keyBytes := make([]byte, 32)
 	if _, err := rand.Read(keyBytes); err != nil {
@@ -36,11 +36,13 @@ func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, userId string, name
 	keyHash := base64.URLEncoding.EncodeToString(hash[:])
 
 	apiKey := &models.APIKey{
-		UserId:    userId,
-		Name:      name,
-		KeyHash:   keyHash,
-		CreatedAt: time.Now().UTC(),
-		IsActive:  true,
+		UserId:         userId,
+		Name:           name,
+		KeyHash:        keyHash,
+		Scopes:         scopes,
+		BankAccountIds: bankAccountIds,
+		CreatedAt:      time.Now().UTC(),
+		IsActive:       true,
 	}
 	if expiresAt != nil {
 		apiKey.ExpiresAt = *expiresAt
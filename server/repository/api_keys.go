@@ -12,14 +12,14 @@ import (
 )
 
 type APIKeyRepository interface {
-	CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time) (string, *models.APIKey, error)
+	CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time, scopes []string, bankAccountIds []models.ID[models.BankAccount]) (string, *models.APIKey, error)
 	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
 	ListAPIKeys(ctx context.Context, userId string) ([]models.APIKey, error)
 	RevokeAPIKey(ctx context.Context, userId string, apiKeyId int64) error
 	UpdateAPIKeyLastUsed(ctx context.Context, apiKeyId int64) error
 }
 
-func (r *repositoryBase) CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time) (string, *models.APIKey, error) {
+func (r *repositoryBase) CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time, scopes []string, bankAccountIds []models.ID[models.BankAccount]) (string, *models.APIKey, error) {
 	// Generate a random key
 	keyBytes := make([]byte, 32)
 	if _, err := rand.Read(keyBytes); err != nil {
@@ -32,11 +32,13 @@ func (r *repositoryBase) CreateAPIKey(ctx context.Context, userId string, name s
 	keyHash := base64.URLEncoding.EncodeToString(hash[:])
 
 	apiKey := &models.APIKey{
-		UserId:    userId,
-		Name:      name,
-		KeyHash:   keyHash,
-		CreatedAt: time.Now().UTC(),
-		IsActive:  true,
+		UserId:         userId,
+		Name:           name,
+		KeyHash:        keyHash,
+		Scopes:         scopes,
+		BankAccountIds: bankAccountIds,
+		CreatedAt:      time.Now().UTC(),
+		IsActive:       true,
 	}
 	if expiresAt != nil {
 		apiKey.ExpiresAt = *expiresAt
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBalanceAlertNotFound = errors.New("balance alert does not exist")
+)
+
+func (r *repositoryBase) GetBalanceAlert(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) (*BalanceAlert, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	var item BalanceAlert
+	err := r.txn.ModelContext(span.Context(), &item).
+		Where(`"balance_alert"."account_id" = ?`, r.AccountId()).
+		Where(`"balance_alert"."bank_account_id" = ?`, bankAccountId).
+		Limit(1).
+		Select(&item)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve balance alert")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &item, nil
+}
+
+// SaveBalanceAlert will create or replace the balance alert for the specified
+// bank account. The result of the previous check is cleared because it may no
+// longer be accurate with the new settings.
+func (r *repositoryBase) SaveBalanceAlert(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	alert *BalanceAlert,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	now := r.clock.Now().UTC()
+	alert.AccountId = r.AccountId()
+	alert.BankAccountId = bankAccountId
+	alert.IsTriggered = false
+	alert.TriggeredDate = nil
+	alert.ProjectedBalance = nil
+	alert.LastCheckedAt = nil
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+
+	_, err := r.txn.ModelContext(span.Context(), alert).
+		OnConflict(`("account_id", "bank_account_id") DO UPDATE`).
+		Set(`"threshold" = EXCLUDED."threshold"`).
+		Set(`"days" = EXCLUDED."days"`).
+		Set(`"is_enabled" = EXCLUDED."is_enabled"`).
+		Set(`"is_triggered" = EXCLUDED."is_triggered"`).
+		Set(`"triggered_date" = EXCLUDED."triggered_date"`).
+		Set(`"projected_balance" = EXCLUDED."projected_balance"`).
+		Set(`"last_checked_at" = EXCLUDED."last_checked_at"`).
+		Set(`"updated_at" = EXCLUDED."updated_at"`).
+		Returning(`*`).
+		Insert(alert)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to save balance alert")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// UpdateBalanceAlertResult stores the result of checking the balance alert,
+// the settings of the alert are not changed.
+func (r *repositoryBase) UpdateBalanceAlertResult(ctx context.Context, alert *BalanceAlert) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":      r.AccountId(),
+		"balanceAlertId": alert.BalanceAlertId,
+	}
+
+	alert.AccountId = r.AccountId()
+	alert.UpdatedAt = r.clock.Now().UTC()
+	_, err := r.txn.ModelContext(span.Context(), alert).
+		Column(
+			"is_triggered",
+			"triggered_date",
+			"projected_balance",
+			"last_checked_at",
+			"updated_at",
+		).
+		WherePK().
+		Update(alert)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update balance alert")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) DeleteBalanceAlert(ctx context.Context, bankAccountId ID[BankAccount]) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &BalanceAlert{}).
+		Where(`"balance_alert"."account_id" = ?`, r.AccountId()).
+		Where(`"balance_alert"."bank_account_id" = ?`, bankAccountId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove balance alert")
+	} else if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrBalanceAlertNotFound)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// SnapshotBalances records the current balances of the bank account for the
+// provided date. If a snapshot already exists for that date then it is
+// replaced.
+func (r *repositoryBase) SnapshotBalances(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	date time.Time,
+) (*BalanceSnapshot, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"date":          date,
+	}
+
+	balances, err := r.GetBalances(span.Context(), bankAccountId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, err
+	}
+
+	snapshot := BalanceSnapshot{
+		AccountId:     r.AccountId(),
+		BankAccountId: bankAccountId,
+		Date:          date,
+		Current:       balances.Current,
+		Available:     balances.Available,
+		Free:          balances.Free,
+		Expenses:      balances.Expenses,
+		Goals:         balances.Goals,
+		CreatedAt:     r.clock.Now().UTC(),
+	}
+	_, err = r.txn.ModelContext(span.Context(), &snapshot).
+		OnConflict(`("account_id", "bank_account_id", "date") DO UPDATE`).
+		Set(`"current" = EXCLUDED."current"`).
+		Set(`"available" = EXCLUDED."available"`).
+		Set(`"free" = EXCLUDED."free"`).
+		Set(`"expenses" = EXCLUDED."expenses"`).
+		Set(`"goals" = EXCLUDED."goals"`).
+		Set(`"created_at" = EXCLUDED."created_at"`).
+		Returning(`*`).
+		Insert(&snapshot)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to store balance snapshot")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &snapshot, nil
+}
+
+// GetBalanceSnapshots returns the balance snapshots for the bank account on or
+// after the start date and before the end date, oldest first.
+func (r *repositoryBase) GetBalanceSnapshots(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	start, end time.Time,
+) ([]BalanceSnapshot, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"start":         start,
+		"end":           end,
+	}
+
+	items := make([]BalanceSnapshot, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"balance_snapshot"."account_id" = ?`, r.AccountId()).
+		Where(`"balance_snapshot"."bank_account_id" = ?`, bankAccountId).
+		Where(`"balance_snapshot"."date" >= ?`, start).
+		Where(`"balance_snapshot"."date" < ?`, end).
+		Order(`date ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve balance snapshots")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation does not exist")
+	ErrAlreadyMember      = errors.New("login is already a member of this account")
+)
+
+// GetInvitations returns the invitations for the account that have not been
+// accepted yet, including ones that have expired.
+func (r *repositoryBase) GetInvitations(ctx context.Context) ([]Invitation, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+	}
+
+	result := make([]Invitation, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"invitation"."account_id" = ?`, r.AccountId()).
+		Where(`"invitation"."accepted_at" IS NULL`).
+		Order(`created_at DESC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve invitations")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+// CreateInvitation stores a new invitation from the current user. If there is
+// already an invitation that has not been accepted for the same email address
+// then it is replaced, so that only the most recent invitation can be used.
+func (r *repositoryBase) CreateInvitation(ctx context.Context, invitation *Invitation) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+	}
+
+	invitation.AccountId = r.AccountId()
+	invitation.Email = strings.ToLower(strings.TrimSpace(invitation.Email))
+	invitation.InvitedBy = r.UserId()
+	invitation.CreatedAt = r.clock.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), &Invitation{}).
+		Where(`"invitation"."account_id" = ?`, r.AccountId()).
+		Where(`"invitation"."email" = ?`, invitation.Email).
+		Where(`"invitation"."accepted_at" IS NULL`).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove previous invitation")
+	}
+
+	if _, err := r.txn.ModelContext(span.Context(), invitation).Insert(invitation); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create invitation")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// DeleteInvitation revokes an invitation that has not been accepted yet.
+func (r *repositoryBase) DeleteInvitation(ctx context.Context, invitationId ID[Invitation]) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":    r.AccountId(),
+		"invitationId": invitationId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &Invitation{}).
+		Where(`"invitation"."account_id" = ?`, r.AccountId()).
+		Where(`"invitation"."invitation_id" = ?`, invitationId).
+		Where(`"invitation"."accepted_at" IS NULL`).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove invitation")
+	} else if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrInvitationNotFound)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetInvitation is used while accepting an invitation, before the person who
+// was invited is authenticated to the account. If the invitation does not exist
+// anymore then ErrInvitationNotFound is returned.
+func (u *unauthenticatedRepo) GetInvitation(
+	ctx context.Context,
+	accountId ID[Account],
+	invitationId ID[Invitation],
+) (*Invitation, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":    accountId,
+		"invitationId": invitationId,
+	}
+
+	var invitation Invitation
+	err := u.txn.ModelContext(span.Context(), &invitation).
+		Where(`"invitation"."account_id" = ?`, accountId).
+		Where(`"invitation"."invitation_id" = ?`, invitationId).
+		Limit(1).
+		Select(&invitation)
+	switch err {
+	case pg.ErrNoRows:
+		span.Status = sentry.SpanStatusNotFound
+		return nil, errors.WithStack(ErrInvitationNotFound)
+	case nil:
+		span.Status = sentry.SpanStatusOK
+		return &invitation, nil
+	default:
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve invitation")
+	}
+}
+
+// AcceptInvitation adds the login to the invitation's account with the role
+// from the invitation. If the login was a member of the account before and was
+// removed, then their previous user is restored instead of creating a new one.
+func (u *unauthenticatedRepo) AcceptInvitation(
+	ctx context.Context,
+	invitation *Invitation,
+	loginId ID[Login],
+) (*User, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":    invitation.AccountId,
+		"invitationId": invitation.InvitationId,
+		"loginId":      loginId,
+	}
+
+	var user User
+	err := u.txn.ModelContext(span.Context(), &user).
+		Where(`"user"."account_id" = ?`, invitation.AccountId).
+		Where(`"user"."login_id" = ?`, loginId).
+		Limit(1).
+		Select(&user)
+	switch err {
+	case pg.ErrNoRows:
+		user = User{
+			LoginId:   loginId,
+			AccountId: invitation.AccountId,
+			Role:      invitation.Role,
+		}
+		if _, err := u.txn.ModelContext(span.Context(), &user).Insert(&user); err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return nil, errors.Wrap(err, "failed to create user")
+		}
+	case nil:
+		if user.DeletedAt == nil {
+			span.Status = sentry.SpanStatusAlreadyExists
+			return nil, errors.WithStack(ErrAlreadyMember)
+		}
+
+		user.Role = invitation.Role
+		user.DeletedAt = nil
+		_, err := u.txn.ModelContext(span.Context(), &user).
+			Set(`"role" = ?`, user.Role).
+			Set(`"deleted_at" = NULL`).
+			WherePK().
+			Update()
+		if err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return nil, errors.Wrap(err, "failed to restore user")
+		}
+	default:
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve existing user")
+	}
+
+	now := u.clock.Now().UTC()
+	result, err := u.txn.ModelContext(span.Context(), invitation).
+		Set(`"accepted_by" = ?`, user.UserId).
+		Set(`"accepted_at" = ?`, now).
+		WherePK().
+		Where(`"invitation"."accepted_at" IS NULL`).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to accept invitation")
+	} else if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return nil, errors.WithStack(ErrInvitationNotFound)
+	}
+	invitation.AcceptedBy = &user.UserId
+	invitation.AcceptedAt = &now
+
+	span.Status = sentry.SpanStatusOK
+
+	return &user, nil
+}
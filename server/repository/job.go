@@ -16,6 +16,10 @@ type JobRepository interface {
 	GetLinksForExpiredAccounts(ctx context.Context) ([]Link, error)
 	GetBankAccountsWithStaleSpending(ctx context.Context) ([]BankAccountWithStaleSpendingItem, error)
 	GetAccountsWithTooManyFiles(ctx context.Context) ([]AccountWithTooManyFiles, error)
+	GetBankAccountsToSnapshot(ctx context.Context) ([]BankAccountItem, error)
+	GetBankAccountsWithBalanceAlerts(ctx context.Context) ([]BankAccountItem, error)
+	GetAccountsWithUnsentNotifications(ctx context.Context) ([]ID[Account], error)
+	GetAccountsWithDueWebhookDeliveries(ctx context.Context, now time.Time) ([]ID[Account], error)
 }
 
 type ProcessFundingSchedulesItem struct {
@@ -41,6 +45,13 @@ type BankAccountWithStaleSpendingItem struct {
 	BankAccountId ID[BankAccount] `pg:"bank_account_id"`
 }
 
+// BankAccountItem is a bank account that a background job should be enqueued
+// for.
+type BankAccountItem struct {
+	AccountId     ID[Account]     `pg:"account_id"`
+	BankAccountId ID[BankAccount] `pg:"bank_account_id"`
+}
+
 type jobRepository struct {
 	txn   pg.DBI
 	clock clock.Clock
@@ -67,7 +78,12 @@ func (j *jobRepository) GetFundingSchedulesToProcess(ctx context.Context) ([]Pro
 			"funding_schedules"."bank_account_id",
 			array_agg("funding_schedules"."funding_schedule_id") AS "funding_schedule_ids"
 		FROM "funding_schedules"
-		WHERE "funding_schedules"."next_recurrence" < ?
+		WHERE "funding_schedules"."next_recurrence" - (
+			CASE WHEN "funding_schedules"."wait_for_deposit"
+				THEN "funding_schedules"."deposit_tolerance_days"
+				ELSE 0
+			END * INTERVAL '1 day'
+		) < ?
 		GROUP BY "funding_schedules"."account_id", "funding_schedules"."bank_account_id"
 		`,
 		j.clock.Now(),
@@ -136,6 +152,84 @@ func (j *jobRepository) GetBankAccountsWithStaleSpending(ctx context.Context) ([
 	return result, err
 }
 
+// GetBankAccountsToSnapshot will return all of the bank accounts globally that
+// are not inactive and that belong to a link that has not been removed.
+func (j *jobRepository) GetBankAccountsToSnapshot(ctx context.Context) ([]BankAccountItem, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	var result []BankAccountItem
+	err := j.txn.ModelContext(span.Context(), &BankAccount{}).
+		ColumnExpr(`"bank_account"."account_id"`).
+		ColumnExpr(`"bank_account"."bank_account_id"`).
+		Join(`INNER JOIN "links" AS "link"`).
+		JoinOn(`"link"."account_id" = "bank_account"."account_id" AND "link"."link_id" = "bank_account"."link_id"`).
+		Where(`"link"."deleted_at" IS NULL`).
+		Where(`"bank_account"."status" != ?`, InactiveBankAccountStatus).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve bank accounts to snapshot")
+	}
+
+	return result, nil
+}
+
+// GetBankAccountsWithBalanceAlerts will return all of the bank accounts
+// globally that have an enabled balance alert.
+func (j *jobRepository) GetBankAccountsWithBalanceAlerts(ctx context.Context) ([]BankAccountItem, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	var result []BankAccountItem
+	err := j.txn.ModelContext(span.Context(), &BalanceAlert{}).
+		ColumnExpr(`"balance_alert"."account_id"`).
+		ColumnExpr(`"balance_alert"."bank_account_id"`).
+		Where(`"balance_alert"."is_enabled" = ?`, true).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve bank accounts with balance alerts")
+	}
+
+	return result, nil
+}
+
+// GetAccountsWithUnsentNotifications will return all of the accounts globally
+// that have notifications waiting to be delivered.
+func (j *jobRepository) GetAccountsWithUnsentNotifications(ctx context.Context) ([]ID[Account], error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result := make([]ID[Account], 0)
+	err := j.txn.ModelContext(span.Context(), &Notification{}).
+		ColumnExpr(`DISTINCT "notification"."account_id"`).
+		Where(`"notification"."sent_at" IS NULL`).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve accounts with unsent notifications")
+	}
+
+	return result, nil
+}
+
+// GetAccountsWithDueWebhookDeliveries will return all of the accounts globally
+// that have pending webhook deliveries that are ready to be attempted.
+func (j *jobRepository) GetAccountsWithDueWebhookDeliveries(ctx context.Context, now time.Time) ([]ID[Account], error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result := make([]ID[Account], 0)
+	err := j.txn.ModelContext(span.Context(), &WebhookDelivery{}).
+		ColumnExpr(`DISTINCT "webhook_delivery"."account_id"`).
+		Where(`"webhook_delivery"."status" = ?`, WebhookDeliveryStatusPending).
+		Where(`"webhook_delivery"."next_attempt_at" <= ?`, now.UTC()).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve accounts with due webhook deliveries")
+	}
+
+	return result, nil
+}
+
 type AccountWithTooManyFiles struct {
 	tableName string `pg:"files"`
 
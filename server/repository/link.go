@@ -19,6 +19,8 @@ func (r *repositoryBase) GetLink(ctx context.Context, linkId ID[Link]) (*Link, e
 	var link Link
 	err := r.txn.ModelContext(span.Context(), &link).
 		Relation("PlaidLink").
+		Relation("TellerLink").
+		Relation("SimpleFINLink").
 		Where(`"link"."account_id" = ?`, r.AccountId()).
 		Where(`"link"."link_id" = ?`, linkId).
 		Limit(1).
@@ -37,6 +39,8 @@ func (r *repositoryBase) GetLinks(ctx context.Context) ([]Link, error) {
 	result := make([]Link, 0)
 	err := r.txn.ModelContext(span.Context(), &result).
 		Relation("PlaidLink").
+		Relation("TellerLink").
+		Relation("SimpleFINLink").
 		Where(`"link"."account_id" = ?`, r.accountId).
 		Where(`"link"."deleted_at" IS NULL`).
 		Select(&result)
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// CreateNotification records a notification to be delivered by the background
+// jobs. If a notification with the same dedupe key already exists for the
+// account then nothing is created and false is returned.
+func (r *repositoryBase) CreateNotification(ctx context.Context, notification *Notification) (bool, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"kind":      notification.Kind,
+		"dedupeKey": notification.DedupeKey,
+	}
+
+	notification.AccountId = r.AccountId()
+	notification.CreatedAt = r.clock.Now().UTC()
+	notification.SentAt = nil
+	result, err := r.txn.ModelContext(span.Context(), notification).
+		OnConflict(`("account_id", "dedupe_key") DO NOTHING`).
+		Insert(notification)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, errors.Wrap(err, "failed to create notification")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result.RowsAffected() > 0, nil
+}
+
+// GetNotifications returns the notifications for the account, newest first.
+func (r *repositoryBase) GetNotifications(ctx context.Context, limit, offset int) ([]Notification, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"limit":     limit,
+		"offset":    offset,
+	}
+
+	items := make([]Notification, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"notification"."account_id" = ?`, r.AccountId()).
+		Order(`created_at DESC`).
+		Order(`notification_id DESC`).
+		Limit(limit).
+		Offset(offset).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve notifications")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetUnsentNotifications returns the notifications that have not been
+// delivered yet, oldest first.
+func (r *repositoryBase) GetUnsentNotifications(ctx context.Context, limit int) ([]Notification, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"limit":     limit,
+	}
+
+	items := make([]Notification, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"notification"."account_id" = ?`, r.AccountId()).
+		Where(`"notification"."sent_at" IS NULL`).
+		Order(`created_at ASC`).
+		Limit(limit).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve unsent notifications")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// MarkNotificationSent records that the notification has been sent. It returns
+// false if the notification had already been marked as sent, this way the
+// notification can be claimed before it is delivered and two jobs will not
+// deliver the same notification.
+func (r *repositoryBase) MarkNotificationSent(
+	ctx context.Context,
+	notificationId ID[Notification],
+	sentAt time.Time,
+) (bool, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":      r.AccountId(),
+		"notificationId": notificationId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &Notification{}).
+		Set(`"sent_at" = ?`, sentAt.UTC()).
+		Where(`"notification"."account_id" = ?`, r.AccountId()).
+		Where(`"notification"."notification_id" = ?`, notificationId).
+		Where(`"notification"."sent_at" IS NULL`).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, errors.Wrap(err, "failed to mark notification as sent")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result.RowsAffected() > 0, nil
+}
+
+// GetNotificationPreferencesByKind returns the enabled preferences of every
+// user in the account for the specified kind of notification. The user and
+// their login are included so that the notification can be delivered.
+func (r *repositoryBase) GetNotificationPreferencesByKind(
+	ctx context.Context,
+	kind NotificationKind,
+) ([]NotificationPreference, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"kind":      kind,
+	}
+
+	items := make([]NotificationPreference, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Relation("User").
+		Relation("User.Login").
+		Where(`"notification_preference"."account_id" = ?`, r.AccountId()).
+		Where(`"notification_preference"."kind" = ?`, kind).
+		Where(`"notification_preference"."is_enabled" = ?`, true).
+		Order(`notification_preference_id ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve notification preferences")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// GetNotificationPreferences returns the current user's notification
+// preferences.
+func (r *repositoryBase) GetNotificationPreferences(ctx context.Context) ([]NotificationPreference, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"userId":    r.UserId(),
+	}
+
+	items := make([]NotificationPreference, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"notification_preference"."account_id" = ?`, r.AccountId()).
+		Where(`"notification_preference"."user_id" = ?`, r.UserId()).
+		Order(`kind ASC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve notification preferences")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// SaveNotificationPreference will create or replace the current user's
+// preference for the kind of notification specified on the preference.
+func (r *repositoryBase) SaveNotificationPreference(ctx context.Context, preference *NotificationPreference) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"userId":    r.UserId(),
+		"kind":      preference.Kind,
+	}
+
+	now := r.clock.Now().UTC()
+	preference.AccountId = r.AccountId()
+	preference.UserId = r.UserId()
+	preference.CreatedAt = now
+	preference.UpdatedAt = now
+
+	_, err := r.txn.ModelContext(span.Context(), preference).
+		OnConflict(`("account_id", "user_id", "kind") DO UPDATE`).
+		Set(`"is_enabled" = EXCLUDED."is_enabled"`).
+		Set(`"channels" = EXCLUDED."channels"`).
+		Set(`"webhook_url" = EXCLUDED."webhook_url"`).
+		Set(`"webhook_secret_id" = EXCLUDED."webhook_secret_id"`).
+		Set(`"threshold" = EXCLUDED."threshold"`).
+		Set(`"updated_at" = EXCLUDED."updated_at"`).
+		Returning(`*`).
+		Insert(preference)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to save notification preference")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
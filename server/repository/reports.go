@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+type ReportGroupBy string
+
+const (
+	ReportGroupBySpending ReportGroupBy = "spending"
+	ReportGroupByCluster  ReportGroupBy = "cluster"
+	ReportGroupByCategory ReportGroupBy = "category"
+)
+
+type ReportInterval string
+
+const (
+	ReportIntervalWeek  ReportInterval = "week"
+	ReportIntervalMonth ReportInterval = "month"
+	ReportIntervalYear  ReportInterval = "year"
+)
+
+type ReportParameters struct {
+	GroupBy  ReportGroupBy
+	Interval ReportInterval
+	// Timezone is used to determine which period each transaction falls in.
+	Timezone *time.Location
+	// StartDate is inclusive and EndDate is exclusive.
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// ReportRow is the aggregate of the transactions for a single group within a
+// single period. Income is the sum of all of the deposits as a positive
+// number, and outflow is the sum of all of the debits.
+type ReportRow struct {
+	// Key is the spending ID, cluster ID or the category depending on what the
+	// report is grouped by. It is nil for transactions that don't belong to any
+	// group.
+	Key          *string   `pg:"key"`
+	Name         *string   `pg:"name"`
+	Period       time.Time `pg:"period"`
+	Income       int64     `pg:"income"`
+	IncomeCount  int64     `pg:"income_count"`
+	Outflow      int64     `pg:"outflow"`
+	OutflowCount int64     `pg:"outflow_count"`
+	// IsTotal is true for the rows that include every transaction in the period
+	// regardless of its group. There is one of these rows for each period that
+	// has transactions.
+	IsTotal bool `pg:"is_total"`
+}
+
+// GetTransactionReport aggregates the transactions for the bank account by the
+// requested group and period. Deleted and hidden transactions are not
+// included. When grouping by spending, the amount of a split transaction is
+// attributed to each of its splits, but the transaction is only counted once in
+// each group and once in the totals.
+func (r *repositoryBase) GetTransactionReport(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	params ReportParameters,
+) ([]ReportRow, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"groupBy":       params.GroupBy,
+		"interval":      params.Interval,
+	}
+
+	switch params.Interval {
+	case ReportIntervalWeek, ReportIntervalMonth, ReportIntervalYear:
+	default:
+		return nil, errors.Errorf("invalid report interval: %s", params.Interval)
+	}
+
+	// The filters that are applied to every transaction included in the report.
+	const filter = `
+		"transaction"."account_id" = ?0
+		AND "transaction"."bank_account_id" = ?1
+		AND "transaction"."deleted_at" IS NULL
+		AND "transaction"."is_hidden" = false
+		AND "transaction"."date" >= ?2
+		AND "transaction"."date" < ?3`
+
+	var items, name string
+	switch params.GroupBy {
+	case ReportGroupBySpending:
+		items = `
+		SELECT "transaction"."transaction_id", "transaction"."date", "transaction"."amount", "transaction"."spending_id" AS "key"
+		FROM "transactions" AS "transaction"
+		WHERE ` + filter + `
+			AND NOT EXISTS (
+				SELECT 1 FROM "transaction_splits" AS "split"
+				WHERE "split"."account_id" = "transaction"."account_id"
+					AND "split"."transaction_id" = "transaction"."transaction_id"
+			)
+		UNION ALL
+		SELECT "transaction"."transaction_id", "transaction"."date", "split"."amount", "split"."spending_id" AS "key"
+		FROM "transaction_splits" AS "split"
+		INNER JOIN "transactions" AS "transaction"
+			ON "transaction"."account_id" = "split"."account_id"
+			AND "transaction"."bank_account_id" = "split"."bank_account_id"
+			AND "transaction"."transaction_id" = "split"."transaction_id"
+		WHERE ` + filter
+		name = `(
+			SELECT "spending"."name" FROM "spending"
+			WHERE "spending"."account_id" = ?0
+				AND "spending"."bank_account_id" = ?1
+				AND "spending"."spending_id" = "item"."key"
+		)`
+	case ReportGroupByCluster:
+		items = `
+		SELECT "transaction"."transaction_id", "transaction"."date", "transaction"."amount", (
+			SELECT "cluster"."transaction_cluster_id" FROM "transaction_clusters" AS "cluster"
+			WHERE "cluster"."account_id" = "transaction"."account_id"
+				AND "cluster"."bank_account_id" = "transaction"."bank_account_id"
+				AND "transaction"."transaction_id" = ANY("cluster"."members")
+			LIMIT 1
+		) AS "key"
+		FROM "transactions" AS "transaction"
+		WHERE ` + filter
+		name = `(
+			SELECT "cluster"."name" FROM "transaction_clusters" AS "cluster"
+			WHERE "cluster"."account_id" = ?0
+				AND "cluster"."bank_account_id" = ?1
+				AND "cluster"."transaction_cluster_id" = "item"."key"
+		)`
+	case ReportGroupByCategory:
+		// Prefer the custom category of the transaction, otherwise use the primary
+		// category from the data source.
+		items = `
+		SELECT "transaction"."transaction_id", "transaction"."date", "transaction"."amount",
+			COALESCE("transaction"."category", "transaction"."categories"[1]) AS "key"
+		FROM "transactions" AS "transaction"
+		WHERE ` + filter
+		name = `"item"."key"`
+	default:
+		return nil, errors.Errorf("invalid report group: %s", params.GroupBy)
+	}
+
+	// The totals for each period are calculated by the second grouping set, this
+	// way transactions with multiple splits are not counted more than once.
+	query := fmt.Sprintf(`
+		WITH "item" AS (%s)
+		SELECT
+			"item"."key",
+			%s AS "name",
+			date_trunc('%s', "item"."date" AT TIME ZONE ?4) AT TIME ZONE ?4 AS "period",
+			COALESCE(-SUM("item"."amount") FILTER (WHERE "item"."amount" < 0), 0) AS "income",
+			COUNT(DISTINCT "item"."transaction_id") FILTER (WHERE "item"."amount" < 0) AS "income_count",
+			COALESCE(SUM("item"."amount") FILTER (WHERE "item"."amount" > 0), 0) AS "outflow",
+			COUNT(DISTINCT "item"."transaction_id") FILTER (WHERE "item"."amount" > 0) AS "outflow_count",
+			GROUPING("item"."key") = 1 AS "is_total"
+		FROM "item"
+		GROUP BY GROUPING SETS (("item"."key", "period"), ("period"))
+		ORDER BY "period", "item"."key"
+	`, items, name, params.Interval)
+
+	result := make([]ReportRow, 0)
+	_, err := r.txn.QueryContext(
+		span.Context(),
+		&result,
+		query,
+		r.AccountId(),
+		bankAccountId,
+		params.StartDate,
+		params.EndDate,
+		params.Timezone.String(),
+	)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction report")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
@@ -24,6 +24,10 @@ type BaseRepository interface {
 	CreateLink(ctx context.Context, link *Link) error
 	CreatePlaidLink(ctx context.Context, link *PlaidLink) error
 	CreateSpending(ctx context.Context, expense *Spending) error
+	// CreateSpendingAllocations appends entries to the allocation ledger, this
+	// should be called whenever the current amount of a spending object is
+	// changed.
+	CreateSpendingAllocations(ctx context.Context, bankAccountId ID[BankAccount], allocations []SpendingAllocation) error
 	CreateTransaction(ctx context.Context, bankAccountId ID[BankAccount], transaction *Transaction) error
 
 	// CreatePlaidTransaction takes a Plaid transaction model and ensures the
@@ -66,8 +70,16 @@ type BaseRepository interface {
 	GetSpendingByFundingSchedule(ctx context.Context, bankAccountId ID[BankAccount], fundingScheduleId ID[FundingSchedule]) ([]Spending, error)
 	GetSpendingById(ctx context.Context, bankAccountId ID[BankAccount], spendingId ID[Spending]) (*Spending, error)
 	GetSpendingExists(ctx context.Context, bankAccountId ID[BankAccount], spendingId ID[Spending]) (bool, error)
+	// GetSpendingAllocations returns the allocation ledger for the spending
+	// object, newest first.
+	GetSpendingAllocations(ctx context.Context, bankAccountId ID[BankAccount], spendingId ID[Spending], limit, offset int) ([]SpendingAllocation, error)
 	GetTransaction(ctx context.Context, bankAccountId ID[BankAccount], transactionId ID[Transaction]) (*Transaction, error)
+	GetTransactionSplits(ctx context.Context, bankAccountId ID[BankAccount], transactionId ID[Transaction]) ([]TransactionSplit, error)
 	GetTransactions(ctx context.Context, bankAccountId ID[BankAccount], limit, offset int) ([]Transaction, error)
+	// SearchTransactions returns the transactions for a bank account that match
+	// the provided filter. If there are more results than the filter's limit
+	// then a cursor for the next page is also returned.
+	SearchTransactions(ctx context.Context, bankAccountId ID[BankAccount], filter TransactionFilter) ([]Transaction, *TransactionCursor, error)
 	// GetTransactionsAfter will return all of the transactions after the
 	// specified date, if the specified date is null then all transactions for an
 	// account is returned. This is intended to be used for partial syncing for
@@ -77,9 +89,9 @@ type BaseRepository interface {
 	// transactions that are currently in a pending state. It will not return
 	// transactions that have been deleted.
 	GetPendingTransactions(ctx context.Context, bankAccountId ID[BankAccount], limit, offset int) ([]Transaction, error)
-	// GetRecentDepositTransactions will return all deposit transactions for the specified bank account within the past
-	// 24 hours.
-	GetRecentDepositTransactions(ctx context.Context, bankAccountId ID[BankAccount]) ([]Transaction, error)
+	// GetDepositTransactionsBetween returns the deposits for the bank account
+	// that happened on or after the start and before the end, oldest first.
+	GetDepositTransactionsBetween(ctx context.Context, bankAccountId ID[BankAccount], start, end time.Time) ([]Transaction, error)
 	GetTransactionsByPlaidId(ctx context.Context, linkId ID[Link], plaidTransactionIds []string) (map[string]Transaction, error)
 
 	// GetTransactonsByUploadIdentifier is meant to be used by the file import
@@ -95,6 +107,11 @@ type BaseRepository interface {
 	GetTransactionsByPlaidTransactionId(ctx context.Context, linkId ID[Link], plaidTransactionIds []string) ([]Transaction, error)
 	GetTransactionsForSpending(ctx context.Context, bankAccountId ID[BankAccount], spendingId ID[Spending], limit, offset int) ([]Transaction, error)
 	InsertTransactions(ctx context.Context, transactions []Transaction) error
+	// InsertTransactionSplits stores the splits for a transaction that does not
+	// have any splits yet. Unlike ProcessTransactionSpentFrom the spending
+	// objects are not changed, so this should only be used when the amounts of
+	// the splits are already accounted for, like when importing data.
+	InsertTransactionSplits(ctx context.Context, bankAccountId ID[BankAccount], transactionId ID[Transaction], splits []TransactionSplit) error
 	ProcessTransactionSpentFrom(ctx context.Context, bankAccountId ID[BankAccount], input, existing *Transaction) (updatedExpenses []Spending, _ error)
 	UpdateBankAccount(ctx context.Context, bankAccount *BankAccount) error
 	UpdateSpending(ctx context.Context, bankAccountId ID[BankAccount], updates []Spending) error
@@ -118,6 +135,32 @@ type BaseRepository interface {
 	// If no cluster can be found then nil and pg.NoRows will be returned
 	// (wrapped).
 	GetTransactionClusterByMember(ctx context.Context, bankAccountId ID[BankAccount], transactionId ID[Transaction]) (*TransactionCluster, error)
+	// GetTransactionClusters returns all of the transaction clusters that were
+	// last calculated for the specified bank account.
+	GetTransactionClusters(ctx context.Context, bankAccountId ID[BankAccount]) ([]TransactionCluster, error)
+	GetTransactionClusterById(ctx context.Context, bankAccountId ID[BankAccount], transactionClusterId ID[TransactionCluster]) (*TransactionCluster, error)
+	CreateTransactionCluster(ctx context.Context, bankAccountId ID[BankAccount], cluster *TransactionCluster) error
+	// UpdateTransactionCluster will update the name and the members of the
+	// provided transaction cluster.
+	UpdateTransactionCluster(ctx context.Context, bankAccountId ID[BankAccount], cluster *TransactionCluster) error
+	DeleteTransactionCluster(ctx context.Context, bankAccountId ID[BankAccount], transactionClusterId ID[TransactionCluster]) error
+	// GetTransactionClusterMonths returns the number of transactions and their
+	// total amount per transaction cluster per month since the provided date.
+	GetTransactionClusterMonths(ctx context.Context, bankAccountId ID[BankAccount], timezone *time.Location, since time.Time) ([]TransactionClusterMonth, error)
+	// GetTransactionClusterOverrides returns the changes the user has made to
+	// the transaction clusters, these are re-applied every time the clusters are
+	// calculated.
+	GetTransactionClusterOverrides(ctx context.Context, bankAccountId ID[BankAccount]) ([]TransactionClusterOverride, error)
+	SaveTransactionClusterOverride(ctx context.Context, bankAccountId ID[BankAccount], override *TransactionClusterOverride) error
+
+	// GetTransactionRecurring returns the recurring transactions that have been
+	// detected for the specified bank account.
+	GetTransactionRecurring(ctx context.Context, bankAccountId ID[BankAccount]) ([]TransactionRecurring, error)
+	GetTransactionRecurringById(ctx context.Context, bankAccountId ID[BankAccount], transactionRecurringId ID[TransactionRecurring]) (*TransactionRecurring, error)
+	// WriteTransactionRecurring will store the recurring transactions provided
+	// for the specified bank account, and remove any that are no longer detected.
+	// Recurring transactions that were already stored keep their IDs.
+	WriteTransactionRecurring(ctx context.Context, bankAccountId ID[BankAccount], recurring []TransactionRecurring) error
 
 	GetTransactionUpload(
 		ctx context.Context,
@@ -130,7 +173,68 @@ type BaseRepository interface {
 		transactionUpload *TransactionUpload,
 	) error
 
+	// GetTransactionUploadMapping will return the saved column layout for
+	// tabular file uploads for the specified bank account. If one has not been
+	// saved yet then pg.ErrNoRows is returned (wrapped).
+	GetTransactionUploadMapping(
+		ctx context.Context,
+		bankAccountId ID[BankAccount],
+	) (*TransactionUploadMapping, error)
+	// SaveTransactionUploadMapping will create or replace the column layout for
+	// the specified bank account.
+	SaveTransactionUploadMapping(
+		ctx context.Context,
+		bankAccountId ID[BankAccount],
+		mapping *TransactionUploadMapping,
+	) error
+
+	// GetTransactionReport aggregates the transactions for the bank account by
+	// the requested group and period, see ReportParameters.
+	GetTransactionReport(ctx context.Context, bankAccountId ID[BankAccount], params ReportParameters) ([]ReportRow, error)
+
+	// GetTransactionRules returns the transaction rules for the specified bank
+	// account in the order that they should be evaluated.
+	GetTransactionRules(ctx context.Context, bankAccountId ID[BankAccount]) ([]TransactionRule, error)
+	GetTransactionRule(ctx context.Context, bankAccountId ID[BankAccount], transactionRuleId ID[TransactionRule]) (*TransactionRule, error)
+	CreateTransactionRule(ctx context.Context, bankAccountId ID[BankAccount], rule *TransactionRule) error
+	UpdateTransactionRule(ctx context.Context, bankAccountId ID[BankAccount], rule *TransactionRule) error
+	DeleteTransactionRule(ctx context.Context, bankAccountId ID[BankAccount], transactionRuleId ID[TransactionRule]) error
+
+	// SnapshotBalances records the current balances of the bank account for the
+	// provided date, replacing any snapshot that already exists for that date.
+	SnapshotBalances(ctx context.Context, bankAccountId ID[BankAccount], date time.Time) (*BalanceSnapshot, error)
+	// GetBalanceSnapshots returns the balance snapshots for the bank account
+	// between the start (inclusive) and the end (exclusive), oldest first.
+	GetBalanceSnapshots(ctx context.Context, bankAccountId ID[BankAccount], start, end time.Time) ([]BalanceSnapshot, error)
+
+	// GetBalanceAlert returns the balance alert for the bank account. If one has
+	// not been configured then pg.ErrNoRows is returned (wrapped).
+	GetBalanceAlert(ctx context.Context, bankAccountId ID[BankAccount]) (*BalanceAlert, error)
+	SaveBalanceAlert(ctx context.Context, bankAccountId ID[BankAccount], alert *BalanceAlert) error
+	UpdateBalanceAlertResult(ctx context.Context, alert *BalanceAlert) error
+	DeleteBalanceAlert(ctx context.Context, bankAccountId ID[BankAccount]) error
+
+	CreateNotification(ctx context.Context, notification *Notification) (bool, error)
+	GetNotifications(ctx context.Context, limit, offset int) ([]Notification, error)
+	GetUnsentNotifications(ctx context.Context, limit int) ([]Notification, error)
+	MarkNotificationSent(ctx context.Context, notificationId ID[Notification], sentAt time.Time) (bool, error)
+	GetNotificationPreferencesByKind(ctx context.Context, kind NotificationKind) ([]NotificationPreference, error)
+
+	GetWebhooks(ctx context.Context) ([]Webhook, error)
+	GetWebhook(ctx context.Context, webhookId ID[Webhook]) (*Webhook, error)
+	GetWebhooksForEvent(ctx context.Context, eventType WebhookEventType) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, webhook *Webhook) error
+	UpdateWebhook(ctx context.Context, webhook *Webhook) error
+	DeleteWebhook(ctx context.Context, webhookId ID[Webhook]) error
+	CreateWebhookDeliveries(ctx context.Context, deliveries []WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, webhookId ID[Webhook], limit, offset int) ([]WebhookDelivery, error)
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+	StartWebhookDeliveryAttempt(ctx context.Context, delivery *WebhookDelivery, previousAttempts int) (bool, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+
 	fileRepositoryInterface
+	tellerRepositoryInterface
+	simpleFINRepositoryInterface
 }
 
 type Repository interface {
@@ -138,9 +242,21 @@ type Repository interface {
 	UserId() ID[User]
 	GetMe(ctx context.Context) (*User, error)
 	UpdateUser(ctx context.Context, user *User) error
+	GetNotificationPreferences(ctx context.Context) ([]NotificationPreference, error)
+	SaveNotificationPreference(ctx context.Context, preference *NotificationPreference) error
+
+	// GetUsers returns the users that are currently members of the account.
+	GetUsers(ctx context.Context) ([]User, error)
+	// GetUserRole returns the role of the current user, or ErrUserNotFound if
+	// they have been removed from the account.
+	GetUserRole(ctx context.Context) (UserRole, error)
+	RemoveUser(ctx context.Context, userId ID[User]) error
+	GetInvitations(ctx context.Context) ([]Invitation, error)
+	CreateInvitation(ctx context.Context, invitation *Invitation) error
+	DeleteInvitation(ctx context.Context, invitationId ID[Invitation]) error
 	
 	// API Key methods
-	CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time) (string, *APIKey, error)
+	CreateAPIKey(ctx context.Context, userId string, name string, expiresAt *time.Time, scopes []string, bankAccountIds []ID[BankAccount]) (string, *APIKey, error)
 	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
 	ListAPIKeys(ctx context.Context, userId string) ([]APIKey, error)
 	RevokeAPIKey(ctx context.Context, userId string, apiKeyId int64) error
@@ -148,9 +264,11 @@ type Repository interface {
 }
 
 type UnauthenticatedRepository interface {
+	AcceptInvitation(ctx context.Context, invitation *Invitation, loginId ID[Login]) (*User, error)
 	CreateAccountV2(ctx context.Context, account *Account) error
 	CreateLogin(ctx context.Context, email, password string, firstName, lastName string) (*Login, error)
 	CreateUser(ctx context.Context, user *User) error
+	GetInvitation(ctx context.Context, accountId ID[Account], invitationId ID[Invitation]) (*Invitation, error)
 	GetLinksForItem(ctx context.Context, itemId string) (*Link, error)
 	GetLoginForEmail(ctx context.Context, emailAddress string) (*Login, error)
 	ResetPassword(ctx context.Context, loginId ID[Login], hashedPassword string) error
@@ -10,6 +10,7 @@ import (
 	"github.com/benbjohnson/clock"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/monetr/monetr/server/consts"
 	"github.com/monetr/monetr/server/crumbs"
 	"github.com/monetr/monetr/server/internal/myownsanity"
@@ -67,6 +68,11 @@ type SecurityRepository interface {
 	// will enable TOTP for the specified login. If they are not valid then this
 	// function will return an error.
 	EnableTOTP(ctx context.Context, loginId ID[Login], code string) error
+
+	// GetUsersForLogin returns every user for the provided login that has not
+	// been removed from its account, along with the account itself. A login will
+	// have more than one user if they are a member of more than one account.
+	GetUsersForLogin(ctx context.Context, loginId ID[Login]) ([]User, error)
 }
 
 var (
@@ -93,7 +99,11 @@ func (b *baseSecurityRepository) Login(ctx context.Context, email, password stri
 
 	var login LoginWithHash
 	err := b.db.ModelContext(span.Context(), &login).
-		Relation("Users").
+		// Users that have been removed from their account cannot be used to
+		// authenticate.
+		Relation("Users", func(q *orm.Query) (*orm.Query, error) {
+			return q.Where(`"user"."deleted_at" IS NULL`), nil
+		}).
 		Relation("Users.Account").
 		Where(`"login_with_hash"."email" = ?`, strings.ToLower(email)).
 		Limit(1).
@@ -264,3 +274,28 @@ func (b *baseSecurityRepository) EnableTOTP(
 
 	return nil
 }
+
+func (b *baseSecurityRepository) GetUsersForLogin(ctx context.Context, loginId ID[Login]) ([]User, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"loginId": loginId,
+	}
+
+	result := make([]User, 0)
+	err := b.db.ModelContext(span.Context(), &result).
+		Relation("Account").
+		Where(`"user"."login_id" = ?`, loginId).
+		Where(`"user"."deleted_at" IS NULL`).
+		Order(`"user"."user_id" ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, crumbs.WrapError(span.Context(), err, "failed to retrieve users for login")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
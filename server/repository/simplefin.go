@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+type simpleFINRepositoryInterface interface {
+	CreateSimpleFINLink(ctx context.Context, link *SimpleFINLink) error
+	UpdateSimpleFINLink(ctx context.Context, link *SimpleFINLink) error
+	CreateSimpleFINBankAccount(ctx context.Context, bankAccount *SimpleFINBankAccount) error
+	UpdateSimpleFINBankAccount(ctx context.Context, bankAccount *SimpleFINBankAccount) error
+	// GetBankAccountsWithSimpleFINByLinkId will return all the bank accounts
+	// associated with the provided link ID that also have a SimpleFIN bank
+	// account associated with them.
+	GetBankAccountsWithSimpleFINByLinkId(ctx context.Context, linkId ID[Link]) ([]BankAccount, error)
+	CreateSimpleFINTransactions(ctx context.Context, transactions ...*SimpleFINTransaction) error
+	UpdateSimpleFINTransaction(ctx context.Context, transaction *SimpleFINTransaction) error
+	// GetTransactionsBySimpleFINId returns the transactions for the provided
+	// bank account that are associated with the provided SimpleFIN transaction
+	// IDs, including deleted transactions. The result is keyed by the SimpleFIN
+	// transaction ID.
+	GetTransactionsBySimpleFINId(ctx context.Context, bankAccountId ID[BankAccount], simpleFINTransactionIds []string) (map[string]Transaction, error)
+	// GetPendingSimpleFINTransactions returns the pending transactions from
+	// SimpleFIN for the specified bank account that are on or after the
+	// provided date.
+	GetPendingSimpleFINTransactions(ctx context.Context, bankAccountId ID[BankAccount], since time.Time) ([]Transaction, error)
+}
+
+func (r *repositoryBase) CreateSimpleFINLink(ctx context.Context, link *SimpleFINLink) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	link.AccountId = r.AccountId()
+	link.CreatedAt = now
+	link.UpdatedAt = now
+	link.CreatedBy = r.UserId()
+	_, err := r.txn.ModelContext(span.Context(), link).Insert(link)
+	return errors.Wrap(err, "failed to create simplefin link")
+}
+
+func (r *repositoryBase) UpdateSimpleFINLink(ctx context.Context, link *SimpleFINLink) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.SetTag("accountId", r.AccountIdStr())
+
+	link.AccountId = r.AccountId()
+	link.UpdatedAt = r.clock.Now().UTC()
+	_, err := r.txn.ModelContext(span.Context(), link).
+		WherePK().
+		Update(link)
+	return errors.Wrap(err, "failed to update SimpleFIN link")
+}
+
+func (r *repositoryBase) CreateSimpleFINBankAccount(
+	ctx context.Context,
+	bankAccount *SimpleFINBankAccount,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	bankAccount.AccountId = r.AccountId()
+	bankAccount.CreatedAt = r.clock.Now().UTC()
+	bankAccount.CreatedBy = r.UserId()
+
+	_, err := r.txn.ModelContext(span.Context(), bankAccount).Insert(bankAccount)
+
+	return errors.Wrap(err, "failed to create simplefin bank account")
+}
+
+func (r *repositoryBase) UpdateSimpleFINBankAccount(
+	ctx context.Context,
+	bankAccount *SimpleFINBankAccount,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	bankAccount.AccountId = r.AccountId()
+
+	_, err := r.txn.ModelContext(span.Context(), bankAccount).
+		WherePK().
+		Update(bankAccount)
+
+	return errors.Wrap(err, "failed to update simplefin bank account")
+}
+
+func (r *repositoryBase) GetBankAccountsWithSimpleFINByLinkId(
+	ctx context.Context,
+	linkId ID[Link],
+) ([]BankAccount, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"linkId":    linkId,
+	}
+
+	var result []BankAccount
+	err := r.txn.ModelContext(span.Context(), &result).
+		Relation(`SimpleFINBankAccount`).
+		Where(`"bank_account"."simplefin_bank_account_id" IS NOT NULL`).
+		Where(`"bank_account"."account_id" = ?`, r.AccountId()).
+		Where(`"bank_account"."link_id" = ? `, linkId).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve simplefin bank accounts by link Id")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+func (r *repositoryBase) CreateSimpleFINTransactions(
+	ctx context.Context,
+	transactions ...*SimpleFINTransaction,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	for i := range transactions {
+		transactions[i].AccountId = r.AccountId()
+		transactions[i].CreatedAt = now
+		transactions[i].UpdatedAt = now
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &transactions).Insert(&transactions)
+	return errors.Wrap(err, "failed to insert simplefin transactions")
+}
+
+func (r *repositoryBase) UpdateSimpleFINTransaction(
+	ctx context.Context,
+	transaction *SimpleFINTransaction,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	transaction.AccountId = r.AccountId()
+	transaction.UpdatedAt = r.clock.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), transaction).
+		WherePK().
+		Update(transaction)
+	return errors.Wrap(err, "failed to update simplefin transaction")
+}
+
+func (r *repositoryBase) GetTransactionsBySimpleFINId(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	simpleFINTransactionIds []string,
+) (map[string]Transaction, error) {
+	if len(simpleFINTransactionIds) == 0 {
+		return map[string]Transaction{}, nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId":           bankAccountId,
+		"simpleFINTransactionIds": simpleFINTransactionIds,
+	}
+
+	items := make([]Transaction, 0)
+	// Deliberatly include all transactions, regardless of delete status.
+	err := r.txn.ModelContext(span.Context(), &items).
+		Relation("SimpleFINTransaction").
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		WhereIn(`"simplefin_transaction"."simplefin_id" IN (?)`, simpleFINTransactionIds).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transactions for simplefin Ids")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	result := make(map[string]Transaction, len(items))
+	for i := range items {
+		item := items[i]
+		if item.SimpleFINTransaction != nil {
+			result[item.SimpleFINTransaction.SimpleFINId] = item
+		}
+	}
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetPendingSimpleFINTransactions(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	since time.Time,
+) ([]Transaction, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"since":         since,
+	}
+
+	items := make([]Transaction, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Relation("SimpleFINTransaction").
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction"."is_pending" = true`).
+		Where(`"transaction"."deleted_at" IS NULL`).
+		Where(`"transaction"."date" >= ?`, since).
+		Where(`"transaction"."simplefin_transaction_id" IS NOT NULL`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve pending simplefin transactions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
@@ -187,6 +187,39 @@ func (r *repositoryBase) DeleteSpending(ctx context.Context, bankAccountId ID[Ba
 		return errors.Wrap(err, "failed to remove spending from any transactions")
 	}
 
+	_, err = r.txn.ModelContext(span.Context(), &TransactionSplit{}).
+		Set(`"spending_id" = NULL`).
+		Set(`"spending_amount" = NULL`).
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_split"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_split"."spending_id" = ?`, spendingId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove spending from any transaction splits")
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &TransactionRule{}).
+		Set(`"spending_id" = NULL`).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_rule"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_rule"."spending_id" = ?`, spendingId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove spending from any transaction rules")
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &SpendingAllocation{}).
+		Where(`"spending_allocation"."account_id" = ?`, r.AccountId()).
+		Where(`"spending_allocation"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending_allocation"."spending_id" = ?`, spendingId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove allocations for spending")
+	}
+
 	result, err := r.txn.ModelContext(span.Context(), &Spending{}).
 		Where(`"spending"."account_id" = ?`, r.AccountId()).
 		Where(`"spending"."bank_account_id" = ?`, bankAccountId).
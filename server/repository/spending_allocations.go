@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetSpendingAllocations returns the allocation ledger for the specified
+// spending object, newest first.
+func (r *repositoryBase) GetSpendingAllocations(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	spendingId ID[Spending],
+	limit, offset int,
+) ([]SpendingAllocation, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"spendingId":    spendingId,
+		"limit":         limit,
+		"offset":        offset,
+	}
+
+	items := make([]SpendingAllocation, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Where(`"spending_allocation"."account_id" = ?`, r.AccountId()).
+		Where(`"spending_allocation"."bank_account_id" = ?`, bankAccountId).
+		Where(`"spending_allocation"."spending_id" = ?`, spendingId).
+		Limit(limit).
+		Offset(offset).
+		Order(`created_at DESC`).
+		Order(`spending_allocation_id DESC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve spending allocations")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// CreateSpendingAllocations appends the provided entries to the allocation
+// ledger. Entries that did not actually move any money are skipped.
+func (r *repositoryBase) CreateSpendingAllocations(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	allocations []SpendingAllocation,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	// Entries are created in the order they were provided, make sure that they
+	// sort the same way when they are read back.
+	now := r.clock.Now().UTC()
+	items := make([]SpendingAllocation, 0, len(allocations))
+	for _, item := range allocations {
+		if item.Amount == 0 {
+			continue
+		}
+
+		item.SpendingAllocationId = ""
+		item.AccountId = r.AccountId()
+		item.BankAccountId = bankAccountId
+		item.CreatedAt = now.Add(time.Duration(len(items)) * time.Microsecond)
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &items).Insert(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create spending allocations")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+type tellerRepositoryInterface interface {
+	CreateTellerLink(ctx context.Context, link *TellerLink) error
+	UpdateTellerLink(ctx context.Context, link *TellerLink) error
+	// DeleteTellerLink will convert the link into a manual link and mark the
+	// Teller link as disconnected and deleted, the access token is detached from
+	// the Teller link but is not deleted by this method.
+	DeleteTellerLink(ctx context.Context, tellerLinkId ID[TellerLink]) error
+	CreateTellerBankAccount(ctx context.Context, bankAccount *TellerBankAccount) error
+	UpdateTellerBankAccount(ctx context.Context, bankAccount *TellerBankAccount) error
+	// GetBankAccountsWithTellerByLinkId will return all the bank accounts
+	// associated with the provided link ID that also have a Teller bank account
+	// associated with them.
+	GetBankAccountsWithTellerByLinkId(ctx context.Context, linkId ID[Link]) ([]BankAccount, error)
+	CreateTellerTransactions(ctx context.Context, transactions ...*TellerTransaction) error
+	UpdateTellerTransaction(ctx context.Context, transaction *TellerTransaction) error
+	// GetTransactionsByTellerId returns the transactions for the provided link
+	// that are associated with the provided Teller transaction IDs, including
+	// deleted transactions. The result is keyed by the Teller transaction ID.
+	GetTransactionsByTellerId(ctx context.Context, linkId ID[Link], tellerTransactionIds []string) (map[string]Transaction, error)
+	// GetPendingTellerTransactions returns the pending transactions from Teller
+	// for the specified bank account that are on or after the provided date.
+	// Teller does not tell us when a pending transaction goes away, so these
+	// are used to find the ones that are no longer present.
+	GetPendingTellerTransactions(ctx context.Context, bankAccountId ID[BankAccount], since time.Time) ([]Transaction, error)
+}
+
+func (r *repositoryBase) CreateTellerLink(ctx context.Context, link *TellerLink) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	link.AccountId = r.AccountId()
+	link.CreatedAt = now
+	link.UpdatedAt = now
+	link.CreatedBy = r.UserId()
+	_, err := r.txn.ModelContext(span.Context(), link).Insert(link)
+	return errors.Wrap(err, "failed to create teller link")
+}
+
+func (r *repositoryBase) UpdateTellerLink(ctx context.Context, link *TellerLink) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.SetTag("accountId", r.AccountIdStr())
+
+	link.AccountId = r.AccountId()
+	link.UpdatedAt = r.clock.Now().UTC()
+	_, err := r.txn.ModelContext(span.Context(), link).
+		WherePK().
+		Update(link)
+	return errors.Wrap(err, "failed to update Teller link")
+}
+
+func (r *repositoryBase) DeleteTellerLink(
+	ctx context.Context,
+	tellerLinkId ID[TellerLink],
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	// Like Plaid links, the link record is kept as a manual link so that the
+	// data the user has is still preserved.
+	_, err := r.txn.ModelContext(span.Context(), &Link{}).
+		Set(`"link_type" = ?`, ManualLinkType).
+		Where(`"link"."account_id" = ?`, r.AccountId()).
+		Where(`"link"."teller_link_id" = ?`, tellerLinkId).
+		Where(`"link"."link_type" = ?`, TellerLinkType).
+		Update()
+	if err != nil {
+		return errors.Wrap(err, "failed to clean Teller link prior to removal")
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &TellerLink{}).
+		Set(`"secret_id" = NULL`).
+		Set(`"status" = ?`, TellerLinkStatusDisconnected).
+		Set(`"deleted_at" = ?`, r.clock.Now().UTC()).
+		Where(`"teller_link"."account_id" = ?`, r.AccountId()).
+		Where(`"teller_link"."teller_link_id" = ?`, tellerLinkId).
+		Update()
+	return errors.Wrap(err, "failed to delete Teller link")
+}
+
+func (r *repositoryBase) CreateTellerBankAccount(
+	ctx context.Context,
+	bankAccount *TellerBankAccount,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	bankAccount.AccountId = r.AccountId()
+	bankAccount.CreatedAt = r.clock.Now().UTC()
+	bankAccount.CreatedBy = r.UserId()
+
+	_, err := r.txn.ModelContext(span.Context(), bankAccount).Insert(bankAccount)
+
+	return errors.Wrap(err, "failed to create teller bank account")
+}
+
+func (r *repositoryBase) UpdateTellerBankAccount(
+	ctx context.Context,
+	bankAccount *TellerBankAccount,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	bankAccount.AccountId = r.AccountId()
+
+	_, err := r.txn.ModelContext(span.Context(), bankAccount).
+		WherePK().
+		Update(bankAccount)
+
+	return errors.Wrap(err, "failed to update teller bank account")
+}
+
+func (r *repositoryBase) GetBankAccountsWithTellerByLinkId(
+	ctx context.Context,
+	linkId ID[Link],
+) ([]BankAccount, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"linkId":    linkId,
+	}
+
+	var result []BankAccount
+	err := r.txn.ModelContext(span.Context(), &result).
+		Relation(`TellerBankAccount`).
+		Where(`"bank_account"."teller_bank_account_id" IS NOT NULL`).
+		Where(`"bank_account"."account_id" = ?`, r.AccountId()).
+		Where(`"bank_account"."link_id" = ? `, linkId).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve teller bank accounts by link Id")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+func (r *repositoryBase) CreateTellerTransactions(
+	ctx context.Context,
+	transactions ...*TellerTransaction,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	for i := range transactions {
+		transactions[i].AccountId = r.AccountId()
+		transactions[i].CreatedAt = now
+		transactions[i].UpdatedAt = now
+	}
+
+	_, err := r.txn.ModelContext(span.Context(), &transactions).Insert(&transactions)
+	return errors.Wrap(err, "failed to insert teller transactions")
+}
+
+func (r *repositoryBase) UpdateTellerTransaction(
+	ctx context.Context,
+	transaction *TellerTransaction,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	transaction.AccountId = r.AccountId()
+	transaction.UpdatedAt = r.clock.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), transaction).
+		WherePK().
+		Update(transaction)
+	return errors.Wrap(err, "failed to update teller transaction")
+}
+
+func (r *repositoryBase) GetTransactionsByTellerId(
+	ctx context.Context,
+	linkId ID[Link],
+	tellerTransactionIds []string,
+) (map[string]Transaction, error) {
+	if len(tellerTransactionIds) == 0 {
+		return map[string]Transaction{}, nil
+	}
+
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"linkId":               linkId,
+		"tellerTransactionIds": tellerTransactionIds,
+	}
+
+	items := make([]Transaction, 0)
+	// Deliberatly include all transactions, regardless of delete status.
+	err := r.txn.ModelContext(span.Context(), &items).
+		Relation("TellerTransaction").
+		Join(`INNER JOIN "bank_accounts" AS "bank_account"`).
+		JoinOn(`"bank_account"."bank_account_id" = "transaction"."bank_account_id" AND "bank_account"."account_id" = "transaction"."account_id"`).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"bank_account"."link_id" = ?`, linkId).
+		WhereIn(`"teller_transaction"."teller_id" IN (?)`, tellerTransactionIds).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transactions for teller Ids")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	result := make(map[string]Transaction, len(items))
+	for i := range items {
+		item := items[i]
+		if item.TellerTransaction != nil {
+			result[item.TellerTransaction.TellerId] = item
+		}
+	}
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetPendingTellerTransactions(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	since time.Time,
+) ([]Transaction, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"since":         since,
+	}
+
+	items := make([]Transaction, 0)
+	err := r.txn.ModelContext(span.Context(), &items).
+		Relation("TellerTransaction").
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction"."is_pending" = true`).
+		Where(`"transaction"."deleted_at" IS NULL`).
+		Where(`"transaction"."date" >= ?`, since).
+		Where(`"transaction"."teller_transaction_id" IS NOT NULL`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve pending teller transactions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return items, nil
+}
+
+// TellerRepository is used to look up Teller links outside the context of an
+// authenticated account, like when a webhook is received from Teller.
+type TellerRepository interface {
+	GetLink(ctx context.Context, accountId ID[Account], linkId ID[Link]) (*Link, error)
+	GetLinkByEnrollmentId(ctx context.Context, enrollmentId string) (*Link, error)
+	// GetLinkByTellerAccountId returns the link that the Teller account belongs
+	// to, transaction webhooks from Teller only include the account ID.
+	GetLinkByTellerAccountId(ctx context.Context, tellerAccountId string) (*Link, error)
+}
+
+func NewTellerRepository(db pg.DBI) TellerRepository {
+	return &tellerRepositoryBase{
+		txn: db,
+	}
+}
+
+type tellerRepositoryBase struct {
+	txn pg.DBI
+}
+
+func (r *tellerRepositoryBase) GetLink(
+	ctx context.Context,
+	accountId ID[Account],
+	linkId ID[Link],
+) (*Link, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": accountId,
+		"linkId":    linkId,
+	}
+
+	var link Link
+	err := r.txn.ModelContext(span.Context(), &link).
+		Relation("TellerLink").
+		Where(`"link"."account_id" = ?`, accountId).
+		Where(`"link"."link_id" = ?`, linkId).
+		Limit(1).
+		Select(&link)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve link")
+	}
+
+	return &link, nil
+}
+
+func (r *tellerRepositoryBase) GetLinkByEnrollmentId(
+	ctx context.Context,
+	enrollmentId string,
+) (*Link, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"enrollmentId": enrollmentId,
+	}
+
+	var link Link
+	err := r.txn.ModelContext(span.Context(), &link).
+		Relation("TellerLink").
+		Where(`"teller_link"."enrollment_id" = ?`, enrollmentId).
+		Where(`"link"."deleted_at" IS NULL`).
+		Limit(1).
+		Select(&link)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve link by enrollment Id")
+	}
+
+	return &link, nil
+}
+
+func (r *tellerRepositoryBase) GetLinkByTellerAccountId(
+	ctx context.Context,
+	tellerAccountId string,
+) (*Link, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"tellerAccountId": tellerAccountId,
+	}
+
+	var link Link
+	err := r.txn.ModelContext(span.Context(), &link).
+		Relation("TellerLink").
+		Join(`INNER JOIN "teller_bank_accounts" AS "teller_bank_account"`).
+		JoinOn(`"teller_bank_account"."teller_link_id" = "link"."teller_link_id" AND "teller_bank_account"."account_id" = "link"."account_id"`).
+		Where(`"teller_bank_account"."teller_id" = ?`, tellerAccountId).
+		Where(`"link"."deleted_at" IS NULL`).
+		Limit(1).
+		Select(&link)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve link by Teller account Id")
+	}
+
+	return &link, nil
+}
@@ -266,6 +266,12 @@ func (r *repositoryBase) GetTransaction(ctx context.Context, bankAccountId ID[Ba
 		return nil, errors.Wrap(err, "failed to retrieve transaction")
 	}
 
+	result.Splits, err = r.GetTransactionSplits(span.Context(), bankAccountId, transactionId)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, err
+	}
+
 	span.Status = sentry.SpanStatusOK
 
 	return &result, nil
@@ -382,22 +388,41 @@ func (r *repositoryBase) GetTransactionsByPlaidTransactionId(ctx context.Context
 	return result, nil
 }
 
-func (r *repositoryBase) GetRecentDepositTransactions(ctx context.Context, bankAccountId ID[BankAccount]) ([]Transaction, error) {
+// GetDepositTransactionsBetween returns the deposits for the bank account that
+// happened on or after the start and before the end, oldest first.
+func (r *repositoryBase) GetDepositTransactionsBetween(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	start, end time.Time,
+) ([]Transaction, error) {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
 
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"start":         start,
+		"end":           end,
+	}
+
 	result := make([]Transaction, 0)
 	err := r.txn.ModelContext(span.Context(), &result).
 		Where(`"transaction"."account_id" = ?`, r.AccountId()).
 		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
 		Where(`"transaction"."amount" < 0`). // Negative transactions are deposits.
-		Where(`"transaction"."date" >= ?`, time.Now().Add(-24*time.Hour)).
+		Where(`"transaction"."date" >= ?`, start).
+		Where(`"transaction"."date" < ?`, end).
 		Where(`"transaction"."deleted_at" IS NULL`).
+		Order(`date ASC`).
+		Order(`transaction_id ASC`).
 		Select(&result)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to retrieve recent deposit transactions")
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve deposit transactions")
 	}
 
+	span.Status = sentry.SpanStatusOK
+
 	return result, nil
 }
 
@@ -410,6 +435,18 @@ func (r *repositoryBase) ProcessTransactionSpentFrom(ctx context.Context, bankAc
 		return nil, err
 	}
 
+	// Split transactions deduct from a spending object for each split rather
+	// than from a single spending object, so they are handled separately.
+	if len(input.Splits) > 0 || len(existing.Splits) > 0 {
+		return r.processTransactionSplitsSpentFrom(
+			span.Context(),
+			account,
+			bankAccountId,
+			input,
+			existing,
+		)
+	}
+
 	const (
 		AddExpense = iota
 		ChangeExpense
@@ -465,6 +502,7 @@ func (r *repositoryBase) ProcessTransactionSpentFrom(ctx context.Context, bankAc
 	}
 
 	expenseUpdates := make([]Spending, 0)
+	allocations := make([]SpendingAllocation, 0, 2)
 
 	switch expensePlan {
 	case ChangeExpense, RemoveExpense:
@@ -500,6 +538,13 @@ func (r *repositoryBase) ProcessTransactionSpentFrom(ctx context.Context, bankAc
 
 		// Then take all the fields that have changed and throw them in our list of things to update.
 		expenseUpdates = append(expenseUpdates, *currentExpense)
+		allocations = append(allocations, newTransactionAllocation(
+			currentExpense,
+			SpendingAllocationKindRefund,
+			*existing.SpendingAmount,
+			existing.TransactionId,
+			nil,
+		))
 
 		// If we are only removing the expense then we are done with this part.
 		if expensePlan == RemoveExpense {
@@ -516,9 +561,41 @@ func (r *repositoryBase) ProcessTransactionSpentFrom(ctx context.Context, bankAc
 
 		// Then take all the fields that have changed and throw them in our list of things to update.
 		expenseUpdates = append(expenseUpdates, *newExpense)
+		allocations = append(allocations, newTransactionAllocation(
+			newExpense,
+			SpendingAllocationKindSpend,
+			-*input.SpendingAmount,
+			input.TransactionId,
+			nil,
+		))
 	}
 
-	return expenseUpdates, r.UpdateSpending(span.Context(), bankAccountId, expenseUpdates)
+	if err := r.UpdateSpending(span.Context(), bankAccountId, expenseUpdates); err != nil {
+		return nil, err
+	}
+
+	return expenseUpdates, r.CreateSpendingAllocations(span.Context(), bankAccountId, allocations)
+}
+
+// newTransactionAllocation creates an allocation ledger entry for an amount
+// that was spent from or refunded to a spending object for a transaction or
+// one of its splits.
+func newTransactionAllocation(
+	spending *Spending,
+	kind SpendingAllocationKind,
+	amount int64,
+	transactionId ID[Transaction],
+	transactionSplitId *ID[TransactionSplit],
+) SpendingAllocation {
+	allocation := NewSpendingAllocation(
+		spending,
+		kind,
+		SpendingAllocationSourceTransaction,
+		amount,
+	)
+	allocation.TransactionId = &transactionId
+	allocation.TransactionSplitId = transactionSplitId
+	return allocation
 }
 
 func (r *repositoryBase) AddExpenseToTransaction(ctx context.Context, transaction *Transaction, spending *Spending) error {
@@ -2,12 +2,23 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/monetr/monetr/server/crumbs"
 	. "github.com/monetr/monetr/server/models"
 	"github.com/pkg/errors"
 )
 
+// TransactionClusterMonth is the number of transactions and their total
+// amount for a single transaction cluster in a single month.
+type TransactionClusterMonth struct {
+	TransactionClusterId ID[TransactionCluster] `json:"-" pg:"transaction_cluster_id"`
+	// Month is the first day of the month at midnight in the account's timezone.
+	Month  time.Time `json:"month" pg:"month"`
+	Count  int64     `json:"count" pg:"count"`
+	Amount int64     `json:"amount" pg:"amount"`
+}
+
 func (r *repositoryBase) WriteTransactionClusters(
 	ctx context.Context,
 	bankAccountId ID[BankAccount],
@@ -58,3 +69,213 @@ func (r *repositoryBase) GetTransactionClusterByMember(
 
 	return &cluster, nil
 }
+
+// GetTransactionClusters returns all of the transaction clusters that were
+// last calculated for the specified bank account.
+func (r *repositoryBase) GetTransactionClusters(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) ([]TransactionCluster, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result := make([]TransactionCluster, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"account_id" = ?`, r.AccountId()).
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Order(`transaction_cluster_id ASC`).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve transaction clusters")
+	}
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetTransactionClusterById(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionClusterId ID[TransactionCluster],
+) (*TransactionCluster, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	var cluster TransactionCluster
+	err := r.txn.ModelContext(span.Context(), &cluster).
+		Where(`"account_id" = ?`, r.AccountId()).
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_cluster_id" = ?`, transactionClusterId).
+		Limit(1).
+		Select(&cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve transaction cluster")
+	}
+
+	return &cluster, nil
+}
+
+func (r *repositoryBase) CreateTransactionCluster(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	cluster *TransactionCluster,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	cluster.AccountId = r.AccountId()
+	cluster.BankAccountId = bankAccountId
+	cluster.CreatedAt = r.clock.Now().UTC()
+
+	_, err := r.txn.ModelContext(span.Context(), cluster).Insert(cluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction cluster")
+	}
+
+	return nil
+}
+
+// UpdateTransactionCluster will update the name and the members of the
+// provided transaction cluster.
+func (r *repositoryBase) UpdateTransactionCluster(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	cluster *TransactionCluster,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	cluster.AccountId = r.AccountId()
+	cluster.BankAccountId = bankAccountId
+
+	_, err := r.txn.ModelContext(span.Context(), cluster).
+		Column("name", "members").
+		WherePK().
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Update(cluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to update transaction cluster")
+	}
+
+	return nil
+}
+
+func (r *repositoryBase) DeleteTransactionCluster(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionClusterId ID[TransactionCluster],
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	_, err := r.txn.ModelContext(span.Context(), new(TransactionCluster)).
+		Where(`"account_id" = ?`, r.AccountId()).
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_cluster_id" = ?`, transactionClusterId).
+		Delete()
+	if err != nil {
+		return errors.Wrap(err, "failed to delete transaction cluster")
+	}
+
+	return nil
+}
+
+// GetTransactionClusterMonths returns the number of transactions and their
+// total amount for each transaction cluster per month, for transactions on or
+// after the provided date. Months are calculated in the provided timezone.
+func (r *repositoryBase) GetTransactionClusterMonths(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	timezone *time.Location,
+	since time.Time,
+) ([]TransactionClusterMonth, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result := make([]TransactionClusterMonth, 0)
+	_, err := r.txn.QueryContext(
+		span.Context(),
+		&result,
+		`
+		SELECT
+			"cluster"."transaction_cluster_id",
+			date_trunc('month', "transaction"."date" AT TIME ZONE ?0) AT TIME ZONE ?0 AS "month",
+			count(*) AS "count",
+			sum("transaction"."amount") AS "amount"
+		FROM "transaction_clusters" AS "cluster"
+		INNER JOIN "transactions" AS "transaction"
+			ON "transaction"."account_id" = "cluster"."account_id"
+			AND "transaction"."bank_account_id" = "cluster"."bank_account_id"
+			AND "transaction"."transaction_id" = ANY("cluster"."members")
+		WHERE "cluster"."account_id" = ?1
+			AND "cluster"."bank_account_id" = ?2
+			AND "transaction"."deleted_at" IS NULL
+			AND "transaction"."date" >= ?3
+		GROUP BY "cluster"."transaction_cluster_id", "month"
+		ORDER BY "cluster"."transaction_cluster_id", "month"
+		`,
+		timezone.String(),
+		r.AccountId(),
+		bankAccountId,
+		since,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve transaction cluster totals")
+	}
+
+	return result, nil
+}
+
+// GetTransactionClusterOverrides returns all of the changes the user has made
+// to the transaction clusters of the specified bank account.
+func (r *repositoryBase) GetTransactionClusterOverrides(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) ([]TransactionClusterOverride, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result := make([]TransactionClusterOverride, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"account_id" = ?`, r.AccountId()).
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Order(`transaction_cluster_override_id ASC`).
+		Select(&result)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve transaction cluster overrides")
+	}
+
+	return result, nil
+}
+
+// SaveTransactionClusterOverride will create or replace the override for the
+// signature of the provided override.
+func (r *repositoryBase) SaveTransactionClusterOverride(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	override *TransactionClusterOverride,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	now := r.clock.Now().UTC()
+	override.AccountId = r.AccountId()
+	override.BankAccountId = bankAccountId
+	if override.CreatedAt.IsZero() {
+		override.CreatedAt = now
+	}
+	override.UpdatedAt = now
+
+	_, err := r.txn.ModelContext(span.Context(), override).
+		OnConflict(`("account_id", "bank_account_id", "signature") DO UPDATE`).
+		Set(`"name" = EXCLUDED."name"`).
+		Set(`"merged_into" = EXCLUDED."merged_into"`).
+		Set(`"members" = EXCLUDED."members"`).
+		Set(`"updated_at" = EXCLUDED."updated_at"`).
+		Returning(`*`).
+		Insert(override)
+	if err != nil {
+		return errors.Wrap(err, "failed to save transaction cluster override")
+	}
+
+	return nil
+}
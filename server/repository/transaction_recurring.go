@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// GetTransactionRecurring returns all of the recurring transactions that were
+// detected for the specified bank account. Recurring transactions that are
+// still ongoing are returned first, ordered by their next occurrence.
+func (r *repositoryBase) GetTransactionRecurring(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) ([]TransactionRecurring, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	result := make([]TransactionRecurring, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"transaction_recurring"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_recurring"."bank_account_id" = ?`, bankAccountId).
+		Order(`ended ASC`).
+		Order(`next ASC`).
+		Order(`transaction_recurring_id ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve recurring transactions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetTransactionRecurringById(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionRecurringId ID[TransactionRecurring],
+) (*TransactionRecurring, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":              r.AccountId(),
+		"bankAccountId":          bankAccountId,
+		"transactionRecurringId": transactionRecurringId,
+	}
+
+	var result TransactionRecurring
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"transaction_recurring"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_recurring"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_recurring"."transaction_recurring_id" = ?`, transactionRecurringId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve recurring transaction")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+// WriteTransactionRecurring stores the recurring transactions that were just
+// detected for the bank account. Recurring transactions that were detected
+// before are matched by their RecurringKey and updated in place so that their
+// IDs do not change, new ones are created, and any that were not detected this
+// time are removed.
+func (r *repositoryBase) WriteTransactionRecurring(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	recurring []TransactionRecurring,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	// Two clusters can end up with the same name, keep their keys unique by
+	// numbering the duplicates from the oldest to the newest.
+	sort.SliceStable(recurring, func(i, j int) bool {
+		return recurring[i].First.Before(recurring[j].First)
+	})
+	keys := make([]string, 0, len(recurring))
+	occurrences := map[string]int{}
+	for i := range recurring {
+		recurring[i].AccountId = r.AccountId()
+		recurring[i].BankAccountId = bankAccountId
+
+		key := TransactionRecurringKey(recurring[i].Name, recurring[i].Window)
+		occurrences[key]++
+		if count := occurrences[key]; count > 1 {
+			key = fmt.Sprintf("%s:%d", key, count)
+		}
+		recurring[i].RecurringKey = key
+		keys = append(keys, key)
+	}
+
+	if len(recurring) > 0 {
+		_, err := r.txn.ModelContext(span.Context(), &recurring).
+			OnConflict(`("account_id", "bank_account_id", "recurring_key") DO UPDATE`).
+			Set(`"name" = EXCLUDED."name"`).
+			Set(`"window_type" = EXCLUDED."window_type"`).
+			Set(`"ruleset" = EXCLUDED."ruleset"`).
+			Set(`"first" = EXCLUDED."first"`).
+			Set(`"last" = EXCLUDED."last"`).
+			Set(`"next" = EXCLUDED."next"`).
+			Set(`"ended" = EXCLUDED."ended"`).
+			Set(`"confidence" = EXCLUDED."confidence"`).
+			Set(`"amounts" = EXCLUDED."amounts"`).
+			Set(`"last_amount" = EXCLUDED."last_amount"`).
+			Set(`"members" = EXCLUDED."members"`).
+			Returning(`*`).
+			Insert(&recurring)
+		if err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return errors.Wrap(err, "failed to write recurring transactions")
+		}
+	}
+
+	query := r.txn.ModelContext(span.Context(), new(TransactionRecurring)).
+		Where(`"transaction_recurring"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_recurring"."bank_account_id" = ?`, bankAccountId)
+	if len(keys) > 0 {
+		query = query.WhereIn(`"transaction_recurring"."recurring_key" NOT IN (?)`, keys)
+	}
+	if _, err := query.Delete(); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove stale recurring transactions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
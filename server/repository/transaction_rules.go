@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrTransactionRuleNotFound = errors.New("transaction rule does not exist")
+)
+
+// GetTransactionRules returns all of the transaction rules for the specified
+// bank account in the order that they should be evaluated.
+func (r *repositoryBase) GetTransactionRules(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) ([]TransactionRule, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	result := make([]TransactionRule, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_rule"."bank_account_id" = ?`, bankAccountId).
+		Order(`priority ASC`).
+		Order(`transaction_rule_id ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction rules")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetTransactionRule(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionRuleId ID[TransactionRule],
+) (*TransactionRule, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":         r.AccountId(),
+		"bankAccountId":     bankAccountId,
+		"transactionRuleId": transactionRuleId,
+	}
+
+	var result TransactionRule
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_rule"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_rule"."transaction_rule_id" = ?`, transactionRuleId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction rule")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+func (r *repositoryBase) CreateTransactionRule(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	rule *TransactionRule,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	now := r.clock.Now().UTC()
+	rule.AccountId = r.AccountId()
+	rule.BankAccountId = bankAccountId
+	rule.CreatedAt = now
+	rule.CreatedBy = r.UserId()
+	rule.UpdatedAt = now
+
+	if _, err := r.txn.ModelContext(span.Context(), rule).Insert(rule); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create transaction rule")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// UpdateTransactionRule requires that the entire rule is provided, including
+// the creation details. Any fields that are missing will be cleared on the
+// rule.
+func (r *repositoryBase) UpdateTransactionRule(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	rule *TransactionRule,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":         r.AccountId(),
+		"bankAccountId":     bankAccountId,
+		"transactionRuleId": rule.TransactionRuleId,
+	}
+
+	rule.AccountId = r.AccountId()
+	rule.BankAccountId = bankAccountId
+	rule.UpdatedAt = r.clock.Now().UTC()
+
+	result, err := r.txn.ModelContext(span.Context(), rule).
+		Where(`"transaction_rule"."bank_account_id" = ?`, bankAccountId).
+		WherePK().
+		Update(rule)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update transaction rule")
+	} else if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrTransactionRuleNotFound)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) DeleteTransactionRule(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionRuleId ID[TransactionRule],
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":         r.AccountId(),
+		"bankAccountId":     bankAccountId,
+		"transactionRuleId": transactionRuleId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &TransactionRule{}).
+		Where(`"transaction_rule"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_rule"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_rule"."transaction_rule_id" = ?`, transactionRuleId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove transaction rule")
+	} else if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrTransactionRuleNotFound)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
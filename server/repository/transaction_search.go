@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// TransactionFilter is used to narrow down the transactions returned by
+// SearchTransactions. Any field that is left nil or empty will not be used to
+// filter transactions.
+type TransactionFilter struct {
+	// StartDate is inclusive, only transactions on or after this date will be
+	// returned.
+	StartDate *time.Time
+	// EndDate is exclusive, only transactions before this date will be returned.
+	EndDate *time.Time
+	// MinAmount and MaxAmount are both inclusive and are in the same format as
+	// the amount stored on the transaction. Deposits are negative.
+	MinAmount  *int64
+	MaxAmount  *int64
+	SpendingId *ID[Spending]
+	IsPending  *bool
+	Source     *TransactionSource
+	// Category will match either the custom category on the transaction or any
+	// of the categories provided by the data source.
+	Category *string
+	// Query is matched case insensitively against the name, merchant name and
+	// original name of each transaction.
+	Query string
+	// IncludeHidden will include transactions that have been hidden by a
+	// transaction rule, they are excluded by default.
+	IncludeHidden bool
+	// Cursor is the position to continue from, if it is nil then the most recent
+	// transactions will be returned first.
+	Cursor *TransactionCursor
+	Limit  int
+	// Offset is only supported for clients that still page through transactions
+	// using offsets, Cursor should be preferred.
+	Offset int
+}
+
+// TransactionCursor represents a position in the list of transactions when
+// they are ordered from newest to oldest. It is used to page through results
+// without needing to count rows with an offset.
+type TransactionCursor struct {
+	Date          time.Time
+	TransactionId ID[Transaction]
+}
+
+// String returns an opaque representation of the cursor that can be provided
+// to clients and parsed again with ParseTransactionCursor.
+func (c TransactionCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(
+		fmt.Sprintf("%s|%s", c.Date.UTC().Format(time.RFC3339Nano), c.TransactionId),
+	))
+}
+
+// ParseTransactionCursor takes a cursor string that was previously returned by
+// TransactionCursor.String and returns the cursor it represents.
+func ParseTransactionCursor(input string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode transaction cursor")
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("transaction cursor is not valid")
+	}
+
+	date, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse transaction cursor date")
+	}
+
+	transactionId, err := ParseID[Transaction](parts[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse transaction cursor id")
+	}
+
+	return &TransactionCursor{
+		Date:          date,
+		TransactionId: transactionId,
+	}, nil
+}
+
+// SearchTransactions returns the transactions for the specified bank account
+// that match the provided filter, ordered from newest to oldest. If there are
+// more transactions after the ones returned then a cursor will also be returned
+// that can be used to retrieve the next page.
+func (r *repositoryBase) SearchTransactions(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	filter TransactionFilter,
+) ([]Transaction, *TransactionCursor, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+		"limit":         filter.Limit,
+	}
+
+	// Retrieve one more transaction than we were asked for, this way we know if
+	// there is another page of results or not.
+	items := make([]Transaction, 0, filter.Limit+1)
+	query := r.txn.ModelContext(span.Context(), &items).
+		Where(`"transaction"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction"."deleted_at" IS NULL`)
+
+	if filter.StartDate != nil {
+		query = query.Where(`"transaction"."date" >= ?`, *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where(`"transaction"."date" < ?`, *filter.EndDate)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where(`"transaction"."amount" >= ?`, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where(`"transaction"."amount" <= ?`, *filter.MaxAmount)
+	}
+	if filter.SpendingId != nil {
+		query = query.Where(`"transaction"."spending_id" = ?`, *filter.SpendingId)
+	}
+	if filter.IsPending != nil {
+		query = query.Where(`"transaction"."is_pending" = ?`, *filter.IsPending)
+	}
+	if filter.Source != nil {
+		query = query.Where(`"transaction"."source" = ?`, *filter.Source)
+	}
+	if !filter.IncludeHidden {
+		query = query.Where(`"transaction"."is_hidden" = false`)
+	}
+	if filter.Category != nil {
+		query = query.WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q = q.
+				WhereOr(`"transaction"."category" = ?`, *filter.Category).
+				WhereOr(`? = ANY("transaction"."categories")`, *filter.Category)
+			return q, nil
+		})
+	}
+	if search := strings.TrimSpace(filter.Query); search != "" {
+		pattern := "%" + escapeLikePattern(search) + "%"
+		query = query.WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q = q.
+				WhereOr(`"transaction"."name" ILIKE ?`, pattern).
+				WhereOr(`"transaction"."merchant_name" ILIKE ?`, pattern).
+				WhereOr(`"transaction"."original_name" ILIKE ?`, pattern)
+			return q, nil
+		})
+	}
+	if filter.Cursor != nil {
+		query = query.Where(
+			`("transaction"."date", "transaction"."transaction_id") < (?, ?)`,
+			filter.Cursor.Date,
+			filter.Cursor.TransactionId,
+		)
+	}
+
+	err := query.
+		Limit(filter.Limit + 1).
+		Offset(filter.Offset).
+		Order(`date DESC`).
+		Order(`transaction_id DESC`).
+		Select(&items)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, nil, crumbs.WrapError(span.Context(), err, "failed to search transactions")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	if len(items) <= filter.Limit {
+		return items, nil, nil
+	}
+
+	items = items[:filter.Limit]
+	last := items[len(items)-1]
+	return items, &TransactionCursor{
+		Date:          last.Date,
+		TransactionId: last.TransactionId,
+	}, nil
+}
+
+// escapeLikePattern will escape any characters in the provided input that
+// would otherwise be treated as wildcards in a LIKE pattern.
+func escapeLikePattern(input string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`%`, `\%`,
+		`_`, `\_`,
+	).Replace(input)
+}
@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTransactionCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		cursor := repository.TransactionCursor{
+			Date:          time.Date(2024, 10, 5, 0, 0, 0, 0, time.UTC),
+			TransactionId: models.NewID(&models.Transaction{}),
+		}
+
+		parsed, err := repository.ParseTransactionCursor(cursor.String())
+		assert.NoError(t, err, "must be able to parse the cursor")
+		assert.Equal(t, cursor.TransactionId, parsed.TransactionId, "transaction Id should match")
+		assert.True(t, cursor.Date.Equal(parsed.Date), "date should match")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		parsed, err := repository.ParseTransactionCursor("not a cursor")
+		assert.Error(t, err, "should fail to parse an invalid cursor")
+		assert.Nil(t, parsed, "cursor should be nil")
+	})
+
+	t.Run("missing separator", func(t *testing.T) {
+		parsed, err := repository.ParseTransactionCursor("Zm9vYmFy")
+		assert.EqualError(t, err, "transaction cursor is not valid")
+		assert.Nil(t, parsed, "cursor should be nil")
+	})
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) GetTransactionSplits(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionId ID[Transaction],
+) ([]TransactionSplit, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"bankAccountId": bankAccountId,
+		"transactionId": transactionId,
+	}
+
+	result := make([]TransactionSplit, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_split"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_split"."transaction_id" = ?`, transactionId).
+		Order(`transaction_split_id ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction splits")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+func (r *repositoryBase) InsertTransactionSplits(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionId ID[Transaction],
+	splits []TransactionSplit,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if len(splits) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	now := r.clock.Now().UTC()
+	for i := range splits {
+		splits[i].AccountId = r.AccountId()
+		splits[i].BankAccountId = bankAccountId
+		splits[i].TransactionId = transactionId
+		splits[i].CreatedAt = now
+	}
+
+	if _, err := r.txn.ModelContext(span.Context(), &splits).Insert(&splits); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create transaction splits")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// replaceTransactionSplits will remove any splits that currently exist for the
+// provided transaction and will store the provided splits instead.
+func (r *repositoryBase) replaceTransactionSplits(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	transactionId ID[Transaction],
+	splits []TransactionSplit,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	_, err := r.txn.ModelContext(span.Context(), &TransactionSplit{}).
+		Where(`"transaction_split"."account_id" = ?`, r.AccountId()).
+		Where(`"transaction_split"."bank_account_id" = ?`, bankAccountId).
+		Where(`"transaction_split"."transaction_id" = ?`, transactionId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove existing transaction splits")
+	}
+
+	if err := r.InsertTransactionSplits(span.Context(), bankAccountId, transactionId, splits); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return err
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// processTransactionSplitsSpentFrom is used by ProcessTransactionSpentFrom when
+// a transaction has been split or is being split. Everything that was deducted
+// for the existing transaction (or its existing splits) is returned to the
+// spending objects it was taken from, and then the amount of each of the new
+// splits is deducted from its spending object. The new splits are stored and
+// the input transaction will no longer have a spending object of its own.
+func (r *repositoryBase) processTransactionSplitsSpentFrom(
+	ctx context.Context,
+	account *Account,
+	bankAccountId ID[BankAccount],
+	input, existing *Transaction,
+) ([]Spending, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if len(input.Splits) > 0 && input.SpendingId != nil {
+		return nil, errors.New("transaction cannot have both splits and a spending object")
+	}
+
+	if err := ValidateTransactionSplits(*input, input.Splits); err != nil {
+		return nil, err
+	}
+
+	// If nothing about the splits has changed then there is nothing to do.
+	if existing.SpendingId == nil && transactionSplitsEqual(input.Splits, existing.Splits) {
+		input.Splits = existing.Splits
+		return nil, nil
+	}
+
+	// Keep track of every spending object we touch, that way if multiple splits
+	// reference the same spending object all of the changes are made to the same
+	// copy of it.
+	order := make([]ID[Spending], 0)
+	spending := map[ID[Spending]]*Spending{}
+	getSpending := func(spendingId ID[Spending]) (*Spending, error) {
+		if item, ok := spending[spendingId]; ok {
+			return item, nil
+		}
+
+		item, err := r.GetSpendingById(span.Context(), bankAccountId, spendingId)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve spending for transaction split")
+		}
+		spending[spendingId] = item
+		order = append(order, spendingId)
+		return item, nil
+	}
+
+	allocations := make([]SpendingAllocation, 0)
+
+	// Return anything the transaction itself was spent from.
+	if existing.SpendingId != nil && existing.SpendingAmount != nil {
+		item, err := getSpending(*existing.SpendingId)
+		if err != nil {
+			return nil, err
+		}
+
+		previous := *existing
+		if err := previous.RemoveSpendingFromTransaction(span.Context(), item, account); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, newTransactionAllocation(
+			item,
+			SpendingAllocationKindRefund,
+			*existing.SpendingAmount,
+			existing.TransactionId,
+			nil,
+		))
+	}
+
+	// Return anything the existing splits were spent from.
+	for _, split := range existing.Splits {
+		if split.SpendingId == nil || split.SpendingAmount == nil {
+			continue
+		}
+
+		item, err := getSpending(*split.SpendingId)
+		if err != nil {
+			return nil, err
+		}
+
+		refunded := *split.SpendingAmount
+		if err := split.RemoveSpendingFromSplit(span.Context(), item, account); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, newTransactionAllocation(
+			item,
+			SpendingAllocationKindRefund,
+			refunded,
+			existing.TransactionId,
+			&split.TransactionSplitId,
+		))
+	}
+
+	input.SpendingId = nil
+	input.SpendingAmount = nil
+
+	// Then deduct the amount for each of the new splits. The split IDs are not
+	// known until the splits are stored, so the index of the split is kept with
+	// each ledger entry until then.
+	splits := make([]TransactionSplit, len(input.Splits))
+	spent := map[int]int{}
+	for i, split := range input.Splits {
+		split.TransactionSplitId = ""
+		split.SpendingAmount = nil
+		if split.SpendingId != nil && split.SpendingId.IsZero() {
+			split.SpendingId = nil
+		}
+
+		if split.SpendingId != nil {
+			item, err := getSpending(*split.SpendingId)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := split.AddSpendingToSplit(span.Context(), item, account); err != nil {
+				return nil, err
+			}
+			spent[len(allocations)] = i
+			allocations = append(allocations, newTransactionAllocation(
+				item,
+				SpendingAllocationKindSpend,
+				-*split.SpendingAmount,
+				input.TransactionId,
+				nil,
+			))
+		}
+
+		splits[i] = split
+	}
+
+	if err := r.replaceTransactionSplits(
+		span.Context(),
+		bankAccountId,
+		input.TransactionId,
+		splits,
+	); err != nil {
+		return nil, err
+	}
+	input.Splits = splits
+	for index, i := range spent {
+		allocations[index].TransactionSplitId = &splits[i].TransactionSplitId
+	}
+
+	expenseUpdates := make([]Spending, 0, len(order))
+	for _, spendingId := range order {
+		expenseUpdates = append(expenseUpdates, *spending[spendingId])
+	}
+
+	if err := r.UpdateSpending(span.Context(), bankAccountId, expenseUpdates); err != nil {
+		return nil, err
+	}
+
+	return expenseUpdates, r.CreateSpendingAllocations(span.Context(), bankAccountId, allocations)
+}
+
+// transactionSplitsEqual returns true if both sets of splits have the same
+// amounts and spending objects in the same order.
+func transactionSplitsEqual(a, b []TransactionSplit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Amount != b[i].Amount {
+			return false
+		}
+
+		var aSpendingId, bSpendingId ID[Spending]
+		if a[i].SpendingId != nil {
+			aSpendingId = *a[i].SpendingId
+		}
+		if b[i].SpendingId != nil {
+			bSpendingId = *b[i].SpendingId
+		}
+		if aSpendingId != bSpendingId {
+			return false
+		}
+	}
+
+	return true
+}
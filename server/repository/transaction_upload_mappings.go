@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+func (r *repositoryBase) GetTransactionUploadMapping(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+) (*TransactionUploadMapping, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	var item TransactionUploadMapping
+	err := r.txn.ModelContext(span.Context(), &item).
+		Where(`"account_id" = ?`, r.AccountId()).
+		Where(`"bank_account_id" = ?`, bankAccountId).
+		Limit(1).
+		Select(&item)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve transaction upload mapping")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &item, nil
+}
+
+func (r *repositoryBase) SaveTransactionUploadMapping(
+	ctx context.Context,
+	bankAccountId ID[BankAccount],
+	mapping *TransactionUploadMapping,
+) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":     r.AccountId(),
+		"bankAccountId": bankAccountId,
+	}
+
+	now := r.clock.Now().UTC()
+	mapping.AccountId = r.AccountId()
+	mapping.BankAccountId = bankAccountId
+	mapping.CreatedAt = now
+	mapping.CreatedBy = r.UserId()
+	mapping.UpdatedAt = now
+
+	// There can only be a single mapping per bank account, if one already exists
+	// then we want to replace its layout but keep the original ID and creation
+	// details.
+	_, err := r.txn.ModelContext(span.Context(), mapping).
+		OnConflict(`("account_id", "bank_account_id") DO UPDATE`).
+		Set(`"fields" = EXCLUDED."fields"`).
+		Set(`"header_row" = EXCLUDED."header_row"`).
+		Set(`"date_format" = EXCLUDED."date_format"`).
+		Set(`"decimal_separator" = EXCLUDED."decimal_separator"`).
+		Set(`"updated_at" = EXCLUDED."updated_at"`).
+		Returning(`*`).
+		Insert(mapping)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to save transaction upload mapping")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
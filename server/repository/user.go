@@ -10,6 +10,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+var (
+	ErrUserNotFound = errors.New("user does not exist")
+)
+
 func (r *repositoryBase) UpdateUser(ctx context.Context, user *User) error {
 	span := crumbs.StartFnTrace(ctx)
 	defer span.Finish()
@@ -45,6 +49,7 @@ func (r *repositoryBase) GetMe(ctx context.Context) (*User, error) {
 		Relation("Login").
 		Relation("Account").
 		Where(`"user"."user_id" = ? AND "user"."account_id" = ?`, r.userId, r.accountId).
+		Where(`"user"."deleted_at" IS NULL`).
 		Limit(1).
 		Select(&user)
 	switch err {
@@ -88,3 +93,134 @@ func (r *repositoryBase) GetAccountOwner(ctx context.Context) (*User, error) {
 
 	return &user, nil
 }
+
+// GetUsers returns every user that is currently a member of the account, along
+// with their login. Users that have been removed are not included.
+func (r *repositoryBase) GetUsers(ctx context.Context) ([]User, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+	}
+
+	result := make([]User, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Relation("Login").
+		Where(`"user"."account_id" = ?`, r.AccountId()).
+		Where(`"user"."deleted_at" IS NULL`).
+		Order(`user_id ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve users")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+// GetUserRole returns the role of the current user. If the user has been
+// removed from the account then ErrUserNotFound is returned.
+func (r *repositoryBase) GetUserRole(ctx context.Context) (UserRole, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"userId":    r.UserId(),
+	}
+
+	var user User
+	err := r.txn.ModelContext(span.Context(), &user).
+		Column("role").
+		Where(`"user"."user_id" = ?`, r.UserId()).
+		Where(`"user"."account_id" = ?`, r.AccountId()).
+		Where(`"user"."deleted_at" IS NULL`).
+		Limit(1).
+		Select(&user)
+	switch err {
+	case pg.ErrNoRows:
+		span.Status = sentry.SpanStatusNotFound
+		return "", errors.WithStack(ErrUserNotFound)
+	case nil:
+		span.Status = sentry.SpanStatusOK
+		return user.Role, nil
+	default:
+		span.Status = sentry.SpanStatusInternalError
+		return "", errors.Wrap(err, "failed to retrieve user role")
+	}
+}
+
+// RemoveUser removes a member from the account. The user is soft deleted so
+// that anything they created is kept, but their API keys are revoked and their
+// notification preferences are removed. The owner of an account cannot be
+// removed.
+func (r *repositoryBase) RemoveUser(ctx context.Context, userId ID[User]) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"userId":    userId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &User{}).
+		Set(`"deleted_at" = ?`, r.clock.Now().UTC()).
+		Where(`"user"."user_id" = ?`, userId).
+		Where(`"user"."account_id" = ?`, r.AccountId()).
+		Where(`"user"."role" = ?`, UserRoleMember).
+		Where(`"user"."deleted_at" IS NULL`).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove user")
+	} else if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrUserNotFound)
+	}
+
+	_, err = r.txn.ModelContext(span.Context(), &APIKey{}).
+		Set(`"is_active" = FALSE`).
+		Where(`"user_id" = ?`, userId).
+		Update()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to revoke API keys for removed user")
+	}
+
+	removed := make([]NotificationPreference, 0)
+	_, err = r.txn.ModelContext(span.Context(), &removed).
+		Where(`"notification_preference"."account_id" = ?`, r.AccountId()).
+		Where(`"notification_preference"."user_id" = ?`, userId).
+		Returning(`"webhook_secret_id"`).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove notification preferences for removed user")
+	}
+
+	// The webhook secrets of the removed preferences are not used by anything
+	// else, so remove them as well.
+	secretIds := make([]ID[Secret], 0, len(removed))
+	for _, preference := range removed {
+		if preference.WebhookSecretId != nil {
+			secretIds = append(secretIds, *preference.WebhookSecretId)
+		}
+	}
+	if len(secretIds) > 0 {
+		_, err = r.txn.ModelContext(span.Context(), &Secret{}).
+			Where(`"secret"."account_id" = ?`, r.AccountId()).
+			WhereIn(`"secret"."secret_id" IN (?)`, secretIds).
+			Delete()
+		if err != nil {
+			span.Status = sentry.SpanStatusInternalError
+			return errors.Wrap(err, "failed to remove webhook secrets for removed user")
+		}
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
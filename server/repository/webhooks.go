@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook does not exist")
+)
+
+func (r *repositoryBase) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+	}
+
+	result := make([]Webhook, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"webhook"."account_id" = ?`, r.AccountId()).
+		Order(`webhook_id ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve webhooks")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+func (r *repositoryBase) GetWebhook(ctx context.Context, webhookId ID[Webhook]) (*Webhook, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"webhookId": webhookId,
+	}
+
+	var result Webhook
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"webhook"."account_id" = ?`, r.AccountId()).
+		Where(`"webhook"."webhook_id" = ?`, webhookId).
+		Limit(1).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve webhook")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return &result, nil
+}
+
+// GetWebhooksForEvent returns the enabled webhooks of the account that are
+// subscribed to the provided type of event.
+func (r *repositoryBase) GetWebhooksForEvent(ctx context.Context, eventType WebhookEventType) ([]Webhook, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"eventType": eventType,
+	}
+
+	result := make([]Webhook, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"webhook"."account_id" = ?`, r.AccountId()).
+		Where(`"webhook"."is_enabled" = true`).
+		Where(`? = ANY("webhook"."event_types")`, eventType).
+		Order(`webhook_id ASC`).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve webhooks for event")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+// CreateWebhook stores a new webhook, the secret for the webhook must already
+// have been stored.
+func (r *repositoryBase) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+	}
+
+	now := r.clock.Now().UTC()
+	webhook.AccountId = r.AccountId()
+	webhook.CreatedBy = r.UserId()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	if _, err := r.txn.ModelContext(span.Context(), webhook).Insert(webhook); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create webhook")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// UpdateWebhook requires that the entire webhook is provided, including the
+// creation details and the secret.
+func (r *repositoryBase) UpdateWebhook(ctx context.Context, webhook *Webhook) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"webhookId": webhook.WebhookId,
+	}
+
+	webhook.AccountId = r.AccountId()
+	webhook.UpdatedAt = r.clock.Now().UTC()
+
+	result, err := r.txn.ModelContext(span.Context(), webhook).
+		WherePK().
+		Update(webhook)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update webhook")
+	} else if result.RowsAffected() != 1 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrWebhookNotFound)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// DeleteWebhook removes the webhook and its delivery log. The secret for the
+// webhook is not removed, it must be removed separately afterwards.
+func (r *repositoryBase) DeleteWebhook(ctx context.Context, webhookId ID[Webhook]) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"webhookId": webhookId,
+	}
+
+	result, err := r.txn.ModelContext(span.Context(), &Webhook{}).
+		Where(`"webhook"."account_id" = ?`, r.AccountId()).
+		Where(`"webhook"."webhook_id" = ?`, webhookId).
+		Delete()
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to remove webhook")
+	} else if result.RowsAffected() == 0 {
+		span.Status = sentry.SpanStatusNotFound
+		return errors.WithStack(ErrWebhookNotFound)
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+func (r *repositoryBase) CreateWebhookDeliveries(ctx context.Context, deliveries []WebhookDelivery) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if len(deliveries) == 0 {
+		span.Status = sentry.SpanStatusOK
+		return nil
+	}
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"count":     len(deliveries),
+	}
+
+	now := r.clock.Now().UTC()
+	for i := range deliveries {
+		deliveries[i].AccountId = r.AccountId()
+		deliveries[i].CreatedAt = now
+	}
+
+	if _, err := r.txn.ModelContext(span.Context(), &deliveries).Insert(&deliveries); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to create webhook deliveries")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the delivery log for the webhook, newest first.
+func (r *repositoryBase) GetWebhookDeliveries(
+	ctx context.Context,
+	webhookId ID[Webhook],
+	limit, offset int,
+) ([]WebhookDelivery, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"webhookId": webhookId,
+		"limit":     limit,
+		"offset":    offset,
+	}
+
+	result := make([]WebhookDelivery, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Where(`"webhook_delivery"."account_id" = ?`, r.AccountId()).
+		Where(`"webhook_delivery"."webhook_id" = ?`, webhookId).
+		Order(`created_at DESC`).
+		Order(`webhook_delivery_id DESC`).
+		Limit(limit).
+		Offset(offset).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve webhook deliveries")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+// GetDueWebhookDeliveries returns the pending deliveries whose next attempt is
+// at or before the provided time, along with their webhooks. Oldest first.
+func (r *repositoryBase) GetDueWebhookDeliveries(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]WebhookDelivery, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId": r.AccountId(),
+		"limit":     limit,
+	}
+
+	result := make([]WebhookDelivery, 0)
+	err := r.txn.ModelContext(span.Context(), &result).
+		Relation("Webhook").
+		Where(`"webhook_delivery"."account_id" = ?`, r.AccountId()).
+		Where(`"webhook_delivery"."status" = ?`, WebhookDeliveryStatusPending).
+		Where(`"webhook_delivery"."next_attempt_at" <= ?`, now.UTC()).
+		Order(`webhook_delivery.created_at ASC`).
+		Limit(limit).
+		Select(&result)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to retrieve due webhook deliveries")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result, nil
+}
+
+// StartWebhookDeliveryAttempt records an attempt to deliver a webhook before
+// the attempt is made. The update only happens if the delivery has not been
+// attempted since it was retrieved, false is returned if another job got to it
+// first.
+func (r *repositoryBase) StartWebhookDeliveryAttempt(
+	ctx context.Context,
+	delivery *WebhookDelivery,
+	previousAttempts int,
+) (bool, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":         r.AccountId(),
+		"webhookDeliveryId": delivery.WebhookDeliveryId,
+	}
+
+	delivery.AccountId = r.AccountId()
+	result, err := r.txn.ModelContext(span.Context(), delivery).
+		Column(
+			"status",
+			"attempts",
+			"error",
+			"next_attempt_at",
+			"last_attempt_at",
+		).
+		WherePK().
+		Where(`"webhook_delivery"."attempts" = ?`, previousAttempts).
+		Update(delivery)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return false, errors.Wrap(err, "failed to start webhook delivery attempt")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return result.RowsAffected() > 0, nil
+}
+
+// UpdateWebhookDelivery stores the result of an attempt to deliver a webhook.
+func (r *repositoryBase) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	span.Data = map[string]interface{}{
+		"accountId":         r.AccountId(),
+		"webhookDeliveryId": delivery.WebhookDeliveryId,
+	}
+
+	delivery.AccountId = r.AccountId()
+	_, err := r.txn.ModelContext(span.Context(), delivery).
+		Column(
+			"status",
+			"attempts",
+			"response_status",
+			"error",
+			"next_attempt_at",
+			"last_attempt_at",
+			"delivered_at",
+		).
+		WherePK().
+		Update(delivery)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to update webhook delivery")
+	}
+
+	span.Status = sentry.SpanStatusOK
+
+	return nil
+}
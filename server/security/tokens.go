@@ -3,6 +3,7 @@ package security
 import (
 	"crypto/ed25519"
 	"fmt"
+	"slices"
 	"time"
 
 	"aidanwoods.dev/go-paseto"
@@ -19,8 +20,52 @@ const (
 	MultiFactorScope   Scope = "multiFactor"
 	ResetPasswordScope Scope = "resetPassword"
 	VerifyEmailScope   Scope = "verifyEmail"
+	// InviteScope is used for tokens that are emailed to someone who has been
+	// invited to join an account. The claims include the invitation and the
+	// account that they were invited to.
+	InviteScope Scope = "invite"
+	// APIKeyScope is used for claims that were derived from an API key rather
+	// than from a login. These claims only have access to the grants that were
+	// specified when the API key was created.
+	APIKeyScope Scope = "apiKey"
 )
 
+// These scopes can be granted to an API key. A normally authenticated login
+// implicitly has all of these scopes.
+const (
+	ReadBankAccountsScope      Scope = "bankAccounts:read"
+	WriteBankAccountsScope     Scope = "bankAccounts:write"
+	ReadTransactionsScope      Scope = "transactions:read"
+	WriteTransactionsScope     Scope = "transactions:write"
+	ReadSpendingScope          Scope = "spending:read"
+	WriteSpendingScope         Scope = "spending:write"
+	ReadFundingSchedulesScope  Scope = "fundingSchedules:read"
+	WriteFundingSchedulesScope Scope = "fundingSchedules:write"
+	ReadLinksScope             Scope = "links:read"
+	WriteLinksScope            Scope = "links:write"
+	TriggerSyncScope           Scope = "sync:trigger"
+)
+
+// GrantScopes is every scope that can be granted to an API key.
+var GrantScopes = []Scope{
+	ReadBankAccountsScope,
+	WriteBankAccountsScope,
+	ReadTransactionsScope,
+	WriteTransactionsScope,
+	ReadSpendingScope,
+	WriteSpendingScope,
+	ReadFundingSchedulesScope,
+	WriteFundingSchedulesScope,
+	ReadLinksScope,
+	WriteLinksScope,
+	TriggerSyncScope,
+}
+
+// IsGrant returns true if the scope is one that can be granted to an API key.
+func (s Scope) IsGrant() bool {
+	return slices.Contains(GrantScopes, s)
+}
+
 type Claims struct {
 	// CreatedAt represents the timestamp the token was created, if this field is
 	// provided by a caller it will be overwritten.
@@ -35,11 +80,24 @@ type Claims struct {
 	// they are using the application frequently. Tokens will not be reissued if
 	// they have expired.
 	ReissueCount uint8 `json:"reissueCount,string"`
+	// APIKeyId is only present when the claims were derived from an API key.
+	APIKeyId string `json:"apiKeyId,omitempty"`
+	// Grants are the scopes that an API key has been granted. This is only used
+	// when the claim's scope is the APIKeyScope.
+	Grants []Scope `json:"grants,omitempty"`
+	// BankAccountIds optionally restricts an API key to only the specified bank
+	// accounts. If this is empty then the API key can access all of the bank
+	// accounts for the account.
+	BankAccountIds []string `json:"bankAccountIds,omitempty"`
+	// InvitationId is only present on claims with the InviteScope.
+	InvitationId string `json:"invitationId,omitempty"`
 }
 
 // RequireScope takes an array of allowed scopes. If the claim is any one of the
 // specified scopes then this function will return nil. If the claim does not
-// contain any of the specified scopes then this will return an error.
+// contain any of the specified scopes then this will return an error. Claims
+// for an API key will also satisfy any of the scopes the key was granted, and
+// a normally authenticated claim satisfies every grant scope.
 func (c Claims) RequireScope(scopes ...Scope) error {
 	if c.Scope == "" {
 		return errors.New("authentication is missing scope")
@@ -52,11 +110,48 @@ func (c Claims) RequireScope(scopes ...Scope) error {
 		if scope == c.Scope {
 			return nil
 		}
+
+		if !scope.IsGrant() {
+			continue
+		}
+
+		switch c.Scope {
+		case AuthenticatedScope:
+			return nil
+		case APIKeyScope:
+			if slices.Contains(c.Grants, scope) {
+				return nil
+			}
+		}
+	}
+
+	if c.Scope == APIKeyScope {
+		return errors.Errorf("api key does not have required scope; has: %v required: %v", c.Grants, scopes)
 	}
 
 	return errors.Errorf("authentication does not have required scope; has: [%s] required: %v", c.Scope, scopes)
 }
 
+// IsBankAccountRestricted returns true if the claims are only permitted to
+// access specific bank accounts.
+func (c Claims) IsBankAccountRestricted() bool {
+	return len(c.BankAccountIds) > 0
+}
+
+// RequireBankAccount will return an error if the claims are restricted to
+// specific bank accounts and the provided bank account is not one of them.
+func (c Claims) RequireBankAccount(bankAccountId string) error {
+	if !c.IsBankAccountRestricted() {
+		return nil
+	}
+
+	if slices.Contains(c.BankAccountIds, bankAccountId) {
+		return nil
+	}
+
+	return errors.Errorf("authentication does not have access to bank account: %s", bankAccountId)
+}
+
 func (c Claims) Valid() error {
 	if c.CreatedAt.IsZero() {
 		return errors.New("claims invalid: created at is zero")
@@ -70,7 +165,8 @@ func (c Claims) Valid() error {
 		return errors.New("claims invalid: scope is not defined")
 	}
 
-	if c.Scope == AuthenticatedScope {
+	switch c.Scope {
+	case AuthenticatedScope, APIKeyScope:
 		if c.AccountId == "" {
 			return errors.New("claims invalid: account Id is not defined")
 		}
@@ -129,4 +129,54 @@ func TestClaimsRequiredScope(t *testing.T) {
 		assert.EqualError(t, claims.RequireScope(security.VerifyEmailScope), "authentication does not have required scope; has: [authenticated] required: [verifyEmail]")
 		assert.EqualError(t, claims.RequireScope(security.VerifyEmailScope, security.ResetPasswordScope), "authentication does not have required scope; has: [authenticated] required: [verifyEmail resetPassword]")
 	})
+	t.Run("authenticated has every grant", func(t *testing.T) {
+		claims := security.Claims{
+			Scope:     security.AuthenticatedScope,
+			UserId:    "user_1",
+			AccountId: "acct_2",
+			LoginId:   "lgn_3",
+		}
+		for _, scope := range security.GrantScopes {
+			assert.NoError(t, claims.RequireScope(scope), "authenticated claims should have every grant scope")
+		}
+		assert.EqualError(t, claims.RequireScope(security.APIKeyScope), "authentication does not have required scope; has: [authenticated] required: [apiKey]")
+	})
+
+	t.Run("api key grants", func(t *testing.T) {
+		claims := security.Claims{
+			Scope:     security.APIKeyScope,
+			UserId:    "user_1",
+			AccountId: "acct_2",
+			LoginId:   "lgn_3",
+			APIKeyId:  "1",
+			Grants: []security.Scope{
+				security.ReadTransactionsScope,
+			},
+		}
+		assert.NoError(t, claims.RequireScope(security.AuthenticatedScope, security.APIKeyScope), "api key claims should satisfy the api key scope")
+		assert.NoError(t, claims.RequireScope(security.ReadTransactionsScope), "api key should have the scope it was granted")
+		assert.NoError(t, claims.RequireScope(security.ReadTransactionsScope, security.WriteTransactionsScope), "api key should have one of the scopes")
+		assert.EqualError(t, claims.RequireScope(security.WriteSpendingScope), "api key does not have required scope; has: [transactions:read] required: [spending:write]")
+		assert.EqualError(t, claims.RequireScope(security.AuthenticatedScope), "api key does not have required scope; has: [transactions:read] required: [authenticated]")
+	})
+}
+
+func TestClaimsRequireBankAccount(t *testing.T) {
+	t.Run("unrestricted", func(t *testing.T) {
+		claims := security.Claims{
+			Scope: security.APIKeyScope,
+		}
+		assert.False(t, claims.IsBankAccountRestricted(), "claims should not be restricted")
+		assert.NoError(t, claims.RequireBankAccount("bac_1"), "unrestricted claims can access any bank account")
+	})
+
+	t.Run("restricted", func(t *testing.T) {
+		claims := security.Claims{
+			Scope:          security.APIKeyScope,
+			BankAccountIds: []string{"bac_1"},
+		}
+		assert.True(t, claims.IsBankAccountRestricted(), "claims should be restricted")
+		assert.NoError(t, claims.RequireBankAccount("bac_1"), "should be able to access the allowed bank account")
+		assert.EqualError(t, claims.RequireBankAccount("bac_2"), "authentication does not have access to bank account: bac_2")
+	})
 }
@@ -0,0 +1,152 @@
+package simplefin
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/currency"
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// DefaultCurrency is used when a SimpleFIN account uses a custom currency,
+// these are identified by a URL rather than an ISO 4217 code.
+const DefaultCurrency = "USD"
+
+var (
+	currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// AccountSet is the response from the /accounts endpoint of a SimpleFIN
+// server.
+type AccountSet struct {
+	// Errors are messages that the SimpleFIN server wants shown to the user,
+	// usually because one of the institutions needs attention.
+	Errors   []string  `json:"errors"`
+	Accounts []Account `json:"accounts"`
+}
+
+type Organization struct {
+	Domain  string `json:"domain"`
+	SFINURL string `json:"sfin-url"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Id      string `json:"id"`
+}
+
+type Account struct {
+	Org              Organization  `json:"org"`
+	Id               string        `json:"id"`
+	Name             string        `json:"name"`
+	Currency         string        `json:"currency"`
+	Balance          string        `json:"balance"`
+	AvailableBalance *string       `json:"available-balance"`
+	BalanceDate      int64         `json:"balance-date"`
+	Transactions     []Transaction `json:"transactions"`
+}
+
+// GetCurrency returns the ISO 4217 currency code of the account, custom
+// currencies are not supported by monetr and will use the default currency.
+func (a Account) GetCurrency() string {
+	code := strings.ToUpper(strings.TrimSpace(a.Currency))
+	if !currencyCodePattern.MatchString(code) {
+		return DefaultCurrency
+	}
+
+	return code
+}
+
+// GetInstitutionName returns the name of the institution the account belongs
+// to, falling back to the domain if the server did not provide a name.
+func (a Account) GetInstitutionName() string {
+	if name := strings.TrimSpace(a.Org.Name); name != "" {
+		return name
+	}
+
+	return strings.TrimSpace(a.Org.Domain)
+}
+
+// GetBalance returns the balance of the account in the smallest unit of the
+// account's currency.
+func (a Account) GetBalance() (int64, error) {
+	amount, err := currency.ParseFriendlyToAmount(a.Balance, a.GetCurrency())
+	return amount, errors.Wrap(err, "failed to parse balance")
+}
+
+// GetAvailableBalance returns the available balance of the account, if the
+// server did not provide one then the balance is used instead.
+func (a Account) GetAvailableBalance() (int64, error) {
+	if a.AvailableBalance == nil || strings.TrimSpace(*a.AvailableBalance) == "" {
+		return a.GetBalance()
+	}
+
+	amount, err := currency.ParseFriendlyToAmount(*a.AvailableBalance, a.GetCurrency())
+	return amount, errors.Wrap(err, "failed to parse available balance")
+}
+
+func (a Account) GetBalanceDate() time.Time {
+	return time.Unix(a.BalanceDate, 0).UTC()
+}
+
+type Transaction struct {
+	Id string `json:"id"`
+	// Posted is the unix timestamp the transaction was posted, this will be 0
+	// for pending transactions.
+	Posted      int64  `json:"posted"`
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+	Payee       string `json:"payee,omitempty"`
+	Memo        string `json:"memo,omitempty"`
+	// TransactedAt is the unix timestamp of when the transaction actually
+	// happened, if the server provides it.
+	TransactedAt *int64 `json:"transacted_at,omitempty"`
+	Pending      bool   `json:"pending,omitempty"`
+}
+
+// GetAmount returns the amount of the transaction in the smallest unit of the
+// account's currency using monetr's convention; debits are positive and
+// credits are negative. SimpleFIN amounts are always negative when money
+// leaves the account, so every amount is inverted.
+func (t Transaction) GetAmount(account Account) (int64, error) {
+	amount, err := currency.ParseFriendlyToAmount(t.Amount, account.GetCurrency())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse transaction amount")
+	}
+
+	return -amount, nil
+}
+
+func (t Transaction) GetIsPending() bool {
+	return t.Pending || t.Posted == 0
+}
+
+// GetTimestamp returns when the transaction was posted. For pending
+// transactions the time it was transacted is used instead. If neither is
+// available then the provided fallback is returned.
+func (t Transaction) GetTimestamp(fallback time.Time) time.Time {
+	switch {
+	case t.Posted > 0:
+		return time.Unix(t.Posted, 0).UTC()
+	case t.TransactedAt != nil && *t.TransactedAt > 0:
+		return time.Unix(*t.TransactedAt, 0).UTC()
+	default:
+		return fallback
+	}
+}
+
+// GetDateLocal returns the date of the transaction at midnight in the provided
+// timezone.
+func (t Transaction) GetDateLocal(timezone *time.Location, fallback time.Time) time.Time {
+	return util.Midnight(t.GetTimestamp(fallback), timezone)
+}
+
+// GetName returns the payee of the transaction if the server provided one,
+// otherwise the description is used.
+func (t Transaction) GetName() string {
+	if payee := strings.TrimSpace(t.Payee); payee != "" {
+		return payee
+	}
+
+	return strings.TrimSpace(t.Description)
+}
@@ -0,0 +1,114 @@
+package simplefin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction_GetAmount(t *testing.T) {
+	t.Run("debit", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "-12.34",
+		}
+		amount, err := transaction.GetAmount(Account{
+			Currency: "USD",
+		})
+		assert.NoError(t, err, "must be able to parse amount")
+		assert.EqualValues(t, 1234, amount, "money leaving the account should be positive")
+	})
+
+	t.Run("credit", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "100.00",
+		}
+		amount, err := transaction.GetAmount(Account{
+			Currency: "USD",
+		})
+		assert.NoError(t, err, "must be able to parse amount")
+		assert.EqualValues(t, -10000, amount, "deposits should be negative")
+	})
+
+	t.Run("custom currency", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "-5.00",
+		}
+		amount, err := transaction.GetAmount(Account{
+			Currency: "https://www.example.com/flight-miles",
+		})
+		assert.NoError(t, err, "must be able to parse amount")
+		assert.EqualValues(t, 500, amount, "custom currencies should use the default currency")
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "twelve",
+		}
+		_, err := transaction.GetAmount(Account{
+			Currency: "USD",
+		})
+		assert.Error(t, err, "should fail to parse an invalid amount")
+	})
+}
+
+func TestTransaction_GetDateLocal(t *testing.T) {
+	central, err := time.LoadLocation("America/Chicago")
+	assert.NoError(t, err, "must be able to load timezone")
+	fallback := time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("posted", func(t *testing.T) {
+		transaction := Transaction{
+			Posted: time.Date(2024, 03, 05, 18, 0, 0, 0, time.UTC).Unix(),
+		}
+		assert.False(t, transaction.GetIsPending(), "posted transaction should not be pending")
+		assert.Equal(t,
+			time.Date(2024, 03, 05, 0, 0, 0, 0, central),
+			transaction.GetDateLocal(central, fallback),
+		)
+	})
+
+	t.Run("pending uses transacted at", func(t *testing.T) {
+		transactedAt := time.Date(2024, 03, 8, 18, 0, 0, 0, time.UTC).Unix()
+		transaction := Transaction{
+			Posted:       0,
+			TransactedAt: &transactedAt,
+		}
+		assert.True(t, transaction.GetIsPending(), "transaction without a posted date should be pending")
+		assert.Equal(t,
+			time.Date(2024, 03, 8, 0, 0, 0, 0, central),
+			transaction.GetDateLocal(central, fallback),
+		)
+	})
+
+	t.Run("no dates uses fallback", func(t *testing.T) {
+		transaction := Transaction{}
+		assert.Equal(t,
+			time.Date(2024, 03, 10, 0, 0, 0, 0, central),
+			transaction.GetDateLocal(central, fallback),
+		)
+	})
+}
+
+func TestAccount_GetAvailableBalance(t *testing.T) {
+	t.Run("falls back to balance", func(t *testing.T) {
+		account := Account{
+			Currency: "USD",
+			Balance:  "150.25",
+		}
+		available, err := account.GetAvailableBalance()
+		assert.NoError(t, err, "must be able to parse balance")
+		assert.EqualValues(t, 15025, available)
+	})
+}
+
+func TestAccount_GetInstitutionName(t *testing.T) {
+	t.Run("falls back to domain", func(t *testing.T) {
+		account := Account{
+			Org: Organization{
+				Domain: "mybank.test",
+			},
+		}
+		assert.Equal(t, "mybank.test", account.GetInstitutionName())
+	})
+}
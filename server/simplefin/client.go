@@ -0,0 +1,122 @@
+package simplefin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	Client interface {
+		// GetAccounts returns all of the accounts available to the access URL
+		// along with their balances and, unless BalancesOnly is specified, their
+		// transactions within the requested date range.
+		GetAccounts(ctx context.Context, options GetAccountsOptions) (*AccountSet, error)
+	}
+
+	GetAccountsOptions struct {
+		// StartDate limits transactions to those posted on or after this time.
+		StartDate *time.Time
+		// EndDate limits transactions to those posted before this time.
+		EndDate *time.Time
+		// Pending will include pending transactions in the response, SimpleFIN
+		// servers are not required to support this.
+		Pending bool
+		// BalancesOnly will omit transactions entirely.
+		BalancesOnly bool
+	}
+)
+
+var (
+	_ Client = &SimpleFINClient{}
+)
+
+type SimpleFINClient struct {
+	baseURL     string
+	credentials *url.Userinfo
+	client      *http.Client
+	log         *logrus.Entry
+}
+
+func (s *SimpleFINClient) GetAccounts(
+	ctx context.Context,
+	options GetAccountsOptions,
+) (*AccountSet, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	query := url.Values{}
+	if options.StartDate != nil {
+		query.Set("start-date", strconv.FormatInt(options.StartDate.Unix(), 10))
+	}
+	if options.EndDate != nil {
+		query.Set("end-date", strconv.FormatInt(options.EndDate.Unix(), 10))
+	}
+	if options.Pending {
+		query.Set("pending", "1")
+	}
+	if options.BalancesOnly {
+		query.Set("balances-only", "1")
+	}
+
+	requestUrl := s.baseURL + "/accounts"
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(span.Context(), http.MethodGet, requestUrl, nil)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to build SimpleFIN request")
+	}
+	password, _ := s.credentials.Password()
+	request.SetBasicAuth(s.credentials.Username(), password)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to send SimpleFIN request")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		span.Status = sentry.SpanStatusInternalError
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+		simpleFINError := &Error{
+			StatusCode: response.StatusCode,
+			Message:    strings.TrimSpace(string(body)),
+		}
+		s.log.WithContext(span.Context()).
+			WithError(simpleFINError).
+			Warn("SimpleFIN API call failed")
+		return nil, errors.WithStack(simpleFINError)
+	}
+
+	var result AccountSet
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return nil, errors.Wrap(err, "failed to decode SimpleFIN response")
+	}
+
+	// Errors in the body are meant to be shown to the user, they do not mean
+	// that the request failed.
+	if len(result.Errors) > 0 {
+		s.log.WithContext(span.Context()).
+			WithField("errors", result.Errors).
+			Warn("SimpleFIN server returned errors with accounts")
+	}
+
+	span.Status = sentry.SpanStatusOK
+	return &result, nil
+}
@@ -0,0 +1,44 @@
+package simplefin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ error = &Error{}
+)
+
+// Error is returned when a SimpleFIN server responds with an unsuccessful
+// status code. SimpleFIN does not define an error body so the message is
+// whatever text the server included.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(
+		"simplefin API call failed with [%d] %s",
+		e.StatusCode, e.Message,
+	)
+}
+
+// IsAccessRevoked returns true if the error indicates that the access URL can
+// no longer be used. SimpleFIN servers respond with a 403 when the user has
+// revoked access, and with a 402 when the user's subscription has lapsed.
+func IsAccessRevoked(err error) bool {
+	simpleFINError, ok := errors.Cause(err).(*Error)
+	if !ok {
+		return false
+	}
+
+	switch simpleFINError.StatusCode {
+	case http.StatusPaymentRequired, http.StatusForbidden:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,263 @@
+package simplefin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/round"
+	"github.com/monetr/monetr/server/util"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	SimpleFIN interface {
+		// ClaimSetupToken exchanges a setup token provided by the user for an
+		// access URL. Setup tokens can only be claimed once, the access URL that is
+		// returned includes the credentials needed to retrieve data and must be
+		// stored securely.
+		ClaimSetupToken(ctx context.Context, setupToken string) (string, error)
+		// NewClient returns a client for a single SimpleFIN connection using the
+		// provided access URL. The link may be nil when the connection has not
+		// been persisted yet, like when the setup token is first being claimed.
+		NewClient(ctx context.Context, link *models.Link, accessUrl string) (Client, error)
+	}
+)
+
+var (
+	_ SimpleFIN = &SimpleFINAPI{}
+)
+
+type SimpleFINAPI struct {
+	client *http.Client
+	log    *logrus.Entry
+	config config.SimpleFIN
+}
+
+func NewSimpleFIN(
+	log *logrus.Entry,
+	options config.SimpleFIN,
+) *SimpleFINAPI {
+	httpClient := &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: round.NewObservabilityRoundTripper(newTransport(),
+			func(
+				ctx context.Context,
+				request *http.Request,
+				response *http.Response,
+				err error,
+			) {
+				// The access URL includes credentials, but they are moved into the
+				// authorization header before the request is sent. So the URL here is
+				// safe to log.
+				requestLog := log.WithContext(ctx).WithFields(logrus.Fields{
+					"simplefin_method": request.Method,
+					"simplefin_url":    request.URL.String(),
+				})
+				var statusCode int
+				if response != nil {
+					statusCode = response.StatusCode
+					requestLog = requestLog.WithField("simplefin_statusCode", statusCode)
+				}
+
+				crumbs.HTTP(ctx,
+					"SimpleFIN API Call",
+					"simplefin",
+					request.URL.String(),
+					request.Method,
+					statusCode,
+					nil,
+				)
+				requestLog.Debug("SimpleFIN API call")
+			}),
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if request.URL.Scheme != "https" {
+				return errors.New("SimpleFIN redirected to a URL that is not https")
+			}
+			if len(via) >= 5 {
+				return errors.New("SimpleFIN redirected too many times")
+			}
+			return nil
+		},
+	}
+
+	return &SimpleFINAPI{
+		client: httpClient,
+		log:    log,
+		config: options,
+	}
+}
+
+// newTransport returns the transport used for SimpleFIN requests. The setup
+// token and the access URL both come from the user, so connections are only
+// made to public addresses the same way webhooks are delivered. If the default
+// transport has been replaced, like when requests are mocked, then it is used
+// as is.
+func newTransport() http.RoundTripper {
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+
+	transport := base.Clone()
+	transport.Proxy = nil
+	transport.DialContext = webhooks.NewPublicDialer().DialContext
+	return transport
+}
+
+func (s *SimpleFINAPI) ClaimSetupToken(ctx context.Context, setupToken string) (string, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	claimUrl, err := ParseSetupToken(setupToken)
+	if err != nil {
+		return "", err
+	}
+
+	// The claim URL is provided by the user, the only thing it should ever be
+	// used for is this single POST.
+	request, err := http.NewRequestWithContext(
+		span.Context(),
+		http.MethodPost,
+		claimUrl.String(),
+		bytes.NewReader(nil),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build SimpleFIN claim request")
+	}
+	request.Header.Set("Content-Length", "0")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to claim SimpleFIN setup token")
+	}
+	defer response.Body.Close()
+
+	// Limit how much we will read, the body should only ever be a single URL.
+	body, err := io.ReadAll(io.LimitReader(response.Body, 4096))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read SimpleFIN claim response")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.WithStack(&Error{
+			StatusCode: response.StatusCode,
+			Message:    strings.TrimSpace(string(body)),
+		})
+	}
+
+	accessUrl := strings.TrimSpace(string(body))
+	if _, _, err := ParseAccessURL(accessUrl); err != nil {
+		return "", errors.Wrap(err, "SimpleFIN returned an invalid access URL")
+	}
+
+	return accessUrl, nil
+}
+
+func (s *SimpleFINAPI) NewClient(ctx context.Context, link *models.Link, accessUrl string) (Client, error) {
+	baseUrl, credentials, err := ParseAccessURL(accessUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	log := s.log
+	if link != nil {
+		log = log.WithFields(logrus.Fields{
+			"accountId": link.AccountId,
+			"linkId":    link.LinkId,
+		})
+	}
+
+	return &SimpleFINClient{
+		baseURL:     strings.TrimSuffix(baseUrl.String(), "/"),
+		credentials: credentials,
+		client:      s.client,
+		log:         log,
+	}, nil
+}
+
+// ParseSetupToken decodes the setup token the user copied from their SimpleFIN
+// server. The token is just the base64 encoded claim URL.
+func ParseSetupToken(setupToken string) (*url.URL, error) {
+	setupToken = strings.TrimSpace(setupToken)
+	if setupToken == "" {
+		return nil, errors.New("setup token is required")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(setupToken)
+	if err != nil {
+		// Some servers will provide the token without padding.
+		decoded, err = base64.RawStdEncoding.DecodeString(setupToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "setup token is not valid base64")
+		}
+	}
+
+	claimUrl, err := url.Parse(strings.TrimSpace(string(decoded)))
+	if err != nil {
+		return nil, errors.Wrap(err, "setup token does not contain a valid URL")
+	}
+
+	if claimUrl.Scheme != "https" || claimUrl.Host == "" {
+		return nil, errors.New("setup token must contain an https URL")
+	}
+
+	if !isPublicHost(claimUrl.Hostname()) {
+		return nil, errors.New("setup token must not point to a private address")
+	}
+
+	return claimUrl, nil
+}
+
+// ParseAccessURL splits the access URL into the base URL for requests and the
+// basic auth credentials that are embedded in it.
+func ParseAccessURL(accessUrl string) (*url.URL, *url.Userinfo, error) {
+	parsed, err := url.Parse(strings.TrimSpace(accessUrl))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse SimpleFIN access URL")
+	}
+
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, nil, errors.New("SimpleFIN access URL must be an https URL")
+	}
+
+	if !isPublicHost(parsed.Hostname()) {
+		return nil, nil, errors.New("SimpleFIN access URL must not point to a private address")
+	}
+
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return nil, nil, errors.New("SimpleFIN access URL does not include credentials")
+	}
+
+	credentials := parsed.User
+	parsed.User = nil
+
+	return parsed, credentials, nil
+}
+
+// isPublicHost rejects hostnames that are obviously not on the public internet
+// so the user gets a clear error up front. Hostnames are still resolved and
+// checked again when the connection is dialed.
+func isPublicHost(hostname string) bool {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+	if hostname == "localhost" || strings.HasSuffix(hostname, ".localhost") {
+		return false
+	}
+
+	if ip, err := netip.ParseAddr(hostname); err == nil {
+		return util.IsPublicAddress(ip)
+	}
+
+	return true
+}
@@ -73,6 +73,8 @@ const (
 	TextCSVContentType      ContentType = "text/csv"
 	OpenXMLExcelContentType ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 	IntuitQFXContentType    ContentType = "application/vnd.intu.QFX"
+	QIFContentType          ContentType = "application/qif"
+	XMLContentType          ContentType = "application/xml"
 )
 
 var (
@@ -80,6 +82,8 @@ var (
 		TextCSVContentType:      "csv",
 		OpenXMLExcelContentType: "xlsx",
 		IntuitQFXContentType:    "qfx",
+		QIFContentType:          "qif",
+		XMLContentType:          "xml",
 	}
 )
 
@@ -0,0 +1,110 @@
+package teller
+
+import (
+	"github.com/monetr/monetr/server/currency"
+	"github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+type AccountType string
+
+const (
+	AccountTypeDepository AccountType = "depository"
+	AccountTypeCredit     AccountType = "credit"
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusOpen   AccountStatus = "open"
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+type Institution struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Account struct {
+	Id           string        `json:"id"`
+	EnrollmentId string        `json:"enrollment_id"`
+	Currency     string        `json:"currency"`
+	Institution  Institution   `json:"institution"`
+	LastFour     string        `json:"last_four"`
+	Name         string        `json:"name"`
+	Type         AccountType   `json:"type"`
+	SubType      string        `json:"subtype"`
+	Status       AccountStatus `json:"status"`
+}
+
+// GetBankAccountType returns the monetr bank account type for the Teller
+// account type.
+func (a Account) GetBankAccountType() models.BankAccountType {
+	return models.ParseBankAccountType(string(a.Type))
+}
+
+// GetBankAccountSubType returns the monetr bank account sub type for the
+// Teller account sub type. Teller uses snake case for sub types where monetr
+// (and Plaid) use spaces.
+func (a Account) GetBankAccountSubType() models.BankAccountSubType {
+	switch a.SubType {
+	case "credit_card":
+		return models.CreditCardBankAccountSubType
+	case "money_market":
+		return models.MoneyMarketBankAccountSubType
+	case "certificate_of_deposit":
+		return models.CDBankAccountSubType
+	default:
+		return models.ParseBankAccountSubType(a.SubType)
+	}
+}
+
+// GetBankAccountStatus returns the monetr bank account status for the Teller
+// account status.
+func (a Account) GetBankAccountStatus() models.BankAccountStatus {
+	switch a.Status {
+	case AccountStatusOpen:
+		return models.ActiveBankAccountStatus
+	case AccountStatusClosed:
+		return models.InactiveBankAccountStatus
+	default:
+		return models.UnknownBankAccountStatus
+	}
+}
+
+// Balance is the live balance of an account, amounts are decimal strings in the
+// currency of the account. Either balance may be missing depending on what the
+// institution provides.
+type Balance struct {
+	AccountId string  `json:"account_id"`
+	Available *string `json:"available"`
+	Ledger    *string `json:"ledger"`
+}
+
+// GetAvailable returns the available balance in the smallest unit of the
+// provided currency. If the institution did not provide an available balance
+// then the ledger balance is used instead.
+func (b Balance) GetAvailable(currencyCode string) (int64, error) {
+	if b.Available == nil {
+		return b.GetLedger(currencyCode)
+	}
+
+	amount, err := currency.ParseFriendlyToAmount(*b.Available, currencyCode)
+	return amount, errors.Wrap(err, "failed to parse available balance")
+}
+
+// GetLedger returns the ledger balance in the smallest unit of the provided
+// currency. If the institution did not provide a ledger balance then the
+// available balance is used instead.
+func (b Balance) GetLedger(currencyCode string) (int64, error) {
+	if b.Ledger == nil {
+		if b.Available == nil {
+			return 0, nil
+		}
+
+		return b.GetAvailable(currencyCode)
+	}
+
+	amount, err := currency.ParseFriendlyToAmount(*b.Ledger, currencyCode)
+	return amount, errors.Wrap(err, "failed to parse ledger balance")
+}
@@ -0,0 +1,195 @@
+package teller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	Client interface {
+		// GetAccounts returns all of the accounts that are part of the enrollment
+		// for this client's access token.
+		GetAccounts(ctx context.Context) ([]Account, error)
+		// GetAccountBalance retrieves the live balance of the specified account.
+		// This will make a request to the institution itself and may be slow.
+		GetAccountBalance(ctx context.Context, accountId string) (*Balance, error)
+		// GetTransactions returns the transactions for the specified account,
+		// newest first. If fromId is provided then only transactions older than
+		// that transaction are returned, this is used to paginate. A count of 0
+		// will return all of the transactions Teller has for the account.
+		GetTransactions(ctx context.Context, accountId string, fromId *string, count int) ([]Transaction, error)
+		// RemoveEnrollment will delete all of the accounts for the enrollment
+		// from Teller, the access token will no longer be usable afterwards.
+		RemoveEnrollment(ctx context.Context) error
+	}
+)
+
+var (
+	_ Client = &TellerClient{}
+)
+
+type TellerClient struct {
+	accessToken string
+	baseURL     string
+	client      *http.Client
+	log         *logrus.Entry
+}
+
+func (t *TellerClient) GetAccounts(ctx context.Context) ([]Account, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	result := make([]Account, 0)
+	if err := t.do(
+		span,
+		http.MethodGet,
+		"/accounts",
+		nil,
+		&result,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve accounts from Teller")
+	}
+
+	return result, nil
+}
+
+func (t *TellerClient) GetAccountBalance(ctx context.Context, accountId string) (*Balance, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	var result Balance
+	if err := t.do(
+		span,
+		http.MethodGet,
+		fmt.Sprintf("/accounts/%s/balances", url.PathEscape(accountId)),
+		nil,
+		&result,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account balance from Teller")
+	}
+
+	return &result, nil
+}
+
+func (t *TellerClient) GetTransactions(
+	ctx context.Context,
+	accountId string,
+	fromId *string,
+	count int,
+) ([]Transaction, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	query := url.Values{}
+	if fromId != nil {
+		query.Set("from_id", *fromId)
+	}
+	if count > 0 {
+		query.Set("count", strconv.Itoa(count))
+	}
+
+	result := make([]Transaction, 0)
+	if err := t.do(
+		span,
+		http.MethodGet,
+		fmt.Sprintf("/accounts/%s/transactions", url.PathEscape(accountId)),
+		query,
+		&result,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve transactions from Teller")
+	}
+
+	return result, nil
+}
+
+func (t *TellerClient) RemoveEnrollment(ctx context.Context) error {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	if err := t.do(
+		span,
+		http.MethodDelete,
+		"/accounts",
+		nil,
+		nil,
+	); err != nil {
+		return errors.Wrap(err, "failed to remove enrollment from Teller")
+	}
+
+	return nil
+}
+
+// do sends a request to the Teller API authenticated with this client's access
+// token. If the response indicates a failure then the error body is returned
+// as an *Error, otherwise the body is decoded into the result if one is
+// provided.
+func (t *TellerClient) do(
+	span *sentry.Span,
+	method, path string,
+	query url.Values,
+	result interface{},
+) error {
+	requestUrl := t.baseURL + path
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(span.Context(), method, requestUrl, nil)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to build Teller request")
+	}
+	// Teller uses the access token as the username for basic auth, the password
+	// is always blank.
+	request.SetBasicAuth(t.accessToken, "")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		span.Status = sentry.SpanStatusInternalError
+		return errors.Wrap(err, "failed to send Teller request")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		span.Status = sentry.SpanStatusInternalError
+		tellerError := &Error{
+			StatusCode: response.StatusCode,
+		}
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&body); err == nil {
+			tellerError.Code = body.Error.Code
+			tellerError.Message = body.Error.Message
+		}
+		t.log.WithContext(span.Context()).
+			WithError(tellerError).
+			Warn("Teller API call failed")
+		return errors.WithStack(tellerError)
+	}
+
+	span.Status = sentry.SpanStatusOK
+	if result == nil {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil
+	}
+
+	return errors.Wrap(
+		json.NewDecoder(response.Body).Decode(result),
+		"failed to decode Teller response",
+	)
+}
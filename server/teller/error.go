@@ -0,0 +1,39 @@
+package teller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	_ error = &Error{}
+)
+
+// Error is returned when the Teller API responds with an unsuccessful status
+// code. Code and Message are populated from the response body when Teller
+// provides them.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(
+		"teller API call failed with [%d - %s] %s",
+		e.StatusCode, e.Code, e.Message,
+	)
+}
+
+// IsEnrollmentDisconnected returns true if the error indicates that the
+// enrollment can no longer be used until the user reconnects it.
+func IsEnrollmentDisconnected(err error) bool {
+	tellerError, ok := errors.Cause(err).(*Error)
+	if !ok {
+		return false
+	}
+
+	return strings.HasPrefix(tellerError.Code, "enrollment.disconnected")
+}
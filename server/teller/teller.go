@@ -0,0 +1,223 @@
+package teller
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-pg/pg/v10"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/crumbs"
+	"github.com/monetr/monetr/server/models"
+	"github.com/monetr/monetr/server/repository"
+	"github.com/monetr/monetr/server/round"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BaseURL is the same for every Teller environment, the environment is
+	// determined by the access token that is used for a request.
+	BaseURL = "https://api.teller.io"
+	// SignatureHeader contains the signature of webhook requests sent by Teller,
+	// it uses the same `t=<unix timestamp>,v1=<hex hmac>` format that monetr's
+	// own webhooks use.
+	SignatureHeader = "Teller-Signature"
+	// webhookTolerance is how old a webhook is allowed to be before we will
+	// reject it, this prevents a captured webhook from being replayed later.
+	webhookTolerance = 3 * time.Minute
+)
+
+type (
+	Teller interface {
+		// NewClient returns a client for a single enrollment using the provided
+		// access token. The link may be nil when the enrollment has not been
+		// persisted yet, like when the token is first being exchanged.
+		NewClient(ctx context.Context, link *models.Link, accessToken string) (Client, error)
+		// NewClientFromLink will read the access token for the link from the
+		// secrets table and return a client for that enrollment.
+		NewClientFromLink(ctx context.Context, accountId models.ID[models.Account], linkId models.ID[models.Link]) (Client, error)
+		// ParseWebhook verifies the signature of a webhook sent by Teller and then
+		// parses the body. If the signature is not valid then an error is
+		// returned.
+		ParseWebhook(ctx context.Context, signature string, body []byte) (*Webhook, error)
+		Close() error
+	}
+)
+
+var (
+	_ Teller = &TellerAPI{}
+)
+
+type TellerAPI struct {
+	clock  clock.Clock
+	client *http.Client
+	db     pg.DBI
+	log    *logrus.Entry
+	kms    secrets.KeyManagement
+	repo   repository.TellerRepository
+	config config.Teller
+}
+
+func NewTeller(
+	log *logrus.Entry,
+	clock clock.Clock,
+	kms secrets.KeyManagement,
+	db pg.DBI,
+	options config.Teller,
+) (*TellerAPI, error) {
+	transport := http.DefaultTransport
+	// Teller requires mutual TLS for the development and production
+	// environments. If a certificate has been provided then we need our own
+	// transport to present it.
+	if options.Certificate != "" {
+		certificate, err := tls.LoadX509KeyPair(options.Certificate, options.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load Teller client certificate")
+		}
+
+		defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return nil, errors.New("cannot use a Teller client certificate with a non-standard transport")
+		}
+
+		tlsTransport := defaultTransport.Clone()
+		tlsTransport.TLSClientConfig = &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+		}
+		transport = tlsTransport
+	}
+
+	httpClient := &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: round.NewObservabilityRoundTripper(transport,
+			func(
+				ctx context.Context,
+				request *http.Request,
+				response *http.Response,
+				err error,
+			) {
+				requestLog := log.WithContext(ctx).WithFields(logrus.Fields{
+					"teller_method": request.Method,
+					"teller_url":    request.URL.String(),
+				})
+				var statusCode int
+				var requestId string
+				if response != nil {
+					statusCode = response.StatusCode
+					requestId = response.Header.Get("Teller-Request-Id")
+					requestLog = requestLog.WithFields(logrus.Fields{
+						"teller_statusCode": statusCode,
+						"teller_requestId":  requestId,
+					})
+				}
+
+				crumbs.HTTP(ctx,
+					"Teller API Call",
+					"teller",
+					request.URL.String(),
+					request.Method,
+					statusCode,
+					map[string]interface{}{
+						"Request-Id": requestId,
+					},
+				)
+				requestLog.Debug("Teller API call")
+			}),
+	}
+
+	return &TellerAPI{
+		clock:  clock,
+		client: httpClient,
+		db:     db,
+		log:    log,
+		kms:    kms,
+		repo:   repository.NewTellerRepository(db),
+		config: options,
+	}, nil
+}
+
+func (t *TellerAPI) NewClient(ctx context.Context, link *models.Link, accessToken string) (Client, error) {
+	if accessToken == "" {
+		return nil, errors.New("teller access token is required to create a client")
+	}
+
+	log := t.log
+	if link != nil {
+		log = log.WithFields(logrus.Fields{
+			"accountId": link.AccountId,
+			"linkId":    link.LinkId,
+		})
+	}
+
+	return &TellerClient{
+		accessToken: accessToken,
+		baseURL:     BaseURL,
+		client:      t.client,
+		log:         log,
+	}, nil
+}
+
+func (t *TellerAPI) NewClientFromLink(
+	ctx context.Context,
+	accountId models.ID[models.Account],
+	linkId models.ID[models.Link],
+) (Client, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	link, err := t.repo.GetLink(span.Context(), accountId, linkId)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create Teller client from link")
+	}
+
+	if link.TellerLink == nil {
+		return nil, errors.New("cannot create Teller client without a Teller link")
+	}
+
+	secret, err := repository.NewSecretsRepository(
+		t.log,
+		t.clock,
+		t.db,
+		t.kms,
+		accountId,
+	).Read(span.Context(), link.TellerLink.SecretId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve Teller access token")
+	}
+
+	return t.NewClient(span.Context(), link, secret.Value)
+}
+
+func (t *TellerAPI) ParseWebhook(ctx context.Context, signature string, body []byte) (*Webhook, error) {
+	if t.config.WebhookSigningSecret == "" {
+		return nil, errors.New("teller webhook signing secret is not configured")
+	}
+
+	if err := webhooks.Verify(
+		t.config.WebhookSigningSecret,
+		signature,
+		body,
+		t.clock.Now(),
+		webhookTolerance,
+	); err != nil {
+		return nil, err
+	}
+
+	var webhook Webhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, errors.Wrap(err, "failed to parse Teller webhook")
+	}
+
+	return &webhook, nil
+}
+
+func (t *TellerAPI) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
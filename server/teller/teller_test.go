@@ -0,0 +1,156 @@
+package teller_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/jarcoal/httpmock"
+	"github.com/monetr/monetr/server/config"
+	"github.com/monetr/monetr/server/internal/mock_teller"
+	"github.com/monetr/monetr/server/internal/testutils"
+	"github.com/monetr/monetr/server/secrets"
+	"github.com/monetr/monetr/server/teller"
+	"github.com/monetr/monetr/server/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTellerClient_GetAccounts(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		accessToken := gofakeit.UUID()
+		account := mock_teller.AccountFixture(t, "enr_"+gofakeit.Generate("????????"))
+		mock_teller.MockGetAccounts(t, accessToken, []teller.Account{account})
+
+		tellerApi, err := teller.NewTeller(log, clock.New(), secrets.NewPlaintextKMS(), nil, config.Teller{
+			Enabled:       true,
+			ApplicationId: "app_test",
+		})
+		require.NoError(t, err, "must be able to create teller api")
+
+		client, err := tellerApi.NewClient(context.Background(), nil, accessToken)
+		require.NoError(t, err, "must be able to create client")
+
+		accounts, err := client.GetAccounts(context.Background())
+		assert.NoError(t, err, "should retrieve accounts")
+		assert.Len(t, accounts, 1, "should have one account")
+		assert.Equal(t, account.Id, accounts[0].Id, "account Id should match")
+		assert.EqualValues(t, 1, httpmock.GetCallCountInfo()["GET https://api.teller.io/accounts"])
+	})
+
+	t.Run("enrollment disconnected", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		accessToken := gofakeit.UUID()
+		mock_teller.MockEnrollmentDisconnected(t, accessToken)
+
+		tellerApi, err := teller.NewTeller(log, clock.New(), secrets.NewPlaintextKMS(), nil, config.Teller{
+			Enabled:       true,
+			ApplicationId: "app_test",
+		})
+		require.NoError(t, err, "must be able to create teller api")
+
+		client, err := tellerApi.NewClient(context.Background(), nil, accessToken)
+		require.NoError(t, err, "must be able to create client")
+
+		accounts, err := client.GetAccounts(context.Background())
+		assert.Error(t, err, "should return an error")
+		assert.True(t, teller.IsEnrollmentDisconnected(err), "error should be an enrollment disconnected error")
+		assert.Empty(t, accounts)
+	})
+}
+
+func TestTellerClient_GetTransactions(t *testing.T) {
+	t.Run("paginate", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		log := testutils.GetLog(t)
+		accessToken := gofakeit.UUID()
+		account := mock_teller.AccountFixture(t, "enr_"+gofakeit.Generate("????????"))
+		now := time.Now()
+		transactions := []teller.Transaction{
+			mock_teller.TransactionFixture(t, account, now.AddDate(0, 0, -3), false),
+			mock_teller.TransactionFixture(t, account, now.AddDate(0, 0, -2), false),
+			mock_teller.TransactionFixture(t, account, now.AddDate(0, 0, -1), true),
+		}
+		mock_teller.MockGetTransactions(t, accessToken, transactions)
+
+		tellerApi, err := teller.NewTeller(log, clock.New(), secrets.NewPlaintextKMS(), nil, config.Teller{
+			Enabled:       true,
+			ApplicationId: "app_test",
+		})
+		require.NoError(t, err, "must be able to create teller api")
+
+		client, err := tellerApi.NewClient(context.Background(), nil, accessToken)
+		require.NoError(t, err, "must be able to create client")
+
+		firstPage, err := client.GetTransactions(context.Background(), account.Id, nil, 2)
+		assert.NoError(t, err, "should retrieve the first page")
+		require.Len(t, firstPage, 2, "first page should be full")
+		assert.Equal(t, transactions[2].Id, firstPage[0].Id, "newest transaction should be first")
+
+		secondPage, err := client.GetTransactions(context.Background(), account.Id, &firstPage[1].Id, 2)
+		assert.NoError(t, err, "should retrieve the second page")
+		require.Len(t, secondPage, 1, "second page should have the remaining transaction")
+		assert.Equal(t, transactions[0].Id, secondPage[0].Id, "oldest transaction should be last")
+	})
+}
+
+func TestTellerAPI_ParseWebhook(t *testing.T) {
+	log := testutils.GetLog(t)
+	clock := clock.NewMock()
+	clock.Set(time.Date(2024, 03, 10, 12, 0, 0, 0, time.UTC))
+	secret := gofakeit.UUID()
+
+	tellerApi, err := teller.NewTeller(log, clock, secrets.NewPlaintextKMS(), nil, config.Teller{
+		Enabled:              true,
+		ApplicationId:        "app_test",
+		WebhookSigningSecret: secret,
+	})
+	require.NoError(t, err, "must be able to create teller api")
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":        "wh_" + gofakeit.Generate("????????"),
+		"type":      teller.WebhookTypeEnrollmentDisconnected,
+		"timestamp": clock.Now(),
+		"payload": map[string]interface{}{
+			"enrollment_id": "enr_test",
+			"reason":        "disconnected.credentials_invalid",
+		},
+	})
+	require.NoError(t, err, "must be able to encode webhook body")
+
+	t.Run("valid signature", func(t *testing.T) {
+		signature := webhooks.Sign(secret, clock.Now(), body)
+		hook, err := tellerApi.ParseWebhook(context.Background(), signature, body)
+		assert.NoError(t, err, "should parse a webhook with a valid signature")
+		require.NotNil(t, hook)
+		assert.Equal(t, teller.WebhookTypeEnrollmentDisconnected, hook.Type)
+		assert.Equal(t, "enr_test", hook.Payload.EnrollmentId)
+		assert.Equal(t, "disconnected.credentials_invalid", hook.Payload.Reason)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		signature := webhooks.Sign(gofakeit.UUID(), clock.Now(), body)
+		hook, err := tellerApi.ParseWebhook(context.Background(), signature, body)
+		assert.Error(t, err, "should reject a webhook signed with another secret")
+		assert.Nil(t, hook)
+	})
+
+	t.Run("too old", func(t *testing.T) {
+		signature := webhooks.Sign(secret, clock.Now().Add(-10*time.Minute), body)
+		hook, err := tellerApi.ParseWebhook(context.Background(), signature, body)
+		assert.Error(t, err, "should reject a webhook that is outside of the tolerance")
+		assert.Nil(t, hook)
+	})
+}
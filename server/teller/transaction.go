@@ -0,0 +1,99 @@
+package teller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/currency"
+	"github.com/pkg/errors"
+)
+
+type TransactionStatus string
+
+const (
+	TransactionStatusPosted  TransactionStatus = "posted"
+	TransactionStatusPending TransactionStatus = "pending"
+)
+
+type Counterparty struct {
+	Name *string `json:"name"`
+	Type *string `json:"type"`
+}
+
+type TransactionDetails struct {
+	ProcessingStatus string        `json:"processing_status"`
+	Category         *string       `json:"category"`
+	Counterparty     *Counterparty `json:"counterparty"`
+}
+
+type Transaction struct {
+	Id             string             `json:"id"`
+	AccountId      string             `json:"account_id"`
+	Amount         string             `json:"amount"`
+	Date           string             `json:"date"`
+	Description    string             `json:"description"`
+	Details        TransactionDetails `json:"details"`
+	Status         TransactionStatus  `json:"status"`
+	RunningBalance *string            `json:"running_balance"`
+	Type           string             `json:"type"`
+}
+
+// GetAmount returns the amount of the transaction in the smallest unit of the
+// account's currency using monetr's convention; debits are positive and
+// credits are negative. Teller reports depository transactions the other way
+// around, so those amounts are inverted. Credit account amounts are already
+// positive for purchases and are left alone.
+func (t Transaction) GetAmount(account Account) (int64, error) {
+	amount, err := currency.ParseFriendlyToAmount(t.Amount, account.Currency)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse transaction amount")
+	}
+
+	if account.Type == AccountTypeDepository {
+		return -amount, nil
+	}
+
+	return amount, nil
+}
+
+// GetRunningBalance returns the running balance of the account after this
+// transaction if the institution provides it.
+func (t Transaction) GetRunningBalance(account Account) (*int64, error) {
+	if t.RunningBalance == nil {
+		return nil, nil
+	}
+
+	amount, err := currency.ParseFriendlyToAmount(*t.RunningBalance, account.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse transaction running balance")
+	}
+
+	return &amount, nil
+}
+
+// GetDateLocal returns the date of the transaction at midnight in the provided
+// timezone. Teller only provides the date of transactions without a time.
+func (t Transaction) GetDateLocal(timezone *time.Location) (time.Time, error) {
+	date, err := time.ParseInLocation("2006-01-02", t.Date, timezone)
+	return date, errors.Wrap(err, "failed to parse transaction date")
+}
+
+// GetDate returns the date of the transaction at midnight UTC, this is how the
+// date is stored on the Teller transaction itself.
+func (t Transaction) GetDate() (time.Time, error) {
+	return t.GetDateLocal(time.UTC)
+}
+
+func (t Transaction) GetIsPending() bool {
+	return t.Status == TransactionStatusPending
+}
+
+// GetMerchantName returns the name of the counterparty of the transaction if
+// Teller was able to determine one.
+func (t Transaction) GetMerchantName() string {
+	if t.Details.Counterparty == nil || t.Details.Counterparty.Name == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(*t.Details.Counterparty.Name)
+}
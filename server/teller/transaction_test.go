@@ -0,0 +1,84 @@
+package teller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction_GetAmount(t *testing.T) {
+	t.Run("depository debit", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "-12.34",
+		}
+		amount, err := transaction.GetAmount(Account{
+			Type:     AccountTypeDepository,
+			Currency: "USD",
+		})
+		assert.NoError(t, err, "must be able to parse amount")
+		assert.EqualValues(t, 1234, amount, "money leaving a depository account should be positive")
+	})
+
+	t.Run("depository credit", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "100.00",
+		}
+		amount, err := transaction.GetAmount(Account{
+			Type:     AccountTypeDepository,
+			Currency: "USD",
+		})
+		assert.NoError(t, err, "must be able to parse amount")
+		assert.EqualValues(t, -10000, amount, "deposits should be negative")
+	})
+
+	t.Run("credit card purchase", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "5.99",
+		}
+		amount, err := transaction.GetAmount(Account{
+			Type:     AccountTypeCredit,
+			Currency: "USD",
+		})
+		assert.NoError(t, err, "must be able to parse amount")
+		assert.EqualValues(t, 599, amount, "credit card purchases should not be inverted")
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		transaction := Transaction{
+			Amount: "twelve",
+		}
+		_, err := transaction.GetAmount(Account{
+			Type:     AccountTypeDepository,
+			Currency: "USD",
+		})
+		assert.Error(t, err, "should fail to parse an invalid amount")
+	})
+}
+
+func TestTransaction_GetDates(t *testing.T) {
+	transaction := Transaction{
+		Date: "2024-03-08",
+	}
+
+	timezone, err := time.LoadLocation("America/Chicago")
+	assert.NoError(t, err, "must retrieve timezone")
+
+	t.Run("GetDate", func(t *testing.T) {
+		date, err := transaction.GetDate()
+		assert.NoError(t, err)
+		assert.Equal(t,
+			"2024-03-08T00:00:00Z", date.Format(time.RFC3339Nano),
+			"should match value without transforming timezone",
+		)
+	})
+
+	t.Run("GetDateLocal", func(t *testing.T) {
+		date, err := transaction.GetDateLocal(timezone)
+		assert.NoError(t, err)
+		assert.Equal(t,
+			"2024-03-08T00:00:00-06:00", date.Format(time.RFC3339Nano),
+			"should match value when transforming timezone",
+		)
+	})
+}
@@ -0,0 +1,34 @@
+package teller
+
+import "time"
+
+type WebhookType string
+
+const (
+	// WebhookTypeEnrollmentDisconnected is sent when an enrollment can no longer
+	// be used, the user will need to reconnect it with Teller Connect.
+	WebhookTypeEnrollmentDisconnected WebhookType = "enrollment.disconnected"
+	// WebhookTypeTransactionsProcessed is sent when Teller has new or updated
+	// transactions for an enrollment.
+	WebhookTypeTransactionsProcessed WebhookType = "transactions.processed"
+	// WebhookTypeWebhookTest is sent when a test webhook is requested from the
+	// Teller dashboard.
+	WebhookTypeWebhookTest WebhookType = "webhook.test"
+)
+
+type Webhook struct {
+	Id        string         `json:"id"`
+	Type      WebhookType    `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Payload   WebhookPayload `json:"payload"`
+}
+
+type WebhookPayload struct {
+	// EnrollmentId and Reason are provided for enrollment webhooks.
+	EnrollmentId string `json:"enrollment_id"`
+	Reason       string `json:"reason"`
+	// Transactions are provided for transaction webhooks, Teller does not include
+	// the enrollment so the account ID of the transactions is used to find the
+	// link they belong to.
+	Transactions []Transaction `json:"transactions"`
+}
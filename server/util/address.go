@@ -0,0 +1,45 @@
+package util
+
+import (
+	"net/netip"
+)
+
+// reservedPrefixes are ranges that are not covered by the netip helpers but
+// still should never be reached from the public internet.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, can embed any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, can embed any IPv4 address
+}
+
+// IsPublicAddress returns true if the address is a unicast address on the
+// public internet. Loopback, private, link-local (including cloud metadata
+// services like 169.254.169.254) and other reserved addresses return false.
+// This is used to keep user provided urls from reaching internal services.
+func IsPublicAddress(address netip.Addr) bool {
+	address = address.Unmap()
+	switch {
+	case !address.IsValid(),
+		address.IsUnspecified(),
+		address.IsLoopback(),
+		address.IsPrivate(),
+		address.IsLinkLocalUnicast(),
+		address.IsLinkLocalMulticast(),
+		address.IsInterfaceLocalMulticast(),
+		address.IsMulticast():
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(address) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.215.14":        true,
+		"2606:2800:21f:cb07::": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fd00:ec2::254":        false,
+		"fe80::1":              false,
+		"0.0.0.0":              false,
+		"100.64.0.1":           false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:10.0.0.1":      false,
+		"64:ff9b::a00:1":       false,
+		"224.0.0.1":            false,
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, IsPublicAddress(netip.MustParseAddr(input)), "address: %s", input)
+	}
+}
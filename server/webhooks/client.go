@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/monetr/monetr/server/build"
+	"github.com/monetr/monetr/server/crumbs"
+	. "github.com/monetr/monetr/server/models"
+	"github.com/pkg/errors"
+)
+
+// Client delivers signed webhook payloads.
+type Client interface {
+	// Deliver posts the payload to the webhook's url. The HTTP status code of
+	// the response is returned when a response was received, even if the status
+	// indicates a failure.
+	Deliver(
+		ctx context.Context,
+		webhook Webhook,
+		secret string,
+		delivery WebhookDelivery,
+		now time.Time,
+	) (status *int, _ error)
+}
+
+var (
+	_ Client = &clientBase{}
+)
+
+type clientBase struct {
+	client *http.Client
+}
+
+// NewClient returns a webhook client using the provided HTTP client. If the
+// client is nil then one from NewHTTPClient with a 10 second timeout is used.
+func NewClient(client *http.Client) Client {
+	if client == nil {
+		client = NewHTTPClient(10 * time.Second)
+	}
+
+	return &clientBase{
+		client: client,
+	}
+}
+
+func (c *clientBase) Deliver(
+	ctx context.Context,
+	webhook Webhook,
+	secret string,
+	delivery WebhookDelivery,
+	now time.Time,
+) (*int, error) {
+	span := crumbs.StartFnTrace(ctx)
+	defer span.Finish()
+
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode webhook payload")
+	}
+
+	request, err := http.NewRequestWithContext(
+		span.Context(),
+		http.MethodPost,
+		webhook.URL,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create webhook request")
+	}
+	// Webhooks created before https was required may still have an http url.
+	if request.URL.Scheme != "https" {
+		return nil, errors.New("webhook url must be an https url")
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", strings.TrimSpace(fmt.Sprintf("monetr %s", build.Release)))
+	request.Header.Set(SignatureHeader, Sign(secret, now, body))
+	request.Header.Set(EventTypeHeader, string(delivery.EventType))
+	request.Header.Set(DeliveryHeader, delivery.WebhookDeliveryId.String())
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send webhook request")
+	}
+	defer response.Body.Close()
+	// Drain some of the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+
+	status := response.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, errors.Errorf("webhook responded with status %d", status)
+	}
+
+	return &status, nil
+}
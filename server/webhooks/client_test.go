@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/monetr/monetr/server/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Deliver(t *testing.T) {
+	secret := "whsec_test"
+	now := time.Now().UTC()
+	delivery := WebhookDelivery{
+		WebhookDeliveryId: "whdl_test",
+		EventId:           "whev_test",
+		EventType:         WebhookEventSyncCompleted,
+		Payload: WebhookPayload{
+			EventId:   "whev_test",
+			Type:      WebhookEventSyncCompleted,
+			AccountId: "acct_test",
+			CreatedAt: now,
+			Data: SyncCompletedEvent{
+				LinkId:  "link_test",
+				Created: 3,
+			},
+		},
+	}
+
+	t.Run("signed request", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.NoError(t, Verify(secret, r.Header.Get(SignatureHeader), body, time.Now(), time.Minute))
+			assert.Equal(t, "sync.completed", r.Header.Get(EventTypeHeader))
+			assert.Equal(t, "whdl_test", r.Header.Get(DeliveryHeader))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		status, err := NewClient(server.Client()).Deliver(
+			context.Background(),
+			Webhook{URL: server.URL},
+			secret,
+			delivery,
+			now,
+		)
+		assert.NoError(t, err)
+		if assert.NotNil(t, status) {
+			assert.Equal(t, http.StatusOK, *status)
+		}
+	})
+
+	t.Run("http url", func(t *testing.T) {
+		status, err := NewClient(nil).Deliver(
+			context.Background(),
+			Webhook{URL: "http://example.com/monetr"},
+			secret,
+			delivery,
+			now,
+		)
+		assert.EqualError(t, err, "webhook url must be an https url")
+		assert.Nil(t, status)
+	})
+
+	t.Run("private address", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("request should not reach a loopback address")
+		}))
+		defer server.Close()
+
+		status, err := NewClient(nil).Deliver(
+			context.Background(),
+			Webhook{URL: server.URL},
+			secret,
+			delivery,
+			now,
+		)
+		assert.ErrorIs(t, err, ErrAddressNotAllowed)
+		assert.Nil(t, status)
+	})
+
+	t.Run("non-2xx response", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		status, err := NewClient(server.Client()).Deliver(
+			context.Background(),
+			Webhook{URL: server.URL},
+			secret,
+			delivery,
+			now,
+		)
+		assert.EqualError(t, err, "webhook responded with status 502")
+		if assert.NotNil(t, status) {
+			assert.Equal(t, http.StatusBadGateway, *status)
+		}
+	})
+}
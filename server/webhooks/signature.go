@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignatureHeader is the header that contains the signature of the request
+	// body. It is formatted as `t=<unix timestamp>,v1=<hex hmac>`.
+	SignatureHeader = "X-Monetr-Signature"
+	// EventTypeHeader is the header that contains the type of the event, so that
+	// consumers can route requests without parsing the body.
+	EventTypeHeader = "X-Monetr-Event"
+	// DeliveryHeader is the header that contains the ID of the delivery.
+	DeliveryHeader = "X-Monetr-Delivery"
+
+	secretPrefix = "whsec_"
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+	ErrSignatureExpired = errors.New("webhook signature timestamp is outside of the tolerance")
+)
+
+// GenerateSecret returns a new random secret that can be used to sign webhook
+// requests.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "failed to generate webhook secret")
+	}
+
+	return secretPrefix + hex.EncodeToString(secret), nil
+}
+
+// Sign returns the value of the signature header for the provided body. The
+// HMAC-SHA256 is calculated over the timestamp and the body joined by a period,
+// including the timestamp keeps signed requests from being replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", unix, computeSignature(secret, unix, body))
+}
+
+// Verify checks the signature header against the body. The timestamp in the
+// header must be within the tolerance of now. This is what consumers of monetr
+// webhooks are expected to do, it is provided here mostly for tests.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unix int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return errors.WithStack(ErrInvalidSignature)
+			}
+			unix = parsed
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if unix == 0 || len(signatures) == 0 {
+		return errors.WithStack(ErrInvalidSignature)
+	}
+
+	if delta := now.Sub(time.Unix(unix, 0)); delta > tolerance || delta < -tolerance {
+		return errors.WithStack(ErrSignatureExpired)
+	}
+
+	expected := computeSignature(secret, unix, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return errors.WithStack(ErrInvalidSignature)
+}
+
+func computeSignature(secret string, unix int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(unix, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
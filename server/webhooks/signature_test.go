@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	require.NoError(t, err)
+	b, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b, "secrets should be random")
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, a)
+}
+
+func TestSign(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"type":"sync.completed"}`)
+	now := time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC)
+
+	header := Sign(secret, now, body)
+	assert.Regexp(t, `^t=1741867200,v1=[0-9a-f]{64}$`, header)
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, Verify(secret, header, body, now.Add(time.Minute), 5*time.Minute))
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		err := Verify(secret, header, []byte(`{"type":"spending.funded"}`), now, 5*time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		err := Verify("whsec_other", header, body, now, 5*time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		err := Verify(secret, header, body, now.Add(time.Hour), 5*time.Minute)
+		assert.ErrorIs(t, err, ErrSignatureExpired)
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		err := Verify(secret, "v1=abc", body, now, 5*time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+}
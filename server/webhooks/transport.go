@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/monetr/monetr/server/util"
+	"github.com/pkg/errors"
+)
+
+// ErrAddressNotAllowed is returned when a user provided url resolves to an
+// address that is not on the public internet.
+var ErrAddressNotAllowed = errors.New("url resolves to an address that is not allowed")
+
+// NewHTTPClient returns an HTTP client for sending requests to user provided
+// urls. Connections are only made to public addresses. The address is checked
+// when the connection is dialed, after the hostname has been resolved, so a
+// hostname that resolves to a different address later cannot be used to
+// reach an internal service. Proxies from the environment are not used, and
+// redirects must stay on https.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := NewPublicDialer()
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if request.URL.Scheme != "https" {
+				return errors.New("webhook redirected to a url that is not https")
+			}
+			if len(via) >= 5 {
+				return errors.New("webhook redirected too many times")
+			}
+			return nil
+		},
+	}
+}
+
+// NewPublicDialer returns a dialer that will only connect to public addresses.
+// It can be used for other clients that make requests to user provided urls.
+func NewPublicDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   controlPublicAddress,
+	}
+}
+
+// controlPublicAddress is called by the dialer with the resolved address right
+// before the connection is made.
+func controlPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address [%s]", address)
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address [%s]", address)
+	}
+
+	if !util.IsPublicAddress(ip) {
+		return errors.WithStack(ErrAddressNotAllowed)
+	}
+
+	return nil
+}